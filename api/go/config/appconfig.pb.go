@@ -23,6 +23,228 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type AppPriorityClass int32
+
+const (
+	// Waits for resources to be freed
+	AppPriorityClass_APP_PRIORITY_CLASS_NORMAL AppPriorityClass = 0
+	// Can be halted to free memory and CPUs for a critical application
+	// instance, and booted again once there are enough resources
+	AppPriorityClass_APP_PRIORITY_CLASS_BEST_EFFORT AppPriorityClass = 1
+	// Halts best-effort application instances if needed to boot
+	AppPriorityClass_APP_PRIORITY_CLASS_CRITICAL AppPriorityClass = 2
+)
+
+// Enum value maps for AppPriorityClass.
+var (
+	AppPriorityClass_name = map[int32]string{
+		0: "APP_PRIORITY_CLASS_NORMAL",
+		1: "APP_PRIORITY_CLASS_BEST_EFFORT",
+		2: "APP_PRIORITY_CLASS_CRITICAL",
+	}
+	AppPriorityClass_value = map[string]int32{
+		"APP_PRIORITY_CLASS_NORMAL":      0,
+		"APP_PRIORITY_CLASS_BEST_EFFORT": 1,
+		"APP_PRIORITY_CLASS_CRITICAL":    2,
+	}
+)
+
+func (x AppPriorityClass) Enum() *AppPriorityClass {
+	p := new(AppPriorityClass)
+	*p = x
+	return p
+}
+
+func (x AppPriorityClass) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AppPriorityClass) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_appconfig_proto_enumTypes[0].Descriptor()
+}
+
+func (AppPriorityClass) Type() protoreflect.EnumType {
+	return &file_config_appconfig_proto_enumTypes[0]
+}
+
+func (x AppPriorityClass) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AppPriorityClass.Descriptor instead.
+func (AppPriorityClass) EnumDescriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{0}
+}
+
+type AppProbeKind int32
+
+const (
+	AppProbeKind_APP_PROBE_KIND_UNSPECIFIED AppProbeKind = 0
+	// The application instance is unhealthy when the probe fails
+	AppProbeKind_APP_PROBE_KIND_LIVENESS AppProbeKind = 1
+	// The application instance is not ready when the probe fails
+	AppProbeKind_APP_PROBE_KIND_READINESS AppProbeKind = 2
+)
+
+// Enum value maps for AppProbeKind.
+var (
+	AppProbeKind_name = map[int32]string{
+		0: "APP_PROBE_KIND_UNSPECIFIED",
+		1: "APP_PROBE_KIND_LIVENESS",
+		2: "APP_PROBE_KIND_READINESS",
+	}
+	AppProbeKind_value = map[string]int32{
+		"APP_PROBE_KIND_UNSPECIFIED": 0,
+		"APP_PROBE_KIND_LIVENESS":    1,
+		"APP_PROBE_KIND_READINESS":   2,
+	}
+)
+
+func (x AppProbeKind) Enum() *AppProbeKind {
+	p := new(AppProbeKind)
+	*p = x
+	return p
+}
+
+func (x AppProbeKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AppProbeKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_appconfig_proto_enumTypes[1].Descriptor()
+}
+
+func (AppProbeKind) Type() protoreflect.EnumType {
+	return &file_config_appconfig_proto_enumTypes[1]
+}
+
+func (x AppProbeKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AppProbeKind.Descriptor instead.
+func (AppProbeKind) EnumDescriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{1}
+}
+
+type AppProbeType int32
+
+const (
+	AppProbeType_APP_PROBE_TYPE_UNSPECIFIED AppProbeType = 0
+	// HTTP GET of httpPath on port, expecting a 2xx or 3xx response
+	AppProbeType_APP_PROBE_TYPE_HTTP AppProbeType = 1
+	// TCP connect to port
+	AppProbeType_APP_PROBE_TYPE_TCP AppProbeType = 2
+	// Run command in the container, expecting a zero exit status.
+	// Only for containers which are not run in a VM.
+	AppProbeType_APP_PROBE_TYPE_EXEC AppProbeType = 3
+	// Ping the QEMU guest agent in the VM. Only for kvm.
+	AppProbeType_APP_PROBE_TYPE_GUEST_AGENT AppProbeType = 4
+)
+
+// Enum value maps for AppProbeType.
+var (
+	AppProbeType_name = map[int32]string{
+		0: "APP_PROBE_TYPE_UNSPECIFIED",
+		1: "APP_PROBE_TYPE_HTTP",
+		2: "APP_PROBE_TYPE_TCP",
+		3: "APP_PROBE_TYPE_EXEC",
+		4: "APP_PROBE_TYPE_GUEST_AGENT",
+	}
+	AppProbeType_value = map[string]int32{
+		"APP_PROBE_TYPE_UNSPECIFIED": 0,
+		"APP_PROBE_TYPE_HTTP":        1,
+		"APP_PROBE_TYPE_TCP":         2,
+		"APP_PROBE_TYPE_EXEC":        3,
+		"APP_PROBE_TYPE_GUEST_AGENT": 4,
+	}
+)
+
+func (x AppProbeType) Enum() *AppProbeType {
+	p := new(AppProbeType)
+	*p = x
+	return p
+}
+
+func (x AppProbeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AppProbeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_appconfig_proto_enumTypes[2].Descriptor()
+}
+
+func (AppProbeType) Type() protoreflect.EnumType {
+	return &file_config_appconfig_proto_enumTypes[2]
+}
+
+func (x AppProbeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AppProbeType.Descriptor instead.
+func (AppProbeType) EnumDescriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{2}
+}
+
+type AppRestartPolicy int32
+
+const (
+	// Same as APP_RESTART_POLICY_NEVER
+	AppRestartPolicy_APP_RESTART_POLICY_UNSPECIFIED AppRestartPolicy = 0
+	// Restart when a liveness probe fails or the domain stops for any
+	// reason, including a shutdown from inside the guest
+	AppRestartPolicy_APP_RESTART_POLICY_ALWAYS AppRestartPolicy = 1
+	// Restart when a liveness probe fails or the domain crashes, with an
+	// exponential backoff between consecutive restarts
+	AppRestartPolicy_APP_RESTART_POLICY_ON_FAILURE AppRestartPolicy = 2
+	// Only report the health state
+	AppRestartPolicy_APP_RESTART_POLICY_NEVER AppRestartPolicy = 3
+)
+
+// Enum value maps for AppRestartPolicy.
+var (
+	AppRestartPolicy_name = map[int32]string{
+		0: "APP_RESTART_POLICY_UNSPECIFIED",
+		1: "APP_RESTART_POLICY_ALWAYS",
+		2: "APP_RESTART_POLICY_ON_FAILURE",
+		3: "APP_RESTART_POLICY_NEVER",
+	}
+	AppRestartPolicy_value = map[string]int32{
+		"APP_RESTART_POLICY_UNSPECIFIED": 0,
+		"APP_RESTART_POLICY_ALWAYS":      1,
+		"APP_RESTART_POLICY_ON_FAILURE":  2,
+		"APP_RESTART_POLICY_NEVER":       3,
+	}
+)
+
+func (x AppRestartPolicy) Enum() *AppRestartPolicy {
+	p := new(AppRestartPolicy)
+	*p = x
+	return p
+}
+
+func (x AppRestartPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AppRestartPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_appconfig_proto_enumTypes[3].Descriptor()
+}
+
+func (AppRestartPolicy) Type() protoreflect.EnumType {
+	return &file_config_appconfig_proto_enumTypes[3]
+}
+
+func (x AppRestartPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AppRestartPolicy.Descriptor instead.
+func (AppRestartPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{3}
+}
+
 type InstanceOpsCmd struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -151,6 +373,38 @@ type AppInstanceConfig struct {
 	// Note that since the name volumeRef was used before and deprecated
 	// python protobuf seems to require that we use a different name.
 	VolumeRefList []*VolumeRef `protobuf:"bytes,16,rep,name=volumeRefList,proto3" json:"volumeRefList,omitempty"`
+	// Health probes for the application instance. A failing liveness
+	// probe makes EVE apply the restartPolicy; readiness probes only
+	// affect the health state reported in ZInfoApp.
+	Probes []*AppProbe `protobuf:"bytes,17,rep,name=probes,proto3" json:"probes,omitempty"`
+	// What EVE does when the application instance fails a liveness probe
+	// or its domain stops.
+	RestartPolicy AppRestartPolicy `protobuf:"varint,18,opt,name=restartPolicy,proto3,enum=org.lfedge.eve.config.AppRestartPolicy" json:"restartPolicy,omitempty"`
+	// UUIDs of the application instances this one depends on. EVE does
+	// not boot this application instance until all of them are running,
+	// and healthy if they have probes. When halting they are halted in
+	// the reverse order. Cyclic dependencies are reported as an error.
+	DependsOn []string `protobuf:"bytes,19,rep,name=dependsOn,proto3" json:"dependsOn,omitempty"`
+	// Used when there are not enough resources to boot all the
+	// application instances.
+	Priority AppPriorityClass `protobuf:"varint,20,opt,name=priority,proto3,enum=org.lfedge.eve.config.AppPriorityClass" json:"priority,omitempty"`
+	// Individual USB devices to pass through to the application instance
+	// when they are plugged in, including while it is running. Only for
+	// kvm; the USB controllers must not be assigned with usbAccess off.
+	UsbDevices []*UsbDeviceRule `protobuf:"bytes,21,rep,name=usbDevices,proto3" json:"usbDevices,omitempty"`
+	// Additional containers run with the main container, which is the
+	// first volume in volumeRefList, e.g., sidecars and init containers.
+	// They share the network adapters and the volumes of the application
+	// instance. Only for containers which are not run in a VM.
+	Containers []*AppContainer `protobuf:"bytes,22,rep,name=containers,proto3" json:"containers,omitempty"`
+	// Used as "vendor-data" in cloud-init, base64-encoded like userData.
+	// Only for VMs.
+	VendorData string `protobuf:"bytes,23,opt,name=vendorData,proto3" json:"vendorData,omitempty"`
+	// Describe the application instance in the cloud-init meta-data using
+	// its display name as hostname, and add a network-config with the
+	// network adapters, instead of using the UUID as hostname without
+	// network-config. Only for VMs.
+	CloudInitNoCloud bool `protobuf:"varint,24,opt,name=cloudInitNoCloud,proto3" json:"cloudInitNoCloud,omitempty"`
 }
 
 func (x *AppInstanceConfig) Reset() {
@@ -283,6 +537,368 @@ func (x *AppInstanceConfig) GetVolumeRefList() []*VolumeRef {
 	return nil
 }
 
+func (x *AppInstanceConfig) GetProbes() []*AppProbe {
+	if x != nil {
+		return x.Probes
+	}
+	return nil
+}
+
+func (x *AppInstanceConfig) GetRestartPolicy() AppRestartPolicy {
+	if x != nil {
+		return x.RestartPolicy
+	}
+	return AppRestartPolicy_APP_RESTART_POLICY_UNSPECIFIED
+}
+
+func (x *AppInstanceConfig) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *AppInstanceConfig) GetPriority() AppPriorityClass {
+	if x != nil {
+		return x.Priority
+	}
+	return AppPriorityClass_APP_PRIORITY_CLASS_NORMAL
+}
+
+func (x *AppInstanceConfig) GetUsbDevices() []*UsbDeviceRule {
+	if x != nil {
+		return x.UsbDevices
+	}
+	return nil
+}
+
+func (x *AppInstanceConfig) GetContainers() []*AppContainer {
+	if x != nil {
+		return x.Containers
+	}
+	return nil
+}
+
+func (x *AppInstanceConfig) GetVendorData() string {
+	if x != nil {
+		return x.VendorData
+	}
+	return ""
+}
+
+func (x *AppInstanceConfig) GetCloudInitNoCloud() bool {
+	if x != nil {
+		return x.CloudInitNoCloud
+	}
+	return false
+}
+
+type AppContainer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name - unique in the application instance; lowercase letters,
+	// digits and '-'
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// image - UUID of the CONTAINER volume in volumeRefList with the image
+	// of the container. It can not be the first volume, and it is not
+	// mounted in the other containers.
+	Image string `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	// init - run to completion, in order, before the main container and
+	// the sidecars start. The application instance fails to boot if it
+	// exits with a non-zero status.
+	Init bool `protobuf:"varint,3,opt,name=init,proto3" json:"init,omitempty"`
+	// command - overrides the entrypoint and the command of the image
+	Command []string `protobuf:"bytes,4,rep,name=command,proto3" json:"command,omitempty"`
+	// environment - "NAME=value", in addition to the ones of the image
+	Environment []string `protobuf:"bytes,5,rep,name=environment,proto3" json:"environment,omitempty"`
+}
+
+func (x *AppContainer) Reset() {
+	*x = AppContainer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_appconfig_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppContainer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppContainer) ProtoMessage() {}
+
+func (x *AppContainer) ProtoReflect() protoreflect.Message {
+	mi := &file_config_appconfig_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppContainer.ProtoReflect.Descriptor instead.
+func (*AppContainer) Descriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AppContainer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AppContainer) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *AppContainer) GetInit() bool {
+	if x != nil {
+		return x.Init
+	}
+	return false
+}
+
+func (x *AppContainer) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *AppContainer) GetEnvironment() []string {
+	if x != nil {
+		return x.Environment
+	}
+	return nil
+}
+
+// A USB device is passed through if it matches all the non-empty fields
+// of a rule. At least one field must be set.
+type UsbDeviceRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// vendorId and productId - hexadecimal, e.g., "0781" and "5581"
+	VendorId  string `protobuf:"bytes,1,opt,name=vendorId,proto3" json:"vendorId,omitempty"`
+	ProductId string `protobuf:"bytes,2,opt,name=productId,proto3" json:"productId,omitempty"`
+	// serial - the iSerial string of the device
+	Serial string `protobuf:"bytes,3,opt,name=serial,proto3" json:"serial,omitempty"`
+	// portPath - the bus and the ports from the root hub, e.g., "1:2.3"
+	// for port 3 of the hub on port 2 of bus 1
+	PortPath string `protobuf:"bytes,4,opt,name=portPath,proto3" json:"portPath,omitempty"`
+}
+
+func (x *UsbDeviceRule) Reset() {
+	*x = UsbDeviceRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_appconfig_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UsbDeviceRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsbDeviceRule) ProtoMessage() {}
+
+func (x *UsbDeviceRule) ProtoReflect() protoreflect.Message {
+	mi := &file_config_appconfig_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsbDeviceRule.ProtoReflect.Descriptor instead.
+func (*UsbDeviceRule) Descriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UsbDeviceRule) GetVendorId() string {
+	if x != nil {
+		return x.VendorId
+	}
+	return ""
+}
+
+func (x *UsbDeviceRule) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *UsbDeviceRule) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *UsbDeviceRule) GetPortPath() string {
+	if x != nil {
+		return x.PortPath
+	}
+	return ""
+}
+
+// A health probe. HTTP and TCP probes reach the application instance
+// from the device through the network instance of the network adapter.
+type AppProbe struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind AppProbeKind `protobuf:"varint,1,opt,name=kind,proto3,enum=org.lfedge.eve.config.AppProbeKind" json:"kind,omitempty"`
+	Type AppProbeType `protobuf:"varint,2,opt,name=type,proto3,enum=org.lfedge.eve.config.AppProbeType" json:"type,omitempty"`
+	// networkAdapter - the name of the NetworkAdapter for HTTP and TCP
+	// probes. Defaults to the first one with an IP address.
+	NetworkAdapter string `protobuf:"bytes,3,opt,name=networkAdapter,proto3" json:"networkAdapter,omitempty"`
+	Port           uint32 `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	// httpPath - defaults to "/"
+	HttpPath string `protobuf:"bytes,5,opt,name=httpPath,proto3" json:"httpPath,omitempty"`
+	// command - for APP_PROBE_TYPE_EXEC
+	Command []string `protobuf:"bytes,6,rep,name=command,proto3" json:"command,omitempty"`
+	// initialDelay - seconds after boot before the first probe
+	InitialDelay uint32 `protobuf:"varint,7,opt,name=initialDelay,proto3" json:"initialDelay,omitempty"`
+	// period - seconds between probes. Defaults to 10
+	Period uint32 `protobuf:"varint,8,opt,name=period,proto3" json:"period,omitempty"`
+	// timeout - seconds to wait for the probe to succeed. Defaults to 5
+	Timeout uint32 `protobuf:"varint,9,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// failureThreshold - consecutive failed probes before the probe is
+	// considered failing. Defaults to 3
+	FailureThreshold uint32 `protobuf:"varint,10,opt,name=failureThreshold,proto3" json:"failureThreshold,omitempty"`
+	// successThreshold - consecutive successful probes before a failing
+	// probe is considered passing again. Defaults to 1
+	SuccessThreshold uint32 `protobuf:"varint,11,opt,name=successThreshold,proto3" json:"successThreshold,omitempty"`
+}
+
+func (x *AppProbe) Reset() {
+	*x = AppProbe{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_appconfig_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppProbe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppProbe) ProtoMessage() {}
+
+func (x *AppProbe) ProtoReflect() protoreflect.Message {
+	mi := &file_config_appconfig_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppProbe.ProtoReflect.Descriptor instead.
+func (*AppProbe) Descriptor() ([]byte, []int) {
+	return file_config_appconfig_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AppProbe) GetKind() AppProbeKind {
+	if x != nil {
+		return x.Kind
+	}
+	return AppProbeKind_APP_PROBE_KIND_UNSPECIFIED
+}
+
+func (x *AppProbe) GetType() AppProbeType {
+	if x != nil {
+		return x.Type
+	}
+	return AppProbeType_APP_PROBE_TYPE_UNSPECIFIED
+}
+
+func (x *AppProbe) GetNetworkAdapter() string {
+	if x != nil {
+		return x.NetworkAdapter
+	}
+	return ""
+}
+
+func (x *AppProbe) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *AppProbe) GetHttpPath() string {
+	if x != nil {
+		return x.HttpPath
+	}
+	return ""
+}
+
+func (x *AppProbe) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *AppProbe) GetInitialDelay() uint32 {
+	if x != nil {
+		return x.InitialDelay
+	}
+	return 0
+}
+
+func (x *AppProbe) GetPeriod() uint32 {
+	if x != nil {
+		return x.Period
+	}
+	return 0
+}
+
+func (x *AppProbe) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *AppProbe) GetFailureThreshold() uint32 {
+	if x != nil {
+		return x.FailureThreshold
+	}
+	return 0
+}
+
+func (x *AppProbe) GetSuccessThreshold() uint32 {
+	if x != nil {
+		return x.SuccessThreshold
+	}
+	return 0
+}
+
 // Reference to a Volume specified separately in the API
 // If a volume is purged (re-created from scratch) it will either have a new
 // UUID or a new generationCount
@@ -301,7 +917,7 @@ type VolumeRef struct {
 func (x *VolumeRef) Reset() {
 	*x = VolumeRef{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_appconfig_proto_msgTypes[2]
+		mi := &file_config_appconfig_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -314,7 +930,7 @@ func (x *VolumeRef) String() string {
 func (*VolumeRef) ProtoMessage() {}
 
 func (x *VolumeRef) ProtoReflect() protoreflect.Message {
-	mi := &file_config_appconfig_proto_msgTypes[2]
+	mi := &file_config_appconfig_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -327,7 +943,7 @@ func (x *VolumeRef) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VolumeRef.ProtoReflect.Descriptor instead.
 func (*VolumeRef) Descriptor() ([]byte, []int) {
-	return file_config_appconfig_proto_rawDescGZIP(), []int{2}
+	return file_config_appconfig_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *VolumeRef) GetUuid() string {
@@ -368,7 +984,7 @@ var file_config_appconfig_proto_rawDesc = []byte{
 	0x6d, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20,
 	0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07,
 	0x6f, 0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6f,
-	0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x9e, 0x06, 0x0a, 0x11, 0x41, 0x70, 0x70, 0x49, 0x6e,
+	0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x22, 0xe0, 0x09, 0x0a, 0x11, 0x41, 0x70, 0x70, 0x49, 0x6e,
 	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x4d, 0x0a, 0x0e,
 	0x75, 0x75, 0x69, 0x64, 0x61, 0x6e, 0x64, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67,
@@ -418,18 +1034,122 @@ var file_config_appconfig_proto_rawDesc = []byte{
 	0x18, 0x10, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65,
 	0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x56,
 	0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x52, 0x65, 0x66, 0x52, 0x0d, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65,
-	0x52, 0x65, 0x66, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x66, 0x0a, 0x09, 0x56, 0x6f, 0x6c, 0x75, 0x6d,
-	0x65, 0x52, 0x65, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x67, 0x65, 0x6e, 0x65,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x0f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x75,
-	0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x44, 0x69, 0x72, 0x42,
-	0x3d, 0x0a, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76,
-	0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5a, 0x24, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x66, 0x2d, 0x65, 0x64, 0x67, 0x65, 0x2f, 0x65, 0x76, 0x65,
-	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x65, 0x66, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x70, 0x72, 0x6f, 0x62, 0x65,
+	0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66,
+	0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x62, 0x65, 0x73,
+	0x12, 0x4d, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x27, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66,
+	0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x41, 0x70, 0x70, 0x52, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x52, 0x0d, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x1c, 0x0a, 0x09, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x73, 0x4f, 0x6e, 0x18, 0x13, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x73, 0x4f, 0x6e, 0x12, 0x43, 0x0a,
+	0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x27, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65,
+	0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x41, 0x70, 0x70, 0x50, 0x72, 0x69, 0x6f, 0x72,
+	0x69, 0x74, 0x79, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69,
+	0x74, 0x79, 0x12, 0x44, 0x0a, 0x0a, 0x75, 0x73, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73,
+	0x18, 0x15, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65,
+	0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x55,
+	0x73, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x0a, 0x75, 0x73,
+	0x62, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x43, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74,
+	0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x6f,
+	0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65,
+	0x72, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x44, 0x61, 0x74, 0x61, 0x18, 0x17, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x44, 0x61, 0x74, 0x61, 0x12, 0x2a, 0x0a,
+	0x10, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x6e, 0x69, 0x74, 0x4e, 0x6f, 0x43, 0x6c, 0x6f, 0x75,
+	0x64, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x6e,
+	0x69, 0x74, 0x4e, 0x6f, 0x43, 0x6c, 0x6f, 0x75, 0x64, 0x22, 0x88, 0x01, 0x0a, 0x0c, 0x41, 0x70,
+	0x70, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x6e, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x04, 0x69, 0x6e, 0x69, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x22, 0x7d, 0x0a, 0x0d, 0x55, 0x73, 0x62, 0x44, 0x65, 0x76, 0x69, 0x63,
+	0x65, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x76, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x49,
+	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x72, 0x74, 0x50,
+	0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6f, 0x72, 0x74, 0x50,
+	0x61, 0x74, 0x68, 0x22, 0x9c, 0x03, 0x0a, 0x08, 0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65,
+	0x12, 0x37, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23,
+	0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x4b,
+	0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x37, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66,
+	0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x41, 0x64, 0x61,
+	0x70, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x41, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f,
+	0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x68, 0x74, 0x74, 0x70, 0x50, 0x61, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x68, 0x74, 0x74, 0x70, 0x50, 0x61, 0x74, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x44,
+	0x65, 0x6c, 0x61, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x69, 0x6e, 0x69, 0x74,
+	0x69, 0x61, 0x6c, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x65, 0x72, 0x69,
+	0x6f, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x66, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x54, 0x68, 0x72,
+	0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x10, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f,
+	0x6c, 0x64, 0x22, 0x66, 0x0a, 0x09, 0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x52, 0x65, 0x66, 0x12,
+	0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75,
+	0x75, 0x69, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x67, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1b, 0x0a,
+	0x09, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x44, 0x69, 0x72, 0x2a, 0x76, 0x0a, 0x10, 0x41, 0x70,
+	0x70, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x1d,
+	0x0a, 0x19, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x43,
+	0x4c, 0x41, 0x53, 0x53, 0x5f, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x10, 0x00, 0x12, 0x22, 0x0a,
+	0x1e, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x43, 0x4c,
+	0x41, 0x53, 0x53, 0x5f, 0x42, 0x45, 0x53, 0x54, 0x5f, 0x45, 0x46, 0x46, 0x4f, 0x52, 0x54, 0x10,
+	0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54,
+	0x59, 0x5f, 0x43, 0x4c, 0x41, 0x53, 0x53, 0x5f, 0x43, 0x52, 0x49, 0x54, 0x49, 0x43, 0x41, 0x4c,
+	0x10, 0x02, 0x2a, 0x69, 0x0a, 0x0c, 0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x4b, 0x69,
+	0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x1a, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f,
+	0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f,
+	0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x4c, 0x49, 0x56, 0x45, 0x4e, 0x45, 0x53, 0x53, 0x10, 0x01, 0x12,
+	0x1c, 0x0a, 0x18, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x4b, 0x49, 0x4e,
+	0x44, 0x5f, 0x52, 0x45, 0x41, 0x44, 0x49, 0x4e, 0x45, 0x53, 0x53, 0x10, 0x02, 0x2a, 0x98, 0x01,
+	0x0a, 0x0c, 0x41, 0x70, 0x70, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e,
+	0x0a, 0x1a, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17,
+	0x0a, 0x13, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x48, 0x54, 0x54, 0x50, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x41, 0x50, 0x50, 0x5f, 0x50,
+	0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54, 0x43, 0x50, 0x10, 0x02, 0x12,
+	0x17, 0x0a, 0x13, 0x41, 0x50, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x45, 0x58, 0x45, 0x43, 0x10, 0x03, 0x12, 0x1e, 0x0a, 0x1a, 0x41, 0x50, 0x50, 0x5f,
+	0x50, 0x52, 0x4f, 0x42, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x55, 0x45, 0x53, 0x54,
+	0x5f, 0x41, 0x47, 0x45, 0x4e, 0x54, 0x10, 0x04, 0x2a, 0x96, 0x01, 0x0a, 0x10, 0x41, 0x70, 0x70,
+	0x52, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x22, 0x0a,
+	0x1e, 0x41, 0x50, 0x50, 0x5f, 0x52, 0x45, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f, 0x50, 0x4f, 0x4c,
+	0x49, 0x43, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x1d, 0x0a, 0x19, 0x41, 0x50, 0x50, 0x5f, 0x52, 0x45, 0x53, 0x54, 0x41, 0x52, 0x54,
+	0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x41, 0x4c, 0x57, 0x41, 0x59, 0x53, 0x10, 0x01,
+	0x12, 0x21, 0x0a, 0x1d, 0x41, 0x50, 0x50, 0x5f, 0x52, 0x45, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f,
+	0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x4f, 0x4e, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x55, 0x52,
+	0x45, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x41, 0x50, 0x50, 0x5f, 0x52, 0x45, 0x53, 0x54, 0x41,
+	0x52, 0x54, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x4e, 0x45, 0x56, 0x45, 0x52, 0x10,
+	0x03, 0x42, 0x3d, 0x0a, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e,
+	0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5a, 0x24, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x66, 0x2d, 0x65, 0x64, 0x67, 0x65, 0x2f, 0x65,
+	0x76, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -444,33 +1164,48 @@ func file_config_appconfig_proto_rawDescGZIP() []byte {
 	return file_config_appconfig_proto_rawDescData
 }
 
-var file_config_appconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_config_appconfig_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_config_appconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_config_appconfig_proto_goTypes = []interface{}{
-	(*InstanceOpsCmd)(nil),    // 0: org.lfedge.eve.config.InstanceOpsCmd
-	(*AppInstanceConfig)(nil), // 1: org.lfedge.eve.config.AppInstanceConfig
-	(*VolumeRef)(nil),         // 2: org.lfedge.eve.config.VolumeRef
-	(*UUIDandVersion)(nil),    // 3: org.lfedge.eve.config.UUIDandVersion
-	(*VmConfig)(nil),          // 4: org.lfedge.eve.config.VmConfig
-	(*Drive)(nil),             // 5: org.lfedge.eve.config.Drive
-	(*NetworkAdapter)(nil),    // 6: org.lfedge.eve.config.NetworkAdapter
-	(*Adapter)(nil),           // 7: org.lfedge.eve.config.Adapter
-	(*CipherBlock)(nil),       // 8: org.lfedge.eve.config.CipherBlock
+	(AppPriorityClass)(0),     // 0: org.lfedge.eve.config.AppPriorityClass
+	(AppProbeKind)(0),         // 1: org.lfedge.eve.config.AppProbeKind
+	(AppProbeType)(0),         // 2: org.lfedge.eve.config.AppProbeType
+	(AppRestartPolicy)(0),     // 3: org.lfedge.eve.config.AppRestartPolicy
+	(*InstanceOpsCmd)(nil),    // 4: org.lfedge.eve.config.InstanceOpsCmd
+	(*AppInstanceConfig)(nil), // 5: org.lfedge.eve.config.AppInstanceConfig
+	(*AppContainer)(nil),      // 6: org.lfedge.eve.config.AppContainer
+	(*UsbDeviceRule)(nil),     // 7: org.lfedge.eve.config.UsbDeviceRule
+	(*AppProbe)(nil),          // 8: org.lfedge.eve.config.AppProbe
+	(*VolumeRef)(nil),         // 9: org.lfedge.eve.config.VolumeRef
+	(*UUIDandVersion)(nil),    // 10: org.lfedge.eve.config.UUIDandVersion
+	(*VmConfig)(nil),          // 11: org.lfedge.eve.config.VmConfig
+	(*Drive)(nil),             // 12: org.lfedge.eve.config.Drive
+	(*NetworkAdapter)(nil),    // 13: org.lfedge.eve.config.NetworkAdapter
+	(*Adapter)(nil),           // 14: org.lfedge.eve.config.Adapter
+	(*CipherBlock)(nil),       // 15: org.lfedge.eve.config.CipherBlock
 }
 var file_config_appconfig_proto_depIdxs = []int32{
-	3, // 0: org.lfedge.eve.config.AppInstanceConfig.uuidandversion:type_name -> org.lfedge.eve.config.UUIDandVersion
-	4, // 1: org.lfedge.eve.config.AppInstanceConfig.fixedresources:type_name -> org.lfedge.eve.config.VmConfig
-	5, // 2: org.lfedge.eve.config.AppInstanceConfig.drives:type_name -> org.lfedge.eve.config.Drive
-	6, // 3: org.lfedge.eve.config.AppInstanceConfig.interfaces:type_name -> org.lfedge.eve.config.NetworkAdapter
-	7, // 4: org.lfedge.eve.config.AppInstanceConfig.adapters:type_name -> org.lfedge.eve.config.Adapter
-	0, // 5: org.lfedge.eve.config.AppInstanceConfig.restart:type_name -> org.lfedge.eve.config.InstanceOpsCmd
-	0, // 6: org.lfedge.eve.config.AppInstanceConfig.purge:type_name -> org.lfedge.eve.config.InstanceOpsCmd
-	8, // 7: org.lfedge.eve.config.AppInstanceConfig.cipherData:type_name -> org.lfedge.eve.config.CipherBlock
-	2, // 8: org.lfedge.eve.config.AppInstanceConfig.volumeRefList:type_name -> org.lfedge.eve.config.VolumeRef
-	9, // [9:9] is the sub-list for method output_type
-	9, // [9:9] is the sub-list for method input_type
-	9, // [9:9] is the sub-list for extension type_name
-	9, // [9:9] is the sub-list for extension extendee
-	0, // [0:9] is the sub-list for field type_name
+	10, // 0: org.lfedge.eve.config.AppInstanceConfig.uuidandversion:type_name -> org.lfedge.eve.config.UUIDandVersion
+	11, // 1: org.lfedge.eve.config.AppInstanceConfig.fixedresources:type_name -> org.lfedge.eve.config.VmConfig
+	12, // 2: org.lfedge.eve.config.AppInstanceConfig.drives:type_name -> org.lfedge.eve.config.Drive
+	13, // 3: org.lfedge.eve.config.AppInstanceConfig.interfaces:type_name -> org.lfedge.eve.config.NetworkAdapter
+	14, // 4: org.lfedge.eve.config.AppInstanceConfig.adapters:type_name -> org.lfedge.eve.config.Adapter
+	4,  // 5: org.lfedge.eve.config.AppInstanceConfig.restart:type_name -> org.lfedge.eve.config.InstanceOpsCmd
+	4,  // 6: org.lfedge.eve.config.AppInstanceConfig.purge:type_name -> org.lfedge.eve.config.InstanceOpsCmd
+	15, // 7: org.lfedge.eve.config.AppInstanceConfig.cipherData:type_name -> org.lfedge.eve.config.CipherBlock
+	9,  // 8: org.lfedge.eve.config.AppInstanceConfig.volumeRefList:type_name -> org.lfedge.eve.config.VolumeRef
+	8,  // 9: org.lfedge.eve.config.AppInstanceConfig.probes:type_name -> org.lfedge.eve.config.AppProbe
+	3,  // 10: org.lfedge.eve.config.AppInstanceConfig.restartPolicy:type_name -> org.lfedge.eve.config.AppRestartPolicy
+	0,  // 11: org.lfedge.eve.config.AppInstanceConfig.priority:type_name -> org.lfedge.eve.config.AppPriorityClass
+	7,  // 12: org.lfedge.eve.config.AppInstanceConfig.usbDevices:type_name -> org.lfedge.eve.config.UsbDeviceRule
+	6,  // 13: org.lfedge.eve.config.AppInstanceConfig.containers:type_name -> org.lfedge.eve.config.AppContainer
+	1,  // 14: org.lfedge.eve.config.AppProbe.kind:type_name -> org.lfedge.eve.config.AppProbeKind
+	2,  // 15: org.lfedge.eve.config.AppProbe.type:type_name -> org.lfedge.eve.config.AppProbeType
+	16, // [16:16] is the sub-list for method output_type
+	16, // [16:16] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_config_appconfig_proto_init() }
@@ -509,6 +1244,42 @@ func file_config_appconfig_proto_init() {
 			}
 		}
 		file_config_appconfig_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppContainer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_appconfig_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UsbDeviceRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_appconfig_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppProbe); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_appconfig_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VolumeRef); i {
 			case 0:
 				return &v.state
@@ -526,13 +1297,14 @@ func file_config_appconfig_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_config_appconfig_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_config_appconfig_proto_goTypes,
 		DependencyIndexes: file_config_appconfig_proto_depIdxs,
+		EnumInfos:         file_config_appconfig_proto_enumTypes,
 		MessageInfos:      file_config_appconfig_proto_msgTypes,
 	}.Build()
 	File_config_appconfig_proto = out.File
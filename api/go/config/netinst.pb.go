@@ -243,6 +243,211 @@ func (ZcServiceType) EnumDescriptor() ([]byte, []int) {
 	return file_config_netinst_proto_rawDescGZIP(), []int{3}
 }
 
+// How application instances on an IPv6 local network instance get their
+// addresses
+type Ipv6AddressingMode int32
+
+const (
+	// Stateful DHCPv6 from the dhcpRange in the ipspec
+	Ipv6AddressingMode_IPV6_ADDRESSING_DHCPV6 Ipv6AddressingMode = 0
+	// SLAAC using the prefix in the router advertisements. The subnet must
+	// be a /64
+	Ipv6AddressingMode_IPV6_ADDRESSING_SLAAC Ipv6AddressingMode = 1
+)
+
+// Enum value maps for Ipv6AddressingMode.
+var (
+	Ipv6AddressingMode_name = map[int32]string{
+		0: "IPV6_ADDRESSING_DHCPV6",
+		1: "IPV6_ADDRESSING_SLAAC",
+	}
+	Ipv6AddressingMode_value = map[string]int32{
+		"IPV6_ADDRESSING_DHCPV6": 0,
+		"IPV6_ADDRESSING_SLAAC":  1,
+	}
+)
+
+func (x Ipv6AddressingMode) Enum() *Ipv6AddressingMode {
+	p := new(Ipv6AddressingMode)
+	*p = x
+	return p
+}
+
+func (x Ipv6AddressingMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Ipv6AddressingMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_netinst_proto_enumTypes[4].Descriptor()
+}
+
+func (Ipv6AddressingMode) Type() protoreflect.EnumType {
+	return &file_config_netinst_proto_enumTypes[4]
+}
+
+func (x Ipv6AddressingMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Ipv6AddressingMode.Descriptor instead.
+func (Ipv6AddressingMode) EnumDescriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{4}
+}
+
+// How an IPv6 local network instance is connected to its port
+type Ipv6UplinkMode int32
+
+const (
+	// Masquerade the network instance subnet behind the address of the port
+	Ipv6UplinkMode_IPV6_UPLINK_NAT66 Ipv6UplinkMode = 0
+	// Route a /64 out of the prefix delegated over DHCPv6 on the port.
+	// The subnet, gateway and dhcpRange in the ipspec are replaced with
+	// addresses in that /64 keeping their interface identifiers
+	Ipv6UplinkMode_IPV6_UPLINK_PREFIX_DELEGATION Ipv6UplinkMode = 1
+)
+
+// Enum value maps for Ipv6UplinkMode.
+var (
+	Ipv6UplinkMode_name = map[int32]string{
+		0: "IPV6_UPLINK_NAT66",
+		1: "IPV6_UPLINK_PREFIX_DELEGATION",
+	}
+	Ipv6UplinkMode_value = map[string]int32{
+		"IPV6_UPLINK_NAT66":             0,
+		"IPV6_UPLINK_PREFIX_DELEGATION": 1,
+	}
+)
+
+func (x Ipv6UplinkMode) Enum() *Ipv6UplinkMode {
+	p := new(Ipv6UplinkMode)
+	*p = x
+	return p
+}
+
+func (x Ipv6UplinkMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Ipv6UplinkMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_netinst_proto_enumTypes[5].Descriptor()
+}
+
+func (Ipv6UplinkMode) Type() protoreflect.EnumType {
+	return &file_config_netinst_proto_enumTypes[5]
+}
+
+func (x Ipv6UplinkMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Ipv6UplinkMode.Descriptor instead.
+func (Ipv6UplinkMode) EnumDescriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{5}
+}
+
+// How a load balancer picks the backend for a new connection
+type LoadBalancerAlgorithm int32
+
+const (
+	LoadBalancerAlgorithm_LB_ALGORITHM_ROUND_ROBIN LoadBalancerAlgorithm = 0
+	// Hash of the source address of the connection so that a client
+	// sticks to the same backend as long as the set of healthy backends
+	// does not change
+	LoadBalancerAlgorithm_LB_ALGORITHM_SOURCE_HASH LoadBalancerAlgorithm = 1
+)
+
+// Enum value maps for LoadBalancerAlgorithm.
+var (
+	LoadBalancerAlgorithm_name = map[int32]string{
+		0: "LB_ALGORITHM_ROUND_ROBIN",
+		1: "LB_ALGORITHM_SOURCE_HASH",
+	}
+	LoadBalancerAlgorithm_value = map[string]int32{
+		"LB_ALGORITHM_ROUND_ROBIN": 0,
+		"LB_ALGORITHM_SOURCE_HASH": 1,
+	}
+)
+
+func (x LoadBalancerAlgorithm) Enum() *LoadBalancerAlgorithm {
+	p := new(LoadBalancerAlgorithm)
+	*p = x
+	return p
+}
+
+func (x LoadBalancerAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LoadBalancerAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_netinst_proto_enumTypes[6].Descriptor()
+}
+
+func (LoadBalancerAlgorithm) Type() protoreflect.EnumType {
+	return &file_config_netinst_proto_enumTypes[6]
+}
+
+func (x LoadBalancerAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LoadBalancerAlgorithm.Descriptor instead.
+func (LoadBalancerAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{6}
+}
+
+type LoadBalancerHealthCheckType int32
+
+const (
+	// The backends are always considered healthy
+	LoadBalancerHealthCheckType_LB_HEALTH_CHECK_NONE LoadBalancerHealthCheckType = 0
+	// TCP connect to the appPort of the backend
+	LoadBalancerHealthCheckType_LB_HEALTH_CHECK_TCP LoadBalancerHealthCheckType = 1
+	// HTTP GET of httpPath on the appPort of the backend, expecting a 2xx
+	// or 3xx response
+	LoadBalancerHealthCheckType_LB_HEALTH_CHECK_HTTP LoadBalancerHealthCheckType = 2
+)
+
+// Enum value maps for LoadBalancerHealthCheckType.
+var (
+	LoadBalancerHealthCheckType_name = map[int32]string{
+		0: "LB_HEALTH_CHECK_NONE",
+		1: "LB_HEALTH_CHECK_TCP",
+		2: "LB_HEALTH_CHECK_HTTP",
+	}
+	LoadBalancerHealthCheckType_value = map[string]int32{
+		"LB_HEALTH_CHECK_NONE": 0,
+		"LB_HEALTH_CHECK_TCP":  1,
+		"LB_HEALTH_CHECK_HTTP": 2,
+	}
+)
+
+func (x LoadBalancerHealthCheckType) Enum() *LoadBalancerHealthCheckType {
+	p := new(LoadBalancerHealthCheckType)
+	*p = x
+	return p
+}
+
+func (x LoadBalancerHealthCheckType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LoadBalancerHealthCheckType) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_netinst_proto_enumTypes[7].Descriptor()
+}
+
+func (LoadBalancerHealthCheckType) Type() protoreflect.EnumType {
+	return &file_config_netinst_proto_enumTypes[7]
+}
+
+func (x LoadBalancerHealthCheckType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LoadBalancerHealthCheckType.Descriptor instead.
+func (LoadBalancerHealthCheckType) EnumDescriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{7}
+}
+
 // Network Instance Opaque config. In future we might add more fields here
 // but idea is here. This is service specific configuration.
 type NetworkInstanceOpaqueConfig struct {
@@ -470,6 +675,303 @@ func (x *NetworkInstanceLispConfig) GetExperimental() bool {
 	return false
 }
 
+// IPFIX (RFC 7011) export of the flow records collected for the network
+// instance to a collector on a local network
+type IpfixExporterConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// collector - IP address of the IPFIX collector
+	Collector string `protobuf:"bytes,1,opt,name=collector,proto3" json:"collector,omitempty"`
+	// port - UDP port of the collector. Defaults to 4739 if not set
+	Port uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	// observationDomainId - put in the IPFIX message header so that the
+	// collector can tell the exporting network instances apart
+	ObservationDomainId uint32 `protobuf:"varint,3,opt,name=observationDomainId,proto3" json:"observationDomainId,omitempty"`
+	// enterpriseNumber - IANA Private Enterprise Number under which the
+	// EVE specific information elements (app instance UUID, network instance
+	// UUID, ACL rule ID and DNS name) are exported. If not set only the
+	// IANA defined information elements are exported
+	EnterpriseNumber uint32 `protobuf:"varint,4,opt,name=enterpriseNumber,proto3" json:"enterpriseNumber,omitempty"`
+}
+
+func (x *IpfixExporterConfig) Reset() {
+	*x = IpfixExporterConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_netinst_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IpfixExporterConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IpfixExporterConfig) ProtoMessage() {}
+
+func (x *IpfixExporterConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_netinst_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IpfixExporterConfig.ProtoReflect.Descriptor instead.
+func (*IpfixExporterConfig) Descriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *IpfixExporterConfig) GetCollector() string {
+	if x != nil {
+		return x.Collector
+	}
+	return ""
+}
+
+func (x *IpfixExporterConfig) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *IpfixExporterConfig) GetObservationDomainId() uint32 {
+	if x != nil {
+		return x.ObservationDomainId
+	}
+	return 0
+}
+
+func (x *IpfixExporterConfig) GetEnterpriseNumber() uint32 {
+	if x != nil {
+		return x.EnterpriseNumber
+	}
+	return 0
+}
+
+// IPv6 specific configuration of a local network instance with ipType IPV6
+type Ipv6Config struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Addressing Ipv6AddressingMode `protobuf:"varint,1,opt,name=addressing,proto3,enum=org.lfedge.eve.config.Ipv6AddressingMode" json:"addressing,omitempty"`
+	Uplink     Ipv6UplinkMode     `protobuf:"varint,2,opt,name=uplink,proto3,enum=org.lfedge.eve.config.Ipv6UplinkMode" json:"uplink,omitempty"`
+}
+
+func (x *Ipv6Config) Reset() {
+	*x = Ipv6Config{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_netinst_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Ipv6Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ipv6Config) ProtoMessage() {}
+
+func (x *Ipv6Config) ProtoReflect() protoreflect.Message {
+	mi := &file_config_netinst_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ipv6Config.ProtoReflect.Descriptor instead.
+func (*Ipv6Config) Descriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Ipv6Config) GetAddressing() Ipv6AddressingMode {
+	if x != nil {
+		return x.Addressing
+	}
+	return Ipv6AddressingMode_IPV6_ADDRESSING_DHCPV6
+}
+
+func (x *Ipv6Config) GetUplink() Ipv6UplinkMode {
+	if x != nil {
+		return x.Uplink
+	}
+	return Ipv6UplinkMode_IPV6_UPLINK_NAT66
+}
+
+type LoadBalancerHealthCheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type LoadBalancerHealthCheckType `protobuf:"varint,1,opt,name=type,proto3,enum=org.lfedge.eve.config.LoadBalancerHealthCheckType" json:"type,omitempty"`
+	// interval - seconds between checks of a backend. Defaults to 10
+	Interval uint32 `protobuf:"varint,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	// timeout - seconds to wait for a check to succeed. Defaults to 5
+	Timeout uint32 `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// healthyThreshold - consecutive successful checks before an unhealthy
+	// backend receives new connections. Defaults to 2
+	HealthyThreshold uint32 `protobuf:"varint,4,opt,name=healthyThreshold,proto3" json:"healthyThreshold,omitempty"`
+	// unhealthyThreshold - consecutive failed checks before a healthy
+	// backend stops receiving new connections. Defaults to 3
+	UnhealthyThreshold uint32 `protobuf:"varint,5,opt,name=unhealthyThreshold,proto3" json:"unhealthyThreshold,omitempty"`
+	// httpPath - path for LB_HEALTH_CHECK_HTTP. Defaults to "/"
+	HttpPath string `protobuf:"bytes,6,opt,name=httpPath,proto3" json:"httpPath,omitempty"`
+}
+
+func (x *LoadBalancerHealthCheck) Reset() {
+	*x = LoadBalancerHealthCheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_netinst_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadBalancerHealthCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadBalancerHealthCheck) ProtoMessage() {}
+
+func (x *LoadBalancerHealthCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_config_netinst_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadBalancerHealthCheck.ProtoReflect.Descriptor instead.
+func (*LoadBalancerHealthCheck) Descriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LoadBalancerHealthCheck) GetType() LoadBalancerHealthCheckType {
+	if x != nil {
+		return x.Type
+	}
+	return LoadBalancerHealthCheckType_LB_HEALTH_CHECK_NONE
+}
+
+func (x *LoadBalancerHealthCheck) GetInterval() uint32 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *LoadBalancerHealthCheck) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *LoadBalancerHealthCheck) GetHealthyThreshold() uint32 {
+	if x != nil {
+		return x.HealthyThreshold
+	}
+	return 0
+}
+
+func (x *LoadBalancerHealthCheck) GetUnhealthyThreshold() uint32 {
+	if x != nil {
+		return x.UnhealthyThreshold
+	}
+	return 0
+}
+
+func (x *LoadBalancerHealthCheck) GetHttpPath() string {
+	if x != nil {
+		return x.HttpPath
+	}
+	return ""
+}
+
+// A service on a local network instance where the connections to the
+// lport of the port map ACEs which refer to the load balancer by name are
+// spread across the app instances with those ACEs
+type LoadBalancerConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name        string                   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Algorithm   LoadBalancerAlgorithm    `protobuf:"varint,2,opt,name=algorithm,proto3,enum=org.lfedge.eve.config.LoadBalancerAlgorithm" json:"algorithm,omitempty"`
+	HealthCheck *LoadBalancerHealthCheck `protobuf:"bytes,3,opt,name=healthCheck,proto3" json:"healthCheck,omitempty"`
+}
+
+func (x *LoadBalancerConfig) Reset() {
+	*x = LoadBalancerConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_netinst_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadBalancerConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadBalancerConfig) ProtoMessage() {}
+
+func (x *LoadBalancerConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_netinst_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadBalancerConfig.ProtoReflect.Descriptor instead.
+func (*LoadBalancerConfig) Descriptor() ([]byte, []int) {
+	return file_config_netinst_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LoadBalancerConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LoadBalancerConfig) GetAlgorithm() LoadBalancerAlgorithm {
+	if x != nil {
+		return x.Algorithm
+	}
+	return LoadBalancerAlgorithm_LB_ALGORITHM_ROUND_ROBIN
+}
+
+func (x *LoadBalancerConfig) GetHealthCheck() *LoadBalancerHealthCheck {
+	if x != nil {
+		return x.HealthCheck
+	}
+	return nil
+}
+
 type NetworkInstanceConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -500,12 +1002,18 @@ type NetworkInstanceConfig struct {
 	Ip *Ipspec `protobuf:"bytes,40,opt,name=ip,proto3" json:"ip,omitempty"`
 	// static DNS entry, if we are running DNS/DHCP service
 	Dns []*ZnetStaticDNSEntry `protobuf:"bytes,41,rep,name=dns,proto3" json:"dns,omitempty"`
+	// ipfix - if set the flow records are also exported over IPFIX
+	Ipfix *IpfixExporterConfig `protobuf:"bytes,42,opt,name=ipfix,proto3" json:"ipfix,omitempty"`
+	// ipv6 - only used for local network instances with ipType IPV6
+	Ipv6 *Ipv6Config `protobuf:"bytes,43,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	// loadBalancers - only used for local network instances
+	LoadBalancers []*LoadBalancerConfig `protobuf:"bytes,44,rep,name=loadBalancers,proto3" json:"loadBalancers,omitempty"`
 }
 
 func (x *NetworkInstanceConfig) Reset() {
 	*x = NetworkInstanceConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_netinst_proto_msgTypes[3]
+		mi := &file_config_netinst_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -518,7 +1026,7 @@ func (x *NetworkInstanceConfig) String() string {
 func (*NetworkInstanceConfig) ProtoMessage() {}
 
 func (x *NetworkInstanceConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_netinst_proto_msgTypes[3]
+	mi := &file_config_netinst_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -531,7 +1039,7 @@ func (x *NetworkInstanceConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NetworkInstanceConfig.ProtoReflect.Descriptor instead.
 func (*NetworkInstanceConfig) Descriptor() ([]byte, []int) {
-	return file_config_netinst_proto_rawDescGZIP(), []int{3}
+	return file_config_netinst_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *NetworkInstanceConfig) GetUuidandversion() *UUIDandVersion {
@@ -597,6 +1105,27 @@ func (x *NetworkInstanceConfig) GetDns() []*ZnetStaticDNSEntry {
 	return nil
 }
 
+func (x *NetworkInstanceConfig) GetIpfix() *IpfixExporterConfig {
+	if x != nil {
+		return x.Ipfix
+	}
+	return nil
+}
+
+func (x *NetworkInstanceConfig) GetIpv6() *Ipv6Config {
+	if x != nil {
+		return x.Ipv6
+	}
+	return nil
+}
+
+func (x *NetworkInstanceConfig) GetLoadBalancers() []*LoadBalancerConfig {
+	if x != nil {
+		return x.LoadBalancers
+	}
+	return nil
+}
+
 var File_config_netinst_proto protoreflect.FileDescriptor
 
 var file_config_netinst_proto_rawDesc = []byte{
@@ -648,65 +1177,149 @@ var file_config_netinst_proto_rawDesc = []byte{
 	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x6c, 0x65, 0x6e, 0x12,
 	0x22, 0x0a, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x18,
 	0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e,
-	0x74, 0x61, 0x6c, 0x22, 0x8b, 0x04, 0x0a, 0x15, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49,
-	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x4d, 0x0a,
-	0x0e, 0x75, 0x75, 0x69, 0x64, 0x61, 0x6e, 0x64, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64,
-	0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x55, 0x55,
-	0x49, 0x44, 0x61, 0x6e, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0e, 0x75, 0x75,
-	0x69, 0x64, 0x61, 0x6e, 0x64, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b,
-	0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x43,
-	0x0a, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x27, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76,
-	0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x5a, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
-	0x6b, 0x49, 0x6e, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x54,
-	0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x12,
-	0x32, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
-	0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x41, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x52, 0x04, 0x70,
-	0x6f, 0x72, 0x74, 0x12, 0x44, 0x0a, 0x03, 0x63, 0x66, 0x67, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x32, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76,
-	0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
-	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4f, 0x70, 0x61, 0x71, 0x75, 0x65, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x52, 0x03, 0x63, 0x66, 0x67, 0x12, 0x3a, 0x0a, 0x06, 0x69, 0x70, 0x54,
-	0x79, 0x70, 0x65, 0x18, 0x27, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x6f, 0x72, 0x67, 0x2e,
+	0x74, 0x61, 0x6c, 0x22, 0xa5, 0x01, 0x0a, 0x13, 0x49, 0x70, 0x66, 0x69, 0x78, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x63,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x30, 0x0a,
+	0x13, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x6f, 0x6d, 0x61,
+	0x69, 0x6e, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x13, 0x6f, 0x62, 0x73, 0x65,
+	0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12,
+	0x2a, 0x0a, 0x10, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x70, 0x72, 0x69, 0x73, 0x65, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x65, 0x6e, 0x74, 0x65, 0x72,
+	0x70, 0x72, 0x69, 0x73, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x96, 0x01, 0x0a, 0x0a,
+	0x49, 0x70, 0x76, 0x36, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x49, 0x0a, 0x0a, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x29,
+	0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x49, 0x70, 0x76, 0x36, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0a, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x3d, 0x0a, 0x06, 0x75, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x25, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64,
+	0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x49, 0x70,
+	0x76, 0x36, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x06, 0x75, 0x70,
+	0x6c, 0x69, 0x6e, 0x6b, 0x22, 0x8f, 0x02, 0x0a, 0x17, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x61, 0x6c,
+	0x61, 0x6e, 0x63, 0x65, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x12, 0x46, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x32,
+	0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e,
+	0x63, 0x65, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x2a,
+	0x0a, 0x10, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f,
+	0x6c, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x79, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x2e, 0x0a, 0x12, 0x75, 0x6e,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x79, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x74,
+	0x74, 0x70, 0x50, 0x61, 0x74, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x74,
+	0x74, 0x70, 0x50, 0x61, 0x74, 0x68, 0x22, 0xc6, 0x01, 0x0a, 0x12, 0x4c, 0x6f, 0x61, 0x64, 0x42,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x4a, 0x0a, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2c, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67,
+	0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x6f, 0x61,
+	0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x52, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x50, 0x0a,
+	0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e,
+	0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x42,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x52, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x22,
+	0xd5, 0x05, 0x0a, 0x15, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x4d, 0x0a, 0x0e, 0x75, 0x75, 0x69,
+	0x64, 0x61, 0x6e, 0x64, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x25, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65,
+	0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x61, 0x6e,
+	0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0e, 0x75, 0x75, 0x69, 0x64, 0x61, 0x6e,
+	0x64, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x69, 0x73, 0x70,
+	0x6c, 0x61, 0x79, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x43, 0x0a, 0x08, 0x69, 0x6e,
+	0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x27, 0x2e, 0x6f,
+	0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x5a, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x73,
+	0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x04, 0x70,
+	0x6f, 0x72, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x6f, 0x72, 0x67, 0x2e,
 	0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x52, 0x06, 0x69,
-	0x70, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2d, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x28, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x1d, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65,
-	0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x69, 0x70, 0x73, 0x70, 0x65, 0x63,
-	0x52, 0x02, 0x69, 0x70, 0x12, 0x3b, 0x0a, 0x03, 0x64, 0x6e, 0x73, 0x18, 0x29, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x29, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65,
-	0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x5a, 0x6e, 0x65, 0x74, 0x53, 0x74,
-	0x61, 0x74, 0x69, 0x63, 0x44, 0x4e, 0x53, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x64, 0x6e,
-	0x73, 0x2a, 0xb3, 0x01, 0x0a, 0x10, 0x5a, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e,
-	0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x11, 0x0a, 0x0d, 0x5a, 0x4e, 0x65, 0x74, 0x49, 0x6e,
-	0x73, 0x74, 0x46, 0x69, 0x72, 0x73, 0x74, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x5a, 0x6e, 0x65,
-	0x74, 0x49, 0x6e, 0x73, 0x74, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68, 0x10, 0x01, 0x12, 0x11, 0x0a,
-	0x0d, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x10, 0x02,
-	0x12, 0x11, 0x0a, 0x0d, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x43, 0x6c, 0x6f, 0x75,
-	0x64, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x4d,
-	0x65, 0x73, 0x68, 0x10, 0x04, 0x12, 0x14, 0x0a, 0x10, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73,
-	0x74, 0x48, 0x6f, 0x6e, 0x65, 0x79, 0x50, 0x6f, 0x74, 0x10, 0x05, 0x12, 0x17, 0x0a, 0x13, 0x5a,
-	0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x61, 0x72, 0x65,
-	0x6e, 0x74, 0x10, 0x06, 0x12, 0x11, 0x0a, 0x0c, 0x5a, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74,
-	0x4c, 0x61, 0x73, 0x74, 0x10, 0xff, 0x01, 0x2a, 0x57, 0x0a, 0x0b, 0x41, 0x64, 0x64, 0x72, 0x65,
-	0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x46, 0x69, 0x72, 0x73, 0x74, 0x10,
-	0x00, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x50, 0x56, 0x34, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x49,
-	0x50, 0x56, 0x36, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x49,
-	0x50, 0x56, 0x34, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x49,
-	0x50, 0x56, 0x36, 0x10, 0x04, 0x12, 0x09, 0x0a, 0x04, 0x4c, 0x61, 0x73, 0x74, 0x10, 0xff, 0x01,
-	0x2a, 0x43, 0x0a, 0x18, 0x5a, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4f, 0x70, 0x61, 0x71,
-	0x75, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x0e,
-	0x5a, 0x4e, 0x65, 0x74, 0x4f, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x56, 0x50, 0x4e, 0x10, 0x00,
-	0x12, 0x13, 0x0a, 0x0f, 0x5a, 0x4e, 0x65, 0x74, 0x4f, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x4c,
-	0x69, 0x73, 0x70, 0x10, 0x01, 0x2a, 0x47, 0x0a, 0x0d, 0x5a, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x7a, 0x63, 0x6c, 0x6f, 0x75, 0x64,
-	0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x53, 0x72, 0x76, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09,
-	0x6d, 0x61, 0x70, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x73,
-	0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x10, 0x02, 0x42, 0x3d,
+	0x67, 0x2e, 0x41, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x44, 0x0a, 0x03, 0x63, 0x66, 0x67, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x6f,
+	0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x4f, 0x70, 0x61, 0x71, 0x75, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x03, 0x63, 0x66, 0x67, 0x12, 0x3a, 0x0a, 0x06, 0x69, 0x70, 0x54, 0x79, 0x70, 0x65, 0x18,
+	0x27, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64,
+	0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x52, 0x06, 0x69, 0x70, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x2d, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x28, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x69, 0x70, 0x73, 0x70, 0x65, 0x63, 0x52, 0x02, 0x69, 0x70,
+	0x12, 0x3b, 0x0a, 0x03, 0x64, 0x6e, 0x73, 0x18, 0x29, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e,
+	0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x5a, 0x6e, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63,
+	0x44, 0x4e, 0x53, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x64, 0x6e, 0x73, 0x12, 0x40, 0x0a,
+	0x05, 0x69, 0x70, 0x66, 0x69, 0x78, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x6f,
+	0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x49, 0x70, 0x66, 0x69, 0x78, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x05, 0x69, 0x70, 0x66, 0x69, 0x78, 0x12,
+	0x35, 0x0a, 0x04, 0x69, 0x70, 0x76, 0x36, 0x18, 0x2b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e,
+	0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x49, 0x70, 0x76, 0x36, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x04, 0x69, 0x70, 0x76, 0x36, 0x12, 0x4f, 0x0a, 0x0d, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x73, 0x18, 0x2c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e,
+	0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63,
+	0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0d, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x73, 0x2a, 0xb3, 0x01, 0x0a, 0x10, 0x5a, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x73, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x11, 0x0a, 0x0d,
+	0x5a, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x46, 0x69, 0x72, 0x73, 0x74, 0x10, 0x00, 0x12,
+	0x12, 0x0a, 0x0e, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x53, 0x77, 0x69, 0x74, 0x63,
+	0x68, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x4c,
+	0x6f, 0x63, 0x61, 0x6c, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e,
+	0x73, 0x74, 0x43, 0x6c, 0x6f, 0x75, 0x64, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x5a, 0x6e, 0x65,
+	0x74, 0x49, 0x6e, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x68, 0x10, 0x04, 0x12, 0x14, 0x0a, 0x10, 0x5a,
+	0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x48, 0x6f, 0x6e, 0x65, 0x79, 0x50, 0x6f, 0x74, 0x10,
+	0x05, 0x12, 0x17, 0x0a, 0x13, 0x5a, 0x6e, 0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x10, 0x06, 0x12, 0x11, 0x0a, 0x0c, 0x5a, 0x4e,
+	0x65, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x4c, 0x61, 0x73, 0x74, 0x10, 0xff, 0x01, 0x2a, 0x57, 0x0a,
+	0x0b, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x12, 0x09, 0x0a, 0x05,
+	0x46, 0x69, 0x72, 0x73, 0x74, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x50, 0x56, 0x34, 0x10,
+	0x01, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x50, 0x56, 0x36, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x43,
+	0x72, 0x79, 0x70, 0x74, 0x6f, 0x49, 0x50, 0x56, 0x34, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x43,
+	0x72, 0x79, 0x70, 0x74, 0x6f, 0x49, 0x50, 0x56, 0x36, 0x10, 0x04, 0x12, 0x09, 0x0a, 0x04, 0x4c,
+	0x61, 0x73, 0x74, 0x10, 0xff, 0x01, 0x2a, 0x43, 0x0a, 0x18, 0x5a, 0x4e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x4f, 0x70, 0x61, 0x71, 0x75, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x12, 0x0a, 0x0e, 0x5a, 0x4e, 0x65, 0x74, 0x4f, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x56, 0x50, 0x4e, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x5a, 0x4e, 0x65, 0x74, 0x4f, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x4c, 0x69, 0x73, 0x70, 0x10, 0x01, 0x2a, 0x47, 0x0a, 0x0d, 0x5a,
+	0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10,
+	0x7a, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x53, 0x72, 0x76,
+	0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x6d, 0x61, 0x70, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x10,
+	0x01, 0x12, 0x11, 0x0a, 0x0d, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x53, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x10, 0x02, 0x2a, 0x4b, 0x0a, 0x12, 0x49, 0x70, 0x76, 0x36, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x49, 0x50,
+	0x56, 0x36, 0x5f, 0x41, 0x44, 0x44, 0x52, 0x45, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x5f, 0x44, 0x48,
+	0x43, 0x50, 0x56, 0x36, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x41,
+	0x44, 0x44, 0x52, 0x45, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x4c, 0x41, 0x41, 0x43, 0x10,
+	0x01, 0x2a, 0x4a, 0x0a, 0x0e, 0x49, 0x70, 0x76, 0x36, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x4d,
+	0x6f, 0x64, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x55, 0x50, 0x4c, 0x49,
+	0x4e, 0x4b, 0x5f, 0x4e, 0x41, 0x54, 0x36, 0x36, 0x10, 0x00, 0x12, 0x21, 0x0a, 0x1d, 0x49, 0x50,
+	0x56, 0x36, 0x5f, 0x55, 0x50, 0x4c, 0x49, 0x4e, 0x4b, 0x5f, 0x50, 0x52, 0x45, 0x46, 0x49, 0x58,
+	0x5f, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x2a, 0x53, 0x0a,
+	0x15, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x72, 0x41, 0x6c, 0x67,
+	0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x1c, 0x0a, 0x18, 0x4c, 0x42, 0x5f, 0x41, 0x4c, 0x47,
+	0x4f, 0x52, 0x49, 0x54, 0x48, 0x4d, 0x5f, 0x52, 0x4f, 0x55, 0x4e, 0x44, 0x5f, 0x52, 0x4f, 0x42,
+	0x49, 0x4e, 0x10, 0x00, 0x12, 0x1c, 0x0a, 0x18, 0x4c, 0x42, 0x5f, 0x41, 0x4c, 0x47, 0x4f, 0x52,
+	0x49, 0x54, 0x48, 0x4d, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x48, 0x41, 0x53, 0x48,
+	0x10, 0x01, 0x2a, 0x6a, 0x0a, 0x1b, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63,
+	0x65, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x18, 0x0a, 0x14, 0x4c, 0x42, 0x5f, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x5f, 0x43,
+	0x48, 0x45, 0x43, 0x4b, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x4c,
+	0x42, 0x5f, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x5f, 0x43, 0x48, 0x45, 0x43, 0x4b, 0x5f, 0x54,
+	0x43, 0x50, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x4c, 0x42, 0x5f, 0x48, 0x45, 0x41, 0x4c, 0x54,
+	0x48, 0x5f, 0x43, 0x48, 0x45, 0x43, 0x4b, 0x5f, 0x48, 0x54, 0x54, 0x50, 0x10, 0x02, 0x42, 0x3d,
 	0x0a, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65,
 	0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5a, 0x24, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
 	0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x66, 0x2d, 0x65, 0x64, 0x67, 0x65, 0x2f, 0x65, 0x76, 0x65, 0x2f,
@@ -726,39 +1339,55 @@ func file_config_netinst_proto_rawDescGZIP() []byte {
 	return file_config_netinst_proto_rawDescData
 }
 
-var file_config_netinst_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_config_netinst_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_config_netinst_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_config_netinst_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_config_netinst_proto_goTypes = []interface{}{
 	(ZNetworkInstType)(0),               // 0: org.lfedge.eve.config.ZNetworkInstType
 	(AddressType)(0),                    // 1: org.lfedge.eve.config.AddressType
 	(ZNetworkOpaqueConfigType)(0),       // 2: org.lfedge.eve.config.ZNetworkOpaqueConfigType
 	(ZcServiceType)(0),                  // 3: org.lfedge.eve.config.ZcServiceType
-	(*NetworkInstanceOpaqueConfig)(nil), // 4: org.lfedge.eve.config.NetworkInstanceOpaqueConfig
-	(*ZcServicePoint)(nil),              // 5: org.lfedge.eve.config.ZcServicePoint
-	(*NetworkInstanceLispConfig)(nil),   // 6: org.lfedge.eve.config.NetworkInstanceLispConfig
-	(*NetworkInstanceConfig)(nil),       // 7: org.lfedge.eve.config.NetworkInstanceConfig
-	(*UUIDandVersion)(nil),              // 8: org.lfedge.eve.config.UUIDandVersion
-	(*Adapter)(nil),                     // 9: org.lfedge.eve.config.Adapter
-	(*Ipspec)(nil),                      // 10: org.lfedge.eve.config.ipspec
-	(*ZnetStaticDNSEntry)(nil),          // 11: org.lfedge.eve.config.ZnetStaticDNSEntry
+	(Ipv6AddressingMode)(0),             // 4: org.lfedge.eve.config.Ipv6AddressingMode
+	(Ipv6UplinkMode)(0),                 // 5: org.lfedge.eve.config.Ipv6UplinkMode
+	(LoadBalancerAlgorithm)(0),          // 6: org.lfedge.eve.config.LoadBalancerAlgorithm
+	(LoadBalancerHealthCheckType)(0),    // 7: org.lfedge.eve.config.LoadBalancerHealthCheckType
+	(*NetworkInstanceOpaqueConfig)(nil), // 8: org.lfedge.eve.config.NetworkInstanceOpaqueConfig
+	(*ZcServicePoint)(nil),              // 9: org.lfedge.eve.config.ZcServicePoint
+	(*NetworkInstanceLispConfig)(nil),   // 10: org.lfedge.eve.config.NetworkInstanceLispConfig
+	(*IpfixExporterConfig)(nil),         // 11: org.lfedge.eve.config.IpfixExporterConfig
+	(*Ipv6Config)(nil),                  // 12: org.lfedge.eve.config.Ipv6Config
+	(*LoadBalancerHealthCheck)(nil),     // 13: org.lfedge.eve.config.LoadBalancerHealthCheck
+	(*LoadBalancerConfig)(nil),          // 14: org.lfedge.eve.config.LoadBalancerConfig
+	(*NetworkInstanceConfig)(nil),       // 15: org.lfedge.eve.config.NetworkInstanceConfig
+	(*UUIDandVersion)(nil),              // 16: org.lfedge.eve.config.UUIDandVersion
+	(*Adapter)(nil),                     // 17: org.lfedge.eve.config.Adapter
+	(*Ipspec)(nil),                      // 18: org.lfedge.eve.config.ipspec
+	(*ZnetStaticDNSEntry)(nil),          // 19: org.lfedge.eve.config.ZnetStaticDNSEntry
 }
 var file_config_netinst_proto_depIdxs = []int32{
-	6,  // 0: org.lfedge.eve.config.NetworkInstanceOpaqueConfig.lispConfig:type_name -> org.lfedge.eve.config.NetworkInstanceLispConfig
+	10, // 0: org.lfedge.eve.config.NetworkInstanceOpaqueConfig.lispConfig:type_name -> org.lfedge.eve.config.NetworkInstanceLispConfig
 	2,  // 1: org.lfedge.eve.config.NetworkInstanceOpaqueConfig.type:type_name -> org.lfedge.eve.config.ZNetworkOpaqueConfigType
 	3,  // 2: org.lfedge.eve.config.ZcServicePoint.zsType:type_name -> org.lfedge.eve.config.ZcServiceType
-	5,  // 3: org.lfedge.eve.config.NetworkInstanceLispConfig.LispMSs:type_name -> org.lfedge.eve.config.ZcServicePoint
-	8,  // 4: org.lfedge.eve.config.NetworkInstanceConfig.uuidandversion:type_name -> org.lfedge.eve.config.UUIDandVersion
-	0,  // 5: org.lfedge.eve.config.NetworkInstanceConfig.instType:type_name -> org.lfedge.eve.config.ZNetworkInstType
-	9,  // 6: org.lfedge.eve.config.NetworkInstanceConfig.port:type_name -> org.lfedge.eve.config.Adapter
-	4,  // 7: org.lfedge.eve.config.NetworkInstanceConfig.cfg:type_name -> org.lfedge.eve.config.NetworkInstanceOpaqueConfig
-	1,  // 8: org.lfedge.eve.config.NetworkInstanceConfig.ipType:type_name -> org.lfedge.eve.config.AddressType
-	10, // 9: org.lfedge.eve.config.NetworkInstanceConfig.ip:type_name -> org.lfedge.eve.config.ipspec
-	11, // 10: org.lfedge.eve.config.NetworkInstanceConfig.dns:type_name -> org.lfedge.eve.config.ZnetStaticDNSEntry
-	11, // [11:11] is the sub-list for method output_type
-	11, // [11:11] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	9,  // 3: org.lfedge.eve.config.NetworkInstanceLispConfig.LispMSs:type_name -> org.lfedge.eve.config.ZcServicePoint
+	4,  // 4: org.lfedge.eve.config.Ipv6Config.addressing:type_name -> org.lfedge.eve.config.Ipv6AddressingMode
+	5,  // 5: org.lfedge.eve.config.Ipv6Config.uplink:type_name -> org.lfedge.eve.config.Ipv6UplinkMode
+	7,  // 6: org.lfedge.eve.config.LoadBalancerHealthCheck.type:type_name -> org.lfedge.eve.config.LoadBalancerHealthCheckType
+	6,  // 7: org.lfedge.eve.config.LoadBalancerConfig.algorithm:type_name -> org.lfedge.eve.config.LoadBalancerAlgorithm
+	13, // 8: org.lfedge.eve.config.LoadBalancerConfig.healthCheck:type_name -> org.lfedge.eve.config.LoadBalancerHealthCheck
+	16, // 9: org.lfedge.eve.config.NetworkInstanceConfig.uuidandversion:type_name -> org.lfedge.eve.config.UUIDandVersion
+	0,  // 10: org.lfedge.eve.config.NetworkInstanceConfig.instType:type_name -> org.lfedge.eve.config.ZNetworkInstType
+	17, // 11: org.lfedge.eve.config.NetworkInstanceConfig.port:type_name -> org.lfedge.eve.config.Adapter
+	8,  // 12: org.lfedge.eve.config.NetworkInstanceConfig.cfg:type_name -> org.lfedge.eve.config.NetworkInstanceOpaqueConfig
+	1,  // 13: org.lfedge.eve.config.NetworkInstanceConfig.ipType:type_name -> org.lfedge.eve.config.AddressType
+	18, // 14: org.lfedge.eve.config.NetworkInstanceConfig.ip:type_name -> org.lfedge.eve.config.ipspec
+	19, // 15: org.lfedge.eve.config.NetworkInstanceConfig.dns:type_name -> org.lfedge.eve.config.ZnetStaticDNSEntry
+	11, // 16: org.lfedge.eve.config.NetworkInstanceConfig.ipfix:type_name -> org.lfedge.eve.config.IpfixExporterConfig
+	12, // 17: org.lfedge.eve.config.NetworkInstanceConfig.ipv6:type_name -> org.lfedge.eve.config.Ipv6Config
+	14, // 18: org.lfedge.eve.config.NetworkInstanceConfig.loadBalancers:type_name -> org.lfedge.eve.config.LoadBalancerConfig
+	19, // [19:19] is the sub-list for method output_type
+	19, // [19:19] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_config_netinst_proto_init() }
@@ -806,6 +1435,54 @@ func file_config_netinst_proto_init() {
 			}
 		}
 		file_config_netinst_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IpfixExporterConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_netinst_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Ipv6Config); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_netinst_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadBalancerHealthCheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_netinst_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadBalancerConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_netinst_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*NetworkInstanceConfig); i {
 			case 0:
 				return &v.state
@@ -823,8 +1500,8 @@ func file_config_netinst_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_config_netinst_proto_rawDesc,
-			NumEnums:      4,
-			NumMessages:   4,
+			NumEnums:      8,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
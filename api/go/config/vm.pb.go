@@ -85,6 +85,106 @@ func (VmMode) EnumDescriptor() ([]byte, []int) {
 	return file_config_vm_proto_rawDescGZIP(), []int{0}
 }
 
+// Page size used to back the guest memory. Only supported on KVM.
+type HugepageSize int32
+
+const (
+	HugepageSize_HUGEPAGE_SIZE_NONE HugepageSize = 0 // Regular pages
+	HugepageSize_HUGEPAGE_SIZE_2M   HugepageSize = 1
+	HugepageSize_HUGEPAGE_SIZE_1G   HugepageSize = 2
+)
+
+// Enum value maps for HugepageSize.
+var (
+	HugepageSize_name = map[int32]string{
+		0: "HUGEPAGE_SIZE_NONE",
+		1: "HUGEPAGE_SIZE_2M",
+		2: "HUGEPAGE_SIZE_1G",
+	}
+	HugepageSize_value = map[string]int32{
+		"HUGEPAGE_SIZE_NONE": 0,
+		"HUGEPAGE_SIZE_2M":   1,
+		"HUGEPAGE_SIZE_1G":   2,
+	}
+)
+
+func (x HugepageSize) Enum() *HugepageSize {
+	p := new(HugepageSize)
+	*p = x
+	return p
+}
+
+func (x HugepageSize) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HugepageSize) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_vm_proto_enumTypes[1].Descriptor()
+}
+
+func (HugepageSize) Type() protoreflect.EnumType {
+	return &file_config_vm_proto_enumTypes[1]
+}
+
+func (x HugepageSize) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HugepageSize.Descriptor instead.
+func (HugepageSize) EnumDescriptor() ([]byte, []int) {
+	return file_config_vm_proto_rawDescGZIP(), []int{1}
+}
+
+// Virtual TPM of a VM. Only supported on KVM.
+type VtpmMode int32
+
+const (
+	VtpmMode_VTPM_MODE_NONE   VtpmMode = 0 // No virtual TPM
+	VtpmMode_VTPM_MODE_VAULT  VtpmMode = 1 // State encrypted with a key stored in the vault
+	VtpmMode_VTPM_MODE_SEALED VtpmMode = 2 // Key additionally sealed by the TPM of the device
+)
+
+// Enum value maps for VtpmMode.
+var (
+	VtpmMode_name = map[int32]string{
+		0: "VTPM_MODE_NONE",
+		1: "VTPM_MODE_VAULT",
+		2: "VTPM_MODE_SEALED",
+	}
+	VtpmMode_value = map[string]int32{
+		"VTPM_MODE_NONE":   0,
+		"VTPM_MODE_VAULT":  1,
+		"VTPM_MODE_SEALED": 2,
+	}
+)
+
+func (x VtpmMode) Enum() *VtpmMode {
+	p := new(VtpmMode)
+	*p = x
+	return p
+}
+
+func (x VtpmMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VtpmMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_vm_proto_enumTypes[2].Descriptor()
+}
+
+func (VtpmMode) Type() protoreflect.EnumType {
+	return &file_config_vm_proto_enumTypes[2]
+}
+
+func (x VtpmMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VtpmMode.Descriptor instead.
+func (VtpmMode) EnumDescriptor() ([]byte, []int) {
+	return file_config_vm_proto_rawDescGZIP(), []int{2}
+}
+
 type VmConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -108,6 +208,16 @@ type VmConfig struct {
 	EnableVnc          bool     `protobuf:"varint,16,opt,name=enableVnc,proto3" json:"enableVnc,omitempty"`
 	VncDisplay         uint32   `protobuf:"varint,17,opt,name=vncDisplay,proto3" json:"vncDisplay,omitempty"`
 	VncPasswd          string   `protobuf:"bytes,18,opt,name=vncPasswd,proto3" json:"vncPasswd,omitempty"`
+	// Run the app instance on dedicated physical cores which EVE, other
+	// app instances and interrupts do not use. vcpus is rounded up to
+	// whole cores when the cores have hyperthreads. Not supported on Xen.
+	ExclusiveCpus bool `protobuf:"varint,19,opt,name=exclusiveCpus,proto3" json:"exclusiveCpus,omitempty"`
+	// Back the guest memory with hugepages from a pool which EVE reserves
+	// for the app instances using them. memory is rounded up to the page size.
+	Hugepages HugepageSize `protobuf:"varint,20,opt,name=hugepages,proto3,enum=org.lfedge.eve.config.HugepageSize" json:"hugepages,omitempty"`
+	// Give the app instance a persistent virtual TPM. VTPM_MODE_SEALED fails
+	// on devices without a TPM.
+	Vtpm VtpmMode `protobuf:"varint,21,opt,name=vtpm,proto3,enum=org.lfedge.eve.config.VtpmMode" json:"vtpm,omitempty"`
 }
 
 func (x *VmConfig) Reset() {
@@ -268,12 +378,33 @@ func (x *VmConfig) GetVncPasswd() string {
 	return ""
 }
 
+func (x *VmConfig) GetExclusiveCpus() bool {
+	if x != nil {
+		return x.ExclusiveCpus
+	}
+	return false
+}
+
+func (x *VmConfig) GetHugepages() HugepageSize {
+	if x != nil {
+		return x.Hugepages
+	}
+	return HugepageSize_HUGEPAGE_SIZE_NONE
+}
+
+func (x *VmConfig) GetVtpm() VtpmMode {
+	if x != nil {
+		return x.Vtpm
+	}
+	return VtpmMode_VTPM_MODE_NONE
+}
+
 var File_config_vm_proto protoreflect.FileDescriptor
 
 var file_config_vm_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x12, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76,
-	0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x93, 0x04, 0x0a, 0x08, 0x56, 0x6d, 0x43,
+	0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0xb1, 0x05, 0x0a, 0x08, 0x56, 0x6d, 0x43,
 	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x6b, 0x65, 0x72, 0x6e, 0x65, 0x6c, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6b, 0x65, 0x72, 0x6e, 0x65, 0x6c, 0x12, 0x18, 0x0a,
 	0x07, 0x72, 0x61, 0x6d, 0x64, 0x69, 0x73, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
@@ -306,16 +437,36 @@ var file_config_vm_proto_rawDesc = []byte{
 	0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x6e, 0x63, 0x44, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x18, 0x11,
 	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x76, 0x6e, 0x63, 0x44, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79,
 	0x12, 0x1c, 0x0a, 0x09, 0x76, 0x6e, 0x63, 0x50, 0x61, 0x73, 0x73, 0x77, 0x64, 0x18, 0x12, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x09, 0x76, 0x6e, 0x63, 0x50, 0x61, 0x73, 0x73, 0x77, 0x64, 0x2a, 0x47,
-	0x0a, 0x06, 0x56, 0x6d, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x06, 0x0a, 0x02, 0x50, 0x56, 0x10, 0x00,
-	0x12, 0x07, 0x0a, 0x03, 0x48, 0x56, 0x4d, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x46, 0x69, 0x6c,
-	0x6c, 0x65, 0x72, 0x10, 0x02, 0x12, 0x07, 0x0a, 0x03, 0x46, 0x4d, 0x4c, 0x10, 0x03, 0x12, 0x0b,
-	0x0a, 0x07, 0x4e, 0x4f, 0x48, 0x59, 0x50, 0x45, 0x52, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x4c,
-	0x45, 0x47, 0x41, 0x43, 0x59, 0x10, 0x05, 0x42, 0x3d, 0x0a, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c,
-	0x66, 0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
-	0x5a, 0x24, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x66, 0x2d,
-	0x65, 0x64, 0x67, 0x65, 0x2f, 0x65, 0x76, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x2f,
-	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x76, 0x6e, 0x63, 0x50, 0x61, 0x73, 0x73, 0x77, 0x64, 0x12, 0x24,
+	0x0a, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65, 0x43, 0x70, 0x75, 0x73, 0x18,
+	0x13, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65,
+	0x43, 0x70, 0x75, 0x73, 0x12, 0x41, 0x0a, 0x09, 0x68, 0x75, 0x67, 0x65, 0x70, 0x61, 0x67, 0x65,
+	0x73, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66,
+	0x65, 0x64, 0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x48, 0x75, 0x67, 0x65, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x09, 0x68, 0x75,
+	0x67, 0x65, 0x70, 0x61, 0x67, 0x65, 0x73, 0x12, 0x33, 0x0a, 0x04, 0x76, 0x74, 0x70, 0x6d, 0x18,
+	0x15, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64,
+	0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x56, 0x74,
+	0x70, 0x6d, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x76, 0x74, 0x70, 0x6d, 0x2a, 0x47, 0x0a, 0x06,
+	0x56, 0x6d, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x06, 0x0a, 0x02, 0x50, 0x56, 0x10, 0x00, 0x12, 0x07,
+	0x0a, 0x03, 0x48, 0x56, 0x4d, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x46, 0x69, 0x6c, 0x6c, 0x65,
+	0x72, 0x10, 0x02, 0x12, 0x07, 0x0a, 0x03, 0x46, 0x4d, 0x4c, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07,
+	0x4e, 0x4f, 0x48, 0x59, 0x50, 0x45, 0x52, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x45, 0x47,
+	0x41, 0x43, 0x59, 0x10, 0x05, 0x2a, 0x52, 0x0a, 0x0c, 0x48, 0x75, 0x67, 0x65, 0x70, 0x61, 0x67,
+	0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x12, 0x48, 0x55, 0x47, 0x45, 0x50, 0x41, 0x47,
+	0x45, 0x5f, 0x53, 0x49, 0x5a, 0x45, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x14, 0x0a,
+	0x10, 0x48, 0x55, 0x47, 0x45, 0x50, 0x41, 0x47, 0x45, 0x5f, 0x53, 0x49, 0x5a, 0x45, 0x5f, 0x32,
+	0x4d, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x48, 0x55, 0x47, 0x45, 0x50, 0x41, 0x47, 0x45, 0x5f,
+	0x53, 0x49, 0x5a, 0x45, 0x5f, 0x31, 0x47, 0x10, 0x02, 0x2a, 0x49, 0x0a, 0x08, 0x56, 0x74, 0x70,
+	0x6d, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x0e, 0x56, 0x54, 0x50, 0x4d, 0x5f, 0x4d, 0x4f,
+	0x44, 0x45, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x56, 0x54, 0x50,
+	0x4d, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x56, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x01, 0x12, 0x14,
+	0x0a, 0x10, 0x56, 0x54, 0x50, 0x4d, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x53, 0x45, 0x41, 0x4c,
+	0x45, 0x44, 0x10, 0x02, 0x42, 0x3d, 0x0a, 0x15, 0x6f, 0x72, 0x67, 0x2e, 0x6c, 0x66, 0x65, 0x64,
+	0x67, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5a, 0x24, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x66, 0x2d, 0x65, 0x64, 0x67,
+	0x65, 0x2f, 0x65, 0x76, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x2f, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -330,19 +481,23 @@ func file_config_vm_proto_rawDescGZIP() []byte {
 	return file_config_vm_proto_rawDescData
 }
 
-var file_config_vm_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_config_vm_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
 var file_config_vm_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_config_vm_proto_goTypes = []interface{}{
-	(VmMode)(0),      // 0: org.lfedge.eve.config.VmMode
-	(*VmConfig)(nil), // 1: org.lfedge.eve.config.VmConfig
+	(VmMode)(0),       // 0: org.lfedge.eve.config.VmMode
+	(HugepageSize)(0), // 1: org.lfedge.eve.config.HugepageSize
+	(VtpmMode)(0),     // 2: org.lfedge.eve.config.VtpmMode
+	(*VmConfig)(nil),  // 3: org.lfedge.eve.config.VmConfig
 }
 var file_config_vm_proto_depIdxs = []int32{
 	0, // 0: org.lfedge.eve.config.VmConfig.virtualizationMode:type_name -> org.lfedge.eve.config.VmMode
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 1: org.lfedge.eve.config.VmConfig.hugepages:type_name -> org.lfedge.eve.config.HugepageSize
+	2, // 2: org.lfedge.eve.config.VmConfig.vtpm:type_name -> org.lfedge.eve.config.VtpmMode
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_config_vm_proto_init() }
@@ -369,7 +524,7 @@ func file_config_vm_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_config_vm_proto_rawDesc,
-			NumEnums:      1,
+			NumEnums:      3,
 			NumMessages:   1,
 			NumExtensions: 0,
 			NumServices:   0,
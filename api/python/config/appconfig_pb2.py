@@ -2,6 +2,7 @@
 # Generated by the protocol buffer compiler.  DO NOT EDIT!
 # source: config/appconfig.proto
 
+from google.protobuf.internal import enum_type_wrapper
 from google.protobuf import descriptor as _descriptor
 from google.protobuf import message as _message
 from google.protobuf import reflection as _reflection
@@ -24,10 +25,164 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'\n\025org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/config',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x16\x63onfig/appconfig.proto\x12\x15org.lfedge.eve.config\x1a\x18\x63onfig/acipherinfo.proto\x1a\x16\x63onfig/devcommon.proto\x1a\x14\x63onfig/storage.proto\x1a\x0f\x63onfig/vm.proto\x1a\x16\x63onfig/netconfig.proto\"2\n\x0eInstanceOpsCmd\x12\x0f\n\x07\x63ounter\x18\x02 \x01(\r\x12\x0f\n\x07opsTime\x18\x04 \x01(\t\"\xf1\x04\n\x11\x41ppInstanceConfig\x12=\n\x0euuidandversion\x18\x01 \x01(\x0b\x32%.org.lfedge.eve.config.UUIDandVersion\x12\x13\n\x0b\x64isplayname\x18\x02 \x01(\t\x12\x37\n\x0e\x66ixedresources\x18\x03 \x01(\x0b\x32\x1f.org.lfedge.eve.config.VmConfig\x12,\n\x06\x64rives\x18\x04 \x03(\x0b\x32\x1c.org.lfedge.eve.config.Drive\x12\x10\n\x08\x61\x63tivate\x18\x05 \x01(\x08\x12\x39\n\ninterfaces\x18\x06 \x03(\x0b\x32%.org.lfedge.eve.config.NetworkAdapter\x12\x30\n\x08\x61\x64\x61pters\x18\x07 \x03(\x0b\x32\x1e.org.lfedge.eve.config.Adapter\x12\x36\n\x07restart\x18\t \x01(\x0b\x32%.org.lfedge.eve.config.InstanceOpsCmd\x12\x34\n\x05purge\x18\n \x01(\x0b\x32%.org.lfedge.eve.config.InstanceOpsCmd\x12\x10\n\x08userData\x18\x0b \x01(\t\x12\x15\n\rremoteConsole\x18\x0c \x01(\x08\x12\x36\n\ncipherData\x18\r \x01(\x0b\x32\".org.lfedge.eve.config.CipherBlock\x12\x1a\n\x12\x63ollectStatsIPAddr\x18\x0f \x01(\t\x12\x37\n\rvolumeRefList\x18\x10 \x03(\x0b\x32 .org.lfedge.eve.config.VolumeRef\"E\n\tVolumeRef\x12\x0c\n\x04uuid\x18\x01 \x01(\t\x12\x17\n\x0fgenerationCount\x18\x02 \x01(\x03\x12\x11\n\tmount_dir\x18\x03 \x01(\tB=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
+  serialized_pb=b'\n\x16\x63onfig/appconfig.proto\x12\x15org.lfedge.eve.config\x1a\x18\x63onfig/acipherinfo.proto\x1a\x16\x63onfig/devcommon.proto\x1a\x14\x63onfig/storage.proto\x1a\x0f\x63onfig/vm.proto\x1a\x16\x63onfig/netconfig.proto\"2\n\x0eInstanceOpsCmd\x12\x0f\n\x07\x63ounter\x18\x02 \x01(\r\x12\x0f\n\x07opsTime\x18\x04 \x01(\t\"\xd1\x07\n\x11\x41ppInstanceConfig\x12=\n\x0euuidandversion\x18\x01 \x01(\x0b\x32%.org.lfedge.eve.config.UUIDandVersion\x12\x13\n\x0b\x64isplayname\x18\x02 \x01(\t\x12\x37\n\x0e\x66ixedresources\x18\x03 \x01(\x0b\x32\x1f.org.lfedge.eve.config.VmConfig\x12,\n\x06\x64rives\x18\x04 \x03(\x0b\x32\x1c.org.lfedge.eve.config.Drive\x12\x10\n\x08\x61\x63tivate\x18\x05 \x01(\x08\x12\x39\n\ninterfaces\x18\x06 \x03(\x0b\x32%.org.lfedge.eve.config.NetworkAdapter\x12\x30\n\x08\x61\x64\x61pters\x18\x07 \x03(\x0b\x32\x1e.org.lfedge.eve.config.Adapter\x12\x36\n\x07restart\x18\t \x01(\x0b\x32%.org.lfedge.eve.config.InstanceOpsCmd\x12\x34\n\x05purge\x18\n \x01(\x0b\x32%.org.lfedge.eve.config.InstanceOpsCmd\x12\x10\n\x08userData\x18\x0b \x01(\t\x12\x15\n\rremoteConsole\x18\x0c \x01(\x08\x12\x36\n\ncipherData\x18\r \x01(\x0b\x32\".org.lfedge.eve.config.CipherBlock\x12\x1a\n\x12\x63ollectStatsIPAddr\x18\x0f \x01(\t\x12\x37\n\rvolumeRefList\x18\x10 \x03(\x0b\x32 .org.lfedge.eve.config.VolumeRef\x12/\n\x06probes\x18\x11 \x03(\x0b\x32\x1f.org.lfedge.eve.config.AppProbe\x12>\n\rrestartPolicy\x18\x12 \x01(\x0e\x32\'.org.lfedge.eve.config.AppRestartPolicy\x12\x11\n\tdependsOn\x18\x13 \x03(\t\x12\x39\n\x08priority\x18\x14 \x01(\x0e\x32\'.org.lfedge.eve.config.AppPriorityClass\x12\x38\n\nusbDevices\x18\x15 \x03(\x0b\x32$.org.lfedge.eve.config.UsbDeviceRule\x12\x37\n\ncontainers\x18\x16 \x03(\x0b\x32#.org.lfedge.eve.config.AppContainer\x12\x12\n\nvendorData\x18\x17 \x01(\t\x12\x18\n\x10\x63loudInitNoCloud\x18\x18 \x01(\x08\"_\n\x0c\x41ppContainer\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\r\n\x05image\x18\x02 \x01(\t\x12\x0c\n\x04init\x18\x03 \x01(\x08\x12\x0f\n\x07\x63ommand\x18\x04 \x03(\t\x12\x13\n\x0b\x65nvironment\x18\x05 \x03(\t\"V\n\rUsbDeviceRule\x12\x10\n\x08vendorId\x18\x01 \x01(\t\x12\x11\n\tproductId\x18\x02 \x01(\t\x12\x0e\n\x06serial\x18\x03 \x01(\t\x12\x10\n\x08portPath\x18\x04 \x01(\t\"\xa4\x02\n\x08\x41ppProbe\x12\x31\n\x04kind\x18\x01 \x01(\x0e\x32#.org.lfedge.eve.config.AppProbeKind\x12\x31\n\x04type\x18\x02 \x01(\x0e\x32#.org.lfedge.eve.config.AppProbeType\x12\x16\n\x0enetworkAdapter\x18\x03 \x01(\t\x12\x0c\n\x04port\x18\x04 \x01(\r\x12\x10\n\x08httpPath\x18\x05 \x01(\t\x12\x0f\n\x07\x63ommand\x18\x06 \x03(\t\x12\x14\n\x0cinitialDelay\x18\x07 \x01(\r\x12\x0e\n\x06period\x18\x08 \x01(\r\x12\x0f\n\x07timeout\x18\t \x01(\r\x12\x18\n\x10\x66\x61ilureThreshold\x18\n \x01(\r\x12\x18\n\x10successThreshold\x18\x0b \x01(\r\"E\n\tVolumeRef\x12\x0c\n\x04uuid\x18\x01 \x01(\t\x12\x17\n\x0fgenerationCount\x18\x02 \x01(\x03\x12\x11\n\tmount_dir\x18\x03 \x01(\t*v\n\x10\x41ppPriorityClass\x12\x1d\n\x19\x41PP_PRIORITY_CLASS_NORMAL\x10\x00\x12\"\n\x1e\x41PP_PRIORITY_CLASS_BEST_EFFORT\x10\x01\x12\x1f\n\x1b\x41PP_PRIORITY_CLASS_CRITICAL\x10\x02*i\n\x0c\x41ppProbeKind\x12\x1e\n\x1a\x41PP_PROBE_KIND_UNSPECIFIED\x10\x00\x12\x1b\n\x17\x41PP_PROBE_KIND_LIVENESS\x10\x01\x12\x1c\n\x18\x41PP_PROBE_KIND_READINESS\x10\x02*\x98\x01\n\x0c\x41ppProbeType\x12\x1e\n\x1a\x41PP_PROBE_TYPE_UNSPECIFIED\x10\x00\x12\x17\n\x13\x41PP_PROBE_TYPE_HTTP\x10\x01\x12\x16\n\x12\x41PP_PROBE_TYPE_TCP\x10\x02\x12\x17\n\x13\x41PP_PROBE_TYPE_EXEC\x10\x03\x12\x1e\n\x1a\x41PP_PROBE_TYPE_GUEST_AGENT\x10\x04*\x96\x01\n\x10\x41ppRestartPolicy\x12\"\n\x1e\x41PP_RESTART_POLICY_UNSPECIFIED\x10\x00\x12\x1d\n\x19\x41PP_RESTART_POLICY_ALWAYS\x10\x01\x12!\n\x1d\x41PP_RESTART_POLICY_ON_FAILURE\x10\x02\x12\x1c\n\x18\x41PP_RESTART_POLICY_NEVER\x10\x03\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
   ,
   dependencies=[config_dot_acipherinfo__pb2.DESCRIPTOR,config_dot_devcommon__pb2.DESCRIPTOR,config_dot_storage__pb2.DESCRIPTOR,config_dot_vm__pb2.DESCRIPTOR,config_dot_netconfig__pb2.DESCRIPTOR,])
 
+_APPPRIORITYCLASS = _descriptor.EnumDescriptor(
+  name='AppPriorityClass',
+  full_name='org.lfedge.eve.config.AppPriorityClass',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='APP_PRIORITY_CLASS_NORMAL', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PRIORITY_CLASS_BEST_EFFORT', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PRIORITY_CLASS_CRITICAL', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=1745,
+  serialized_end=1863,
+)
+_sym_db.RegisterEnumDescriptor(_APPPRIORITYCLASS)
+
+AppPriorityClass = enum_type_wrapper.EnumTypeWrapper(_APPPRIORITYCLASS)
+_APPPROBEKIND = _descriptor.EnumDescriptor(
+  name='AppProbeKind',
+  full_name='org.lfedge.eve.config.AppProbeKind',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_KIND_UNSPECIFIED', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_KIND_LIVENESS', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_KIND_READINESS', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=1865,
+  serialized_end=1970,
+)
+_sym_db.RegisterEnumDescriptor(_APPPROBEKIND)
+
+AppProbeKind = enum_type_wrapper.EnumTypeWrapper(_APPPROBEKIND)
+_APPPROBETYPE = _descriptor.EnumDescriptor(
+  name='AppProbeType',
+  full_name='org.lfedge.eve.config.AppProbeType',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_TYPE_UNSPECIFIED', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_TYPE_HTTP', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_TYPE_TCP', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_TYPE_EXEC', index=3, number=3,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_PROBE_TYPE_GUEST_AGENT', index=4, number=4,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=1973,
+  serialized_end=2125,
+)
+_sym_db.RegisterEnumDescriptor(_APPPROBETYPE)
+
+AppProbeType = enum_type_wrapper.EnumTypeWrapper(_APPPROBETYPE)
+_APPRESTARTPOLICY = _descriptor.EnumDescriptor(
+  name='AppRestartPolicy',
+  full_name='org.lfedge.eve.config.AppRestartPolicy',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='APP_RESTART_POLICY_UNSPECIFIED', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_RESTART_POLICY_ALWAYS', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_RESTART_POLICY_ON_FAILURE', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='APP_RESTART_POLICY_NEVER', index=3, number=3,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2128,
+  serialized_end=2278,
+)
+_sym_db.RegisterEnumDescriptor(_APPRESTARTPOLICY)
+
+AppRestartPolicy = enum_type_wrapper.EnumTypeWrapper(_APPRESTARTPOLICY)
+APP_PRIORITY_CLASS_NORMAL = 0
+APP_PRIORITY_CLASS_BEST_EFFORT = 1
+APP_PRIORITY_CLASS_CRITICAL = 2
+APP_PROBE_KIND_UNSPECIFIED = 0
+APP_PROBE_KIND_LIVENESS = 1
+APP_PROBE_KIND_READINESS = 2
+APP_PROBE_TYPE_UNSPECIFIED = 0
+APP_PROBE_TYPE_HTTP = 1
+APP_PROBE_TYPE_TCP = 2
+APP_PROBE_TYPE_EXEC = 3
+APP_PROBE_TYPE_GUEST_AGENT = 4
+APP_RESTART_POLICY_UNSPECIFIED = 0
+APP_RESTART_POLICY_ALWAYS = 1
+APP_RESTART_POLICY_ON_FAILURE = 2
+APP_RESTART_POLICY_NEVER = 3
 
 
 
@@ -176,6 +331,62 @@ _APPINSTANCECONFIG = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='probes', full_name='org.lfedge.eve.config.AppInstanceConfig.probes', index=14,
+      number=17, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='restartPolicy', full_name='org.lfedge.eve.config.AppInstanceConfig.restartPolicy', index=15,
+      number=18, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='dependsOn', full_name='org.lfedge.eve.config.AppInstanceConfig.dependsOn', index=16,
+      number=19, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='priority', full_name='org.lfedge.eve.config.AppInstanceConfig.priority', index=17,
+      number=20, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='usbDevices', full_name='org.lfedge.eve.config.AppInstanceConfig.usbDevices', index=18,
+      number=21, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='containers', full_name='org.lfedge.eve.config.AppInstanceConfig.containers', index=19,
+      number=22, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='vendorData', full_name='org.lfedge.eve.config.AppInstanceConfig.vendorData', index=20,
+      number=23, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='cloudInitNoCloud', full_name='org.lfedge.eve.config.AppInstanceConfig.cloudInitNoCloud', index=21,
+      number=24, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -189,7 +400,222 @@ _APPINSTANCECONFIG = _descriptor.Descriptor(
   oneofs=[
   ],
   serialized_start=215,
-  serialized_end=840,
+  serialized_end=1192,
+)
+
+
+_APPCONTAINER = _descriptor.Descriptor(
+  name='AppContainer',
+  full_name='org.lfedge.eve.config.AppContainer',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='name', full_name='org.lfedge.eve.config.AppContainer.name', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='image', full_name='org.lfedge.eve.config.AppContainer.image', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='init', full_name='org.lfedge.eve.config.AppContainer.init', index=2,
+      number=3, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='command', full_name='org.lfedge.eve.config.AppContainer.command', index=3,
+      number=4, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='environment', full_name='org.lfedge.eve.config.AppContainer.environment', index=4,
+      number=5, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1194,
+  serialized_end=1289,
+)
+
+
+_USBDEVICERULE = _descriptor.Descriptor(
+  name='UsbDeviceRule',
+  full_name='org.lfedge.eve.config.UsbDeviceRule',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='vendorId', full_name='org.lfedge.eve.config.UsbDeviceRule.vendorId', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='productId', full_name='org.lfedge.eve.config.UsbDeviceRule.productId', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='serial', full_name='org.lfedge.eve.config.UsbDeviceRule.serial', index=2,
+      number=3, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='portPath', full_name='org.lfedge.eve.config.UsbDeviceRule.portPath', index=3,
+      number=4, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1291,
+  serialized_end=1377,
+)
+
+
+_APPPROBE = _descriptor.Descriptor(
+  name='AppProbe',
+  full_name='org.lfedge.eve.config.AppProbe',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='kind', full_name='org.lfedge.eve.config.AppProbe.kind', index=0,
+      number=1, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='type', full_name='org.lfedge.eve.config.AppProbe.type', index=1,
+      number=2, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='networkAdapter', full_name='org.lfedge.eve.config.AppProbe.networkAdapter', index=2,
+      number=3, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='port', full_name='org.lfedge.eve.config.AppProbe.port', index=3,
+      number=4, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='httpPath', full_name='org.lfedge.eve.config.AppProbe.httpPath', index=4,
+      number=5, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='command', full_name='org.lfedge.eve.config.AppProbe.command', index=5,
+      number=6, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='initialDelay', full_name='org.lfedge.eve.config.AppProbe.initialDelay', index=6,
+      number=7, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='period', full_name='org.lfedge.eve.config.AppProbe.period', index=7,
+      number=8, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='timeout', full_name='org.lfedge.eve.config.AppProbe.timeout', index=8,
+      number=9, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='failureThreshold', full_name='org.lfedge.eve.config.AppProbe.failureThreshold', index=9,
+      number=10, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='successThreshold', full_name='org.lfedge.eve.config.AppProbe.successThreshold', index=10,
+      number=11, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1380,
+  serialized_end=1672,
 )
 
 
@@ -234,8 +660,8 @@ _VOLUMEREF = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=842,
-  serialized_end=911,
+  serialized_start=1674,
+  serialized_end=1743,
 )
 
 _APPINSTANCECONFIG.fields_by_name['uuidandversion'].message_type = config_dot_devcommon__pb2._UUIDANDVERSION
@@ -247,9 +673,23 @@ _APPINSTANCECONFIG.fields_by_name['restart'].message_type = _INSTANCEOPSCMD
 _APPINSTANCECONFIG.fields_by_name['purge'].message_type = _INSTANCEOPSCMD
 _APPINSTANCECONFIG.fields_by_name['cipherData'].message_type = config_dot_acipherinfo__pb2._CIPHERBLOCK
 _APPINSTANCECONFIG.fields_by_name['volumeRefList'].message_type = _VOLUMEREF
+_APPINSTANCECONFIG.fields_by_name['probes'].message_type = _APPPROBE
+_APPINSTANCECONFIG.fields_by_name['restartPolicy'].enum_type = _APPRESTARTPOLICY
+_APPINSTANCECONFIG.fields_by_name['priority'].enum_type = _APPPRIORITYCLASS
+_APPINSTANCECONFIG.fields_by_name['usbDevices'].message_type = _USBDEVICERULE
+_APPINSTANCECONFIG.fields_by_name['containers'].message_type = _APPCONTAINER
+_APPPROBE.fields_by_name['kind'].enum_type = _APPPROBEKIND
+_APPPROBE.fields_by_name['type'].enum_type = _APPPROBETYPE
 DESCRIPTOR.message_types_by_name['InstanceOpsCmd'] = _INSTANCEOPSCMD
 DESCRIPTOR.message_types_by_name['AppInstanceConfig'] = _APPINSTANCECONFIG
+DESCRIPTOR.message_types_by_name['AppContainer'] = _APPCONTAINER
+DESCRIPTOR.message_types_by_name['UsbDeviceRule'] = _USBDEVICERULE
+DESCRIPTOR.message_types_by_name['AppProbe'] = _APPPROBE
 DESCRIPTOR.message_types_by_name['VolumeRef'] = _VOLUMEREF
+DESCRIPTOR.enum_types_by_name['AppPriorityClass'] = _APPPRIORITYCLASS
+DESCRIPTOR.enum_types_by_name['AppProbeKind'] = _APPPROBEKIND
+DESCRIPTOR.enum_types_by_name['AppProbeType'] = _APPPROBETYPE
+DESCRIPTOR.enum_types_by_name['AppRestartPolicy'] = _APPRESTARTPOLICY
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
 
 InstanceOpsCmd = _reflection.GeneratedProtocolMessageType('InstanceOpsCmd', (_message.Message,), {
@@ -266,6 +706,27 @@ AppInstanceConfig = _reflection.GeneratedProtocolMessageType('AppInstanceConfig'
   })
 _sym_db.RegisterMessage(AppInstanceConfig)
 
+AppContainer = _reflection.GeneratedProtocolMessageType('AppContainer', (_message.Message,), {
+  'DESCRIPTOR' : _APPCONTAINER,
+  '__module__' : 'config.appconfig_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.AppContainer)
+  })
+_sym_db.RegisterMessage(AppContainer)
+
+UsbDeviceRule = _reflection.GeneratedProtocolMessageType('UsbDeviceRule', (_message.Message,), {
+  'DESCRIPTOR' : _USBDEVICERULE,
+  '__module__' : 'config.appconfig_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.UsbDeviceRule)
+  })
+_sym_db.RegisterMessage(UsbDeviceRule)
+
+AppProbe = _reflection.GeneratedProtocolMessageType('AppProbe', (_message.Message,), {
+  'DESCRIPTOR' : _APPPROBE,
+  '__module__' : 'config.appconfig_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.AppProbe)
+  })
+_sym_db.RegisterMessage(AppProbe)
+
 VolumeRef = _reflection.GeneratedProtocolMessageType('VolumeRef', (_message.Message,), {
   'DESCRIPTOR' : _VOLUMEREF,
   '__module__' : 'config.appconfig_pb2'
@@ -20,7 +20,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'\n\025org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/config',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x0f\x63onfig/fw.proto\x12\x15org.lfedge.eve.config\"\'\n\x08\x41\x43\x45Match\x12\x0c\n\x04type\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t\"\x84\x01\n\tACEAction\x12\x0c\n\x04\x64rop\x18\x01 \x01(\x08\x12\r\n\x05limit\x18\x02 \x01(\x08\x12\x11\n\tlimitrate\x18\x03 \x01(\r\x12\x11\n\tlimitunit\x18\x04 \x01(\t\x12\x12\n\nlimitburst\x18\x05 \x01(\r\x12\x0f\n\x07portmap\x18\x06 \x01(\x08\x12\x0f\n\x07\x61ppPort\x18\x07 \x01(\r\"\xb6\x01\n\x03\x41\x43\x45\x12\x30\n\x07matches\x18\x01 \x03(\x0b\x32\x1f.org.lfedge.eve.config.ACEMatch\x12\x31\n\x07\x61\x63tions\x18\x02 \x03(\x0b\x32 .org.lfedge.eve.config.ACEAction\x12\x0c\n\x04name\x18\x03 \x01(\t\x12\n\n\x02id\x18\x04 \x01(\x05\x12\x30\n\x03\x64ir\x18\x05 \x01(\x0e\x32#.org.lfedge.eve.config.ACEDirection*1\n\x0c\x41\x43\x45\x44irection\x12\x08\n\x04\x42OTH\x10\x00\x12\x0b\n\x07INGRESS\x10\x01\x12\n\n\x06\x45GRESS\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
+  serialized_pb=b'\n\x0f\x63onfig/fw.proto\x12\x15org.lfedge.eve.config\"\'\n\x08\x41\x43\x45Match\x12\x0c\n\x04type\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t\"\x9a\x01\n\tACEAction\x12\x0c\n\x04\x64rop\x18\x01 \x01(\x08\x12\r\n\x05limit\x18\x02 \x01(\x08\x12\x11\n\tlimitrate\x18\x03 \x01(\r\x12\x11\n\tlimitunit\x18\x04 \x01(\t\x12\x12\n\nlimitburst\x18\x05 \x01(\r\x12\x0f\n\x07portmap\x18\x06 \x01(\x08\x12\x0f\n\x07\x61ppPort\x18\x07 \x01(\r\x12\x14\n\x0cloadBalancer\x18\x08 \x01(\t\"\xb6\x01\n\x03\x41\x43\x45\x12\x30\n\x07matches\x18\x01 \x03(\x0b\x32\x1f.org.lfedge.eve.config.ACEMatch\x12\x31\n\x07\x61\x63tions\x18\x02 \x03(\x0b\x32 .org.lfedge.eve.config.ACEAction\x12\x0c\n\x04name\x18\x03 \x01(\t\x12\n\n\x02id\x18\x04 \x01(\x05\x12\x30\n\x03\x64ir\x18\x05 \x01(\x0e\x32#.org.lfedge.eve.config.ACEDirection*1\n\x0c\x41\x43\x45\x44irection\x12\x08\n\x04\x42OTH\x10\x00\x12\x0b\n\x07INGRESS\x10\x01\x12\n\n\x06\x45GRESS\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
 )
 
 _ACEDIRECTION = _descriptor.EnumDescriptor(
@@ -48,8 +48,8 @@ _ACEDIRECTION = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=403,
-  serialized_end=452,
+  serialized_start=425,
+  serialized_end=474,
 )
 _sym_db.RegisterEnumDescriptor(_ACEDIRECTION)
 
@@ -156,6 +156,13 @@ _ACEACTION = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='loadBalancer', full_name='org.lfedge.eve.config.ACEAction.loadBalancer', index=7,
+      number=8, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -169,7 +176,7 @@ _ACEACTION = _descriptor.Descriptor(
   oneofs=[
   ],
   serialized_start=84,
-  serialized_end=216,
+  serialized_end=238,
 )
 
 
@@ -228,8 +235,8 @@ _ACE = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=219,
-  serialized_end=401,
+  serialized_start=241,
+  serialized_end=423,
 )
 
 _ACE.fields_by_name['matches'].message_type = _ACEMATCH
@@ -2,6 +2,7 @@
 # Generated by the protocol buffer compiler.  DO NOT EDIT!
 # source: config/netconfig.proto
 
+from google.protobuf.internal import enum_type_wrapper
 from google.protobuf import descriptor as _descriptor
 from google.protobuf import message as _message
 from google.protobuf import reflection as _reflection
@@ -22,10 +23,44 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'\n\025org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/config',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x16\x63onfig/netconfig.proto\x12\x15org.lfedge.eve.config\x1a\x18\x63onfig/acipherinfo.proto\x1a\x0f\x63onfig/fw.proto\x1a\x13\x63onfig/netcmn.proto\"\x9f\x02\n\rNetworkConfig\x12\n\n\x02id\x18\x01 \x01(\t\x12\x30\n\x04type\x18\x05 \x01(\x0e\x32\".org.lfedge.eve.config.NetworkType\x12)\n\x02ip\x18\x06 \x01(\x0b\x32\x1d.org.lfedge.eve.config.ipspec\x12\x36\n\x03\x64ns\x18\x07 \x03(\x0b\x32).org.lfedge.eve.config.ZnetStaticDNSEntry\x12\x34\n\x08\x65ntProxy\x18\x08 \x01(\x0b\x32\".org.lfedge.eve.config.ProxyConfig\x12\x37\n\x08wireless\x18\n \x01(\x0b\x32%.org.lfedge.eve.config.WirelessConfig\"\xe1\x01\n\x0eNetworkAdapter\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x11\n\tnetworkId\x18\x03 \x01(\t\x12\x0c\n\x04\x61\x64\x64r\x18\x04 \x01(\t\x12\x10\n\x08hostname\x18\x05 \x01(\t\x12\x11\n\tcryptoEid\x18\n \x01(\t\x12\x15\n\rlispsignature\x18\x06 \x01(\t\x12\x0f\n\x07pemcert\x18\x07 \x01(\x0c\x12\x15\n\rpemprivatekey\x18\x08 \x01(\x0c\x12\x12\n\nmacAddress\x18\t \x01(\t\x12(\n\x04\x61\x63ls\x18( \x03(\x0b\x32\x1a.org.lfedge.eve.config.ACE\"\xb3\x01\n\x0eWirelessConfig\x12\x31\n\x04type\x18\x01 \x01(\x0e\x32#.org.lfedge.eve.config.WirelessType\x12:\n\x0b\x63\x65llularCfg\x18\x05 \x03(\x0b\x32%.org.lfedge.eve.config.CellularConfig\x12\x32\n\x07wifiCfg\x18\n \x03(\x0b\x32!.org.lfedge.eve.config.WifiConfig\"\x1d\n\x0e\x43\x65llularConfig\x12\x0b\n\x03\x41PN\x18\x01 \x01(\t\"\xb7\x02\n\nWifiConfig\x12\x10\n\x08wifiSSID\x18\x01 \x01(\t\x12\x37\n\tkeyScheme\x18\x02 \x01(\x0e\x32$.org.lfedge.eve.config.WiFiKeyScheme\x12\x10\n\x08identity\x18\x05 \x01(\t\x12\x10\n\x08password\x18\n \x01(\t\x12=\n\x06\x63rypto\x18\x14 \x01(\x0b\x32-.org.lfedge.eve.config.WifiConfig.cryptoblock\x12\x10\n\x08priority\x18\x19 \x01(\x05\x12\x36\n\ncipherData\x18\x1e \x01(\x0b\x32\".org.lfedge.eve.config.CipherBlock\x1a\x31\n\x0b\x63ryptoblock\x12\x10\n\x08identity\x18\x0b \x01(\t\x12\x10\n\x08password\x18\x0c \x01(\tB=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
+  serialized_pb=b'\n\x16\x63onfig/netconfig.proto\x12\x15org.lfedge.eve.config\x1a\x18\x63onfig/acipherinfo.proto\x1a\x0f\x63onfig/fw.proto\x1a\x13\x63onfig/netcmn.proto\"\xf0\x02\n\rNetworkConfig\x12\n\n\x02id\x18\x01 \x01(\t\x12\x30\n\x04type\x18\x05 \x01(\x0e\x32\".org.lfedge.eve.config.NetworkType\x12)\n\x02ip\x18\x06 \x01(\x0b\x32\x1d.org.lfedge.eve.config.ipspec\x12\x36\n\x03\x64ns\x18\x07 \x03(\x0b\x32).org.lfedge.eve.config.ZnetStaticDNSEntry\x12\x34\n\x08\x65ntProxy\x18\x08 \x01(\x0b\x32\".org.lfedge.eve.config.ProxyConfig\x12\x37\n\x08wireless\x18\n \x01(\x0b\x32%.org.lfedge.eve.config.WirelessConfig\x12\x31\n\x05\x64ot1x\x18\x0b \x01(\x0b\x32\".org.lfedge.eve.config.Dot1xConfig\x12\x1c\n\x14ipv6PrefixDelegation\x18\x0c \x01(\x08\"\xe1\x01\n\x0eNetworkAdapter\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x11\n\tnetworkId\x18\x03 \x01(\t\x12\x0c\n\x04\x61\x64\x64r\x18\x04 \x01(\t\x12\x10\n\x08hostname\x18\x05 \x01(\t\x12\x11\n\tcryptoEid\x18\n \x01(\t\x12\x15\n\rlispsignature\x18\x06 \x01(\t\x12\x0f\n\x07pemcert\x18\x07 \x01(\x0c\x12\x15\n\rpemprivatekey\x18\x08 \x01(\x0c\x12\x12\n\nmacAddress\x18\t \x01(\t\x12(\n\x04\x61\x63ls\x18( \x03(\x0b\x32\x1a.org.lfedge.eve.config.ACE\"\xb3\x01\n\x0eWirelessConfig\x12\x31\n\x04type\x18\x01 \x01(\x0e\x32#.org.lfedge.eve.config.WirelessType\x12:\n\x0b\x63\x65llularCfg\x18\x05 \x03(\x0b\x32%.org.lfedge.eve.config.CellularConfig\x12\x32\n\x07wifiCfg\x18\n \x03(\x0b\x32!.org.lfedge.eve.config.WifiConfig\"\x1d\n\x0e\x43\x65llularConfig\x12\x0b\n\x03\x41PN\x18\x01 \x01(\t\"\xb7\x02\n\nWifiConfig\x12\x10\n\x08wifiSSID\x18\x01 \x01(\t\x12\x37\n\tkeyScheme\x18\x02 \x01(\x0e\x32$.org.lfedge.eve.config.WiFiKeyScheme\x12\x10\n\x08identity\x18\x05 \x01(\t\x12\x10\n\x08password\x18\n \x01(\t\x12=\n\x06\x63rypto\x18\x14 \x01(\x0b\x32-.org.lfedge.eve.config.WifiConfig.cryptoblock\x12\x10\n\x08priority\x18\x19 \x01(\x05\x12\x36\n\ncipherData\x18\x1e \x01(\x0b\x32\".org.lfedge.eve.config.CipherBlock\x1a\x31\n\x0b\x63ryptoblock\x12\x10\n\x08identity\x18\x0b \x01(\t\x12\x10\n\x08password\x18\x0c \x01(\t\"\x82\x02\n\x0b\x44ot1xConfig\x12\x0e\n\x06\x65nable\x18\x01 \x01(\x08\x12\x38\n\teapMethod\x18\x02 \x01(\x0e\x32%.org.lfedge.eve.config.Dot1xEapMethod\x12\x10\n\x08identity\x18\x03 \x01(\t\x12\x19\n\x11\x61nonymousIdentity\x18\x04 \x01(\t\x12\x11\n\tcaCertPem\x18\x05 \x01(\x0c\x12\x1a\n\x12serverDomainSuffix\x18\x06 \x01(\t\x12\x15\n\rclientCertPem\x18\x07 \x01(\x0c\x12\x36\n\ncipherData\x18\n \x01(\x0b\x32\".org.lfedge.eve.config.CipherBlock*g\n\x0e\x44ot1xEapMethod\x12 \n\x1c\x44OT1X_EAP_METHOD_UNSPECIFIED\x10\x00\x12\x18\n\x14\x44OT1X_EAP_METHOD_TLS\x10\x01\x12\x19\n\x15\x44OT1X_EAP_METHOD_PEAP\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
   ,
   dependencies=[config_dot_acipherinfo__pb2.DESCRIPTOR,config_dot_fw__pb2.DESCRIPTOR,config_dot_netcmn__pb2.DESCRIPTOR,])
 
+_DOT1XEAPMETHOD = _descriptor.EnumDescriptor(
+  name='Dot1xEapMethod',
+  full_name='org.lfedge.eve.config.Dot1xEapMethod',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='DOT1X_EAP_METHOD_UNSPECIFIED', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='DOT1X_EAP_METHOD_TLS', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='DOT1X_EAP_METHOD_PEAP', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=1500,
+  serialized_end=1603,
+)
+_sym_db.RegisterEnumDescriptor(_DOT1XEAPMETHOD)
+
+Dot1xEapMethod = enum_type_wrapper.EnumTypeWrapper(_DOT1XEAPMETHOD)
+DOT1X_EAP_METHOD_UNSPECIFIED = 0
+DOT1X_EAP_METHOD_TLS = 1
+DOT1X_EAP_METHOD_PEAP = 2
 
 
 
@@ -79,6 +114,20 @@ _NETWORKCONFIG = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='dot1x', full_name='org.lfedge.eve.config.NetworkConfig.dot1x', index=6,
+      number=11, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='ipv6PrefixDelegation', full_name='org.lfedge.eve.config.NetworkConfig.ipv6PrefixDelegation', index=7,
+      number=12, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -92,7 +141,7 @@ _NETWORKCONFIG = _descriptor.Descriptor(
   oneofs=[
   ],
   serialized_start=114,
-  serialized_end=401,
+  serialized_end=482,
 )
 
 
@@ -186,8 +235,8 @@ _NETWORKADAPTER = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=404,
-  serialized_end=629,
+  serialized_start=485,
+  serialized_end=710,
 )
 
 
@@ -232,8 +281,8 @@ _WIRELESSCONFIG = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=632,
-  serialized_end=811,
+  serialized_start=713,
+  serialized_end=892,
 )
 
 
@@ -264,8 +313,8 @@ _CELLULARCONFIG = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=813,
-  serialized_end=842,
+  serialized_start=894,
+  serialized_end=923,
 )
 
 
@@ -303,8 +352,8 @@ _WIFICONFIG_CRYPTOBLOCK = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1107,
-  serialized_end=1156,
+  serialized_start=1188,
+  serialized_end=1237,
 )
 
 _WIFICONFIG = _descriptor.Descriptor(
@@ -376,8 +425,89 @@ _WIFICONFIG = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=845,
-  serialized_end=1156,
+  serialized_start=926,
+  serialized_end=1237,
+)
+
+
+_DOT1XCONFIG = _descriptor.Descriptor(
+  name='Dot1xConfig',
+  full_name='org.lfedge.eve.config.Dot1xConfig',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='enable', full_name='org.lfedge.eve.config.Dot1xConfig.enable', index=0,
+      number=1, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='eapMethod', full_name='org.lfedge.eve.config.Dot1xConfig.eapMethod', index=1,
+      number=2, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='identity', full_name='org.lfedge.eve.config.Dot1xConfig.identity', index=2,
+      number=3, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='anonymousIdentity', full_name='org.lfedge.eve.config.Dot1xConfig.anonymousIdentity', index=3,
+      number=4, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='caCertPem', full_name='org.lfedge.eve.config.Dot1xConfig.caCertPem', index=4,
+      number=5, type=12, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"",
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='serverDomainSuffix', full_name='org.lfedge.eve.config.Dot1xConfig.serverDomainSuffix', index=5,
+      number=6, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='clientCertPem', full_name='org.lfedge.eve.config.Dot1xConfig.clientCertPem', index=6,
+      number=7, type=12, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"",
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='cipherData', full_name='org.lfedge.eve.config.Dot1xConfig.cipherData', index=7,
+      number=10, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1240,
+  serialized_end=1498,
 )
 
 _NETWORKCONFIG.fields_by_name['type'].enum_type = config_dot_netcmn__pb2._NETWORKTYPE
@@ -385,6 +515,7 @@ _NETWORKCONFIG.fields_by_name['ip'].message_type = config_dot_netcmn__pb2._IPSPE
 _NETWORKCONFIG.fields_by_name['dns'].message_type = config_dot_netcmn__pb2._ZNETSTATICDNSENTRY
 _NETWORKCONFIG.fields_by_name['entProxy'].message_type = config_dot_netcmn__pb2._PROXYCONFIG
 _NETWORKCONFIG.fields_by_name['wireless'].message_type = _WIRELESSCONFIG
+_NETWORKCONFIG.fields_by_name['dot1x'].message_type = _DOT1XCONFIG
 _NETWORKADAPTER.fields_by_name['acls'].message_type = config_dot_fw__pb2._ACE
 _WIRELESSCONFIG.fields_by_name['type'].enum_type = config_dot_netcmn__pb2._WIRELESSTYPE
 _WIRELESSCONFIG.fields_by_name['cellularCfg'].message_type = _CELLULARCONFIG
@@ -393,11 +524,15 @@ _WIFICONFIG_CRYPTOBLOCK.containing_type = _WIFICONFIG
 _WIFICONFIG.fields_by_name['keyScheme'].enum_type = config_dot_netcmn__pb2._WIFIKEYSCHEME
 _WIFICONFIG.fields_by_name['crypto'].message_type = _WIFICONFIG_CRYPTOBLOCK
 _WIFICONFIG.fields_by_name['cipherData'].message_type = config_dot_acipherinfo__pb2._CIPHERBLOCK
+_DOT1XCONFIG.fields_by_name['eapMethod'].enum_type = _DOT1XEAPMETHOD
+_DOT1XCONFIG.fields_by_name['cipherData'].message_type = config_dot_acipherinfo__pb2._CIPHERBLOCK
 DESCRIPTOR.message_types_by_name['NetworkConfig'] = _NETWORKCONFIG
 DESCRIPTOR.message_types_by_name['NetworkAdapter'] = _NETWORKADAPTER
 DESCRIPTOR.message_types_by_name['WirelessConfig'] = _WIRELESSCONFIG
 DESCRIPTOR.message_types_by_name['CellularConfig'] = _CELLULARCONFIG
 DESCRIPTOR.message_types_by_name['WifiConfig'] = _WIFICONFIG
+DESCRIPTOR.message_types_by_name['Dot1xConfig'] = _DOT1XCONFIG
+DESCRIPTOR.enum_types_by_name['Dot1xEapMethod'] = _DOT1XEAPMETHOD
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
 
 NetworkConfig = _reflection.GeneratedProtocolMessageType('NetworkConfig', (_message.Message,), {
@@ -443,6 +578,13 @@ WifiConfig = _reflection.GeneratedProtocolMessageType('WifiConfig', (_message.Me
 _sym_db.RegisterMessage(WifiConfig)
 _sym_db.RegisterMessage(WifiConfig.cryptoblock)
 
+Dot1xConfig = _reflection.GeneratedProtocolMessageType('Dot1xConfig', (_message.Message,), {
+  'DESCRIPTOR' : _DOT1XCONFIG,
+  '__module__' : 'config.netconfig_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.Dot1xConfig)
+  })
+_sym_db.RegisterMessage(Dot1xConfig)
+
 
 DESCRIPTOR._options = None
 # @@protoc_insertion_point(module_scope)
@@ -22,7 +22,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'\n\025org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/config',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x14\x63onfig/netinst.proto\x12\x15org.lfedge.eve.config\x1a\x16\x63onfig/devcommon.proto\x1a\x13\x63onfig/netcmn.proto\"\xb3\x01\n\x1bNetworkInstanceOpaqueConfig\x12\x0f\n\x07oconfig\x18\x01 \x01(\t\x12\x44\n\nlispConfig\x18\x02 \x01(\x0b\x32\x30.org.lfedge.eve.config.NetworkInstanceLispConfig\x12=\n\x04type\x18\x03 \x01(\x0e\x32/.org.lfedge.eve.config.ZNetworkOpaqueConfigType\"l\n\x0eZcServicePoint\x12\x34\n\x06zsType\x18\x03 \x01(\x0e\x32$.org.lfedge.eve.config.ZcServiceType\x12\x10\n\x08NameOrIp\x18\x01 \x01(\t\x12\x12\n\nCredential\x18\x02 \x01(\t\"\xe1\x01\n\x19NetworkInstanceLispConfig\x12\x36\n\x07LispMSs\x18\x01 \x03(\x0b\x32%.org.lfedge.eve.config.ZcServicePoint\x12\x16\n\x0eLispInstanceId\x18\x02 \x01(\r\x12\x10\n\x08\x61llocate\x18\x03 \x01(\x08\x12\x15\n\rexportprivate\x18\x04 \x01(\x08\x12\x18\n\x10\x61llocationprefix\x18\x05 \x01(\x0c\x12\x1b\n\x13\x61llocationprefixlen\x18\x06 \x01(\r\x12\x14\n\x0c\x65xperimental\x18\x14 \x01(\x08\"\xbe\x03\n\x15NetworkInstanceConfig\x12=\n\x0euuidandversion\x18\x01 \x01(\x0b\x32%.org.lfedge.eve.config.UUIDandVersion\x12\x13\n\x0b\x64isplayname\x18\x02 \x01(\t\x12\x39\n\x08instType\x18\x04 \x01(\x0e\x32\'.org.lfedge.eve.config.ZNetworkInstType\x12\x10\n\x08\x61\x63tivate\x18\x05 \x01(\x08\x12,\n\x04port\x18\x14 \x01(\x0b\x32\x1e.org.lfedge.eve.config.Adapter\x12?\n\x03\x63\x66g\x18\x1e \x01(\x0b\x32\x32.org.lfedge.eve.config.NetworkInstanceOpaqueConfig\x12\x32\n\x06ipType\x18\' \x01(\x0e\x32\".org.lfedge.eve.config.AddressType\x12)\n\x02ip\x18( \x01(\x0b\x32\x1d.org.lfedge.eve.config.ipspec\x12\x36\n\x03\x64ns\x18) \x03(\x0b\x32).org.lfedge.eve.config.ZnetStaticDNSEntry*\xb3\x01\n\x10ZNetworkInstType\x12\x11\n\rZNetInstFirst\x10\x00\x12\x12\n\x0eZnetInstSwitch\x10\x01\x12\x11\n\rZnetInstLocal\x10\x02\x12\x11\n\rZnetInstCloud\x10\x03\x12\x10\n\x0cZnetInstMesh\x10\x04\x12\x14\n\x10ZnetInstHoneyPot\x10\x05\x12\x17\n\x13ZnetInstTransparent\x10\x06\x12\x11\n\x0cZNetInstLast\x10\xff\x01*W\n\x0b\x41\x64\x64ressType\x12\t\n\x05\x46irst\x10\x00\x12\x08\n\x04IPV4\x10\x01\x12\x08\n\x04IPV6\x10\x02\x12\x0e\n\nCryptoIPV4\x10\x03\x12\x0e\n\nCryptoIPV6\x10\x04\x12\t\n\x04Last\x10\xff\x01*C\n\x18ZNetworkOpaqueConfigType\x12\x12\n\x0eZNetOConfigVPN\x10\x00\x12\x13\n\x0fZNetOConfigLisp\x10\x01*G\n\rZcServiceType\x12\x14\n\x10zcloudInvalidSrv\x10\x00\x12\r\n\tmapServer\x10\x01\x12\x11\n\rsupportServer\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
+  serialized_pb=b'\n\x14\x63onfig/netinst.proto\x12\x15org.lfedge.eve.config\x1a\x16\x63onfig/devcommon.proto\x1a\x13\x63onfig/netcmn.proto\"\xb3\x01\n\x1bNetworkInstanceOpaqueConfig\x12\x0f\n\x07oconfig\x18\x01 \x01(\t\x12\x44\n\nlispConfig\x18\x02 \x01(\x0b\x32\x30.org.lfedge.eve.config.NetworkInstanceLispConfig\x12=\n\x04type\x18\x03 \x01(\x0e\x32/.org.lfedge.eve.config.ZNetworkOpaqueConfigType\"l\n\x0eZcServicePoint\x12\x34\n\x06zsType\x18\x03 \x01(\x0e\x32$.org.lfedge.eve.config.ZcServiceType\x12\x10\n\x08NameOrIp\x18\x01 \x01(\t\x12\x12\n\nCredential\x18\x02 \x01(\t\"\xe1\x01\n\x19NetworkInstanceLispConfig\x12\x36\n\x07LispMSs\x18\x01 \x03(\x0b\x32%.org.lfedge.eve.config.ZcServicePoint\x12\x16\n\x0eLispInstanceId\x18\x02 \x01(\r\x12\x10\n\x08\x61llocate\x18\x03 \x01(\x08\x12\x15\n\rexportprivate\x18\x04 \x01(\x08\x12\x18\n\x10\x61llocationprefix\x18\x05 \x01(\x0c\x12\x1b\n\x13\x61llocationprefixlen\x18\x06 \x01(\r\x12\x14\n\x0c\x65xperimental\x18\x14 \x01(\x08\"m\n\x13IpfixExporterConfig\x12\x11\n\tcollector\x18\x01 \x01(\t\x12\x0c\n\x04port\x18\x02 \x01(\r\x12\x1b\n\x13observationDomainId\x18\x03 \x01(\r\x12\x18\n\x10\x65nterpriseNumber\x18\x04 \x01(\r\"\x82\x01\n\nIpv6Config\x12=\n\naddressing\x18\x01 \x01(\x0e\x32).org.lfedge.eve.config.Ipv6AddressingMode\x12\x35\n\x06uplink\x18\x02 \x01(\x0e\x32%.org.lfedge.eve.config.Ipv6UplinkMode\"\xc6\x01\n\x17LoadBalancerHealthCheck\x12@\n\x04type\x18\x01 \x01(\x0e\x32\x32.org.lfedge.eve.config.LoadBalancerHealthCheckType\x12\x10\n\x08interval\x18\x02 \x01(\r\x12\x0f\n\x07timeout\x18\x03 \x01(\r\x12\x18\n\x10healthyThreshold\x18\x04 \x01(\r\x12\x1a\n\x12unhealthyThreshold\x18\x05 \x01(\r\x12\x10\n\x08httpPath\x18\x06 \x01(\t\"\xa8\x01\n\x12LoadBalancerConfig\x12\x0c\n\x04name\x18\x01 \x01(\t\x12?\n\talgorithm\x18\x02 \x01(\x0e\x32,.org.lfedge.eve.config.LoadBalancerAlgorithm\x12\x43\n\x0bhealthCheck\x18\x03 \x01(\x0b\x32..org.lfedge.eve.config.LoadBalancerHealthCheck\"\xec\x04\n\x15NetworkInstanceConfig\x12=\n\x0euuidandversion\x18\x01 \x01(\x0b\x32%.org.lfedge.eve.config.UUIDandVersion\x12\x13\n\x0b\x64isplayname\x18\x02 \x01(\t\x12\x39\n\x08instType\x18\x04 \x01(\x0e\x32\'.org.lfedge.eve.config.ZNetworkInstType\x12\x10\n\x08\x61\x63tivate\x18\x05 \x01(\x08\x12,\n\x04port\x18\x14 \x01(\x0b\x32\x1e.org.lfedge.eve.config.Adapter\x12?\n\x03\x63\x66g\x18\x1e \x01(\x0b\x32\x32.org.lfedge.eve.config.NetworkInstanceOpaqueConfig\x12\x32\n\x06ipType\x18\' \x01(\x0e\x32\".org.lfedge.eve.config.AddressType\x12)\n\x02ip\x18( \x01(\x0b\x32\x1d.org.lfedge.eve.config.ipspec\x12\x36\n\x03\x64ns\x18) \x03(\x0b\x32).org.lfedge.eve.config.ZnetStaticDNSEntry\x12\x39\n\x05ipfix\x18* \x01(\x0b\x32*.org.lfedge.eve.config.IpfixExporterConfig\x12/\n\x04ipv6\x18+ \x01(\x0b\x32!.org.lfedge.eve.config.Ipv6Config\x12@\n\rloadBalancers\x18, \x03(\x0b\x32).org.lfedge.eve.config.LoadBalancerConfig*\xb3\x01\n\x10ZNetworkInstType\x12\x11\n\rZNetInstFirst\x10\x00\x12\x12\n\x0eZnetInstSwitch\x10\x01\x12\x11\n\rZnetInstLocal\x10\x02\x12\x11\n\rZnetInstCloud\x10\x03\x12\x10\n\x0cZnetInstMesh\x10\x04\x12\x14\n\x10ZnetInstHoneyPot\x10\x05\x12\x17\n\x13ZnetInstTransparent\x10\x06\x12\x11\n\x0cZNetInstLast\x10\xff\x01*W\n\x0b\x41\x64\x64ressType\x12\t\n\x05\x46irst\x10\x00\x12\x08\n\x04IPV4\x10\x01\x12\x08\n\x04IPV6\x10\x02\x12\x0e\n\nCryptoIPV4\x10\x03\x12\x0e\n\nCryptoIPV6\x10\x04\x12\t\n\x04Last\x10\xff\x01*C\n\x18ZNetworkOpaqueConfigType\x12\x12\n\x0eZNetOConfigVPN\x10\x00\x12\x13\n\x0fZNetOConfigLisp\x10\x01*G\n\rZcServiceType\x12\x14\n\x10zcloudInvalidSrv\x10\x00\x12\r\n\tmapServer\x10\x01\x12\x11\n\rsupportServer\x10\x02*K\n\x12Ipv6AddressingMode\x12\x1a\n\x16IPV6_ADDRESSING_DHCPV6\x10\x00\x12\x19\n\x15IPV6_ADDRESSING_SLAAC\x10\x01*J\n\x0eIpv6UplinkMode\x12\x15\n\x11IPV6_UPLINK_NAT66\x10\x00\x12!\n\x1dIPV6_UPLINK_PREFIX_DELEGATION\x10\x01*S\n\x15LoadBalancerAlgorithm\x12\x1c\n\x18LB_ALGORITHM_ROUND_ROBIN\x10\x00\x12\x1c\n\x18LB_ALGORITHM_SOURCE_HASH\x10\x01*j\n\x1bLoadBalancerHealthCheckType\x12\x18\n\x14LB_HEALTH_CHECK_NONE\x10\x00\x12\x17\n\x13LB_HEALTH_CHECK_TCP\x10\x01\x12\x18\n\x14LB_HEALTH_CHECK_HTTP\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
   ,
   dependencies=[config_dot_devcommon__pb2.DESCRIPTOR,config_dot_netcmn__pb2.DESCRIPTOR,])
 
@@ -76,8 +76,8 @@ _ZNETWORKINSTTYPE = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=1062,
-  serialized_end=1241,
+  serialized_start=1852,
+  serialized_end=2031,
 )
 _sym_db.RegisterEnumDescriptor(_ZNETWORKINSTTYPE)
 
@@ -122,8 +122,8 @@ _ADDRESSTYPE = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=1243,
-  serialized_end=1330,
+  serialized_start=2033,
+  serialized_end=2120,
 )
 _sym_db.RegisterEnumDescriptor(_ADDRESSTYPE)
 
@@ -148,8 +148,8 @@ _ZNETWORKOPAQUECONFIGTYPE = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=1332,
-  serialized_end=1399,
+  serialized_start=2122,
+  serialized_end=2189,
 )
 _sym_db.RegisterEnumDescriptor(_ZNETWORKOPAQUECONFIGTYPE)
 
@@ -179,12 +179,121 @@ _ZCSERVICETYPE = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=1401,
-  serialized_end=1472,
+  serialized_start=2191,
+  serialized_end=2262,
 )
 _sym_db.RegisterEnumDescriptor(_ZCSERVICETYPE)
 
 ZcServiceType = enum_type_wrapper.EnumTypeWrapper(_ZCSERVICETYPE)
+_IPV6ADDRESSINGMODE = _descriptor.EnumDescriptor(
+  name='Ipv6AddressingMode',
+  full_name='org.lfedge.eve.config.Ipv6AddressingMode',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='IPV6_ADDRESSING_DHCPV6', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='IPV6_ADDRESSING_SLAAC', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2264,
+  serialized_end=2339,
+)
+_sym_db.RegisterEnumDescriptor(_IPV6ADDRESSINGMODE)
+
+Ipv6AddressingMode = enum_type_wrapper.EnumTypeWrapper(_IPV6ADDRESSINGMODE)
+_IPV6UPLINKMODE = _descriptor.EnumDescriptor(
+  name='Ipv6UplinkMode',
+  full_name='org.lfedge.eve.config.Ipv6UplinkMode',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='IPV6_UPLINK_NAT66', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='IPV6_UPLINK_PREFIX_DELEGATION', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2341,
+  serialized_end=2415,
+)
+_sym_db.RegisterEnumDescriptor(_IPV6UPLINKMODE)
+
+Ipv6UplinkMode = enum_type_wrapper.EnumTypeWrapper(_IPV6UPLINKMODE)
+_LOADBALANCERALGORITHM = _descriptor.EnumDescriptor(
+  name='LoadBalancerAlgorithm',
+  full_name='org.lfedge.eve.config.LoadBalancerAlgorithm',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='LB_ALGORITHM_ROUND_ROBIN', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='LB_ALGORITHM_SOURCE_HASH', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2417,
+  serialized_end=2500,
+)
+_sym_db.RegisterEnumDescriptor(_LOADBALANCERALGORITHM)
+
+LoadBalancerAlgorithm = enum_type_wrapper.EnumTypeWrapper(_LOADBALANCERALGORITHM)
+_LOADBALANCERHEALTHCHECKTYPE = _descriptor.EnumDescriptor(
+  name='LoadBalancerHealthCheckType',
+  full_name='org.lfedge.eve.config.LoadBalancerHealthCheckType',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='LB_HEALTH_CHECK_NONE', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='LB_HEALTH_CHECK_TCP', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='LB_HEALTH_CHECK_HTTP', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2502,
+  serialized_end=2608,
+)
+_sym_db.RegisterEnumDescriptor(_LOADBALANCERHEALTHCHECKTYPE)
+
+LoadBalancerHealthCheckType = enum_type_wrapper.EnumTypeWrapper(_LOADBALANCERHEALTHCHECKTYPE)
 ZNetInstFirst = 0
 ZnetInstSwitch = 1
 ZnetInstLocal = 2
@@ -204,6 +313,15 @@ ZNetOConfigLisp = 1
 zcloudInvalidSrv = 0
 mapServer = 1
 supportServer = 2
+IPV6_ADDRESSING_DHCPV6 = 0
+IPV6_ADDRESSING_SLAAC = 1
+IPV6_UPLINK_NAT66 = 0
+IPV6_UPLINK_PREFIX_DELEGATION = 1
+LB_ALGORITHM_ROUND_ROBIN = 0
+LB_ALGORITHM_SOURCE_HASH = 1
+LB_HEALTH_CHECK_NONE = 0
+LB_HEALTH_CHECK_TCP = 1
+LB_HEALTH_CHECK_HTTP = 2
 
 
 
@@ -373,6 +491,211 @@ _NETWORKINSTANCELISPCONFIG = _descriptor.Descriptor(
 )
 
 
+_IPFIXEXPORTERCONFIG = _descriptor.Descriptor(
+  name='IpfixExporterConfig',
+  full_name='org.lfedge.eve.config.IpfixExporterConfig',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='collector', full_name='org.lfedge.eve.config.IpfixExporterConfig.collector', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='port', full_name='org.lfedge.eve.config.IpfixExporterConfig.port', index=1,
+      number=2, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='observationDomainId', full_name='org.lfedge.eve.config.IpfixExporterConfig.observationDomainId', index=2,
+      number=3, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='enterpriseNumber', full_name='org.lfedge.eve.config.IpfixExporterConfig.enterpriseNumber', index=3,
+      number=4, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=612,
+  serialized_end=721,
+)
+
+
+_IPV6CONFIG = _descriptor.Descriptor(
+  name='Ipv6Config',
+  full_name='org.lfedge.eve.config.Ipv6Config',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='addressing', full_name='org.lfedge.eve.config.Ipv6Config.addressing', index=0,
+      number=1, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='uplink', full_name='org.lfedge.eve.config.Ipv6Config.uplink', index=1,
+      number=2, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=724,
+  serialized_end=854,
+)
+
+
+_LOADBALANCERHEALTHCHECK = _descriptor.Descriptor(
+  name='LoadBalancerHealthCheck',
+  full_name='org.lfedge.eve.config.LoadBalancerHealthCheck',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='type', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.type', index=0,
+      number=1, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='interval', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.interval', index=1,
+      number=2, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='timeout', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.timeout', index=2,
+      number=3, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='healthyThreshold', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.healthyThreshold', index=3,
+      number=4, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='unhealthyThreshold', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.unhealthyThreshold', index=4,
+      number=5, type=13, cpp_type=3, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='httpPath', full_name='org.lfedge.eve.config.LoadBalancerHealthCheck.httpPath', index=5,
+      number=6, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=857,
+  serialized_end=1055,
+)
+
+
+_LOADBALANCERCONFIG = _descriptor.Descriptor(
+  name='LoadBalancerConfig',
+  full_name='org.lfedge.eve.config.LoadBalancerConfig',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='name', full_name='org.lfedge.eve.config.LoadBalancerConfig.name', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='algorithm', full_name='org.lfedge.eve.config.LoadBalancerConfig.algorithm', index=1,
+      number=2, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='healthCheck', full_name='org.lfedge.eve.config.LoadBalancerConfig.healthCheck', index=2,
+      number=3, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1058,
+  serialized_end=1226,
+)
+
+
 _NETWORKINSTANCECONFIG = _descriptor.Descriptor(
   name='NetworkInstanceConfig',
   full_name='org.lfedge.eve.config.NetworkInstanceConfig',
@@ -444,6 +767,27 @@ _NETWORKINSTANCECONFIG = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='ipfix', full_name='org.lfedge.eve.config.NetworkInstanceConfig.ipfix', index=9,
+      number=42, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='ipv6', full_name='org.lfedge.eve.config.NetworkInstanceConfig.ipv6', index=10,
+      number=43, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='loadBalancers', full_name='org.lfedge.eve.config.NetworkInstanceConfig.loadBalancers', index=11,
+      number=44, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -456,14 +800,19 @@ _NETWORKINSTANCECONFIG = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=613,
-  serialized_end=1059,
+  serialized_start=1229,
+  serialized_end=1849,
 )
 
 _NETWORKINSTANCEOPAQUECONFIG.fields_by_name['lispConfig'].message_type = _NETWORKINSTANCELISPCONFIG
 _NETWORKINSTANCEOPAQUECONFIG.fields_by_name['type'].enum_type = _ZNETWORKOPAQUECONFIGTYPE
 _ZCSERVICEPOINT.fields_by_name['zsType'].enum_type = _ZCSERVICETYPE
 _NETWORKINSTANCELISPCONFIG.fields_by_name['LispMSs'].message_type = _ZCSERVICEPOINT
+_IPV6CONFIG.fields_by_name['addressing'].enum_type = _IPV6ADDRESSINGMODE
+_IPV6CONFIG.fields_by_name['uplink'].enum_type = _IPV6UPLINKMODE
+_LOADBALANCERHEALTHCHECK.fields_by_name['type'].enum_type = _LOADBALANCERHEALTHCHECKTYPE
+_LOADBALANCERCONFIG.fields_by_name['algorithm'].enum_type = _LOADBALANCERALGORITHM
+_LOADBALANCERCONFIG.fields_by_name['healthCheck'].message_type = _LOADBALANCERHEALTHCHECK
 _NETWORKINSTANCECONFIG.fields_by_name['uuidandversion'].message_type = config_dot_devcommon__pb2._UUIDANDVERSION
 _NETWORKINSTANCECONFIG.fields_by_name['instType'].enum_type = _ZNETWORKINSTTYPE
 _NETWORKINSTANCECONFIG.fields_by_name['port'].message_type = config_dot_devcommon__pb2._ADAPTER
@@ -471,14 +820,25 @@ _NETWORKINSTANCECONFIG.fields_by_name['cfg'].message_type = _NETWORKINSTANCEOPAQ
 _NETWORKINSTANCECONFIG.fields_by_name['ipType'].enum_type = _ADDRESSTYPE
 _NETWORKINSTANCECONFIG.fields_by_name['ip'].message_type = config_dot_netcmn__pb2._IPSPEC
 _NETWORKINSTANCECONFIG.fields_by_name['dns'].message_type = config_dot_netcmn__pb2._ZNETSTATICDNSENTRY
+_NETWORKINSTANCECONFIG.fields_by_name['ipfix'].message_type = _IPFIXEXPORTERCONFIG
+_NETWORKINSTANCECONFIG.fields_by_name['ipv6'].message_type = _IPV6CONFIG
+_NETWORKINSTANCECONFIG.fields_by_name['loadBalancers'].message_type = _LOADBALANCERCONFIG
 DESCRIPTOR.message_types_by_name['NetworkInstanceOpaqueConfig'] = _NETWORKINSTANCEOPAQUECONFIG
 DESCRIPTOR.message_types_by_name['ZcServicePoint'] = _ZCSERVICEPOINT
 DESCRIPTOR.message_types_by_name['NetworkInstanceLispConfig'] = _NETWORKINSTANCELISPCONFIG
+DESCRIPTOR.message_types_by_name['IpfixExporterConfig'] = _IPFIXEXPORTERCONFIG
+DESCRIPTOR.message_types_by_name['Ipv6Config'] = _IPV6CONFIG
+DESCRIPTOR.message_types_by_name['LoadBalancerHealthCheck'] = _LOADBALANCERHEALTHCHECK
+DESCRIPTOR.message_types_by_name['LoadBalancerConfig'] = _LOADBALANCERCONFIG
 DESCRIPTOR.message_types_by_name['NetworkInstanceConfig'] = _NETWORKINSTANCECONFIG
 DESCRIPTOR.enum_types_by_name['ZNetworkInstType'] = _ZNETWORKINSTTYPE
 DESCRIPTOR.enum_types_by_name['AddressType'] = _ADDRESSTYPE
 DESCRIPTOR.enum_types_by_name['ZNetworkOpaqueConfigType'] = _ZNETWORKOPAQUECONFIGTYPE
 DESCRIPTOR.enum_types_by_name['ZcServiceType'] = _ZCSERVICETYPE
+DESCRIPTOR.enum_types_by_name['Ipv6AddressingMode'] = _IPV6ADDRESSINGMODE
+DESCRIPTOR.enum_types_by_name['Ipv6UplinkMode'] = _IPV6UPLINKMODE
+DESCRIPTOR.enum_types_by_name['LoadBalancerAlgorithm'] = _LOADBALANCERALGORITHM
+DESCRIPTOR.enum_types_by_name['LoadBalancerHealthCheckType'] = _LOADBALANCERHEALTHCHECKTYPE
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
 
 NetworkInstanceOpaqueConfig = _reflection.GeneratedProtocolMessageType('NetworkInstanceOpaqueConfig', (_message.Message,), {
@@ -502,6 +862,34 @@ NetworkInstanceLispConfig = _reflection.GeneratedProtocolMessageType('NetworkIns
   })
 _sym_db.RegisterMessage(NetworkInstanceLispConfig)
 
+IpfixExporterConfig = _reflection.GeneratedProtocolMessageType('IpfixExporterConfig', (_message.Message,), {
+  'DESCRIPTOR' : _IPFIXEXPORTERCONFIG,
+  '__module__' : 'config.netinst_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.IpfixExporterConfig)
+  })
+_sym_db.RegisterMessage(IpfixExporterConfig)
+
+Ipv6Config = _reflection.GeneratedProtocolMessageType('Ipv6Config', (_message.Message,), {
+  'DESCRIPTOR' : _IPV6CONFIG,
+  '__module__' : 'config.netinst_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.Ipv6Config)
+  })
+_sym_db.RegisterMessage(Ipv6Config)
+
+LoadBalancerHealthCheck = _reflection.GeneratedProtocolMessageType('LoadBalancerHealthCheck', (_message.Message,), {
+  'DESCRIPTOR' : _LOADBALANCERHEALTHCHECK,
+  '__module__' : 'config.netinst_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.LoadBalancerHealthCheck)
+  })
+_sym_db.RegisterMessage(LoadBalancerHealthCheck)
+
+LoadBalancerConfig = _reflection.GeneratedProtocolMessageType('LoadBalancerConfig', (_message.Message,), {
+  'DESCRIPTOR' : _LOADBALANCERCONFIG,
+  '__module__' : 'config.netinst_pb2'
+  # @@protoc_insertion_point(class_scope:org.lfedge.eve.config.LoadBalancerConfig)
+  })
+_sym_db.RegisterMessage(LoadBalancerConfig)
+
 NetworkInstanceConfig = _reflection.GeneratedProtocolMessageType('NetworkInstanceConfig', (_message.Message,), {
   'DESCRIPTOR' : _NETWORKINSTANCECONFIG,
   '__module__' : 'config.netinst_pb2'
@@ -20,7 +20,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'\n\025org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/config',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x0f\x63onfig/vm.proto\x12\x15org.lfedge.eve.config\"\xe6\x02\n\x08VmConfig\x12\x0e\n\x06kernel\x18\x01 \x01(\t\x12\x0f\n\x07ramdisk\x18\x02 \x01(\t\x12\x0e\n\x06memory\x18\x03 \x01(\r\x12\x0e\n\x06maxmem\x18\x04 \x01(\r\x12\r\n\x05vcpus\x18\x05 \x01(\r\x12\x0f\n\x07maxcpus\x18\x06 \x01(\r\x12\x0f\n\x07rootdev\x18\x07 \x01(\t\x12\x11\n\textraargs\x18\x08 \x01(\t\x12\x12\n\nbootloader\x18\t \x01(\t\x12\x0c\n\x04\x63pus\x18\n \x01(\t\x12\x12\n\ndevicetree\x18\x0b \x01(\t\x12\r\n\x05\x64tdev\x18\x0c \x03(\t\x12\x0c\n\x04irqs\x18\r \x03(\r\x12\r\n\x05iomem\x18\x0e \x03(\t\x12\x39\n\x12virtualizationMode\x18\x0f \x01(\x0e\x32\x1d.org.lfedge.eve.config.VmMode\x12\x11\n\tenableVnc\x18\x10 \x01(\x08\x12\x12\n\nvncDisplay\x18\x11 \x01(\r\x12\x11\n\tvncPasswd\x18\x12 \x01(\t*G\n\x06VmMode\x12\x06\n\x02PV\x10\x00\x12\x07\n\x03HVM\x10\x01\x12\n\n\x06\x46iller\x10\x02\x12\x07\n\x03\x46ML\x10\x03\x12\x0b\n\x07NOHYPER\x10\x04\x12\n\n\x06LEGACY\x10\x05\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
+  serialized_pb=b'\n\x0f\x63onfig/vm.proto\x12\x15org.lfedge.eve.config\"\xe4\x03\n\x08VmConfig\x12\x0e\n\x06kernel\x18\x01 \x01(\t\x12\x0f\n\x07ramdisk\x18\x02 \x01(\t\x12\x0e\n\x06memory\x18\x03 \x01(\r\x12\x0e\n\x06maxmem\x18\x04 \x01(\r\x12\r\n\x05vcpus\x18\x05 \x01(\r\x12\x0f\n\x07maxcpus\x18\x06 \x01(\r\x12\x0f\n\x07rootdev\x18\x07 \x01(\t\x12\x11\n\textraargs\x18\x08 \x01(\t\x12\x12\n\nbootloader\x18\t \x01(\t\x12\x0c\n\x04\x63pus\x18\n \x01(\t\x12\x12\n\ndevicetree\x18\x0b \x01(\t\x12\r\n\x05\x64tdev\x18\x0c \x03(\t\x12\x0c\n\x04irqs\x18\r \x03(\r\x12\r\n\x05iomem\x18\x0e \x03(\t\x12\x39\n\x12virtualizationMode\x18\x0f \x01(\x0e\x32\x1d.org.lfedge.eve.config.VmMode\x12\x11\n\tenableVnc\x18\x10 \x01(\x08\x12\x12\n\nvncDisplay\x18\x11 \x01(\r\x12\x11\n\tvncPasswd\x18\x12 \x01(\t\x12\x15\n\rexclusiveCpus\x18\x13 \x01(\x08\x12\x36\n\thugepages\x18\x14 \x01(\x0e\x32#.org.lfedge.eve.config.HugepageSize\x12-\n\x04vtpm\x18\x15 \x01(\x0e\x32\x1f.org.lfedge.eve.config.VtpmMode*G\n\x06VmMode\x12\x06\n\x02PV\x10\x00\x12\x07\n\x03HVM\x10\x01\x12\n\n\x06\x46iller\x10\x02\x12\x07\n\x03\x46ML\x10\x03\x12\x0b\n\x07NOHYPER\x10\x04\x12\n\n\x06LEGACY\x10\x05*R\n\x0cHugepageSize\x12\x16\n\x12HUGEPAGE_SIZE_NONE\x10\x00\x12\x14\n\x10HUGEPAGE_SIZE_2M\x10\x01\x12\x14\n\x10HUGEPAGE_SIZE_1G\x10\x02*I\n\x08VtpmMode\x12\x12\n\x0eVTPM_MODE_NONE\x10\x00\x12\x13\n\x0fVTPM_MODE_VAULT\x10\x01\x12\x14\n\x10VTPM_MODE_SEALED\x10\x02\x42=\n\x15org.lfedge.eve.configZ$github.com/lf-edge/eve/api/go/configb\x06proto3'
 )
 
 _VMMODE = _descriptor.EnumDescriptor(
@@ -63,18 +63,86 @@ _VMMODE = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=403,
-  serialized_end=474,
+  serialized_start=529,
+  serialized_end=600,
 )
 _sym_db.RegisterEnumDescriptor(_VMMODE)
 
 VmMode = enum_type_wrapper.EnumTypeWrapper(_VMMODE)
+_HUGEPAGESIZE = _descriptor.EnumDescriptor(
+  name='HugepageSize',
+  full_name='org.lfedge.eve.config.HugepageSize',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='HUGEPAGE_SIZE_NONE', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='HUGEPAGE_SIZE_2M', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='HUGEPAGE_SIZE_1G', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=602,
+  serialized_end=684,
+)
+_sym_db.RegisterEnumDescriptor(_HUGEPAGESIZE)
+
+HugepageSize = enum_type_wrapper.EnumTypeWrapper(_HUGEPAGESIZE)
+_VTPMMODE = _descriptor.EnumDescriptor(
+  name='VtpmMode',
+  full_name='org.lfedge.eve.config.VtpmMode',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='VTPM_MODE_NONE', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='VTPM_MODE_VAULT', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='VTPM_MODE_SEALED', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=686,
+  serialized_end=759,
+)
+_sym_db.RegisterEnumDescriptor(_VTPMMODE)
+
+VtpmMode = enum_type_wrapper.EnumTypeWrapper(_VTPMMODE)
 PV = 0
 HVM = 1
 Filler = 2
 FML = 3
 NOHYPER = 4
 LEGACY = 5
+HUGEPAGE_SIZE_NONE = 0
+HUGEPAGE_SIZE_2M = 1
+HUGEPAGE_SIZE_1G = 2
+VTPM_MODE_NONE = 0
+VTPM_MODE_VAULT = 1
+VTPM_MODE_SEALED = 2
 
 
 
@@ -212,6 +280,27 @@ _VMCONFIG = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='exclusiveCpus', full_name='org.lfedge.eve.config.VmConfig.exclusiveCpus', index=18,
+      number=19, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='hugepages', full_name='org.lfedge.eve.config.VmConfig.hugepages', index=19,
+      number=20, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='vtpm', full_name='org.lfedge.eve.config.VmConfig.vtpm', index=20,
+      number=21, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -225,12 +314,16 @@ _VMCONFIG = _descriptor.Descriptor(
   oneofs=[
   ],
   serialized_start=43,
-  serialized_end=401,
+  serialized_end=527,
 )
 
 _VMCONFIG.fields_by_name['virtualizationMode'].enum_type = _VMMODE
+_VMCONFIG.fields_by_name['hugepages'].enum_type = _HUGEPAGESIZE
+_VMCONFIG.fields_by_name['vtpm'].enum_type = _VTPMMODE
 DESCRIPTOR.message_types_by_name['VmConfig'] = _VMCONFIG
 DESCRIPTOR.enum_types_by_name['VmMode'] = _VMMODE
+DESCRIPTOR.enum_types_by_name['HugepageSize'] = _HUGEPAGESIZE
+DESCRIPTOR.enum_types_by_name['VtpmMode'] = _VTPMMODE
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
 
 VmConfig = _reflection.GeneratedProtocolMessageType('VmConfig', (_message.Message,), {
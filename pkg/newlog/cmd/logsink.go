@@ -0,0 +1,520 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Forwarding of the device and app logs to a RFC 5424 syslog server and to
+// a Loki push endpoint next to the gzip files for the controller. Each sink
+// has its own queue so a slow or unreachable server never holds up the log
+// collection: when the queue is full the entries are dropped and counted in
+// the sink metrics of NewlogMetrics.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	sinkQueueLen      = 4096             // log entries queued per sink
+	sinkRetryInterval = 10 * time.Second // wait after a send error
+	sinkDialTimeout   = 10 * time.Second
+	sinkBatchSize     = 500
+	lokiBatchDelay    = time.Second
+	syslogMaxUDPLen   = 8192
+	syslogVersion     = 1
+)
+
+// syslog facilities
+const (
+	facilityKern   = 0
+	facilityUser   = 1
+	facilityDaemon = 3
+)
+
+// sinkEntry is a log entry as forwarded
+type sinkEntry struct {
+	timestamp time.Time
+	severity  int // syslog severity
+	source    string
+	content   string
+	pid       string
+	appUUID   string
+	appName   string // DisplayName of the app, empty if unknown
+}
+
+// sinkConfig is the global config of a sink
+type sinkConfig struct {
+	url         string
+	maxSeverity int      // forward entries up to this syslog severity
+	sources     []string // forward only these sources if set
+}
+
+type logSink struct {
+	name       string
+	config     sinkConfig
+	send       func(sink *logSink, batch []sinkEntry) error
+	batchDelay time.Duration // to collect more entries before sending
+	queue      chan sinkEntry
+	done       chan struct{}
+	conn       net.Conn // syslog connection, only used by the sink goroutine
+
+	sync.Mutex // protects metrics
+	metrics    types.LogSinkMetrics
+}
+
+var (
+	sinksLock  sync.Mutex
+	syslogSink *logSink
+	lokiSink   *logSink
+
+	// metrics survive reconfiguring the sinks
+	syslogSinkMetrics types.LogSinkMetrics
+	lokiSinkMetrics   types.LogSinkMetrics
+)
+
+// severityLevel maps the syslog and logrus severity names to the syslog
+// severity, info if unknown
+func severityLevel(severity string) int {
+	switch strings.ToLower(severity) {
+	case "emerg", "panic":
+		return 0
+	case "alert":
+		return 1
+	case "crit", "fatal":
+		return 2
+	case "err", "error":
+		return 3
+	case "warning", "warn":
+		return 4
+	case "notice":
+		return 5
+	case "debug", "trace":
+		return 7
+	default:
+		return 6
+	}
+}
+
+func parseSinkConfig(gcp *types.ConfigItemValueMap, urlKey, levelKey,
+	sourcesKey types.GlobalSettingKey) sinkConfig {
+
+	config := sinkConfig{
+		url:         gcp.GlobalValueString(urlKey),
+		maxSeverity: severityLevel(gcp.GlobalValueString(levelKey)),
+	}
+	for _, source := range strings.Split(gcp.GlobalValueString(sourcesKey), ",") {
+		source = strings.TrimSpace(source)
+		if source != "" {
+			config.sources = append(config.sources, source)
+		}
+	}
+	return config
+}
+
+// match returns true if the sink forwards the entry. The sources are
+// "device", "app", source names, app UUIDs or app names.
+func (config sinkConfig) match(entry sinkEntry) bool {
+	if entry.severity > config.maxSeverity {
+		return false
+	}
+	if len(config.sources) == 0 {
+		return true
+	}
+	for _, source := range config.sources {
+		switch source {
+		case "device":
+			if entry.appUUID == "" {
+				return true
+			}
+		case "app":
+			if entry.appUUID != "" {
+				return true
+			}
+		case entry.source, entry.appUUID, entry.appName:
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns the URL without the password to log it
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+func (config sinkConfig) equal(other sinkConfig) bool {
+	return config.url == other.url &&
+		config.maxSeverity == other.maxSeverity &&
+		strings.Join(config.sources, ",") == strings.Join(other.sources, ",")
+}
+
+// handleSinkConfig starts, stops or restarts the sinks following the
+// global config
+func handleSinkConfig(gcp *types.ConfigItemValueMap) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	syslogSink = updateSink(syslogSink, "syslog", sendSyslog, 0, &syslogSinkMetrics,
+		parseSinkConfig(gcp, types.SyslogURL, types.SyslogLogLevel, types.SyslogSources))
+	lokiSink = updateSink(lokiSink, "loki", sendLoki, lokiBatchDelay, &lokiSinkMetrics,
+		parseSinkConfig(gcp, types.LokiURL, types.LokiLogLevel, types.LokiSources))
+}
+
+func updateSink(sink *logSink, name string,
+	send func(sink *logSink, batch []sinkEntry) error, batchDelay time.Duration,
+	metrics *types.LogSinkMetrics, config sinkConfig) *logSink {
+
+	if sink != nil && sink.config.equal(config) {
+		return sink
+	}
+	if sink != nil {
+		log.Noticef("updateSink: stopping %s sink to %s", name,
+			redactURL(sink.config.url))
+		close(sink.done)
+		*metrics = sink.getMetrics()
+		metrics.Enabled = false
+		metrics.QueueLen = 0
+		sink = nil
+	}
+	if config.url == "" {
+		return nil
+	}
+	log.Noticef("updateSink: starting %s sink to %s", name,
+		redactURL(config.url))
+	sink = &logSink{
+		name:       name,
+		config:     config,
+		send:       send,
+		batchDelay: batchDelay,
+		queue:      make(chan sinkEntry, sinkQueueLen),
+		done:       make(chan struct{}),
+		metrics:    *metrics,
+	}
+	sink.metrics.Enabled = true
+	go sink.run()
+	return sink
+}
+
+// forwardToSinks queues the entry on the sinks which forward it
+func forwardToSinks(entry inputEntry, appUUID string) {
+	sinksLock.Lock()
+	sinks := []*logSink{syslogSink, lokiSink}
+	sinksLock.Unlock()
+	if sinks[0] == nil && sinks[1] == nil {
+		return
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, entry.timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	se := sinkEntry{
+		timestamp: timestamp,
+		severity:  severityLevel(entry.severity),
+		source:    entry.source,
+		content:   entry.content,
+		pid:       entry.pid,
+		appUUID:   appUUID,
+	}
+	if appUUID != "" {
+		se.appName = lookupAppName(appUUID)
+	}
+	for _, sink := range sinks {
+		if sink == nil || !sink.config.match(se) {
+			continue
+		}
+		select {
+		case sink.queue <- se:
+			sink.Lock()
+			queueLen := uint32(len(sink.queue))
+			if queueLen > sink.metrics.MaxQueueLen {
+				sink.metrics.MaxQueueLen = queueLen
+			}
+			sink.Unlock()
+		default:
+			sink.Lock()
+			sink.metrics.NumDropped++
+			sink.Unlock()
+		}
+	}
+}
+
+// lookupAppName returns the DisplayName of the app from its DomainStatus,
+// or "" if there is none
+func lookupAppName(appUUID string) string {
+	for _, appD := range domainUUID {
+		if appD.appUUID == appUUID {
+			return appD.appName
+		}
+	}
+	return ""
+}
+
+// getSinkMetrics fills in the sink metrics for publishing
+func getSinkMetrics() {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	logmetrics.SyslogMetrics = syslogSinkMetrics
+	if syslogSink != nil {
+		logmetrics.SyslogMetrics = syslogSink.getMetrics()
+	}
+	logmetrics.LokiMetrics = lokiSinkMetrics
+	if lokiSink != nil {
+		logmetrics.LokiMetrics = lokiSink.getMetrics()
+	}
+}
+
+func (sink *logSink) getMetrics() types.LogSinkMetrics {
+	sink.Lock()
+	defer sink.Unlock()
+	metrics := sink.metrics
+	metrics.QueueLen = uint32(len(sink.queue))
+	return metrics
+}
+
+// sent records the result of sending count entries. Only the first of
+// consecutive errors is logged since the log entry itself is forwarded.
+func (sink *logSink) sent(count int, err error) {
+	sink.Lock()
+	defer sink.Unlock()
+	if err == nil {
+		sink.metrics.NumSent += uint64(count)
+		if sink.metrics.LastError != "" {
+			log.Noticef("%s sink to %s recovered", sink.name,
+				redactURL(sink.config.url))
+			sink.metrics.LastError = ""
+		}
+		return
+	}
+	sink.metrics.NumFailed += uint64(count)
+	if sink.metrics.LastError == "" {
+		log.Warnf("%s sink to %s failed: %v", sink.name,
+			redactURL(sink.config.url), err)
+	}
+	sink.metrics.LastError = err.Error()
+	sink.metrics.LastErrorTime = time.Now()
+}
+
+// wait returns false if the sink is stopped during d
+func (sink *logSink) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-sink.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// run sends the queued entries in batches of what is queued within
+// batchDelay until the sink is stopped
+func (sink *logSink) run() {
+	defer func() {
+		if sink.conn != nil {
+			sink.conn.Close()
+		}
+	}()
+	for {
+		var batch []sinkEntry
+		select {
+		case <-sink.done:
+			return
+		case entry := <-sink.queue:
+			batch = append(batch, entry)
+		}
+		timer := time.NewTimer(sink.batchDelay)
+	collect:
+		for len(batch) < sinkBatchSize {
+			select {
+			case entry := <-sink.queue:
+				batch = append(batch, entry)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		err := sink.send(sink, batch)
+		sink.sent(len(batch), err)
+		if err != nil && !sink.wait(sinkRetryInterval) {
+			return
+		}
+	}
+}
+
+func dialSyslog(sink *logSink) (net.Conn, error) {
+	u, err := url.Parse(sink.config.url)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: sinkDialTimeout}
+	switch u.Scheme {
+	case "tcp", "udp":
+		return dialer.Dial(u.Scheme, u.Host)
+	case "tls":
+		return tls.DialWithDialer(dialer, "tcp", u.Host,
+			&tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("unsupported syslog scheme %s", u.Scheme)
+	}
+}
+
+// syslogField returns the printable ASCII of s up to maxLen, or the
+// nil value "-"
+func syslogField(s string, maxLen int) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c > ' ' && c < 127 {
+			b.WriteRune(c)
+		}
+		if b.Len() == maxLen {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// formatSyslog formats the entry as a RFC 5424 message. The app logs are
+// sent with the app name as APP-NAME, the nil value if unknown, and the
+// source in MSGID.
+func formatSyslog(entry sinkEntry, hostname string) string {
+	facility := facilityDaemon
+	appName, msgID := entry.source, "-"
+	switch {
+	case entry.appUUID != "":
+		facility = facilityUser
+		appName, msgID = entry.appName, entry.source
+	case entry.source == "kernel":
+		facility = facilityKern
+	}
+	return fmt.Sprintf("<%d>%d %s %s %s %s %s - %s",
+		facility*8+entry.severity, syslogVersion,
+		entry.timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(hostname, 255), syslogField(appName, 48),
+		syslogField(entry.pid, 128), syslogField(msgID, 32),
+		strings.TrimRight(entry.content, "\n"))
+}
+
+// sendSyslog sends the entries with octet counting framing over TCP and
+// TLS (RFC 6587, RFC 5425) and one per datagram over UDP (RFC 5426)
+func sendSyslog(sink *logSink, batch []sinkEntry) error {
+	if sink.conn == nil {
+		conn, err := dialSyslog(sink)
+		if err != nil {
+			return err
+		}
+		sink.conn = conn
+	}
+	_, isUDP := sink.conn.(*net.UDPConn)
+	for _, entry := range batch {
+		msg := formatSyslog(entry, devMetaData.uuid)
+		if isUDP {
+			if len(msg) > syslogMaxUDPLen {
+				msg = msg[:syslogMaxUDPLen]
+			}
+		} else {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+		sink.conn.SetWriteDeadline(time.Now().Add(sinkDialTimeout))
+		if _, err := sink.conn.Write([]byte(msg)); err != nil {
+			sink.conn.Close()
+			sink.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// lokiRequest returns the push request of the entries, one stream per
+// source, severity and app
+func lokiRequest(batch []sinkEntry, device string) lokiPush {
+	var push lokiPush
+	streams := make(map[string]*lokiStream)
+	for _, entry := range batch {
+		key := entry.source + "\x00" + priorityStr[entry.severity] + "\x00" + entry.appUUID
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: map[string]string{
+				"job":    "eve",
+				"device": device,
+				"source": entry.source,
+				"level":  priorityStr[entry.severity],
+			}}
+			if entry.appUUID != "" {
+				stream.Stream["app_uuid"] = entry.appUUID
+				stream.Stream["app_name"] = entry.appName
+				if entry.appName == "" {
+					stream.Stream["app_name"] = entry.appUUID
+				}
+			}
+			streams[key] = stream
+			push.Streams = append(push.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(entry.timestamp.UnixNano(), 10), entry.content})
+	}
+	return push
+}
+
+var lokiClient = &http.Client{Timeout: 30 * time.Second}
+
+// sendLoki pushes the entries in the JSON format, with the user and
+// password of the URL if any
+func sendLoki(sink *logSink, batch []sinkEntry) error {
+	body, err := json.Marshal(lokiRequest(batch, devMetaData.uuid))
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(sink.config.url)
+	if err != nil {
+		return err
+	}
+	user := u.User
+	u.User = nil
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	resp, err := lokiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("push to %s: %s", u.Host, resp.Status)
+	}
+	return nil
+}
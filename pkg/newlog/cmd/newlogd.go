@@ -289,6 +289,8 @@ func main() {
 		select {
 		case <-metricsPublishTimer.C:
 			getDevTop10Inputs()
+			getTop10RateLimited()
+			getSinkMetrics()
 			err = metricsPub.Publish("global", logmetrics)
 			if err != nil {
 				log.Error(err)
@@ -476,6 +478,9 @@ func handleGlobalConfigImp(ctxArg interface{}, key string, statusArg interface{}
 		if limitGzipFilesMbyts > uint32(persistMbytes / 10) {
 			limitGzipFilesMbyts = uint32(persistMbytes / 10)
 		}
+
+		handleSinkConfig(gcp)
+		handleLimitConfig(gcp)
 	}
 	log.Tracef("handleGlobalConfigModify done for %s, debug set %v, fastupload enabled %v", key, debug, enableFastUpload)
 }
@@ -731,42 +736,53 @@ func writelogFile(logChan <-chan inputEntry, moveChan chan fileChanInfo) {
 		case <-checklogTimer.C:
 			timeIdx++
 			checkLogTimeExpire(fileinfo, &devStats, moveChan)
+			for _, e := range flushRepeats(time.Now()) {
+				writeEntry(e, fileinfo, &devStats, moveChan)
+			}
 			checklogTimer = time.NewTimer(5 * time.Second)  // check the file time limit every 5 seconds
 
 		case entry := <-logChan:
-			appuuid := checkAppEntry(&entry)
-			var appM statsLogFile
-			if appuuid != "" {
-				appM = getAppStatsMap(appuuid)
+			for _, e := range limitEntry(entry, time.Now()) {
+				writeEntry(e, fileinfo, &devStats, moveChan)
 			}
-			timeS := getPtypeTimestamp(entry.timestamp)
-			mapLog := logs.LogEntry{
-				Severity:  entry.severity,
-				Source:    entry.source,
-				Content:   entry.content,
-				Iid:       entry.pid,
-				Filename:  entry.filename,
-				Msgid:     updateLogMsgID(appM.domainName),
-				Function:  entry.function,
-				Timestamp: timeS,
-			}
-			mapJentry, _ := json.Marshal(&mapLog)
-			logline := string(mapJentry) + "\n"
-			if appuuid != "" {
-				len := writelogEntry(&appM, logline)
+		}
+	}
+}
+
+// writeEntry writes the log entry into the dev or app logfile
+func writeEntry(entry inputEntry, fileinfo fileChanInfo, devStats *statsLogFile, moveChan chan fileChanInfo) {
+	appuuid := checkAppEntry(&entry)
+	forwardToSinks(entry, appuuid)
+	var appM statsLogFile
+	if appuuid != "" {
+		appM = getAppStatsMap(appuuid)
+	}
+	timeS := getPtypeTimestamp(entry.timestamp)
+	mapLog := logs.LogEntry{
+		Severity:  entry.severity,
+		Source:    entry.source,
+		Content:   entry.content,
+		Iid:       entry.pid,
+		Filename:  entry.filename,
+		Msgid:     updateLogMsgID(appM.domainName),
+		Function:  entry.function,
+		Timestamp: timeS,
+	}
+	mapJentry, _ := json.Marshal(&mapLog)
+	logline := string(mapJentry) + "\n"
+	if appuuid != "" {
+		len := writelogEntry(&appM, logline)
 
-				logmetrics.AppMetrics.NumBytesWrite += uint64(len)
-				appStatsMap[appuuid] = appM
+		logmetrics.AppMetrics.NumBytesWrite += uint64(len)
+		appStatsMap[appuuid] = appM
 
-				trigMoveToGzip(fileinfo, &appM, appuuid, moveChan, false)
+		trigMoveToGzip(fileinfo, &appM, appuuid, moveChan, false)
 
-			} else {
-				len := writelogEntry(&devStats, logline)
-				updateDevInputlogStats(entry.source, uint64(len))
+	} else {
+		len := writelogEntry(devStats, logline)
+		updateDevInputlogStats(entry.source, uint64(len))
 
-				trigMoveToGzip(fileinfo, &devStats, "", moveChan, false)
-			}
-		}
+		trigMoveToGzip(fileinfo, devStats, "", moveChan, false)
 	}
 }
 
@@ -788,7 +804,9 @@ func checkAppEntry(entry *inputEntry) string {
 		if len(appSplitArr) == 2 {
 			if appSplitArr[0] == "" && appSplitArr[1] != "" {
 				entry.source = appSplitArr[1]
-				if du, ok := domainUUID[entry.source]; ok {
+				// the additional containers of a pod keep their name in the source
+				domainName, _ := types.PodDomainName(entry.source)
+				if du, ok := domainUUID[domainName]; ok {
 					appuuid = du.appUUID
 				}
 			}
@@ -0,0 +1,196 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Deduplication and rate limiting of the log entries before they are
+// written to the log files and forwarded. Identical consecutive entries of
+// a source are collapsed into a "last message repeated N times" entry. Each
+// device source and each app has a token bucket of entries per second with
+// a burst of rateLimitBurstSec seconds; the rate of an app can be overridden
+// by its UUID. The entries over the limit are dropped, counted per source,
+// and reported with an entry once the source gets through again.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	dedupFlushInterval = 30 * time.Second // report the repeats at least that often
+	dedupIdleTimeout   = 10 * time.Minute // forget the sources idle for longer
+	rateLimitBurstSec  = 10
+)
+
+type repeatState struct {
+	last     inputEntry // last entry of the source let through
+	lastTime time.Time
+	count    uint64    // identical entries since last
+	since    time.Time // time of the first of them
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastTime time.Time
+	dropped  uint64 // entries dropped since the last one let through
+}
+
+var (
+	limitLock       sync.Mutex // protects the settings and rateLimitedSrcs
+	dedupEnabled    = true
+	sourceRateLimit uint32                    // entries per second of each device source
+	appRateLimit    uint32                    // entries per second of each app
+	appRateLimits   map[string]uint32         // per app UUID, overrides appRateLimit
+	rateLimitedSrcs = make(map[string]uint64) // dropped entries per source
+
+	// only used by the writelogFile goroutine
+	repeats = make(map[string]*repeatState)
+	buckets = make(map[string]*tokenBucket)
+)
+
+// handleLimitConfig updates the deduplication and rate limit settings
+func handleLimitConfig(gcp *types.ConfigItemValueMap) {
+	limitLock.Lock()
+	defer limitLock.Unlock()
+	dedupEnabled = gcp.GlobalValueBool(types.LogDedup)
+	sourceRateLimit = gcp.GlobalValueInt(types.LogSourceRateLimit)
+	appRateLimit = gcp.GlobalValueInt(types.LogAppRateLimit)
+	overrides, err := types.ParseRateLimitOverrides(
+		gcp.GlobalValueString(types.LogAppRateLimitOverrides))
+	if err != nil {
+		log.Errorf("handleLimitConfig: %s: %v", types.LogAppRateLimitOverrides, err)
+	}
+	appRateLimits = overrides
+}
+
+// getAppRateLimit returns the rate limit of the app, from the overrides
+// if set. Called with limitLock held.
+func getAppRateLimit(appUUID string) uint32 {
+	if rate, ok := appRateLimits[appUUID]; ok {
+		return rate
+	}
+	return appRateLimit
+}
+
+// limitEntry returns the entries to write for the entry: none if it is
+// a repeat or over the rate limit, and before it the pending repeat and
+// drop reports of its source
+func limitEntry(entry inputEntry, now time.Time) []inputEntry {
+	limitLock.Lock()
+	dedup := dedupEnabled
+	rate := sourceRateLimit
+	limitLock.Unlock()
+
+	// checkAppEntry on a copy tells the app and the source of the entry
+	e := entry
+	appuuid := checkAppEntry(&e)
+	key, bucketKey := e.source, e.source
+	if appuuid != "" {
+		key, bucketKey = appuuid+"/"+e.source, appuuid
+		limitLock.Lock()
+		rate = getAppRateLimit(appuuid)
+		limitLock.Unlock()
+	}
+
+	var entries []inputEntry
+	if dedup {
+		r, ok := repeats[key]
+		if ok && r.last.content == entry.content &&
+			r.last.severity == entry.severity {
+			if r.count == 0 {
+				r.since = now
+			}
+			r.count++
+			r.lastTime = now
+			logmetrics.NumDeduplicated++
+			return nil
+		}
+		if ok && r.count != 0 {
+			entries = append(entries, repeatedEntry(r, now))
+		}
+		repeats[key] = &repeatState{last: entry, lastTime: now}
+	}
+
+	if rate != 0 {
+		b, ok := buckets[bucketKey]
+		if !ok {
+			b = &tokenBucket{tokens: float64(rate * rateLimitBurstSec)}
+			buckets[bucketKey] = b
+		} else {
+			b.tokens += now.Sub(b.lastTime).Seconds() * float64(rate)
+			if max := float64(rate * rateLimitBurstSec); b.tokens > max {
+				b.tokens = max
+			}
+		}
+		b.lastTime = now
+		if b.tokens < 1 {
+			b.dropped++
+			// a repeat of a dropped entry is not a repeat of the last one
+			delete(repeats, key)
+			limitLock.Lock()
+			rateLimitedSrcs[bucketKey]++
+			limitLock.Unlock()
+			logmetrics.NumRateLimited++
+			return entries
+		}
+		b.tokens--
+		if b.dropped != 0 {
+			dropped := entry
+			dropped.severity = "warning"
+			dropped.content = fmt.Sprintf("%d messages dropped by the rate limit of %d per second",
+				b.dropped, rate)
+			dropped.timestamp = now.Format(time.RFC3339Nano)
+			entries = append(entries, dropped)
+			b.dropped = 0
+		}
+	}
+	return append(entries, entry)
+}
+
+func repeatedEntry(r *repeatState, now time.Time) inputEntry {
+	entry := r.last
+	entry.content = fmt.Sprintf("last message repeated %d times", r.count)
+	entry.timestamp = now.Format(time.RFC3339Nano)
+	r.count = 0
+	return entry
+}
+
+// flushRepeats returns the repeat reports which are due and forgets the
+// idle sources, including their dropped entries once reported
+func flushRepeats(now time.Time) []inputEntry {
+	var entries []inputEntry
+	for key, r := range repeats {
+		if r.count != 0 && now.Sub(r.since) >= dedupFlushInterval {
+			entries = append(entries, repeatedEntry(r, now))
+		}
+		if r.count == 0 && now.Sub(r.lastTime) >= dedupIdleTimeout {
+			delete(repeats, key)
+		}
+	}
+	limitLock.Lock()
+	for key, b := range buckets {
+		if b.dropped == 0 && now.Sub(b.lastTime) >= dedupIdleTimeout {
+			delete(buckets, key)
+			delete(rateLimitedSrcs, key)
+		}
+	}
+	limitLock.Unlock()
+	return entries
+}
+
+// getTop10RateLimited ranks the sources by the dropped entries
+func getTop10RateLimited() {
+	limitLock.Lock()
+	defer limitLock.Unlock()
+	top10 := make(map[string]uint64)
+	for i, p := range rankByInputCount(rateLimitedSrcs) {
+		if i >= 10 {
+			break
+		}
+		top10[p.Key] = p.Value
+	}
+	logmetrics.Top10RateLimited = top10
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// resetLimits sets the limits and forgets the state of the sources
+func resetLimits(dedup bool, sourceRate, appRate uint32,
+	overrides map[string]uint32) {
+
+	limitLock.Lock()
+	dedupEnabled = dedup
+	sourceRateLimit = sourceRate
+	appRateLimit = appRate
+	appRateLimits = overrides
+	rateLimitedSrcs = make(map[string]uint64)
+	limitLock.Unlock()
+	repeats = make(map[string]*repeatState)
+	buckets = make(map[string]*tokenBucket)
+}
+
+func contents(entries []inputEntry) []string {
+	var s []string
+	for _, e := range entries {
+		s = append(s, e.content)
+	}
+	return s
+}
+
+func checkContents(t *testing.T, what string, entries []inputEntry,
+	expected ...string) {
+
+	got := contents(entries)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("%s: got %q, expected %q", what, got, expected)
+	}
+}
+
+func TestLimitEntryDedup(t *testing.T) {
+	resetLimits(true, 0, 0, nil)
+	now := time.Now()
+	entry := inputEntry{source: "zedagent", severity: "info", content: "a"}
+	checkContents(t, "first", limitEntry(entry, now), "a")
+	checkContents(t, "repeat", limitEntry(entry, now))
+	checkContents(t, "repeat", limitEntry(entry, now))
+	// Another source is not a repeat
+	other := entry
+	other.source = "nim"
+	checkContents(t, "other source", limitEntry(other, now), "a")
+	// Nor another severity
+	warning := entry
+	warning.severity = "warning"
+	checkContents(t, "other severity", limitEntry(warning, now),
+		"last message repeated 2 times", "a")
+	entry.content = "b"
+	checkContents(t, "new content", limitEntry(entry, now), "b")
+
+	resetLimits(false, 0, 0, nil)
+	checkContents(t, "dedup disabled", limitEntry(entry, now), "b")
+	checkContents(t, "dedup disabled", limitEntry(entry, now), "b")
+}
+
+func TestLimitEntryRate(t *testing.T) {
+	resetLimits(false, 1, 0, nil)
+	now := time.Now()
+	entry := inputEntry{source: "zedagent", severity: "info", content: "a"}
+	for i := 0; i < rateLimitBurstSec; i++ {
+		checkContents(t, "burst", limitEntry(entry, now), "a")
+	}
+	checkContents(t, "over the limit", limitEntry(entry, now))
+	checkContents(t, "over the limit", limitEntry(entry, now))
+	if dropped := rateLimitedSrcs["zedagent"]; dropped != 2 {
+		t.Errorf("%d entries counted as dropped, expected 2", dropped)
+	}
+	// Another source has its own bucket
+	other := entry
+	other.source = "nim"
+	checkContents(t, "other source", limitEntry(other, now), "a")
+	// One token a second
+	now = now.Add(time.Second)
+	checkContents(t, "after a second", limitEntry(entry, now),
+		"2 messages dropped by the rate limit of 1 per second", "a")
+	checkContents(t, "over the limit", limitEntry(entry, now))
+}
+
+func TestLimitEntryAppOverride(t *testing.T) {
+	limitedApp := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	otherApp := "6ba7b811-9dad-11d1-80b4-00c04fd430c8"
+	resetLimits(false, 0, 2, map[string]uint32{limitedApp: 1})
+	now := time.Now()
+	limited := inputEntry{source: "app", severity: "info", content: "a",
+		appUUID: limitedApp}
+	other := limited
+	other.appUUID = otherApp
+	for i := 0; i < rateLimitBurstSec; i++ {
+		checkContents(t, "burst", limitEntry(limited, now), "a")
+	}
+	checkContents(t, "over the override", limitEntry(limited, now))
+	// The other app has the global limit
+	for i := 0; i < 2*rateLimitBurstSec; i++ {
+		checkContents(t, "burst", limitEntry(other, now), "a")
+	}
+	checkContents(t, "over the global limit", limitEntry(other, now))
+	// The device sources are not limited
+	device := inputEntry{source: "zedagent", severity: "info", content: "a"}
+	checkContents(t, "device", limitEntry(device, now), "a")
+}
+
+func TestFlushRepeats(t *testing.T) {
+	resetLimits(true, 1, 0, nil)
+	now := time.Now()
+	entry := inputEntry{source: "zedagent", severity: "info", content: "a"}
+	limitEntry(entry, now)
+	limitEntry(entry, now)
+	limitEntry(entry, now)
+	checkContents(t, "not due", flushRepeats(now.Add(dedupFlushInterval-time.Second)))
+	checkContents(t, "due", flushRepeats(now.Add(dedupFlushInterval)),
+		"last message repeated 2 times")
+	checkContents(t, "flushed", flushRepeats(now.Add(dedupFlushInterval)))
+
+	// Drop entries of another source, then let one through which
+	// reports them
+	other := inputEntry{source: "nim", severity: "info"}
+	for i := 0; i <= rateLimitBurstSec; i++ {
+		other.content = fmt.Sprint(i)
+		limitEntry(other, now)
+	}
+	if dropped := rateLimitedSrcs["nim"]; dropped != 1 {
+		t.Fatalf("%d entries counted as dropped, expected 1", dropped)
+	}
+	// Not forgotten while the drops are not reported
+	flushRepeats(now.Add(dedupIdleTimeout))
+	if _, ok := buckets["nim"]; !ok {
+		t.Error("bucket forgotten with dropped entries")
+	}
+	now = now.Add(time.Second)
+	other.content = "x"
+	checkContents(t, "report", limitEntry(other, now),
+		"1 messages dropped by the rate limit of 1 per second", "x")
+
+	// The idle sources are forgotten with their dropped entries
+	checkContents(t, "idle", flushRepeats(now.Add(dedupIdleTimeout)))
+	if len(repeats) != 0 || len(buckets) != 0 {
+		t.Errorf("idle sources not forgotten: %v %v", repeats, buckets)
+	}
+	if len(rateLimitedSrcs) != 0 {
+		t.Errorf("dropped entries of idle sources not forgotten: %v",
+			rateLimitedSrcs)
+	}
+}
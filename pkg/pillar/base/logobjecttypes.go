@@ -148,6 +148,16 @@ const (
 	SigUSR1StacksType LogObjectType = "sigusr1_stacks"
 	// FatalStacksType:
 	FatalStacksType LogObjectType = "fatal_stacks"
+	// AlertStatusLogType:
+	AlertStatusLogType LogObjectType = "alert_status"
+	// DiagBundleConfigLogType:
+	DiagBundleConfigLogType LogObjectType = "diag_bundle_config"
+	// DiagBundleStatusLogType:
+	DiagBundleStatusLogType LogObjectType = "diag_bundle_status"
+	// SensorMetricLogType:
+	SensorMetricLogType LogObjectType = "sensor_metric"
+	// DiskHealthStatusLogType:
+	DiskHealthStatusLogType LogObjectType = "disk_health_status"
 )
 
 // RelationObjectType :
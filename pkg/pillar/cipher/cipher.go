@@ -25,6 +25,8 @@ func getEncryptionBlock(
 	decBlock.WifiUserName = zconfigDecBlockPtr.WifiUserName
 	decBlock.WifiPassword = zconfigDecBlockPtr.WifiPassword
 	decBlock.ProtectedUserData = zconfigDecBlockPtr.ProtectedUserData
+	decBlock.Dot1xPassword = zconfigDecBlockPtr.Dot1XPassword
+	decBlock.Dot1xPrivateKey = zconfigDecBlockPtr.Dot1XPrivateKey
 	return decBlock
 }
 
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Collect a diagnostic bundle on the device, as the controller does with
+// the collect_info command, into a file or the standard output. The
+// bundle stays on the device; copy it from there e.g., with edgeview or scp.
+
+package diagbundle
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/diagbundle"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Run is the diagbundle entrypoint
+func Run(ps *pubsub.PubSub, logger *logrus.Logger, log *base.LogObject) int {
+	defaultFilename := filepath.Join(types.DiagBundleDir,
+		fmt.Sprintf("diag-local-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z")))
+	outputPtr := flag.String("o", defaultFilename, "Write the bundle to the file, or - for stdout")
+	flag.Parse()
+
+	if *outputPtr == "-" {
+		if err := diagbundle.Collect(log, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "diagbundle: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	size, err := diagbundle.CollectToFile(log, *outputPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "diagbundle: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s: %d bytes\n", *outputPtr, size)
+	return 0
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Files of the NoCloud cloud-init datasource besides user-data. meta-data
+// tells the guest its instance-id and hostname, and network-config (v2)
+// configures the network adapters by MAC address: statically when zedrouter
+// knows the IP address of the app, as given by DHCP on local network
+// instances, and with DHCP otherwise. The hostname made of the DisplayName
+// and the network-config are only used with CloudInitNoCloud, so that the
+// existing guests keep the UUID as hostname and configure their network
+// as before.
+
+package domainmgr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// Longest hostname label
+const maxHostnameLen = 63
+
+// guestHostname returns a hostname made of the DisplayName of the domain,
+// or of its UUID if nothing is left of the DisplayName
+func guestHostname(config types.DomainConfig) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(config.DisplayName) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			dash = dash && b.Len() > 0
+			need := 1
+			if dash {
+				need = 2
+			}
+			if b.Len()+need > maxHostnameLen {
+				break
+			}
+			if dash {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	hostname := b.String()
+	if hostname == "" {
+		return config.UUIDandVersion.UUID.String()
+	}
+	return hostname
+}
+
+// cloudInitMetaData returns the meta-data of the domain
+func cloudInitMetaData(config types.DomainConfig) string {
+	hostname := config.UUIDandVersion.UUID.String()
+	if config.CloudInitNoCloud {
+		hostname = guestHostname(config)
+	}
+	return fmt.Sprintf("instance-id: %s/%s\nlocal-hostname: %s\n",
+		config.UUIDandVersion.UUID.String(),
+		config.UUIDandVersion.Version,
+		hostname)
+}
+
+// cloudInitNetworkConfig returns the network-config of the domain, or ""
+// if it has no network adapters or CloudInitNoCloud is not set
+func cloudInitNetworkConfig(config types.DomainConfig) string {
+	if !config.CloudInitNoCloud || len(config.GuestNetworks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("version: 2\nethernets:\n")
+	for i, gn := range config.GuestNetworks {
+		if gn.Name != "" {
+			fmt.Fprintf(&b, "  # %s\n", strconv.Quote(gn.Name))
+		}
+		fmt.Fprintf(&b, "  eth%d:\n", i)
+		fmt.Fprintf(&b, "    match:\n      macaddress: %q\n", gn.Mac)
+		if gn.IPAddr == nil {
+			b.WriteString("    dhcp4: true\n")
+			continue
+		}
+		b.WriteString("    dhcp4: false\n")
+		if gn.Gateway == nil {
+			prefixLen, _ := gn.Subnet.Mask.Size()
+			fmt.Fprintf(&b, "    addresses:\n      - %s/%d\n",
+				gn.IPAddr, prefixLen)
+		} else {
+			// Same as DHCP on local network instances: a host address
+			// which makes all the traffic, including to the other apps
+			// on the subnet, go through the router
+			fmt.Fprintf(&b, "    addresses:\n      - %s/32\n", gn.IPAddr)
+			b.WriteString("    routes:\n")
+			for _, to := range []string{"0.0.0.0/0", gn.Subnet.String()} {
+				fmt.Fprintf(&b, "      - to: %s\n        via: %s\n        on-link: true\n",
+					to, gn.Gateway)
+			}
+		}
+		if len(gn.DNSServers) != 0 || gn.DomainName != "" {
+			b.WriteString("    nameservers:\n")
+		}
+		if len(gn.DNSServers) != 0 {
+			b.WriteString("      addresses:\n")
+			for _, ns := range gn.DNSServers {
+				fmt.Fprintf(&b, "        - %s\n", ns)
+			}
+		}
+		if gn.DomainName != "" {
+			fmt.Fprintf(&b, "      search:\n        - %q\n", gn.DomainName)
+		}
+	}
+	return b.String()
+}
+
+// hasCloudInitData returns true if the domain has user-data or vendor-data
+func hasCloudInitData(config types.DomainConfig) bool {
+	return config.IsCipher || config.CloudInitUserData != nil ||
+		config.CloudInitVendorData != ""
+}
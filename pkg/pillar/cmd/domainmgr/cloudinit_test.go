@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestGuestHostname(t *testing.T) {
+	appUUID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	testMatrix := map[string]string{
+		"web-server":       "web-server",
+		"My App_1 (test)":  "my-app-1-test",
+		"--":               appUUID.String(),
+		"":                 appUUID.String(),
+		"app.example.com.": "app-example-com",
+		// At most 63 characters, without a trailing dash
+		strings.Repeat("a", 70):          strings.Repeat("a", 63),
+		strings.Repeat("a", 62) + " bcd": strings.Repeat("a", 62),
+		strings.Repeat("a", 61) + " bcd": strings.Repeat("a", 61) + "-b",
+	}
+	for displayName, expected := range testMatrix {
+		config := types.DomainConfig{
+			UUIDandVersion: types.UUIDandVersion{UUID: appUUID},
+			DisplayName:    displayName,
+		}
+		if hostname := guestHostname(config); hostname != expected {
+			t.Errorf("guestHostname(%q) = %q, expected %q",
+				displayName, hostname, expected)
+		}
+	}
+}
+
+func TestCloudInitMetaData(t *testing.T) {
+	appUUID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	config := types.DomainConfig{
+		UUIDandVersion: types.UUIDandVersion{UUID: appUUID, Version: "2"},
+		DisplayName:    "web-server",
+	}
+	// The existing guests keep the UUID as hostname
+	expected := "instance-id: " + appUUID.String() + "/2\n" +
+		"local-hostname: " + appUUID.String() + "\n"
+	if metaData := cloudInitMetaData(config); metaData != expected {
+		t.Errorf("got meta-data %q, expected %q", metaData, expected)
+	}
+	config.CloudInitNoCloud = true
+	expected = "instance-id: " + appUUID.String() + "/2\n" +
+		"local-hostname: web-server\n"
+	if metaData := cloudInitMetaData(config); metaData != expected {
+		t.Errorf("got meta-data %q with CloudInitNoCloud, expected %q",
+			metaData, expected)
+	}
+}
+
+func TestCloudInitNetworkConfig(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.1.0.0/24")
+	config := types.DomainConfig{
+		CloudInitNoCloud: true,
+		GuestNetworks: []types.GuestNetwork{
+			{
+				Name: "local",
+				Mac:  "02:16:3e:00:00:01",
+				GuestIPConfig: types.GuestIPConfig{
+					IPAddr:     net.ParseIP("10.1.0.2"),
+					Subnet:     *subnet,
+					Gateway:    net.ParseIP("10.1.0.1"),
+					DNSServers: []net.IP{net.ParseIP("10.1.0.1")},
+					DomainName: "example.com",
+				},
+			},
+			{
+				Name: "airgap",
+				Mac:  "02:16:3e:00:00:02",
+				GuestIPConfig: types.GuestIPConfig{
+					IPAddr: net.ParseIP("10.1.0.3"),
+					Subnet: *subnet,
+				},
+			},
+			{
+				Name: "switch",
+				Mac:  "02:16:3e:00:00:03",
+			},
+		},
+	}
+	expected := `version: 2
+ethernets:
+  # "local"
+  eth0:
+    match:
+      macaddress: "02:16:3e:00:00:01"
+    dhcp4: false
+    addresses:
+      - 10.1.0.2/32
+    routes:
+      - to: 0.0.0.0/0
+        via: 10.1.0.1
+        on-link: true
+      - to: 10.1.0.0/24
+        via: 10.1.0.1
+        on-link: true
+    nameservers:
+      addresses:
+        - 10.1.0.1
+      search:
+        - "example.com"
+  # "airgap"
+  eth1:
+    match:
+      macaddress: "02:16:3e:00:00:02"
+    dhcp4: false
+    addresses:
+      - 10.1.0.3/24
+  # "switch"
+  eth2:
+    match:
+      macaddress: "02:16:3e:00:00:03"
+    dhcp4: true
+`
+	if networkConfig := cloudInitNetworkConfig(config); networkConfig != expected {
+		t.Errorf("got network-config\n%s\nexpected\n%s", networkConfig, expected)
+	}
+	if networkConfig := cloudInitNetworkConfig(types.DomainConfig{CloudInitNoCloud: true}); networkConfig != "" {
+		t.Errorf("got network-config %q without network adapters", networkConfig)
+	}
+	config.CloudInitNoCloud = false
+	if networkConfig := cloudInitNetworkConfig(config); networkConfig != "" {
+		t.Errorf("got network-config %q without CloudInitNoCloud", networkConfig)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Additional containers, e.g., sidecars and init containers, of a domain
+// which runs containers without a VM. The task runs them in the network
+// namespace of the main container with the volumes of the domain, and the
+// runHandler periodically publishes their state in DomainStatus.Containers.
+
+package domainmgr
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// How often runHandler checks the state of the additional containers
+const containerInterval = 5 * time.Second
+
+// checkContainers returns an error if the domain can not run its
+// additional containers
+func checkContainers(config types.DomainConfig, status *types.DomainStatus) error {
+	if len(config.Containers) == 0 {
+		return nil
+	}
+	if status.OCIConfigDir == "" {
+		return errors.New("additional containers need a container app instance")
+	}
+	_, ok := hyper.Task(status).(hypervisor.Pod)
+	if !ok || (hyper.Name() != "containerd" &&
+		config.VirtualizationModeOrDefault() != types.NOHYPER) {
+		return errors.New("additional containers need a container which does not run in a VM")
+	}
+	for _, container := range config.Containers {
+		found := false
+		for _, disk := range status.DiskStatusList {
+			if disk.Container == container.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("no image volume for container " + container.Name)
+		}
+	}
+	return nil
+}
+
+// updateContainers publishes the state of the additional containers
+func updateContainers(ctx *domainContext, status *types.DomainStatus) {
+	var list []types.ContainerStatus
+	config := lookupDomainConfig(ctx, status.Key())
+	pod, ok := hyper.Task(status).(hypervisor.Pod)
+	if config != nil && ok && status.Activated {
+		for _, container := range config.Containers {
+			cs := types.ContainerStatus{
+				Name: container.Name,
+				Init: container.Init,
+			}
+			state, exitCode, err := pod.ContainerInfo(status.DomainName,
+				container.Name)
+			cs.State = state
+			if state == types.HALTED || state == types.BROKEN {
+				cs.ExitCode = exitCode
+			}
+			if err != nil {
+				cs.Error = err.Error()
+			}
+			list = append(list, cs)
+		}
+	}
+	if !cmp.Equal(list, status.Containers) {
+		log.Functionf("updateContainers(%s) %+v", status.Key(), list)
+		status.Containers = list
+		publishDomainStatus(ctx, status)
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// CPU manager which allocates dedicated physical cores to the domains with
+// ExclusiveCPUs. All the hyperthreads of such a core go to the domain.
+// EVE services, the other domains and the interrupts are moved to the
+// remaining shared CPUs using the cpuset cgroups and the IRQ affinity.
+// The first core is always kept for EVE.
+// Only used with kvm and containerd where the domains are Linux processes
+// in the cpuset cgroups; with Xen dom0 does not see the physical CPUs.
+
+package domainmgr
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	cpuSysDir       = "/sys/devices/system/cpu"
+	cpusetCgroupDir = "/hostfs/sys/fs/cgroup/cpuset"
+	irqDir          = "/proc/irq"
+	// The cgroups of the EVE services and of the domains
+	eveCgroup  = "eve"
+	appsCgroup = "eve-user-apps"
+)
+
+// cpuPinning is what a domain is pinned to
+type cpuPinning struct {
+	cpus      []int
+	exclusive bool
+}
+
+// cpuManager is used by the runHandler of all domains hence the mutex
+type cpuManager struct {
+	sync.Mutex
+	online []int
+	cores  [][]int               // The CPUs of each core, sorted
+	pinned map[string]cpuPinning // Key is the domain name
+}
+
+// newCPUManager reads the CPU topology from sysDir
+func newCPUManager(sysDir string) (*cpuManager, error) {
+	data, err := ioutil.ReadFile(filepath.Join(sysDir, "online"))
+	if err != nil {
+		return nil, err
+	}
+	online, err := parseCPUList(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("online CPUs: %v", err)
+	}
+	cm := &cpuManager{
+		online: online,
+		pinned: make(map[string]cpuPinning),
+	}
+	seen := make(map[int]bool)
+	for _, cpu := range online {
+		if seen[cpu] {
+			continue
+		}
+		filename := filepath.Join(sysDir, fmt.Sprintf("cpu%d", cpu),
+			"topology", "thread_siblings_list")
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		siblings, err := parseCPUList(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("CPU %d siblings: %v", cpu, err)
+		}
+		var core []int
+		for _, sibling := range siblings {
+			if !seen[sibling] && cpuIn(online, sibling) {
+				seen[sibling] = true
+				core = append(core, sibling)
+			}
+		}
+		if !cpuIn(core, cpu) {
+			core = append(core, cpu)
+			seen[cpu] = true
+		}
+		sort.Ints(core)
+		cm.cores = append(cm.cores, core)
+	}
+	sort.Slice(cm.cores, func(i, j int) bool {
+		return cm.cores[i][0] < cm.cores[j][0]
+	})
+	return cm, nil
+}
+
+// reserve allocates whole cores with at least vcpus CPUs. Returns the
+// existing allocation if the domain already has one.
+func (cm *cpuManager) reserve(domainName string, vcpus int) ([]int, error) {
+	cm.Lock()
+	defer cm.Unlock()
+	if p, ok := cm.pinned[domainName]; ok && p.exclusive {
+		return p.cpus, nil
+	}
+	if vcpus <= 0 {
+		vcpus = 1
+	}
+	used := make(map[int]bool)
+	for _, p := range cm.pinned {
+		for _, cpu := range p.cpus {
+			used[cpu] = true
+		}
+	}
+	var cpus []int
+	// Allocate from the last core; the first one is for EVE
+	for i := len(cm.cores) - 1; i > 0 && len(cpus) < vcpus; i-- {
+		free := true
+		for _, cpu := range cm.cores[i] {
+			if used[cpu] {
+				free = false
+				break
+			}
+		}
+		if free {
+			cpus = append(cpus, cm.cores[i]...)
+		}
+	}
+	if len(cpus) < vcpus {
+		return nil, fmt.Errorf("not enough free cores for %d exclusive CPUs; %d CPUs available",
+			vcpus, len(cpus))
+	}
+	sort.Ints(cpus)
+	cm.pinned[domainName] = cpuPinning{cpus: cpus, exclusive: true}
+	return cpus, nil
+}
+
+// pin records CPUs set in the configuration after checking that they
+// exist and are not used exclusively by another domain
+func (cm *cpuManager) pin(domainName string, cpus []int) error {
+	cm.Lock()
+	defer cm.Unlock()
+	for _, cpu := range cpus {
+		if !cpuIn(cm.online, cpu) {
+			return fmt.Errorf("CPU %d is not online", cpu)
+		}
+		for name, p := range cm.pinned {
+			if p.exclusive && name != domainName && cpuIn(p.cpus, cpu) {
+				return fmt.Errorf("CPU %d is used exclusively by another app instance",
+					cpu)
+			}
+		}
+	}
+	cm.pinned[domainName] = cpuPinning{cpus: cpus}
+	return nil
+}
+
+// release returns true if the domain had exclusive CPUs
+func (cm *cpuManager) release(domainName string) bool {
+	cm.Lock()
+	defer cm.Unlock()
+	p, ok := cm.pinned[domainName]
+	delete(cm.pinned, domainName)
+	return ok && p.exclusive
+}
+
+// sharedCPUs returns the CPUs which are not used exclusively, and whether
+// any CPUs are used exclusively
+func (cm *cpuManager) sharedCPUs() ([]int, bool) {
+	cm.Lock()
+	defer cm.Unlock()
+	return cm.sharedCPUsLocked()
+}
+
+func (cm *cpuManager) sharedCPUsLocked() ([]int, bool) {
+	exclusive := make(map[int]bool)
+	for _, p := range cm.pinned {
+		if p.exclusive {
+			for _, cpu := range p.cpus {
+				exclusive[cpu] = true
+			}
+		}
+	}
+	var shared []int
+	for _, cpu := range cm.online {
+		if !exclusive[cpu] {
+			shared = append(shared, cpu)
+		}
+	}
+	return shared, len(exclusive) != 0
+}
+
+// applyIsolation moves the EVE services, the domains without exclusive
+// CPUs and the interrupts to the shared CPUs
+func (cm *cpuManager) applyIsolation() {
+	cm.Lock()
+	defer cm.Unlock()
+	shared, _ := cm.sharedCPUsLocked()
+	sharedList := formatCPUList(shared)
+	log.Noticef("applyIsolation: shared CPUs %s", sharedList)
+	setCgroupCPUs(filepath.Join(cpusetCgroupDir, eveCgroup), sharedList)
+	appsDir := filepath.Join(cpusetCgroupDir, appsCgroup)
+	entries, err := ioutil.ReadDir(appsDir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("applyIsolation: %s", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		cpus := sharedList
+		if p, ok := cm.pinned[entry.Name()]; ok {
+			cpus = formatCPUList(p.cpus)
+		}
+		setCgroupCPUs(filepath.Join(appsDir, entry.Name()), cpus)
+	}
+	setIrqAffinity(shared)
+}
+
+// setCgroupCPUs sets the cpuset of the cgroup and its children. The CPUs
+// of a child must be a subset of its parent hence the parent is written
+// before the children when adding CPUs and after when removing them.
+func setCgroupCPUs(dir string, cpus string) {
+	filename := filepath.Join(dir, "cpuset.cpus")
+	if _, err := os.Stat(filename); err != nil {
+		return
+	}
+	first := ioutil.WriteFile(filename, []byte(cpus), 0644)
+	entries, _ := ioutil.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			setCgroupCPUs(filepath.Join(dir, entry.Name()), cpus)
+		}
+	}
+	if first != nil {
+		if err := ioutil.WriteFile(filename, []byte(cpus), 0644); err != nil {
+			log.Errorf("setCgroupCPUs(%s, %s): %s", dir, cpus, err)
+		}
+	}
+}
+
+// setIrqAffinity moves the interrupts to the CPUs. Some interrupts can
+// not be moved which we ignore.
+func setIrqAffinity(cpus []int) {
+	err := ioutil.WriteFile(filepath.Join(irqDir, "default_smp_affinity"),
+		[]byte(formatCPUMask(cpus)), 0644)
+	if err != nil {
+		log.Errorf("setIrqAffinity default: %s", err)
+	}
+	entries, err := ioutil.ReadDir(irqDir)
+	if err != nil {
+		log.Errorf("setIrqAffinity: %s", err)
+		return
+	}
+	cpuList := formatCPUList(cpus)
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		filename := filepath.Join(irqDir, entry.Name(), "smp_affinity_list")
+		if err := ioutil.WriteFile(filename, []byte(cpuList), 0644); err != nil {
+			log.Functionf("setIrqAffinity IRQ %s: %s", entry.Name(), err)
+		}
+	}
+}
+
+// pinCPUs picks the CPUs of the domain and sets config.CPUs which the
+// hypervisor uses for the pinning. Domains without pinning are given
+// the shared CPUs if there are any exclusive ones.
+func pinCPUs(ctx *domainContext, config *types.DomainConfig,
+	status *types.DomainStatus) error {
+
+	cm := ctx.cpuManager
+	status.ExclusiveCPUs = false
+	status.CPUs = ""
+	if cm == nil {
+		if config.ExclusiveCPUs {
+			return fmt.Errorf("exclusive CPUs are not supported with hypervisor %s",
+				hyper.Name())
+		}
+		status.CPUs = config.CPUs
+		return nil
+	}
+	switch {
+	case config.ExclusiveCPUs:
+		cpus, err := cm.reserve(status.DomainName, config.VCpus)
+		if err != nil {
+			return err
+		}
+		config.CPUs = formatCPUList(cpus)
+		status.CPUs = config.CPUs
+		status.ExclusiveCPUs = true
+		log.Noticef("pinCPUs(%s) exclusive CPUs %s", status.Key(),
+			config.CPUs)
+		cm.applyIsolation()
+	case config.CPUs != "":
+		cpus, err := parseCPUList(config.CPUs)
+		if err != nil {
+			return fmt.Errorf("invalid CPUs %s: %v", config.CPUs, err)
+		}
+		if err := cm.pin(status.DomainName, cpus); err != nil {
+			return err
+		}
+		status.CPUs = config.CPUs
+	default:
+		if shared, exclusive := cm.sharedCPUs(); exclusive {
+			config.CPUs = formatCPUList(shared)
+		}
+	}
+	return nil
+}
+
+// unpinCPUs releases the CPUs of the domain
+func unpinCPUs(ctx *domainContext, status *types.DomainStatus) {
+	cm := ctx.cpuManager
+	if cm == nil {
+		return
+	}
+	if cm.release(status.DomainName) {
+		log.Noticef("unpinCPUs(%s) released CPUs %s", status.Key(),
+			status.CPUs)
+		cm.applyIsolation()
+	}
+	status.CPUs = ""
+	status.ExclusiveCPUs = false
+}
+
+func cpuIn(cpus []int, cpu int) bool {
+	for _, c := range cpus {
+		if c == cpu {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCPUList parses the kernel format e.g. "0-3,8"
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return nil, errors.New("empty CPU list")
+	}
+	for _, item := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(item), "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("invalid range %s", item)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			if !cpuIn(cpus, cpu) {
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// formatCPUList returns e.g. "2,3,6,7" as used for VmConfig.CPUs
+func formatCPUList(cpus []int) string {
+	var items []string
+	for _, cpu := range cpus {
+		items = append(items, strconv.Itoa(cpu))
+	}
+	return strings.Join(items, ",")
+}
+
+// formatCPUMask returns the hex mask with comma separated 32 bit words
+func formatCPUMask(cpus []int) string {
+	mask := new(big.Int)
+	for _, cpu := range cpus {
+		mask.SetBit(mask, cpu, 1)
+	}
+	hex := mask.Text(16)
+	if pad := len(hex) % 8; pad != 0 {
+		hex = strings.Repeat("0", 8-pad) + hex
+	}
+	var words []string
+	for i := 0; i < len(hex); i += 8 {
+		words = append(words, hex[i:i+8])
+	}
+	return strings.Join(words, ",")
+}
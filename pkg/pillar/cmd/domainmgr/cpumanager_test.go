@@ -0,0 +1,165 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTopology creates a sysfs CPU directory with cores of two threads
+// where CPU n and n+cores are siblings
+func writeTopology(t *testing.T, dir string, cores int) {
+	online := fmt.Sprintf("0-%d", 2*cores-1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "online"), []byte(online+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for cpu := 0; cpu < 2*cores; cpu++ {
+		topology := filepath.Join(dir, fmt.Sprintf("cpu%d", cpu), "topology")
+		if err := os.MkdirAll(topology, 0755); err != nil {
+			t.Fatal(err)
+		}
+		core := cpu % cores
+		siblings := fmt.Sprintf("%d,%d\n", core, core+cores)
+		err := ioutil.WriteFile(filepath.Join(topology, "thread_siblings_list"),
+			[]byte(siblings), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCPUManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTopology(t, dir, 4)
+	cm, err := newCPUManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCores := [][]int{{0, 4}, {1, 5}, {2, 6}, {3, 7}}
+	if !reflect.DeepEqual(cm.cores, expectedCores) {
+		t.Fatalf("cores %v, expected %v", cm.cores, expectedCores)
+	}
+
+	// Steps are run in order since they depend on the earlier allocations
+	steps := []struct {
+		name       string
+		domain     string
+		vcpus      int
+		pin        []int
+		release    bool
+		expectFail bool
+		expected   []int
+		shared     []int
+	}{
+		{name: "Reserve one core", domain: "a", vcpus: 2,
+			expected: []int{3, 7}, shared: []int{0, 1, 2, 4, 5, 6}},
+		{name: "Reserve is idempotent", domain: "a", vcpus: 2,
+			expected: []int{3, 7}, shared: []int{0, 1, 2, 4, 5, 6}},
+		{name: "Round up to cores", domain: "b", vcpus: 3,
+			expected: []int{1, 2, 5, 6}, shared: []int{0, 4}},
+		{name: "First core is for EVE", domain: "c", vcpus: 1,
+			expectFail: true, shared: []int{0, 4}},
+		{name: "Pin to exclusive CPU", domain: "d", pin: []int{0, 3},
+			expectFail: true, shared: []int{0, 4}},
+		{name: "Pin to shared CPU", domain: "d", pin: []int{0},
+			shared: []int{0, 4}},
+		{name: "Pin to offline CPU", domain: "e", pin: []int{8},
+			expectFail: true, shared: []int{0, 4}},
+		{name: "Release", domain: "b", release: true,
+			shared: []int{0, 1, 2, 4, 5, 6}},
+		{name: "Reserve skips pinned", domain: "c", vcpus: 4,
+			expected: []int{1, 2, 5, 6}, shared: []int{0, 4}},
+	}
+	for _, step := range steps {
+		t.Logf("Running step %s", step.name)
+		var cpus []int
+		var err error
+		switch {
+		case step.release:
+			cm.release(step.domain)
+		case step.pin != nil:
+			err = cm.pin(step.domain, step.pin)
+		default:
+			cpus, err = cm.reserve(step.domain, step.vcpus)
+		}
+		if (err != nil) != step.expectFail {
+			t.Errorf("%s: error %v, expected failure %t", step.name,
+				err, step.expectFail)
+		}
+		if !reflect.DeepEqual(cpus, step.expected) {
+			t.Errorf("%s: CPUs %v, expected %v", step.name,
+				cpus, step.expected)
+		}
+		shared, _ := cm.sharedCPUs()
+		if !reflect.DeepEqual(shared, step.shared) {
+			t.Errorf("%s: shared CPUs %v, expected %v", step.name,
+				shared, step.shared)
+		}
+	}
+}
+
+func TestCPUListFormats(t *testing.T) {
+	testMatrix := map[string]struct {
+		list       string
+		expectFail bool
+		expected   []int
+		mask       string
+	}{
+		"Single": {
+			list:     "0",
+			expected: []int{0},
+			mask:     "00000001",
+		},
+		"Ranges": {
+			list:     "0-2,8,5-6",
+			expected: []int{0, 1, 2, 5, 6, 8},
+			mask:     "00000167",
+		},
+		"Above 32": {
+			list:     "1,32-33",
+			expected: []int{1, 32, 33},
+			mask:     "00000003,00000002",
+		},
+		"Empty": {
+			expectFail: true,
+		},
+		"Bad range": {
+			list:       "3-1",
+			expectFail: true,
+		},
+		"Not a number": {
+			list:       "a",
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		cpus, err := parseCPUList(test.list)
+		if (err != nil) != test.expectFail {
+			t.Errorf("%s: error %v, expected failure %t", testname,
+				err, test.expectFail)
+			continue
+		}
+		if test.expectFail {
+			continue
+		}
+		if !reflect.DeepEqual(cpus, test.expected) {
+			t.Errorf("%s: CPUs %v, expected %v", testname,
+				cpus, test.expected)
+		}
+		if mask := formatCPUMask(cpus); mask != test.mask {
+			t.Errorf("%s: mask %s, expected %s", testname,
+				mask, test.mask)
+		}
+	}
+}
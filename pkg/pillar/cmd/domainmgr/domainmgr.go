@@ -35,6 +35,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/pidfile"
 	"github.com/lf-edge/eve/pkg/pillar/pubsub"
 	"github.com/lf-edge/eve/pkg/pillar/sema"
+	"github.com/lf-edge/eve/pkg/pillar/tracing"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/utils"
 	uuid "github.com/satori/go.uuid"
@@ -84,6 +85,7 @@ type domainContext struct {
 	pubDomainMetric        pubsub.Publication
 	pubHostMemory          pubsub.Publication
 	pubProcessMetric       pubsub.Publication
+	pubSensorMetric        pubsub.Publication
 	pubCipherBlockStatus   pubsub.Publication
 	usbAccess              bool
 	createSema             *sema.Semaphore
@@ -92,7 +94,12 @@ type domainContext struct {
 	GCInitialized          bool
 	domainBootRetryTime    uint32 // In seconds
 	metricInterval         uint32 // In seconds
+	sensorsIPMI            bool
 	pids                   map[int32]bool
+	cpuManager             *cpuManager      // nil if not supported
+	hugepages              *hugepageManager // nil if not supported
+	usbDevices             *usbManager
+	guestFilesystems       *guestFilesystems
 	// Common CAS client which can be used by multiple routines.
 	// There is no shared data so its safe to be used by multiple goroutines
 	casClient cas.CAS
@@ -108,10 +115,12 @@ var debugOverride bool          // From command line arg
 var hyper hypervisor.Hypervisor // Current hypervisor
 var logger *logrus.Logger
 var log *base.LogObject
+var tracer *tracing.Tracer
 
 func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
 	logger = loggerArg
 	log = logArg
+	tracer = tracing.NewTracer(log, agentName)
 	var err error
 	handlersInit()
 	allHypervisors, enabledHypervisors := hypervisor.GetAvailableHypervisors()
@@ -191,6 +200,28 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	aa := types.AssignableAdapters{}
 	domainCtx.assignableAdapters = &aa
 
+	switch hyper.Name() {
+	case "kvm", "containerd":
+		cm, err := newCPUManager(cpuSysDir)
+		if err != nil {
+			log.Errorf("No CPU pinning: %s", err)
+			break
+		}
+		domainCtx.cpuManager = cm
+		// Undo any isolation from before we restarted
+		cm.applyIsolation()
+	}
+	domainCtx.usbDevices = newUsbManager(usbSysDir)
+	domainCtx.guestFilesystems = newGuestFilesystems()
+	if hyper.Name() == "kvm" {
+		hm, err := newHugepageManager(hugepagesSysDir, hugepagesStateFile)
+		if err != nil {
+			log.Errorf("No hugepages: %s", err)
+		} else {
+			domainCtx.hugepages = hm
+		}
+	}
+
 	// Allow only one concurrent domain create
 	domainCtx.createSema = sema.New(log, 1)
 	domainCtx.createSema.P(1)
@@ -236,6 +267,16 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	}
 	domainCtx.pubProcessMetric = pubProcessMetric
 
+	pubSensorMetric, err := ps.NewPublication(
+		pubsub.PublicationOptions{
+			AgentName: agentName,
+			TopicType: types.SensorMetric{},
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	domainCtx.pubSensorMetric = pubSensorMetric
+
 	pubHostMemory, err := ps.NewPublication(
 		pubsub.PublicationOptions{
 			AgentName: agentName,
@@ -585,6 +626,9 @@ func handleRestart(ctxArg interface{}, done bool) {
 	ctx := ctxArg.(*domainContext)
 	if done {
 		log.Functionf("handleRestart: avoid cleanup")
+		if ctx.hugepages != nil {
+			ctx.hugepages.setRestarted()
+		}
 		ctx.pubDomainStatus.SignalRestarted()
 		return
 	}
@@ -663,7 +707,11 @@ func handleDomainModify(ctxArg interface{}, key string, configArg interface{},
 	oldConfigArg interface{}) {
 
 	log.Functionf("handleDomainModify(%s)", key)
+	ctx := ctxArg.(*domainContext)
 	config := configArg.(types.DomainConfig)
+	if ctx.hugepages != nil {
+		ctx.hugepages.update(config.Key(), config)
+	}
 	h, ok := handlerMap[config.Key()]
 	if !ok {
 		log.Fatalf("handleDomainModify called on config that does not exist")
@@ -686,6 +734,9 @@ func handleDomainCreate(ctxArg interface{}, key string, configArg interface{}) {
 	if ok {
 		log.Fatalf("handleDomainCreate called on config that already exists")
 	}
+	if ctx.hugepages != nil {
+		ctx.hugepages.update(config.Key(), config)
+	}
 	h1 := make(chan Notify, 1)
 	handlerMap[config.Key()] = h1
 	log.Functionf("Creating %s at %s", "runHandler", agentlog.GetMyStack())
@@ -711,6 +762,9 @@ func handleDomainDelete(ctxArg interface{}, key string,
 	if config.IsCipher || config.CloudInitUserData != nil {
 		unpublishCipherBlockStatus(ctx, config.Key())
 	}
+	if ctx.hugepages != nil {
+		ctx.hugepages.release(config.Key())
+	}
 	// Do we have a channel/goroutine?
 	h, ok := handlerMap[key]
 	if ok {
@@ -725,7 +779,9 @@ func handleDomainDelete(ctxArg interface{}, key string,
 }
 
 // Server for each domU
-// Runs timer every 30 seconds to update status
+// Runs timer every 30 seconds to update status, a timer every second
+// to run the health probes and ask the guest agent, and one for the USB
+// devices
 func runHandler(ctx *domainContext, key string, c <-chan Notify) {
 
 	log.Functionf("runHandler starting")
@@ -735,6 +791,19 @@ func runHandler(ctx *domainContext, key string, c <-chan Notify) {
 	min := max * 0.3
 	ticker := flextimer.NewRangeTicker(time.Duration(min),
 		time.Duration(max))
+	probeTicker := time.NewTicker(probeInterval)
+	defer probeTicker.Stop()
+	probes := newDomainProbes()
+	defer close(probes.done)
+	usbTicker := time.NewTicker(usbInterval)
+	defer usbTicker.Stop()
+	usb := newDomainUsb()
+	defer ctx.usbDevices.releaseAll(key)
+	guest := newDomainGuest()
+	defer close(guest.done)
+	defer ctx.guestFilesystems.set(key, nil)
+	containerTicker := time.NewTicker(containerInterval)
+	defer containerTicker.Stop()
 
 	closed := false
 	for !closed {
@@ -769,6 +838,26 @@ func runHandler(ctx *domainContext, key string, c <-chan Notify) {
 				verifyStatus(ctx, status)
 				maybeRetry(ctx, status)
 			}
+		case <-probeTicker.C:
+			status := lookupDomainStatus(ctx, key)
+			if status != nil {
+				runProbes(ctx, status, probes)
+				updateGuestInfo(ctx, status, guest)
+			}
+		case res := <-probes.results:
+			probeDone(ctx, key, probes, res)
+		case res := <-guest.results:
+			guestInfoDone(ctx, key, guest, res)
+		case <-usbTicker.C:
+			status := lookupDomainStatus(ctx, key)
+			if status != nil {
+				updateUsbDevices(ctx, status, usb)
+			}
+		case <-containerTicker.C:
+			status := lookupDomainStatus(ctx, key)
+			if status != nil {
+				updateContainers(ctx, status)
+			}
 		}
 	}
 	log.Functionf("runHandler(%s) DONE", key)
@@ -996,6 +1085,7 @@ func handleCreate(ctx *domainContext, key string, config *types.DomainConfig) {
 		VncDisplay:         config.VncDisplay,
 		VncPasswd:          config.VncPasswd,
 		State:              types.INSTALLED,
+		DependsOn:          config.DependsOn,
 	}
 	// Note that the -emu interface doesn't exist until after boot of the domU, but we
 	// initialize the VifList here with the VifUsed.
@@ -1147,6 +1237,15 @@ func doActivate(ctx *domainContext, config types.DomainConfig,
 
 	log.Functionf("doActivate(%v) for %s",
 		config.UUIDandVersion, config.DisplayName)
+	span := tracer.Start(config.Trace, "boot", "app", config.DisplayName)
+	start := time.Now()
+	defer func() {
+		if status.HasError() && !status.ErrorTime.Before(start) {
+			span.End(status.Error)
+		} else {
+			span.End("")
+		}
+	}()
 	if status.AdaptersFailed || status.PendingModify {
 		if err := configAdapters(ctx, config); err != nil {
 			log.Errorf("Failed to reserve adapters for %v: %s",
@@ -1198,6 +1297,26 @@ func doActivate(ctx *domainContext, config types.DomainConfig,
 		}
 	}
 
+	if err := pinCPUs(ctx, &config, status); err != nil {
+		log.Errorf("Failed to pin CPUs for %s: %s", status.Key(), err)
+		status.SetErrorNow(err.Error())
+		return
+	}
+
+	if err := checkHugepages(ctx, config); err != nil {
+		log.Errorf("Failed to get hugepages for %s: %s", status.Key(), err)
+		unpinCPUs(ctx, status)
+		status.SetErrorNow(err.Error())
+		return
+	}
+
+	if err := startVTpm(config, status); err != nil {
+		log.Errorf("Failed to start vTPM for %s: %s", status.Key(), err)
+		unpinCPUs(ctx, status)
+		status.SetErrorNow(err.Error())
+		return
+	}
+
 	filename := xenCfgFilename(config.AppNum)
 	file, err := os.Create(filename)
 	if err != nil {
@@ -1398,6 +1517,8 @@ func doInactivate(ctx *domainContext, status *types.DomainStatus, impatient bool
 	} else {
 		status.Activated = false
 		status.State = types.HALTED
+		unpinCPUs(ctx, status)
+		stopVTpm(status)
 	}
 	publishDomainStatus(ctx, status)
 
@@ -1479,6 +1600,7 @@ func configToStatus(ctx *domainContext, config types.DomainConfig,
 		ds.Format = dc.Format
 		ds.MountDir = dc.MountDir
 		ds.DisplayName = dc.DisplayName
+		ds.Container = dc.Container
 		// Generate Devtype for hypervisor package
 		// XXX can hypervisor look at something different?
 		if dc.Format == zconfig.Format_CONTAINER {
@@ -1499,13 +1621,15 @@ func configToStatus(ctx *domainContext, config types.DomainConfig,
 		ds.Vdev = fmt.Sprintf("xvd%c", int('a')+i)
 	}
 
-	if config.IsCipher || config.CloudInitUserData != nil {
+	if hasCloudInitData(config) {
 		if status.OCIConfigDir != "" {
-			envList, err := fetchEnvVariablesFromCloudInit(ctx, config)
-			if err != nil {
-				return fmt.Errorf("failed to fetch environment variable from userdata. %s", err.Error())
+			if config.IsCipher || config.CloudInitUserData != nil {
+				envList, err := fetchEnvVariablesFromCloudInit(ctx, config)
+				if err != nil {
+					return fmt.Errorf("failed to fetch environment variable from userdata. %s", err.Error())
+				}
+				status.EnvVariables = envList
 			}
-			status.EnvVariables = envList
 		} else {
 			ds, err := createCloudInitISO(ctx, config)
 			if err != nil {
@@ -1522,7 +1646,7 @@ func configToStatus(ctx *domainContext, config types.DomainConfig,
 			ReadOnly:     false,
 		})
 	}
-	return nil
+	return checkContainers(config, status)
 }
 
 // Check and reserve any assigned adapters
@@ -1591,6 +1715,7 @@ func handleModify(ctx *domainContext, key string,
 		config.UUIDandVersion, config.Activate, config.DisplayName)
 
 	status.PendingModify = true
+	status.DependsOn = config.DependsOn
 	publishDomainStatus(ctx, status)
 
 	changed := false
@@ -1766,7 +1891,10 @@ func handleDelete(ctx *domainContext, key string, status *types.DomainStatus) {
 		doInactivate(ctx, status, true)
 	} else {
 		pciUnassign(ctx, status, true)
+		unpinCPUs(ctx, status)
+		stopVTpm(status)
 	}
+	deleteVTpm(types.VTpmDirName, status.UUIDandVersion.UUID.String())
 
 	// Look for any adapters used by us and clear UsedByUUID
 	// XXX zedagent might assume that the setting to nil arrives before
@@ -1823,6 +1951,21 @@ func DomainShutdown(status types.DomainStatus, force bool) error {
 	var err error
 	log.Functionf("DomainShutdown force-%v %s %d", force, status.DomainName, status.DomainId)
 
+	// The guest agent does a cleaner shutdown than the ACPI power button
+	// which some guests ignore. Containers which do not run in a VM have
+	// no guest agent.
+	agent, ok := hyper.(hypervisor.GuestAgent)
+	if ok && !force && status.VirtualizationMode != types.NOHYPER {
+		err = agent.GuestShutdown(status.DomainName, guestShutdownTimeout)
+		if err == nil {
+			log.Noticef("Guest agent shutting down domain - %s",
+				status.DomainName)
+			return nil
+		}
+		log.Functionf("Guest agent shutdown of %s failed: %s",
+			status.DomainName, err)
+	}
+
 	// Stop the domain
 	log.Functionf("Stopping domain - %s", status.DomainName)
 	err = hyper.Task(&status).Stop(status.DomainName, status.DomainId, force)
@@ -1918,6 +2061,8 @@ func handleGlobalConfigImpl(ctxArg interface{}, key string,
 		if gcp.GlobalValueInt(types.MetricInterval) != 0 {
 			ctx.metricInterval = gcp.GlobalValueInt(types.MetricInterval)
 		}
+		ctx.sensorsIPMI = gcp.GlobalValueBool(types.SensorsIPMI)
+		tracer.UpdateConfig(gcp)
 		ctx.GCInitialized = true
 	}
 	log.Functionf("handleGlobalConfigImpl done for %s. "+
@@ -2028,7 +2173,8 @@ func cloudInitISOFileLocation(ctx *domainContext, config types.DomainConfig) str
 		ciDirname, config.UUIDandVersion.UUID.String())
 }
 
-// Create a isofs with user-data and meta-data and add it to DiskStatus
+// Create a isofs with user-data, meta-data, network-config and vendor-data
+// and add it to DiskStatus
 // We do this in domainmgr and keep it in /run (and not volumemgr and /persist)
 // since it 1) potentially contains confidential info like passwords,
 // 2) only needed if we run as a VM, and 3) the data might be changed
@@ -2049,30 +2195,51 @@ func createCloudInitISO(ctx *domainContext,
 	if err != nil {
 		log.Fatalf("createCloudInitISO failed %s", err)
 	}
-	metafile.WriteString(fmt.Sprintf("instance-id: %s/%s\n",
-		config.UUIDandVersion.UUID.String(),
-		config.UUIDandVersion.Version))
-	metafile.WriteString(fmt.Sprintf("local-hostname: %s\n",
-		config.UUIDandVersion.UUID.String()))
+	metafile.WriteString(cloudInitMetaData(config))
 	metafile.Close()
 
-	userfile, err := os.Create(dir + "/user-data")
-	if err != nil {
-		log.Fatalf("createCloudInitISO failed %s", err)
+	if networkConfig := cloudInitNetworkConfig(config); networkConfig != "" {
+		netfile, err := os.Create(dir + "/network-config")
+		if err != nil {
+			log.Fatalf("createCloudInitISO failed %s", err)
+		}
+		netfile.WriteString(networkConfig)
+		netfile.Close()
 	}
 
-	decBlock, err := getCloudInitUserData(ctx, config)
+	// NoCloud needs user-data even if it is empty
+	userfile, err := os.Create(dir + "/user-data")
 	if err != nil {
-		return nil, err
+		log.Fatalf("createCloudInitISO failed %s", err)
 	}
-	ud, err := base64.StdEncoding.DecodeString(decBlock.ProtectedUserData)
-	if err != nil {
-		errStr := fmt.Sprintf("createCloudInitISO failed %s", err)
-		return nil, errors.New(errStr)
+	if config.IsCipher || config.CloudInitUserData != nil {
+		decBlock, err := getCloudInitUserData(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		ud, err := base64.StdEncoding.DecodeString(decBlock.ProtectedUserData)
+		if err != nil {
+			errStr := fmt.Sprintf("createCloudInitISO failed %s", err)
+			return nil, errors.New(errStr)
+		}
+		userfile.WriteString(string(ud))
 	}
-	userfile.WriteString(string(ud))
 	userfile.Close()
 
+	if config.CloudInitVendorData != "" {
+		vd, err := base64.StdEncoding.DecodeString(config.CloudInitVendorData)
+		if err != nil {
+			errStr := fmt.Sprintf("createCloudInitISO vendor-data failed %s", err)
+			return nil, errors.New(errStr)
+		}
+		vendorfile, err := os.Create(dir + "/vendor-data")
+		if err != nil {
+			log.Fatalf("createCloudInitISO failed %s", err)
+		}
+		vendorfile.WriteString(string(vd))
+		vendorfile.Close()
+	}
+
 	if err := mkisofs(fileName, dir); err != nil {
 		errStr := fmt.Sprintf("createCloudInitISO failed %s", err)
 		return nil, errors.New(errStr)
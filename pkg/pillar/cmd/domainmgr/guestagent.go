@@ -0,0 +1,144 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Information from the QEMU guest agent running in the domains. The
+// runHandler of each running domain periodically asks the agent for the
+// IP addresses, the OS and the filesystems of the domain in a go routine
+// which reports back to the runHandler. The filesystems go into the
+// DomainMetric while the rest is published in DomainStatus.GuestInfo.
+
+package domainmgr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	guestInfoInterval = time.Minute
+	// For each command; the domains without an agent take that long
+	guestAgentTimeout = 5 * time.Second
+	// The guest-shutdown is tried first when halting, hence shorter
+	guestShutdownTimeout = 2 * time.Second
+)
+
+type guestInfoResult struct {
+	bootTime    time.Time
+	info        types.GuestInfo
+	filesystems []types.GuestFilesystem
+	err         error
+}
+
+// domainGuest is the guest agent state of one domain, only used by its
+// runHandler
+type domainGuest struct {
+	running bool
+	lastRun time.Time
+	results chan guestInfoResult
+	done    chan struct{}
+}
+
+func newDomainGuest() *domainGuest {
+	return &domainGuest{
+		results: make(chan guestInfoResult),
+		done:    make(chan struct{}),
+	}
+}
+
+// guestFilesystems is updated by the runHandlers and read by the metrics
+// task hence the mutex
+type guestFilesystems struct {
+	sync.Mutex
+	byKey map[string][]types.GuestFilesystem
+}
+
+func newGuestFilesystems() *guestFilesystems {
+	return &guestFilesystems{byKey: make(map[string][]types.GuestFilesystem)}
+}
+
+func (gf *guestFilesystems) set(key string, filesystems []types.GuestFilesystem) {
+	gf.Lock()
+	defer gf.Unlock()
+	if len(filesystems) == 0 {
+		delete(gf.byKey, key)
+	} else {
+		gf.byKey[key] = filesystems
+	}
+}
+
+func (gf *guestFilesystems) get(key string) []types.GuestFilesystem {
+	gf.Lock()
+	defer gf.Unlock()
+	return gf.byKey[key]
+}
+
+// updateGuestInfo starts asking the guest agent when it is time, and
+// clears what it reported once the domain is no longer running
+func updateGuestInfo(ctx *domainContext, status *types.DomainStatus,
+	dg *domainGuest) {
+
+	agent, ok := hyper.(hypervisor.GuestAgent)
+	if !ok {
+		return
+	}
+	if !status.Activated || status.State != types.RUNNING {
+		dg.lastRun = time.Time{}
+		setGuestInfo(ctx, status, types.GuestInfo{}, nil)
+		return
+	}
+	if dg.running || time.Since(dg.lastRun) < guestInfoInterval {
+		return
+	}
+	dg.running = true
+	dg.lastRun = time.Now()
+	domainName := status.DomainName
+	bootTime := status.BootTime
+	go func() {
+		info, filesystems, err := agent.GuestInfo(domainName,
+			guestAgentTimeout)
+		res := guestInfoResult{
+			bootTime:    bootTime,
+			info:        info,
+			filesystems: filesystems,
+			err:         err,
+		}
+		select {
+		case dg.results <- res:
+		case <-dg.done:
+		}
+	}()
+}
+
+// guestInfoDone is called by the runHandler with the result
+func guestInfoDone(ctx *domainContext, key string, dg *domainGuest,
+	res guestInfoResult) {
+
+	dg.running = false
+	status := lookupDomainStatus(ctx, key)
+	if status == nil || !res.bootTime.Equal(status.BootTime) {
+		return
+	}
+	if res.err != nil {
+		// Most likely no agent in the domain
+		log.Functionf("guestInfoDone(%s): %s", key, res.err)
+		setGuestInfo(ctx, status, types.GuestInfo{}, nil)
+		return
+	}
+	setGuestInfo(ctx, status, res.info, res.filesystems)
+}
+
+func setGuestInfo(ctx *domainContext, status *types.DomainStatus,
+	info types.GuestInfo, filesystems []types.GuestFilesystem) {
+
+	ctx.guestFilesystems.set(status.Key(), filesystems)
+	if cmp.Equal(status.GuestInfo, info) {
+		return
+	}
+	log.Functionf("setGuestInfo(%s) %+v", status.Key(), info)
+	status.GuestInfo = info
+	publishDomainStatus(ctx, status)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Hugepage pool for the domains with Hugepages. The pool of each page size
+// is sized for all the deployed domains using it, whether activated or not,
+// so that the pages are there when the domain boots. The sizes are saved
+// in /persist and reserved again when domainmgr starts at boot, before
+// the memory gets fragmented; 1G pages can rarely be allocated later.
+// The pools are not shrunk until we have seen all the DomainConfigs.
+// Only used with kvm where qemu maps the pages from a hugetlbfs mount.
+
+package domainmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	fileutils "github.com/lf-edge/eve/pkg/pillar/utils/file"
+)
+
+const (
+	hugepagesSysDir = "/sys/kernel/mm/hugepages"
+	// Number of pages of each size to reserve at boot
+	hugepagesStateFile = types.PersistStatusDir + "/hugepages.json"
+)
+
+var hugepageSizes = []types.HugepageSize{types.Hugepage2M, types.Hugepage1G}
+
+// hugepageNeed is what a domain uses from the pool
+type hugepageNeed struct {
+	size  types.HugepageSize
+	pages uint64
+}
+
+// hugepageManager is used by the runHandler of all domains hence the mutex
+type hugepageManager struct {
+	sync.Mutex
+	sysDir    string
+	stateFile string
+	needs     map[string]hugepageNeed // Key is the DomainConfig key
+	restarted bool                    // Have all the DomainConfigs
+}
+
+// newHugepageManager reserves the pages saved in stateFile
+func newHugepageManager(sysDir string, stateFile string) (*hugepageManager, error) {
+	if _, err := os.Stat(sysDir); err != nil {
+		return nil, err
+	}
+	hm := &hugepageManager{
+		sysDir:    sysDir,
+		stateFile: stateFile,
+		needs:     make(map[string]hugepageNeed),
+	}
+	data, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("newHugepageManager: %s", err)
+		}
+		return hm, nil
+	}
+	var saved map[string]uint64
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Errorf("newHugepageManager: %s: %s", stateFile, err)
+		return hm, nil
+	}
+	for _, size := range hugepageSizes {
+		pages, ok := saved[size.String()]
+		if !ok || !hm.supported(size) {
+			continue
+		}
+		if err := hm.setPool(size, pages); err != nil {
+			log.Errorf("newHugepageManager: %s", err)
+		} else {
+			log.Noticef("newHugepageManager: reserved %d %s pages",
+				pages, size)
+		}
+	}
+	return hm, nil
+}
+
+// sizeDir returns the sysfs directory for the page size
+func (hm *hugepageManager) sizeDir(size types.HugepageSize) string {
+	return filepath.Join(hm.sysDir,
+		fmt.Sprintf("hugepages-%dkB", size.Bytes()>>10))
+}
+
+// supported returns true if the CPU and kernel support the page size
+func (hm *hugepageManager) supported(size types.HugepageSize) bool {
+	_, err := os.Stat(hm.sizeDir(size))
+	return err == nil
+}
+
+func (hm *hugepageManager) readCount(size types.HugepageSize, name string) (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(hm.sizeDir(size), name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// setPool asks the kernel for the number of pages and returns an error if
+// it could not allocate them all
+func (hm *hugepageManager) setPool(size types.HugepageSize, pages uint64) error {
+	filename := filepath.Join(hm.sizeDir(size), "nr_hugepages")
+	if err := ioutil.WriteFile(filename,
+		[]byte(strconv.FormatUint(pages, 10)), 0644); err != nil {
+		return err
+	}
+	reserved, err := hm.readCount(size, "nr_hugepages")
+	if err != nil {
+		return err
+	}
+	if reserved < pages {
+		return fmt.Errorf("reserved only %d of %d %s pages",
+			reserved, pages, size)
+	}
+	return nil
+}
+
+// update sets what the domain needs and resizes the pools
+func (hm *hugepageManager) update(key string, config types.DomainConfig) {
+	hm.Lock()
+	defer hm.Unlock()
+	size := config.Hugepages
+	_, exists := hm.needs[key]
+	if size.Bytes() == 0 {
+		if !exists {
+			return
+		}
+		delete(hm.needs, key)
+	} else {
+		hm.needs[key] = hugepageNeed{
+			size:  size,
+			pages: size.RoundUpMemory(config.Memory) / size.Bytes(),
+		}
+	}
+	hm.resize()
+}
+
+// setRestarted is called once we have all the DomainConfigs
+func (hm *hugepageManager) setRestarted() {
+	hm.Lock()
+	defer hm.Unlock()
+	hm.restarted = true
+	hm.resize()
+}
+
+// release removes the domain from the pools
+func (hm *hugepageManager) release(key string) {
+	hm.Lock()
+	defer hm.Unlock()
+	if _, exists := hm.needs[key]; !exists {
+		return
+	}
+	delete(hm.needs, key)
+	hm.resize()
+}
+
+// resize sets the pools to what the domains need and saves the sizes.
+// Pages which are in use when we shrink a pool are freed by the kernel
+// once the domain is halted.
+func (hm *hugepageManager) resize() {
+	totals := make(map[string]uint64)
+	for _, size := range hugepageSizes {
+		var pages uint64
+		for _, need := range hm.needs {
+			if need.size == size {
+				pages += need.pages
+			}
+		}
+		if !hm.supported(size) {
+			if pages != 0 {
+				log.Errorf("resize: %s pages not supported", size)
+			}
+			continue
+		}
+		if !hm.restarted {
+			// Keep what we reserved at boot for the domains to come
+			reserved, err := hm.readCount(size, "nr_hugepages")
+			if err != nil {
+				log.Errorf("resize: %s", err)
+			} else if reserved > pages {
+				pages = reserved
+			}
+		}
+		if err := hm.setPool(size, pages); err != nil {
+			log.Errorf("resize: %s", err)
+		}
+		log.Functionf("resize: %d %s pages", pages, size)
+		totals[size.String()] = pages
+	}
+	data, err := json.Marshal(totals)
+	if err != nil {
+		log.Fatalf("resize: json.Marshal: %s", err)
+	}
+	if err := fileutils.WriteRename(hm.stateFile, data); err != nil {
+		log.Errorf("resize: %s", err)
+	}
+}
+
+// check returns an error if there are not enough free pages for the domain
+func (hm *hugepageManager) check(key string) error {
+	hm.Lock()
+	defer hm.Unlock()
+	need, exists := hm.needs[key]
+	if !exists {
+		return nil
+	}
+	if !hm.supported(need.size) {
+		return fmt.Errorf("%s hugepages not supported", need.size)
+	}
+	free, err := hm.readCount(need.size, "free_hugepages")
+	if err != nil {
+		return err
+	}
+	if free >= need.pages {
+		return nil
+	}
+	// The kernel might be able to allocate them now
+	hm.resize()
+	free, err = hm.readCount(need.size, "free_hugepages")
+	if err != nil {
+		return err
+	}
+	if free < need.pages {
+		return fmt.Errorf("not enough %s hugepages: need %d, free %d",
+			need.size, need.pages, free)
+	}
+	return nil
+}
+
+// poolBytes returns the memory reserved in all the pools
+func (hm *hugepageManager) poolBytes() uint64 {
+	hm.Lock()
+	defer hm.Unlock()
+	var total uint64
+	for _, size := range hugepageSizes {
+		if !hm.supported(size) {
+			continue
+		}
+		pages, err := hm.readCount(size, "nr_hugepages")
+		if err != nil {
+			log.Errorf("poolBytes: %s", err)
+			continue
+		}
+		total += pages * size.Bytes()
+	}
+	return total
+}
+
+// checkHugepages returns an error if the domain can not get its hugepages
+func checkHugepages(ctx *domainContext, config types.DomainConfig) error {
+	if config.Hugepages.Bytes() == 0 {
+		return nil
+	}
+	if ctx.hugepages == nil {
+		return fmt.Errorf("hugepages not supported with %s hypervisor",
+			hyper.Name())
+	}
+	return ctx.hugepages.check(config.Key())
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+// writeHugepages creates a sysfs hugepages directory with 2M pages only
+func writeHugepages(t *testing.T, dir string) {
+	sizeDir := filepath.Join(dir, "hugepages-2048kB")
+	if err := os.MkdirAll(sizeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"nr_hugepages", "free_hugepages"} {
+		err := ioutil.WriteFile(filepath.Join(sizeDir, name), []byte("0\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHugepageManager(t *testing.T) {
+	log = base.NewSourceLogObject(logrus.StandardLogger(), "domainmgr", 0)
+	dir, err := ioutil.TempDir("", "hugepages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sysDir := filepath.Join(dir, "sys")
+	stateFile := filepath.Join(dir, "hugepages.json")
+	writeHugepages(t, sysDir)
+	if err := ioutil.WriteFile(stateFile, []byte(`{"2M":8}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hm, err := newHugepageManager(sysDir, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool := hm.poolBytes(); pool != 8*2<<20 {
+		t.Fatalf("pool %d after boot, expected %d", pool, 8*2<<20)
+	}
+
+	// Steps are run in order since they depend on the earlier ones
+	steps := []struct {
+		name         string
+		key          string
+		size         types.HugepageSize
+		memoryKB     int
+		release      bool
+		restarted    bool
+		expectedPool uint64
+	}{
+		{
+			name:         "Keep the boot reservation",
+			key:          "app1",
+			size:         types.Hugepage2M,
+			memoryKB:     3 * 1024,
+			expectedPool: 8 * 2 << 20,
+		},
+		{
+			name:         "Shrink once restarted",
+			restarted:    true,
+			expectedPool: 2 * 2 << 20,
+		},
+		{
+			name:         "Add an app",
+			key:          "app2",
+			size:         types.Hugepage2M,
+			memoryKB:     10 * 1024,
+			expectedPool: 7 * 2 << 20,
+		},
+		{
+			name:         "No longer use hugepages",
+			key:          "app1",
+			size:         types.HugepageNone,
+			memoryKB:     3 * 1024,
+			expectedPool: 5 * 2 << 20,
+		},
+		{
+			name:         "Unsupported size",
+			key:          "app3",
+			size:         types.Hugepage1G,
+			memoryKB:     1024 * 1024,
+			expectedPool: 5 * 2 << 20,
+		},
+		{
+			name:         "Delete an app",
+			key:          "app2",
+			release:      true,
+			expectedPool: 0,
+		},
+	}
+	for _, step := range steps {
+		t.Logf("Running step %s", step.name)
+		switch {
+		case step.restarted:
+			hm.setRestarted()
+		case step.release:
+			hm.release(step.key)
+		default:
+			config := types.DomainConfig{
+				VmConfig: types.VmConfig{
+					Memory:    step.memoryKB,
+					Hugepages: step.size,
+				},
+			}
+			hm.update(step.key, config)
+		}
+		if pool := hm.poolBytes(); pool != step.expectedPool {
+			t.Errorf("%s: pool %d, expected %d", step.name, pool,
+				step.expectedPool)
+		}
+	}
+	if err := hm.check("app3"); err == nil {
+		t.Errorf("check of unsupported size did not fail")
+	}
+
+	// What is reserved at the next boot
+	data, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"2M":0}` {
+		t.Errorf("saved %s", string(data))
+	}
+}
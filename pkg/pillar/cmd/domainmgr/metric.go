@@ -50,16 +50,21 @@ func getAndPublishMetrics(ctx *domainContext, hyper hypervisor.Hypervisor) {
 			continue
 		}
 		dm.UUIDandVersion.UUID = uuid
+		dm.GuestFilesystems = ctx.guestFilesystems.get(dm.Key())
 		ctx.pubDomainMetric.Publish(dm.Key(), dm)
 	}
 
 	hm, _ := hyper.GetHostCPUMem()
+	if ctx.hugepages != nil {
+		hm.HugepagesMB = ctx.hugepages.poolBytes() >> 20
+	}
 	if hyper.Name() != "xen" {
 		// the the hypervisor other than Xen, we don't have the Dom0 stats. Get the host
 		// cpu and memory for the device here
 		formatAndPublishHostCPUMem(ctx, hm)
 	}
 	ctx.pubHostMemory.Publish("global", hm)
+	publishSensorMetrics(ctx)
 }
 
 func formatAndPublishHostCPUMem(ctx *domainContext, hm types.HostMemory) {
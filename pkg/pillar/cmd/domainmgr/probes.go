@@ -0,0 +1,391 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Health probes of the domains and their restart policies.
+// The probes of a domain are started from its runHandler and run in go
+// routines which report back to the runHandler, hence the state below is
+// only used by the runHandler of the domain. The resulting health is
+// published in DomainStatus.Health.
+
+package domainmgr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	// How often runHandler checks for probes which are due
+	probeInterval = time.Second
+	// Backoff between restarts. Only on-failure grows it exponentially
+	restartBackoffMin = 10 * time.Second
+	restartBackoffMax = 5 * time.Minute
+	// The backoff is reset once the domain stayed up for this long
+	restartBackoffReset = 10 * time.Minute
+)
+
+// errProbeUnsupported is returned for probes which can never succeed for
+// the domain. Restarting would not help hence they do not trigger the
+// restart policy.
+var errProbeUnsupported = errors.New("probe not supported")
+
+type probeState struct {
+	probe       types.AppProbe
+	running     bool
+	lastRun     time.Time
+	successes   uint32
+	failures    uint32
+	passing     bool // SuccessThreshold reached
+	failing     bool // FailureThreshold reached
+	unsupported bool
+	lastError   string
+}
+
+type probeResult struct {
+	generation int
+	index      int
+	err        error
+	time       time.Time
+}
+
+// domainProbes is the probe state of one domain
+type domainProbes struct {
+	bootTime   time.Time
+	generation int // Increased on reset to ignore stale results
+	probes     []*probeState
+	restarts   uint // Consecutive restarts for the backoff
+	results    chan probeResult
+	done       chan struct{}
+}
+
+func newDomainProbes() *domainProbes {
+	return &domainProbes{
+		results: make(chan probeResult),
+		done:    make(chan struct{}),
+	}
+}
+
+// reset starts over with the probes of the config
+func (dp *domainProbes) reset(probes []types.AppProbe, bootTime time.Time) {
+	dp.generation++
+	dp.bootTime = bootTime
+	dp.probes = nil
+	for _, probe := range probes {
+		dp.probes = append(dp.probes, &probeState{probe: probe})
+	}
+}
+
+func (dp *domainProbes) configChanged(probes []types.AppProbe) bool {
+	if len(probes) != len(dp.probes) {
+		return true
+	}
+	for i := range probes {
+		if !reflect.DeepEqual(probes[i], dp.probes[i].probe) {
+			return true
+		}
+	}
+	return false
+}
+
+// health returns the health state and the error of the last failed probe
+func (dp *domainProbes) health() (types.AppHealthState, string) {
+	if len(dp.probes) == 0 {
+		return types.AppHealthUnknown, ""
+	}
+	unhealthy, notReady, starting := false, false, false
+	lastError := ""
+	for _, ps := range dp.probes {
+		if ps.lastError != "" {
+			lastError = ps.lastError
+		}
+		switch {
+		case ps.failing && ps.probe.Kind == types.AppProbeLiveness:
+			unhealthy = true
+		case ps.failing:
+			notReady = true
+		case !ps.passing:
+			starting = true
+		}
+	}
+	switch {
+	case unhealthy:
+		return types.AppHealthUnhealthy, lastError
+	case notReady:
+		return types.AppHealthNotReady, lastError
+	case starting:
+		return types.AppHealthStarting, lastError
+	default:
+		return types.AppHealthHealthy, ""
+	}
+}
+
+// livenessFailed returns true if a liveness probe fails for a reason
+// which a restart could fix
+func (dp *domainProbes) livenessFailed() bool {
+	for _, ps := range dp.probes {
+		if ps.failing && !ps.unsupported &&
+			ps.probe.Kind == types.AppProbeLiveness {
+			return true
+		}
+	}
+	return false
+}
+
+// restartBackoff returns how long to wait before the next restart
+func restartBackoff(policy types.AppRestartPolicy, restarts uint) time.Duration {
+	if policy != types.RestartPolicyOnFailure {
+		return restartBackoffMin
+	}
+	backoff := restartBackoffMin
+	for i := uint(0); i < restarts && backoff < restartBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > restartBackoffMax {
+		backoff = restartBackoffMax
+	}
+	return backoff
+}
+
+// runProbe runs one probe of the domain
+func runProbe(status types.DomainStatus, probe types.AppProbe) error {
+	timeout := time.Duration(probe.Timeout) * time.Second
+	switch probe.Type {
+	case types.AppProbeTCP, types.AppProbeHTTP:
+		if probe.IPAddr == nil {
+			if probe.NetworkAdapter != "" {
+				return fmt.Errorf("no IP address for network adapter %s",
+					probe.NetworkAdapter)
+			}
+			return errors.New("no IP address for the app instance")
+		}
+		addr := net.JoinHostPort(probe.IPAddr.String(),
+			strconv.Itoa(int(probe.Port)))
+		if probe.Type == types.AppProbeTCP {
+			conn, err := net.DialTimeout("tcp", addr, timeout)
+			if err != nil {
+				return err
+			}
+			conn.Close()
+			return nil
+		}
+		client := &http.Client{
+			Transport: &http.Transport{DisableKeepAlives: true},
+			Timeout:   timeout,
+			// A redirect is a healthy response
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := client.Get("http://" + addr + probe.HTTPPath)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return errors.New("HTTP status " + resp.Status)
+		}
+		return nil
+	case types.AppProbeExec:
+		te, ok := hyper.Task(&status).(hypervisor.TaskExec)
+		if !ok {
+			return fmt.Errorf("%w: exec needs a container which does not run in a VM",
+				errProbeUnsupported)
+		}
+		_, stderr, err := te.Exec(status.DomainName, probe.Command, timeout)
+		if err != nil && stderr != "" {
+			return fmt.Errorf("%v: %s", err, stderr)
+		}
+		return err
+	case types.AppProbeGuestAgent:
+		ga, ok := hyper.(hypervisor.GuestAgent)
+		if !ok || status.VirtualizationMode == types.NOHYPER {
+			return fmt.Errorf("%w: no guest agent with hypervisor %s",
+				errProbeUnsupported, hyper.Name())
+		}
+		return ga.GuestPing(status.DomainName, timeout)
+	}
+	return fmt.Errorf("%w: unknown type %s", errProbeUnsupported, probe.Type)
+}
+
+// runProbes is called periodically by runHandler. It starts the probes
+// which are due, updates the health and applies the restart policy.
+func runProbes(ctx *domainContext, status *types.DomainStatus,
+	dp *domainProbes) {
+
+	config := lookupDomainConfig(ctx, status.Key())
+	if config == nil {
+		return
+	}
+	running := status.Activated && status.State == types.RUNNING &&
+		!status.Pending()
+	if dp.configChanged(config.Probes) ||
+		(running && !status.BootTime.Equal(dp.bootTime)) {
+		dp.reset(config.Probes, status.BootTime)
+	}
+	if running {
+		if !status.BootTime.IsZero() &&
+			time.Since(status.BootTime) > restartBackoffReset &&
+			!dp.livenessFailed() {
+			dp.restarts = 0
+		}
+		startProbes(status, dp)
+	} else if !dp.bootTime.IsZero() {
+		dp.reset(config.Probes, time.Time{})
+	}
+	updateHealth(ctx, status, dp, running)
+	maybeRestart(ctx, status, config, dp)
+}
+
+func startProbes(status *types.DomainStatus, dp *domainProbes) {
+	now := time.Now()
+	for i, ps := range dp.probes {
+		if ps.running {
+			continue
+		}
+		initialDelay := time.Duration(ps.probe.InitialDelay) * time.Second
+		if now.Sub(status.BootTime) < initialDelay {
+			continue
+		}
+		period := time.Duration(ps.probe.Period) * time.Second
+		if now.Sub(ps.lastRun) < period {
+			continue
+		}
+		ps.running = true
+		ps.lastRun = now
+		res := probeResult{generation: dp.generation, index: i}
+		go func(status types.DomainStatus, probe types.AppProbe) {
+			res.err = runProbe(status, probe)
+			res.time = time.Now()
+			select {
+			case dp.results <- res:
+			case <-dp.done:
+			}
+		}(*status, ps.probe)
+	}
+}
+
+// probeDone records the result of a probe and updates the health
+func probeDone(ctx *domainContext, key string, dp *domainProbes,
+	res probeResult) {
+
+	if res.generation != dp.generation || res.index >= len(dp.probes) {
+		return
+	}
+	ps := dp.probes[res.index]
+	ps.running = false
+	if res.err == nil {
+		ps.failures = 0
+		ps.successes++
+		ps.lastError = ""
+		ps.unsupported = false
+		if !ps.passing && ps.successes >= ps.probe.SuccessThreshold {
+			ps.passing = true
+			ps.failing = false
+		}
+	} else {
+		log.Functionf("probeDone(%s) %s probe failed: %s",
+			key, ps.probe.Type, res.err)
+		ps.successes = 0
+		ps.failures++
+		ps.lastError = fmt.Sprintf("%s probe: %s", ps.probe.Type, res.err)
+		ps.unsupported = errors.Is(res.err, errProbeUnsupported)
+		if !ps.failing && ps.failures >= ps.probe.FailureThreshold {
+			ps.failing = true
+			ps.passing = false
+		}
+	}
+	status := lookupDomainStatus(ctx, key)
+	if status != nil {
+		updateHealth(ctx, status, dp, true)
+	}
+}
+
+// updateHealth publishes the health if it changed
+func updateHealth(ctx *domainContext, status *types.DomainStatus,
+	dp *domainProbes, running bool) {
+
+	state, lastError := dp.health()
+	if !running {
+		state = types.AppHealthUnknown
+		lastError = status.Health.LastError
+	}
+	if state == status.Health.State && lastError == status.Health.LastError {
+		return
+	}
+	if state != status.Health.State {
+		log.Noticef("updateHealth(%s) health %s -> %s %s",
+			status.Key(), status.Health.State, state, lastError)
+		status.Health.State = state
+		status.Health.LastChange = time.Now()
+	}
+	status.Health.LastError = lastError
+	publishDomainStatus(ctx, status)
+}
+
+// maybeRestart applies the restart policy once the backoff has passed
+func maybeRestart(ctx *domainContext, status *types.DomainStatus,
+	config *types.DomainConfig, dp *domainProbes) {
+
+	reason := ""
+	if config.RestartPolicy != types.RestartPolicyNever &&
+		config.Activate && !status.Pending() &&
+		!status.BootFailed && !status.AdaptersFailed {
+
+		if status.Activated {
+			if dp.livenessFailed() {
+				reason = "liveness probe failed"
+			}
+		} else if status.State == types.HALTED {
+			if status.HasError() {
+				reason = "domain failed: " + status.Error
+			} else if config.RestartPolicy == types.RestartPolicyAlways {
+				reason = "domain stopped"
+			}
+		}
+	}
+	if reason == "" {
+		if !status.Health.NextRestart.IsZero() {
+			status.Health.NextRestart = time.Time{}
+			publishDomainStatus(ctx, status)
+		}
+		return
+	}
+	if status.Health.NextRestart.IsZero() {
+		backoff := restartBackoff(config.RestartPolicy, dp.restarts)
+		log.Warnf("maybeRestart(%s) %s; restart policy %s restarts in %v",
+			status.Key(), reason, config.RestartPolicy, backoff)
+		status.Health.NextRestart = time.Now().Add(backoff)
+		publishDomainStatus(ctx, status)
+		return
+	}
+	if time.Now().Before(status.Health.NextRestart) {
+		return
+	}
+	log.Noticef("maybeRestart(%s) restarting %s: %s",
+		status.Key(), status.DisplayName, reason)
+	dp.restarts++
+	status.Health.NextRestart = time.Time{}
+	status.Health.RestartCount++
+	status.Health.LastRestart = time.Now()
+	if status.Activated {
+		doInactivate(ctx, status, true)
+	}
+	status.ClearError()
+	if err := configToStatus(ctx, *config, status); err != nil {
+		log.Errorf("maybeRestart(%s) configToStatus failed: %s",
+			status.Key(), err)
+		status.SetErrorNow(err.Error())
+		publishDomainStatus(ctx, status)
+		return
+	}
+	doActivate(ctx, *config, status)
+	publishDomainStatus(ctx, status)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func initProbeCtx(t *testing.T) (*domainContext, *types.DomainStatus) {
+	logger := logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, "domainmgr", 0)
+	ps := pubsub.New(&pubsub.EmptyDriver{}, logger, log)
+	pubDomainStatus, err := ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.DomainStatus{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &domainContext{pubDomainStatus: pubDomainStatus}
+	status := &types.DomainStatus{
+		UUIDandVersion: types.UUIDandVersion{UUID: uuid.NewV4()},
+		Activated:      true,
+		State:          types.RUNNING,
+		BootTime:       time.Now(),
+	}
+	publishDomainStatus(ctx, status)
+	return ctx, status
+}
+
+func TestProbeHealth(t *testing.T) {
+	liveness := types.AppProbe{
+		Kind:             types.AppProbeLiveness,
+		Type:             types.AppProbeTCP,
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+	}
+	readiness := types.AppProbe{
+		Kind:             types.AppProbeReadiness,
+		Type:             types.AppProbeHTTP,
+		FailureThreshold: 1,
+		SuccessThreshold: 2,
+	}
+	failed := errors.New("connection refused")
+	unsupported := errProbeUnsupported
+
+	type result struct {
+		index int
+		err   error
+	}
+	testMatrix := map[string]struct {
+		probes         []types.AppProbe
+		results        []result
+		expectedState  types.AppHealthState
+		expectedFailed bool
+	}{
+		"No probes": {
+			expectedState: types.AppHealthUnknown,
+		},
+		"Starting": {
+			probes:        []types.AppProbe{liveness, readiness},
+			results:       []result{{0, nil}, {1, nil}},
+			expectedState: types.AppHealthStarting,
+		},
+		"Healthy": {
+			probes:        []types.AppProbe{liveness, readiness},
+			results:       []result{{0, nil}, {1, nil}, {1, nil}},
+			expectedState: types.AppHealthHealthy,
+		},
+		"Liveness below threshold": {
+			probes:        []types.AppProbe{liveness},
+			results:       []result{{0, nil}, {0, failed}},
+			expectedState: types.AppHealthHealthy,
+		},
+		"Unhealthy": {
+			probes:         []types.AppProbe{liveness, readiness},
+			results:        []result{{0, nil}, {1, nil}, {1, nil}, {0, failed}, {0, failed}},
+			expectedState:  types.AppHealthUnhealthy,
+			expectedFailed: true,
+		},
+		"Not ready": {
+			probes:        []types.AppProbe{liveness, readiness},
+			results:       []result{{0, nil}, {1, nil}, {1, nil}, {1, failed}},
+			expectedState: types.AppHealthNotReady,
+		},
+		"Ready again": {
+			probes:        []types.AppProbe{liveness, readiness},
+			results:       []result{{0, nil}, {1, failed}, {1, nil}, {1, nil}},
+			expectedState: types.AppHealthHealthy,
+		},
+		"Unsupported": {
+			probes:        []types.AppProbe{liveness},
+			results:       []result{{0, unsupported}, {0, unsupported}},
+			expectedState: types.AppHealthUnhealthy,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		ctx, status := initProbeCtx(t)
+		dp := newDomainProbes()
+		dp.reset(test.probes, status.BootTime)
+		for _, res := range test.results {
+			probeDone(ctx, status.Key(), dp, probeResult{
+				generation: dp.generation,
+				index:      res.index,
+				err:        res.err,
+				time:       time.Now(),
+			})
+		}
+		status = lookupDomainStatus(ctx, status.Key())
+		if status.Health.State != test.expectedState {
+			t.Errorf("%s: health %s, expected %s", testname,
+				status.Health.State, test.expectedState)
+		}
+		if dp.livenessFailed() != test.expectedFailed {
+			t.Errorf("%s: liveness failed %t, expected %t", testname,
+				dp.livenessFailed(), test.expectedFailed)
+		}
+		if test.expectedState == types.AppHealthHealthy &&
+			status.Health.LastError != "" {
+			t.Errorf("%s: unexpected error %s", testname,
+				status.Health.LastError)
+		}
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	testMatrix := map[string]struct {
+		policy   types.AppRestartPolicy
+		restarts uint
+		expected time.Duration
+	}{
+		"Always": {
+			policy:   types.RestartPolicyAlways,
+			restarts: 5,
+			expected: restartBackoffMin,
+		},
+		"On-failure first": {
+			policy:   types.RestartPolicyOnFailure,
+			expected: restartBackoffMin,
+		},
+		"On-failure third": {
+			policy:   types.RestartPolicyOnFailure,
+			restarts: 2,
+			expected: 4 * restartBackoffMin,
+		},
+		"On-failure max": {
+			policy:   types.RestartPolicyOnFailure,
+			restarts: 100,
+			expected: restartBackoffMax,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		backoff := restartBackoff(test.policy, test.restarts)
+		if backoff != test.expected {
+			t.Errorf("%s: backoff %v, expected %v", testname,
+				backoff, test.expected)
+		}
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	log = base.NewSourceLogObject(logrus.StandardLogger(), "domainmgr", 0)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/healthz" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+	defer server.Close()
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	testMatrix := map[string]struct {
+		probe      types.AppProbe
+		expectFail bool
+	}{
+		"TCP": {
+			probe: types.AppProbe{Type: types.AppProbeTCP,
+				IPAddr: net.ParseIP(host), Port: uint16(port)},
+		},
+		"HTTP": {
+			probe: types.AppProbe{Type: types.AppProbeHTTP,
+				IPAddr: net.ParseIP(host), Port: uint16(port),
+				HTTPPath: "/healthz"},
+		},
+		"HTTP error status": {
+			probe: types.AppProbe{Type: types.AppProbeHTTP,
+				IPAddr: net.ParseIP(host), Port: uint16(port),
+				HTTPPath: "/"},
+			expectFail: true,
+		},
+		"No IP address": {
+			probe: types.AppProbe{Type: types.AppProbeTCP,
+				NetworkAdapter: "eth0", Port: uint16(port)},
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		test.probe.Timeout = 5
+		err := runProbe(types.DomainStatus{}, test.probe)
+		if (err != nil) != test.expectFail {
+			t.Errorf("%s: error %v, expected failure %t", testname,
+				err, test.expectFail)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"github.com/lf-edge/eve/pkg/pillar/sensors"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// publishSensorMetrics publishes the hardware sensors read with the
+// metrics, and unpublishes those which could no longer be read
+func publishSensorMetrics(ctx *domainContext) {
+	current := make(map[string]bool)
+	for _, metric := range sensors.Read(log, ctx.sensorsIPMI) {
+		current[metric.Key()] = true
+		ctx.pubSensorMetric.Publish(metric.Key(), metric)
+	}
+	for _, m := range ctx.pubSensorMetric.GetAll() {
+		metric := m.(types.SensorMetric)
+		if !current[metric.Key()] {
+			ctx.pubSensorMetric.Unpublish(metric.Key())
+		}
+	}
+}
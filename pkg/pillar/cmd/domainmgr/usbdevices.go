@@ -0,0 +1,264 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Passthrough of individual USB devices matching the UsbDevices rules of
+// a domain. The runHandler of each running domain periodically scans the
+// USB devices in sysfs, hotplugs the matching ones into the domain and
+// detaches the ones which were unplugged. A device is only given to one
+// domain; the usbManager keeps track of which one across the runHandlers.
+// Devices of a USB controller which is assigned to a domain, or in
+// pciback, are not seen by EVE hence can not be matched.
+
+package domainmgr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	usbSysDir = "/sys/bus/usb/devices"
+	// How often runHandler looks for plugged and unplugged devices
+	usbInterval = 2 * time.Second
+)
+
+// usbManager is used by the runHandler of all domains hence the mutex
+type usbManager struct {
+	sync.Mutex
+	sysDir string
+	claims map[string]string // Key is the PortPath, value the domain key
+}
+
+func newUsbManager(sysDir string) *usbManager {
+	return &usbManager{
+		sysDir: sysDir,
+		claims: make(map[string]string),
+	}
+}
+
+// scan returns the USB devices which are plugged in, without the root hubs
+func (um *usbManager) scan() ([]types.UsbDevice, error) {
+	entries, err := ioutil.ReadDir(um.sysDir)
+	if err != nil {
+		return nil, err
+	}
+	var devices []types.UsbDevice
+	for _, entry := range entries {
+		// E.g., "1-2.3" while "1-2.3:1.0" is one of its interfaces
+		name := entry.Name()
+		if strings.HasPrefix(name, "usb") || strings.Contains(name, ":") {
+			continue
+		}
+		dev, err := um.readDevice(name)
+		if err != nil {
+			log.Warnf("usbManager scan %s: %s", name, err)
+			continue
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+func (um *usbManager) readDevice(name string) (types.UsbDevice, error) {
+	var dev types.UsbDevice
+	ids := strings.SplitN(name, "-", 2)
+	if len(ids) != 2 {
+		return dev, fmt.Errorf("unexpected name")
+	}
+	dev.PortPath = ids[0] + ":" + ids[1]
+	read := func(attr string) string {
+		data, err := ioutil.ReadFile(filepath.Join(um.sysDir, name, attr))
+		if err != nil {
+			// serial and product are optional
+			if !os.IsNotExist(err) {
+				log.Warnf("usbManager read %s/%s: %s", name, attr, err)
+			}
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	for attr, num := range map[string]*uint32{
+		"busnum": &dev.Busnum,
+		"devnum": &dev.Devnum,
+	} {
+		n, err := strconv.ParseUint(read(attr), 10, 32)
+		if err != nil {
+			return dev, fmt.Errorf("bad %s: %v", attr, err)
+		}
+		*num = uint32(n)
+	}
+	dev.VendorID = read("idVendor")
+	dev.ProductID = read("idProduct")
+	dev.Serial = read("serial")
+	dev.Product = read("product")
+	return dev, nil
+}
+
+// claim gives the device to the domain unless another one has it, and
+// returns the key of the domain which has it
+func (um *usbManager) claim(portPath string, key string) string {
+	um.Lock()
+	defer um.Unlock()
+	if owner, ok := um.claims[portPath]; ok {
+		return owner
+	}
+	um.claims[portPath] = key
+	return key
+}
+
+func (um *usbManager) release(portPath string, key string) {
+	um.Lock()
+	defer um.Unlock()
+	if um.claims[portPath] == key {
+		delete(um.claims, portPath)
+	}
+}
+
+// releaseAll releases all the devices of the domain
+func (um *usbManager) releaseAll(key string) {
+	um.Lock()
+	defer um.Unlock()
+	for portPath, owner := range um.claims {
+		if owner == key {
+			delete(um.claims, portPath)
+		}
+	}
+}
+
+// domainUsb is the USB state of one domain, only used by its runHandler
+type domainUsb struct {
+	bootTime time.Time
+	attached map[string]string // Key is the PortPath, value the device id
+}
+
+func newDomainUsb() *domainUsb {
+	return &domainUsb{attached: make(map[string]string)}
+}
+
+// usbDeviceID returns the id of the device in the hypervisor
+func usbDeviceID(dev types.UsbDevice) string {
+	return "usbhost" + strings.NewReplacer(":", "-").Replace(dev.PortPath)
+}
+
+func matchUsbRules(rules []types.UsbDeviceRule, dev types.UsbDevice) bool {
+	for _, rule := range rules {
+		if rule.Match(dev) {
+			return true
+		}
+	}
+	return false
+}
+
+// usbAdapterOwner returns the domain which has the device as an I/O
+// adapter, if any
+func usbAdapterOwner(ctx *domainContext, portPath string) uuid.UUID {
+	for _, ib := range ctx.assignableAdapters.IoBundleList {
+		if ib.Type == types.IoUSB && ib.UsbAddr == portPath {
+			return ib.UsedByUUID
+		}
+	}
+	return nilUUID
+}
+
+// updateUsbDevices attaches and detaches the devices of the domain and
+// publishes them in DomainStatus.UsbDevices
+func updateUsbDevices(ctx *domainContext, status *types.DomainStatus,
+	du *domainUsb) {
+
+	key := status.Key()
+	var rules []types.UsbDeviceRule
+	if config := lookupDomainConfig(ctx, key); config != nil {
+		rules = config.UsbDevices
+	}
+	running := status.Activated && status.State == types.RUNNING
+	if (!running || !status.BootTime.Equal(du.bootTime)) &&
+		len(du.attached) != 0 {
+		// The devices went away with the domain
+		log.Functionf("updateUsbDevices(%s) releasing %v", key, du.attached)
+		du.attached = make(map[string]string)
+		ctx.usbDevices.releaseAll(key)
+	}
+	du.bootTime = status.BootTime
+	if len(rules) == 0 && len(du.attached) == 0 &&
+		len(status.UsbDevices) == 0 {
+		return
+	}
+	devices, err := ctx.usbDevices.scan()
+	if err != nil {
+		log.Errorf("updateUsbDevices(%s): %s", key, err)
+		return
+	}
+	hotplug, supported := hyper.(hypervisor.UsbHotplug)
+	present := make(map[string]bool)
+	var list []types.UsbDeviceStatus
+	for _, dev := range devices {
+		if !matchUsbRules(rules, dev) {
+			continue
+		}
+		present[dev.PortPath] = true
+		devStatus := types.UsbDeviceStatus{UsbDevice: dev}
+		if _, ok := du.attached[dev.PortPath]; ok {
+			devStatus.Attached = true
+			list = append(list, devStatus)
+			continue
+		}
+		switch {
+		case !running:
+		case !supported:
+			devStatus.Error = fmt.Sprintf("not supported with %s hypervisor",
+				hyper.Name())
+		case !uuid.Equal(usbAdapterOwner(ctx, dev.PortPath), nilUUID):
+			devStatus.Error = "assigned as an I/O adapter"
+		default:
+			if owner := ctx.usbDevices.claim(dev.PortPath, key); owner != key {
+				name := owner
+				if st := lookupDomainStatus(ctx, owner); st != nil {
+					name = st.DisplayName
+				}
+				devStatus.Error = fmt.Sprintf("used by %s", name)
+				break
+			}
+			id := usbDeviceID(dev)
+			if err := hotplug.UsbAttach(status.DomainName, id, dev); err != nil {
+				log.Errorf("updateUsbDevices(%s) attach %s failed: %s",
+					key, dev.PortPath, err)
+				ctx.usbDevices.release(dev.PortPath, key)
+				devStatus.Error = err.Error()
+				break
+			}
+			log.Noticef("updateUsbDevices(%s) attached %s %s:%s %s",
+				key, dev.PortPath, dev.VendorID, dev.ProductID, dev.Product)
+			du.attached[dev.PortPath] = id
+			devStatus.Attached = true
+		}
+		list = append(list, devStatus)
+	}
+	// Detach the unplugged devices, and the ones no longer matching a rule
+	for portPath, id := range du.attached {
+		if present[portPath] {
+			continue
+		}
+		log.Noticef("updateUsbDevices(%s) detaching %s", key, portPath)
+		if err := hotplug.UsbDetach(status.DomainName, id); err != nil {
+			log.Warnf("updateUsbDevices(%s) detach %s failed: %s",
+				key, portPath, err)
+		}
+		delete(du.attached, portPath)
+		ctx.usbDevices.release(portPath, key)
+	}
+	if !cmp.Equal(list, status.UsbDevices) {
+		status.UsbDevices = list
+		publishDomainStatus(ctx, status)
+	}
+}
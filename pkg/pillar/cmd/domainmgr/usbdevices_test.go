@@ -0,0 +1,139 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+// writeUsbDevice creates a sysfs USB device directory with the attributes
+func writeUsbDevice(t *testing.T, dir string, name string, attrs map[string]string) {
+	devDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for attr, value := range attrs {
+		err := ioutil.WriteFile(filepath.Join(devDir, attr), []byte(value+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUsbDevices(t *testing.T) {
+	log = base.NewSourceLogObject(logrus.StandardLogger(), "domainmgr", 0)
+	dir, err := ioutil.TempDir("", "usb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeUsbDevice(t, dir, "usb1", map[string]string{
+		"busnum": "1", "devnum": "1", "idVendor": "1d6b", "idProduct": "0002",
+	})
+	writeUsbDevice(t, dir, "1-2", map[string]string{
+		"busnum": "1", "devnum": "2", "idVendor": "05e3", "idProduct": "0608",
+	})
+	writeUsbDevice(t, dir, "1-2:1.0", map[string]string{})
+	writeUsbDevice(t, dir, "1-2.3", map[string]string{
+		"busnum": "1", "devnum": "5", "idVendor": "0781", "idProduct": "5581",
+		"serial": "4C530001", "product": "Ultra",
+	})
+	writeUsbDevice(t, dir, "2-1", map[string]string{
+		"busnum": "2", "devnum": "3", "idVendor": "0781", "idProduct": "5581",
+	})
+	um := newUsbManager(dir)
+	devices, err := um.scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]types.UsbDevice{
+		"1:2": {PortPath: "1:2", Busnum: 1, Devnum: 2, VendorID: "05e3",
+			ProductID: "0608"},
+		"1:2.3": {PortPath: "1:2.3", Busnum: 1, Devnum: 5, VendorID: "0781",
+			ProductID: "5581", Serial: "4C530001", Product: "Ultra"},
+		"2:1": {PortPath: "2:1", Busnum: 2, Devnum: 3, VendorID: "0781",
+			ProductID: "5581"},
+	}
+	if len(devices) != len(expected) {
+		t.Fatalf("scan found %v", devices)
+	}
+	for _, dev := range devices {
+		if dev != expected[dev.PortPath] {
+			t.Errorf("scan found %+v, expected %+v", dev,
+				expected[dev.PortPath])
+		}
+	}
+
+	testMatrix := map[string]struct {
+		rules    []types.UsbDeviceRule
+		expected []string
+	}{
+		"Empty rule": {
+			rules: []types.UsbDeviceRule{{}},
+		},
+		"Vendor and product": {
+			rules:    []types.UsbDeviceRule{{VendorID: "0781", ProductID: "5581"}},
+			expected: []string{"1:2.3", "2:1"},
+		},
+		"Case of the ids": {
+			rules:    []types.UsbDeviceRule{{VendorID: "05E3"}},
+			expected: []string{"1:2"},
+		},
+		"Serial": {
+			rules:    []types.UsbDeviceRule{{VendorID: "0781", Serial: "4C530001"}},
+			expected: []string{"1:2.3"},
+		},
+		"Port path": {
+			rules:    []types.UsbDeviceRule{{PortPath: "2:1"}},
+			expected: []string{"2:1"},
+		},
+		"Any rule": {
+			rules: []types.UsbDeviceRule{
+				{PortPath: "2:1"},
+				{VendorID: "05e3"},
+			},
+			expected: []string{"1:2", "2:1"},
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		var matched []string
+		for _, dev := range devices {
+			if matchUsbRules(test.rules, dev) {
+				matched = append(matched, dev.PortPath)
+			}
+		}
+		if len(matched) != len(test.expected) {
+			t.Errorf("%s: matched %v, expected %v", testname,
+				matched, test.expected)
+			continue
+		}
+		for i := range matched {
+			if matched[i] != test.expected[i] {
+				t.Errorf("%s: matched %v, expected %v", testname,
+					matched, test.expected)
+				break
+			}
+		}
+	}
+
+	// A device is only given to one domain
+	if owner := um.claim("1:2.3", "app1"); owner != "app1" {
+		t.Errorf("claim returned %s", owner)
+	}
+	if owner := um.claim("1:2.3", "app2"); owner != "app1" {
+		t.Errorf("claim by another domain returned %s", owner)
+	}
+	um.releaseAll("app1")
+	if owner := um.claim("1:2.3", "app2"); owner != "app2" {
+		t.Errorf("claim after release returned %s", owner)
+	}
+}
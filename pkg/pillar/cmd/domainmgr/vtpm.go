@@ -0,0 +1,144 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Virtual TPM of the domains with VTpm. The state of each vTPM lives in
+// the vault in a directory named after the app instance UUID, encrypted
+// with a random key which is created with the vTPM. In the vault mode the
+// key is stored next to the state, in the sealed mode it is additionally
+// encrypted by the TPM of the device so the state is bound to the device.
+// The key is converted when the mode changes. The state and the key are
+// removed when the app instance is deleted.
+
+package domainmgr
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eve/pkg/pillar/evetpm"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	fileutils "github.com/lf-edge/eve/pkg/pillar/utils/file"
+)
+
+func vtpmStateDir(dir string, appUUID string) string {
+	return filepath.Join(dir, appUUID)
+}
+
+func vtpmKeyFile(dir string, appUUID string) string {
+	return filepath.Join(dir, appUUID+".key")
+}
+
+func vtpmSealedKeyFile(dir string, appUUID string) string {
+	return filepath.Join(dir, appUUID+".sealed-key")
+}
+
+// getVTpmKey returns the key of the vTPM of the app instance in dir,
+// creating or converting it for the mode as needed
+func getVTpmKey(dir string, appUUID string, mode types.VTpmMode) ([]byte, error) {
+	keyFile := vtpmKeyFile(dir, appUUID)
+	sealedKeyFile := vtpmSealedKeyFile(dir, appUUID)
+	if mode == types.VTpmSealed && !evetpm.IsTpmEnabled() {
+		return nil, errors.New("sealed vTPM needs a device with a TPM")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	var key []byte
+	current := types.VTpmNone
+	if b, err := ioutil.ReadFile(keyFile); err == nil {
+		key, current = b, types.VTpmVault
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	} else if sealed, err := ioutil.ReadFile(sealedKeyFile); err == nil {
+		key, err = evetpm.EncryptDecryptUsingTpm(sealed, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unseal vTPM key: %v", err)
+		}
+		current = types.VTpmSealed
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if key == nil {
+		key = make([]byte, hypervisor.VTpmKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		log.Noticef("getVTpmKey(%s): created %s key", appUUID, mode)
+	} else if current != mode {
+		log.Noticef("getVTpmKey(%s): converting key from %s to %s",
+			appUUID, current, mode)
+	}
+	if len(key) != hypervisor.VTpmKeySize {
+		return nil, fmt.Errorf("bad vTPM key size %d", len(key))
+	}
+	if current == mode {
+		return key, nil
+	}
+	switch mode {
+	case types.VTpmVault:
+		if err := fileutils.WriteRename(keyFile, key); err != nil {
+			return nil, err
+		}
+		os.Remove(sealedKeyFile)
+	case types.VTpmSealed:
+		sealed, err := evetpm.EncryptDecryptUsingTpm(key, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal vTPM key: %v", err)
+		}
+		if err := fileutils.WriteRename(sealedKeyFile, sealed); err != nil {
+			return nil, err
+		}
+		os.Remove(keyFile)
+	default:
+		return nil, fmt.Errorf("unsupported vTPM mode %s", mode)
+	}
+	return key, nil
+}
+
+// startVTpm starts the vTPM of the domain if it has one
+func startVTpm(config types.DomainConfig, status *types.DomainStatus) error {
+	if config.VTpm == types.VTpmNone {
+		return nil
+	}
+	vtpm, ok := hyper.Task(status).(hypervisor.VTpm)
+	if !ok || config.VirtualizationModeOrDefault() == types.NOHYPER {
+		return fmt.Errorf("vTPM is not supported by hypervisor %s",
+			hyper.Name())
+	}
+	appUUID := config.UUIDandVersion.UUID.String()
+	key, err := getVTpmKey(types.VTpmDirName, appUUID, config.VTpm)
+	if err != nil {
+		return fmt.Errorf("vTPM key: %v", err)
+	}
+	return vtpm.VTpmStart(status.DomainName,
+		vtpmStateDir(types.VTpmDirName, appUUID), key)
+}
+
+// stopVTpm stops the vTPM of the domain if it has one running
+func stopVTpm(status *types.DomainStatus) {
+	vtpm, ok := hyper.Task(status).(hypervisor.VTpm)
+	if !ok {
+		return
+	}
+	if err := vtpm.VTpmStop(status.DomainName); err != nil {
+		log.Errorf("stopVTpm(%s): %v", status.Key(), err)
+	}
+}
+
+// deleteVTpm removes the state and the key of the vTPM of the app instance
+func deleteVTpm(dir string, appUUID string) {
+	if err := os.RemoveAll(vtpmStateDir(dir, appUUID)); err != nil {
+		log.Errorf("deleteVTpm(%s): %v", appUUID, err)
+	}
+	for _, file := range []string{vtpmKeyFile(dir, appUUID),
+		vtpmSealedKeyFile(dir, appUUID)} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Errorf("deleteVTpm(%s): %v", appUUID, err)
+		}
+	}
+}
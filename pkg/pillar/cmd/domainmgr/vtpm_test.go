@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package domainmgr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestVTpmKey(t *testing.T) {
+	log = base.NewSourceLogObject(logrus.StandardLogger(), "domainmgr", 0)
+	dir, err := ioutil.TempDir("", "vtpm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	appUUID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	key, err := getVTpmKey(dir, appUUID, types.VTpmVault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != hypervisor.VTpmKeySize {
+		t.Fatalf("key size %d, expected %d", len(key), hypervisor.VTpmKeySize)
+	}
+	stored, err := ioutil.ReadFile(vtpmKeyFile(dir, appUUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, key) {
+		t.Fatal("stored key differs from the returned one")
+	}
+	again, err := getVTpmKey(dir, appUUID, types.VTpmVault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, key) {
+		t.Fatal("key changed")
+	}
+	other, err := getVTpmKey(dir, "6ba7b811-9dad-11d1-80b4-00c04fd430c8", types.VTpmVault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(other, key) {
+		t.Fatal("two app instances got the same key")
+	}
+
+	if err := os.MkdirAll(vtpmStateDir(dir, appUUID), 0700); err != nil {
+		t.Fatal(err)
+	}
+	deleteVTpm(dir, appUUID)
+	for _, path := range []string{vtpmStateDir(dir, appUUID), vtpmKeyFile(dir, appUUID)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed: %v", path, err)
+		}
+	}
+	again, err = getVTpmKey(dir, appUUID, types.VTpmVault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, key) {
+		t.Fatal("key reused after delete")
+	}
+}
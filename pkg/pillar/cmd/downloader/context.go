@@ -22,6 +22,8 @@ type downloaderContext struct {
 	pubResolveStatus       pubsub.Publication
 	pubCipherBlockStatus   pubsub.Publication
 	subDatastoreConfig     pubsub.Subscription
+	subDiagBundleConfig    pubsub.Subscription
+	pubDiagBundleStatus    pubsub.Publication
 	deviceNetworkStatus    types.DeviceNetworkStatus
 	globalStatusLock       sync.Mutex
 	subGlobalConfig        pubsub.Subscription
@@ -198,6 +200,32 @@ func (ctx *downloaderContext) registerHandlers(ps *pubsub.PubSub) error {
 	ctx.subResolveConfig = subResolveConfig
 	subResolveConfig.Activate()
 
+	pubDiagBundleStatus, err := ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.DiagBundleStatus{},
+	})
+	if err != nil {
+		return err
+	}
+	ctx.pubDiagBundleStatus = pubDiagBundleStatus
+
+	subDiagBundleConfig, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		CreateHandler: handleDiagBundleConfigCreate,
+		ModifyHandler: handleDiagBundleConfigModify,
+		DeleteHandler: handleDiagBundleConfigDelete,
+		WarningTime:   warningTime,
+		ErrorTime:     errorTime,
+		AgentName:     "zedagent",
+		MyAgentName:   agentName,
+		TopicImpl:     types.DiagBundleConfig{},
+		Ctx:           ctx,
+	})
+	if err != nil {
+		return err
+	}
+	ctx.subDiagBundleConfig = subDiagBundleConfig
+	subDiagBundleConfig.Activate()
+
 	pubDownloaderStatus.SignalRestarted()
 	pubResolveStatus.SignalRestarted()
 
@@ -0,0 +1,199 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Upload of the diagnostic bundles collected by zedagent to the datastore
+// of the collect_info command, as Name under the path of the datastore.
+// The container registries can't take a bundle.
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	zconfig "github.com/lf-edge/eve/api/go/config"
+	"github.com/lf-edge/eve/libs/zedUpload"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/lf-edge/eve/pkg/pillar/utils"
+)
+
+// uploadStatus tells download that an upload is progressing
+type uploadStatus struct {
+	currentSize int64
+}
+
+// Progress returns true if more was uploaded
+func (u *uploadStatus) Progress(p uint, currentSize, totalSize int64) bool {
+	if u.currentSize == currentSize {
+		return false
+	}
+	u.currentSize = currentSize
+	return true
+}
+
+func handleDiagBundleConfigCreate(ctxArg interface{}, key string,
+	configArg interface{}) {
+
+	ctx := ctxArg.(*downloaderContext)
+	config := configArg.(types.DiagBundleConfig)
+	log.Functionf("handleDiagBundleConfigCreate(%s) %s", key, config.Name)
+	status := types.DiagBundleStatus{
+		Counter: config.Counter,
+		Name:    config.Name,
+		State:   types.DiagBundleStateUploading,
+	}
+	publishDiagBundleStatus(ctx, &status)
+	go uploadDiagBundle(ctx, config, status)
+}
+
+func handleDiagBundleConfigModify(ctxArg interface{}, key string,
+	configArg interface{}, oldConfigArg interface{}) {
+
+	// zedagent publishes a new counter for each bundle
+	log.Warnf("handleDiagBundleConfigModify(%s) ignored", key)
+}
+
+func handleDiagBundleConfigDelete(ctxArg interface{}, key string,
+	configArg interface{}) {
+
+	ctx := ctxArg.(*downloaderContext)
+	log.Functionf("handleDiagBundleConfigDelete(%s)", key)
+	if st, _ := ctx.pubDiagBundleStatus.Get(key); st != nil {
+		ctx.pubDiagBundleStatus.Unpublish(key)
+	}
+}
+
+// uploadDiagBundle runs in its own goroutine and tries the management
+// ports until one succeeds
+func uploadDiagBundle(ctx *downloaderContext, config types.DiagBundleConfig,
+	status types.DiagBundleStatus) {
+
+	err := uploadDiagBundleFile(ctx, config, &status)
+	if err != nil {
+		log.Errorf("uploadDiagBundle(%s) failed: %v", config.Name, err)
+		status.State = types.DiagBundleStateFailed
+		status.SetErrorNow(err.Error())
+	} else {
+		log.Noticef("uploadDiagBundle(%s) uploaded %d bytes",
+			config.Name, status.Size)
+		status.State = types.DiagBundleStateUploaded
+	}
+	// zedagent may have moved on to a new bundle
+	if c, _ := ctx.subDiagBundleConfig.Get(config.Key()); c == nil {
+		log.Functionf("uploadDiagBundle(%s) config is gone", config.Name)
+		return
+	}
+	publishDiagBundleStatus(ctx, &status)
+}
+
+func uploadDiagBundleFile(ctx *downloaderContext, config types.DiagBundleConfig,
+	status *types.DiagBundleStatus) error {
+
+	var (
+		trType    zedUpload.SyncTransportType
+		auth      *zedUpload.AuthInput
+		serverURL string
+		err       error
+	)
+	info, err := os.Stat(config.Filename)
+	if err != nil {
+		return err
+	}
+	status.Size = info.Size()
+
+	dst, err := utils.LookupDatastoreConfig(ctx.subDatastoreConfig, config.DatastoreID)
+	if err != nil {
+		return err
+	}
+	dsCtx, err := constructDatastoreContext(ctx, config.Name, false, *dst)
+	if err != nil {
+		return fmt.Errorf("%s, Datastore construction failed, %s", config.Name, err)
+	}
+
+	switch dsCtx.TransportMethod {
+	case zconfig.DsType_DsS3.String():
+		auth = &zedUpload.AuthInput{
+			AuthType: "s3",
+			Uname:    dsCtx.APIKey,
+			Password: dsCtx.Password,
+		}
+		trType = zedUpload.SyncAwsTr
+		serverURL = dsCtx.DownloadURL
+
+	case zconfig.DsType_DsAzureBlob.String():
+		auth = &zedUpload.AuthInput{
+			AuthType: "password",
+			Uname:    dsCtx.APIKey,
+			Password: dsCtx.Password,
+		}
+		trType = zedUpload.SyncAzureTr
+		serverURL = dsCtx.DownloadURL
+
+	case zconfig.DsType_DsSFTP.String():
+		auth = &zedUpload.AuthInput{
+			AuthType: "sftp",
+			Uname:    dsCtx.APIKey,
+			Password: dsCtx.Password,
+		}
+		trType = zedUpload.SyncSftpTr
+		serverURL = dst.Fqdn
+
+	case zconfig.DsType_DsHttp.String(), zconfig.DsType_DsHttps.String(), "":
+		auth = &zedUpload.AuthInput{
+			AuthType: "http",
+		}
+		trType = zedUpload.SyncHttpTr
+		serverURL, err = getServerURL(dsCtx)
+		if err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unsupported transport method %s for a diag bundle",
+			dsCtx.TransportMethod)
+	}
+
+	// The bundle is for debugging, which may be needed most when only
+	// the non-free ports work
+	addrCount := types.CountLocalAddrAnyNoLinkLocal(ctx.deviceNetworkStatus)
+	log.Functionf("Have %d any management port addresses", addrCount)
+	if addrCount == 0 {
+		return errors.New("No IP management port addresses for upload")
+	}
+	var errStr string
+	for addrIndex := 0; addrIndex < addrCount; addrIndex++ {
+		var ipSrc net.IP
+		// Note that GetLocalAddrAny has the free ones first
+		ipSrc, err = types.GetLocalAddrAnyNoLinkLocal(ctx.deviceNetworkStatus,
+			addrIndex, "")
+		if err != nil {
+			log.Errorf("GetLocalAddr failed: %s", err)
+			errStr = errStr + "\n" + err.Error()
+			continue
+		}
+		ifname := types.GetMgmtPortFromAddr(ctx.deviceNetworkStatus, ipSrc)
+		log.Functionf("Uploading %s using IP source %v if %s transport %v",
+			config.Name, ipSrc, ifname, dsCtx.TransportMethod)
+		_, err = download(ctx, trType, &uploadStatus{}, zedUpload.SyncOpUpload,
+			serverURL, auth, dsCtx.Dpath, dsCtx.Region, uint64(status.Size),
+			ifname, ipSrc, config.Name, config.Filename)
+		if err == nil {
+			return nil
+		}
+		log.Errorf("Source IP %s failed: %s", ipSrc.String(), err)
+		errStr = errStr + "\n" + err.Error()
+	}
+	return fmt.Errorf("All source IP addresses failed. All errors:%s", errStr)
+}
+
+func publishDiagBundleStatus(ctx *downloaderContext,
+	status *types.DiagBundleStatus) {
+
+	status.Time = time.Now()
+	key := status.Key()
+	log.Tracef("publishDiagBundleStatus(%s)", key)
+	ctx.pubDiagBundleStatus.Publish(key, *status)
+}
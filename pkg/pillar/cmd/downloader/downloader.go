@@ -11,6 +11,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/lf-edge/eve/libs/zedUpload"
@@ -19,6 +20,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/flextimer"
 	"github.com/lf-edge/eve/pkg/pillar/pidfile"
 	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/tracing"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/utils"
 	"github.com/lf-edge/eve/pkg/pillar/zedcloud"
@@ -45,12 +47,14 @@ var (
 	resHandler     = makeResolveHandler()
 	logger         *logrus.Logger
 	log            *base.LogObject
+	tracer         *tracing.Tracer
 )
 
 // Run downloader
 func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
 	logger = loggerArg
 	log = logArg
+	tracer = tracing.NewTracer(log, agentName)
 	versionPtr := flag.Bool("v", false, "Version")
 	debugPtr := flag.Bool("d", false, "Debug flag")
 	flag.Parse()
@@ -172,6 +176,9 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 		case change := <-ctx.subDatastoreConfig.MsgChan():
 			ctx.subDatastoreConfig.ProcessChange(change)
 
+		case change := <-ctx.subDiagBundleConfig.MsgChan():
+			ctx.subDiagBundleConfig.ProcessChange(change)
+
 		case <-publishTimer.C:
 			start := time.Now()
 			err := metricsPub.Publish("global", zedcloud.GetCloudMetrics(log))
@@ -429,7 +436,10 @@ func doDownload(ctx *downloaderContext, config types.DownloaderConfig, status *t
 	}
 	log.Tracef("Found datastore(%s) for %s", config.DatastoreID.String(), config.Name)
 
+	span := tracer.Start(config.Trace, "download", "sha256", config.ImageSha256,
+		"name", config.Name, "retry", strconv.Itoa(status.RetryCount))
 	handleSyncOp(ctx, status.Key(), config, status, dst)
+	span.End(status.Error)
 }
 
 func handleDelete(ctx *downloaderContext, key string,
@@ -150,6 +150,8 @@ func resolveTagsToHash(ctx *downloaderContext, rc types.ResolveConfig) {
 		}
 	}
 	rs.ClearError()
+	span := tracer.Start(rc.Trace, "resolve", "name", rc.Name)
+	defer func() { span.End(rs.Error) }()
 
 	sha := maybeNameHasSha(rc.Name)
 	if sha != "" {
@@ -41,6 +41,8 @@ import (
 const (
 	agentName        = "ledmanager"
 	ledConfigDirName = "/var/tmp/ledmanager/config"
+	// After the blinks of the counter, while a critical alert is firing
+	alertBlinkCount = 7
 )
 
 // State passed to handlers
@@ -50,6 +52,9 @@ type ledManagerContext struct {
 	subGlobalConfig        pubsub.Subscription
 	subLedBlinkCounter     pubsub.Subscription
 	subDeviceNetworkStatus pubsub.Subscription
+	subAlertStatus         pubsub.Subscription
+	alertChange            chan bool
+	criticalAlert          bool // A critical alert is firing
 	deviceNetworkStatus    types.DeviceNetworkStatus
 	usableAddressCount     int
 	derivedLedCounter      int // Based on ledCounter + usableAddressCount
@@ -255,8 +260,9 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	// Any state needed by handler functions
 	ctx := ledManagerContext{}
 	ctx.countChange = make(chan int)
+	ctx.alertChange = make(chan bool)
 	log.Functionf("Creating %s at %s", "triggerBinkOnDevice", agentlog.GetMyStack())
-	go TriggerBlinkOnDevice(ctx.countChange, blinkFunc, ledName)
+	go TriggerBlinkOnDevice(ctx.countChange, ctx.alertChange, blinkFunc, ledName)
 
 	subLedBlinkCounter, err := ps.NewSubscription(pubsub.SubscriptionOptions{
 		AgentName:     "",
@@ -294,6 +300,24 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	ctx.subDeviceNetworkStatus = subDeviceNetworkStatus
 	subDeviceNetworkStatus.Activate()
 
+	subAlertStatus, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		AgentName:     "zedagent",
+		MyAgentName:   agentName,
+		TopicImpl:     types.AlertStatus{},
+		Activate:      false,
+		Ctx:           &ctx,
+		CreateHandler: handleAlertCreate,
+		ModifyHandler: handleAlertModify,
+		DeleteHandler: handleAlertDelete,
+		WarningTime:   warningTime,
+		ErrorTime:     errorTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx.subAlertStatus = subAlertStatus
+	subAlertStatus.Activate()
+
 	// Look for global config such as log levels
 	subGlobalConfig, err := ps.NewSubscription(pubsub.SubscriptionOptions{
 		AgentName:     "zedagent",
@@ -337,6 +361,9 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 		case change := <-subLedBlinkCounter.MsgChan():
 			subLedBlinkCounter.ProcessChange(change)
 
+		case change := <-subAlertStatus.MsgChan():
+			subAlertStatus.ProcessChange(change)
+
 		case <-stillRunning.C:
 			// Fault injection
 			if fatalFlag {
@@ -404,15 +431,18 @@ func handleLedBlinkDelete(ctxArg interface{}, key string,
 	log.Functionf("handleLedBlinkDelete done for %s", key)
 }
 
-func TriggerBlinkOnDevice(countChange chan int, blinkFunc Blink200msFunc,
-	ledName string) {
+func TriggerBlinkOnDevice(countChange chan int, alertChange chan bool,
+	blinkFunc Blink200msFunc, ledName string) {
 
 	var counter int
+	var alert bool
 	for {
 		select {
 		case counter = <-countChange:
 			log.Tracef("Received counter update: %d",
 				counter)
+		case alert = <-alertChange:
+			log.Tracef("Received alert update: %t", alert)
 		default:
 			log.Tracef("Unchanged counter: %d", counter)
 		}
@@ -424,6 +454,16 @@ func TriggerBlinkOnDevice(countChange chan int, blinkFunc Blink200msFunc,
 			time.Sleep(200 * time.Millisecond)
 		}
 		time.Sleep(1200 * time.Millisecond)
+		if !alert {
+			continue
+		}
+		for i := 0; i < alertBlinkCount; i++ {
+			if blinkFunc != nil {
+				blinkFunc(ledName)
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+		time.Sleep(1200 * time.Millisecond)
 	}
 }
 
@@ -567,6 +607,40 @@ func ExecuteLedCmd(ledName string) {
 	}
 }
 
+func handleAlertCreate(ctxArg interface{}, key string,
+	statusArg interface{}) {
+	handleAlertImpl(ctxArg)
+}
+
+func handleAlertModify(ctxArg interface{}, key string,
+	statusArg interface{}, oldStatusArg interface{}) {
+	handleAlertImpl(ctxArg)
+}
+
+func handleAlertDelete(ctxArg interface{}, key string,
+	statusArg interface{}) {
+	handleAlertImpl(ctxArg)
+}
+
+// handleAlertImpl tells the blink go routine whether a critical alert is
+// firing
+func handleAlertImpl(ctxArg interface{}) {
+	ctx := ctxArg.(*ledManagerContext)
+	critical := false
+	for _, s := range ctx.subAlertStatus.GetAll() {
+		if s.(types.AlertStatus).Severity == types.AlertSeverityCritical {
+			critical = true
+			break
+		}
+	}
+	if critical == ctx.criticalAlert {
+		return
+	}
+	ctx.criticalAlert = critical
+	log.Functionf("handleAlertImpl: critical alert %t", critical)
+	ctx.alertChange <- critical
+}
+
 func handleDNSCreate(ctxArg interface{}, key string,
 	statusArg interface{}) {
 	handleDNSImpl(ctxArg, key, statusArg)
@@ -0,0 +1,430 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Query the logs kept on the device by newlogd: the gzip files waiting for
+// upload, failed to upload and already uploaded, and the log files being
+// collected, oldest first. The entries can be filtered by time range,
+// source, app, level, LogObject type and key, and regular expression, and
+// printed as text or JSON lines. With -f the log files being collected are
+// followed for new entries.
+
+package logquery
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/api/go/logs"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	followInterval = time.Second
+)
+
+var logger *logrus.Logger
+var log *base.LogObject
+
+// logDirs are the directories of the gzip files, oldest content first
+var logDirs = []string{
+	types.NewlogKeepSentQueueDir,
+	types.NewlogDir + "/failedUpload",
+	types.NewlogUploadDevDir,
+	types.NewlogUploadAppDir,
+}
+
+// query is what to print and how
+type query struct {
+	since    time.Time
+	until    time.Time
+	sources  map[string]bool
+	appUUID  string
+	level    logrus.Level // least severe level
+	objType  string
+	objKey   string
+	regex    *regexp.Regexp
+	jsonOut  bool
+	output   io.Writer
+	numFound int
+}
+
+// logFile is a gzip file or a log file being collected
+type logFile struct {
+	path    string
+	appUUID string
+	gzip    bool
+	modTime time.Time // after all the entries of a gzip file
+}
+
+// queryEntry is the JSON output
+type queryEntry struct {
+	Time     string `json:"time"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	AppUUID  string `json:"appuuid,omitempty"`
+	Pid      string `json:"pid,omitempty"`
+	Msgid    uint64 `json:"msgid"`
+	Filename string `json:"filename,omitempty"`
+	Function string `json:"function,omitempty"`
+	Content  string `json:"content"`
+}
+
+// Run is the logquery entrypoint
+func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
+	logger = loggerArg
+	log = logArg
+	sincePtr := flag.String("since", "", "Only entries since time (RFC3339) or duration ago, e.g., 2h")
+	untilPtr := flag.String("until", "", "Only entries until time (RFC3339) or duration ago")
+	sourcePtr := flag.String("source", "", "Only entries of the comma separated sources, e.g., zedagent,kernel")
+	appPtr := flag.String("app", "", "Only entries of the app instance UUID")
+	devicePtr := flag.Bool("device", false, "Only device entries")
+	levelPtr := flag.String("level", "trace", "Only entries of this level or more severe")
+	objTypePtr := flag.String("objtype", "", "Only entries of the LogObject type, e.g., app_instance_status")
+	objKeyPtr := flag.String("objkey", "", "Only entries of the LogObject key")
+	regexPtr := flag.String("e", "", "Only entries with content matching the regular expression")
+	jsonPtr := flag.Bool("json", false, "Print JSON lines")
+	followPtr := flag.Bool("f", false, "Follow the entries being collected")
+	flag.Parse()
+
+	q := query{
+		appUUID: *appPtr,
+		objType: *objTypePtr,
+		objKey:  *objKeyPtr,
+		jsonOut: *jsonPtr,
+		output:  os.Stdout,
+	}
+	var err error
+	now := time.Now()
+	if q.since, err = parseTime(*sincePtr, now); err != nil {
+		fmt.Fprintf(os.Stderr, "logquery: -since: %v\n", err)
+		return 1
+	}
+	if q.until, err = parseTime(*untilPtr, now); err != nil {
+		fmt.Fprintf(os.Stderr, "logquery: -until: %v\n", err)
+		return 1
+	}
+	if q.level, err = logrus.ParseLevel(*levelPtr); err != nil {
+		fmt.Fprintf(os.Stderr, "logquery: -level: %v\n", err)
+		return 1
+	}
+	if *regexPtr != "" {
+		if q.regex, err = regexp.Compile(*regexPtr); err != nil {
+			fmt.Fprintf(os.Stderr, "logquery: -e: %v\n", err)
+			return 1
+		}
+	}
+	if *sourcePtr != "" {
+		q.sources = make(map[string]bool)
+		for _, source := range strings.Split(*sourcePtr, ",") {
+			q.sources[strings.TrimSpace(source)] = true
+		}
+	}
+	if *devicePtr {
+		if q.appUUID != "" {
+			fmt.Fprintln(os.Stderr, "logquery: -device and -app are exclusive")
+			return 1
+		}
+		// no app has an empty UUID
+		q.appUUID = "-"
+	}
+
+	files := listGzipFiles(logDirs)
+	files = append(files, listCollectFiles(types.NewlogCollectDir)...)
+	offsets := make(map[string]int64)
+	for _, f := range files {
+		if !q.matchFile(f) {
+			continue
+		}
+		offset, err := q.readFile(f, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logquery: %s: %v\n", f.path, err)
+		}
+		if !f.gzip {
+			offsets[f.path] = offset
+		}
+	}
+	if !*followPtr {
+		if q.numFound == 0 {
+			return 1
+		}
+		return 0
+	}
+	for {
+		time.Sleep(followInterval)
+		current := make(map[string]int64)
+		for _, f := range listCollectFiles(types.NewlogCollectDir) {
+			offset := offsets[f.path]
+			if q.matchFile(f) {
+				offset, err = q.readFile(f, offset)
+				if err != nil && !os.IsNotExist(err) {
+					fmt.Fprintf(os.Stderr, "logquery: %s: %v\n", f.path, err)
+				}
+			}
+			current[f.path] = offset
+		}
+		offsets = current
+	}
+}
+
+// parseTime parses a RFC3339 time or a duration before now
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// listGzipFiles returns the gzip files in the dirs ordered by time
+func listGzipFiles(dirs []string) []logFile {
+	var files []logFile
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			name := info.Name()
+			if info.IsDir() || !strings.HasSuffix(name, ".gz") {
+				continue
+			}
+			f := logFile{path: filepath.Join(dir, name), gzip: true}
+			// dev.log.<msec>.gz or app.<UUID>.log.<msec>.gz
+			stem := strings.TrimSuffix(name, ".gz")
+			if strings.HasPrefix(name, types.AppPrefix) {
+				parts := strings.SplitN(strings.TrimPrefix(stem, types.AppPrefix),
+					types.AppSuffix, 2)
+				if len(parts) != 2 {
+					continue
+				}
+				f.appUUID, stem = parts[0], parts[1]
+			} else if strings.HasPrefix(name, types.DevPrefix) {
+				stem = strings.TrimPrefix(stem, types.DevPrefix)
+			} else {
+				continue
+			}
+			msec, err := strconv.ParseInt(stem, 10, 64)
+			if err != nil {
+				continue
+			}
+			f.modTime = time.Unix(0, msec*int64(time.Millisecond))
+			files = append(files, f)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files
+}
+
+// listCollectFiles returns the log files being collected in dir
+func listCollectFiles(dir string) []logFile {
+	var files []logFile
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		f := logFile{path: filepath.Join(dir, name), modTime: info.ModTime()}
+		// dev.log.<random> or app.<UUID>.log<random>
+		if strings.HasPrefix(name, types.AppPrefix) {
+			f.appUUID = strings.SplitN(strings.TrimPrefix(name, types.AppPrefix),
+				".log", 2)[0]
+		} else if !strings.HasPrefix(name, types.DevPrefix) {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files
+}
+
+// matchFile returns false if no entry of the file can match
+func (q *query) matchFile(f logFile) bool {
+	if q.appUUID != "" && f.appUUID != q.appUUID &&
+		!(q.appUUID == "-" && f.appUUID == "") {
+		return false
+	}
+	// the gzip files are written after their last entry
+	if f.gzip && !q.since.IsZero() && f.modTime.Before(q.since) {
+		return false
+	}
+	return true
+}
+
+// readFile prints the matching entries of the file from offset and
+// returns the offset after the last complete line. The first line of the
+// files being collected is metadata.
+func (q *query) readFile(f logFile, offset int64) (int64, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if f.gzip {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return offset, err
+		}
+		defer gz.Close()
+		reader = gz
+	} else if offset != 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return offset, err
+		}
+	}
+	bufReader := bufio.NewReader(reader)
+	for {
+		line, err := bufReader.ReadBytes('\n')
+		if err != nil {
+			// an incomplete line is read again when complete
+			if err == io.EOF {
+				err = nil
+			}
+			return offset, err
+		}
+		skip := !f.gzip && offset == 0
+		offset += int64(len(line))
+		if skip {
+			continue
+		}
+		var entry logs.LogEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			log.Tracef("readFile: %s: %v", f.path, err)
+			continue
+		}
+		if q.match(&entry) {
+			q.print(&entry, f.appUUID)
+		}
+	}
+}
+
+// severityLevel maps the logrus and syslog severities to logrus levels
+func severityLevel(severity string) logrus.Level {
+	switch strings.ToLower(severity) {
+	case "emerg", "alert", "crit":
+		return logrus.FatalLevel
+	case "err":
+		return logrus.ErrorLevel
+	case "notice":
+		return logrus.InfoLevel
+	}
+	level, err := logrus.ParseLevel(severity)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+	return level
+}
+
+// objectFields returns the JSON fields of the content, for the entries
+// of a LogObject
+func objectFields(content string) map[string]interface{} {
+	if !strings.HasPrefix(content, "{") {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+func (q *query) match(entry *logs.LogEntry) bool {
+	if entry.Timestamp != nil {
+		t := time.Unix(entry.Timestamp.Seconds, int64(entry.Timestamp.Nanos))
+		if !q.since.IsZero() && t.Before(q.since) {
+			return false
+		}
+		if !q.until.IsZero() && t.After(q.until) {
+			return false
+		}
+	}
+	if q.sources != nil && !q.sources[entry.Source] {
+		return false
+	}
+	if severityLevel(entry.Severity) > q.level {
+		return false
+	}
+	if q.objType != "" || q.objKey != "" {
+		fields := objectFields(entry.Content)
+		if q.objType != "" && fields["obj_type"] != q.objType {
+			return false
+		}
+		if q.objKey != "" && fields["obj_key"] != q.objKey {
+			return false
+		}
+	}
+	if q.regex != nil && !q.regex.MatchString(entry.Content) {
+		return false
+	}
+	return true
+}
+
+func (q *query) print(entry *logs.LogEntry, appUUID string) {
+	q.numFound++
+	var timeStr string
+	if entry.Timestamp != nil {
+		timeStr = time.Unix(entry.Timestamp.Seconds,
+			int64(entry.Timestamp.Nanos)).UTC().Format(time.RFC3339Nano)
+	}
+	if q.jsonOut {
+		out, _ := json.Marshal(queryEntry{
+			Time:     timeStr,
+			Severity: entry.Severity,
+			Source:   entry.Source,
+			AppUUID:  appUUID,
+			Pid:      entry.Iid,
+			Msgid:    entry.Msgid,
+			Filename: entry.Filename,
+			Function: entry.Function,
+			Content:  entry.Content,
+		})
+		fmt.Fprintf(q.output, "%s\n", out)
+		return
+	}
+	source := entry.Source
+	if entry.Iid != "" {
+		source += "[" + entry.Iid + "]"
+	}
+	if appUUID != "" {
+		source = appUUID + "/" + source
+	}
+	content := entry.Content
+	if fields := objectFields(content); fields != nil {
+		if msg, ok := fields["msg"].(string); ok {
+			content = msg
+			for _, key := range []string{"obj_type", "obj_key"} {
+				if value, ok := fields[key]; ok {
+					content += fmt.Sprintf(" %s=%v", key, value)
+				}
+			}
+		}
+	}
+	fmt.Fprintf(q.output, "%s %s %s: %s\n", timeStr, entry.Severity, source,
+		content)
+}
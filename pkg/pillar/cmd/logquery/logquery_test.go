@@ -0,0 +1,162 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package logquery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/sirupsen/logrus"
+)
+
+const testAppUUID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func writeGzip(t *testing.T, path string, lines ...string) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	gw.Close()
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	logger = logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, "logquery", 0)
+	dir, err := ioutil.TempDir("", "logquery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	uploadDir := filepath.Join(dir, "devUpload")
+	collectDir := filepath.Join(dir, "collect")
+	os.Mkdir(uploadDir, 0755)
+	os.Mkdir(collectDir, 0755)
+
+	writeGzip(t, filepath.Join(uploadDir, "dev.log.2000000000000.gz"),
+		`{"severity":"info","source":"zedagent","iid":"10","content":"{\"msg\":\"old\"}","msgid":1,"timestamp":{"seconds":1999999999}}`)
+	writeGzip(t, filepath.Join(uploadDir, "dev.log.3000000000000.gz"),
+		`{"severity":"error","source":"zedmanager","iid":"11","content":"{\"msg\":\"failed\",\"obj_type\":\"app_instance_status\",\"obj_key\":\"`+testAppUUID+`\"}","msgid":2,"timestamp":{"seconds":2999999999}}`,
+		`{"severity":"debug","source":"zedmanager","iid":"11","content":"{\"msg\":\"details\"}","msgid":3,"timestamp":{"seconds":2999999999}}`)
+	collectFile := filepath.Join(collectDir, "app."+testAppUUID+".log123")
+	err = ioutil.WriteFile(collectFile, []byte("myapp\n"+
+		`{"severity":"info","source":"guest_vm","content":"hello","msgid":4,"timestamp":{"seconds":3000000001}}`+"\n"+
+		`{"severity":"info","source":"guest_vm","content":"partial`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(q query) string {
+		var buf bytes.Buffer
+		q.output = &buf
+		if q.level == 0 {
+			q.level = logrus.TraceLevel
+		}
+		files := listGzipFiles([]string{uploadDir})
+		files = append(files, listCollectFiles(collectDir)...)
+		for _, f := range files {
+			if q.matchFile(f) {
+				if _, err := q.readFile(f, 0); err != nil {
+					t.Errorf("readFile %s: %v", f.path, err)
+				}
+			}
+		}
+		return buf.String()
+	}
+
+	out := run(query{})
+	expected := "2033-05-18T03:33:19Z info zedagent[10]: old\n" +
+		"2065-01-24T05:19:59Z error zedmanager[11]: failed obj_type=app_instance_status obj_key=" + testAppUUID + "\n" +
+		"2065-01-24T05:19:59Z debug zedmanager[11]: details\n" +
+		"2065-01-24T05:20:01Z info " + testAppUUID + "/guest_vm: hello\n"
+	if out != expected {
+		t.Errorf("got\n%s\nexpected\n%s", out, expected)
+	}
+
+	testMatrix := map[string]struct {
+		q        query
+		expected []string
+	}{
+		"since": {
+			q:        query{since: time.Unix(2500000000, 0)},
+			expected: []string{"failed", "details", "hello"},
+		},
+		"until": {
+			q:        query{until: time.Unix(2500000000, 0)},
+			expected: []string{"old"},
+		},
+		"level": {
+			q:        query{level: logrus.InfoLevel},
+			expected: []string{"old", "failed", "hello"},
+		},
+		"source": {
+			q:        query{sources: map[string]bool{"zedagent": true, "guest_vm": true}},
+			expected: []string{"old", "hello"},
+		},
+		"app": {
+			q:        query{appUUID: testAppUUID},
+			expected: []string{"hello"},
+		},
+		"device": {
+			q:        query{appUUID: "-"},
+			expected: []string{"old", "failed", "details"},
+		},
+		"object": {
+			q:        query{objType: "app_instance_status", objKey: testAppUUID},
+			expected: []string{"failed"},
+		},
+		"regex": {
+			q:        query{regex: regexp.MustCompile("^hel+o$")},
+			expected: []string{"hello"},
+		},
+	}
+	for name, test := range testMatrix {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(run(test.q)), "\n") {
+			if line != "" {
+				got = append(got, line[strings.LastIndex(line, ": ")+2:])
+			}
+		}
+		for i, msg := range got {
+			got[i] = strings.Fields(msg)[0]
+		}
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: got %v, expected %v", name, got, test.expected)
+		}
+	}
+
+	// follow: the partial line is read once complete
+	q := query{level: logrus.TraceLevel, jsonOut: true}
+	var buf bytes.Buffer
+	q.output = &buf
+	f := listCollectFiles(collectDir)[0]
+	offset, err := q.readFile(f, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(collectFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(` line","msgid":5,"timestamp":{"seconds":3000000002}}` + "\n")
+	file.Close()
+	buf.Reset()
+	if _, err := q.readFile(f, offset); err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"time":"2065-01-24T05:20:02Z","severity":"info","source":"guest_vm","appuuid":"` +
+		testAppUUID + `","msgid":5,"content":"partial line"}` + "\n"
+	if buf.String() != expectedJSON {
+		t.Errorf("got %s, expected %s", buf.String(), expectedJSON)
+	}
+}
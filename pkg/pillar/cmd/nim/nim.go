@@ -46,6 +46,7 @@ type nimContext struct {
 	sshAccess            bool
 	sshAuthorizedKeys    string
 	allowAppVnc          bool
+	prometheusAccess     iptables.PrometheusAccess
 
 	subNetworkInstanceStatus pubsub.Subscription
 
@@ -1024,6 +1025,20 @@ func handleGlobalConfigImpl(ctxArg interface{}, key string,
 			ctx.allowAppVnc = gcpAllowAppVnc
 			iptables.UpdateVncAccess(log, ctx.allowAppVnc)
 		}
+		gcpPrometheusAccess := iptables.PrometheusAccess{
+			Port:   gcp.GlobalValueInt(types.PrometheusPort),
+			Ifname: gcp.GlobalValueString(types.PrometheusInterface),
+		}
+		allow, err := types.ParsePrefixList(gcp.GlobalValueString(types.PrometheusAllow))
+		if err != nil {
+			log.Errorf("handleGlobalConfigImpl: %s: %v", types.PrometheusAllow, err)
+		}
+		gcpPrometheusAccess.Allow = allow
+		if !reflect.DeepEqual(gcpPrometheusAccess, ctx.prometheusAccess) {
+			iptables.UpdatePrometheusAccess(log, ctx.prometheusAccess,
+				gcpPrometheusAccess)
+			ctx.prometheusAccess = gcpPrometheusAccess
+		}
 		if gcpNetworkFallbackAnyEth != ctx.networkFallbackAnyEth || first {
 			ctx.networkFallbackAnyEth = gcpNetworkFallbackAnyEth
 			updateFallbackAnyEth(ctx)
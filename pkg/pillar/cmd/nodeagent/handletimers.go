@@ -271,16 +271,54 @@ func allDomainsHalted(ctxPtr *nodeagentContext) bool {
 
 }
 
+// haltableDomains returns the activated domains which no other activated
+// domain depends on. zedmanager halts those first.
+func haltableDomains(ctxPtr *nodeagentContext) []string {
+	items := ctxPtr.subDomainStatus.GetAll()
+	needed := make(map[string]bool)
+	for _, c := range items {
+		ds := c.(types.DomainStatus)
+		if !ds.Activated {
+			continue
+		}
+		for _, dep := range ds.DependsOn {
+			needed[dep.String()] = true
+		}
+	}
+	var haltable []string
+	for _, c := range items {
+		ds := c.(types.DomainStatus)
+		if ds.Activated && !needed[ds.Key()] {
+			haltable = append(haltable, ds.Key())
+		}
+	}
+	return haltable
+}
+
 // waitForAllDomainsHalted
-//  blocks till all domains are halted. Should only be invoked from
-//  a thread.
+//  blocks till all domains are halted. Domains are halted in the reverse
+//  order of their dependencies hence we wait up to maxDomainHaltTime
+//  each time a domain can start halting since its dependents halted.
+//  Should only be invoked from a thread.
 func waitForAllDomainsHalted(ctxPtr *nodeagentContext) {
 
 	var totalWaitTime uint32
+	haltable := make(map[string]bool)
 	for totalWaitTime = 0; totalWaitTime < ctxPtr.maxDomainHaltTime; totalWaitTime += ctxPtr.domainHaltWaitIncrement {
 		if allDomainsHalted(ctxPtr) {
 			return
 		}
+		for _, key := range haltableDomains(ctxPtr) {
+			if haltable[key] {
+				continue
+			}
+			if len(haltable) != 0 {
+				log.Functionf("waitForAllDomainsHalted: dependents of %s halted "+
+					"after %d sec; restarting wait", key, totalWaitTime)
+				totalWaitTime = 0
+			}
+			haltable[key] = true
+		}
 
 		duration := time.Second * time.Duration(ctxPtr.domainHaltWaitIncrement)
 		domainHaltWaitTimer := time.NewTimer(duration)
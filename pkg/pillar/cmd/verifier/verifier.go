@@ -26,6 +26,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/base"
 	"github.com/lf-edge/eve/pkg/pillar/pidfile"
 	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/tracing"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/utils"
 	uuid "github.com/satori/go.uuid"
@@ -61,10 +62,12 @@ var debug = false
 var debugOverride bool // From command line arg
 var logger *logrus.Logger
 var log *base.LogObject
+var tracer *tracing.Tracer
 
 func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
 	logger = loggerArg
 	log = logArg
+	tracer = tracing.NewTracer(log, agentName)
 	versionPtr := flag.Bool("v", false, "Version")
 	debugPtr := flag.Bool("d", false, "Debug flag")
 	flag.Parse()
@@ -291,6 +294,8 @@ func handleCreate(ctx *verifierContext,
 		RefCount:    config.RefCount,
 	}
 	publishVerifyImageStatus(ctx, &status)
+	span := tracer.Start(config.Trace, "verify", "sha256", config.ImageSha256)
+	defer func() { span.End(status.Error) }()
 
 	if config.FileLocation == "" {
 		err := fmt.Errorf("handleCreate: verifyImageConfig: %s has empty fileLocation", config.ImageSha256)
@@ -469,6 +474,7 @@ func handleGlobalConfigImpl(ctxArg interface{}, key string,
 	debug, gcp = agentlog.HandleGlobalConfig(log, ctx.subGlobalConfig, agentName,
 		debugOverride, logger)
 	if gcp != nil {
+		tracer.UpdateConfig(gcp)
 		ctx.GCInitialized = true
 	}
 	log.Functionf("handleGlobalConfigImpl done for %s", key)
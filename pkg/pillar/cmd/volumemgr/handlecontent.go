@@ -35,6 +35,10 @@ func handleContentTreeModify(ctxArg interface{}, key string,
 	if status == nil {
 		log.Fatalf("Missing ContentTreeStatus for %s", config.Key())
 	}
+	if config.Trace != status.Trace {
+		status.Trace = config.Trace
+		publishContentTreeStatus(ctx, status)
+	}
 	updateContentTree(ctx, status)
 	log.Functionf("handleContentTree(%s) Done", key)
 }
@@ -159,6 +163,7 @@ func createContentTreeStatus(ctx *volumemgrContext, config types.ContentTreeConf
 			DisplayName:       config.DisplayName,
 			State:             types.INITIAL,
 			Blobs:             []string{},
+			Trace:             config.Trace,
 		}
 
 		// we only publish the BlobStatus if we have the hash for it; this
@@ -0,0 +1,105 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package volumemgr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/diskhealth"
+	"github.com/lf-edge/eve/pkg/pillar/flextimer"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// diskHealthTimerTask reads and publishes the health of the disks
+// periodically
+func diskHealthTimerTask(ctx *volumemgrContext, handleChannel chan interface{}) {
+	log.Functionln("starting diskHealthTimerTask timer task")
+	tracker := diskhealth.NewTracker(log, types.DiskHealthDir)
+	updateDiskHealth(ctx, tracker)
+
+	interval := time.Duration(ctx.globalConfig.GlobalValueInt(types.DiskHealthInterval)) * time.Second
+	max := float64(interval)
+	min := max * 0.3
+	ticker := flextimer.NewRangeTicker(time.Duration(min), time.Duration(max))
+	// Return handle to caller
+	handleChannel <- ticker
+
+	wdName := agentName + "diskhealth"
+
+	// Run a periodic timer so we always update StillRunning
+	stillRunning := time.NewTicker(25 * time.Second)
+	ctx.ps.StillRunning(wdName, warningTime, errorTime)
+	ctx.ps.RegisterFileWatchdog(wdName)
+
+	for {
+		select {
+		case <-ticker.C:
+			start := time.Now()
+			updateDiskHealth(ctx, tracker)
+			ctx.ps.CheckMaxTimeTopic(wdName, "updateDiskHealth", start,
+				warningTime, errorTime)
+
+		case <-stillRunning.C:
+		}
+		ctx.ps.StillRunning(wdName, warningTime, errorTime)
+	}
+}
+
+// updateDiskHealth publishes the health of the disks read, and unpublishes
+// those which are gone
+func updateDiskHealth(ctx *volumemgrContext, tracker *diskhealth.Tracker) {
+	pub := ctx.pubDiskHealthStatus
+	now := time.Now()
+	current := make(map[string]bool)
+	for _, status := range diskhealth.Read(log) {
+		tracker.Update(&status, now)
+		diskhealth.Evaluate(&status)
+		current[status.Key()] = true
+		pub.Publish(status.Key(), status)
+	}
+	for _, s := range pub.GetAll() {
+		status := s.(types.DiskHealthStatus)
+		if !current[status.Key()] {
+			pub.Unpublish(status.Key())
+		}
+	}
+}
+
+// checkFailingDisk returns an error when BlockFailingDiskForApps is set and
+// a disk holding /persist is failing, so that no new volume is put on it
+func checkFailingDisk(ctx *volumemgrContext) error {
+	if !ctx.globalConfig.GlobalValueBool(types.BlockFailingDiskForApps) {
+		return nil
+	}
+	for _, s := range ctx.pubDiskHealthStatus.GetAll() {
+		status := s.(types.DiskHealthStatus)
+		if status.Persist && status.State == types.DiskHealthFailing {
+			return fmt.Errorf("disk %s holding %s is failing: %v",
+				status.DiskName, types.PersistDir, status.Reasons)
+		}
+	}
+	return nil
+}
+
+// retryVolumesAwaitingHealthyDisk creates the volumes which were blocked by
+// checkFailingDisk once the disk recovers or BlockFailingDiskForApps is
+// cleared
+func retryVolumesAwaitingHealthyDisk(ctx *volumemgrContext) {
+	if err := checkFailingDisk(ctx); err != nil {
+		return
+	}
+	for _, st := range ctx.pubVolumeStatus.GetAll() {
+		status := st.(types.VolumeStatus)
+		if !status.AwaitingHealthyDisk {
+			continue
+		}
+		log.Noticef("retryVolumesAwaitingHealthyDisk(%s) creating volume",
+			status.Key())
+		if changed, _ := doUpdateVol(ctx, &status); changed {
+			publishVolumeStatus(ctx, &status)
+			updateVolumeRefStatus(ctx, &status)
+		}
+	}
+}
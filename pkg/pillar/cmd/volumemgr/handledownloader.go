@@ -59,6 +59,7 @@ func AddOrRefcountDownloaderConfig(ctx *volumemgrContext, blob types.BlobStatus)
 		Size:             size,
 		Target:           locFilename,
 		RefCount:         refCount,
+		Trace:            blob.Trace,
 	}
 	log.Functionf("AddOrRefcountDownloaderConfig: DownloaderConfig: %+v", n)
 	publishDownloaderConfig(ctx, &n)
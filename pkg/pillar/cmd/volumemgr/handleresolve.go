@@ -17,6 +17,7 @@ func MaybeAddResolveConfig(ctx *volumemgrContext, cs types.ContentTreeStatus) {
 		Name:             cs.RelativeURL,
 		AllowNonFreePort: types.AllowNonFreePort(*ctx.globalConfig),
 		Counter:          uint32(cs.GenerationCounter),
+		Trace:            cs.Trace,
 	}
 	publishResolveConfig(ctx, &resolveConfig)
 	log.Functionf("MaybeAddResolveConfig for %s Done", cs.ContentID)
@@ -34,6 +34,7 @@ func handleVolumeCreate(ctxArg interface{}, key string,
 		RefCount:                config.RefCount,
 		LastUse:                 time.Now(),
 		State:                   types.INITIAL,
+		Trace:                   config.Trace,
 	}
 	updateVolumeStatusRefCount(ctx, status)
 	status.ContentFormat = volumeFormat[status.Key()]
@@ -78,6 +79,8 @@ func handleVolumeCreate(ctxArg interface{}, key string,
 		} else if remaining < status.MaxVolSize {
 			errStr := fmt.Sprintf("Remaining disk space %d volume needs %d\n",
 				remaining, status.MaxVolSize)
+			// Retried by retryVolumesAwaitingSpace
+			status.AwaitingDiskSpace = true
 			status.SetError(errStr, time.Now())
 			publishVolumeStatus(ctx, status)
 			updateVolumeRefStatus(ctx, status)
@@ -131,6 +134,7 @@ func handleVolumeModify(ctxArg interface{}, key string,
 			status.RefCount, config.RefCount, config.DisplayName)
 		status.RefCount = config.RefCount
 	}
+	status.Trace = config.Trace
 	updateVolumeStatusRefCount(ctx, status)
 	publishVolumeStatus(ctx, status)
 	updateVolumeRefStatus(ctx, status)
@@ -262,6 +266,7 @@ func maybeDeleteVolume(ctx *volumemgrContext, status *types.VolumeStatus) {
 	if appDiskMetric := lookupAppDiskMetric(ctx, status.FileLocation); appDiskMetric != nil {
 		unpublishAppDiskMetrics(ctx, appDiskMetric)
 	}
+	retryVolumesAwaitingSpace(ctx)
 	log.Functionf("maybeDeleteVolume for %v Done", status.Key())
 }
 
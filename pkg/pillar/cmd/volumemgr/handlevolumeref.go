@@ -32,6 +32,7 @@ func handleVolumeRefCreate(ctxArg interface{}, key string,
 			ReadOnly:           vs.ReadOnly,
 			DisplayName:        vs.DisplayName,
 			MaxVolSize:         vs.MaxVolSize,
+			AwaitingDiskSpace:  vs.AwaitingDiskSpace,
 		}
 		if vs.HasError() {
 			status.SetErrorWithSource(vs.Error, types.VolumeStatus{}, vs.ErrorTime)
@@ -149,6 +150,7 @@ func updateVolumeRefStatus(ctx *volumemgrContext, vs *types.VolumeStatus) {
 				status.ReadOnly = vs.ReadOnly
 				status.DisplayName = vs.DisplayName
 				status.MaxVolSize = vs.MaxVolSize
+				status.AwaitingDiskSpace = vs.AwaitingDiskSpace
 				if vs.HasError() {
 					status.SetErrorWithSource(vs.Error, types.VolumeStatus{}, vs.ErrorTime)
 				} else if status.IsErrorSource(types.VolumeStatus{}) {
@@ -168,6 +170,7 @@ func updateVolumeRefStatus(ctx *volumemgrContext, vs *types.VolumeStatus) {
 				ReadOnly:           vs.ReadOnly,
 				DisplayName:        vs.DisplayName,
 				MaxVolSize:         vs.MaxVolSize,
+				AwaitingDiskSpace:  vs.AwaitingDiskSpace,
 			}
 			if vs.HasError() {
 				status.SetErrorWithSource(vs.Error, types.VolumeStatus{}, vs.ErrorTime)
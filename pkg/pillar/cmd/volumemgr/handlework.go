@@ -125,7 +125,14 @@ func volumeWorker(ctxPtr interface{}, w worker.Work) worker.WorkResult {
 	var fileLocation string
 	var err error
 	if d.create {
+		span := tracer.Start(d.status.Trace, "create volume",
+			"volume", d.status.DisplayName)
 		volumeCreated, fileLocation, err = createVolume(ctx, d.status)
+		if err != nil {
+			span.End(err.Error())
+		} else {
+			span.End("")
+		}
 	} else if d.destroy {
 		volumeCreated, fileLocation, err = destroyVolume(ctx, d.status)
 	}
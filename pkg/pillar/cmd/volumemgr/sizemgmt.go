@@ -63,6 +63,44 @@ func getRemainingDiskSpace(ctxPtr *volumemgrContext) (uint64, error) {
 	}
 }
 
+// retryVolumesAwaitingSpace creates the volumes which did not fit on the
+// disk if there is space for them now
+func retryVolumesAwaitingSpace(ctx *volumemgrContext) {
+	var remaining uint64
+	var err error
+	checked := false
+	for _, st := range ctx.pubVolumeStatus.GetAll() {
+		status := st.(types.VolumeStatus)
+		if !status.AwaitingDiskSpace {
+			continue
+		}
+		if !checked {
+			remaining, err = getRemainingDiskSpace(ctx)
+			if err != nil {
+				log.Errorf("retryVolumesAwaitingSpace: %s", err)
+				return
+			}
+			checked = true
+		}
+		if !ctx.globalConfig.GlobalValueBool(types.IgnoreDiskCheckForApps) {
+			if remaining < status.MaxVolSize {
+				log.Functionf("retryVolumesAwaitingSpace(%s) remaining %d needs %d",
+					status.Key(), remaining, status.MaxVolSize)
+				continue
+			}
+			// Not counted by getRemainingDiskSpace until created
+			remaining -= status.MaxVolSize
+		}
+		log.Noticef("retryVolumesAwaitingSpace(%s) creating volume",
+			status.Key())
+		status.AwaitingDiskSpace = false
+		status.ClearErrorWithSource()
+		doUpdateVol(ctx, &status)
+		publishVolumeStatus(ctx, &status)
+		updateVolumeRefStatus(ctx, &status)
+	}
+}
+
 func dom0DiskReservedSize(ctxPtr *volumemgrContext, deviceDiskSize uint64) uint64 {
 	dom0MinDiskUsagePercent := ctxPtr.globalConfig.GlobalValueInt(
 		types.Dom0MinDiskUsagePercent)
@@ -141,6 +141,10 @@ func doUpdateContentTree(ctx *volumemgrContext, status *types.ContentTreeStatus)
 			}
 			totalSize += blob.TotalSize
 			currentSize += blob.CurrentSize
+			if blob.State < types.VERIFIED {
+				// the downloads and verifications are of this content tree
+				blob.Trace = status.Trace
+			}
 
 			// now the type should not be unknown (unless it is in error state)
 			// these calls might update Blob.State hence we check
@@ -463,6 +467,24 @@ func doUpdateVol(ctx *volumemgrContext, status *types.VolumeStatus) (bool, bool)
 				log.Functionf("doUpdateVol(%s): waiting for image create: %s", status.Key(), err.Error())
 				return changed, false
 			}
+			if err := checkFailingDisk(ctx); err != nil {
+				// Retried by retryVolumesAwaitingHealthyDisk
+				if !status.AwaitingHealthyDisk || status.Error != err.Error() {
+					log.Errorf("doUpdateVol(%s): %s", status.Key(), err)
+					status.AwaitingHealthyDisk = true
+					status.SetErrorWithSource(err.Error(),
+						types.DiskHealthStatus{}, time.Now())
+					changed = true
+				}
+				return changed, false
+			}
+			if status.AwaitingHealthyDisk {
+				status.AwaitingHealthyDisk = false
+				if status.IsErrorSource(types.DiskHealthStatus{}) {
+					status.ClearErrorWithSource()
+				}
+				changed = true
+			}
 
 			status.State = types.CREATING_VOLUME
 			// first blob is always the root
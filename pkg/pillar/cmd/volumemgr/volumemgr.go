@@ -19,6 +19,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/flextimer"
 	"github.com/lf-edge/eve/pkg/pillar/pidfile"
 	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/tracing"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/utils"
 	"github.com/lf-edge/eve/pkg/pillar/worker"
@@ -65,8 +66,10 @@ type volumemgrContext struct {
 	pubBlobStatus           pubsub.Publication
 	pubDiskMetric           pubsub.Publication
 	pubAppDiskMetric        pubsub.Publication
+	pubDiskHealthStatus     pubsub.Publication
 	subDatastoreConfig      pubsub.Subscription
 	diskMetricsTickerHandle interface{}
+	diskHealthTickerHandle  interface{}
 	gc                      *time.Ticker
 	deferDelete             *time.Ticker
 
@@ -91,11 +94,13 @@ var debug = false
 var debugOverride bool // From command line arg
 var logger *logrus.Logger
 var log *base.LogObject
+var tracer *tracing.Tracer
 
 // Run - the main function invoked by zedbox
 func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
 	logger = loggerArg
 	log = logArg
+	tracer = tracing.NewTracer(log, agentName)
 	versionPtr := flag.Bool("v", false, "Version")
 	debugPtr := flag.Bool("d", false, "Debug flag")
 	flag.Parse()
@@ -253,6 +258,17 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	}
 	ctx.pubAppDiskMetric = pubAppDiskMetric
 
+	pubDiskHealthStatus, err := ps.NewPublication(
+		pubsub.PublicationOptions{
+			AgentName: agentName,
+			TopicType: types.DiskHealthStatus{},
+		},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx.pubDiskHealthStatus = pubDiskHealthStatus
+
 	// Look for global config such as log levels
 	subZedAgentStatus, err := ps.NewSubscription(pubsub.SubscriptionOptions{
 		AgentName:     "zedagent",
@@ -497,6 +513,13 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	go diskMetricsTimerTask(&ctx, diskMetricsTickerHandle)
 	ctx.diskMetricsTickerHandle = <-diskMetricsTickerHandle
 
+	// start the disk health task
+	diskHealthTickerHandle := make(chan interface{})
+	log.Functionf("Creating %s at %s", "diskHealthTimerTask", agentlog.GetMyStack())
+
+	go diskHealthTimerTask(&ctx, diskHealthTickerHandle)
+	ctx.diskHealthTickerHandle = <-diskHealthTickerHandle
+
 	for {
 		select {
 		case change := <-ctx.subGlobalConfig.MsgChan():
@@ -537,6 +560,8 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 				gcUnusedInitObjects(&ctx)
 				ctx.initGced = true
 			}
+			retryVolumesAwaitingSpace(&ctx)
+			retryVolumesAwaitingHealthyDisk(&ctx)
 			ps.CheckMaxTimeTopic(agentName, "gc", start,
 				warningTime, errorTime)
 
@@ -597,7 +622,10 @@ func handleGlobalConfigImpl(ctxArg interface{}, key string,
 	if gcp != nil {
 		maybeUpdateConfigItems(ctx, gcp)
 		ctx.globalConfig = gcp
+		tracer.UpdateConfig(gcp)
 		ctx.GCInitialized = true
+		// BlockFailingDiskForApps might have been cleared
+		retryVolumesAwaitingHealthyDisk(ctx)
 	}
 	log.Functionf("handleGlobalConfigImpl done for %s", key)
 }
@@ -614,6 +642,7 @@ func handleGlobalConfigDelete(ctxArg interface{}, key string,
 	debug, _ = agentlog.HandleGlobalConfig(log, ctx.subGlobalConfig, agentName,
 		debugOverride, logger)
 	*ctx.globalConfig = *types.DefaultConfigItemValueMap()
+	retryVolumesAwaitingHealthyDisk(ctx)
 	log.Functionf("handleGlobalConfigDelete done for %s", key)
 }
 
@@ -676,6 +705,25 @@ func maybeUpdateConfigItems(ctx *volumemgrContext, newConfigItemValueMap *types.
 			flextimer.TickNow(ctx.diskMetricsTickerHandle)
 		}
 	}
+
+	if newConfigItemValueMap.GlobalValueInt(types.DiskHealthInterval) != 0 &&
+		newConfigItemValueMap.GlobalValueInt(types.DiskHealthInterval) !=
+			oldConfigItemValueMap.GlobalValueInt(types.DiskHealthInterval) {
+		log.Functionf("maybeUpdateConfigItems: Updating DiskHealthInterval from %d to %d",
+			oldConfigItemValueMap.GlobalValueInt(types.DiskHealthInterval),
+			newConfigItemValueMap.GlobalValueInt(types.DiskHealthInterval))
+		if ctx.diskHealthTickerHandle == nil {
+			log.Warnf("maybeUpdateConfigItems: no diskHealthTickerHandle yet")
+		} else {
+			interval := time.Duration(newConfigItemValueMap.
+				GlobalValueInt(types.DiskHealthInterval)) * time.Second
+			max := float64(interval)
+			min := max * 0.3
+			flextimer.UpdateRangeTicker(ctx.diskHealthTickerHandle,
+				time.Duration(min), time.Duration(max))
+			// The samples are only kept hourly, no need to tick now
+		}
+	}
 	newDC := newConfigItemValueMap.GlobalValueInt(types.DeferContentDelete)
 	oldDC := oldConfigItemValueMap.GlobalValueInt(types.DeferContentDelete)
 	if newDC != oldDC {
@@ -0,0 +1,599 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Local alerting: after each metrics collection the alert rules are checked
+// against the metrics and the status we have, whether or not the controller
+// is reachable. The rules come from the alert.* global config items, plus
+// the rules in AlertRulesFilename, a JSON list of AlertRule in an
+// AuthContainer signed by the controller, which override the rules with the
+// same name. An alert fires when the condition of a rule has held for a
+// subject for the time of the rule; it is then published as an AlertStatus,
+// reported in the device info, and sent to the alert.webhook.url if set. It
+// is unpublished, and sent as resolved, when the condition no longer holds.
+
+package zedagent
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/lf-edge/eve/pkg/pillar/zedcloud"
+)
+
+const (
+	alertWebhookTimeout  = 10 * time.Second
+	alertWebhookQueueLen = 64
+)
+
+// alertCheck is a subject for which the condition of a rule holds
+type alertCheck struct {
+	subject string
+	value   float64
+	message string
+}
+
+// alertEvent is posted to the webhook
+type alertEvent struct {
+	Status    string    `json:"status"` // firing or resolved
+	Device    string    `json:"device"`
+	Rule      string    `json:"rule"`
+	Condition string    `json:"condition"`
+	Subject   string    `json:"subject,omitempty"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Since     time.Time `json:"since"`
+	Time      time.Time `json:"time"`
+
+	url string
+}
+
+type alertEngine struct {
+	pending     map[string]types.AlertStatus // condition holds, not yet firing
+	firing      map[string]types.AlertStatus
+	fileRules   []types.AlertRule
+	fileModTime time.Time
+	certFile    string
+	certExpiry  time.Time
+	webhook     chan alertEvent
+}
+
+var alerts = alertEngine{
+	pending:  make(map[string]types.AlertStatus),
+	firing:   make(map[string]types.AlertStatus),
+	certFile: types.DeviceCertName,
+}
+
+// globalAlertRules are the rules set by the global config items
+func globalAlertRules(gc *types.ConfigItemValueMap) []types.AlertRule {
+	holdTime := gc.GlobalValueInt(types.AlertHoldTime)
+	var rules []types.AlertRule
+	addRule := func(name string, condition types.AlertCondition,
+		threshold uint32, severity types.AlertSeverity) {
+		rules = append(rules, types.AlertRule{
+			Name:      name,
+			Condition: condition,
+			Threshold: float64(threshold),
+			For:       holdTime,
+			Severity:  severity,
+		})
+	}
+	if t := gc.GlobalValueInt(types.AlertDiskUsagePercent); t != 0 {
+		addRule("disk-usage", types.AlertConditionDiskUsage, t,
+			types.AlertSeverityCritical)
+	}
+	if t := gc.GlobalValueInt(types.AlertMemoryUsagePercent); t != 0 {
+		addRule("memory-usage", types.AlertConditionMemoryUsage, t,
+			types.AlertSeverityWarning)
+	}
+	if gc.GlobalValueBool(types.AlertSmartPreFailure) {
+		addRule("smart-prefailure", types.AlertConditionSmartPreFailure, 0,
+			types.AlertSeverityCritical)
+	}
+	if gc.GlobalValueBool(types.AlertAppNotRunning) {
+		addRule("app-not-running", types.AlertConditionAppNotRunning, 0,
+			types.AlertSeverityWarning)
+	}
+	if gc.GlobalValueBool(types.AlertPortDown) {
+		addRule("port-down", types.AlertConditionPortDown, 0,
+			types.AlertSeverityWarning)
+	}
+	if t := gc.GlobalValueInt(types.AlertCertExpiryDays); t != 0 {
+		addRule("cert-expiry", types.AlertConditionCertExpiry, t,
+			types.AlertSeverityWarning)
+	}
+	if t := gc.GlobalValueInt(types.AlertTemperatureCelsius); t != 0 {
+		addRule("temperature", types.AlertConditionTemperature, t,
+			types.AlertSeverityCritical)
+	}
+	if gc.GlobalValueBool(types.AlertSensorCritical) {
+		addRule("sensor-critical", types.AlertConditionSensorCritical, 0,
+			types.AlertSeverityCritical)
+	}
+	if gc.GlobalValueBool(types.AlertDiskFailing) {
+		addRule("disk-failing", types.AlertConditionDiskFailing, 0,
+			types.AlertSeverityCritical)
+	}
+	return rules
+}
+
+// readAlertRulesFile picks up the rules of the signed file when it changes
+func (e *alertEngine) readAlertRulesFile(filename string,
+	verify func([]byte) ([]byte, error)) {
+
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		if e.fileRules != nil {
+			log.Noticef("readAlertRulesFile: %s removed", filename)
+		}
+		e.fileRules = nil
+		e.fileModTime = time.Time{}
+		return
+	}
+	if fileInfo.ModTime().Equal(e.fileModTime) {
+		return
+	}
+	e.fileModTime = fileInfo.ModTime()
+	e.fileRules = nil
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Errorf("readAlertRulesFile: %v", err)
+		return
+	}
+	payload, err := verify(data)
+	if err != nil {
+		log.Errorf("readAlertRulesFile: %s failed verification: %v",
+			filename, err)
+		return
+	}
+	var rules []types.AlertRule
+	if err := json.Unmarshal(payload, &rules); err != nil {
+		log.Errorf("readAlertRulesFile: %s: %v", filename, err)
+		return
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			log.Errorf("readAlertRulesFile: %s: %v", filename, err)
+			continue
+		}
+		e.fileRules = append(e.fileRules, rule)
+	}
+	log.Noticef("readAlertRulesFile: %d rules from %s",
+		len(e.fileRules), filename)
+}
+
+// mergeAlertRules overrides the global rules with the file rules of the
+// same name
+func mergeAlertRules(globalRules, fileRules []types.AlertRule) []types.AlertRule {
+	overridden := make(map[string]bool)
+	for _, rule := range fileRules {
+		overridden[rule.Name] = true
+	}
+	var rules []types.AlertRule
+	for _, rule := range globalRules {
+		if !overridden[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return append(rules, fileRules...)
+}
+
+func checkDiskUsage(report *metrics.ZMetricMsg, threshold float64) []alertCheck {
+	var checks []alertCheck
+	for _, disk := range report.GetDm().GetDisk() {
+		if disk.MountPath == "" || disk.Total == 0 {
+			continue
+		}
+		percent := float64(disk.Used) * 100 / float64(disk.Total)
+		if percent > threshold {
+			checks = append(checks, alertCheck{
+				subject: disk.MountPath,
+				value:   percent,
+				message: fmt.Sprintf("%s is %.0f%% full, over %.0f%%",
+					disk.MountPath, percent, threshold),
+			})
+		}
+	}
+	return checks
+}
+
+func checkMemoryUsage(report *metrics.ZMetricMsg, threshold float64) []alertCheck {
+	memory := report.GetDm().GetMemory()
+	if memory == nil || memory.UsedPercentage <= threshold {
+		return nil
+	}
+	return []alertCheck{{
+		value: memory.UsedPercentage,
+		message: fmt.Sprintf("memory is %.0f%% used, over %.0f%%",
+			memory.UsedPercentage, threshold),
+	}}
+}
+
+func checkSmartPreFailure(smart *types.SmartData) []alertCheck {
+	if smart == nil || smart.SmartCtl.ExitStatus != 0 {
+		return nil
+	}
+	var checks []alertCheck
+	for _, attr := range smart.SmartAttribute.Table {
+		failed := attr.WhenFailed != "" && attr.WhenFailed != "-"
+		if !attr.Flags.PreFailure ||
+			!(failed || (attr.Thresh != 0 && attr.Value <= attr.Thresh)) {
+			continue
+		}
+		checks = append(checks, alertCheck{
+			subject: fmt.Sprintf("%s-%d", smart.Device.Name, attr.ID),
+			value:   float64(attr.Value),
+			message: fmt.Sprintf("%s SMART attribute %d %s at %d, threshold %d",
+				smart.Device.Name, attr.ID, attr.Name, attr.Value, attr.Thresh),
+		})
+	}
+	return checks
+}
+
+func checkTemperature(report *metrics.ZMetricMsg, threshold float64) []alertCheck {
+	var checks []alertCheck
+	for _, sensor := range report.GetDm().GetSensors() {
+		if sensor.Type != metrics.SensorType_SENSOR_TYPE_TEMPERATURE ||
+			sensor.Value <= threshold {
+			continue
+		}
+		checks = append(checks, alertCheck{
+			subject: sensor.Source + "/" + sensor.Name,
+			value:   sensor.Value,
+			message: fmt.Sprintf("%s is %.0fC, over %.0fC",
+				sensor.Name, sensor.Value, threshold),
+		})
+	}
+	return checks
+}
+
+func checkSensorCritical(report *metrics.ZMetricMsg) []alertCheck {
+	var checks []alertCheck
+	for _, sensor := range report.GetDm().GetSensors() {
+		metric := types.SensorMetric{
+			Value:    sensor.Value,
+			Critical: sensor.Critical,
+			Alarm:    sensor.Alarm,
+		}
+		if !metric.OverCritical() {
+			continue
+		}
+		unit := types.SensorType(sensor.Type).Unit()
+		message := fmt.Sprintf("%s %s is %g%s", sensor.Name,
+			types.SensorType(sensor.Type).String(), sensor.Value, unit)
+		if sensor.Critical != 0 {
+			message += fmt.Sprintf(", critical %g%s", sensor.Critical, unit)
+		}
+		if sensor.Alarm {
+			message += ", alarm"
+		}
+		checks = append(checks, alertCheck{
+			subject: sensor.Source + "/" + sensor.Name,
+			value:   sensor.Value,
+			message: message,
+		})
+	}
+	return checks
+}
+
+func checkDiskFailing(report *metrics.ZMetricMsg) []alertCheck {
+	var checks []alertCheck
+	for _, health := range report.GetDm().GetDiskHealth() {
+		if health.State != metrics.DiskHealthState_DISK_HEALTH_STATE_FAILING {
+			continue
+		}
+		checks = append(checks, alertCheck{
+			subject: health.Disk,
+			value:   float64(health.State),
+			message: fmt.Sprintf("disk %s is failing: %s", health.Disk,
+				strings.Join(health.Reasons, ", ")),
+		})
+	}
+	return checks
+}
+
+func checkAppsNotRunning(statuses []types.AppInstanceStatus,
+	activate map[string]bool) []alertCheck {
+
+	var checks []alertCheck
+	for _, status := range statuses {
+		if !activate[status.Key()] || status.State == types.RUNNING ||
+			status.RestartInprogress != types.NotInprogress ||
+			status.PurgeInprogress != types.NotInprogress {
+			continue
+		}
+		message := fmt.Sprintf("app %s is %s", status.DisplayName,
+			status.State.String())
+		if status.HasError() {
+			message += ": " + status.Error
+		}
+		checks = append(checks, alertCheck{
+			subject: status.Key(),
+			value:   float64(status.State),
+			message: message,
+		})
+	}
+	return checks
+}
+
+func checkPortsDown(dns *types.DeviceNetworkStatus) []alertCheck {
+	var checks []alertCheck
+	for _, port := range dns.Ports {
+		if !port.IsMgmt {
+			continue
+		}
+		var message string
+		if !port.Up {
+			message = fmt.Sprintf("port %s has no link", port.IfName)
+		} else if types.CountLocalAddrAnyNoLinkLocalIf(*dns, port.IfName) == 0 {
+			message = fmt.Sprintf("port %s has no usable address", port.IfName)
+		} else {
+			continue
+		}
+		checks = append(checks, alertCheck{
+			subject: port.IfName,
+			message: message,
+		})
+	}
+	return checks
+}
+
+func checkCertExpiry(notAfter time.Time, now time.Time,
+	threshold float64) []alertCheck {
+
+	if notAfter.IsZero() {
+		return nil
+	}
+	days := notAfter.Sub(now).Hours() / 24
+	if days >= threshold {
+		return nil
+	}
+	message := fmt.Sprintf("device certificate expires in %.0f days",
+		days)
+	if days < 0 {
+		message = fmt.Sprintf("device certificate expired %.0f days ago",
+			-days)
+	}
+	return []alertCheck{{value: days, message: message}}
+}
+
+// deviceCertExpiry returns the NotAfter of the device certificate
+func (e *alertEngine) deviceCertExpiry() time.Time {
+	if !e.certExpiry.IsZero() {
+		return e.certExpiry
+	}
+	certBytes, err := ioutil.ReadFile(e.certFile)
+	if err != nil {
+		return time.Time{}
+	}
+	block, _ := pem.Decode(certBytes)
+	if block == nil {
+		log.Errorf("deviceCertExpiry: no PEM in %s", e.certFile)
+		return time.Time{}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		log.Errorf("deviceCertExpiry: %v", err)
+		return time.Time{}
+	}
+	e.certExpiry = cert.NotAfter
+	return e.certExpiry
+}
+
+// update moves the subjects of the rule checks through pending and
+// firing, and returns the alerts which start firing or change, and those
+// which resolve
+func (e *alertEngine) update(rules []types.AlertRule,
+	checks map[string][]alertCheck, now time.Time) (
+	changed []types.AlertStatus, resolved []types.AlertStatus) {
+
+	holding := make(map[string]bool)
+	for _, rule := range rules {
+		for _, check := range checks[rule.Name] {
+			status := types.AlertStatus{
+				Rule:      rule.Name,
+				Condition: rule.Condition,
+				Subject:   check.subject,
+				Severity:  rule.Severity,
+				Message:   check.message,
+				Value:     check.value,
+				Threshold: rule.Threshold,
+				Since:     now,
+			}
+			key := status.Key()
+			holding[key] = true
+			if old, ok := e.firing[key]; ok {
+				status.Since = old.Since
+				e.firing[key] = status
+				if old.Message != status.Message ||
+					old.Severity != status.Severity {
+					changed = append(changed, status)
+				}
+				continue
+			}
+			if old, ok := e.pending[key]; ok {
+				status.Since = old.Since
+			}
+			if now.Sub(status.Since) >= time.Duration(rule.For)*time.Second {
+				delete(e.pending, key)
+				e.firing[key] = status
+				changed = append(changed, status)
+			} else {
+				e.pending[key] = status
+			}
+		}
+	}
+	for key := range e.pending {
+		if !holding[key] {
+			delete(e.pending, key)
+		}
+	}
+	for key, status := range e.firing {
+		if !holding[key] {
+			delete(e.firing, key)
+			resolved = append(resolved, status)
+		}
+	}
+	return changed, resolved
+}
+
+// evaluateAlerts checks the alert rules after a metrics collection
+func evaluateAlerts(ctx *zedagentContext, report *metrics.ZMetricMsg) {
+	now := time.Now()
+	alerts.readAlertRulesFile(types.AlertRulesFilename,
+		func(data []byte) ([]byte, error) {
+			return zedcloud.VerifyAuthContainer(zedcloudCtx, data)
+		})
+	rules := mergeAlertRules(globalAlertRules(&ctx.globalConfig),
+		alerts.fileRules)
+
+	checks := make(map[string][]alertCheck)
+	for _, rule := range rules {
+		switch rule.Condition {
+		case types.AlertConditionDiskUsage:
+			checks[rule.Name] = checkDiskUsage(report, rule.Threshold)
+		case types.AlertConditionMemoryUsage:
+			checks[rule.Name] = checkMemoryUsage(report, rule.Threshold)
+		case types.AlertConditionSmartPreFailure:
+			checks[rule.Name] = checkSmartPreFailure(smartData)
+		case types.AlertConditionAppNotRunning:
+			var statuses []types.AppInstanceStatus
+			activate := make(map[string]bool)
+			for _, st := range ctx.getconfigCtx.subAppInstanceStatus.GetAll() {
+				statuses = append(statuses, st.(types.AppInstanceStatus))
+			}
+			for _, c := range ctx.getconfigCtx.pubAppInstanceConfig.GetAll() {
+				config := c.(types.AppInstanceConfig)
+				activate[config.Key()] = config.Activate
+			}
+			checks[rule.Name] = checkAppsNotRunning(statuses, activate)
+		case types.AlertConditionPortDown:
+			checks[rule.Name] = checkPortsDown(deviceNetworkStatus)
+		case types.AlertConditionCertExpiry:
+			checks[rule.Name] = checkCertExpiry(alerts.deviceCertExpiry(),
+				now, rule.Threshold)
+		case types.AlertConditionTemperature:
+			checks[rule.Name] = checkTemperature(report, rule.Threshold)
+		case types.AlertConditionSensorCritical:
+			checks[rule.Name] = checkSensorCritical(report)
+		case types.AlertConditionDiskFailing:
+			checks[rule.Name] = checkDiskFailing(report)
+		}
+	}
+
+	changed, resolved := alerts.update(rules, checks, now)
+	webhookURL := ctx.globalConfig.GlobalValueString(types.AlertWebhookURL)
+	transition := false
+	for _, status := range changed {
+		if _, err := ctx.pubAlertStatus.Get(status.Key()); err != nil {
+			log.Noticef("evaluateAlerts: %s firing: %s",
+				status.Key(), status.Message)
+			transition = true
+			sendAlertEvent(webhookURL, "firing", status, now)
+		}
+		ctx.pubAlertStatus.Publish(status.Key(), status)
+	}
+	for _, status := range resolved {
+		log.Noticef("evaluateAlerts: %s resolved", status.Key())
+		transition = true
+		sendAlertEvent(webhookURL, "resolved", status, now)
+		ctx.pubAlertStatus.Unpublish(status.Key())
+	}
+	if transition {
+		triggerPublishDevInfo(ctx)
+	}
+}
+
+// getAlertsInfo returns the firing alerts for the device info
+func getAlertsInfo(ctx *zedagentContext) []*info.ZInfoAlert {
+	var alertsInfo []*info.ZInfoAlert
+	for _, a := range ctx.pubAlertStatus.GetAll() {
+		status := a.(types.AlertStatus)
+		since, _ := ptypes.TimestampProto(status.Since)
+		alertsInfo = append(alertsInfo, &info.ZInfoAlert{
+			Rule:      status.Rule,
+			Condition: string(status.Condition),
+			Subject:   status.Subject,
+			Severity:  info.AlertSeverity(status.Severity),
+			Message:   status.Message,
+			Value:     status.Value,
+			Threshold: status.Threshold,
+			Since:     since,
+		})
+	}
+	sort.Slice(alertsInfo, func(i, j int) bool {
+		return alertsInfo[i].Since.AsTime().Before(alertsInfo[j].Since.AsTime())
+	})
+	return alertsInfo
+}
+
+// sendAlertEvent queues the event for the webhook, if any
+func sendAlertEvent(url string, state string, status types.AlertStatus,
+	now time.Time) {
+
+	if url == "" {
+		return
+	}
+	if alerts.webhook == nil {
+		alerts.webhook = make(chan alertEvent, alertWebhookQueueLen)
+		go alertWebhookTask(alerts.webhook)
+	}
+	event := alertEvent{
+		Status:    state,
+		Device:    devUUID.String(),
+		Rule:      status.Rule,
+		Condition: string(status.Condition),
+		Subject:   status.Subject,
+		Severity:  status.Severity.String(),
+		Message:   status.Message,
+		Value:     status.Value,
+		Threshold: status.Threshold,
+		Since:     status.Since,
+		Time:      now,
+		url:       url,
+	}
+	select {
+	case alerts.webhook <- event:
+	default:
+		log.Warnf("sendAlertEvent: queue full, dropping %s %s",
+			state, status.Key())
+	}
+}
+
+// alertWebhookTask posts the alert events so a slow webhook does not
+// hold up the metrics
+func alertWebhookTask(events <-chan alertEvent) {
+	client := &http.Client{Timeout: alertWebhookTimeout}
+	for event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Errorf("alertWebhookTask: %v", err)
+			continue
+		}
+		resp, err := client.Post(event.url, "application/json",
+			bytes.NewBuffer(data))
+		if err != nil {
+			log.Errorf("alertWebhookTask: %v", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			log.Errorf("alertWebhookTask: %s returned %s",
+				event.url, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedagent
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAlertChecks(t *testing.T) {
+	report := &metrics.ZMetricMsg{
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedPercentage: 50},
+			Disk: []*metrics.DiskMetric{
+				{MountPath: "/persist", Total: 100, Used: 95},
+				{MountPath: "/persist/clear", Total: 100, Used: 10},
+				{Disk: "sda", Total: 100, Used: 100},
+			},
+			Sensors: []*metrics.SensorMetric{
+				{Name: "coretemp.0/Core 0", Source: "hwmon", Value: 82,
+					Type: metrics.SensorType_SENSOR_TYPE_TEMPERATURE, Critical: 100},
+				{Name: "acpitz", Source: "thermal", Value: 105,
+					Type: metrics.SensorType_SENSOR_TYPE_TEMPERATURE, Critical: 105},
+				{Name: "FAN1", Source: "ipmi", Value: 300,
+					Type: metrics.SensorType_SENSOR_TYPE_FAN, Alarm: true},
+			},
+			DiskHealth: []*metrics.DiskHealthMetric{
+				{Disk: "sda", State: metrics.DiskHealthState_DISK_HEALTH_STATE_WARNING,
+					Reasons: []string{"8 sectors pending reallocation"}},
+				{Disk: "nvme0n1", State: metrics.DiskHealthState_DISK_HEALTH_STATE_FAILING,
+					Reasons: []string{"media is read-only", "100% of the endurance used"}},
+			},
+		}},
+	}
+	checks := checkDiskUsage(report, 90)
+	if len(checks) != 1 || checks[0].subject != "/persist" ||
+		checks[0].message != "/persist is 95% full, over 90%" {
+		t.Errorf("checkDiskUsage got %+v", checks)
+	}
+	if checks := checkMemoryUsage(report, 90); len(checks) != 0 {
+		t.Errorf("checkMemoryUsage got %+v", checks)
+	}
+
+	checks = checkTemperature(report, 90)
+	if len(checks) != 1 || checks[0].subject != "thermal/acpitz" ||
+		checks[0].message != "acpitz is 105C, over 90C" {
+		t.Errorf("checkTemperature got %+v", checks)
+	}
+	checks = checkSensorCritical(report)
+	if len(checks) != 2 ||
+		checks[0].message != "acpitz temperature is 105C, critical 105C" ||
+		checks[1].message != "FAN1 fan is 300RPM, alarm" {
+		t.Errorf("checkSensorCritical got %+v", checks)
+	}
+
+	checks = checkDiskFailing(report)
+	if len(checks) != 1 || checks[0].message !=
+		"disk nvme0n1 is failing: media is read-only, 100% of the endurance used" {
+		t.Errorf("checkDiskFailing got %+v", checks)
+	}
+
+	smart := &types.SmartData{
+		Device: types.Device{Name: "/dev/sda"},
+		SmartAttribute: types.SmartAttribute{Table: []types.Table{
+			{ID: 5, Name: "Reallocated_Sector_Ct", Value: 10, Thresh: 10,
+				Flags: types.Flags{PreFailure: true}},
+			{ID: 9, Name: "Power_On_Hours", Value: 1, Thresh: 0},
+			{ID: 10, Name: "Spin_Retry_Count", Value: 100, Thresh: 97,
+				Flags: types.Flags{PreFailure: true}},
+		}},
+	}
+	checks = checkSmartPreFailure(smart)
+	if len(checks) != 1 || checks[0].subject != "/dev/sda-5" {
+		t.Errorf("checkSmartPreFailure got %+v", checks)
+	}
+
+	statuses := []types.AppInstanceStatus{
+		{UUIDandVersion: types.UUIDandVersion{UUID: nilUUID}, DisplayName: "app",
+			State: types.HALTED},
+	}
+	if checks := checkAppsNotRunning(statuses, map[string]bool{}); len(checks) != 0 {
+		t.Errorf("checkAppsNotRunning got %+v for a deactivated app", checks)
+	}
+	checks = checkAppsNotRunning(statuses,
+		map[string]bool{statuses[0].Key(): true})
+	if len(checks) != 1 || checks[0].message != "app app is HALTED" {
+		t.Errorf("checkAppsNotRunning got %+v", checks)
+	}
+
+	dns := &types.DeviceNetworkStatus{Ports: []types.NetworkPortStatus{
+		{IfName: "eth0", IsMgmt: true, Up: false},
+		{IfName: "eth1", IsMgmt: false, Up: false},
+	}}
+	checks = checkPortsDown(dns)
+	if len(checks) != 1 || checks[0].message != "port eth0 has no link" {
+		t.Errorf("checkPortsDown got %+v", checks)
+	}
+
+	now := time.Unix(1600000000, 0)
+	if checks := checkCertExpiry(now.Add(40*24*time.Hour), now, 30); len(checks) != 0 {
+		t.Errorf("checkCertExpiry got %+v", checks)
+	}
+	checks = checkCertExpiry(now.Add(-2*24*time.Hour), now, 30)
+	if len(checks) != 1 || checks[0].message != "device certificate expired 2 days ago" {
+		t.Errorf("checkCertExpiry got %+v", checks)
+	}
+}
+
+func TestAlertUpdate(t *testing.T) {
+	e := alertEngine{
+		pending: make(map[string]types.AlertStatus),
+		firing:  make(map[string]types.AlertStatus),
+	}
+	rules := []types.AlertRule{
+		{Name: "disk", Condition: types.AlertConditionDiskUsage,
+			Threshold: 90, For: 60, Severity: types.AlertSeverityCritical},
+	}
+	start := time.Unix(1600000000, 0)
+	holds := map[string][]alertCheck{
+		"disk": {{subject: "/persist", value: 95, message: "full"}},
+	}
+	changed, resolved := e.update(rules, holds, start)
+	if len(changed) != 0 || len(resolved) != 0 || len(e.pending) != 1 {
+		t.Fatalf("pending: changed %v resolved %v", changed, resolved)
+	}
+	changed, _ = e.update(rules, holds, start.Add(60*time.Second))
+	if len(changed) != 1 || changed[0].Key() != "disk-_persist" ||
+		!changed[0].Since.Equal(start) || len(e.pending) != 0 {
+		t.Fatalf("firing: changed %v", changed)
+	}
+	// same message, no change
+	changed, _ = e.update(rules, holds, start.Add(120*time.Second))
+	if len(changed) != 0 {
+		t.Errorf("unchanged: changed %v", changed)
+	}
+	changed, resolved = e.update(rules, nil, start.Add(180*time.Second))
+	if len(changed) != 0 || len(resolved) != 1 || len(e.firing) != 0 {
+		t.Errorf("resolved: changed %v resolved %v", changed, resolved)
+	}
+	// a condition which stops holding before the time of the rule
+	e.update(rules, holds, start.Add(240*time.Second))
+	e.update(rules, nil, start.Add(270*time.Second))
+	changed, _ = e.update(rules, holds, start.Add(300*time.Second))
+	if len(changed) != 0 || len(e.pending) != 1 {
+		t.Errorf("restarted pending: changed %v", changed)
+	}
+}
+
+func TestAlertRulesFile(t *testing.T) {
+	logger = logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, "zedagent", 0)
+	dir, err := ioutil.TempDir("", "alertrules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "alert-rules.auth")
+	content := `[{"name":"disk-usage","condition":"disk_usage","threshold":80,"for":0,"severity":"warning"},
+{"name":"bad","condition":"unknown","severity":"warning"}]`
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := alertEngine{}
+	e.readAlertRulesFile(filename, func(data []byte) ([]byte, error) {
+		return nil, errors.New("bad signature")
+	})
+	if len(e.fileRules) != 0 {
+		t.Errorf("rules of an unverified file: %v", e.fileRules)
+	}
+	e = alertEngine{}
+	e.readAlertRulesFile(filename, func(data []byte) ([]byte, error) {
+		return data, nil
+	})
+	if len(e.fileRules) != 1 || e.fileRules[0].Severity != types.AlertSeverityWarning {
+		t.Fatalf("got rules %v", e.fileRules)
+	}
+
+	gc := types.DefaultConfigItemValueMap()
+	rules := mergeAlertRules(globalAlertRules(gc), e.fileRules)
+	if len(rules) != 8 {
+		t.Errorf("got %d rules, expected 8", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.Name == "disk-usage" && rule.Threshold != 80 {
+			t.Errorf("disk-usage rule not overridden: %v", rule)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Traces of the deployment of the app instances. Each deployment of an app
+// instance gets a types.TraceContext which goes with the AppInstanceConfig
+// and the VolumeConfig and ContentTreeConfig it needs, so the agents
+// involved report their stages as spans of the same trace.
+
+package zedagent
+
+import (
+	zconfig "github.com/lf-edge/eve/api/go/config"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// assignTraces picks the traces of the app instances, volumes and content
+// trees of the config before they are parsed. An app instance gets a new
+// trace when it is new or its version, restart or purge counter changes
+// and keeps its trace otherwise. A volume gets the trace of the first app
+// instance with a new trace which refers to it, and keeps its trace
+// otherwise. Likewise for a content tree and the volumes created from it.
+func assignTraces(getconfigCtx *getconfigContext, config *zconfig.EdgeDevConfig) {
+	appTraces := make(map[string]types.TraceContext)
+	volumeTraces := make(map[string]types.TraceContext)
+	contentTraces := make(map[string]types.TraceContext)
+	newTraces := make(map[types.TraceContext]bool)
+
+	for _, cfgApp := range config.GetApps() {
+		key := cfgApp.GetUuidandversion().GetUuid()
+		trace := types.TraceContext{}
+		c, _ := getconfigCtx.pubAppInstanceConfig.Get(key)
+		if c != nil {
+			old := c.(types.AppInstanceConfig)
+			if old.UUIDandVersion.Version == cfgApp.GetUuidandversion().GetVersion() &&
+				old.RestartCmd.Counter == cfgApp.GetRestart().GetCounter() &&
+				old.PurgeCmd.Counter == cfgApp.GetPurge().GetCounter() {
+				trace = old.Trace
+			}
+		}
+		if !trace.IsValid() {
+			trace = types.NewTraceContext()
+			newTraces[trace] = true
+			log.Functionf("assignTraces: app instance %s (%s) trace %s",
+				key, cfgApp.GetDisplayname(), trace.TraceID)
+		}
+		appTraces[key] = trace
+	}
+
+	for _, cfgVolume := range config.GetVolumes() {
+		key := volumeKey(cfgVolume.GetUuid(), cfgVolume.GetGenerationCount())
+		var trace types.TraceContext
+		c, _ := getconfigCtx.pubVolumeConfig.Get(key)
+		if c != nil {
+			trace = c.(types.VolumeConfig).Trace
+		}
+		for _, cfgApp := range config.GetApps() {
+			appTrace := appTraces[cfgApp.GetUuidandversion().GetUuid()]
+			if !newTraces[appTrace] && trace.IsValid() {
+				continue
+			}
+			for _, volumeRef := range cfgApp.GetVolumeRefList() {
+				if volumeRef.GetUuid() == cfgVolume.GetUuid() &&
+					volumeRef.GetGenerationCount() == cfgVolume.GetGenerationCount() {
+					trace = appTrace
+					break
+				}
+			}
+			if newTraces[trace] {
+				break
+			}
+		}
+		volumeTraces[key] = trace
+	}
+
+	for _, cfgContentTree := range config.GetContentInfo() {
+		key := cfgContentTree.GetUuid()
+		var trace types.TraceContext
+		c, _ := getconfigCtx.pubContentTreeConfig.Get(key)
+		if c != nil {
+			trace = c.(types.ContentTreeConfig).Trace
+		}
+		for _, cfgVolume := range config.GetVolumes() {
+			if cfgVolume.GetOrigin().GetDownloadContentTreeID() != key {
+				continue
+			}
+			volumeTrace := volumeTraces[volumeKey(cfgVolume.GetUuid(),
+				cfgVolume.GetGenerationCount())]
+			if newTraces[volumeTrace] || !trace.IsValid() {
+				trace = volumeTrace
+			}
+			if newTraces[trace] {
+				break
+			}
+		}
+		contentTraces[key] = trace
+	}
+
+	getconfigCtx.appTraces = appTraces
+	getconfigCtx.volumeTraces = volumeTraces
+	getconfigCtx.contentTraces = contentTraces
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Diagnostic bundles requested by the controller with the collect_info
+// command. When its counter changes zedagent collects a bundle into
+// types.DiagBundleDir, and publishes a DiagBundleConfig for downloader to
+// upload it to the datastore of the command. The state of the bundle is
+// reported in the device info, from zedagent while the bundle is collected
+// and from the DiagBundleStatus of downloader once it is uploaded.
+
+package zedagent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	zconfig "github.com/lf-edge/eve/api/go/config"
+	"github.com/lf-edge/eve/api/go/info"
+	"github.com/lf-edge/eve/pkg/pillar/diagbundle"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	fileutils "github.com/lf-edge/eve/pkg/pillar/utils/file"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	collectInfoConfigFilename = types.PersistStatusDir + "/collectInfoConfig"
+	diagBundlePrefix          = "diag-"
+)
+
+// diagBundleState is the bundle of the last collect_info command until it
+// is handed to downloader
+type diagBundleState struct {
+	sync.Mutex
+	// The bundles are collected one at a time
+	collectLock sync.Mutex
+	status      types.DiagBundleStatus
+}
+
+var collectInfoPrevConfigHash []byte
+
+// scheduleCollectInfo starts collecting a bundle when the counter of the
+// command differs from the one of the last bundle. The counter is kept
+// across reboots so a command is run once.
+func scheduleCollectInfo(collectInfo *zconfig.DeviceOpsCmd, datastore string,
+	getconfigCtx *getconfigContext) {
+
+	if collectInfo == nil {
+		return
+	}
+	configHash := computeConfigSha(collectInfo)
+	same := bytes.Equal(configHash, collectInfoPrevConfigHash)
+	collectInfoPrevConfigHash = configHash
+	if same {
+		return
+	}
+	log.Functionf("scheduleCollectInfo: Applying updated config %v", collectInfo)
+	// The zero counter is no request
+	if collectInfo.Counter == readCollectInfoCounter() {
+		return
+	}
+	saveCollectInfoCounter(types.DeviceOpsCmd{
+		Counter:      collectInfo.Counter,
+		DesiredState: collectInfo.DesiredState,
+		OpsTime:      collectInfo.OpsTime,
+	})
+
+	ctx := getconfigCtx.zedagentCtx
+	datastoreID, err := uuid.FromString(datastore)
+	if err != nil {
+		errStr := fmt.Sprintf("collect_info datastore %q: %v", datastore, err)
+		log.Errorf("scheduleCollectInfo: %s", errStr)
+		status := types.DiagBundleStatus{
+			Counter: collectInfo.Counter,
+			State:   types.DiagBundleStateFailed,
+		}
+		status.SetErrorNow(errStr)
+		setDiagBundleStatus(ctx, status)
+		return
+	}
+	go collectDiagBundle(ctx, collectInfo.Counter, datastoreID)
+}
+
+// readCollectInfoCounter returns the counter of the last bundle, or zero
+func readCollectInfoCounter() uint32 {
+	data, err := ioutil.ReadFile(collectInfoConfigFilename)
+	if err != nil {
+		log.Functionf("readCollectInfoCounter - %s doesn't exist",
+			collectInfoConfigFilename)
+		return 0
+	}
+	collectInfo := types.DeviceOpsCmd{}
+	if err := json.Unmarshal(data, &collectInfo); err != nil {
+		// Treat the same way as a missing file
+		log.Error(err)
+		return 0
+	}
+	return collectInfo.Counter
+}
+
+func saveCollectInfoCounter(collectInfo types.DeviceOpsCmd) {
+	log.Functionf("saveCollectInfoCounter - Counter: %d", collectInfo.Counter)
+	data, err := json.Marshal(collectInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := fileutils.WriteRename(collectInfoConfigFilename, data); err != nil {
+		// Can fail if low on disk space
+		log.Error(err)
+	}
+}
+
+// collectDiagBundle runs in its own goroutine to collect the bundle and
+// hand it to downloader. The bundles of earlier commands are removed.
+func collectDiagBundle(ctx *zedagentContext, counter uint32, datastoreID uuid.UUID) {
+	ctx.diagBundle.collectLock.Lock()
+	defer ctx.diagBundle.collectLock.Unlock()
+
+	setDiagBundleStatus(ctx, types.DiagBundleStatus{
+		Counter: counter,
+		State:   types.DiagBundleStateCollecting,
+	})
+	for _, c := range ctx.pubDiagBundleConfig.GetAll() {
+		config := c.(types.DiagBundleConfig)
+		ctx.pubDiagBundleConfig.Unpublish(config.Key())
+	}
+	removeDiagBundles()
+
+	name := fmt.Sprintf("%s%d-%s.tar.gz", diagBundlePrefix, counter,
+		time.Now().UTC().Format("20060102T150405Z"))
+	filename := filepath.Join(types.DiagBundleDir, name)
+	log.Noticef("collectDiagBundle: collecting %s", filename)
+	size, err := diagbundle.CollectToFile(log, filename)
+	if err != nil {
+		log.Errorf("collectDiagBundle: %v", err)
+		status := types.DiagBundleStatus{
+			Counter: counter,
+			State:   types.DiagBundleStateFailed,
+		}
+		status.SetErrorNow(err.Error())
+		setDiagBundleStatus(ctx, status)
+		return
+	}
+	log.Noticef("collectDiagBundle: collected %s, %d bytes", filename, size)
+	config := types.DiagBundleConfig{
+		Counter:     counter,
+		DatastoreID: datastoreID,
+		Filename:    filename,
+		Name:        devUUID.String() + "/" + name,
+	}
+	setDiagBundleStatus(ctx, types.DiagBundleStatus{
+		Counter: counter,
+		Name:    config.Name,
+		State:   types.DiagBundleStateUploading,
+		Size:    size,
+	})
+	ctx.pubDiagBundleConfig.Publish(config.Key(), config)
+}
+
+// removeDiagBundles removes the bundles collected for the controller, but
+// not those collected locally
+func removeDiagBundles() {
+	infos, err := ioutil.ReadDir(types.DiagBundleDir)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, diagBundlePrefix) ||
+			strings.HasPrefix(name, diagBundlePrefix+"local") {
+			continue
+		}
+		filename := filepath.Join(types.DiagBundleDir, name)
+		if err := os.Remove(filename); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func setDiagBundleStatus(ctx *zedagentContext, status types.DiagBundleStatus) {
+	status.Time = time.Now()
+	ctx.diagBundle.Lock()
+	ctx.diagBundle.status = status
+	ctx.diagBundle.Unlock()
+	triggerPublishDevInfo(ctx)
+}
+
+func handleDiagBundleStatusCreate(ctxArg interface{}, key string,
+	statusArg interface{}) {
+	handleDiagBundleStatusImpl(ctxArg, key, statusArg)
+}
+
+func handleDiagBundleStatusModify(ctxArg interface{}, key string,
+	statusArg interface{}, oldStatusArg interface{}) {
+	handleDiagBundleStatusImpl(ctxArg, key, statusArg)
+}
+
+func handleDiagBundleStatusImpl(ctxArg interface{}, key string,
+	statusArg interface{}) {
+
+	ctx := ctxArg.(*zedagentContext)
+	status := statusArg.(types.DiagBundleStatus)
+	log.Functionf("handleDiagBundleStatusImpl: %s %s", key, status.State)
+	triggerPublishDevInfo(ctx)
+}
+
+func handleDiagBundleStatusDelete(ctxArg interface{}, key string,
+	statusArg interface{}) {
+
+	ctx := ctxArg.(*zedagentContext)
+	log.Functionf("handleDiagBundleStatusDelete: %s", key)
+	triggerPublishDevInfo(ctx)
+}
+
+// getDiagBundleInfo returns the state of the last bundle for the device
+// info, from downloader once it has the bundle
+func getDiagBundleInfo(ctx *zedagentContext) *info.ZInfoDiagBundle {
+	ctx.diagBundle.Lock()
+	status := ctx.diagBundle.status
+	ctx.diagBundle.Unlock()
+	if status.Counter == 0 {
+		return nil
+	}
+	if status.State == types.DiagBundleStateUploading {
+		s, _ := ctx.subDiagBundleStatus.Get(status.Key())
+		if s != nil {
+			status = s.(types.DiagBundleStatus)
+		}
+	}
+	diagInfo := &info.ZInfoDiagBundle{
+		Counter: status.Counter,
+		State:   info.DiagBundleState(status.State),
+		Name:    status.Name,
+		Size:    uint64(status.Size),
+		Error:   status.Error,
+	}
+	diagInfo.Time, _ = ptypes.TimestampProto(status.Time)
+	return diagInfo
+}
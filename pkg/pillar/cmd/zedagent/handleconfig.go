@@ -60,6 +60,12 @@ type getconfigContext struct {
 	subVolumeStatus          pubsub.Subscription
 	pubVolumeConfig          pubsub.Publication
 	rebootFlag               bool
+
+	// Traces of the app instances, volumes and content trees of the
+	// config, see assignTraces
+	appTraces     map[string]types.TraceContext
+	volumeTraces  map[string]types.TraceContext
+	contentTraces map[string]types.TraceContext
 }
 
 // devUUID is set in Run and never changed
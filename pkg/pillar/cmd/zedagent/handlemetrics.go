@@ -13,6 +13,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -83,6 +84,30 @@ func getAllDiskMetrics(ctx *zedagentContext) []*types.DiskMetric {
 	return retList
 }
 
+// getAllSensorMetrics returns the hardware sensors sorted by their key
+func getAllSensorMetrics(ctx *zedagentContext) []types.SensorMetric {
+	var retList []types.SensorMetric
+	for _, m := range ctx.subSensorMetric.GetAll() {
+		retList = append(retList, m.(types.SensorMetric))
+	}
+	sort.Slice(retList, func(i, j int) bool {
+		return retList[i].Key() < retList[j].Key()
+	})
+	return retList
+}
+
+// getAllDiskHealthStatus returns the health of the disks sorted by name
+func getAllDiskHealthStatus(ctx *zedagentContext) []types.DiskHealthStatus {
+	var retList []types.DiskHealthStatus
+	for _, s := range ctx.subDiskHealthStatus.GetAll() {
+		retList = append(retList, s.(types.DiskHealthStatus))
+	}
+	sort.Slice(retList, func(i, j int) bool {
+		return retList[i].DiskName < retList[j].DiskName
+	})
+	return retList
+}
+
 func lookupAppDiskMetric(ctx *zedagentContext, diskPath string) *types.AppDiskMetric {
 	diskPath = types.PathToKey(diskPath)
 	sub := ctx.subAppDiskMetric
@@ -111,6 +136,45 @@ func encodeErrorInfo(et types.ErrorAndTime) *info.ErrorInfo {
 	return errInfo
 }
 
+func encodeAppHealth(health types.AppHealthStatus) *info.ZAppHealth {
+	reportHealth := new(info.ZAppHealth)
+	reportHealth.State = info.ZAppHealthState(health.State)
+	reportHealth.LastError = health.LastError
+	reportHealth.RestartCount = health.RestartCount
+	if !health.LastChange.IsZero() {
+		reportHealth.LastChange, _ = ptypes.TimestampProto(health.LastChange)
+	}
+	if !health.LastRestart.IsZero() {
+		reportHealth.LastRestart, _ = ptypes.TimestampProto(health.LastRestart)
+	}
+	if !health.NextRestart.IsZero() {
+		reportHealth.NextRestart, _ = ptypes.TimestampProto(health.NextRestart)
+	}
+	return reportHealth
+}
+
+// encodeGuestInfo returns nil if the guest agent did not report anything
+func encodeGuestInfo(guest types.GuestInfo) *info.ZInfoGuest {
+	if guest.OSName == "" && guest.Hostname == "" &&
+		len(guest.Interfaces) == 0 {
+		return nil
+	}
+	reportGuest := new(info.ZInfoGuest)
+	reportGuest.OsName = guest.OSName
+	reportGuest.OsVersion = guest.OSVersion
+	reportGuest.KernelRelease = guest.KernelRelease
+	reportGuest.Hostname = guest.Hostname
+	for _, gi := range guest.Interfaces {
+		reportGuest.Interfaces = append(reportGuest.Interfaces,
+			&info.ZInfoGuestInterface{
+				Name:    gi.Name,
+				MacAddr: gi.MacAddr,
+				IpAddrs: gi.IPAddrs,
+			})
+	}
+	return reportGuest
+}
+
 // We reuse the info.ErrorInfo to pass both failure and success. If success
 // the Description is left empty
 func encodeTestResults(tr types.TestResults) *info.ErrorInfo {
@@ -485,6 +549,46 @@ func publishMetrics(ctx *zedagentContext, iteration int) {
 	}
 	log.Tracef("DirPaths in persist, elapse sec %v", time.Since(startPubTime).Seconds())
 
+	for _, status := range getAllDiskHealthStatus(ctx) {
+		metric := metrics.DiskHealthMetric{
+			Disk:            status.DiskName,
+			Model:           status.Model,
+			Serial:          status.Serial,
+			Protocol:        status.Protocol,
+			State:           metrics.DiskHealthState(status.State),
+			Reasons:         status.Reasons,
+			Persist:         status.Persist,
+			Temperature:     status.Temperature,
+			PowerOnHours:    status.PowerOnHours,
+			PercentageUsed:  uint32(status.PercentageUsed),
+			AvailableSpare:  uint32(status.AvailableSpare),
+			CriticalWarning: uint32(status.CriticalWarning),
+		}
+		for _, counter := range status.Counters {
+			metric.Counters = append(metric.Counters, &metrics.DiskHealthCounter{
+				Name:      counter.Name,
+				Value:     counter.Value,
+				DeltaDay:  counter.DeltaDay,
+				DeltaWeek: counter.DeltaWeek,
+			})
+		}
+		ReportDeviceMetric.DiskHealth = append(ReportDeviceMetric.DiskHealth, &metric)
+	}
+
+	for _, sensor := range getAllSensorMetrics(ctx) {
+		ReportDeviceMetric.Sensors = append(ReportDeviceMetric.Sensors,
+			&metrics.SensorMetric{
+				Name:     sensor.Name,
+				Source:   sensor.Source,
+				Type:     metrics.SensorType(sensor.Type),
+				Value:    sensor.Value,
+				Min:      sensor.Min,
+				Max:      sensor.Max,
+				Critical: sensor.Critical,
+				Alarm:    sensor.Alarm,
+			})
+	}
+
 	// Determine how much we use in /persist and how much of it is
 	// for the benefits of applications
 	var persistAppUsage uint64
@@ -540,6 +644,9 @@ func publishMetrics(ctx *zedagentContext, iteration int) {
 		x.Dm = ReportDeviceMetric
 	}
 
+	// Network instance of each app interface for the Prometheus labels
+	vifNetworkInstance := make(map[string]string)
+
 	// Loop over AppInstanceStatus so we report before the instance has booted
 	sub = ctx.getconfigCtx.subAppInstanceStatus
 	items := sub.GetAll()
@@ -569,6 +676,15 @@ func publishMetrics(ctx *zedagentContext, iteration int) {
 			ReportAppMetric.Memory.UsedPercentage = dm.UsedMemoryPercent
 			availableMemoryPercent := 100.0 - dm.UsedMemoryPercent
 			ReportAppMetric.Memory.AvailPercentage = availableMemoryPercent
+			for _, fs := range dm.GuestFilesystems {
+				ReportAppMetric.GuestFs = append(ReportAppMetric.GuestFs,
+					&metrics.AppGuestFsMetric{
+						MountPath: fs.Mountpoint,
+						FsType:    fs.Type,
+						Total:     utils.RoundToMbytes(fs.TotalBytes),
+						Used:      utils.RoundToMbytes(fs.UsedBytes),
+					})
+			}
 		}
 
 		appInterfaceList := aiStatus.GetAppInterfaceList()
@@ -593,6 +709,9 @@ func publishMetrics(ctx *zedagentContext, iteration int) {
 				metric.IfName, name)
 			networkDetails.IName = name
 			networkDetails.LocalName = metric.IfName
+			if niStatus := appIfnameToNetworkInstance(ctx, &aiStatus, ifName); niStatus != nil {
+				vifNetworkInstance[ifName] = niStatus.DisplayName
+			}
 			// Counters not swapped on vif
 			if strings.HasPrefix(ifName, "nbn") ||
 				strings.HasPrefix(ifName, "nbu") ||
@@ -689,8 +808,12 @@ func publishMetrics(ctx *zedagentContext, iteration int) {
 	createVolumeInstanceMetrics(ctx, ReportMetrics)
 	createProcessMetrics(ctx, ReportMetrics)
 
+	setPrometheusMetrics(ReportMetrics, vifNetworkInstance)
+
 	log.Tracef("PublishMetricsToZedCloud sending %s", ReportMetrics)
-	SendMetricsProtobuf(ReportMetrics, iteration)
+	sent := SendMetricsProtobuf(ReportMetrics, iteration)
+	updateMetricsHistory(ctx, ReportMetrics, sent)
+	evaluateAlerts(ctx, ReportMetrics)
 	log.Tracef("publishMetrics: after send, total elapse sec %v", time.Since(startPubTime).Seconds())
 }
 
@@ -974,6 +1097,34 @@ func PublishAppInfoToZedCloud(ctx *zedagentContext, uuid string,
 			ReportAppInfo.VolumeRefs = append(ReportAppInfo.VolumeRefs,
 				vr.VolumeID.String())
 		}
+		if aiStatus.Health.State != types.AppHealthUnknown {
+			ReportAppInfo.Health = encodeAppHealth(aiStatus.Health)
+		}
+		ReportAppInfo.PendingReason = aiStatus.PendingReason
+		ReportAppInfo.PreemptedBy = aiStatus.PreemptedBy
+		for _, dev := range aiStatus.UsbDevices {
+			ReportAppInfo.UsbDevices = append(ReportAppInfo.UsbDevices,
+				&info.ZInfoUsbDevice{
+					PortPath:  dev.PortPath,
+					VendorId:  dev.VendorID,
+					ProductId: dev.ProductID,
+					Serial:    dev.Serial,
+					Product:   dev.Product,
+					Attached:  dev.Attached,
+					Error:     dev.Error,
+				})
+		}
+		ReportAppInfo.Guest = encodeGuestInfo(aiStatus.GuestInfo)
+		for _, cs := range aiStatus.Containers {
+			ReportAppInfo.Containers = append(ReportAppInfo.Containers,
+				&info.ZInfoAppContainer{
+					Name:     cs.Name,
+					Init:     cs.Init,
+					State:    cs.State.ZSwState(),
+					ExitCode: int32(cs.ExitCode),
+					Error:    cs.Error,
+				})
+		}
 	}
 
 	ReportInfo.InfoContent = new(info.ZInfoMsg_Ainfo)
@@ -1277,8 +1428,9 @@ func SendProtobuf(url string, buf *bytes.Buffer, size int64,
 // Try all (first free, then rest) until it gets through.
 // Each iteration we try a different port for load spreading.
 // For each port we try all its local IP addresses until we get a success.
+// SendMetricsProtobuf returns true if the metrics were sent
 func SendMetricsProtobuf(ReportMetrics *metrics.ZMetricMsg,
-	iteration int) {
+	iteration int) bool {
 	data, err := proto.Marshal(ReportMetrics)
 	if err != nil {
 		log.Fatal("SendInfoProtobufStr proto marshaling error: ", err)
@@ -1293,10 +1445,11 @@ func SendMetricsProtobuf(ReportMetrics *metrics.ZMetricMsg,
 	if err != nil {
 		// Hopefully next timeout will be more successful
 		log.Errorf("SendMetricsProtobuf status %d failed: %s", rtf, err)
-		return
+		return false
 	} else {
 		writeSentMetricsProtoMessage(data)
 	}
+	return true
 }
 
 // Use the ifname/vifname to find the underlay status
@@ -7,6 +7,7 @@ package zedagent
 
 import (
 	"bytes"
+	"strconv"
 	"strings"
 	"time"
 
@@ -148,6 +149,7 @@ func prepareAndPublishNetworkInstanceInfoMsg(ctx *zedagentContext,
 		if status.VpnStatus != nil {
 			fillVpnInfo(info, status.VpnStatus)
 		}
+		fillLoadBalancerInfo(info, status.LoadBalancerStatus)
 	}
 
 	infoMsg.InfoContent = new(zinfo.ZInfoMsg_Niinfo)
@@ -159,6 +161,38 @@ func prepareAndPublishNetworkInstanceInfoMsg(ctx *zedagentContext,
 	publishInfo(ctx, uuid, infoMsg)
 }
 
+// fillLoadBalancerInfo reports the load balancers and the health of
+// their backends
+func fillLoadBalancerInfo(info *zinfo.ZInfoNetworkInstance,
+	lbStatus []types.LoadBalancerStatus) {
+
+	for _, lb := range lbStatus {
+		lbInfo := new(zinfo.ZInfoLoadBalancer)
+		lbInfo.Name = lb.Name
+		lbInfo.Protocol = lb.Protocol
+		if port, err := strconv.Atoi(lb.Port); err == nil {
+			lbInfo.Port = uint32(port)
+		}
+		lbInfo.Error = lb.Error
+		for _, be := range lb.Backends {
+			beInfo := new(zinfo.ZInfoLoadBalancerBackend)
+			beInfo.AppID = be.AppUUID.String()
+			beInfo.VifName = be.VifName
+			if be.IPAddr != nil {
+				beInfo.IpAddr = be.IPAddr.String()
+			}
+			beInfo.Port = uint32(be.Port)
+			beInfo.Healthy = be.Healthy
+			if !be.LastChange.IsZero() {
+				beInfo.LastChange, _ = ptypes.TimestampProto(be.LastChange)
+			}
+			beInfo.LastError = be.LastError
+			lbInfo.Backends = append(lbInfo.Backends, beInfo)
+		}
+		info.LoadBalancers = append(info.LoadBalancers, lbInfo)
+	}
+}
+
 func fillVpnInfo(info *zinfo.ZInfoNetworkInstance, vpnStatus *types.VpnStatus) {
 
 	info.SoftwareList = new(zinfo.ZInfoSW)
@@ -80,6 +80,7 @@ func parseVolumeConfig(ctx *getconfigContext,
 		volumeConfig.DisplayName = cfgVolume.GetDisplayName()
 		volumeConfig.ReadOnly = cfgVolume.GetReadonly()
 		volumeConfig.RefCount = 1
+		volumeConfig.Trace = ctx.volumeTraces[volumeConfig.Key()]
 		publishVolumeConfig(ctx, *volumeConfig)
 	}
 	log.Tracef("parsing volume config done\n")
@@ -0,0 +1,184 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// History of the metrics which could not be sent to the controller. While
+// the controller is not reachable one metrics message per resolution
+// interval is kept in metricsHistoryDirname, a file per message named after
+// its time in milliseconds, up to the retention time and
+// metricsHistoryMaxBytes. Once a message is sent again the kept ones are
+// sent oldest first, marked as backfilled, metricsBackfillBatch at a time
+// so the metrics timer task keeps up.
+
+package zedagent
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	fileutils "github.com/lf-edge/eve/pkg/pillar/utils/file"
+	"github.com/lf-edge/eve/pkg/pillar/zedcloud"
+)
+
+const (
+	metricsHistoryDirname  = types.PersistDir + "/metrics-history"
+	metricsHistoryMaxBytes = 100 * 1024 * 1024
+	metricsBackfillBatch   = 30
+)
+
+type metricsSample struct {
+	time time.Time
+	size int64
+}
+
+type metricsHistory struct {
+	dirname    string
+	samples    []metricsSample // oldest first
+	totalBytes int64
+	loaded     bool
+}
+
+var history = metricsHistory{dirname: metricsHistoryDirname}
+
+func (h *metricsHistory) filename(t time.Time) string {
+	return filepath.Join(h.dirname,
+		fmt.Sprintf("%d.pb", t.UnixNano()/int64(time.Millisecond)))
+}
+
+// load picks up the samples kept before a restart
+func (h *metricsHistory) load() {
+	h.loaded = true
+	infos, err := ioutil.ReadDir(h.dirname)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("metricsHistory load: %v", err)
+		}
+		return
+	}
+	for _, info := range infos {
+		msec, err := strconv.ParseInt(strings.TrimSuffix(info.Name(), ".pb"), 10, 64)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		h.samples = append(h.samples, metricsSample{
+			time: time.Unix(0, msec*int64(time.Millisecond)),
+			size: info.Size(),
+		})
+		h.totalBytes += info.Size()
+	}
+	sort.Slice(h.samples, func(i, j int) bool {
+		return h.samples[i].time.Before(h.samples[j].time)
+	})
+	log.Functionf("metricsHistory load: %d samples, %d bytes",
+		len(h.samples), h.totalBytes)
+}
+
+// add keeps the metrics unless a sample of the same resolution interval
+// is kept, and removes the samples beyond the retention and size limits
+func (h *metricsHistory) add(report *metrics.ZMetricMsg, now time.Time,
+	resolution, retention time.Duration) {
+
+	if !h.loaded {
+		h.load()
+	}
+	slot := now.Truncate(resolution)
+	if len(h.samples) != 0 && !h.samples[len(h.samples)-1].time.Before(slot) {
+		return
+	}
+	report = proto.Clone(report).(*metrics.ZMetricMsg)
+	report.Backfilled = true
+	data, err := proto.Marshal(report)
+	if err != nil {
+		log.Errorf("metricsHistory add: %v", err)
+		return
+	}
+	if err := os.MkdirAll(h.dirname, 0755); err != nil {
+		log.Errorf("metricsHistory add: %v", err)
+		return
+	}
+	if err := fileutils.WriteRename(h.filename(now), data); err != nil {
+		// Can occur if no space in filesystem
+		log.Errorf("metricsHistory add: %v", err)
+		return
+	}
+	h.samples = append(h.samples, metricsSample{time: now, size: int64(len(data))})
+	h.totalBytes += int64(len(data))
+	for len(h.samples) != 0 && (now.Sub(h.samples[0].time) > retention ||
+		h.totalBytes > metricsHistoryMaxBytes) {
+		h.removeOldest()
+	}
+}
+
+func (h *metricsHistory) removeOldest() {
+	sample := h.samples[0]
+	if err := os.Remove(h.filename(sample.time)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("metricsHistory removeOldest: %v", err)
+	}
+	h.samples = h.samples[1:]
+	h.totalBytes -= sample.size
+}
+
+// backfill sends up to metricsBackfillBatch of the oldest samples and
+// stops at the first one which fails to send. A sample rejected by the
+// controller is dropped.
+func (h *metricsHistory) backfill(send func(data []byte) (*http.Response, error)) {
+	if !h.loaded {
+		h.load()
+	}
+	for i := 0; i < metricsBackfillBatch && len(h.samples) != 0; i++ {
+		data, err := ioutil.ReadFile(h.filename(h.samples[0].time))
+		if err != nil {
+			log.Errorf("metricsHistory backfill: %v", err)
+			h.removeOldest()
+			continue
+		}
+		resp, err := send(data)
+		if err != nil {
+			if resp == nil || resp.StatusCode < 400 || resp.StatusCode >= 500 ||
+				resp.StatusCode == http.StatusForbidden {
+				log.Warnf("metricsHistory backfill: %v; %d samples left",
+					err, len(h.samples))
+				return
+			}
+			log.Errorf("metricsHistory backfill: dropping sample of %v: %v",
+				h.samples[0].time, err)
+		}
+		h.removeOldest()
+	}
+	if len(h.samples) == 0 {
+		log.Functionf("metricsHistory backfill: done")
+	}
+}
+
+// sendBackfillMetrics sends a kept metrics message to the controller
+func sendBackfillMetrics(data []byte) (*http.Response, error) {
+	metricsURL := zedcloud.URLPathString(serverNameAndPort, zedcloudCtx.V2API, devUUID, "metrics")
+	const bailOnHTTPErr = true
+	resp, _, _, err := zedcloud.SendOnAllIntf(zedcloudCtx, metricsURL,
+		int64(len(data)), bytes.NewBuffer(data), 0, bailOnHTTPErr)
+	return resp, err
+}
+
+// updateMetricsHistory keeps the metrics after a failure to send them, and
+// sends the kept ones after a success
+func updateMetricsHistory(ctx *zedagentContext, report *metrics.ZMetricMsg,
+	sent bool) {
+
+	resolution := time.Duration(ctx.globalConfig.GlobalValueInt(types.MetricsHistoryResolution)) * time.Second
+	if sent {
+		history.backfill(sendBackfillMetrics)
+	} else if resolution != 0 {
+		retention := time.Duration(ctx.globalConfig.GlobalValueInt(types.MetricsHistoryRetention)) * time.Second
+		history.add(report, time.Now(), resolution, retention)
+	}
+}
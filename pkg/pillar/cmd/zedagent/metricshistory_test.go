@@ -0,0 +1,82 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedagent
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMetricsHistory(t *testing.T) {
+	logger = logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, "zedagent", 0)
+	dir, err := ioutil.TempDir("", "metricshistory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := metricsHistory{dirname: dir}
+	start := time.Unix(1600000000, 0)
+	// a metrics message every 20 seconds for 10 minutes
+	for i := 0; i < 30; i++ {
+		now := start.Add(time.Duration(i) * 20 * time.Second)
+		report := &metrics.ZMetricMsg{DevID: now.String()}
+		h.add(report, now, time.Minute, 5*time.Minute)
+	}
+	// one per minute of the last 5 minutes
+	if len(h.samples) != 6 {
+		t.Fatalf("got %d samples, expected 6", len(h.samples))
+	}
+
+	// the samples are picked up after a restart
+	h = metricsHistory{dirname: dir}
+	var sent []string
+	failAfter := 2
+	send := func(data []byte) (*http.Response, error) {
+		if len(sent) == failAfter {
+			return nil, errors.New("unreachable")
+		}
+		var report metrics.ZMetricMsg
+		if err := proto.Unmarshal(data, &report); err != nil {
+			t.Fatal(err)
+		}
+		if !report.Backfilled {
+			t.Errorf("sample %s not marked as backfilled", report.DevID)
+		}
+		sent = append(sent, report.DevID)
+		return nil, nil
+	}
+	h.backfill(send)
+	if len(sent) != 2 || len(h.samples) != 4 {
+		t.Fatalf("sent %d, %d left, expected 2 and 4", len(sent), len(h.samples))
+	}
+	failAfter = -1
+	h.backfill(send)
+	if len(h.samples) != 0 || h.totalBytes != 0 {
+		t.Errorf("%d samples of %d bytes left", len(h.samples), h.totalBytes)
+	}
+	if len(sent) != 6 {
+		t.Errorf("sent %d samples, expected 6", len(sent))
+	}
+	for i, devID := range sent {
+		expected := start.Add(time.Duration(13+3*i) * 20 * time.Second).String()
+		if devID != expected {
+			t.Errorf("sample %d is %s, expected %s", i, devID, expected)
+		}
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("%d files left", len(files))
+	}
+}
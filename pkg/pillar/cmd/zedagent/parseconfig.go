@@ -13,6 +13,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -87,6 +88,7 @@ func parseConfig(config *zconfig.EdgeDevConfig, getconfigCtx *getconfigContext,
 		parseSystemAdapterConfig(config, getconfigCtx, forceSystemAdaptersParse)
 		parseBaseOsConfig(getconfigCtx, config)
 		parseNetworkInstanceConfig(config, getconfigCtx)
+		assignTraces(getconfigCtx, config)
 		parseContentInfoConfig(getconfigCtx, config)
 		parseVolumeConfig(getconfigCtx, config)
 		parseAppInstanceConfig(config, getconfigCtx)
@@ -264,6 +266,135 @@ func parseDnsNameToIpList(
 	config.DnsNameToIPList = nameToIPs
 }
 
+func parseIpfixExporterConfig(
+	apiConfigEntry *zconfig.NetworkInstanceConfig,
+	config *types.NetworkInstanceConfig) {
+
+	ipfix := apiConfigEntry.GetIpfix()
+	if ipfix == nil || ipfix.GetCollector() == "" {
+		return
+	}
+	collector := net.ParseIP(ipfix.GetCollector())
+	if collector == nil {
+		log.Errorf("Network instance %s %s: bad IPFIX collector %s ignored",
+			config.UUID.String(), config.DisplayName, ipfix.GetCollector())
+		return
+	}
+	if ipfix.GetPort() > 0xFFFF {
+		log.Errorf("Network instance %s %s: bad IPFIX port %d ignored",
+			config.UUID.String(), config.DisplayName, ipfix.GetPort())
+		return
+	}
+	config.IPFIXExporter = types.IPFIXExporterConfig{
+		Collector:           collector,
+		Port:                uint16(ipfix.GetPort()),
+		ObservationDomainID: ipfix.GetObservationDomainId(),
+		EnterpriseNumber:    ipfix.GetEnterpriseNumber(),
+	}
+}
+
+func parseIpv6Config(
+	apiConfigEntry *zconfig.NetworkInstanceConfig,
+	config *types.NetworkInstanceConfig) {
+
+	ipv6 := apiConfigEntry.GetIpv6()
+	if ipv6 == nil {
+		return
+	}
+	if !config.IsLocalIPv6() {
+		log.Warnf("Network instance %s %s: ipv6 config ignored for type %v, %v",
+			config.UUID.String(), config.DisplayName, config.Type,
+			config.IpType)
+		return
+	}
+	switch ipv6.GetAddressing() {
+	case zconfig.Ipv6AddressingMode_IPV6_ADDRESSING_DHCPV6:
+		config.IPv6.Addressing = types.IPv6AddressingDHCPv6
+	case zconfig.Ipv6AddressingMode_IPV6_ADDRESSING_SLAAC:
+		config.IPv6.Addressing = types.IPv6AddressingSLAAC
+	default:
+		log.Errorf("Network instance %s %s: unknown IPv6 addressing mode %v",
+			config.UUID.String(), config.DisplayName, ipv6.GetAddressing())
+	}
+	switch ipv6.GetUplink() {
+	case zconfig.Ipv6UplinkMode_IPV6_UPLINK_NAT66:
+		config.IPv6.Uplink = types.IPv6UplinkNAT66
+	case zconfig.Ipv6UplinkMode_IPV6_UPLINK_PREFIX_DELEGATION:
+		config.IPv6.Uplink = types.IPv6UplinkPrefixDelegation
+	default:
+		log.Errorf("Network instance %s %s: unknown IPv6 uplink mode %v",
+			config.UUID.String(), config.DisplayName, ipv6.GetUplink())
+	}
+}
+
+func parseLoadBalancerConfig(
+	apiConfigEntry *zconfig.NetworkInstanceConfig,
+	config *types.NetworkInstanceConfig) {
+
+	if len(apiConfigEntry.GetLoadBalancers()) == 0 {
+		return
+	}
+	if config.Type != types.NetworkInstanceTypeLocal {
+		log.Warnf("Network instance %s %s: load balancers ignored for type %v",
+			config.UUID.String(), config.DisplayName, config.Type)
+		return
+	}
+	names := make(map[string]bool)
+	for _, lb := range apiConfigEntry.GetLoadBalancers() {
+		if lb.GetName() == "" || names[lb.GetName()] {
+			log.Errorf("Network instance %s %s: load balancer without name or with duplicate name %s",
+				config.UUID.String(), config.DisplayName, lb.GetName())
+			continue
+		}
+		names[lb.GetName()] = true
+		lbConfig := types.LoadBalancerConfig{Name: lb.GetName()}
+		switch lb.GetAlgorithm() {
+		case zconfig.LoadBalancerAlgorithm_LB_ALGORITHM_ROUND_ROBIN:
+			lbConfig.Algorithm = types.LBAlgorithmRoundRobin
+		case zconfig.LoadBalancerAlgorithm_LB_ALGORITHM_SOURCE_HASH:
+			lbConfig.Algorithm = types.LBAlgorithmSourceHash
+		default:
+			log.Errorf("Network instance %s %s: load balancer %s unknown algorithm %v",
+				config.UUID.String(), config.DisplayName, lb.GetName(),
+				lb.GetAlgorithm())
+		}
+		hc := lb.GetHealthCheck()
+		switch hc.GetType() {
+		case zconfig.LoadBalancerHealthCheckType_LB_HEALTH_CHECK_NONE:
+			lbConfig.HealthCheck.Type = types.LBHealthCheckNone
+		case zconfig.LoadBalancerHealthCheckType_LB_HEALTH_CHECK_TCP:
+			lbConfig.HealthCheck.Type = types.LBHealthCheckTCP
+		case zconfig.LoadBalancerHealthCheckType_LB_HEALTH_CHECK_HTTP:
+			lbConfig.HealthCheck.Type = types.LBHealthCheckHTTP
+		default:
+			log.Errorf("Network instance %s %s: load balancer %s unknown health check %v",
+				config.UUID.String(), config.DisplayName, lb.GetName(),
+				hc.GetType())
+		}
+		lbConfig.HealthCheck.Interval = hc.GetInterval()
+		if lbConfig.HealthCheck.Interval == 0 {
+			lbConfig.HealthCheck.Interval = 10
+		}
+		lbConfig.HealthCheck.Timeout = hc.GetTimeout()
+		if lbConfig.HealthCheck.Timeout == 0 {
+			lbConfig.HealthCheck.Timeout = 5
+		}
+		lbConfig.HealthCheck.HealthyThreshold = hc.GetHealthyThreshold()
+		if lbConfig.HealthCheck.HealthyThreshold == 0 {
+			lbConfig.HealthCheck.HealthyThreshold = 2
+		}
+		lbConfig.HealthCheck.UnhealthyThreshold = hc.GetUnhealthyThreshold()
+		if lbConfig.HealthCheck.UnhealthyThreshold == 0 {
+			lbConfig.HealthCheck.UnhealthyThreshold = 3
+		}
+		lbConfig.HealthCheck.HTTPPath = hc.GetHttpPath()
+		if lbConfig.HealthCheck.HTTPPath == "" {
+			lbConfig.HealthCheck.HTTPPath = "/"
+		}
+		config.LoadBalancers = append(config.LoadBalancers, lbConfig)
+	}
+}
+
 func publishNetworkInstanceConfig(ctx *getconfigContext,
 	networkInstances []*zconfig.NetworkInstanceConfig) {
 
@@ -370,6 +501,9 @@ func publishNetworkInstanceConfig(ctx *getconfigContext,
 			parseDnsNameToIpList(apiConfigEntry,
 				&networkInstanceConfig)
 		}
+		parseIpfixExporterConfig(apiConfigEntry, &networkInstanceConfig)
+		parseIpv6Config(apiConfigEntry, &networkInstanceConfig)
+		parseLoadBalancerConfig(apiConfigEntry, &networkInstanceConfig)
 
 		ctx.pubNetworkInstanceConfig.Publish(networkInstanceConfig.UUID.String(),
 			networkInstanceConfig)
@@ -458,6 +592,10 @@ func parseAppInstanceConfig(config *zconfig.EdgeDevConfig,
 		appInstance.FixedResources.Memory = int(cfgApp.Fixedresources.Memory)
 		appInstance.FixedResources.RootDev = cfgApp.Fixedresources.Rootdev
 		appInstance.FixedResources.VCpus = int(cfgApp.Fixedresources.Vcpus)
+		appInstance.FixedResources.CPUs = cfgApp.Fixedresources.Cpus
+		appInstance.FixedResources.ExclusiveCPUs = cfgApp.Fixedresources.ExclusiveCpus
+		appInstance.FixedResources.Hugepages = types.HugepageSize(cfgApp.Fixedresources.Hugepages)
+		appInstance.FixedResources.VTpm = types.VTpmMode(cfgApp.Fixedresources.Vtpm)
 		appInstance.FixedResources.VirtualizationMode = types.VmMode(cfgApp.Fixedresources.VirtualizationMode)
 		appInstance.FixedResources.EnableVnc = cfgApp.Fixedresources.EnableVnc
 		appInstance.FixedResources.VncDisplay = cfgApp.Fixedresources.VncDisplay
@@ -499,15 +637,214 @@ func parseAppInstanceConfig(config *zconfig.EdgeDevConfig,
 		if userData != "" {
 			appInstance.CloudInitUserData = &userData
 		}
+		appInstance.CloudInitVendorData = cfgApp.GetVendorData()
+		appInstance.CloudInitNoCloud = cfgApp.GetCloudInitNoCloud()
 		appInstance.RemoteConsole = cfgApp.GetRemoteConsole()
 		appInstance.CipherBlockStatus = parseCipherBlock(getconfigCtx, appInstance.Key(),
 			cfgApp.GetCipherData())
+		parseAppProbes(&appInstance, cfgApp)
+		parseAppDependencies(&appInstance, cfgApp)
+		parseAppPriority(&appInstance, cfgApp)
+		parseAppUsbDevices(&appInstance, cfgApp)
+		parseAppContainers(&appInstance, cfgApp)
+		appInstance.Trace = getconfigCtx.appTraces[appInstance.Key()]
 
 		// write to zedmanager config directory
 		publishAppInstanceConfig(getconfigCtx, appInstance)
 	}
 }
 
+func parseAppPriority(appInstance *types.AppInstanceConfig,
+	cfgApp *zconfig.AppInstanceConfig) {
+
+	switch cfgApp.GetPriority() {
+	case zconfig.AppPriorityClass_APP_PRIORITY_CLASS_NORMAL:
+		appInstance.Priority = types.AppPriorityNormal
+	case zconfig.AppPriorityClass_APP_PRIORITY_CLASS_BEST_EFFORT:
+		appInstance.Priority = types.AppPriorityBestEffort
+	case zconfig.AppPriorityClass_APP_PRIORITY_CLASS_CRITICAL:
+		appInstance.Priority = types.AppPriorityCritical
+	default:
+		log.Errorf("App instance %s %s: unknown priority %v",
+			appInstance.Key(), appInstance.DisplayName,
+			cfgApp.GetPriority())
+	}
+}
+
+func parseAppProbes(appInstance *types.AppInstanceConfig,
+	cfgApp *zconfig.AppInstanceConfig) {
+
+	switch cfgApp.GetRestartPolicy() {
+	case zconfig.AppRestartPolicy_APP_RESTART_POLICY_UNSPECIFIED,
+		zconfig.AppRestartPolicy_APP_RESTART_POLICY_NEVER:
+		appInstance.RestartPolicy = types.RestartPolicyNever
+	case zconfig.AppRestartPolicy_APP_RESTART_POLICY_ALWAYS:
+		appInstance.RestartPolicy = types.RestartPolicyAlways
+	case zconfig.AppRestartPolicy_APP_RESTART_POLICY_ON_FAILURE:
+		appInstance.RestartPolicy = types.RestartPolicyOnFailure
+	default:
+		log.Errorf("App instance %s %s: unknown restart policy %v",
+			appInstance.Key(), appInstance.DisplayName,
+			cfgApp.GetRestartPolicy())
+	}
+	for _, p := range cfgApp.GetProbes() {
+		probe := types.AppProbe{
+			NetworkAdapter:   p.GetNetworkAdapter(),
+			HTTPPath:         p.GetHttpPath(),
+			Command:          p.GetCommand(),
+			InitialDelay:     p.GetInitialDelay(),
+			Period:           p.GetPeriod(),
+			Timeout:          p.GetTimeout(),
+			FailureThreshold: p.GetFailureThreshold(),
+			SuccessThreshold: p.GetSuccessThreshold(),
+		}
+		switch p.GetKind() {
+		case zconfig.AppProbeKind_APP_PROBE_KIND_LIVENESS:
+			probe.Kind = types.AppProbeLiveness
+		case zconfig.AppProbeKind_APP_PROBE_KIND_READINESS:
+			probe.Kind = types.AppProbeReadiness
+		default:
+			log.Errorf("App instance %s %s: probe with unknown kind %v",
+				appInstance.Key(), appInstance.DisplayName, p.GetKind())
+			continue
+		}
+		switch p.GetType() {
+		case zconfig.AppProbeType_APP_PROBE_TYPE_HTTP:
+			probe.Type = types.AppProbeHTTP
+		case zconfig.AppProbeType_APP_PROBE_TYPE_TCP:
+			probe.Type = types.AppProbeTCP
+		case zconfig.AppProbeType_APP_PROBE_TYPE_EXEC:
+			probe.Type = types.AppProbeExec
+		case zconfig.AppProbeType_APP_PROBE_TYPE_GUEST_AGENT:
+			probe.Type = types.AppProbeGuestAgent
+		default:
+			log.Errorf("App instance %s %s: probe with unknown type %v",
+				appInstance.Key(), appInstance.DisplayName, p.GetType())
+			continue
+		}
+		switch probe.Type {
+		case types.AppProbeHTTP, types.AppProbeTCP:
+			if p.GetPort() == 0 || p.GetPort() > 65535 {
+				log.Errorf("App instance %s %s: %s probe with invalid port %d",
+					appInstance.Key(), appInstance.DisplayName,
+					probe.Type, p.GetPort())
+				continue
+			}
+			probe.Port = uint16(p.GetPort())
+		case types.AppProbeExec:
+			if len(probe.Command) == 0 {
+				log.Errorf("App instance %s %s: exec probe without command",
+					appInstance.Key(), appInstance.DisplayName)
+				continue
+			}
+		}
+		if probe.HTTPPath == "" {
+			probe.HTTPPath = "/"
+		}
+		if probe.Period == 0 {
+			probe.Period = 10
+		}
+		if probe.Timeout == 0 {
+			probe.Timeout = 5
+		}
+		if probe.FailureThreshold == 0 {
+			probe.FailureThreshold = 3
+		}
+		if probe.SuccessThreshold == 0 {
+			probe.SuccessThreshold = 1
+		}
+		appInstance.Probes = append(appInstance.Probes, probe)
+	}
+}
+
+// parseAppDependencies ignores invalid UUIDs; dependencies on unknown
+// app instances and cycles are reported by zedmanager
+func parseAppDependencies(appInstance *types.AppInstanceConfig,
+	cfgApp *zconfig.AppInstanceConfig) {
+
+	for _, uuidStr := range cfgApp.GetDependsOn() {
+		id, err := uuid.FromString(uuidStr)
+		if err != nil {
+			log.Errorf("App instance %s %s: invalid dependency %s: %s",
+				appInstance.Key(), appInstance.DisplayName, uuidStr, err)
+			continue
+		}
+		if uuid.Equal(id, appInstance.UUIDandVersion.UUID) {
+			log.Errorf("App instance %s %s: ignoring dependency on itself",
+				appInstance.Key(), appInstance.DisplayName)
+			continue
+		}
+		appInstance.DependsOn = append(appInstance.DependsOn, id)
+	}
+}
+
+func parseAppUsbDevices(appInstance *types.AppInstanceConfig,
+	cfgApp *zconfig.AppInstanceConfig) {
+
+	for _, cfgRule := range cfgApp.GetUsbDevices() {
+		rule := types.UsbDeviceRule{
+			VendorID:  strings.TrimPrefix(cfgRule.GetVendorId(), "0x"),
+			ProductID: strings.TrimPrefix(cfgRule.GetProductId(), "0x"),
+			Serial:    cfgRule.GetSerial(),
+			PortPath:  cfgRule.GetPortPath(),
+		}
+		if rule == (types.UsbDeviceRule{}) {
+			log.Errorf("App instance %s %s: ignoring empty USB device rule",
+				appInstance.Key(), appInstance.DisplayName)
+			continue
+		}
+		appInstance.UsbDevices = append(appInstance.UsbDevices, rule)
+	}
+}
+
+// The container names end up in the task names hence no '_' nor '.'
+var containerNameRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// parseAppContainers ignores the containers with an invalid or duplicate
+// name, and the ones whose image is not a volume of the app instance
+// other than the first one
+func parseAppContainers(appInstance *types.AppInstanceConfig,
+	cfgApp *zconfig.AppInstanceConfig) {
+
+	names := make(map[string]bool)
+	for _, cfgContainer := range cfgApp.GetContainers() {
+		name := cfgContainer.GetName()
+		if !containerNameRe.MatchString(name) || names[name] {
+			log.Errorf("App instance %s %s: ignoring container with invalid or duplicate name %q",
+				appInstance.Key(), appInstance.DisplayName, name)
+			continue
+		}
+		image, err := uuid.FromString(cfgContainer.GetImage())
+		if err != nil {
+			log.Errorf("App instance %s %s: container %s: invalid image %s: %s",
+				appInstance.Key(), appInstance.DisplayName, name,
+				cfgContainer.GetImage(), err)
+			continue
+		}
+		found := false
+		for i, vrc := range appInstance.VolumeRefConfigList {
+			if i != 0 && uuid.Equal(vrc.VolumeID, image) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Errorf("App instance %s %s: container %s: image %s is not an additional volume",
+				appInstance.Key(), appInstance.DisplayName, name, image)
+			continue
+		}
+		names[name] = true
+		appInstance.Containers = append(appInstance.Containers,
+			types.ContainerConfig{
+				Name:    name,
+				Image:   image,
+				Init:    cfgContainer.GetInit(),
+				Command: cfgContainer.GetCommand(),
+				Env:     cfgContainer.GetEnvironment(),
+			})
+	}
+}
+
 var systemAdaptersPrevConfigHash []byte
 
 func parseSystemAdapterConfig(config *zconfig.EdgeDevConfig,
@@ -710,12 +1047,14 @@ func parseOneSystemAdapterConfig(getconfigCtx *getconfigContext,
 				port.AddrSubnet = addrSubnet.String()
 			}
 			port.WirelessCfg = network.WirelessCfg
+			port.Dot1xCfg = network.Dot1xCfg
 			port.Gateway = network.Gateway
 			port.DomainName = network.DomainName
 			port.NtpServer = network.NtpServer
 			port.DnsServers = network.DnsServers
 			// Need to be careful since zedcloud can feed us bad Dhcp type
 			port.Dhcp = network.Dhcp
+			port.PrefixDelegation = network.PrefixDelegation
 		}
 		switch port.Dhcp {
 		case types.DT_STATIC:
@@ -1085,6 +1424,11 @@ func parseOneNetworkXObjectConfig(ctx *getconfigContext, netEnt *zconfig.Network
 	// wireless property configuration
 	config.WirelessCfg = parseNetworkWirelessConfig(ctx, config.Key(), netEnt)
 
+	// 802.1X property configuration
+	config.Dot1xCfg = parseNetworkDot1xConfig(ctx, config.Key(), netEnt)
+
+	config.PrefixDelegation = netEnt.GetIpv6PrefixDelegation()
+
 	ipspec := netEnt.GetIp()
 	switch config.Type {
 	case types.NT_IPV4, types.NT_IPV6:
@@ -1209,6 +1553,35 @@ func parseNetworkWirelessConfig(ctx *getconfigContext, key string, netEnt *zconf
 	return wconfig
 }
 
+func parseNetworkDot1xConfig(ctx *getconfigContext, key string, netEnt *zconfig.NetworkConfig) types.Dot1xConfig {
+	var dot1x types.Dot1xConfig
+
+	netDot1x := netEnt.GetDot1X()
+	if netDot1x == nil || !netDot1x.GetEnable() {
+		return dot1x
+	}
+	log.Functionf("parseNetworkDot1xConfig: 802.1X of network present in %s, method %s",
+		netEnt.Id, netDot1x.GetEapMethod())
+	dot1x.Enable = true
+	switch netDot1x.GetEapMethod() {
+	case zconfig.Dot1XEapMethod_DOT1X_EAP_METHOD_TLS:
+		dot1x.EapMethod = types.Dot1xEapMethodTLS
+	case zconfig.Dot1XEapMethod_DOT1X_EAP_METHOD_PEAP:
+		dot1x.EapMethod = types.Dot1xEapMethodPEAP
+	default:
+		log.Errorf("parseNetworkDot1xConfig: unsupported EAP method %d in %s",
+			netDot1x.GetEapMethod(), netEnt.Id)
+	}
+	dot1x.Identity = netDot1x.GetIdentity()
+	dot1x.AnonymousIdentity = netDot1x.GetAnonymousIdentity()
+	dot1x.CACertPEM = netDot1x.GetCaCertPem()
+	dot1x.ServerDomainSuffix = netDot1x.GetServerDomainSuffix()
+	dot1x.ClientCertPEM = netDot1x.GetClientCertPem()
+	dot1x.CipherBlockStatus = parseCipherBlock(ctx, key+"-dot1x",
+		netDot1x.GetCipherData())
+	return dot1x
+}
+
 func parseIpspecNetworkXObject(ipspec *zconfig.Ipspec, config *types.NetworkXObjectConfig) error {
 	config.Dhcp = types.DhcpType(ipspec.Dhcp)
 	config.DomainName = ipspec.GetDomain()
@@ -1473,6 +1846,7 @@ func parseUnderlayNetworkConfigEntry(
 			actionCfg.LimitBurst = int(action.Limitburst)
 			actionCfg.PortMap = action.Portmap
 			actionCfg.TargetPort = int(action.AppPort)
+			actionCfg.LoadBalancer = action.LoadBalancer
 			// XXX:FIXME actionCfg.Drop = <TBD>
 			aclCfg.Actions[actionIdx] = *actionCfg
 		}
@@ -1576,6 +1950,7 @@ func parseConfigItems(config *zconfig.EdgeDevConfig, ctx *getconfigContext) {
 			ctx.zedagentCtx.gcpMaintenanceMode = newMaintenanceMode
 			mergeMaintenanceMode(ctx.zedagentCtx)
 		}
+		updatePrometheusServer(ctx.zedagentCtx)
 
 		pub := ctx.zedagentCtx.pubGlobalConfig
 		err := pub.Publish("global", *gcPtr)
@@ -1661,6 +2036,8 @@ func parseOpCmds(config *zconfig.EdgeDevConfig,
 	getconfigCtx *getconfigContext) bool {
 
 	scheduleBackup(config.GetBackup())
+	scheduleCollectInfo(config.GetCollectInfo(),
+		config.GetCollectInfoDatastore(), getconfigCtx)
 	return scheduleReboot(config.GetReboot(), getconfigCtx)
 }
 
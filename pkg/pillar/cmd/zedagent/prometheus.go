@@ -0,0 +1,466 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Local Prometheus endpoint. When metrics.prometheus.port is set the
+// metrics which publishMetrics reports to the controller are also rendered
+// in the OpenMetrics text format and served on /metrics, over TLS with the
+// device certificate unless metrics.prometheus.tls is disabled. nim limits
+// the access to the port with iptables; the handler checks the
+// metrics.prometheus.allow prefixes as well. Unless metrics.prometheus.interface
+// or metrics.prometheus.allow is set the endpoint is only reachable from the
+// device itself.
+
+package zedagent
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/api/go/metrics"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/lf-edge/eve/pkg/pillar/zedcloud"
+)
+
+const (
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	mbyte                  = 1 << 20
+)
+
+// prometheusServer serves the metrics rendered by the last publishMetrics
+type prometheusServer struct {
+	sync.Mutex
+	addr    string // Listen address; empty if disabled
+	tls     bool
+	allow   []*net.IPNet
+	server  *http.Server
+	metrics []byte
+}
+
+var promServer prometheusServer
+
+type metricSample struct {
+	labels string
+	value  float64
+}
+
+type metricFamily struct {
+	name    string
+	typ     string // "counter" or "gauge"
+	help    string
+	samples []metricSample
+}
+
+// openMetrics collects the samples grouped by family in the order the
+// families are first added
+type openMetrics struct {
+	families []*metricFamily
+	byName   map[string]*metricFamily
+}
+
+func newOpenMetrics() *openMetrics {
+	return &openMetrics{byName: make(map[string]*metricFamily)}
+}
+
+// add adds a sample; labels are pairs of names and values
+func (om *openMetrics) add(name string, typ string, help string,
+	value float64, labels ...string) {
+
+	family, ok := om.byName[name]
+	if !ok {
+		family = &metricFamily{name: name, typ: typ, help: help}
+		om.families = append(om.families, family)
+		om.byName[name] = family
+	}
+	var b strings.Builder
+	for i := 0; i+1 < len(labels); i += 2 {
+		if b.Len() != 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+	}
+	family.samples = append(family.samples,
+		metricSample{labels: b.String(), value: value})
+}
+
+func (om *openMetrics) counter(name string, help string, value uint64,
+	labels ...string) {
+	om.add(name, "counter", help, float64(value), labels...)
+}
+
+func (om *openMetrics) gauge(name string, help string, value float64,
+	labels ...string) {
+	om.add(name, "gauge", help, value, labels...)
+}
+
+// bytes returns the exposition ending with # EOF
+func (om *openMetrics) bytes() []byte {
+	var b bytes.Buffer
+	for _, family := range om.families {
+		fmt.Fprintf(&b, "# TYPE %s %s\n", family.name, family.typ)
+		fmt.Fprintf(&b, "# HELP %s %s\n", family.name, family.help)
+		sampleName := family.name
+		if family.typ == "counter" {
+			sampleName += "_total"
+		}
+		for _, sample := range family.samples {
+			b.WriteString(sampleName)
+			if sample.labels != "" {
+				fmt.Fprintf(&b, "{%s}", sample.labels)
+			}
+			fmt.Fprintf(&b, " %s\n",
+				strconv.FormatFloat(sample.value, 'f', -1, 64))
+		}
+	}
+	b.WriteString("# EOF\n")
+	return b.Bytes()
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func addNetworkMetric(om *openMetrics, prefix string, nm *metrics.NetworkMetric,
+	labels ...string) {
+	om.counter(prefix+"_tx_bytes", "Bytes transmitted", nm.TxBytes, labels...)
+	om.counter(prefix+"_rx_bytes", "Bytes received", nm.RxBytes, labels...)
+	om.counter(prefix+"_tx_packets", "Packets transmitted", nm.TxPkts, labels...)
+	om.counter(prefix+"_rx_packets", "Packets received", nm.RxPkts, labels...)
+	om.counter(prefix+"_tx_drops", "Transmitted packets dropped",
+		nm.TxDrops+nm.TxAclDrops+nm.TxAclRateLimitDrops, labels...)
+	om.counter(prefix+"_rx_drops", "Received packets dropped",
+		nm.RxDrops+nm.RxAclDrops+nm.RxAclRateLimitDrops, labels...)
+	om.counter(prefix+"_tx_errors", "Transmit errors", nm.TxErrors, labels...)
+	om.counter(prefix+"_rx_errors", "Receive errors", nm.RxErrors, labels...)
+}
+
+// renderOpenMetrics renders the metrics reported to the controller.
+// vifNetworkInstance maps the app interfaces to the names of their
+// network instances.
+func renderOpenMetrics(report *metrics.ZMetricMsg,
+	vifNetworkInstance map[string]string) []byte {
+
+	om := newOpenMetrics()
+	if dm := report.GetDm(); dm != nil {
+		if mem := dm.GetMemory(); mem != nil {
+			om.gauge("eve_memory_used_bytes", "Memory used by the device",
+				float64(mem.UsedMem)*mbyte)
+			om.gauge("eve_memory_available_bytes", "Memory available on the device",
+				float64(mem.AvailMem)*mbyte)
+		}
+		if cpu := dm.GetCpuMetric(); cpu != nil {
+			om.counter("eve_cpu_seconds", "CPU time used by the device", cpu.Total)
+			if cpu.UpTime != nil {
+				om.gauge("eve_uptime_seconds", "Time since the device booted",
+					float64(cpu.UpTime.Seconds))
+			}
+		}
+		for _, nm := range dm.Network {
+			addNetworkMetric(om, "eve_network", nm, "interface", nm.IName)
+		}
+		for _, disk := range dm.Disk {
+			labels := []string{"disk", disk.Disk, "mount_path", disk.MountPath}
+			om.counter("eve_disk_read_bytes", "Bytes read from the disk",
+				disk.ReadBytes*mbyte, labels...)
+			om.counter("eve_disk_written_bytes", "Bytes written to the disk",
+				disk.WriteBytes*mbyte, labels...)
+			om.counter("eve_disk_reads", "Read operations on the disk",
+				disk.ReadCount, labels...)
+			om.counter("eve_disk_writes", "Write operations on the disk",
+				disk.WriteCount, labels...)
+			om.gauge("eve_disk_size_bytes", "Size of the filesystem",
+				float64(disk.Total)*mbyte, labels...)
+			om.gauge("eve_disk_used_bytes", "Space used on the filesystem",
+				float64(disk.Used)*mbyte, labels...)
+			om.gauge("eve_disk_free_bytes", "Space free on the filesystem",
+				float64(disk.Free)*mbyte, labels...)
+		}
+		for _, health := range dm.DiskHealth {
+			labels := []string{"disk", health.Disk, "model", health.Model,
+				"serial", health.Serial}
+			om.gauge("eve_disk_health_state",
+				"Health of the disk: 1 good, 2 warning, 3 failing",
+				float64(health.State), labels...)
+			if health.Temperature != 0 {
+				om.gauge("eve_disk_temperature_celsius", "Temperature of the disk",
+					float64(health.Temperature), labels...)
+			}
+			om.gauge("eve_disk_power_on_hours", "Hours the disk was powered on",
+				float64(health.PowerOnHours), labels...)
+			for _, counter := range health.Counters {
+				om.gauge("eve_disk_health_counter",
+					"Error or wear counter of the disk",
+					float64(counter.Value),
+					append(labels, "counter", counter.Name)...)
+			}
+		}
+		for _, sensor := range dm.Sensors {
+			typ := types.SensorType(sensor.Type)
+			labels := []string{"source", sensor.Source, "name", sensor.Name,
+				"type", typ.String(), "unit", typ.Unit()}
+			om.gauge("eve_sensor_value", "Value read from the hardware sensor",
+				sensor.Value, labels...)
+			if sensor.Critical != 0 {
+				om.gauge("eve_sensor_critical", "Critical limit of the hardware sensor",
+					sensor.Critical, labels...)
+			}
+			alarm := 0.0
+			if sensor.Alarm {
+				alarm = 1
+			}
+			om.gauge("eve_sensor_alarm", "Alarm flagged by the hardware sensor",
+				alarm, labels...)
+		}
+		for _, zm := range dm.Zedcloud {
+			om.counter("eve_controller_failures", "Failed requests to the controller",
+				zm.Failures, "interface", zm.IfName)
+			om.counter("eve_controller_successes", "Successful requests to the controller",
+				zm.Success, "interface", zm.IfName)
+			for _, um := range zm.UrlMetrics {
+				labels := []string{"interface", zm.IfName, "url", um.Url}
+				om.counter("eve_controller_sent_messages", "Messages sent to the URL",
+					uint64(um.SentMsgCount), labels...)
+				om.counter("eve_controller_sent_bytes", "Bytes sent to the URL",
+					uint64(um.SentByteCount), labels...)
+				om.counter("eve_controller_received_messages", "Messages received from the URL",
+					uint64(um.RecvMsgCount), labels...)
+				om.counter("eve_controller_received_bytes", "Bytes received from the URL",
+					uint64(um.RecvByteCount), labels...)
+			}
+		}
+		for _, cm := range dm.Cipher {
+			om.counter("eve_cipher_failures", "Failed object decryptions",
+				cm.FailureCount, "agent", cm.AgentName)
+			om.counter("eve_cipher_successes", "Successful object decryptions",
+				cm.SuccessCount, "agent", cm.AgentName)
+			for _, tc := range cm.Tc {
+				om.counter("eve_cipher_errors", "Object decryption errors by type",
+					tc.Count, "agent", cm.AgentName, "error", tc.ErrorCode.String())
+			}
+		}
+		if nl := dm.GetNewlog(); nl != nil {
+			failed := 0.0
+			if nl.FailedToSend {
+				failed = 1
+			}
+			om.gauge("eve_log_upload_failing", "Whether the log upload fails", failed)
+			om.counter("eve_log_uploaded_bytes", "Bytes of logs uploaded",
+				nl.TotalBytesUpload)
+			om.counter("eve_log_gzip_files_removed", "Log files removed before upload",
+				uint64(nl.GzipFilesRemoved))
+			for i, lm := range []*metrics.LogfileMetrics{nl.DeviceMetrics, nl.AppMetrics} {
+				if lm == nil {
+					continue
+				}
+				source := []string{"device", "app"}[i]
+				om.counter("eve_log_input_events", "Log events received",
+					lm.NumInputEvent, "source", source)
+				om.counter("eve_log_sent_files", "Log files uploaded",
+					lm.NumGzipFileSent, "source", source)
+				om.gauge("eve_log_pending_files", "Log files waiting for upload",
+					float64(lm.NumGzipFileInDir), "source", source)
+			}
+		}
+	}
+	for _, am := range report.Am {
+		labels := []string{"app_uuid", am.AppID, "app_name", am.AppName}
+		if cpu := am.GetCpu(); cpu != nil {
+			om.counter("eve_app_cpu_seconds", "CPU time used by the app",
+				cpu.Total, labels...)
+		}
+		if mem := am.GetMemory(); mem != nil {
+			om.gauge("eve_app_memory_used_bytes", "Memory used by the app",
+				float64(mem.UsedMem)*mbyte, labels...)
+			om.gauge("eve_app_memory_available_bytes", "Memory available to the app",
+				float64(mem.AvailMem)*mbyte, labels...)
+		}
+		for _, nm := range am.Network {
+			addNetworkMetric(om, "eve_app_network", nm, append(labels,
+				"interface", nm.IName,
+				"network_instance", vifNetworkInstance[nm.LocalName])...)
+		}
+		for _, disk := range am.Disk {
+			diskLabels := append(labels, "disk", disk.Disk)
+			om.gauge("eve_app_disk_size_bytes", "Provisioned size of the app disk",
+				float64(disk.Provisioned)*mbyte, diskLabels...)
+			om.gauge("eve_app_disk_used_bytes", "Space used on the app disk",
+				float64(disk.Used)*mbyte, diskLabels...)
+		}
+	}
+	for _, nm := range report.Nm {
+		stats := nm.GetNetworkStats()
+		if stats == nil || stats.Rx == nil || stats.Tx == nil {
+			continue
+		}
+		labels := []string{"network_instance", nm.Displayname,
+			"network_instance_uuid", nm.NetworkID}
+		om.counter("eve_network_instance_tx_bytes", "Bytes transmitted on the bridge",
+			stats.Tx.TotalBytes, labels...)
+		om.counter("eve_network_instance_rx_bytes", "Bytes received on the bridge",
+			stats.Rx.TotalBytes, labels...)
+		om.counter("eve_network_instance_tx_packets", "Packets transmitted on the bridge",
+			stats.Tx.TotalPackets, labels...)
+		om.counter("eve_network_instance_rx_packets", "Packets received on the bridge",
+			stats.Rx.TotalPackets, labels...)
+		om.counter("eve_network_instance_tx_drops", "Transmitted packets dropped on the bridge",
+			stats.Tx.Drops, labels...)
+		om.counter("eve_network_instance_rx_drops", "Received packets dropped on the bridge",
+			stats.Rx.Drops, labels...)
+		om.counter("eve_network_instance_tx_errors", "Transmit errors on the bridge",
+			stats.Tx.Errors, labels...)
+		om.counter("eve_network_instance_rx_errors", "Receive errors on the bridge",
+			stats.Rx.Errors, labels...)
+	}
+	return om.bytes()
+}
+
+// setPrometheusMetrics replaces the metrics served by the endpoint
+func setPrometheusMetrics(report *metrics.ZMetricMsg,
+	vifNetworkInstance map[string]string) {
+
+	promServer.Lock()
+	enabled := promServer.server != nil
+	promServer.Unlock()
+	if !enabled {
+		return
+	}
+	buf := renderOpenMetrics(report, vifNetworkInstance)
+	promServer.Lock()
+	promServer.metrics = buf
+	promServer.Unlock()
+}
+
+func (s *prometheusServer) allowed(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	s.Lock()
+	defer s.Unlock()
+	if len(s.allow) == 0 {
+		return true
+	}
+	for _, prefix := range s.allow {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *prometheusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/metrics" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowed(r.RemoteAddr) {
+		log.Warnf("prometheus: rejected request from %s", r.RemoteAddr)
+		http.Error(w, http.StatusText(http.StatusForbidden),
+			http.StatusForbidden)
+		return
+	}
+	s.Lock()
+	buf := s.metrics
+	s.Unlock()
+	if buf == nil {
+		http.Error(w, "metrics not yet collected",
+			http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", openMetricsContentType)
+	w.Write(buf)
+}
+
+// prometheusListenAddr returns the address to listen on. nim only lets
+// the requests in through the interface or from the allowed prefixes,
+// hence without either the endpoint is only for the local scrapers.
+func prometheusListenAddr(port uint32, ifname string, allow []*net.IPNet) string {
+	host := ""
+	if ifname == "" && len(allow) == 0 {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// updatePrometheusServer starts, stops or restarts the endpoint
+// following the global config
+func updatePrometheusServer(ctx *zedagentContext) {
+	port := ctx.globalConfig.GlobalValueInt(types.PrometheusPort)
+	useTLS := ctx.globalConfig.GlobalValueBool(types.PrometheusTLS)
+	ifname := ctx.globalConfig.GlobalValueString(types.PrometheusInterface)
+	allow, err := types.ParsePrefixList(
+		ctx.globalConfig.GlobalValueString(types.PrometheusAllow))
+	if err != nil {
+		log.Errorf("updatePrometheusServer: %s: %v", types.PrometheusAllow, err)
+	}
+	addr := ""
+	if port != 0 {
+		addr = prometheusListenAddr(port, ifname, allow)
+	}
+
+	promServer.Lock()
+	promServer.allow = allow
+	server := promServer.server
+	if server != nil && promServer.addr == addr && promServer.tls == useTLS {
+		promServer.Unlock()
+		return
+	}
+	promServer.server = nil
+	promServer.metrics = nil
+	promServer.Unlock()
+
+	if server != nil {
+		log.Noticef("updatePrometheusServer: stopping on %s",
+			promServer.addr)
+		server.Close()
+	}
+	promServer.addr = addr
+	promServer.tls = useTLS
+	if addr == "" {
+		return
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Errorf("updatePrometheusServer: %v", err)
+		return
+	}
+	if useTLS {
+		cert, err := zedcloud.GetClientCert()
+		if err != nil {
+			log.Errorf("updatePrometheusServer: device certificate: %v", err)
+			listener.Close()
+			return
+		}
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+	server = &http.Server{
+		Handler:      &promServer,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	promServer.Lock()
+	promServer.server = server
+	promServer.Unlock()
+	log.Noticef("updatePrometheusServer: serving on %s, TLS %t",
+		addr, useTLS)
+	go func() {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.Errorf("updatePrometheusServer: %v", err)
+		}
+	}()
+}
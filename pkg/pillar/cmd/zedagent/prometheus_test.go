@@ -0,0 +1,123 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedagent
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lf-edge/eve/api/go/metrics"
+)
+
+func TestRenderOpenMetrics(t *testing.T) {
+	report := &metrics.ZMetricMsg{
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedMem: 100, AvailMem: 900},
+			Cipher: []*metrics.CipherMetric{
+				{AgentName: "zedagent", SuccessCount: 3},
+			},
+		}},
+		Am: []*metrics.AppMetric{
+			{
+				AppID:   "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+				AppName: `my "app"`,
+				Network: []*metrics.NetworkMetric{
+					{IName: "eth0", LocalName: "nbu1x1", TxBytes: 10,
+						RxBytes: 20, TxDrops: 1, TxAclDrops: 2},
+				},
+			},
+		},
+	}
+	expected := `# TYPE eve_memory_used_bytes gauge
+# HELP eve_memory_used_bytes Memory used by the device
+eve_memory_used_bytes 104857600
+# TYPE eve_memory_available_bytes gauge
+# HELP eve_memory_available_bytes Memory available on the device
+eve_memory_available_bytes 943718400
+# TYPE eve_cipher_failures counter
+# HELP eve_cipher_failures Failed object decryptions
+eve_cipher_failures_total{agent="zedagent"} 0
+# TYPE eve_cipher_successes counter
+# HELP eve_cipher_successes Successful object decryptions
+eve_cipher_successes_total{agent="zedagent"} 3
+# TYPE eve_app_network_tx_bytes counter
+# HELP eve_app_network_tx_bytes Bytes transmitted
+eve_app_network_tx_bytes_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 10
+# TYPE eve_app_network_rx_bytes counter
+# HELP eve_app_network_rx_bytes Bytes received
+eve_app_network_rx_bytes_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 20
+# TYPE eve_app_network_tx_packets counter
+# HELP eve_app_network_tx_packets Packets transmitted
+eve_app_network_tx_packets_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 0
+# TYPE eve_app_network_rx_packets counter
+# HELP eve_app_network_rx_packets Packets received
+eve_app_network_rx_packets_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 0
+# TYPE eve_app_network_tx_drops counter
+# HELP eve_app_network_tx_drops Transmitted packets dropped
+eve_app_network_tx_drops_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 3
+# TYPE eve_app_network_rx_drops counter
+# HELP eve_app_network_rx_drops Received packets dropped
+eve_app_network_rx_drops_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 0
+# TYPE eve_app_network_tx_errors counter
+# HELP eve_app_network_tx_errors Transmit errors
+eve_app_network_tx_errors_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 0
+# TYPE eve_app_network_rx_errors counter
+# HELP eve_app_network_rx_errors Receive errors
+eve_app_network_rx_errors_total{app_uuid="6ba7b810-9dad-11d1-80b4-00c04fd430c8",app_name="my \"app\"",interface="eth0",network_instance="local"} 0
+# EOF
+`
+	buf := renderOpenMetrics(report, map[string]string{"nbu1x1": "local"})
+	if string(buf) != expected {
+		t.Errorf("got\n%s\nexpected\n%s", buf, expected)
+	}
+}
+
+func TestPrometheusAllowed(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("10.1.0.0/16")
+	s := prometheusServer{}
+	if !s.allowed("192.168.1.1:4000") {
+		t.Error("rejected without an allow list")
+	}
+	s.allow = []*net.IPNet{prefix}
+	testMatrix := map[string]bool{
+		"10.1.2.3:4000":      true,
+		"10.2.2.3:4000":      false,
+		"[2001:db8::1]:4000": false,
+		"garbage":            false,
+	}
+	for remoteAddr, expected := range testMatrix {
+		if allowed := s.allowed(remoteAddr); allowed != expected {
+			t.Errorf("allowed(%s) = %t, expected %t",
+				remoteAddr, allowed, expected)
+		}
+	}
+}
+
+func TestPrometheusListenAddr(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("10.1.0.0/16")
+	testMatrix := map[string]struct {
+		ifname   string
+		allow    []*net.IPNet
+		expected string
+	}{
+		"Local only": {
+			expected: "127.0.0.1:9100",
+		},
+		"Interface": {
+			ifname:   "eth0",
+			expected: ":9100",
+		},
+		"Allow list": {
+			allow:    []*net.IPNet{prefix},
+			expected: ":9100",
+		},
+	}
+	for testname, test := range testMatrix {
+		addr := prometheusListenAddr(9100, test.ifname, test.allow)
+		if addr != test.expected {
+			t.Errorf("%s: listen address %s, expected %s",
+				testname, addr, test.expected)
+		}
+	}
+}
@@ -480,6 +480,10 @@ func PublishDeviceInfoToZedCloud(ctx *zedagentContext) {
 	// Watchdog
 	ReportDeviceInfo.HardwareWatchdogPresent = getHarwareWatchdogPresent(ctx)
 
+	// Firing alerts
+	ReportDeviceInfo.Alerts = getAlertsInfo(ctx)
+	ReportDeviceInfo.DiagBundle = getDiagBundleInfo(ctx)
+
 	ReportInfo.InfoContent = new(info.ZInfoMsg_Dinfo)
 	if x, ok := ReportInfo.GetInfoContent().(*info.ZInfoMsg_Dinfo); ok {
 		x.Dinfo = ReportDeviceInfo
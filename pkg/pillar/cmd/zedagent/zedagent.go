@@ -109,6 +109,8 @@ type zedagentContext struct {
 	subAppContainerMetrics    pubsub.Subscription
 	subDiskMetric             pubsub.Subscription
 	subAppDiskMetric          pubsub.Subscription
+	subSensorMetric           pubsub.Subscription
+	subDiskHealthStatus       pubsub.Subscription
 	rebootCmd                 bool
 	rebootCmdDeferred         bool
 	deviceReboot              bool
@@ -145,6 +147,14 @@ type zedagentContext struct {
 
 	// Track the counter from force.fallback.counter to detect changes
 	forceFallbackCounter int
+
+	// Firing alerts, see alerts.go
+	pubAlertStatus pubsub.Publication
+
+	// Diagnostic bundles requested by the controller, see diagbundle.go
+	pubDiagBundleConfig pubsub.Publication
+	subDiagBundleStatus pubsub.Subscription
+	diagBundle          diagBundleState
 }
 
 var debug = false
@@ -291,6 +301,7 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 
 	// We know our own UUID; prepare for communication with controller
 	zedcloudCtx = handleConfigInit(zedagentCtx.globalConfig.GlobalValueInt(types.NetworkSendTimeout))
+	updatePrometheusServer(&zedagentCtx)
 
 	// Timer for deferred sends of info messages
 	deferredChan := zedcloud.GetDeferredChan(zedcloudCtx)
@@ -313,6 +324,14 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	zedagentCtx.subAssignableAdapters = subAssignableAdapters
 	subAssignableAdapters.Activate()
 
+	zedagentCtx.pubAlertStatus, err = ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.AlertStatus{},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	pubPhysicalIOAdapters, err := ps.NewPublication(pubsub.PublicationOptions{
 		AgentName: agentName,
 		TopicType: types.PhysicalIOAdapterList{},
@@ -873,6 +892,62 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	zedagentCtx.subAppDiskMetric = subAppDiskMetric
 	subAppDiskMetric.Activate()
 
+	subSensorMetric, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		AgentName:   "domainmgr",
+		MyAgentName: agentName,
+		TopicImpl:   types.SensorMetric{},
+		Activate:    false,
+		Ctx:         &zedagentCtx,
+		WarningTime: warningTime,
+		ErrorTime:   errorTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	zedagentCtx.subSensorMetric = subSensorMetric
+	subSensorMetric.Activate()
+
+	subDiskHealthStatus, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		AgentName:   "volumemgr",
+		MyAgentName: agentName,
+		TopicImpl:   types.DiskHealthStatus{},
+		Activate:    false,
+		Ctx:         &zedagentCtx,
+		WarningTime: warningTime,
+		ErrorTime:   errorTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	zedagentCtx.subDiskHealthStatus = subDiskHealthStatus
+	subDiskHealthStatus.Activate()
+
+	zedagentCtx.pubDiagBundleConfig, err = ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.DiagBundleConfig{},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	subDiagBundleStatus, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		AgentName:     "downloader",
+		MyAgentName:   agentName,
+		TopicImpl:     types.DiagBundleStatus{},
+		Activate:      false,
+		Ctx:           &zedagentCtx,
+		CreateHandler: handleDiagBundleStatusCreate,
+		ModifyHandler: handleDiagBundleStatusModify,
+		DeleteHandler: handleDiagBundleStatusDelete,
+		WarningTime:   warningTime,
+		ErrorTime:     errorTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	zedagentCtx.subDiagBundleStatus = subDiagBundleStatus
+	subDiagBundleStatus.Activate()
+
 	//initialize cipher processing block
 	cipherModuleInitialize(&zedagentCtx, ps)
 
@@ -1248,6 +1323,15 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 		case change := <-subAppDiskMetric.MsgChan():
 			subAppDiskMetric.ProcessChange(change)
 
+		case change := <-subSensorMetric.MsgChan():
+			subSensorMetric.ProcessChange(change)
+
+		case change := <-subDiskHealthStatus.MsgChan():
+			subDiskHealthStatus.ProcessChange(change)
+
+		case change := <-subDiagBundleStatus.MsgChan():
+			subDiagBundleStatus.ProcessChange(change)
+
 		case <-stillRunning.C:
 			// Fault injection
 			if fatalFlag {
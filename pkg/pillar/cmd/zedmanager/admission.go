@@ -0,0 +1,309 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Admission control of app instances. Before an app instance is booted we
+// check that the memory, the exclusive CPUs and the adapters it needs are
+// not used by the app instances which are booted or being booted. If they
+// are not available the app instance waits in AWAITRESOURCES with the
+// reason in PendingReason, and is retried when an app instance halts or
+// the device resources change.
+// A critical app instance can preempt best-effort ones, i.e., halt them
+// to free memory and CPUs. The preempted app instances are booted again
+// once there are enough resources. Adapters are reserved by domainmgr for
+// deployed app instances, hence are not freed by halting.
+// Disk space is reserved by volumemgr when the volumes are created; an
+// app instance whose volumes do not fit waits in AWAITRESOURCES as well.
+
+package zedmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// holdsResources returns true if the app instance uses, or will soon use,
+// memory and CPUs
+func holdsResources(ctx *zedmanagerContext, key string) bool {
+	if _, ok := ctx.preemptors[key]; ok {
+		return true
+	}
+	return domainActive(ctx, key)
+}
+
+// checkResources returns what the app instance is missing to boot,
+// ignoring the app instances in released. preemptable is false if
+// something is missing which halting app instances does not free.
+func checkResources(ctx *zedmanagerContext, config types.AppInstanceConfig,
+	released map[string]bool) (reasons []string, preemptable bool, err error) {
+
+	hostMemory, err := getHostMemory(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := config.Key()
+	holds := func(k string) bool {
+		return k != key && !released[k] && holdsResources(ctx, k)
+	}
+	preemptable = true
+	if !ctx.globalConfig.GlobalValueBool(types.IgnoreMemoryCheckForApps) {
+		remaining := getRemainingMemory(ctx, hostMemory, holds)
+		needed := appMemorySize(config.FixedResources)
+		if remaining < needed {
+			reasons = append(reasons,
+				fmt.Sprintf("memory: needs %d MB, %d MB available",
+					needed>>20, remaining>>20))
+		}
+	}
+	vcpus := config.FixedResources.VCpus
+	if vcpus > int(hostMemory.Ncpus) {
+		reasons = append(reasons,
+			fmt.Sprintf("CPUs: needs %d, device has %d",
+				vcpus, hostMemory.Ncpus))
+		preemptable = false
+	} else if config.FixedResources.ExclusiveCPUs {
+		remaining := getRemainingExclusiveCPUs(ctx, hostMemory, holds)
+		if remaining < vcpus {
+			reasons = append(reasons,
+				fmt.Sprintf("exclusive CPUs: needs %d, %d available",
+					vcpus, remaining))
+		}
+	}
+	if adapterReasons := checkAdapters(ctx, config); len(adapterReasons) != 0 {
+		reasons = append(reasons, adapterReasons...)
+		preemptable = false
+	}
+	return reasons, preemptable, nil
+}
+
+// getRemainingExclusiveCPUs returns how many CPUs can still be dedicated to
+// an app instance. domainmgr keeps the first CPU for EVE, and allocates
+// whole cores hence might still fail when the cores have hyperthreads.
+func getRemainingExclusiveCPUs(ctx *zedmanagerContext,
+	hostMemory types.HostMemory, holds func(key string) bool) int {
+
+	remaining := int(hostMemory.Ncpus) - 1
+	for _, st := range ctx.pubAppInstanceStatus.GetAll() {
+		status := st.(types.AppInstanceStatus)
+		if !status.FixedResources.ExclusiveCPUs || !holds(status.Key()) {
+			continue
+		}
+		remaining -= status.FixedResources.VCpus
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// checkAdapters returns why the adapters of the app instance can not be
+// assigned to it
+func checkAdapters(ctx *zedmanagerContext, config types.AppInstanceConfig) []string {
+	var reasons []string
+	aa := ctx.assignableAdapters
+	if !aa.Initialized {
+		// domainmgr checks them once it knows them
+		return nil
+	}
+	for _, adapter := range config.IoAdapterList {
+		list := aa.LookupIoBundleAny(adapter.Name)
+		if len(list) == 0 {
+			reasons = append(reasons,
+				fmt.Sprintf("adapter %s: not found", adapter.Name))
+			continue
+		}
+		for _, ib := range list {
+			if ib.IsPort {
+				reasons = append(reasons,
+					fmt.Sprintf("adapter %s: %s is used by EVE",
+						adapter.Name, ib.Phylabel))
+				break
+			}
+			if !uuid.Equal(ib.UsedByUUID, uuid.Nil) &&
+				!uuid.Equal(ib.UsedByUUID, config.UUIDandVersion.UUID) {
+				reasons = append(reasons,
+					fmt.Sprintf("adapter %s: used by %s", adapter.Name,
+						dependencyName(ctx, ib.UsedByUUID)))
+				break
+			}
+		}
+	}
+	return reasons
+}
+
+// findPreemptionVictims returns the best-effort app instances to halt so
+// that the app instance can boot, or nil if that is not enough
+func findPreemptionVictims(ctx *zedmanagerContext,
+	config types.AppInstanceConfig) []types.AppInstanceConfig {
+
+	var candidates []types.AppInstanceConfig
+	for _, c := range ctx.subAppInstanceConfig.GetAll() {
+		candidate := c.(types.AppInstanceConfig)
+		key := candidate.Key()
+		if candidate.Priority != types.AppPriorityBestEffort ||
+			key == config.Key() || !holdsResources(ctx, key) {
+			continue
+		}
+		if _, ok := ctx.preemptedBy[key]; ok {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	// Halt as few app instances as we can
+	sort.Slice(candidates, func(i, j int) bool {
+		mi := appMemorySize(candidates[i].FixedResources)
+		mj := appMemorySize(candidates[j].FixedResources)
+		if mi != mj {
+			return mi > mj
+		}
+		return candidates[i].DisplayName < candidates[j].DisplayName
+	})
+	fits := func(released map[string]bool) bool {
+		reasons, _, err := checkResources(ctx, config, released)
+		return err == nil && len(reasons) == 0
+	}
+	released := make(map[string]bool)
+	var victims []types.AppInstanceConfig
+	for _, candidate := range candidates {
+		released[candidate.Key()] = true
+		victims = append(victims, candidate)
+		if fits(released) {
+			break
+		}
+	}
+	if !fits(released) {
+		return nil
+	}
+	// Keep the ones which are not needed, e.g., without exclusive CPUs
+	var needed []types.AppInstanceConfig
+	for i := len(victims) - 1; i >= 0; i-- {
+		key := victims[i].Key()
+		delete(released, key)
+		if fits(released) {
+			continue
+		}
+		released[key] = true
+		needed = append(needed, victims[i])
+	}
+	return needed
+}
+
+// admitAppInstance returns why the app instance can not boot yet, or an
+// empty string if it can. A critical app instance preempts best-effort
+// ones if that frees enough resources.
+func admitAppInstance(ctx *zedmanagerContext, config types.AppInstanceConfig,
+	status *types.AppInstanceStatus) (string, error) {
+
+	key := config.Key()
+	reasons, preemptable, err := checkResources(ctx, config, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(reasons) == 0 {
+		if victims, ok := ctx.preemptors[key]; ok {
+			log.Noticef("admitAppInstance(%s) admitted after preempting %v",
+				key, victims)
+			delete(ctx.preemptors, key)
+		}
+		if status.PreemptedBy != "" {
+			log.Noticef("admitAppInstance(%s) no longer preempted by %s",
+				key, status.PreemptedBy)
+			delete(ctx.preemptedBy, key)
+			status.PreemptedBy = ""
+		}
+		return "", nil
+	}
+	reason := "waiting for resources: " + strings.Join(reasons, ", ")
+	if status.PreemptedBy != "" {
+		reason = fmt.Sprintf("preempted by %s; %s", status.PreemptedBy,
+			reason)
+	}
+	if config.Priority != types.AppPriorityCritical || !preemptable {
+		return reason, nil
+	}
+	// Wait for the ones we preempted to halt
+	var halting []string
+	for _, victim := range ctx.preemptors[key] {
+		if holdsResources(ctx, victim) {
+			halting = append(halting, dependencyName(ctx,
+				uuid.FromStringOrNil(victim)))
+		}
+	}
+	if len(halting) != 0 {
+		return fmt.Sprintf("halting %s; %s", strings.Join(halting, ", "),
+			reason), nil
+	}
+	victims := findPreemptionVictims(ctx, config)
+	if len(victims) == 0 {
+		return reason, nil
+	}
+	var keys, names []string
+	for _, victim := range victims {
+		log.Noticef("admitAppInstance(%s) preempting %s %s",
+			key, victim.Key(), victim.DisplayName)
+		ctx.preemptedBy[victim.Key()] = config.DisplayName
+		queueDependencyUpdate(ctx, victim.Key())
+		keys = append(keys, victim.Key())
+		names = append(names, victim.DisplayName)
+	}
+	ctx.preemptors[key] = keys
+	return fmt.Sprintf("halting %s; %s", strings.Join(names, ", "),
+		reason), nil
+}
+
+// updatePreempted sets status.PreemptedBy and returns true if it changed
+func updatePreempted(ctx *zedmanagerContext,
+	status *types.AppInstanceStatus) bool {
+
+	preemptedBy := ctx.preemptedBy[status.Key()]
+	if preemptedBy == status.PreemptedBy {
+		return false
+	}
+	status.PreemptedBy = preemptedBy
+	return true
+}
+
+// removeAdmission is called when the app instance is deleted
+func removeAdmission(ctx *zedmanagerContext, key string) {
+	delete(ctx.preemptors, key)
+	delete(ctx.preemptedBy, key)
+	for preemptor, victims := range ctx.preemptors {
+		for i, victim := range victims {
+			if victim == key {
+				ctx.preemptors[preemptor] = append(victims[:i],
+					victims[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// queueAwaitingResources queues the app instances waiting for resources,
+// the critical ones first
+func queueAwaitingResources(ctx *zedmanagerContext) {
+	var awaiting []types.AppInstanceConfig
+	for _, st := range ctx.pubAppInstanceStatus.GetAll() {
+		status := st.(types.AppInstanceStatus)
+		if status.State != types.AWAITRESOURCES &&
+			(status.PreemptedBy == "" || status.Activated) {
+			continue
+		}
+		config := lookupAppInstanceConfig(ctx, status.Key())
+		if config == nil {
+			continue
+		}
+		awaiting = append(awaiting, *config)
+	}
+	sort.Slice(awaiting, func(i, j int) bool {
+		if awaiting[i].Priority != awaiting[j].Priority {
+			return awaiting[i].Priority == types.AppPriorityCritical
+		}
+		return awaiting[i].DisplayName < awaiting[j].DisplayName
+	})
+	for _, config := range awaiting {
+		queueDependencyUpdate(ctx, config.Key())
+	}
+}
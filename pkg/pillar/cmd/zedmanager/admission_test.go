@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedmanager
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetRemainingResources(t *testing.T) {
+	logger := logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, "zedmanager", 0)
+	ps := pubsub.New(&pubsub.EmptyDriver{}, logger, log)
+	pubAppInstanceStatus, err := ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.AppInstanceStatus{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &zedmanagerContext{
+		pubAppInstanceStatus: pubAppInstanceStatus,
+		globalConfig:         types.DefaultConfigItemValueMap(),
+	}
+	ctx.globalConfig.SetGlobalValueInt(types.EveMemoryLimitInBytes, 512<<20)
+
+	apps := map[string]types.VmConfig{
+		"normal":    {Memory: 1024 * 1024, VCpus: 2},
+		"exclusive": {Memory: 512 * 1024, VCpus: 2, ExclusiveCPUs: true},
+		// Rounded up to 4 pages
+		"hugepages": {Memory: 7 * 1024, VCpus: 1, Hugepages: types.Hugepage2M},
+	}
+	keys := make(map[string]string)
+	for name, vmConfig := range apps {
+		status := types.AppInstanceStatus{
+			UUIDandVersion: types.UUIDandVersion{UUID: uuid.NewV4()},
+			DisplayName:    name,
+			FixedResources: vmConfig,
+		}
+		keys[name] = status.Key()
+		pubAppInstanceStatus.Publish(status.Key(), status)
+	}
+	hostMemory := types.HostMemory{
+		TotalMemoryMB: 4096,
+		HugepagesMB:   16,
+		Ncpus:         8,
+	}
+
+	testMatrix := map[string]struct {
+		holding          []string
+		expectedMemoryMB uint64
+		expectedCPUs     int
+	}{
+		"None": {
+			// The unused hugepages are not available
+			expectedMemoryMB: 4096 - 512 - 16,
+			expectedCPUs:     7,
+		},
+		"All": {
+			holding:          []string{"normal", "exclusive", "hugepages"},
+			expectedMemoryMB: 4096 - 512 - 1024 - 512 - 16,
+			expectedCPUs:     5,
+		},
+		"Only hugepages": {
+			holding:          []string{"hugepages"},
+			expectedMemoryMB: 4096 - 512 - 16,
+			expectedCPUs:     7,
+		},
+		"Only exclusive": {
+			holding:          []string{"exclusive"},
+			expectedMemoryMB: 4096 - 512 - 512 - 16,
+			expectedCPUs:     5,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		holding := make(map[string]bool)
+		for _, name := range test.holding {
+			holding[keys[name]] = true
+		}
+		holds := func(key string) bool {
+			return holding[key]
+		}
+		memory := getRemainingMemory(ctx, hostMemory, holds)
+		if memory != test.expectedMemoryMB<<20 {
+			t.Errorf("%s: memory %d MB, expected %d MB", testname,
+				memory>>20, test.expectedMemoryMB)
+		}
+		cpus := getRemainingExclusiveCPUs(ctx, hostMemory, holds)
+		if cpus != test.expectedCPUs {
+			t.Errorf("%s: exclusive CPUs %d, expected %d", testname,
+				cpus, test.expectedCPUs)
+		}
+	}
+}
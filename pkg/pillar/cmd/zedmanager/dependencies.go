@@ -0,0 +1,249 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Start ordering between app instances. An app instance is booted once
+// all the app instances in its DependsOn are running, and healthy if they
+// have probes. When app instances are halted together, e.g., for a device
+// reboot, an app instance is halted after the ones which depend on it.
+
+package zedmanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+// dependencyState is what we last published for an app instance
+type dependencyState struct {
+	ready     bool
+	activated bool
+	dependsOn []uuid.UUID
+}
+
+// dependencyReady returns true if app instances which depend on this one
+// can be booted
+func dependencyReady(ctx *zedmanagerContext, status types.AppInstanceStatus) bool {
+	if !status.Activated || status.State != types.RUNNING ||
+		status.HasError() {
+		return false
+	}
+	config := lookupAppInstanceConfig(ctx, status.Key())
+	if config == nil || !config.Activate {
+		return false
+	}
+	if len(config.Probes) != 0 {
+		return status.Health.State == types.AppHealthHealthy
+	}
+	return true
+}
+
+// dependencyName returns the DisplayName if we know the app instance
+func dependencyName(ctx *zedmanagerContext, appInstID uuid.UUID) string {
+	config := lookupAppInstanceConfig(ctx, appInstID.String())
+	if config == nil || config.DisplayName == "" {
+		return appInstID.String()
+	}
+	return config.DisplayName
+}
+
+// findDependencyCycle returns the cycle through the app instance, if any.
+// dependencies returns the DependsOn of an app instance.
+func findDependencyCycle(appInstID uuid.UUID,
+	dependencies func(uuid.UUID) []uuid.UUID) []uuid.UUID {
+
+	visited := make(map[uuid.UUID]bool)
+	var path []uuid.UUID
+	var visit func(id uuid.UUID) bool
+	visit = func(id uuid.UUID) bool {
+		path = append(path, id)
+		for _, dep := range dependencies(id) {
+			if uuid.Equal(dep, appInstID) {
+				path = append(path, dep)
+				return true
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if visit(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(appInstID) {
+		return path
+	}
+	return nil
+}
+
+// checkDependencies returns the app instances we are waiting for, and an
+// error if we can not boot due to the dependencies
+func checkDependencies(ctx *zedmanagerContext,
+	config types.AppInstanceConfig) ([]string, error) {
+
+	dependencies := func(id uuid.UUID) []uuid.UUID {
+		if c := lookupAppInstanceConfig(ctx, id.String()); c != nil {
+			return c.DependsOn
+		}
+		return nil
+	}
+	cycle := findDependencyCycle(config.UUIDandVersion.UUID, dependencies)
+	if cycle != nil {
+		var names []string
+		for _, id := range cycle {
+			names = append(names, dependencyName(ctx, id))
+		}
+		return nil, fmt.Errorf("dependency cycle: %s",
+			strings.Join(names, " -> "))
+	}
+	var waiting []string
+	for _, dep := range config.DependsOn {
+		depConfig := lookupAppInstanceConfig(ctx, dep.String())
+		if depConfig == nil {
+			return nil, fmt.Errorf("depends on unknown app instance %s",
+				dep.String())
+		}
+		if !depConfig.Activate {
+			return nil, fmt.Errorf("depends on %s which is not activated",
+				depConfig.DisplayName)
+		}
+		depStatus := lookupAppInstanceStatus(ctx, dep.String())
+		if depStatus == nil || !dependencyReady(ctx, *depStatus) {
+			waiting = append(waiting, depConfig.DisplayName)
+		}
+	}
+	return waiting, nil
+}
+
+// haltingDependents returns the app instances which depend on this one
+// and are being halted but are not yet halted
+func haltingDependents(ctx *zedmanagerContext, appInstID uuid.UUID) []string {
+	var dependents []string
+	for _, c := range ctx.subAppInstanceConfig.GetAll() {
+		config := c.(types.AppInstanceConfig)
+		if config.Activate || !dependsOn(config.DependsOn, appInstID) {
+			continue
+		}
+		if domainActive(ctx, config.Key()) {
+			dependents = append(dependents, config.DisplayName)
+		}
+	}
+	// App instances being deleted no longer have a config
+	for key, state := range ctx.dependencyStates {
+		if lookupAppInstanceConfig(ctx, key) != nil ||
+			!dependsOn(state.dependsOn, appInstID) {
+			continue
+		}
+		if domainActive(ctx, key) {
+			dependents = append(dependents, key)
+		}
+	}
+	return dependents
+}
+
+func domainActive(ctx *zedmanagerContext, key string) bool {
+	dc := lookupDomainConfig(ctx, key)
+	ds := lookupDomainStatus(ctx, key)
+	return (dc != nil && dc.Activate) || (ds != nil && ds.Activated)
+}
+
+func dependsOn(dependencies []uuid.UUID, appInstID uuid.UUID) bool {
+	for _, dep := range dependencies {
+		if uuid.Equal(dep, appInstID) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDependencyState is called when the AppInstanceStatus is published
+// or deleted. It updates the app instances waiting for this one to be
+// ready, and those waiting for this one to halt.
+func updateDependencyState(ctx *zedmanagerContext,
+	status types.AppInstanceStatus, deleted bool) {
+
+	key := status.Key()
+	old, exists := ctx.dependencyStates[key]
+	var state dependencyState
+	if !deleted {
+		state.ready = dependencyReady(ctx, status)
+		state.activated = status.Activated
+		if config := lookupAppInstanceConfig(ctx, key); config != nil {
+			state.dependsOn = config.DependsOn
+		} else {
+			state.dependsOn = old.dependsOn
+		}
+		ctx.dependencyStates[key] = state
+	} else {
+		delete(ctx.dependencyStates, key)
+		removeAdmission(ctx, key)
+	}
+	if !exists || deleted || state.ready != old.ready {
+		log.Functionf("updateDependencyState(%s) ready %t", key, state.ready)
+		queueAwaitingDependency(ctx, status.UUIDandVersion.UUID)
+	}
+	if old.activated && !state.activated {
+		log.Functionf("updateDependencyState(%s) halted", key)
+		for _, dep := range old.dependsOn {
+			depConfig := lookupAppInstanceConfig(ctx, dep.String())
+			if depConfig != nil && !depConfig.Activate {
+				queueDependencyUpdate(ctx, dep.String())
+			}
+		}
+	}
+	if deleted || (old.activated && !state.activated) {
+		// It might have freed what others are waiting for
+		queueAwaitingResources(ctx)
+	}
+	processDependencyUpdates(ctx)
+}
+
+// queueAwaitingDependency queues the app instances waiting for this one,
+// or for all dependencies if appInstID is nil
+func queueAwaitingDependency(ctx *zedmanagerContext, appInstID uuid.UUID) {
+	for _, st := range ctx.pubAppInstanceStatus.GetAll() {
+		status := st.(types.AppInstanceStatus)
+		if status.State != types.AWAITDEPENDENCY {
+			continue
+		}
+		config := lookupAppInstanceConfig(ctx, status.Key())
+		if config == nil {
+			continue
+		}
+		if uuid.Equal(appInstID, uuid.Nil) ||
+			dependsOn(config.DependsOn, appInstID) {
+			queueDependencyUpdate(ctx, status.Key())
+		}
+	}
+}
+
+func queueDependencyUpdate(ctx *zedmanagerContext, key string) {
+	for _, k := range ctx.dependencyUpdates {
+		if k == key {
+			return
+		}
+	}
+	ctx.dependencyUpdates = append(ctx.dependencyUpdates, key)
+}
+
+// processDependencyUpdates calls updateAIStatusUUID for the queued app
+// instances. Their updates can queue more which we process in the
+// outermost call.
+func processDependencyUpdates(ctx *zedmanagerContext) {
+	if ctx.updatingDependencies {
+		return
+	}
+	ctx.updatingDependencies = true
+	for len(ctx.dependencyUpdates) != 0 {
+		key := ctx.dependencyUpdates[0]
+		ctx.dependencyUpdates = ctx.dependencyUpdates[1:]
+		log.Functionf("processDependencyUpdates(%s)", key)
+		updateAIStatusUUID(ctx, key)
+	}
+	ctx.updatingDependencies = false
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedmanager
+
+import (
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	a, b, c, d := uuid.NewV4(), uuid.NewV4(), uuid.NewV4(), uuid.NewV4()
+	testMatrix := map[string]struct {
+		dependsOn map[uuid.UUID][]uuid.UUID
+		appInstID uuid.UUID
+		expected  []uuid.UUID
+	}{
+		"No dependencies": {
+			appInstID: a,
+		},
+		"Chain": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {b}, b: {c}},
+			appInstID: a,
+		},
+		"Diamond": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {b, c}, b: {d}, c: {d}},
+			appInstID: a,
+		},
+		"Unknown dependency": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {d}},
+			appInstID: a,
+		},
+		"Cycle of two": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {b}, b: {a}},
+			appInstID: a,
+			expected:  []uuid.UUID{a, b, a},
+		},
+		"Cycle of three": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {d, b}, b: {c}, c: {a}},
+			appInstID: b,
+			expected:  []uuid.UUID{b, c, a, b},
+		},
+		"Cycle not through app": {
+			dependsOn: map[uuid.UUID][]uuid.UUID{a: {b}, b: {c}, c: {b}},
+			appInstID: a,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		cycle := findDependencyCycle(test.appInstID,
+			func(id uuid.UUID) []uuid.UUID {
+				return test.dependsOn[id]
+			})
+		if len(cycle) != len(test.expected) {
+			t.Errorf("%s: cycle %v, expected %v", testname,
+				cycle, test.expected)
+			continue
+		}
+		for i := range cycle {
+			if !uuid.Equal(cycle[i], test.expected[i]) {
+				t.Errorf("%s: cycle %v, expected %v", testname,
+					cycle, test.expected)
+				break
+			}
+		}
+	}
+}
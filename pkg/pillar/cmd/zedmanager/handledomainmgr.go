@@ -6,9 +6,11 @@ package zedmanager
 import (
 	"errors"
 	"fmt"
+	"net"
 	"runtime"
 
 	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
 )
 
 // MaybeAddDomainConfig makes sure we have a DomainConfig
@@ -35,15 +37,21 @@ func MaybeAddDomainConfig(ctx *zedmanagerContext,
 	}
 
 	dc := types.DomainConfig{
-		UUIDandVersion:    aiConfig.UUIDandVersion,
-		DisplayName:       aiConfig.DisplayName,
-		Activate:          aiConfig.Activate,
-		AppNum:            AppNum,
-		VmConfig:          aiConfig.FixedResources,
-		IoAdapterList:     aiConfig.IoAdapterList,
-		CloudInitUserData: aiConfig.CloudInitUserData,
-		CipherBlockStatus: aiConfig.CipherBlockStatus,
-		GPUConfig:         "legacy",
+		UUIDandVersion:      aiConfig.UUIDandVersion,
+		DisplayName:         aiConfig.DisplayName,
+		Activate:            aiConfig.Activate,
+		AppNum:              AppNum,
+		VmConfig:            aiConfig.FixedResources,
+		IoAdapterList:       aiConfig.IoAdapterList,
+		CloudInitUserData:   aiConfig.CloudInitUserData,
+		CipherBlockStatus:   aiConfig.CipherBlockStatus,
+		CloudInitVendorData: aiConfig.CloudInitVendorData,
+		CloudInitNoCloud:    aiConfig.CloudInitNoCloud,
+		GPUConfig:           "legacy",
+		DependsOn:           aiConfig.DependsOn,
+		UsbDevices:          aiConfig.UsbDevices,
+		Containers:          aiConfig.Containers,
+		Trace:               aiConfig.Trace,
 	}
 
 	dc.DiskConfigList = make([]types.DiskConfig, 0, len(aiStatus.VolumeRefStatusList))
@@ -66,6 +74,12 @@ func MaybeAddDomainConfig(ctx *zedmanagerContext,
 		disk.Format = vrs.ContentFormat
 		disk.MountDir = vrs.MountDir
 		disk.DisplayName = vrs.DisplayName
+		for _, container := range aiConfig.Containers {
+			if uuid.Equal(container.Image, vrc.VolumeID) {
+				disk.Container = container.Name
+				break
+			}
+		}
 		dc.DiskConfigList = append(dc.DiskConfigList, disk)
 	}
 	// let's fill some of the default values (arguably we may want controller
@@ -108,16 +122,50 @@ func MaybeAddDomainConfig(ctx *zedmanagerContext,
 
 		dc.VifList = make([]types.VifInfo, ulNum)
 		// Put UL before OL
+		dc.GuestNetworks = make([]types.GuestNetwork, ulNum)
 		for i, ul := range ns.UnderlayNetworkList {
 			dc.VifList[i] = ul.VifInfo
+			dc.GuestNetworks[i] = types.GuestNetwork{
+				Name:          ul.Name,
+				Mac:           ul.Mac,
+				GuestIPConfig: ul.GuestIP,
+			}
 		}
 	}
+	dc.RestartPolicy = aiConfig.RestartPolicy
+	dc.Probes = make([]types.AppProbe, len(aiConfig.Probes))
+	for i, probe := range aiConfig.Probes {
+		switch probe.Type {
+		case types.AppProbeHTTP, types.AppProbeTCP:
+			probe.IPAddr = lookupProbeIPAddr(probe, ns)
+		}
+		dc.Probes[i] = probe
+	}
 	publishDomainConfig(ctx, &dc)
 
 	log.Functionf("MaybeAddDomainConfig done for %s", key)
 	return nil
 }
 
+// lookupProbeIPAddr returns the IP address of the network adapter of the
+// probe, or of the first one with an IP address if the probe does not
+// name one. Returns nil if zedrouter does not know the address yet.
+func lookupProbeIPAddr(probe types.AppProbe, ns *types.AppNetworkStatus) net.IP {
+	if ns == nil {
+		return nil
+	}
+	for _, ul := range ns.UnderlayNetworkList {
+		if probe.NetworkAdapter != "" && ul.Name != probe.NetworkAdapter {
+			continue
+		}
+		ip := net.ParseIP(ul.AllocatedIPAddr)
+		if ip != nil || probe.NetworkAdapter != "" {
+			return ip
+		}
+	}
+	return nil
+}
+
 func lookupDomainConfig(ctx *zedmanagerContext, key string) *types.DomainConfig {
 
 	pub := ctx.pubDomainConfig
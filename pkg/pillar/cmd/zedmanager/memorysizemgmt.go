@@ -9,34 +9,57 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/types"
 )
 
-// getRemainingMemory returns how many bytes remain for app instance usage
-func getRemainingMemory(ctxPtr *zedmanagerContext) (uint64, error) {
+// getRemainingMemory returns how many bytes remain for app instance usage.
+// Only the app instances for which holds returns true are counted.
+// The hugepage pool is part of the device memory but can only be used by
+// app instances backed by hugepages. Those are counted in the pool, and
+// what is left in it by the pool being larger is not available to anybody.
+func getRemainingMemory(ctxPtr *zedmanagerContext, hostMemory types.HostMemory,
+	holds func(key string) bool) uint64 {
 
 	var usedMemorySize uint64
+	var hugepagesUsed uint64
 	pubAppInstanceStatus := ctxPtr.pubAppInstanceStatus
 	itemsAppInstanceStatus := pubAppInstanceStatus.GetAll()
 	for _, iterAppInstanceStatusJSON := range itemsAppInstanceStatus {
 		iterAppInstanceStatus := iterAppInstanceStatusJSON.(types.AppInstanceStatus)
-		usedMemorySize += uint64(iterAppInstanceStatus.FixedResources.Memory) << 10
+		if !holds(iterAppInstanceStatus.Key()) {
+			continue
+		}
+		memory := appMemorySize(iterAppInstanceStatus.FixedResources)
+		usedMemorySize += memory
+		if iterAppInstanceStatus.FixedResources.Hugepages.Bytes() != 0 {
+			hugepagesUsed += memory
+		}
 	}
 	memoryReservedForEve := ctxPtr.globalConfig.GlobalValueInt(types.EveMemoryLimitInBytes)
 	usedMemorySize += uint64(memoryReservedForEve)
-	deviceMemorySize, err := sysTotalMemory(ctxPtr)
-	if err != nil {
-		return 0, err
+	deviceMemorySize := hostMemory.TotalMemoryMB << 20
+	hugepagesSize := hostMemory.HugepagesMB << 20
+	if hugepagesSize > hugepagesUsed {
+		usedMemorySize += hugepagesSize - hugepagesUsed
+	}
+	if usedMemorySize > deviceMemorySize {
+		return 0
 	}
-	return deviceMemorySize - usedMemorySize, nil
+	return deviceMemorySize - usedMemorySize
+}
+
+// appMemorySize returns the bytes used by the app instance including the
+// rounding up to the hugepage size
+func appMemorySize(vmConfig types.VmConfig) uint64 {
+	return vmConfig.Hugepages.RoundUpMemory(vmConfig.Memory)
 }
 
-func sysTotalMemory(ctx *zedmanagerContext) (uint64, error) {
+// getHostMemory returns the memory and CPUs of the device
+func getHostMemory(ctx *zedmanagerContext) (types.HostMemory, error) {
 	sub := ctx.subHostMemory
 	m, err := sub.Get("global")
 	if err != nil {
-		return 0, err
+		return types.HostMemory{}, err
 	}
 	if m != nil {
-		memory := m.(types.HostMemory)
-		return uint64(memory.TotalMemoryMB) << 20, nil
+		return m.(types.HostMemory), nil
 	}
-	return 0, fmt.Errorf("Global host memory is empty")
+	return types.HostMemory{}, fmt.Errorf("Global host memory is empty")
 }
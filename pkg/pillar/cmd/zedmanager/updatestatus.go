@@ -5,8 +5,10 @@ package zedmanager
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/uuidtonum"
 	"github.com/satori/go.uuid"
@@ -129,6 +131,15 @@ func doUpdate(ctx *zedmanagerContext,
 		return changed
 	}
 
+	if updatePreempted(ctx, status) {
+		changed = true
+	}
+	if status.PreemptedBy != "" && config.Activate &&
+		(status.Activated || status.ActivateInprogress) {
+		log.Noticef("Halting %s preempted by %s", uuidStr,
+			status.PreemptedBy)
+		config.Activate = false
+	}
 	if !config.Activate {
 		if status.Activated || status.ActivateInprogress {
 			c := doInactivateHalt(ctx, config, status)
@@ -145,6 +156,8 @@ func doUpdate(ctx *zedmanagerContext,
 			}
 		}
 		log.Functionf("Waiting for config.Activate for %s", uuidStr)
+		// Deployed as far as it goes without Activate
+		tracer.EndRoot(uuidStr, status.Error)
 		return changed
 	}
 	log.Functionf("Have config.Activate for %s", uuidStr)
@@ -254,11 +267,17 @@ func doInstall(ctx *zedmanagerContext,
 	}
 	// Determine minimum state and errors across all of VolumeRefStatus
 	minState := types.MAXSTATE
+	var diskReasons []string
 	for _, vrs := range status.VolumeRefStatusList {
 		if vrs.State < minState {
 			minState = vrs.State
 		}
-		if vrs.HasError() {
+		if vrs.HasError() && vrs.AwaitingDiskSpace {
+			// Not an error; volumemgr retries once there is space
+			diskReasons = append(diskReasons,
+				fmt.Sprintf("disk space for volume %s: %s",
+					vrs.DisplayName, strings.TrimSpace(vrs.Error)))
+		} else if vrs.HasError() {
 			errorSource = vrs.ErrorSourceType
 			errorTime = vrs.ErrorTime
 			allErrors = appendError(allErrors, vrs.Error)
@@ -275,6 +294,21 @@ func doInstall(ctx *zedmanagerContext,
 		changed = true
 	}
 
+	if allErrors == "" && len(diskReasons) != 0 {
+		reason := "waiting for resources: " + strings.Join(diskReasons, ", ")
+		log.Functionf("%s for %s", reason, uuidStr)
+		if status.State < types.BOOTING {
+			status.State = types.AWAITRESOURCES
+		}
+		status.PendingReason = reason
+		status.ClearErrorWithSource()
+		return true, false
+	}
+	if len(diskReasons) == 0 && status.State < types.BOOTING &&
+		strings.HasPrefix(status.PendingReason, "waiting for resources: disk") {
+		status.PendingReason = ""
+		changed = true
+	}
 	if allErrors == "" {
 		status.ClearErrorWithSource()
 	} else if errorSource == nil {
@@ -425,6 +459,59 @@ func doActivate(ctx *zedmanagerContext, uuidStr string,
 	}
 	log.Tracef("Done with AppNetworkStatus for %s", uuidStr)
 
+	// Wait for the app instances we depend on before booting
+	dc := lookupDomainConfig(ctx, uuidStr)
+	if !status.Activated && status.RestartInprogress == types.NotInprogress &&
+		(dc == nil || !dc.Activate) {
+		waiting, err := checkDependencies(ctx, config)
+		if err != nil {
+			log.Errorf("Dependency error for %s: %s", uuidStr, err)
+			status.SetErrorWithSource(err.Error(),
+				types.AppInstanceConfig{}, time.Now())
+			status.State = types.AWAITDEPENDENCY
+			changed = true
+			return changed
+		}
+		if status.IsErrorSource(types.AppInstanceConfig{}) {
+			log.Functionf("Clearing dependency error %s", status.Error)
+			status.ClearErrorWithSource()
+			changed = true
+		}
+		if len(waiting) != 0 {
+			log.Functionf("Waiting for dependencies %v for %s",
+				waiting, uuidStr)
+			status.State = types.AWAITDEPENDENCY
+			status.PendingReason = "waiting for app instances: " +
+				strings.Join(waiting, ", ")
+			changed = true
+			return changed
+		}
+		reason, err := admitAppInstance(ctx, config, status)
+		if err != nil {
+			log.Errorf("Admission error for %s: %s", uuidStr, err)
+			reason = fmt.Sprintf("waiting for resources: %s", err)
+		}
+		if reason != "" {
+			log.Functionf("%s for %s", reason, uuidStr)
+			if status.State != types.AWAITRESOURCES ||
+				status.PendingReason != reason {
+				status.State = types.AWAITRESOURCES
+				status.PendingReason = reason
+				changed = true
+			}
+			return changed
+		}
+		if status.State == types.AWAITDEPENDENCY ||
+			status.State == types.AWAITRESOURCES {
+			status.State = types.INSTALLED
+			changed = true
+		}
+		if status.PendingReason != "" {
+			status.PendingReason = ""
+			changed = true
+		}
+	}
+
 	// Make sure we have a DomainConfig
 	err := MaybeAddDomainConfig(ctx, config, *status, ns)
 	if err != nil {
@@ -454,6 +541,30 @@ func doActivate(ctx *zedmanagerContext, uuidStr string,
 		status.BootTime = ds.BootTime
 		changed = true
 	}
+	if status.Health != ds.Health {
+		log.Functionf("Update health to %s for %s",
+			ds.Health.State, status.Key())
+		status.Health = ds.Health
+		changed = true
+	}
+	if !cmp.Equal(status.UsbDevices, ds.UsbDevices) {
+		log.Functionf("Update USB devices to %v for %s",
+			ds.UsbDevices, status.Key())
+		status.UsbDevices = ds.UsbDevices
+		changed = true
+	}
+	if !cmp.Equal(status.GuestInfo, ds.GuestInfo) {
+		log.Functionf("Update guest info to %+v for %s",
+			ds.GuestInfo, status.Key())
+		status.GuestInfo = ds.GuestInfo
+		changed = true
+	}
+	if !cmp.Equal(status.Containers, ds.Containers) {
+		log.Functionf("Update containers to %+v for %s",
+			ds.Containers, status.Key())
+		status.Containers = ds.Containers
+		changed = true
+	}
 	c := updateVifUsed(status, *ds)
 	if c {
 		changed = true
@@ -829,6 +940,13 @@ func doInactivateHalt(ctx *zedmanagerContext,
 	}
 	log.Tracef("Done with AppNetworkStatus for %s", uuidStr)
 
+	// Halt after the app instances which depend on us
+	if dependents := haltingDependents(ctx, config.UUIDandVersion.UUID); len(dependents) != 0 {
+		log.Functionf("Waiting for dependents %v to halt for %s",
+			dependents, uuidStr)
+		return changed
+	}
+
 	// Make sure we have a DomainConfig. Clears dc.Activate based
 	// on the AppInstanceConfig's Activate
 	err := MaybeAddDomainConfig(ctx, config, *status, ns)
@@ -858,6 +976,30 @@ func doInactivateHalt(ctx *zedmanagerContext,
 		status.BootTime = ds.BootTime
 		changed = true
 	}
+	if status.Health != ds.Health {
+		log.Functionf("Update health to %s for %s",
+			ds.Health.State, status.Key())
+		status.Health = ds.Health
+		changed = true
+	}
+	if !cmp.Equal(status.UsbDevices, ds.UsbDevices) {
+		log.Functionf("Update USB devices to %v for %s",
+			ds.UsbDevices, status.Key())
+		status.UsbDevices = ds.UsbDevices
+		changed = true
+	}
+	if !cmp.Equal(status.GuestInfo, ds.GuestInfo) {
+		log.Functionf("Update guest info to %+v for %s",
+			ds.GuestInfo, status.Key())
+		status.GuestInfo = ds.GuestInfo
+		changed = true
+	}
+	if !cmp.Equal(status.Containers, ds.Containers) {
+		log.Functionf("Update containers to %+v for %s",
+			ds.Containers, status.Key())
+		status.Containers = ds.Containers
+		changed = true
+	}
 	c := updateVifUsed(status, *ds)
 	if c {
 		changed = true
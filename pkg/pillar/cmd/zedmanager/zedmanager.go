@@ -18,8 +18,10 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/base"
 	"github.com/lf-edge/eve/pkg/pillar/pidfile"
 	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/tracing"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/lf-edge/eve/pkg/pillar/uuidtonum"
+	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -35,29 +37,42 @@ var Version = "No version specified"
 
 // State used by handlers
 type zedmanagerContext struct {
-	subAppInstanceConfig pubsub.Subscription
-	pubAppInstanceStatus pubsub.Publication
-	pubVolumeRefConfig   pubsub.Publication
-	subVolumeRefStatus   pubsub.Subscription
-	pubAppNetworkConfig  pubsub.Publication
-	subAppNetworkStatus  pubsub.Subscription
-	pubDomainConfig      pubsub.Publication
-	subDomainStatus      pubsub.Subscription
-	subGlobalConfig      pubsub.Subscription
-	subHostMemory        pubsub.Subscription
-	globalConfig         *types.ConfigItemValueMap
-	pubUuidToNum         pubsub.Publication
-	GCInitialized        bool
+	subAppInstanceConfig  pubsub.Subscription
+	pubAppInstanceStatus  pubsub.Publication
+	pubVolumeRefConfig    pubsub.Publication
+	subVolumeRefStatus    pubsub.Subscription
+	pubAppNetworkConfig   pubsub.Publication
+	subAppNetworkStatus   pubsub.Subscription
+	pubDomainConfig       pubsub.Publication
+	subDomainStatus       pubsub.Subscription
+	subGlobalConfig       pubsub.Subscription
+	subHostMemory         pubsub.Subscription
+	subAssignableAdapters pubsub.Subscription
+	globalConfig          *types.ConfigItemValueMap
+	pubUuidToNum          pubsub.Publication
+	GCInitialized         bool
+
+	// Start ordering between app instances
+	dependencyStates     map[string]dependencyState
+	dependencyUpdates    []string
+	updatingDependencies bool
+
+	// Admission control; see admission.go
+	assignableAdapters *types.AssignableAdapters
+	preemptors         map[string][]string // Key is the critical app
+	preemptedBy        map[string]string   // Value is the critical app name
 }
 
 var debug = false
 var debugOverride bool // From command line arg
 var logger *logrus.Logger
 var log *base.LogObject
+var tracer *tracing.Tracer
 
 func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) int {
 	logger = loggerArg
 	log = logArg
+	tracer = tracing.NewTracer(log, agentName)
 	versionPtr := flag.Bool("v", false, "Version")
 	debugPtr := flag.Bool("d", false, "Debug flag")
 	flag.Parse()
@@ -83,7 +98,11 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 
 	// Any state needed by handler functions
 	ctx := zedmanagerContext{
-		globalConfig: types.DefaultConfigItemValueMap(),
+		globalConfig:       types.DefaultConfigItemValueMap(),
+		dependencyStates:   make(map[string]dependencyState),
+		assignableAdapters: &types.AssignableAdapters{},
+		preemptors:         make(map[string][]string),
+		preemptedBy:        make(map[string]string),
 	}
 	// Create publish before subscribing and activating subscriptions
 	pubAppInstanceStatus, err := ps.NewPublication(pubsub.PublicationOptions{
@@ -237,19 +256,39 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	subDomainStatus.Activate()
 
 	subHostMemory, err := ps.NewSubscription(pubsub.SubscriptionOptions{
-		AgentName:   "domainmgr",
-		MyAgentName: agentName,
-		TopicImpl:   types.HostMemory{},
-		Activate:    true,
-		Ctx:         &ctx,
-		WarningTime: warningTime,
-		ErrorTime:   errorTime,
+		AgentName:     "domainmgr",
+		MyAgentName:   agentName,
+		TopicImpl:     types.HostMemory{},
+		Activate:      true,
+		Ctx:           &ctx,
+		CreateHandler: handleHostMemoryCreate,
+		ModifyHandler: handleHostMemoryModify,
+		WarningTime:   warningTime,
+		ErrorTime:     errorTime,
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
 	ctx.subHostMemory = subHostMemory
 
+	// Look for AssignableAdapters from domainmgr
+	subAssignableAdapters, err := ps.NewSubscription(pubsub.SubscriptionOptions{
+		AgentName:     "domainmgr",
+		MyAgentName:   agentName,
+		TopicImpl:     types.AssignableAdapters{},
+		Activate:      true,
+		Ctx:           &ctx,
+		CreateHandler: handleAACreate,
+		ModifyHandler: handleAAModify,
+		DeleteHandler: handleAADelete,
+		WarningTime:   warningTime,
+		ErrorTime:     errorTime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx.subAssignableAdapters = subAssignableAdapters
+
 	// Pick up debug aka log level before we start real work
 	for !ctx.GCInitialized {
 		log.Functionf("waiting for GCInitialized")
@@ -278,6 +317,9 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 		case change := <-subHostMemory.MsgChan():
 			subHostMemory.ProcessChange(change)
 
+		case change := <-subAssignableAdapters.MsgChan():
+			subAssignableAdapters.ProcessChange(change)
+
 		case change := <-subAppInstanceConfig.MsgChan():
 			subAppInstanceConfig.ProcessChange(change)
 
@@ -328,6 +370,12 @@ func publishAppInstanceStatus(ctx *zedmanagerContext,
 	log.Tracef("publishAppInstanceStatus(%s)", key)
 	pub := ctx.pubAppInstanceStatus
 	pub.Publish(key, *status)
+	updateDependencyState(ctx, *status, false)
+	if status.HasError() {
+		tracer.EndRoot(key, status.Error)
+	} else if status.State == types.RUNNING {
+		tracer.EndRoot(key, "")
+	}
 }
 
 func unpublishAppInstanceStatus(ctx *zedmanagerContext,
@@ -342,6 +390,7 @@ func unpublishAppInstanceStatus(ctx *zedmanagerContext,
 		return
 	}
 	pub.Unpublish(key)
+	updateDependencyState(ctx, *status, true)
 }
 
 func handleAppInstanceConfigDelete(ctxArg interface{}, key string,
@@ -396,7 +445,10 @@ func handleCreate(ctxArg interface{}, key string,
 		DisplayName:    config.DisplayName,
 		FixedResources: config.FixedResources,
 		State:          types.INITIAL,
+		Trace:          config.Trace,
 	}
+	tracer.StartRoot(config.Trace, "deploy", key, "app", config.DisplayName,
+		"version", config.UUIDandVersion.Version)
 
 	// Do we have a PurgeCmd counter from before the reboot?
 	c, err := uuidtonum.UuidToNumGet(log, ctx.pubUuidToNum,
@@ -458,19 +510,6 @@ func handleCreate(ctxArg interface{}, key string,
 		allErrors += errStr
 	}
 
-	if !ctx.globalConfig.GlobalValueBool(types.IgnoreMemoryCheckForApps) {
-		remaining, err := getRemainingMemory(ctx)
-		if err != nil {
-			errStr := fmt.Sprintf("getRemainingMemory failed: %s\n",
-				err)
-			allErrors += errStr
-		} else if remaining < uint64(config.FixedResources.Memory)<<10 {
-			errStr := fmt.Sprintf("Remaining memory bytes %d app instance needs %d\n",
-				remaining, config.FixedResources.Memory<<10)
-			allErrors += errStr
-		}
-	}
-
 	// if some error, return
 	if allErrors != "" {
 		log.Errorf("AppInstance(Name:%s, UUID:%s): Errors in App Instance "+
@@ -502,6 +541,12 @@ func handleModify(ctxArg interface{}, key string,
 	log.Functionf("handleModify(%v) for %s",
 		config.UUIDandVersion, config.DisplayName)
 
+	if config.Trace != status.Trace {
+		status.Trace = config.Trace
+		tracer.StartRoot(config.Trace, "deploy", key, "app", config.DisplayName,
+			"version", config.UUIDandVersion.Version)
+	}
+
 	// We handle at least ACL and activate changes. XXX What else?
 	// Not checking the version here; assume the microservices can handle
 	// some updates.
@@ -573,6 +618,13 @@ func handleModify(ctxArg interface{}, key string,
 		publishAppInstanceStatus(ctx, status)
 	}
 	publishAppInstanceStatus(ctx, status)
+
+	// Others might wait for us or be part of a dependency cycle
+	if config.Activate != oldConfig.Activate ||
+		!cmp.Equal(config.DependsOn, oldConfig.DependsOn) {
+		queueAwaitingDependency(ctx, uuid.Nil)
+		processDependencyUpdates(ctx)
+	}
 	log.Functionf("handleModify done for %s", config.DisplayName)
 }
 
@@ -582,6 +634,7 @@ func handleDelete(ctx *zedmanagerContext, key string,
 	log.Functionf("handleDelete(%v) for %s",
 		status.UUIDandVersion, status.DisplayName)
 
+	tracer.EndRoot(key, "deleted")
 	removeAIStatus(ctx, status)
 	// Remove the recorded PurgeCmd Counter
 	uuidtonum.UuidToNumDelete(log, ctx.pubUuidToNum, status.UUIDandVersion.UUID)
@@ -701,6 +754,7 @@ func handleGlobalConfigImpl(ctxArg interface{}, key string,
 		debugOverride, logger)
 	if gcp != nil {
 		ctx.globalConfig = gcp
+		tracer.UpdateConfig(gcp)
 		ctx.GCInitialized = true
 	}
 	log.Functionf("handleGlobalConfigImpl done for %s", key)
@@ -720,3 +774,73 @@ func handleGlobalConfigDelete(ctxArg interface{}, key string,
 	*ctx.globalConfig = *types.DefaultConfigItemValueMap()
 	log.Functionf("handleGlobalConfigDelete done for %s", key)
 }
+
+func handleHostMemoryCreate(ctxArg interface{}, key string,
+	statusArg interface{}) {
+	handleHostMemoryImpl(ctxArg, key, statusArg, nil)
+}
+
+func handleHostMemoryModify(ctxArg interface{}, key string,
+	statusArg interface{}, oldStatusArg interface{}) {
+	handleHostMemoryImpl(ctxArg, key, statusArg, oldStatusArg)
+}
+
+// handleHostMemoryImpl retries the app instances waiting for resources
+// when the memory or CPUs of the device change
+func handleHostMemoryImpl(ctxArg interface{}, key string,
+	statusArg interface{}, oldStatusArg interface{}) {
+
+	ctx := ctxArg.(*zedmanagerContext)
+	status := statusArg.(types.HostMemory)
+	if oldStatusArg != nil {
+		old := oldStatusArg.(types.HostMemory)
+		if old.TotalMemoryMB == status.TotalMemoryMB &&
+			old.HugepagesMB == status.HugepagesMB &&
+			old.Ncpus == status.Ncpus {
+			return
+		}
+	}
+	log.Functionf("handleHostMemoryImpl(%s) %d MB, %d MB hugepages, %d CPUs",
+		key, status.TotalMemoryMB, status.HugepagesMB, status.Ncpus)
+	queueAwaitingResources(ctx)
+	processDependencyUpdates(ctx)
+}
+
+func handleAACreate(ctxArg interface{}, key string,
+	statusArg interface{}) {
+	handleAAImpl(ctxArg, key, statusArg)
+}
+
+func handleAAModify(ctxArg interface{}, key string,
+	statusArg interface{}, oldStatusArg interface{}) {
+	handleAAImpl(ctxArg, key, statusArg)
+}
+
+func handleAAImpl(ctxArg interface{}, key string,
+	statusArg interface{}) {
+
+	ctx := ctxArg.(*zedmanagerContext)
+	status := statusArg.(types.AssignableAdapters)
+	if key != "global" {
+		log.Functionf("handleAAImpl: ignoring %s", key)
+		return
+	}
+	log.Functionf("handleAAImpl() %+v", status)
+	*ctx.assignableAdapters = status
+	queueAwaitingResources(ctx)
+	processDependencyUpdates(ctx)
+	log.Functionf("handleAAImpl() done")
+}
+
+func handleAADelete(ctxArg interface{}, key string,
+	statusArg interface{}) {
+
+	ctx := ctxArg.(*zedmanagerContext)
+	if key != "global" {
+		log.Functionf("handleAADelete: ignoring %s", key)
+		return
+	}
+	log.Functionf("handleAADelete()")
+	ctx.assignableAdapters.Initialized = false
+	log.Functionf("handleAADelete() done")
+}
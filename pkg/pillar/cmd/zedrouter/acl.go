@@ -14,6 +14,7 @@ import (
 	"syscall"
 
 	"github.com/eriknordmark/netlink"
+	"github.com/lf-edge/eve/pkg/pillar/base"
 	"github.com/lf-edge/eve/pkg/pillar/iptables"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 )
@@ -438,13 +439,56 @@ func aclToRules(ctx *zedrouterContext, aclArgs types.AppNetworkACLArgs, ACLs []t
 			aclRule4.Action = []string{"-j", "ACCEPT"}
 			rulesList = append(rulesList, aclRule1, aclRule2, aclRule3, aclRule4)
 
-			aclRule1.Rule = []string{"-i", aclArgs.BridgeName, "-d", "169.254.169.254",
-				"-p", "tcp", "--dport", "http"}
-			aclRule1.Action = []string{"-j", "ACCEPT"}
-			aclRule2.Rule = []string{"-i", aclArgs.BridgeName, "-s", "169.254.169.254",
-				"-p", "tcp", "--sport", "http"}
-			aclRule2.Action = []string{"-j", "ACCEPT"}
-			rulesList = append(rulesList, aclRule1, aclRule2)
+			// Mark the local ICMPv6, DHCPv6 and DNS traffic like the IPv4
+			// local traffic, so that it is accounted in the flows.
+			// The metadata server is only reachable over IPv4 hence has no
+			// rule here.
+			aclRule5.Table = "mangle"
+			aclRule5.Chain = "PREROUTING"
+			aclRule5.Rule = []string{"-i", aclArgs.BridgeName, "-m", "set",
+				"--match-set", "ipv6.local", "dst", "-p", "ipv6-icmp"}
+			chainName := fmt.Sprintf("proto-%s-%s-%d",
+				aclArgs.BridgeName, aclArgs.VifName, 6)
+			createMarkAndAcceptChain(aclArgs, chainName, 6)
+			aclRule5.Action = []string{"-j", chainName}
+			aclRule5.ActionChainName = chainName
+			rulesList = append(rulesList, aclRule5)
+
+			aclRule5.Rule = []string{"-i", aclArgs.BridgeName, "-d", aclArgs.BridgeIP,
+				"-p", "ipv6-icmp"}
+			chainName = fmt.Sprintf("proto-%s-%s-%d",
+				aclArgs.BridgeName, aclArgs.VifName, 7)
+			createMarkAndAcceptChain(aclArgs, chainName, 7)
+			aclRule5.Action = []string{"-j", chainName}
+			aclRule5.ActionChainName = chainName
+			rulesList = append(rulesList, aclRule5)
+
+			aclRule5.Rule = []string{"-i", aclArgs.BridgeName, "-m", "set",
+				"--match-set", "ipv6.local", "dst", "-p", "udp", "--dport", "dhcpv6-server"}
+			chainName = fmt.Sprintf("proto-%s-%s-%d",
+				aclArgs.BridgeName, aclArgs.VifName, 8)
+			createMarkAndAcceptChain(aclArgs, chainName, 8)
+			aclRule5.Action = []string{"-j", chainName}
+			aclRule5.ActionChainName = chainName
+			rulesList = append(rulesList, aclRule5)
+
+			aclRule5.Rule = []string{"-i", aclArgs.BridgeName, "-d", aclArgs.BridgeIP,
+				"-p", "udp", "-m", "multiport", "--dports", "dhcpv6-server,domain"}
+			chainName = fmt.Sprintf("proto-%s-%s-%d",
+				aclArgs.BridgeName, aclArgs.VifName, 9)
+			createMarkAndAcceptChain(aclArgs, chainName, 9)
+			aclRule5.Action = []string{"-j", chainName}
+			aclRule5.ActionChainName = chainName
+			rulesList = append(rulesList, aclRule5)
+
+			aclRule5.Rule = []string{"-i", aclArgs.BridgeName, "-d", aclArgs.BridgeIP,
+				"-p", "tcp", "--dport", "domain"}
+			chainName = fmt.Sprintf("proto-%s-%s-%d",
+				aclArgs.BridgeName, aclArgs.VifName, 10)
+			createMarkAndAcceptChain(aclArgs, chainName, 10)
+			aclRule5.Action = []string{"-j", chainName}
+			aclRule5.ActionChainName = chainName
+			rulesList = append(rulesList, aclRule5)
 		} else if aclArgs.NIType == types.NetworkInstanceTypeSwitch {
 			aclRule1.Rule = []string{"-i", aclArgs.BridgeName, "-m", "set",
 				"--match-set", "ipv6.local", "dst", "-p", "ipv6-icmp"}
@@ -842,6 +886,12 @@ func aceToRules(ctx *zedrouterContext, aclArgs types.AppNetworkACLArgs,
 	}
 
 	if ip != "" {
+		// Skip rules for the other address family
+		if ver := ipMatchVer(ip); ver != 0 && ver != aclArgs.IPVer {
+			log.Functionf("aceToRules: skipping IPv%d ip match %s",
+				ver, ip)
+			return rulesList, dependList, nil
+		}
 		outArgs = append(outArgs, "-d", ip)
 		inArgs = append(inArgs, "-s", ip)
 	}
@@ -913,10 +963,18 @@ func aceToRules(ctx *zedrouterContext, aclArgs types.AppNetworkACLArgs,
 			}
 			targetPort := fmt.Sprintf("%d", action.TargetPort)
 			target := fmt.Sprintf("%s:%d", aclArgs.AppIP, action.TargetPort)
+			if aclArgs.IPVer == 6 {
+				target = fmt.Sprintf("[%s]:%d", aclArgs.AppIP, action.TargetPort)
+			}
 			// These rules are applied on the upLink interfaces,
 			// the uplink IP address, and port number.
 			// We add those to the dependList we return
-			for _, upLink := range aclArgs.UpLinks {
+			upLinks := aclArgs.UpLinks
+			if action.LoadBalancer != "" {
+				// The DNAT is done by the load balancer
+				upLinks = nil
+			}
+			for _, upLink := range upLinks {
 				log.Tracef("PortMap - upLink %s\n", upLink)
 
 				// Check that we have an IP address on the uplink
@@ -928,16 +986,22 @@ func aceToRules(ctx *zedrouterContext, aclArgs types.AppNetworkACLArgs,
 					dependList = append(dependList, depend)
 					continue
 				}
-				// Pick first IPv4 address
+				// Pick first address of the same family
 				var extIP net.IP
 				for _, ip := range extIPs {
-					if ip.To4() != nil {
+					if aclArgs.IPVer == 6 {
+						if ip.To4() == nil && ip.IsGlobalUnicast() {
+							extIP = ip
+							break
+						}
+					} else if ip.To4() != nil {
 						extIP = ip
 						break
 					}
 				}
 				if len(extIP) == 0 {
-					log.Errorf("Can't add hairpin rule for %s: no IPv4 address", upLink)
+					log.Errorf("Can't add hairpin rule for %s: no IPv%d address",
+						upLink, aclArgs.IPVer)
 					depend := types.ACLDepend{Ifname: upLink}
 					dependList = append(dependList, depend)
 					continue
@@ -1173,6 +1237,23 @@ func aceToRules(ctx *zedrouterContext, aclArgs types.AppNetworkACLArgs,
 	return rulesList, dependList, nil
 }
 
+// ipMatchVer returns the IP version of an ip match (address or CIDR)
+func ipMatchVer(str string) int {
+	ip := net.ParseIP(str)
+	if ip == nil {
+		var err error
+		ip, _, err = net.ParseCIDR(str)
+		if err != nil {
+			// Let iptables complain
+			return 0
+		}
+	}
+	if ip.To4() != nil {
+		return 4
+	}
+	return 6
+}
+
 func isIPorCIDR(str string) bool {
 	if net.ParseIP(str) != nil {
 		return true
@@ -1212,26 +1293,6 @@ func rulePrefix(aclArgs types.AppNetworkACLArgs, rule *types.IPTablesRule) error
 		return nil
 	}
 
-	if aclArgs.IPVer == 6 {
-		// The input rules (from domU are applied to raw to intercept
-		// before lisp/pcap can pick them up.
-		// The output rules (to domU) are applied in forwarding path
-		// since packets are forwarded from lispers.net interface after
-		// decap.
-		// Note that the counter parsing code assumes this.
-		if rule.Rule[0] == "-i" {
-			rule.Table = "raw"
-			rule.Chain = "PREROUTING"
-			rule.Prefix = []string{"-m", "physdev", "--physdev-in", vifName}
-		} else if rule.Rule[0] == "-o" {
-			rule.Chain = "FORWARD"
-			if aclArgs.AppIP != "" {
-				rule.Prefix = []string{"-d", aclArgs.AppIP}
-			}
-		}
-		return nil
-	}
-
 	// table, chain are already set, nothing extra need to be done
 	if rule.Table != "" || rule.Chain != "" {
 		// NAT verbatim rule, already set
@@ -1257,6 +1318,28 @@ func rulePrefix(aclArgs types.AppNetworkACLArgs, rule *types.IPTablesRule) error
 		return nil
 	}
 
+	if aclArgs.IPVer == 6 {
+		// The input rules (from domU are applied to raw to intercept
+		// before lisp/pcap can pick them up.
+		// The output rules (to domU) are applied in forwarding path
+		// since packets are forwarded from lispers.net interface after
+		// decap.
+		// Note that the counter parsing code assumes this.
+		// The mangle rules of the IPv6 local network instances have
+		// their table set and are handled above.
+		if rule.Rule[0] == "-i" {
+			rule.Table = "raw"
+			rule.Chain = "PREROUTING"
+			rule.Prefix = []string{"-m", "physdev", "--physdev-in", vifName}
+		} else if rule.Rule[0] == "-o" {
+			rule.Chain = "FORWARD"
+			if aclArgs.AppIP != "" {
+				rule.Prefix = []string{"-d", aclArgs.AppIP}
+			}
+		}
+		return nil
+	}
+
 	// Underlay; we have NAT rules and otherwise the same as
 	// for IPv6
 	if rule.Rule[0] == "-i" {
@@ -1442,7 +1525,8 @@ func compareACE(ACE0 types.ACE, ACE1 types.ACE) bool {
 			return false
 		}
 		if action0.PortMap {
-			if action0.TargetPort != action1.TargetPort {
+			if action0.TargetPort != action1.TargetPort ||
+				action0.LoadBalancer != action1.LoadBalancer {
 				return false
 			}
 		}
@@ -1511,8 +1595,11 @@ func matchACLForPortMap(ACLs []types.ACE) bool {
 }
 
 // check for duplicate portmap rules in between two set of ACLs
-// for this, we will match the protocol/lport being same
-func matchACLsForPortMap(ACLs []types.ACE, ACLs1 []types.ACE) bool {
+// for this, we will match the protocol/lport being same.
+// Backends of the same load balancer on the same network instance
+// share the protocol/lport.
+func matchACLsForPortMap(ACLs []types.ACE, ACLs1 []types.ACE,
+	sameNetwork bool) bool {
 	matchTypes := []string{"protocol", "lport"}
 	for _, ace := range ACLs {
 		for _, action := range ace.Actions {
@@ -1526,6 +1613,10 @@ func matchACLsForPortMap(ACLs []types.ACE, ACLs1 []types.ACE) bool {
 					if !action1.PortMap {
 						continue
 					}
+					if sameNetwork && action.LoadBalancer != "" &&
+						action.LoadBalancer == action1.LoadBalancer {
+						continue
+					}
 					// match for protocol/lport
 					if checkForMatchCondition(ace, ace1, matchTypes) {
 						log.Errorf("match found for ace %v ace1 %v", ace, ace1)
@@ -1582,23 +1673,26 @@ func executeIPTablesRule(operation string, rule types.IPTablesRule) error {
 	if len(rule.Action) > 0 {
 		ruleStr = append(ruleStr, rule.Action...)
 	}
-	if rule.IPVer == 4 {
-		err = iptables.IptableCmd(log, ruleStr...)
-		if operation == "-D" && rule.Table == "mangle" {
-			if rule.ActionChainName != "" {
-				chainFlush := []string{"-t", "mangle", "--flush", rule.ActionChainName}
-				chainDelete := []string{"-t", "mangle", "-X", rule.ActionChainName}
-				err = iptables.IptableCmd(log, chainFlush...)
-				if err == nil {
-					iptables.IptableCmd(log, chainDelete...)
-				}
+	var iptableCmd func(*base.LogObject, ...string) error
+	switch rule.IPVer {
+	case 4:
+		iptableCmd = iptables.IptableCmd
+	case 6:
+		iptableCmd = iptables.Ip6tableCmd
+	default:
+		errStr := fmt.Sprintf("ACL: Unknown IP version %d", rule.IPVer)
+		return errors.New(errStr)
+	}
+	err = iptableCmd(log, ruleStr...)
+	if operation == "-D" && rule.Table == "mangle" {
+		if rule.ActionChainName != "" {
+			chainFlush := []string{"-t", "mangle", "--flush", rule.ActionChainName}
+			chainDelete := []string{"-t", "mangle", "-X", rule.ActionChainName}
+			err = iptableCmd(log, chainFlush...)
+			if err == nil {
+				iptableCmd(log, chainDelete...)
 			}
 		}
-	} else if rule.IPVer == 6 {
-		err = iptables.Ip6tableCmd(log, ruleStr...)
-	} else {
-		errStr := fmt.Sprintf("ACL: Unknown IP version %d", rule.IPVer)
-		err = errors.New(errStr)
 	}
 	return err
 }
@@ -1704,7 +1798,7 @@ func createFlowMatchRules(aclArgs types.AppNetworkACLArgs) types.IPTablesRuleLis
 	}
 	aclArgs.IPVer = determineIPVer(aclArgs.IsMgmt, aclArgs.BridgeIP)
 	for _, uplink := range aclArgs.UpLinks {
-		aclRule.IPVer = 4
+		aclRule.IPVer = aclArgs.IPVer
 		aclRule.Table = "mangle"
 		aclRule.Chain = "PREROUTING"
 		aclRule.Rule = []string{"-i", uplink}
@@ -1712,7 +1806,7 @@ func createFlowMatchRules(aclArgs types.AppNetworkACLArgs) types.IPTablesRuleLis
 		aclRule.Action = []string{"-j", "CONNMARK", "--restore-mark"}
 		rulesList = append(rulesList, aclRule)
 
-		aclRule.IPVer = 4
+		aclRule.IPVer = aclArgs.IPVer
 		aclRule.Table = "mangle"
 		aclRule.Chain = "PREROUTING"
 		// Check if packet has non-zero marking and ACCEPT if Yes.
@@ -1720,7 +1814,7 @@ func createFlowMatchRules(aclArgs types.AppNetworkACLArgs) types.IPTablesRuleLis
 		aclRule.Action = []string{"-j", "ACCEPT"}
 		rulesList = append(rulesList, aclRule)
 
-		aclRule.IPVer = 4
+		aclRule.IPVer = aclArgs.IPVer
 		aclRule.Table = "mangle"
 		aclRule.Chain = "PREROUTING"
 		aclRule.Rule = []string{"-i", uplink}
@@ -1729,7 +1823,7 @@ func createFlowMatchRules(aclArgs types.AppNetworkACLArgs) types.IPTablesRuleLis
 		aclRule.Action = []string{"-j", "MARK", "--set-mark", "0x00FFFFFF"}
 		rulesList = append(rulesList, aclRule)
 
-		aclRule.IPVer = 4
+		aclRule.IPVer = aclArgs.IPVer
 		aclRule.Table = "mangle"
 		aclRule.Chain = "PREROUTING"
 		aclRule.Rule = []string{"-i", uplink}
@@ -1748,11 +1842,15 @@ func createMarkAndAcceptChain(aclArgs types.AppNetworkACLArgs,
 		return errors.New("Invalid chain creation")
 	}
 
+	iptableCmd := iptables.IptableCmd
+	if aclArgs.IPVer == 6 {
+		iptableCmd = iptables.Ip6tableCmd
+	}
 	chainFlush := []string{"-t", "mangle", "--flush", name}
 
 	newChain := []string{"-t", "mangle", "-N", name}
 	log.Functionf("createMarkAndAcceptChain: Creating new chain (%s)", name)
-	err := iptables.IptableCmd(log, newChain...)
+	err := iptableCmd(log, newChain...)
 	if err != nil {
 		// if chain already exists, we can skip this error
 		if !strings.Contains(err.Error(), "Chain already exists") {
@@ -1762,7 +1860,7 @@ func createMarkAndAcceptChain(aclArgs types.AppNetworkACLArgs,
 		}
 		log.Functionf("createMarkAndAcceptChain: Chain (%s) flushing and recreating of rules: %s",
 			name, err)
-		if err := iptables.IptableCmd(log, chainFlush...); err != nil {
+		if err := iptableCmd(log, chainFlush...); err != nil {
 			log.Errorf("createMarkAndAcceptChain: Flush exists chain (%s) failed: %s",
 				name, err)
 			return err
@@ -1786,44 +1884,44 @@ func createMarkAndAcceptChain(aclArgs types.AppNetworkACLArgs,
 
 	chainDelete := []string{"-t", "mangle", "-X", name}
 
-	err = iptables.IptableCmd(log, rule1...)
+	err = iptableCmd(log, rule1...)
 	if err != nil {
 		log.Errorf("createMarkAndAcceptChain: New rule (%s) creation failed: %s",
 			rule1, err)
-		iptables.IptableCmd(log, chainFlush...)
-		iptables.IptableCmd(log, chainDelete...)
+		iptableCmd(log, chainFlush...)
+		iptableCmd(log, chainDelete...)
 		return err
 	}
-	err = iptables.IptableCmd(log, rule2...)
+	err = iptableCmd(log, rule2...)
 	if err != nil {
 		log.Errorf("createMarkAndAcceptChain: New rule (%s) creation failed: %s",
 			rule2, err)
-		iptables.IptableCmd(log, chainFlush...)
-		iptables.IptableCmd(log, chainDelete...)
+		iptableCmd(log, chainFlush...)
+		iptableCmd(log, chainDelete...)
 		return err
 	}
-	err = iptables.IptableCmd(log, rule3...)
+	err = iptableCmd(log, rule3...)
 	if err != nil {
 		log.Errorf("createMarkAndAcceptChain: New rule (%s) creation failed: %s",
 			rule3, err)
-		iptables.IptableCmd(log, chainFlush...)
-		iptables.IptableCmd(log, chainDelete...)
+		iptableCmd(log, chainFlush...)
+		iptableCmd(log, chainDelete...)
 		return err
 	}
-	err = iptables.IptableCmd(log, rule4...)
+	err = iptableCmd(log, rule4...)
 	if err != nil {
 		log.Errorf("createMarkAndAcceptChain: New rule (%s) creation failed: %s",
 			rule4, err)
-		iptables.IptableCmd(log, chainFlush...)
-		iptables.IptableCmd(log, chainDelete...)
+		iptableCmd(log, chainFlush...)
+		iptableCmd(log, chainDelete...)
 		return err
 	}
-	err = iptables.IptableCmd(log, rule5...)
+	err = iptableCmd(log, rule5...)
 	if err != nil {
 		log.Errorf("createMarkAndAcceptChain: New rule (%s) creation failed: %s",
 			rule5, err)
-		iptables.IptableCmd(log, chainFlush...)
-		iptables.IptableCmd(log, chainDelete...)
+		iptableCmd(log, chainFlush...)
+		iptableCmd(log, chainDelete...)
 		return err
 	}
 	return nil
@@ -100,6 +100,50 @@ func dnsmasqDhcpHostDir(bridgeName string) string {
 	return dhcphostsDir
 }
 
+// dhcpRouter returns the router which dnsmasq advertizes to the apps on
+// the network instance, or "" if none.
+// By default dnsmasq advertizes a router (and we can have a
+// static router defined in the NetworkInstanceConfig).
+// To support airgap networks we interpret gateway=0.0.0.0
+// to not advertize ourselves as a router.
+func dhcpRouter(netconf *types.NetworkInstanceConfig, bridgeIPAddr string) string {
+	if netconf.Logicallabel == "" {
+		log.Functionf("Internal switch without external port case, dnsmasq suppress router advertize\n")
+		return ""
+	} else if netconf.Gateway != nil {
+		if netconf.Gateway.IsUnspecified() {
+			return ""
+		}
+		return netconf.Gateway.String()
+	}
+	return bridgeIPAddr
+}
+
+// guestIPConfig returns what dnsmasq hands out to an app with appIPAddr
+// on the network instance, for the apps which configure it statically
+func guestIPConfig(status *types.NetworkInstanceStatus,
+	bridgeIPAddr string, appIPAddr string) types.GuestIPConfig {
+
+	ip := net.ParseIP(appIPAddr)
+	if ip == nil || ip.To4() == nil || status.Subnet.IP == nil {
+		return types.GuestIPConfig{}
+	}
+	config := types.GuestIPConfig{
+		IPAddr:     ip,
+		Subnet:     status.Subnet,
+		DNSServers: status.DnsServers,
+		DomainName: status.DomainName,
+	}
+	if router := dhcpRouter(&status.NetworkInstanceConfig, bridgeIPAddr); router != "" {
+		config.Gateway = net.ParseIP(router)
+		// dnsmasq is the DNS server unless there are explicit ones
+		if len(config.DNSServers) == 0 && bridgeIPAddr != "" {
+			config.DNSServers = []net.IP{net.ParseIP(bridgeIPAddr)}
+		}
+	}
+	return config
+}
+
 // createDnsmasqConfiglet
 // When we create a linux bridge we set this up
 // Also called when we need to update the ipsets
@@ -182,36 +226,23 @@ func createDnsmasqConfiglet(
 	}
 	file.WriteString(fmt.Sprintf("hostsdir=%s\n", hostsDir))
 	file.WriteString(fmt.Sprintf("dhcp-hostsdir=%s\n", dhcphostsDir))
+	// For IPv6 local network instances dnsmasq sends the router
+	// advertisements instead of radvd
+	localIPv6 := isIPv6 && netconf.IsLocalIPv6()
+	if localIPv6 {
+		file.WriteString("enable-ra\n")
+	}
 
 	ipv4Netmask := "255.255.255.0" // Default unless there is a Subnet
 	dhcpRange := bridgeIPAddr      // Default unless there is a DhcpRange
 
-	// By default dnsmasq advertizes a router (and we can have a
-	// static router defined in the NetworkInstanceConfig).
-	// To support airgap networks we interpret gateway=0.0.0.0
-	// to not advertize ourselves as a router. Also,
-	// if there is not an explicit dns server we skip
-	// advertising that as well.
-	advertizeRouter := true
-	var router string
-
-	if netconf.Logicallabel == "" {
-		log.Functionf("Internal switch without external port case, dnsmasq suppress router advertize\n")
-		advertizeRouter = false
-	} else if netconf.Gateway != nil {
-		if netconf.Gateway.IsUnspecified() {
-			advertizeRouter = false
-		} else {
-			router = netconf.Gateway.String()
-		}
-	} else if bridgeIPAddr != "" {
-		router = bridgeIPAddr
-	} else {
-		advertizeRouter = false
-	}
+	// If there is not an explicit dns server and we do not advertize
+	// a router we skip advertising that as well.
+	router := dhcpRouter(netconf, bridgeIPAddr)
+	advertizeRouter := router != ""
 	if netconf.DomainName != "" {
 		if isIPv6 {
-			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-search,%s\n",
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:domain-search,%s\n",
 				netconf.DomainName))
 		} else {
 			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-name,%s\n",
@@ -221,8 +252,13 @@ func createDnsmasqConfiglet(
 	advertizeDns := false
 	for _, ns := range netconf.DnsServers {
 		advertizeDns = true
-		file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
-			ns.String()))
+		if ns.To4() == nil {
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:dns-server,[%s]\n",
+				ns.String()))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
+				ns.String()))
+		}
 	}
 	if netconf.NtpServer != nil {
 		file.WriteString(fmt.Sprintf("dhcp-option=option:ntp-server,%s\n",
@@ -239,7 +275,7 @@ func createDnsmasqConfiglet(
 	if netconf.Subnet.IP != nil {
 		ipv4Netmask = net.IP(netconf.Subnet.Mask).String()
 	}
-	if netconf.Subnet.IP != nil {
+	if netconf.Subnet.IP != nil && !isIPv6 {
 		if advertizeRouter {
 			// Network prefix "255.255.255.255" will force packets to go through
 			// dom0 virtual router that makes the packets pass through ACLs and flow log.
@@ -264,19 +300,36 @@ func createDnsmasqConfiglet(
 		log.Functionf("createDnsmasqConfiglet: no router\n")
 		if !isIPv6 {
 			file.WriteString(fmt.Sprintf("dhcp-option=option:router\n"))
+		} else if localIPv6 {
+			// Zero router lifetime in the router advertisements
+			file.WriteString(fmt.Sprintf("ra-param=%s,600,0\n",
+				bridgeName))
 		}
 		if !advertizeDns {
 			// Handle isolated network by making sure
 			// we are not a DNS server. Can be overridden
 			// with the DnsServers above
 			log.Functionf("createDnsmasqConfiglet: no DNS server\n")
-			file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server\n"))
+			if isIPv6 {
+				file.WriteString(fmt.Sprintf("dhcp-option=option6:dns-server\n"))
+			} else {
+				file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server\n"))
+			}
 		}
 	}
 	if netconf.DhcpRange.Start != nil {
 		dhcpRange = netconf.DhcpRange.Start.String()
 	}
-	if isIPv6 {
+	if localIPv6 {
+		prefixLen, _ := netconf.Subnet.Mask.Size()
+		if netconf.IPv6.Addressing == types.IPv6AddressingSLAAC {
+			file.WriteString(fmt.Sprintf("dhcp-range=%s,ra-stateless,%d,60m\n",
+				netconf.Subnet.IP.String(), prefixLen))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-range=%s,static,%d,60m\n",
+				dhcpRange, prefixLen))
+		}
+	} else if isIPv6 {
 		file.WriteString(fmt.Sprintf("dhcp-range=::,static,0,60m\n"))
 	} else {
 		file.WriteString(fmt.Sprintf("dhcp-range=%s,static,%s,60m\n",
@@ -124,6 +124,7 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 	instData.intfAddrs = IntfAddrs
 
 	checkAppAndACL(ctx, &instData)
+	ipfixExportersUpdate(ctx)
 
 	// Get IPv4/v6 conntrack table flows
 	Protocols := [2]netlink.InetFamily{syscall.AF_INET, syscall.AF_INET6}
@@ -157,6 +158,7 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 		for appIdx := range instData.appNet {
 
 			var sequence, flowIdx int
+			var exportFlows []types.FlowRec
 
 			// fill in the partial scope information, later the aclNum and aclAttr will decide
 			// if we have a match in this flow into app/bridge scope
@@ -253,6 +255,7 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 				}
 
 				flowdata.Flows = append(flowdata.Flows, flowrec)
+				exportFlows = append(exportFlows, flowrec)
 				flowIdx++
 				if flowIdx > maxFlowPack {
 					flowPublish(ctx, &flowdata, &sequence, &flowIdx)
@@ -264,6 +267,7 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 			}
 
 			var dnsrec [2]map[string]dnsEntry
+			dnsNames := make(map[string]string)   // resolved IP address to domain name
 			dnsrec[0] = make(map[string]dnsEntry) // store IPv4 addresses from dns
 			dnsrec[1] = make(map[string]dnsEntry) // store IPv6 addresses from dns
 			bnNum, err := bridgeStrToNum(ctx, bnx)
@@ -293,6 +297,9 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 						Addrs:       dnsRec.Answers,
 						RequestTime: dnsRec.TimeStamp.UnixNano(),
 					}
+					for _, addr := range dnsRec.Answers {
+						dnsNames[addr.String()] = dnsRec.DomainName
+					}
 					dnsPacked = true
 					flowdata.DNSReqs = append(flowdata.DNSReqs, dnsrec)
 					flowIdx++
@@ -303,6 +310,8 @@ func FlowStatsCollect(ctx *zedrouterContext) {
 			}
 			dnssys[bnNum].Unlock()
 
+			ipfixExport(scope.NetUUID, scope.UUID, exportFlows, dnsNames)
+
 			// flow record done for the bridge/app
 			// publish the flow data (per app/bridge) and sequence (for size limit) to zedagent now
 			flowPublish(ctx, &flowdata, &sequence, &flowIdx)
@@ -0,0 +1,382 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// IPFIX (RFC 7011) export of the flow records collected by FlowStatsCollect
+// to a collector on a local network
+
+package zedrouter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	ipfixVersion       uint16 = 10
+	ipfixDefaultPort   uint16 = 4739
+	ipfixTemplateSetID uint16 = 2
+	ipfixTemplateIDv4  uint16 = 256
+	ipfixTemplateIDv6  uint16 = 257
+	ipfixVarLen        uint16 = 65535
+	ipfixEnterpriseBit uint16 = 0x8000
+	ipfixReversePEN    uint32 = 29305 // RFC 5103 reverse information elements
+	ipfixHeaderLen     int    = 16
+	ipfixSetHeaderLen  int    = 4
+	ipfixMaxMsgLen     int    = 1400 // stay below the usual path MTU
+	ipfixMaxDNSNameLen int    = 253
+)
+
+// IANA assigned information elements
+const (
+	ipfixIEOctetDeltaCount       uint16 = 1
+	ipfixIEPacketDeltaCount      uint16 = 2
+	ipfixIEProtocolIdentifier    uint16 = 4
+	ipfixIESourceTransportPort   uint16 = 7
+	ipfixIESourceIPv4Address     uint16 = 8
+	ipfixIEDestTransportPort     uint16 = 11
+	ipfixIEDestIPv4Address       uint16 = 12
+	ipfixIESourceIPv6Address     uint16 = 27
+	ipfixIEDestIPv6Address       uint16 = 28
+	ipfixIEFlowDirection         uint16 = 61
+	ipfixIEForwardingStatus      uint16 = 89
+	ipfixIEFlowStartMilliseconds uint16 = 152
+	ipfixIEFlowEndMilliseconds   uint16 = 153
+)
+
+// EVE specific information elements, exported under the configured
+// Private Enterprise Number
+const (
+	ipfixIEAppInstanceUUID     uint16 = 1
+	ipfixIENetworkInstanceUUID uint16 = 2
+	ipfixIEACLRuleID           uint16 = 3
+	ipfixIEDNSName             uint16 = 4
+)
+
+// forwardingStatus values from RFC 7270
+const (
+	ipfixForwarded uint8 = 0x40
+	ipfixDropped   uint8 = 0x80
+)
+
+type ipfixField struct {
+	id         uint16
+	length     uint16
+	enterprise uint32
+}
+
+// ipfixRecord is one flow record as exported to the collector
+type ipfixRecord struct {
+	flow    types.FlowRec
+	appUUID uuid.UUID
+	netUUID uuid.UUID
+	dnsName string
+}
+
+type ipfixExporter struct {
+	config   types.IPFIXExporterConfig
+	conn     net.Conn
+	sequence uint32 // number of data records sent so far
+}
+
+var ipfixExportersLock sync.Mutex
+var ipfixExporters = make(map[uuid.UUID]*ipfixExporter)
+
+// ipfixTemplate returns the template for IPv4 or IPv6 flows.
+// The EVE specific elements are only included if an enterprise
+// number is configured.
+func ipfixTemplate(ipv6 bool, enterprise uint32) []ipfixField {
+	srcAddr := ipfixField{id: ipfixIESourceIPv4Address, length: net.IPv4len}
+	dstAddr := ipfixField{id: ipfixIEDestIPv4Address, length: net.IPv4len}
+	if ipv6 {
+		srcAddr = ipfixField{id: ipfixIESourceIPv6Address, length: net.IPv6len}
+		dstAddr = ipfixField{id: ipfixIEDestIPv6Address, length: net.IPv6len}
+	}
+	fields := []ipfixField{
+		{id: ipfixIEFlowStartMilliseconds, length: 8},
+		{id: ipfixIEFlowEndMilliseconds, length: 8},
+		srcAddr,
+		dstAddr,
+		{id: ipfixIESourceTransportPort, length: 2},
+		{id: ipfixIEDestTransportPort, length: 2},
+		{id: ipfixIEProtocolIdentifier, length: 1},
+		{id: ipfixIEFlowDirection, length: 1},
+		{id: ipfixIEForwardingStatus, length: 1},
+		{id: ipfixIEOctetDeltaCount, length: 8},
+		{id: ipfixIEPacketDeltaCount, length: 8},
+		{id: ipfixIEOctetDeltaCount, length: 8, enterprise: ipfixReversePEN},
+		{id: ipfixIEPacketDeltaCount, length: 8, enterprise: ipfixReversePEN},
+	}
+	if enterprise != 0 {
+		fields = append(fields,
+			ipfixField{id: ipfixIEAppInstanceUUID, length: 16, enterprise: enterprise},
+			ipfixField{id: ipfixIENetworkInstanceUUID, length: 16, enterprise: enterprise},
+			ipfixField{id: ipfixIEACLRuleID, length: 4, enterprise: enterprise},
+			ipfixField{id: ipfixIEDNSName, length: ipfixVarLen, enterprise: enterprise})
+	}
+	return fields
+}
+
+// ipfixEncodeTemplateSet returns a template set with the IPv4 and
+// IPv6 templates
+func ipfixEncodeTemplateSet(enterprise uint32) []byte {
+	var buf bytes.Buffer
+	writeTemplate := func(templateID uint16, fields []ipfixField) {
+		binary.Write(&buf, binary.BigEndian, templateID)
+		binary.Write(&buf, binary.BigEndian, uint16(len(fields)))
+		for _, f := range fields {
+			if f.enterprise != 0 {
+				binary.Write(&buf, binary.BigEndian, f.id|ipfixEnterpriseBit)
+				binary.Write(&buf, binary.BigEndian, f.length)
+				binary.Write(&buf, binary.BigEndian, f.enterprise)
+			} else {
+				binary.Write(&buf, binary.BigEndian, f.id)
+				binary.Write(&buf, binary.BigEndian, f.length)
+			}
+		}
+	}
+	writeTemplate(ipfixTemplateIDv4, ipfixTemplate(false, enterprise))
+	writeTemplate(ipfixTemplateIDv6, ipfixTemplate(true, enterprise))
+	return ipfixEncodeSet(ipfixTemplateSetID, buf.Bytes())
+}
+
+func ipfixEncodeSet(setID uint16, content []byte) []byte {
+	set := make([]byte, ipfixSetHeaderLen, ipfixSetHeaderLen+len(content))
+	binary.BigEndian.PutUint16(set[0:2], setID)
+	binary.BigEndian.PutUint16(set[2:4], uint16(ipfixSetHeaderLen+len(content)))
+	return append(set, content...)
+}
+
+// ipfixEncodeRecord appends the data record for rec according to
+// the template fields
+func ipfixEncodeRecord(buf *bytes.Buffer, fields []ipfixField, rec ipfixRecord) {
+	flow := rec.flow
+	for _, f := range fields {
+		if f.enterprise != 0 && f.enterprise != ipfixReversePEN {
+			switch f.id {
+			case ipfixIEAppInstanceUUID:
+				buf.Write(rec.appUUID.Bytes())
+			case ipfixIENetworkInstanceUUID:
+				buf.Write(rec.netUUID.Bytes())
+			case ipfixIEACLRuleID:
+				binary.Write(buf, binary.BigEndian, uint32(flow.ACLID))
+			case ipfixIEDNSName:
+				name := rec.dnsName
+				if len(name) > ipfixMaxDNSNameLen {
+					name = name[:ipfixMaxDNSNameLen]
+				}
+				// short variable length encoding, RFC 7011 section 7
+				buf.WriteByte(uint8(len(name)))
+				buf.WriteString(name)
+			}
+			continue
+		}
+		reverse := f.enterprise == ipfixReversePEN
+		switch f.id {
+		case ipfixIEFlowStartMilliseconds:
+			binary.Write(buf, binary.BigEndian, uint64(flow.StartTime/int64(time.Millisecond)))
+		case ipfixIEFlowEndMilliseconds:
+			binary.Write(buf, binary.BigEndian, uint64(flow.StopTime/int64(time.Millisecond)))
+		case ipfixIESourceIPv4Address:
+			buf.Write(flow.Flow.Src.To4())
+		case ipfixIEDestIPv4Address:
+			buf.Write(flow.Flow.Dst.To4())
+		case ipfixIESourceIPv6Address:
+			buf.Write(flow.Flow.Src.To16())
+		case ipfixIEDestIPv6Address:
+			buf.Write(flow.Flow.Dst.To16())
+		case ipfixIESourceTransportPort:
+			binary.Write(buf, binary.BigEndian, uint16(flow.Flow.SrcPort))
+		case ipfixIEDestTransportPort:
+			binary.Write(buf, binary.BigEndian, uint16(flow.Flow.DstPort))
+		case ipfixIEProtocolIdentifier:
+			buf.WriteByte(uint8(flow.Flow.Proto))
+		case ipfixIEFlowDirection:
+			// 0 is ingress, 1 is egress as seen from the app
+			if flow.Inbound {
+				buf.WriteByte(0)
+			} else {
+				buf.WriteByte(1)
+			}
+		case ipfixIEForwardingStatus:
+			if flow.Action == types.ACLActionDrop {
+				buf.WriteByte(ipfixDropped)
+			} else {
+				buf.WriteByte(ipfixForwarded)
+			}
+		case ipfixIEOctetDeltaCount:
+			// The flow source is always the app hence forward is Tx
+			if reverse {
+				binary.Write(buf, binary.BigEndian, uint64(flow.RxBytes))
+			} else {
+				binary.Write(buf, binary.BigEndian, uint64(flow.TxBytes))
+			}
+		case ipfixIEPacketDeltaCount:
+			if reverse {
+				binary.Write(buf, binary.BigEndian, uint64(flow.RxPkts))
+			} else {
+				binary.Write(buf, binary.BigEndian, uint64(flow.TxPkts))
+			}
+		}
+	}
+}
+
+// ipfixEncodeMessages returns the IPFIX messages for the records.
+// The first message carries the template set so that a collector which
+// restarted relearns the templates within one collection interval.
+func ipfixEncodeMessages(config types.IPFIXExporterConfig, records []ipfixRecord,
+	sequence uint32, exportTime time.Time) [][]byte {
+
+	var messages [][]byte
+	fields := map[uint16][]ipfixField{
+		ipfixTemplateIDv4: ipfixTemplate(false, config.EnterpriseNumber),
+		ipfixTemplateIDv6: ipfixTemplate(true, config.EnterpriseNumber),
+	}
+	var sets bytes.Buffer
+	sets.Write(ipfixEncodeTemplateSet(config.EnterpriseNumber))
+	msgSequence := sequence
+	var setID uint16
+	var set bytes.Buffer
+	var msgRecords uint32
+
+	finishSet := func() {
+		if set.Len() != 0 {
+			sets.Write(ipfixEncodeSet(setID, set.Bytes()))
+			set.Reset()
+		}
+	}
+	finishMessage := func() {
+		finishSet()
+		if sets.Len() == 0 {
+			return
+		}
+		msg := make([]byte, ipfixHeaderLen, ipfixHeaderLen+sets.Len())
+		binary.BigEndian.PutUint16(msg[0:2], ipfixVersion)
+		binary.BigEndian.PutUint16(msg[2:4], uint16(ipfixHeaderLen+sets.Len()))
+		binary.BigEndian.PutUint32(msg[4:8], uint32(exportTime.Unix()))
+		binary.BigEndian.PutUint32(msg[8:12], msgSequence)
+		binary.BigEndian.PutUint32(msg[12:16], config.ObservationDomainID)
+		messages = append(messages, append(msg, sets.Bytes()...))
+		sets.Reset()
+		msgSequence += msgRecords
+		msgRecords = 0
+	}
+
+	for _, rec := range records {
+		templateID := ipfixTemplateIDv4
+		if rec.flow.Flow.Src.To4() == nil {
+			templateID = ipfixTemplateIDv6
+		}
+		var record bytes.Buffer
+		ipfixEncodeRecord(&record, fields[templateID], rec)
+		msgLen := ipfixHeaderLen + sets.Len() + ipfixSetHeaderLen + set.Len()
+		if templateID != setID {
+			msgLen += ipfixSetHeaderLen
+		}
+		if msgLen+record.Len() > ipfixMaxMsgLen {
+			finishMessage()
+		}
+		if templateID != setID {
+			finishSet()
+			setID = templateID
+		}
+		set.Write(record.Bytes())
+		msgRecords++
+	}
+	finishMessage()
+	return messages
+}
+
+// ipfixExportersUpdate creates, updates and removes the exporters based on
+// the IPFIX configuration of the network instances
+func ipfixExportersUpdate(ctx *zedrouterContext) {
+	ipfixExportersLock.Lock()
+	defer ipfixExportersLock.Unlock()
+
+	configured := make(map[uuid.UUID]types.IPFIXExporterConfig)
+	pub := ctx.pubNetworkInstanceStatus
+	items := pub.GetAll()
+	for _, st := range items {
+		status := st.(types.NetworkInstanceStatus)
+		if status.IPFIXExporter.IsEnabled() && status.Activated {
+			configured[status.UUID] = status.IPFIXExporter
+		}
+	}
+	for netUUID, exporter := range ipfixExporters {
+		config, ok := configured[netUUID]
+		if ok && config.Equal(exporter.config) {
+			continue
+		}
+		log.Functionf("ipfixExportersUpdate: stop exporter for %s to %s",
+			netUUID, exporter.conn.RemoteAddr())
+		exporter.conn.Close()
+		delete(ipfixExporters, netUUID)
+	}
+	for netUUID, config := range configured {
+		if _, ok := ipfixExporters[netUUID]; ok {
+			continue
+		}
+		port := config.Port
+		if port == 0 {
+			port = ipfixDefaultPort
+		}
+		addr := net.JoinHostPort(config.Collector.String(),
+			strconv.Itoa(int(port)))
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			log.Errorf("ipfixExportersUpdate: network instance %s collector %s: %v",
+				netUUID, addr, err)
+			continue
+		}
+		log.Functionf("ipfixExportersUpdate: start exporter for %s to %s",
+			netUUID, addr)
+		ipfixExporters[netUUID] = &ipfixExporter{config: config, conn: conn}
+	}
+}
+
+// ipfixExport sends the flow records of one app on one network instance
+// to the collector, if any is configured for the network instance
+func ipfixExport(netUUID uuid.UUID, appUUID uuid.UUID, flows []types.FlowRec,
+	dnsNames map[string]string) {
+
+	if len(flows) == 0 {
+		return
+	}
+	ipfixExportersLock.Lock()
+	defer ipfixExportersLock.Unlock()
+	exporter, ok := ipfixExporters[netUUID]
+	if !ok {
+		return
+	}
+	records := make([]ipfixRecord, 0, len(flows))
+	for _, flow := range flows {
+		records = append(records, ipfixRecord{
+			flow:    flow,
+			appUUID: appUUID,
+			netUUID: netUUID,
+			dnsName: dnsNames[flow.Flow.Dst.String()],
+		})
+	}
+	messages := ipfixEncodeMessages(exporter.config, records,
+		exporter.sequence, time.Now())
+	for _, msg := range messages {
+		if _, err := exporter.conn.Write(msg); err != nil {
+			err = fmt.Errorf("ipfixExport: network instance %s collector %s: %v",
+				netUUID, exporter.conn.RemoteAddr(), err)
+			log.Error(err)
+			break
+		}
+	}
+	// Records which were not sent are lost anyhow; RFC 7011 lets the
+	// collector detect that from the sequence number
+	exporter.sequence += uint32(len(records))
+	log.Tracef("ipfixExport: %d records in %d messages for app %s on %s",
+		len(records), len(messages), appUUID, netUUID)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedrouter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/satori/go.uuid"
+)
+
+func testIpfixRecord(src, dst string, dnsName string) ipfixRecord {
+	return ipfixRecord{
+		flow: types.FlowRec{
+			Flow: types.IPTuple{
+				Src:     net.ParseIP(src),
+				Dst:     net.ParseIP(dst),
+				SrcPort: 40000,
+				DstPort: 443,
+				Proto:   6,
+			},
+			ACLID:     2,
+			Action:    types.ACLActionAccept,
+			StartTime: time.Unix(1600000000, 0).UnixNano(),
+			StopTime:  time.Unix(1600000060, 0).UnixNano(),
+			TxBytes:   1000,
+			TxPkts:    10,
+			RxBytes:   20000,
+			RxPkts:    20,
+		},
+		appUUID: uuid.NewV4(),
+		netUUID: uuid.NewV4(),
+		dnsName: dnsName,
+	}
+}
+
+// ipfixParseSets returns the set IDs and the set contents of a message
+func ipfixParseSets(t *testing.T, msg []byte) ([]uint16, [][]byte) {
+	if binary.BigEndian.Uint16(msg[0:2]) != ipfixVersion {
+		t.Fatalf("bad version %d", binary.BigEndian.Uint16(msg[0:2]))
+	}
+	if int(binary.BigEndian.Uint16(msg[2:4])) != len(msg) {
+		t.Fatalf("message length %d, expected %d",
+			binary.BigEndian.Uint16(msg[2:4]), len(msg))
+	}
+	var ids []uint16
+	var sets [][]byte
+	for offset := ipfixHeaderLen; offset < len(msg); {
+		setLen := int(binary.BigEndian.Uint16(msg[offset+2 : offset+4]))
+		if setLen < ipfixSetHeaderLen || offset+setLen > len(msg) {
+			t.Fatalf("bad set length %d at offset %d", setLen, offset)
+		}
+		ids = append(ids, binary.BigEndian.Uint16(msg[offset:offset+2]))
+		sets = append(sets, msg[offset+ipfixSetHeaderLen:offset+setLen])
+		offset += setLen
+	}
+	return ids, sets
+}
+
+func TestIpfixEncodeMessage(t *testing.T) {
+	config := types.IPFIXExporterConfig{
+		Collector:           net.ParseIP("192.168.1.10"),
+		ObservationDomainID: 7,
+	}
+	records := []ipfixRecord{
+		testIpfixRecord("10.1.0.2", "8.8.8.8", ""),
+		testIpfixRecord("fd00::2", "2001:db8::1", ""),
+	}
+	exportTime := time.Unix(1600000100, 0)
+	messages := ipfixEncodeMessages(config, records, 42, exportTime)
+	if len(messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(messages))
+	}
+	msg := messages[0]
+	if binary.BigEndian.Uint32(msg[4:8]) != uint32(exportTime.Unix()) {
+		t.Errorf("bad export time %d", binary.BigEndian.Uint32(msg[4:8]))
+	}
+	if binary.BigEndian.Uint32(msg[8:12]) != 42 {
+		t.Errorf("bad sequence number %d", binary.BigEndian.Uint32(msg[8:12]))
+	}
+	if binary.BigEndian.Uint32(msg[12:16]) != 7 {
+		t.Errorf("bad observation domain %d", binary.BigEndian.Uint32(msg[12:16]))
+	}
+	ids, sets := ipfixParseSets(t, msg)
+	expectedIDs := []uint16{ipfixTemplateSetID, ipfixTemplateIDv4, ipfixTemplateIDv6}
+	if len(ids) != len(expectedIDs) {
+		t.Fatalf("got sets %v, expected %v", ids, expectedIDs)
+	}
+	for i := range ids {
+		if ids[i] != expectedIDs[i] {
+			t.Fatalf("got sets %v, expected %v", ids, expectedIDs)
+		}
+	}
+	// 2 x 8 timestamps, 2 x 2 ports, 3 x 1 proto/direction/status,
+	// 4 x 8 counters plus the addresses
+	if len(sets[1]) != 55+2*net.IPv4len {
+		t.Errorf("IPv4 record length %d", len(sets[1]))
+	}
+	if len(sets[2]) != 55+2*net.IPv6len {
+		t.Errorf("IPv6 record length %d", len(sets[2]))
+	}
+	if !bytes.Equal(sets[1][16:20], net.ParseIP("10.1.0.2").To4()) {
+		t.Errorf("bad source address % x", sets[1][16:20])
+	}
+}
+
+func TestIpfixEncodeEnterprise(t *testing.T) {
+	config := types.IPFIXExporterConfig{
+		Collector:        net.ParseIP("192.168.1.10"),
+		EnterpriseNumber: 12345,
+	}
+	rec := testIpfixRecord("10.1.0.2", "8.8.8.8", "dns.google")
+	messages := ipfixEncodeMessages(config, []ipfixRecord{rec}, 0, time.Now())
+	if len(messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(messages))
+	}
+	_, sets := ipfixParseSets(t, messages[0])
+	data := sets[1]
+	// app UUID, network instance UUID, ACL ID, DNS name
+	expectedLen := 55 + 2*net.IPv4len + 16 + 16 + 4 + 1 + len("dns.google")
+	if len(data) != expectedLen {
+		t.Fatalf("record length %d, expected %d", len(data), expectedLen)
+	}
+	offset := 55 + 2*net.IPv4len
+	if !bytes.Equal(data[offset:offset+16], rec.appUUID.Bytes()) {
+		t.Errorf("bad app UUID % x", data[offset:offset+16])
+	}
+	offset += 32
+	if binary.BigEndian.Uint32(data[offset:offset+4]) != 2 {
+		t.Errorf("bad ACL ID % x", data[offset:offset+4])
+	}
+	offset += 4
+	if string(data[offset+1:]) != "dns.google" || int(data[offset]) != len("dns.google") {
+		t.Errorf("bad DNS name % x", data[offset:])
+	}
+}
+
+func TestIpfixEncodeSplit(t *testing.T) {
+	config := types.IPFIXExporterConfig{
+		Collector: net.ParseIP("192.168.1.10"),
+	}
+	var records []ipfixRecord
+	for i := 0; i < 100; i++ {
+		records = append(records, testIpfixRecord("10.1.0.2", "8.8.8.8", ""))
+	}
+	messages := ipfixEncodeMessages(config, records, 0, time.Now())
+	if len(messages) < 2 {
+		t.Fatalf("expected several messages, got %d", len(messages))
+	}
+	var sequence uint32
+	for _, msg := range messages {
+		if len(msg) > ipfixMaxMsgLen {
+			t.Errorf("message length %d exceeds %d", len(msg), ipfixMaxMsgLen)
+		}
+		if binary.BigEndian.Uint32(msg[8:12]) != sequence {
+			t.Errorf("sequence number %d, expected %d",
+				binary.BigEndian.Uint32(msg[8:12]), sequence)
+		}
+		ids, sets := ipfixParseSets(t, msg)
+		for i, id := range ids {
+			if id == ipfixTemplateIDv4 {
+				sequence += uint32(len(sets[i]) / (55 + 2*net.IPv4len))
+			}
+		}
+	}
+	if sequence != uint32(len(records)) {
+		t.Errorf("got %d records, expected %d", sequence, len(records))
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Addressing for IPv6 local network instances. The apps get their
+// addresses using DHCPv6 or SLAAC from dnsmasq, and the network instance
+// is either NATed (NAT66) or routed using a /64 out of the prefix
+// delegated over DHCPv6 to the uplink port.
+
+package zedrouter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// Add to an IPv6 address
+func addToIPv6(ip net.IP, addition uint) net.IP {
+	addr := ip.To16()
+	if addr == nil {
+		log.Fatalf("addToIPv6: not an IP address %s", ip.String())
+	}
+	val := new(big.Int).SetBytes(addr)
+	val.Add(val, new(big.Int).SetUint64(uint64(addition)))
+	b := val.Bytes()
+	if len(b) > net.IPv6len {
+		// Wrap around
+		b = b[len(b)-net.IPv6len:]
+	}
+	res := make(net.IP, net.IPv6len)
+	copy(res[net.IPv6len-len(b):], b)
+	return res
+}
+
+// eui64Address returns the address a SLAAC client with the mac will
+// configure in the /64 subnet
+func eui64Address(subnet net.IPNet, mac net.HardwareAddr) net.IP {
+	addr := make(net.IP, net.IPv6len)
+	copy(addr, subnet.IP.To16())
+	if len(mac) != 6 {
+		log.Errorf("eui64Address: unexpected MAC %s", mac.String())
+		return addr
+	}
+	addr[8] = mac[0] ^ 0x02
+	addr[9] = mac[1]
+	addr[10] = mac[2]
+	addr[11] = 0xff
+	addr[12] = 0xfe
+	addr[13] = mac[3]
+	addr[14] = mac[4]
+	addr[15] = mac[5]
+	return addr
+}
+
+// replacePrefix returns ip with its prefix replaced by the one of the
+// subnet, keeping the interface identifier
+func replacePrefix(ip net.IP, subnet net.IPNet) net.IP {
+	addr := ip.To16()
+	prefix := subnet.IP.To16()
+	res := make(net.IP, net.IPv6len)
+	for i := range res {
+		res[i] = (prefix[i] & subnet.Mask[i]) | (addr[i] &^ subnet.Mask[i])
+	}
+	return res
+}
+
+// delegatedSubnet returns the /64 subnet with the given index out of
+// the delegated prefix
+func delegatedSubnet(prefix net.IPNet, index int) (net.IPNet, error) {
+	ones, bits := prefix.Mask.Size()
+	if bits != 8*net.IPv6len || ones > 64 {
+		return net.IPNet{}, fmt.Errorf("delegated prefix %s is not an IPv6 /64 or shorter",
+			prefix.String())
+	}
+	if index >= 1<<uint(64-ones) {
+		return net.IPNet{}, fmt.Errorf("delegated prefix %s too small for subnet %d",
+			prefix.String(), index)
+	}
+	// The subnet ID is in the bits between the prefix and the /64
+	ip := make(net.IP, net.IPv6len)
+	high := binary.BigEndian.Uint64(prefix.IP.To16().Mask(prefix.Mask))
+	binary.BigEndian.PutUint64(ip, high|uint64(index))
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(64, 8*net.IPv6len)}, nil
+}
+
+// setDelegatedSubnet picks the subnet for a network instance using prefix
+// delegation based on the bridge number, and moves the gateway and
+// DhcpRange into that subnet
+func setDelegatedSubnet(ctx *zedrouterContext,
+	status *types.NetworkInstanceStatus) error {
+
+	subnet, err := lookupDelegatedSubnet(ctx, status)
+	if err != nil {
+		return err
+	}
+	log.Functionf("setDelegatedSubnet(%s) subnet %s from uplink %s",
+		status.Key(), subnet.String(), status.CurrentUplinkIntf)
+	status.Subnet = subnet
+	if status.Gateway == nil {
+		status.Gateway = addToIPv6(subnet.IP, 1)
+	} else {
+		status.Gateway = replacePrefix(status.Gateway, subnet)
+	}
+	if status.DhcpRange.Start != nil {
+		status.DhcpRange.Start = replacePrefix(status.DhcpRange.Start, subnet)
+	}
+	if status.DhcpRange.End != nil {
+		status.DhcpRange.End = replacePrefix(status.DhcpRange.End, subnet)
+	}
+	return nil
+}
+
+func lookupDelegatedSubnet(ctx *zedrouterContext,
+	status *types.NetworkInstanceStatus) (net.IPNet, error) {
+
+	if status.CurrentUplinkIntf == "" {
+		return net.IPNet{}, errors.New("prefix delegation requires a port")
+	}
+	prefix := types.GetDelegatedPrefix(*ctx.deviceNetworkStatus,
+		status.CurrentUplinkIntf)
+	if prefix == nil {
+		return net.IPNet{}, fmt.Errorf("no delegated IPv6 prefix on %s",
+			status.CurrentUplinkIntf)
+	}
+	index := 0
+	if ones, _ := prefix.Mask.Size(); ones < 64 {
+		index = bridgeNumAllocate(ctx, status.UUID)
+	}
+	return delegatedSubnet(*prefix, index)
+}
+
+// doNetworkInstanceIPv6SanityCheck checks the subnet of an IPv6 local
+// network instance
+func doNetworkInstanceIPv6SanityCheck(status *types.NetworkInstanceStatus) error {
+	if status.Subnet.IP.To4() != nil {
+		return fmt.Errorf("IPv6 network instance with IPv4 subnet %s",
+			status.Subnet.String())
+	}
+	if status.IPv6.Addressing == types.IPv6AddressingSLAAC {
+		if ones, _ := status.Subnet.Mask.Size(); ones != 64 {
+			return fmt.Errorf("SLAAC requires a /64 subnet, not %s",
+				status.Subnet.String())
+		}
+	}
+	return nil
+}
+
+// awaitDelegatedPrefix returns true when the subnet of the network instance
+// is to be taken from a prefix which DHCPv6 did not delegate yet
+func awaitDelegatedPrefix(ctx *zedrouterContext,
+	status *types.NetworkInstanceStatus) bool {
+
+	// Without a port there is nothing to wait for, the creation fails
+	return status.IsLocalIPv6() &&
+		status.IPv6.Uplink == types.IPv6UplinkPrefixDelegation &&
+		status.Logicallabel != "" &&
+		types.GetDelegatedPrefix(*ctx.deviceNetworkStatus,
+			status.CurrentUplinkIntf) == nil
+}
+
+// delegatedPrefixesArrived returns the network instances which wait for a
+// delegated prefix which is there now
+func delegatedPrefixesArrived(ctx *zedrouterContext) []*types.NetworkInstanceStatus {
+	var arrived []*types.NetworkInstanceStatus
+	for _, status := range ctx.networkInstanceStatusMap {
+		if status.AwaitingDelegatedPrefix && !awaitDelegatedPrefix(ctx, status) {
+			arrived = append(arrived, status)
+		}
+	}
+	return arrived
+}
+
+const delegatedPrefixErrorPrefix = "delegated prefix: "
+
+// checkDelegatedPrefixes starts the network instances which waited for the
+// prefix delegated to their port, and reports the network instances whose
+// subnet is no longer part of it
+// XXX we could renumber the network instance and its apps instead
+func checkDelegatedPrefixes(ctx *zedrouterContext) {
+	for _, status := range delegatedPrefixesArrived(ctx) {
+		log.Noticef("checkDelegatedPrefixes(%s): starting with the prefix on %s",
+			status.Key(), status.CurrentUplinkIntf)
+		startNetworkInstance(ctx, status)
+	}
+	for _, status := range ctx.networkInstanceStatusMap {
+		if !status.IsLocalIPv6() ||
+			status.IPv6.Uplink != types.IPv6UplinkPrefixDelegation ||
+			status.Subnet.IP == nil {
+			continue
+		}
+		subnet, err := lookupDelegatedSubnet(ctx, status)
+		if err == nil && types.EqualSubnet(subnet, status.Subnet) {
+			if strings.HasPrefix(status.Error, delegatedPrefixErrorPrefix) {
+				status.ClearError()
+				publishNetworkInstanceStatus(ctx, status)
+			}
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("subnet changed from %s to %s",
+				status.Subnet.String(), subnet.String())
+		}
+		log.Errorf("checkDelegatedPrefixes(%s): %s", status.Key(), err)
+		status.SetErrorNow(delegatedPrefixErrorPrefix + err.Error())
+		publishNetworkInstanceStatus(ctx, status)
+	}
+}
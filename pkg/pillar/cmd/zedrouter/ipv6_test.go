@@ -0,0 +1,192 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedrouter
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAddToIPv6(t *testing.T) {
+	testMatrix := map[string]struct {
+		ip       string
+		addition uint
+		expected string
+	}{
+		"Simple": {
+			ip:       "fd00:1::2",
+			addition: 3,
+			expected: "fd00:1::5",
+		},
+		"Carry": {
+			ip:       "fd00:1::ffff",
+			addition: 1,
+			expected: "fd00:1::1:0",
+		},
+		"Wrap around": {
+			ip:       "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			addition: 2,
+			expected: "::1",
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		res := addToIPv6(net.ParseIP(test.ip), test.addition)
+		if !res.Equal(net.ParseIP(test.expected)) {
+			t.Errorf("%s: got %s, expected %s", testname, res, test.expected)
+		}
+	}
+}
+
+func TestEui64Address(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	mac, _ := net.ParseMAC("00:16:3e:01:02:03")
+	res := eui64Address(*subnet, mac)
+	expected := net.ParseIP("2001:db8:1:2:216:3eff:fe01:203")
+	if !res.Equal(expected) {
+		t.Errorf("got %s, expected %s", res, expected)
+	}
+}
+
+func TestReplacePrefix(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	res := replacePrefix(net.ParseIP("fd00::1:2"), *subnet)
+	expected := net.ParseIP("2001:db8:1:2::1:2")
+	if !res.Equal(expected) {
+		t.Errorf("got %s, expected %s", res, expected)
+	}
+}
+
+func TestDelegatedSubnet(t *testing.T) {
+	testMatrix := map[string]struct {
+		prefix      string
+		index       int
+		expectFail  bool
+		expectedNet string
+	}{
+		"Slash 64": {
+			prefix:      "2001:db8:1:2::/64",
+			index:       0,
+			expectedNet: "2001:db8:1:2::/64",
+		},
+		"Slash 56": {
+			prefix:      "2001:db8:1:200::/56",
+			index:       5,
+			expectedNet: "2001:db8:1:205::/64",
+		},
+		"Slash 60 host bits": {
+			prefix:      "2001:db8:1:2ff::1/60",
+			index:       15,
+			expectedNet: "2001:db8:1:2ff::/64",
+		},
+		"Too small": {
+			prefix:     "2001:db8:1:200::/62",
+			index:      4,
+			expectFail: true,
+		},
+		"Longer than 64": {
+			prefix:     "2001:db8:1:2::/80",
+			index:      0,
+			expectFail: true,
+		},
+		"IPv4": {
+			prefix:     "10.1.0.0/16",
+			index:      0,
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		ip, prefix, _ := net.ParseCIDR(test.prefix)
+		prefix.IP = ip
+		subnet, err := delegatedSubnet(*prefix, test.index)
+		if test.expectFail {
+			if err == nil {
+				t.Errorf("%s: expected failure, got %s", testname,
+					subnet.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected failure %s", testname, err)
+			continue
+		}
+		if subnet.String() != test.expectedNet {
+			t.Errorf("%s: got %s, expected %s", testname,
+				subnet.String(), test.expectedNet)
+		}
+	}
+}
+
+func TestNetworkInstanceAwaitingDelegatedPrefix(t *testing.T) {
+	logger = logrus.StandardLogger()
+	log = base.NewSourceLogObject(logger, agentName, 0)
+	ps := pubsub.New(&pubsub.EmptyDriver{}, logger, log)
+	pub, err := ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: agentName,
+		TopicType: types.NetworkInstanceStatus{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &zedrouterContext{
+		pubNetworkInstanceStatus: pub,
+		networkInstanceStatusMap: make(map[uuid.UUID]*types.NetworkInstanceStatus),
+		deviceNetworkStatus: &types.DeviceNetworkStatus{
+			Ports: []types.NetworkPortStatus{{IfName: "eth0"}},
+		},
+	}
+	status := &types.NetworkInstanceStatus{
+		NetworkInstanceConfig: types.NetworkInstanceConfig{
+			UUIDandVersion: types.UUIDandVersion{UUID: uuid.NewV4()},
+			Type:           types.NetworkInstanceTypeLocal,
+			IpType:         types.AddressTypeIPV6,
+			Logicallabel:   "eth0",
+			IPv6:           types.IPv6Config{Uplink: types.IPv6UplinkPrefixDelegation},
+			Activate:       true,
+		},
+		NetworkInstanceInfo: types.NetworkInstanceInfo{
+			IPAssignments: make(map[string]net.IP),
+		},
+	}
+	status.CurrentUplinkIntf = "eth0"
+	status.ChangeInProgress = types.ChangeInProgressTypeCreate
+	ctx.networkInstanceStatusMap[status.UUID] = status
+
+	// Created before DHCPv6 delegates the prefix
+	startNetworkInstance(ctx, status)
+	if !status.AwaitingDelegatedPrefix {
+		t.Fatal("not waiting for the delegated prefix")
+	}
+	if status.HasError() || status.BridgeName != "" || status.Activated ||
+		status.ChangeInProgress != types.ChangeInProgressTypeNone {
+		t.Errorf("unexpected status of a waiting network instance: %+v", status)
+	}
+	published, err := pub.Get(status.Key())
+	if err != nil || !published.(types.NetworkInstanceStatus).AwaitingDelegatedPrefix {
+		t.Errorf("waiting network instance not published: %v", err)
+	}
+	// Not reported as an error while waiting
+	checkDelegatedPrefixes(ctx)
+	if status.HasError() {
+		t.Errorf("error while waiting for the delegated prefix: %s", status.Error)
+	}
+	if arrived := delegatedPrefixesArrived(ctx); len(arrived) != 0 {
+		t.Errorf("%d network instances started without a prefix", len(arrived))
+	}
+
+	// The prefix arrives
+	_, prefix, _ := net.ParseCIDR("2001:db8:1:200::/56")
+	ctx.deviceNetworkStatus.Ports[0].DelegatedPrefixes = []net.IPNet{*prefix}
+	arrived := delegatedPrefixesArrived(ctx)
+	if len(arrived) != 1 || arrived[0] != status {
+		t.Fatalf("got %v, expected the waiting network instance", arrived)
+	}
+}
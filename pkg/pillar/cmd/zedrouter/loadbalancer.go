@@ -0,0 +1,568 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Load balancing of the connections to the lport of the port map ACEs
+// which refer to a load balancer of a local network instance.
+// The backend for a new connection from the uplink, or from an app instance
+// on the bridge to an address of the device other than the bridge IP
+// (hairpin), is picked in the mangle table, where it jumps to the marking
+// chain of the backend so that the flow log attributes the connection to
+// the app instance and ACE of the backend. The nat table then does the DNAT
+// to the backend based on the mark. Only the healthy backends are in the
+// chains; the health checks run in go routines and report back to the main
+// loop.
+
+package zedrouter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/iptables"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+type lbBackend struct {
+	status    types.LBBackendStatus
+	appNum    int
+	ruleID    int32
+	protocol  string
+	lport     string
+	successes uint32
+	failures  uint32
+	checking  bool
+	lastCheck time.Time
+}
+
+func (be *lbBackend) key() string {
+	return fmt.Sprintf("%s-%d-%s-%d", be.status.VifName, be.ruleID,
+		be.status.IPAddr.String(), be.status.Port)
+}
+
+func (be *lbBackend) mark() int32 {
+	return int32(be.appNum<<24) | be.ruleID
+}
+
+func (be *lbBackend) chainName() string {
+	return fmt.Sprintf("lb-%s-%d", be.status.VifName, be.ruleID)
+}
+
+type loadBalancer struct {
+	config   types.LoadBalancerConfig
+	backends []*lbBackend
+	err      string
+}
+
+// The load balancers of one network instance and what is programmed
+type niLoadBalancers struct {
+	bridgeName string
+	bridgeIP   string
+	uplink     string
+	ipVer      int
+	lbs        []*loadBalancer
+	markChains []string
+}
+
+type lbHealthResult struct {
+	netUUID    uuid.UUID
+	lbName     string
+	backendKey string
+	err        error
+	time       time.Time
+}
+
+func lbIptableCmd(ipVer int, args ...string) error {
+	if ipVer == 6 {
+		return iptables.Ip6tableCmd(log, args...)
+	}
+	return iptables.IptableCmd(log, args...)
+}
+
+// lbChainName is used for the chains in both the mangle and nat tables
+func lbChainName(bridgeName string) string {
+	return "lb-" + bridgeName
+}
+
+// lbCollectBackends returns the backends of the load balancers of the
+// network instance based on the port map ACEs of the app instances
+func lbCollectBackends(ctx *zedrouterContext,
+	status *types.NetworkInstanceStatus) map[string][]*lbBackend {
+
+	backends := make(map[string][]*lbBackend)
+	pub := ctx.pubAppNetworkStatus
+	items := pub.GetAll()
+	for _, st := range items {
+		appNetStatus := st.(types.AppNetworkStatus)
+		if !appNetStatus.Activated || appNetStatus.PendingDelete {
+			continue
+		}
+		appNetConfig := lookupAppNetworkConfig(ctx, appNetStatus.Key())
+		if appNetConfig == nil {
+			continue
+		}
+		for i, ulStatus := range appNetStatus.UnderlayNetworkList {
+			if ulStatus.Network != status.UUID ||
+				ulStatus.AllocatedIPAddr == "" ||
+				i >= len(appNetConfig.UnderlayNetworkList) {
+				continue
+			}
+			ip := net.ParseIP(ulStatus.AllocatedIPAddr)
+			if ip == nil {
+				continue
+			}
+			for _, ace := range appNetConfig.UnderlayNetworkList[i].ACLs {
+				var protocol, lport string
+				for _, match := range ace.Matches {
+					switch match.Type {
+					case "protocol":
+						protocol = match.Value
+					case "lport":
+						lport = match.Value
+					}
+				}
+				for _, action := range ace.Actions {
+					if !action.PortMap || action.LoadBalancer == "" {
+						continue
+					}
+					be := &lbBackend{
+						status: types.LBBackendStatus{
+							AppUUID: appNetStatus.UUIDandVersion.UUID,
+							VifName: ulStatus.Vif,
+							IPAddr:  ip,
+							Port:    action.TargetPort,
+						},
+						appNum:   appNetStatus.AppNum,
+						ruleID:   ace.RuleID,
+						protocol: protocol,
+						lport:    lport,
+					}
+					backends[action.LoadBalancer] = append(
+						backends[action.LoadBalancer], be)
+				}
+			}
+		}
+	}
+	return backends
+}
+
+// lbUpdate determines the backends of the load balancers of an activated
+// local network instance and reprograms iptables. The health of the known
+// backends is kept unless the load balancer configuration changed.
+// Updates the LoadBalancerStatus but does not publish.
+func lbUpdate(ctx *zedrouterContext, status *types.NetworkInstanceStatus) {
+	if status.Type != types.NetworkInstanceTypeLocal ||
+		len(status.LoadBalancers) == 0 || status.CurrentUplinkIntf == "" {
+		lbDelete(ctx, status)
+		return
+	}
+	log.Functionf("lbUpdate(%s)", status.Key())
+	old := ctx.loadBalancers[status.UUID]
+	if old != nil && (old.uplink != status.CurrentUplinkIntf ||
+		old.bridgeName != status.BridgeName ||
+		old.bridgeIP != status.BridgeIPAddr) {
+		lbDelete(ctx, status)
+		old = nil
+	}
+	ipVer := 4
+	if status.IsIPv6() {
+		ipVer = 6
+	}
+	nlbs := &niLoadBalancers{
+		bridgeName: status.BridgeName,
+		bridgeIP:   status.BridgeIPAddr,
+		uplink:     status.CurrentUplinkIntf,
+		ipVer:      ipVer,
+	}
+	backends := lbCollectBackends(ctx, status)
+	for _, lbConfig := range status.LoadBalancers {
+		lb := &loadBalancer{config: lbConfig}
+		var oldLB *loadBalancer
+		if old != nil {
+			oldLB = old.lookup(lbConfig.Name)
+		}
+		for _, be := range backends[lbConfig.Name] {
+			if be.protocol != backends[lbConfig.Name][0].protocol ||
+				be.lport != backends[lbConfig.Name][0].lport {
+				lb.err = fmt.Sprintf("port map ACEs with different protocol or lport: %s/%s and %s/%s",
+					be.protocol, be.lport,
+					backends[lbConfig.Name][0].protocol,
+					backends[lbConfig.Name][0].lport)
+				log.Errorf("lbUpdate(%s) load balancer %s: %s",
+					status.Key(), lbConfig.Name, lb.err)
+				lb.backends = nil
+				break
+			}
+			if oldLB != nil && oldLB.config == lbConfig {
+				if oldBe := oldLB.lookup(be.key()); oldBe != nil {
+					lb.backends = append(lb.backends, oldBe)
+					continue
+				}
+			}
+			if lbConfig.HealthCheck.Type == types.LBHealthCheckNone {
+				be.status.Healthy = true
+			}
+			lb.backends = append(lb.backends, be)
+		}
+		nlbs.lbs = append(nlbs.lbs, lb)
+	}
+	if old == nil {
+		if err := lbCreateChains(nlbs); err != nil {
+			log.Errorf("lbUpdate(%s): %s", status.Key(), err)
+			lbDeleteChains(nlbs)
+			status.LoadBalancerStatus = nil
+			return
+		}
+	} else {
+		nlbs.markChains = old.markChains
+	}
+	lbProgram(nlbs)
+	ctx.loadBalancers[status.UUID] = nlbs
+	status.LoadBalancerStatus = nlbs.getStatus()
+}
+
+// lbUpdateAll updates the load balancers of all activated network
+// instances after a change to the app instances
+func lbUpdateAll(ctx *zedrouterContext) {
+	for _, status := range ctx.networkInstanceStatusMap {
+		if len(status.LoadBalancers) == 0 &&
+			ctx.loadBalancers[status.UUID] == nil {
+			continue
+		}
+		if !status.Activated {
+			continue
+		}
+		lbUpdate(ctx, status)
+		publishNetworkInstanceStatus(ctx, status)
+	}
+}
+
+// lbDelete removes the load balancers of a network instance
+func lbDelete(ctx *zedrouterContext, status *types.NetworkInstanceStatus) {
+	status.LoadBalancerStatus = nil
+	nlbs := ctx.loadBalancers[status.UUID]
+	if nlbs == nil {
+		return
+	}
+	log.Functionf("lbDelete(%s)", status.Key())
+	lbDeleteChains(nlbs)
+	delete(ctx.loadBalancers, status.UUID)
+}
+
+func (nlbs *niLoadBalancers) lookup(name string) *loadBalancer {
+	for _, lb := range nlbs.lbs {
+		if lb.config.Name == name {
+			return lb
+		}
+	}
+	return nil
+}
+
+func (lb *loadBalancer) lookup(key string) *lbBackend {
+	for _, be := range lb.backends {
+		if be.key() == key {
+			return be
+		}
+	}
+	return nil
+}
+
+func (lb *loadBalancer) healthyBackends() []*lbBackend {
+	var healthy []*lbBackend
+	for _, be := range lb.backends {
+		if be.status.Healthy {
+			healthy = append(healthy, be)
+		}
+	}
+	return healthy
+}
+
+func (nlbs *niLoadBalancers) getStatus() []types.LoadBalancerStatus {
+	var lbStatus []types.LoadBalancerStatus
+	for _, lb := range nlbs.lbs {
+		st := types.LoadBalancerStatus{
+			Name:  lb.config.Name,
+			Error: lb.err,
+		}
+		for _, be := range lb.backends {
+			st.Protocol = be.protocol
+			st.Port = be.lport
+			st.Backends = append(st.Backends, be.status)
+		}
+		lbStatus = append(lbStatus, st)
+	}
+	return lbStatus
+}
+
+// lbJumps returns the table and the match of the jumps from PREROUTING
+// to the chains, for the connections from the uplink and the hairpin
+// connections from the bridge. Only new connections go through the mangle
+// chain; the others get their mark restored by the flow match rules.
+func lbJumps(nlbs *niLoadBalancers) [][]string {
+	hairpin := []string{"-i", nlbs.bridgeName}
+	if nlbs.bridgeIP != "" {
+		// Not the services of the bridge, such as DNS
+		hairpin = append(hairpin, "!", "-d", nlbs.bridgeIP)
+	}
+	var jumps [][]string
+	for _, match := range [][]string{{"-i", nlbs.uplink}, hairpin} {
+		jumps = append(jumps,
+			append([]string{"mangle"}, append(match,
+				"-m", "conntrack", "--ctstate", "NEW")...),
+			append([]string{"nat"}, match...))
+	}
+	return jumps
+}
+
+// lbCreateChains creates the chains in the mangle and nat tables and
+// jumps to them from PREROUTING
+func lbCreateChains(nlbs *niLoadBalancers) error {
+	chainName := lbChainName(nlbs.bridgeName)
+	for _, table := range []string{"mangle", "nat"} {
+		err := lbIptableCmd(nlbs.ipVer, "-t", table, "-N", chainName)
+		if err != nil && !strings.Contains(err.Error(), "Chain already exists") {
+			return err
+		}
+	}
+	for _, jump := range lbJumps(nlbs) {
+		args := append([]string{"-t", jump[0], "-I", "PREROUTING"}, jump[1:]...)
+		if err := lbIptableCmd(nlbs.ipVer, append(args, "-j", chainName)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lbDeleteChains(nlbs *niLoadBalancers) {
+	chainName := lbChainName(nlbs.bridgeName)
+	for _, jump := range lbJumps(nlbs) {
+		args := append([]string{"-t", jump[0], "-D", "PREROUTING"}, jump[1:]...)
+		lbIptableCmd(nlbs.ipVer, append(args, "-j", chainName)...)
+	}
+	for _, table := range []string{"mangle", "nat"} {
+		lbIptableCmd(nlbs.ipVer, "-t", table, "--flush", chainName)
+		lbIptableCmd(nlbs.ipVer, "-t", table, "-X", chainName)
+	}
+	for _, markChain := range nlbs.markChains {
+		lbIptableCmd(nlbs.ipVer, "-t", "mangle", "--flush", markChain)
+		lbIptableCmd(nlbs.ipVer, "-t", "mangle", "-X", markChain)
+	}
+	nlbs.markChains = nil
+}
+
+// lbSelector returns the match for backend i out of n
+func lbSelector(algorithm types.LoadBalancerAlgorithm, ipVer int,
+	i int, n int) []string {
+
+	if i == n-1 && algorithm == types.LBAlgorithmRoundRobin {
+		// The remaining connections
+		return nil
+	}
+	switch algorithm {
+	case types.LBAlgorithmSourceHash:
+		// Split the range of the last byte of the source address
+		offset := 12
+		if ipVer == 6 {
+			offset = 20
+		}
+		low := i * 256 / n
+		high := (i+1)*256/n - 1
+		return []string{"-m", "u32", "--u32",
+			fmt.Sprintf("%d&0xFF=%d:%d", offset, low, high)}
+	default:
+		// Every (n-i)th of the connections which did not match the
+		// earlier backends
+		return []string{"-m", "statistic", "--mode", "nth",
+			"--every", strconv.Itoa(n - i), "--packet", "0"}
+	}
+}
+
+// lbProgram replaces the content of the chains with the healthy backends
+func lbProgram(nlbs *niLoadBalancers) {
+	chainName := lbChainName(nlbs.bridgeName)
+	for _, table := range []string{"mangle", "nat"} {
+		if err := lbIptableCmd(nlbs.ipVer, "-t", table, "--flush", chainName); err != nil {
+			log.Errorf("lbProgram(%s): %s", nlbs.bridgeName, err)
+		}
+	}
+	aclArgs := types.AppNetworkACLArgs{IPVer: nlbs.ipVer}
+	var markChains []string
+	for _, lb := range nlbs.lbs {
+		healthy := lb.healthyBackends()
+		for i, be := range healthy {
+			markChain := be.chainName()
+			if err := createMarkAndAcceptChain(aclArgs, markChain, be.mark()); err != nil {
+				log.Errorf("lbProgram(%s): %s", nlbs.bridgeName, err)
+				continue
+			}
+			markChains = append(markChains, markChain)
+			rule := []string{"-t", "mangle", "-A", chainName,
+				"-p", be.protocol, "--dport", be.lport,
+				"-m", "addrtype", "--dst-type", "LOCAL"}
+			rule = append(rule, lbSelector(lb.config.Algorithm, nlbs.ipVer,
+				i, len(healthy))...)
+			rule = append(rule, "-j", markChain)
+			if err := lbIptableCmd(nlbs.ipVer, rule...); err != nil {
+				log.Errorf("lbProgram(%s): %s", nlbs.bridgeName, err)
+			}
+			target := fmt.Sprintf("%s:%d", be.status.IPAddr.String(),
+				be.status.Port)
+			if nlbs.ipVer == 6 {
+				target = fmt.Sprintf("[%s]:%d", be.status.IPAddr.String(),
+					be.status.Port)
+			}
+			err := lbIptableCmd(nlbs.ipVer, "-t", "nat", "-A", chainName,
+				"-p", be.protocol, "--dport", be.lport,
+				"-m", "mark", "--mark", strconv.FormatInt(int64(be.mark()), 10),
+				"-j", "DNAT", "--to-destination", target)
+			if err != nil {
+				log.Errorf("lbProgram(%s): %s", nlbs.bridgeName, err)
+			}
+		}
+	}
+	// Remove the marking chains no longer referenced
+	for _, oldChain := range nlbs.markChains {
+		found := false
+		for _, markChain := range markChains {
+			if markChain == oldChain {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lbIptableCmd(nlbs.ipVer, "-t", "mangle", "--flush", oldChain)
+			lbIptableCmd(nlbs.ipVer, "-t", "mangle", "-X", oldChain)
+		}
+	}
+	nlbs.markChains = markChains
+}
+
+// lbHealthCheck checks one backend
+func lbHealthCheck(check types.LBHealthCheck, ip net.IP, port int) error {
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	timeout := time.Duration(check.Timeout) * time.Second
+	switch check.Type {
+	case types.LBHealthCheckTCP:
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	case types.LBHealthCheckHTTP:
+		client := &http.Client{
+			Transport: &http.Transport{DisableKeepAlives: true},
+			Timeout:   timeout,
+			// A redirect is a healthy response
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := client.Get("http://" + addr + check.HTTPPath)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return errors.New("HTTP status " + resp.Status)
+		}
+		return nil
+	}
+	return nil
+}
+
+// lbHealthCheckStart starts the checks which are due
+func lbHealthCheckStart(ctx *zedrouterContext) {
+	now := time.Now()
+	for netUUID, nlbs := range ctx.loadBalancers {
+		for _, lb := range nlbs.lbs {
+			check := lb.config.HealthCheck
+			if check.Type == types.LBHealthCheckNone {
+				continue
+			}
+			interval := time.Duration(check.Interval) * time.Second
+			for _, be := range lb.backends {
+				if be.checking || now.Sub(be.lastCheck) < interval {
+					continue
+				}
+				be.checking = true
+				res := lbHealthResult{
+					netUUID:    netUUID,
+					lbName:     lb.config.Name,
+					backendKey: be.key(),
+				}
+				go func(ip net.IP, port int) {
+					res.err = lbHealthCheck(check, ip, port)
+					res.time = time.Now()
+					ctx.lbHealthResults <- res
+				}(be.status.IPAddr, be.status.Port)
+			}
+		}
+	}
+}
+
+// lbHealthCheckDone updates the health of the backend and reprograms
+// and publishes when it changes
+func lbHealthCheckDone(ctx *zedrouterContext, res lbHealthResult) {
+	nlbs := ctx.loadBalancers[res.netUUID]
+	if nlbs == nil {
+		return
+	}
+	lb := nlbs.lookup(res.lbName)
+	if lb == nil {
+		return
+	}
+	be := lb.lookup(res.backendKey)
+	if be == nil {
+		return
+	}
+	be.checking = false
+	be.lastCheck = res.time
+	check := lb.config.HealthCheck
+	changed := false
+	if res.err == nil {
+		be.failures = 0
+		be.successes++
+		if be.status.LastError != "" {
+			be.status.LastError = ""
+			changed = true
+		}
+		if !be.status.Healthy && be.successes >= check.HealthyThreshold {
+			log.Noticef("lbHealthCheckDone: load balancer %s backend %s is healthy",
+				res.lbName, res.backendKey)
+			be.status.Healthy = true
+			be.status.LastChange = res.time
+			lbProgram(nlbs)
+			changed = true
+		}
+	} else {
+		be.successes = 0
+		be.failures++
+		if be.status.LastError != res.err.Error() {
+			be.status.LastError = res.err.Error()
+			changed = true
+		}
+		if be.status.Healthy && be.failures >= check.UnhealthyThreshold {
+			log.Warnf("lbHealthCheckDone: load balancer %s backend %s is unhealthy: %s",
+				res.lbName, res.backendKey, res.err)
+			be.status.Healthy = false
+			be.status.LastChange = res.time
+			lbProgram(nlbs)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	status := ctx.networkInstanceStatusMap[res.netUUID]
+	if status == nil {
+		return
+	}
+	status.LoadBalancerStatus = nlbs.getStatus()
+	publishNetworkInstanceStatus(ctx, status)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package zedrouter
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+func TestLbSelector(t *testing.T) {
+	testMatrix := map[string]struct {
+		algorithm types.LoadBalancerAlgorithm
+		ipVer     int
+		i         int
+		n         int
+		expected  []string
+	}{
+		"Round robin first": {
+			algorithm: types.LBAlgorithmRoundRobin,
+			ipVer:     4,
+			i:         0,
+			n:         3,
+			expected: []string{"-m", "statistic", "--mode", "nth",
+				"--every", "3", "--packet", "0"},
+		},
+		"Round robin second": {
+			algorithm: types.LBAlgorithmRoundRobin,
+			ipVer:     4,
+			i:         1,
+			n:         3,
+			expected: []string{"-m", "statistic", "--mode", "nth",
+				"--every", "2", "--packet", "0"},
+		},
+		"Round robin last": {
+			algorithm: types.LBAlgorithmRoundRobin,
+			ipVer:     4,
+			i:         2,
+			n:         3,
+			expected:  nil,
+		},
+		"Source hash IPv4 first": {
+			algorithm: types.LBAlgorithmSourceHash,
+			ipVer:     4,
+			i:         0,
+			n:         3,
+			expected:  []string{"-m", "u32", "--u32", "12&0xFF=0:84"},
+		},
+		"Source hash IPv4 last": {
+			algorithm: types.LBAlgorithmSourceHash,
+			ipVer:     4,
+			i:         2,
+			n:         3,
+			expected:  []string{"-m", "u32", "--u32", "12&0xFF=170:255"},
+		},
+		"Source hash IPv6 single": {
+			algorithm: types.LBAlgorithmSourceHash,
+			ipVer:     6,
+			i:         0,
+			n:         1,
+			expected:  []string{"-m", "u32", "--u32", "20&0xFF=0:255"},
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		res := lbSelector(test.algorithm, test.ipVer, test.i, test.n)
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: got %v, expected %v", testname, res, test.expected)
+		}
+	}
+}
+
+func TestLbHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/healthz" {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+	defer server.Close()
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := net.ParseIP(host)
+	port, _ := strconv.Atoi(portStr)
+
+	check := types.LBHealthCheck{
+		Type:     types.LBHealthCheckHTTP,
+		Timeout:  2,
+		HTTPPath: "/healthz",
+	}
+	if err := lbHealthCheck(check, ip, port); err != nil {
+		t.Errorf("HTTP check failed: %s", err)
+	}
+	check.HTTPPath = "/"
+	if err := lbHealthCheck(check, ip, port); err == nil {
+		t.Errorf("HTTP check of unhealthy path succeeded")
+	}
+	check.Type = types.LBHealthCheckTCP
+	if err := lbHealthCheck(check, ip, port); err != nil {
+		t.Errorf("TCP check failed: %s", err)
+	}
+	server.Close()
+	if err := lbHealthCheck(check, ip, port); err == nil {
+		t.Errorf("TCP check of closed port succeeded")
+	}
+}
+
+func TestLbJumps(t *testing.T) {
+	nlbs := &niLoadBalancers{bridgeName: "bn1", bridgeIP: "10.1.0.1",
+		uplink: "eth0", ipVer: 4}
+	expected := [][]string{
+		{"mangle", "-i", "eth0", "-m", "conntrack", "--ctstate", "NEW"},
+		{"nat", "-i", "eth0"},
+		// Hairpin from the app instances on the bridge
+		{"mangle", "-i", "bn1", "!", "-d", "10.1.0.1",
+			"-m", "conntrack", "--ctstate", "NEW"},
+		{"nat", "-i", "bn1", "!", "-d", "10.1.0.1"},
+	}
+	if jumps := lbJumps(nlbs); !reflect.DeepEqual(jumps, expected) {
+		t.Errorf("got %v, expected %v", jumps, expected)
+	}
+}
@@ -277,6 +277,13 @@ func handleNetworkInstanceModify(
 	status := lookupNetworkInstanceStatus(ctx, key)
 	if status != nil {
 		log.Functionf("handleNetworkInstanceModify(%s)\n", key)
+		if status.AwaitingDelegatedPrefix {
+			// Not created yet; it will be with the new config
+			status.NetworkInstanceConfig = config
+			publishNetworkInstanceStatus(ctx, status)
+			log.Functionf("handleNetworkInstanceModify(%s) done\n", key)
+			return
+		}
 		status.ChangeInProgress = types.ChangeInProgressTypeModify
 		pub.Publish(status.Key(), *status)
 		doNetworkInstanceModify(ctx, config, status)
@@ -316,21 +323,41 @@ func handleNetworkInstanceCreate(
 	status.PInfo = make(map[string]types.ProbeInfo)
 	niUpdateNIprobing(ctx, &status)
 
-	err := doNetworkInstanceCreate(ctx, &status)
+	startNetworkInstance(ctx, &status)
+	log.Functionf("handleNetworkInstanceCreate(%s) done\n", key)
+}
+
+// startNetworkInstance creates and activates the network instance, unless
+// it waits for a delegated prefix. checkDelegatedPrefixes starts it once
+// the prefix is there.
+func startNetworkInstance(ctx *zedrouterContext,
+	status *types.NetworkInstanceStatus) {
+
+	key := status.Key()
+	if awaitDelegatedPrefix(ctx, status) {
+		log.Noticef("startNetworkInstance(%s): waiting for a delegated prefix on %s",
+			key, status.CurrentUplinkIntf)
+		status.AwaitingDelegatedPrefix = true
+		status.ChangeInProgress = types.ChangeInProgressTypeNone
+		publishNetworkInstanceStatus(ctx, status)
+		return
+	}
+	status.AwaitingDelegatedPrefix = false
+	err := doNetworkInstanceCreate(ctx, status)
 	if err != nil {
 		log.Errorf("doNetworkInstanceCreate(%s) failed: %s\n",
 			key, err)
 		log.Error(err)
 		status.SetErrorNow(err.Error())
 		status.ChangeInProgress = types.ChangeInProgressTypeNone
-		publishNetworkInstanceStatus(ctx, &status)
+		publishNetworkInstanceStatus(ctx, status)
 		return
 	}
-	pub.Publish(status.Key(), status)
+	ctx.pubNetworkInstanceStatus.Publish(key, *status)
 
-	if config.Activate {
-		log.Functionf("handleNetworkInstanceCreate: Activating network instance")
-		err := doNetworkInstanceActivate(ctx, &status)
+	if status.Activate {
+		log.Functionf("startNetworkInstance: Activating network instance")
+		err := doNetworkInstanceActivate(ctx, status)
 		if err != nil {
 			log.Errorf("doNetworkInstanceActivate(%s) failed: %s\n", key, err)
 			log.Error(err)
@@ -342,10 +369,9 @@ func handleNetworkInstanceCreate(
 	}
 
 	status.ChangeInProgress = types.ChangeInProgressTypeNone
-	publishNetworkInstanceStatus(ctx, &status)
+	publishNetworkInstanceStatus(ctx, status)
 	// Hooks for updating dependent objects
-	checkAndRecreateAppNetwork(ctx, config.UUID)
-	log.Functionf("handleNetworkInstanceCreate(%s) done\n", key)
+	checkAndRecreateAppNetwork(ctx, status.UUID)
 }
 
 func handleNetworkInstanceDelete(ctxArg interface{}, key string,
@@ -378,6 +404,15 @@ func doNetworkInstanceCreate(ctx *zedrouterContext,
 	log.Functionf("NetworkInstance(%s-%s): NetworkType: %d, IpType: %d\n",
 		status.DisplayName, status.UUID, status.Type, status.IpType)
 
+	if status.IsLocalIPv6() &&
+		status.IPv6.Uplink == types.IPv6UplinkPrefixDelegation {
+		if err := setDelegatedSubnet(ctx, status); err != nil {
+			log.Errorf("NetworkInstance(%s-%s): %s",
+				status.DisplayName, status.UUID, err)
+			return err
+		}
+	}
+
 	if err := doNetworkInstanceSanityCheck(ctx, status); err != nil {
 		log.Errorf("NetworkInstance(%s-%s): Sanity Check failed: %s",
 			status.DisplayName, status.UUID, err)
@@ -469,7 +504,8 @@ func doNetworkInstanceCreate(ctx *zedrouterContext,
 	log.Functionf("Creating %s at %s", "DNSMonitor", agentlog.GetMyStack())
 	go DNSMonitor(bridgeName, bridgeNum, ctx, status)
 
-	if status.IsIPv6() {
+	// Local network instances get their router advertisements from dnsmasq
+	if status.IsIPv6() && !status.IsLocalIPv6() {
 		// XXX do we need same logic as for IPv4 dnsmasq to not
 		// advertize as default router? Might we need lower
 		// radvd preference if isolated local network?
@@ -536,6 +572,16 @@ func doNetworkInstanceSanityCheck(
 				status.Gateway)
 			return errors.New(err)
 		}
+		if status.IsLocalIPv6() {
+			err = doNetworkInstanceIPv6SanityCheck(status)
+			if err != nil {
+				return err
+			}
+			if status.IPv6.Addressing == types.IPv6AddressingSLAAC {
+				// No DhcpRange
+				break
+			}
+		}
 		err = DoNetworkInstanceStatusDhcpRangeSanityCheck(status)
 		if err != nil {
 			return err
@@ -655,6 +701,21 @@ func doNetworkInstanceModify(ctx *zedrouterContext,
 		return
 	}
 
+	if !config.IPFIXExporter.Equal(status.IPFIXExporter) {
+		log.Functionf("doNetworkInstanceModify: IPFIX exporter %+v -> %+v\n",
+			status.IPFIXExporter, config.IPFIXExporter)
+		status.IPFIXExporter = config.IPFIXExporter
+	}
+
+	if !types.LoadBalancerEqual(config.LoadBalancers, status.LoadBalancers) {
+		log.Functionf("doNetworkInstanceModify: load balancers %+v -> %+v\n",
+			status.LoadBalancers, config.LoadBalancers)
+		status.LoadBalancers = config.LoadBalancers
+		if status.Activated {
+			lbUpdate(ctx, status)
+		}
+	}
+
 	if config.Activate && !status.Activated {
 		err := doNetworkInstanceActivate(ctx, status)
 		if err != nil {
@@ -778,12 +839,12 @@ func createHostDnsmasqFile(ctx *zedrouterContext, bridge string) {
 }
 
 // Returns an IP address as a string, or "" if not found.
-func lookupOrAllocateIPv4(
+func lookupOrAllocateIP(
 	ctx *zedrouterContext,
 	status *types.NetworkInstanceStatus,
 	mac net.HardwareAddr) (string, error) {
 
-	log.Functionf("lookupOrAllocateIPv4(%s-%s): mac:%s\n",
+	log.Functionf("lookupOrAllocateIP(%s-%s): mac:%s\n",
 		status.DisplayName, status.Key(), mac.String())
 	// Lookup to see if it exists
 	if ip, ok := status.IPAssignments[mac.String()]; ok {
@@ -792,6 +853,17 @@ func lookupOrAllocateIPv4(
 		return ip.String(), nil
 	}
 
+	// With SLAAC the app picks the EUI-64 address from the prefix
+	// XXX apps using RFC 4941 temporary addresses are not tracked
+	if status.IsLocalIPv6() &&
+		status.IPv6.Addressing == types.IPv6AddressingSLAAC {
+		a := eui64Address(status.Subnet, mac)
+		log.Functionf("lookupOrAllocateIP(%s) SLAAC address %s\n",
+			mac.String(), a.String())
+		recordIPAssignment(ctx, status, a, mac.String())
+		return a.String(), nil
+	}
+
 	log.Functionf("bridgeName %s Subnet %v range %v-%v\n",
 		status.BridgeName, status.Subnet,
 		status.DhcpRange.Start, status.DhcpRange.End)
@@ -820,19 +892,19 @@ func lookupOrAllocateIPv4(
 	for status.DhcpRange.End == nil ||
 		bytes.Compare(a, status.DhcpRange.End) <= 0 {
 
-		log.Functionf("lookupOrAllocateIPv4(%s) testing %s\n",
+		log.Functionf("lookupOrAllocateIP(%s) testing %s\n",
 			mac.String(), a.String())
 		if status.IsIpAssigned(a) {
 			a = addToIP(a, 1)
 			continue
 		}
-		log.Functionf("lookupOrAllocateIPv4(%s) found free %s\n",
+		log.Functionf("lookupOrAllocateIP(%s) found free %s\n",
 			mac.String(), a.String())
 
 		recordIPAssignment(ctx, status, a, mac.String())
 		return a.String(), nil
 	}
-	errStr := fmt.Sprintf("lookupOrAllocateIPv4(%s) no free address in DhcpRange",
+	errStr := fmt.Sprintf("lookupOrAllocateIP(%s) no free address in DhcpRange",
 		status.Key())
 	return "", errors.New(errStr)
 }
@@ -846,11 +918,11 @@ func recordIPAssignment(ctx *zedrouterContext,
 	publishNetworkInstanceStatus(ctx, status)
 }
 
-// Add to an IPv4 address
+// Add to an IPv4 or IPv6 address
 func addToIP(ip net.IP, addition uint) net.IP {
 	addr := ip.To4()
 	if addr == nil {
-		log.Fatalf("addIP: not an IPv4 address %s", ip.String())
+		return addToIPv6(ip, addition)
 	}
 	val := uint(addr[0])<<24 + uint(addr[1])<<16 +
 		uint(addr[2])<<8 + uint(addr[3])
@@ -981,7 +1053,8 @@ func setBridgeIPAddr(
 	}
 
 	// Create new radvd configuration and restart radvd if ipv6
-	if status.IsIPv6() {
+	// unless dnsmasq sends the router advertisements
+	if status.IsIPv6() && !status.IsLocalIPv6() {
 		log.Functionf("Restart Radvd\n")
 		restartRadvdWithNewConfig(status.BridgeName)
 	}
@@ -1065,10 +1138,14 @@ func doNetworkInstanceActivate(ctx *zedrouterContext,
 		}
 	case types.NetworkInstanceTypeLocal:
 		err = natActivate(ctx, status)
-		if err == nil {
+		// The meta-data server is only available over IPv4
+		if err == nil && !status.IsIPv6() {
 			err = createServer4(ctx, status.BridgeIPAddr,
 				status.BridgeName)
 		}
+		if err == nil {
+			lbUpdate(ctx, status)
+		}
 
 	case types.NetworkInstanceTypeCloud:
 		err = vpnActivate(ctx, status)
@@ -1149,8 +1226,11 @@ func doNetworkInstanceInactivate(
 	bridgeInactivateforNetworkInstance(ctx, status)
 	switch status.Type {
 	case types.NetworkInstanceTypeLocal:
+		lbDelete(ctx, status)
 		natInactivate(ctx, status, false)
-		deleteServer4(ctx, status.BridgeIPAddr, status.BridgeName)
+		if !status.IsIPv6() {
+			deleteServer4(ctx, status.BridgeIPAddr, status.BridgeName)
+		}
 	case types.NetworkInstanceTypeCloud:
 		vpnInactivate(ctx, status)
 		deleteServer4(ctx, status.BridgeIPAddr, status.BridgeName)
@@ -1183,7 +1263,7 @@ func doNetworkInstanceDelete(
 	if status.BridgeName != "" {
 		stopDnsmasq(status.BridgeName, false, false)
 
-		if status.IsIPv6() {
+		if status.IsIPv6() && !status.IsLocalIPv6() {
 			stopRadvd(status.BridgeName, true)
 		}
 		DNSStopMonitor(status.BridgeNum)
@@ -1381,7 +1461,6 @@ func natActivate(ctx *zedrouterContext,
 	status *types.NetworkInstanceStatus) error {
 
 	log.Functionf("natActivate(%s)\n", status.DisplayName)
-	subnetStr := status.Subnet.String()
 
 	// status.IfNameList should not have more than one interface name.
 	// Put a check anyway.
@@ -1395,8 +1474,7 @@ func natActivate(ctx *zedrouterContext,
 	}
 	for _, a := range status.IfNameList {
 		log.Functionf("Adding iptables rules for %s \n", a)
-		err := iptables.IptableCmd(log, "-t", "nat", "-A", "POSTROUTING", "-o", a,
-			"-s", subnetStr, "-j", "MASQUERADE")
+		err := natMasquerade(status, "-A", a)
 		if err != nil {
 			log.Errorf("IptableCmd failed: %s", err)
 			return err
@@ -1419,7 +1497,6 @@ func natInactivate(ctx *zedrouterContext,
 	status *types.NetworkInstanceStatus, inActivateOld bool) {
 
 	log.Functionf("natInactivate(%s)\n", status.DisplayName)
-	subnetStr := status.Subnet.String()
 	var oldUplinkIntf string
 	if inActivateOld {
 		// XXX Should we instead use status.ProgUplinkIntf
@@ -1427,8 +1504,7 @@ func natInactivate(ctx *zedrouterContext,
 	} else {
 		oldUplinkIntf = status.CurrentUplinkIntf
 	}
-	err := iptables.IptableCmd(log, "-t", "nat", "-D", "POSTROUTING", "-o", oldUplinkIntf,
-		"-s", subnetStr, "-j", "MASQUERADE")
+	err := natMasquerade(status, "-D", oldUplinkIntf)
 	if err != nil {
 		log.Errorf("natInactivate: iptableCmd failed %s\n", err)
 	}
@@ -1442,6 +1518,23 @@ func natInactivate(ctx *zedrouterContext,
 	}
 }
 
+// natMasquerade adds or deletes the MASQUERADE rule for the subnet on the
+// uplink. IPv6 subnets from a delegated prefix are routed instead.
+func natMasquerade(status *types.NetworkInstanceStatus, op string,
+	uplink string) error {
+
+	subnetStr := status.Subnet.String()
+	if !status.IsIPv6() {
+		return iptables.IptableCmd(log, "-t", "nat", op, "POSTROUTING",
+			"-o", uplink, "-s", subnetStr, "-j", "MASQUERADE")
+	}
+	if status.IPv6.Uplink == types.IPv6UplinkPrefixDelegation {
+		return nil
+	}
+	return iptables.Ip6tableCmd(log, "-t", "nat", op, "POSTROUTING",
+		"-o", uplink, "-s", subnetStr, "-j", "MASQUERADE")
+}
+
 func natDelete(status *types.NetworkInstanceStatus) {
 
 	log.Functionf("natDelete(%s)\n", status.DisplayName)
@@ -1703,6 +1796,7 @@ func doNetworkInstanceFallback(
 		if err != nil {
 			log.Errorf("doNetworkInstanceFallback: %s", err)
 		}
+		lbUpdate(ctx, status)
 		status.ProgUplinkIntf = status.CurrentUplinkIntf
 
 		// Use dns server received from DHCP for the current uplink
@@ -43,8 +43,8 @@ func PbrRouteAddAll(bridgeName string, port string) error {
 		log.Errorln(errStr)
 		return errors.New(errStr)
 	}
-	routes := getAllIPv4Routes(ifindex)
-	if routes == nil {
+	routes := append(getAllIPv4Routes(ifindex), getAllIPv6Routes(ifindex)...)
+	if len(routes) == 0 {
 		log.Warnf("PbrRouteAddAll(%s, %s) no routes",
 			bridgeName, port)
 		return nil
@@ -111,8 +111,8 @@ func PbrRouteDeleteAll(bridgeName string, port string) error {
 		log.Errorln(errStr)
 		return errors.New(errStr)
 	}
-	routes := getAllIPv4Routes(ifindex)
-	if routes == nil {
+	routes := append(getAllIPv4Routes(ifindex), getAllIPv6Routes(ifindex)...)
+	if len(routes) == 0 {
 		log.Warnf("PbrRouteDeleteAll(%s, %s) no routes",
 			bridgeName, port)
 		return nil
@@ -285,42 +285,48 @@ func AddOverlayRuleAndRoute(bridgeName string, iifIndex int,
 // out of interface with given index.
 func AddFwMarkRuleToDummy(fwmark uint32, iifIndex int) error {
 
-	r := netlink.NewRule()
 	myTable := baseTableIndex + iifIndex
-	r.Table = myTable
-	r.Mark = int(fwmark)
-	r.Mask = 0x00ffffff
-	// This rule gets added during the starting steps of service.
-	// Other ip rules corresponding to network instances get added after this
-	// and take higher priority. We want this ip rule to match before anything else.
-	// Hence we make the priority of this 1000 and the other rules to have 10000.
-	r.Priority = 1000
-
-	// Avoid duplicate rules
-	_ = netlink.RuleDel(r)
+	// Marked IPv4 and IPv6 packets go to the same table
+	for _, dst := range []string{"0.0.0.0/0", "::/0"} {
+		_, ipnet, err := net.ParseCIDR(dst)
+		if err != nil {
+			errStr := fmt.Sprintf("AddFwMarkRuleToDummy: ParseCIDR of %s failed",
+				dst)
+			return errors.New(errStr)
+		}
+		r := netlink.NewRule()
+		r.Table = myTable
+		r.Mark = int(fwmark)
+		r.Mask = 0x00ffffff
+		if ipnet.IP.To4() != nil {
+			r.Family = syscall.AF_INET
+		} else {
+			r.Family = syscall.AF_INET6
+		}
+		// This rule gets added during the starting steps of service.
+		// Other ip rules corresponding to network instances get added after this
+		// and take higher priority. We want this ip rule to match before anything else.
+		// Hence we make the priority of this 1000 and the other rules to have 10000.
+		r.Priority = 1000
 
-	// Add rule
-	if err := netlink.RuleAdd(r); err != nil {
-		errStr := fmt.Sprintf("AddFwMarkRuleToDummy: RuleAdd %v failed with %s", r, err)
-		log.Errorln(errStr)
-		return errors.New(errStr)
-	}
+		// Avoid duplicate rules
+		_ = netlink.RuleDel(r)
 
-	// Add default route that points to dummy interface.
-	_, ipnet, err := net.ParseCIDR("0.0.0.0/0")
-	if err != nil {
-		errStr := fmt.Sprintf("AddFwMarkRuleToDummy: ParseCIDR of %s failed",
-			"0.0.0.0/0")
-		return errors.New(errStr)
-	}
+		// Add rule
+		if err := netlink.RuleAdd(r); err != nil {
+			errStr := fmt.Sprintf("AddFwMarkRuleToDummy: RuleAdd %v failed with %s", r, err)
+			log.Errorln(errStr)
+			return errors.New(errStr)
+		}
 
-	// Setup a route for the current network's subnet to point out of the given oifIndex
-	rt := netlink.Route{Dst: ipnet, LinkIndex: iifIndex, Table: myTable, Flags: 0}
-	if err := netlink.RouteAdd(&rt); err != nil {
-		errStr := fmt.Sprintf("AddFwMarkRuleToDummy: RouteAdd %s failed: %s",
-			ipnet.String(), err)
-		log.Errorln(errStr)
-		return errors.New(errStr)
+		// Add default route that points to dummy interface.
+		rt := netlink.Route{Dst: ipnet, LinkIndex: iifIndex, Table: myTable, Flags: 0}
+		if err := netlink.RouteAdd(&rt); err != nil {
+			errStr := fmt.Sprintf("AddFwMarkRuleToDummy: RouteAdd %s failed: %s",
+				ipnet.String(), err)
+			log.Errorln(errStr)
+			return errors.New(errStr)
+		}
 	}
 	return nil
 }
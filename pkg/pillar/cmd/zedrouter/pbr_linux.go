@@ -35,6 +35,32 @@ func getAllIPv4Routes(ifindex int) []netlink.Route {
 	return routes
 }
 
+// Return the all IPv6 routes for one interface
+func getAllIPv6Routes(ifindex int) []netlink.Route {
+	table := syscall.RT_TABLE_MAIN
+	filter := netlink.Route{Table: table, LinkIndex: ifindex}
+	fflags := netlink.RT_FILTER_TABLE
+	fflags |= netlink.RT_FILTER_OIF
+	log.Functionf("getAllIPv6Routes(%d) filter %v\n", ifindex, filter)
+	routes, err := netlink.RouteListFiltered(syscall.AF_INET6,
+		&filter, fflags)
+	if err != nil {
+		log.Errorf("getAllIPv6Routes: ifindex %d failed, error %v", ifindex, err)
+		return nil
+	}
+	// Skip the link-local routes
+	var res []netlink.Route
+	for _, rt := range routes {
+		if rt.Dst != nil && rt.Dst.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		res = append(res, rt)
+	}
+	log.Tracef("getAllIPv6Routes(%d) - got %d matches\n",
+		ifindex, len(res))
+	return res
+}
+
 func getDefaultRouteTable() int {
 	return syscall.RT_TABLE_MAIN
 }
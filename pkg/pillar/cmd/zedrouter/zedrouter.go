@@ -88,6 +88,8 @@ type zedrouterContext struct {
 	appStatsMutex             sync.Mutex // to protect the changing appNetworkStatus & appCollectStatsRunning
 	appStatsInterval          uint32
 	aclog                     *logrus.Logger // App Container logger
+	loadBalancers             map[uuid.UUID]*niLoadBalancers
+	lbHealthResults           chan lbHealthResult
 }
 
 var debug = false
@@ -159,6 +161,8 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 		dnsServers:         make(map[string][]net.IP),
 		aclog:              agentlog.CustomLogInit(logrus.InfoLevel),
 		NLaclMap:           make(map[uuid.UUID]map[string]types.ULNetworkACLs),
+		loadBalancers:      make(map[uuid.UUID]*niLoadBalancers),
+		lbHealthResults:    make(chan lbHealthResult),
 	}
 	zedrouterCtx.networkInstanceStatusMap =
 		make(map[uuid.UUID]*types.NetworkInstanceStatus)
@@ -415,6 +419,9 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 	flowStatTimer := flextimer.NewRangeTicker(time.Duration(fmin),
 		time.Duration(fmax))
 
+	// Start the due load balancer health checks every second
+	lbHealthTimer := time.NewTicker(time.Second)
+
 	setProbeTimer(&zedrouterCtx, nhProbeInterval)
 	zedrouterCtx.checkNIUplinks = make(chan bool, 1) // allow one signal without blocking
 
@@ -554,6 +561,15 @@ func Run(ps *pubsub.PubSub, loggerArg *logrus.Logger, logArg *base.LogObject) in
 			ps.CheckMaxTimeTopic(agentName, "lauchHostProbe", start,
 				warningTime, errorTime)
 
+		case <-lbHealthTimer.C:
+			lbHealthCheckStart(&zedrouterCtx)
+
+		case res := <-zedrouterCtx.lbHealthResults:
+			start := time.Now()
+			lbHealthCheckDone(&zedrouterCtx, res)
+			ps.CheckMaxTimeTopic(agentName, "lbHealthCheckDone", start,
+				warningTime, errorTime)
+
 		case <-zedrouterCtx.appNetCreateTimer.C:
 			start := time.Now()
 			log.Tracef("appNetCreateTimer: at %v", time.Now())
@@ -760,6 +776,7 @@ func handleAppNetworkCreate(ctxArg interface{}, key string, configArg interface{
 		releaseAppNetworkResources(ctx, key, &status)
 	}
 	log.Functionf("handleAppNetworkCreate(%s) done\n", key)
+	lbUpdateAll(ctx)
 	// on resource release, check whether any one else
 	// needs it
 	checkAppNetworkErrorAndStartTimer(ctx)
@@ -898,6 +915,7 @@ func appNetworkDoActivateUnderlayNetwork(
 	ulStatus.BridgeIPAddr = bridgeIPAddr
 	// appIPAddr is "" for switch NI. DHCP snoop will set AllocatedIPAddr later
 	ulStatus.AllocatedIPAddr = appIPAddr
+	ulStatus.GuestIP = guestIPConfig(netInstStatus, bridgeIPAddr, appIPAddr)
 	hostsDirpath := runDirname + "/hosts." + bridgeName
 	if appIPAddr != "" {
 		addToHostsConfiglet(hostsDirpath, config.DisplayName,
@@ -1120,7 +1138,7 @@ func getUlAddrs(ctx *zedrouterContext,
 		status.BridgeMac.String())
 	var err error
 	var addr string
-	addr, err = lookupOrAllocateIPv4(ctx, netInstStatus,
+	addr, err = lookupOrAllocateIP(ctx, netInstStatus,
 		status.BridgeMac)
 	if err != nil {
 		log.Errorf("getUlAddrs: Bridge IP address allocation failed %s\n", err)
@@ -1146,7 +1164,7 @@ func getUlAddrs(ctx *zedrouterContext,
 		}
 		log.Functionf("getUlAddrs(%d/%d for %s) app Mac %s\n",
 			ifnum, appNum, netInstStatus.UUID.String(), mac.String())
-		addr, err = lookupOrAllocateIPv4(ctx, netInstStatus, mac)
+		addr, err = lookupOrAllocateIP(ctx, netInstStatus, mac)
 		if err != nil {
 			log.Errorf("getUlAddrs: App IP address allocation failed: %s\n", err)
 			return bridgeIPAddr, appIPAddr, err
@@ -1250,6 +1268,7 @@ func handleAppNetworkModify(ctxArg interface{}, key string,
 		releaseAppNetworkResources(ctx, key, status)
 	}
 	log.Functionf("handleAppNetworkModify(%s) done\n", key)
+	lbUpdateAll(ctx)
 	// on resource release, check whether any one else
 	// needs it
 	checkAppNetworkErrorAndStartTimer(ctx)
@@ -1421,6 +1440,7 @@ func handleDelete(ctx *zedrouterContext, key string,
 	unpublishAppNetworkStatus(ctx, status)
 
 	appNumFree(ctx, status.UUIDandVersion.UUID)
+	lbUpdateAll(ctx)
 	log.Functionf("handleDelete done for %s\n", status.DisplayName)
 }
 
@@ -1706,6 +1726,7 @@ func handleDNSImpl(ctxArg interface{}, key string,
 		status)
 	*ctx.deviceNetworkStatus = status
 	maybeHandleDNS(ctx)
+	checkDelegatedPrefixes(ctx)
 
 	deviceUpdateNIprobing(ctx, &status)
 	if changedDepend != nil {
@@ -1905,7 +1926,8 @@ func checkUnderlayNetworkForPortMapOverlap(ctx *zedrouterContext,
 		for _, ulCfg1 := range ulCfgList1 {
 			network1 := ulCfg1.Network.String()
 			if network == network1 || checkUplinkPortOverlap(ctx, network, network1) {
-				if matchACLsForPortMap(ulCfg.ACLs, ulCfg1.ACLs) {
+				if matchACLsForPortMap(ulCfg.ACLs, ulCfg1.ACLs,
+					network == network1) {
 					log.Functionf("ACL PortMap overlaps for %s, %s\n", network, network1)
 					log.Errorf("app Network(%s) have overlapping portmap rule in %s\n",
 						network, network1)
@@ -26,6 +26,7 @@ import (
 	"github.com/containerd/containerd/leases"
 	"github.com/containerd/containerd/mount"
 	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/oci"
 	"github.com/containerd/containerd/snapshots"
 	"github.com/containerd/typeurl"
 	"github.com/eriknordmark/netlink"
@@ -33,6 +34,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/identity"
+	"github.com/opencontainers/runtime-spec/specs-go"
 
 	v1stat "github.com/containerd/cgroups/stats/v1"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -65,6 +67,10 @@ const (
 	eveOCIMountPointsLabel = "org.lfedge.eve.blk_mounts"
 	// EVEOCIVNCPasswordLabel is OCI runtime spec label that tracks VNC password in OCI Image config
 	EVEOCIVNCPasswordLabel = "org.lfedge.eve.vnc_password"
+	// EVEOCIPodInitLabel and EVEOCIPodSidecarsLabel are OCI runtime spec labels of the main
+	// container of a pod listing its additional containers in the order they are started
+	EVEOCIPodInitLabel     = "org.lfedge.eve.pod.init"
+	EVEOCIPodSidecarsLabel = "org.lfedge.eve.pod.sidecars"
 
 	//TBD: Have a better way to calculate this number.
 	//For now it is based on some trial-and-error experiments
@@ -472,6 +478,32 @@ func (client *Client) CtrCreateTask(ctx context.Context, domainName string) (int
 	return int(task.Pid()), nil
 }
 
+// CtrCreatePodTask creates (but doesn't start) the default task of an additional container
+// of a pod in the network namespace of the main task of the pod with the given PID
+func (client *Client) CtrCreatePodTask(ctx context.Context, containerID string, pid int) (int, error) {
+	if err := client.verifyCtr(ctx, true); err != nil {
+		return 0, fmt.Errorf("CtrCreatePodTask: exception while verifying ctrd client: %s", err.Error())
+	}
+	ctr, err := client.CtrLoadContainer(ctx, containerID)
+	if err != nil {
+		return 0, err
+	}
+	ctrSpec, err := ctr.Spec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	netns := specs.LinuxNamespace{
+		Type: specs.NetworkNamespace,
+		Path: fmt.Sprintf("/proc/%d/ns/net", pid),
+	}
+	err = ctr.Update(ctx, containerd.UpdateContainerOpts(
+		containerd.WithSpec(ctrSpec, oci.WithLinuxNamespace(netns))))
+	if err != nil {
+		return 0, fmt.Errorf("CtrCreatePodTask: couldn't join the network of %d: %v", pid, err)
+	}
+	return client.CtrCreateTask(ctx, containerID)
+}
+
 // CtrListTaskIds returns a list of all known tasks
 func (client *Client) CtrListTaskIds(ctx context.Context) ([]string, error) {
 	if err := client.verifyCtr(ctx, true); err != nil {
@@ -54,6 +54,7 @@ type OCISpec interface {
 	AdjustMemLimit(types.DomainConfig, int64)
 	UpdateVifList([]types.VifInfo)
 	UpdateFromDomain(*types.DomainConfig)
+	UpdateFromContainer(*types.DomainConfig, types.ContainerConfig)
 	UpdateFromVolume(string) error
 	UpdateMounts([]types.DiskStatus) error
 	UpdateEnvVar(map[string]string)
@@ -258,6 +259,14 @@ func (s *ociSpec) AdjustMemLimit(dom types.DomainConfig, addMemory int64) {
 	// update cgroup resource constraints for CPU and memory
 	if s.Linux != nil {
 		m := int64(dom.Memory*1024) + addMemory
+		if dom.Hugepages.Bytes() != 0 {
+			// guest memory is charged to the hugetlb cgroup instead
+			m = addMemory
+			s.Linux.Resources.HugepageLimits = []specs.LinuxHugepageLimit{{
+				Pagesize: dom.Hugepages.String() + "B",
+				Limit:    dom.Hugepages.RoundUpMemory(dom.Memory),
+			}}
+		}
 		s.Linux.Resources.Memory.Limit = &m
 	}
 }
@@ -306,12 +315,28 @@ func (s *ociSpec) UpdateFromDomain(dom *types.DomainConfig) {
 		s.Linux.Resources.Memory.Limit = &m
 		s.Linux.Resources.CPU.Period = &p
 		s.Linux.Resources.CPU.Quota = &q
+		s.Linux.Resources.CPU.Cpus = dom.CPUs
 
 		s.Linux.CgroupsPath = fmt.Sprintf("/%s/%s", ctrdServicesNamespace, dom.GetTaskName())
 	}
 	s.Annotations[EVEOCIVNCPasswordLabel] = dom.VncPasswd
 }
 
+// UpdateFromContainer updates values in the OCI spec of an additional container
+// of a pod based on its EVE ContainerConfig. The resources are limited for the
+// whole pod by the cgroup of the main container, hence it is put under it.
+func (s *ociSpec) UpdateFromContainer(dom *types.DomainConfig, container types.ContainerConfig) {
+	if len(container.Command) != 0 {
+		s.Process.Args = container.Command
+	}
+	s.Process.Env = append(s.Process.Env, container.Env...)
+	if s.Linux != nil {
+		s.Linux.Resources = nil
+		s.Linux.CgroupsPath = fmt.Sprintf("/%s/%s/%s", ctrdServicesNamespace,
+			dom.GetTaskName(), container.Name)
+	}
+}
+
 // UpdateFromVolume updates values in the OCI spec based on the location
 // of an EVE volume. EVE volume's are expected to be structured as directories
 // in the filesystem with either config.json containing the full OCI runtime
@@ -381,7 +381,7 @@ func TestOciSpec(t *testing.T) {
 	}
 
 	conf := &types.DomainConfig{
-		VmConfig: types.VmConfig{Memory: 1234, VCpus: 4},
+		VmConfig: types.VmConfig{Memory: 1234, VCpus: 4, CPUs: "2,3"},
 		VifList: []types.VifInfo{
 			{Vif: "vif0", Bridge: "br0", Mac: "52:54:00:12:34:56", VifUsed: "vif0-ctr"},
 			{Vif: "vif1", Bridge: "br0", Mac: "52:54:00:12:34:57", VifUsed: "vif1-ctr"},
@@ -403,6 +403,7 @@ func TestOciSpec(t *testing.T) {
 	s := spec.Get()
 	assert.Equal(t, int64(1234*1024), *s.Linux.Resources.Memory.Limit)
 	assert.Equal(t, float64(4), float64(*s.Linux.Resources.CPU.Quota)/float64(*s.Linux.Resources.CPU.Period))
+	assert.Equal(t, "2,3", s.Linux.Resources.CPU.Cpus)
 	assert.Equal(t, tmpdir+"/rootfs", s.Root.Path)
 	assert.Equal(t, []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}, s.Process.Env)
 	assert.Equal(t, []string{"/bin/sh", "-c", "/runme.sh"}, s.Process.Args)
@@ -413,6 +414,12 @@ func TestOciSpec(t *testing.T) {
 	assert.Equal(t, []string{"eve", "exec", "pillar", "/opt/zededa/bin/veth.sh", "up", "vif0", "br0", "52:54:00:12:34:56"}, s.Hooks.Prestart[0].Args)
 	assert.Equal(t, []string{"eve", "exec", "pillar", "/opt/zededa/bin/veth.sh", "down", "vif1"}, s.Hooks.Poststop[1].Args)
 	assert.Equal(t, 60, *s.Hooks.Poststop[1].Timeout)
+
+	conf.Hugepages = types.Hugepage2M
+	spec.AdjustMemLimit(*conf, 1024)
+	assert.Equal(t, int64(1024), *s.Linux.Resources.Memory.Limit)
+	assert.Equal(t, []specs.LinuxHugepageLimit{{Pagesize: "2MB", Limit: 2 << 20}},
+		s.Linux.Resources.HugepageLimits)
 }
 
 func TestCreateMountPointExecEnvFiles(t *testing.T) {
@@ -767,6 +774,31 @@ func TestUpdateMounts(t *testing.T) {
 	g.Expect(spec.UpdateMounts(tresAmigos)).To(HaveOccurred())
 }
 
+func TestUpdateFromContainer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := ociSpec{
+		name: "test",
+		Spec: specs.Spec{
+			Process: &specs.Process{
+				Args: []string{"/bin/sh"},
+				Env:  []string{"FOO=foo"},
+			},
+			Linux: &specs.Linux{Resources: &specs.LinuxResources{}},
+		},
+	}
+	dom := types.DomainConfig{AppNum: 1}
+	dom.UUIDandVersion.Version = "2"
+
+	spec.UpdateFromContainer(&dom, types.ContainerConfig{Name: "sidecar", Env: []string{"BAR=bar"}})
+	g.Expect(spec.Process.Args).To(Equal([]string{"/bin/sh"}))
+	g.Expect(spec.Process.Env).To(Equal([]string{"FOO=foo", "BAR=bar"}))
+	g.Expect(spec.Linux.Resources).To(BeNil())
+	g.Expect(spec.Linux.CgroupsPath).To(Equal("/" + ctrdServicesNamespace + "/" + dom.GetTaskName() + "/sidecar"))
+
+	spec.UpdateFromContainer(&dom, types.ContainerConfig{Name: "init", Command: []string{"/bin/true"}})
+	g.Expect(spec.Process.Args).To(Equal([]string{"/bin/true"}))
+}
+
 func TestAddLoader(t *testing.T) {
 	g := NewGomegaWithT(t)
 	specTemplate := ociSpec{
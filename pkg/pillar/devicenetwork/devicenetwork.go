@@ -237,6 +237,7 @@ func MakeDeviceNetworkStatus(log *base.LogObject, globalConfig types.DevicePortC
 		}
 		// Get DNS etc info from dhcpcd. Updates DomainName and DnsServers
 		GetDhcpInfo(log, &globalStatus.Ports[ix])
+		GetDhcp6Info(log, &globalStatus.Ports[ix])
 		GetDNSInfo(log, &globalStatus.Ports[ix])
 
 		// Get used default routers aka gateways from kernel
@@ -275,7 +276,7 @@ func MakeDeviceNetworkStatus(log *base.LogObject, globalConfig types.DevicePortC
 	UpdateResolvConf(log, globalStatus)
 	UpdatePBR(log, globalStatus)
 	// Immediate check
-	UpdateDeviceNetworkGeo(log, time.Second, &globalStatus)
+	updateDeviceNetworkGeo(log, time.Second, &globalStatus)
 	log.Functionf("MakeDeviceNetworkStatus() DONE\n")
 	return globalStatus
 }
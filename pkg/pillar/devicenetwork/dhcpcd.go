@@ -90,7 +90,7 @@ func doDhcpClientActivate(log *base.LogObject, nuc types.NetworkPortConfig) {
 			time.Sleep(10 * time.Second)
 		}
 		log.Functionf("dhcpcd %s not running", nuc.IfName)
-		extras := []string{"-f", "/dhcpcd.conf", "--noipv4ll", "-b", "-t", "0"}
+		extras := []string{"-f", dhcpcdConfig(log, nuc), "--noipv4ll", "-b", "-t", "0"}
 		if nuc.Gateway != nil && nuc.Gateway.String() == "0.0.0.0" {
 			extras = append(extras, "--nogateway")
 		}
@@ -215,6 +215,37 @@ func doDhcpClientInactivate(log *base.LogObject, nuc types.NetworkPortConfig) {
 	}
 }
 
+// dhcpcdConfig returns /dhcpcd.conf, or for a port with PrefixDelegation
+// a copy of it with an interface block requesting an address and a
+// delegated prefix over DHCPv6. The prefix is not assigned to any
+// interface; zedrouter carves /64 subnets for the IPv6 local network
+// instances out of it.
+func dhcpcdConfig(log *base.LogObject, nuc types.NetworkPortConfig) string {
+	const baseConfig = "/dhcpcd.conf"
+	if !nuc.PrefixDelegation {
+		return baseConfig
+	}
+	config, err := ioutil.ReadFile(baseConfig)
+	if err != nil {
+		log.Errorf("dhcpcdConfig(%s): %s", nuc.IfName, err)
+		return baseConfig
+	}
+	// The IAID of the prefix only has to be unique on the device
+	index, err := IfnameToIndex(log, nuc.IfName)
+	if err != nil {
+		log.Errorf("dhcpcdConfig(%s): %s", nuc.IfName, err)
+		return baseConfig
+	}
+	config = append(config, fmt.Sprintf("\ninterface %s\nia_na\nia_pd %d -\n",
+		nuc.IfName, index)...)
+	filename := fmt.Sprintf("/run/dhcpcd.%s.conf", nuc.IfName)
+	if err := ioutil.WriteFile(filename, config, 0644); err != nil {
+		log.Errorf("dhcpcdConfig(%s): %s", nuc.IfName, err)
+		return baseConfig
+	}
+	return filename
+}
+
 func dhcpcdCmd(log *base.LogObject, op string, extras []string, ifname string, background bool) bool {
 	name := "/sbin/dhcpcd"
 	args := append([]string{op}, extras...)
@@ -4,6 +4,7 @@
 package devicenetwork
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -22,6 +23,14 @@ const (
 	MaxDPCCheckIfCount = 2
 )
 
+// The functions which need dhcpcd, a controller, or a geolocation service.
+// Replaced by the network namespace based test harness.
+var (
+	updateDhcpClient          = UpdateDhcpClient
+	verifyDeviceNetworkStatus = VerifyDeviceNetworkStatus
+	updateDeviceNetworkGeo    = UpdateDeviceNetworkGeo
+)
+
 type DPCPending struct {
 	Inprogress bool
 	PendDPC    types.DevicePortConfig
@@ -29,6 +38,8 @@ type DPCPending struct {
 	PendDNS    types.DeviceNetworkStatus
 	PendTimer  *time.Timer
 	TestCount  uint
+	// Dot1xWaitCount counts the tests waiting for 802.1X authentication
+	Dot1xWaitCount uint
 }
 
 type DeviceNetworkContext struct {
@@ -98,6 +109,7 @@ func SetupVerify(ctx *DeviceNetworkContext, index int) {
 	pend2 := MakeDeviceNetworkStatus(log, pending.PendDPC, pending.PendDNS)
 	pending.PendDNS = pend2
 	pending.TestCount = 0
+	pending.Dot1xWaitCount = 0
 	log.Functionf("SetupVerify: Started testing DPC (index %d): %v",
 		ctx.NextDPCIndex,
 		ctx.DevicePortConfigList.PortConfigList[ctx.NextDPCIndex])
@@ -264,10 +276,26 @@ func VerifyPending(ctx *DeviceNetworkContext, pending *DPCPending,
 		// ethN; move MAC address to bridge. Reverse if removed from DPC
 		UpdateBridge(log, runnableDPC, pending.RunningDPC)
 
-		UpdateDhcpClient(log, runnableDPC, pending.RunningDPC)
+		updateDot1x(ctx, runnableDPC, pending.RunningDPC)
+		updateDhcpClient(log, runnableDPC, pending.RunningDPC)
 		pending.RunningDPC = runnableDPC
 		log.Functionf("Running with DPC %v", pending.RunningDPC)
 	}
+
+	// Wait for 802.1X authentication before testing. Ports which
+	// do not authenticate in time are reported as failed.
+	dot1xWaiting, dot1xErrors := checkDot1xPorts(log, runnableDPC)
+	if len(dot1xWaiting) != 0 {
+		if pending.Dot1xWaitCount < MaxDPCRetestCount {
+			pending.Dot1xWaitCount++
+			log.Functionf("VerifyPending: waiting for 802.1X on %v at count %d",
+				dot1xWaiting, pending.Dot1xWaitCount)
+			return types.DPC_DOT1X_WAIT
+		}
+		for _, ifname := range dot1xWaiting {
+			dot1xErrors[ifname] = errors.New("802.1X authentication did not complete")
+		}
+	}
 	pend2 := MakeDeviceNetworkStatus(log, pending.PendDPC, pending.PendDNS)
 	pending.PendDNS = pend2
 
@@ -275,8 +303,12 @@ func VerifyPending(ctx *DeviceNetworkContext, pending *DPCPending,
 	// Hard-coded at 1 for now; at least one interface needs to work
 	const successCount uint = 1
 	ctx.Iteration++
-	rtf, intfStatusMap, err := VerifyDeviceNetworkStatus(log, pending.PendDNS,
+	rtf, intfStatusMap, err := verifyDeviceNetworkStatus(log, pending.PendDNS,
 		successCount, ctx.Iteration, timeout)
+	// EAP failures explain why the ports do not work
+	for ifname, dot1xErr := range dot1xErrors {
+		intfStatusMap.RecordFailure(ifname, dot1xErr.Error())
+	}
 	// Use TestResults to update the DevicePortConfigList and DeviceNetworkStatus
 	// Note that the TestResults will at least have an updated timestamp
 	// for one of the ports.
@@ -389,7 +421,7 @@ func VerifyDevicePortConfig(ctx *DeviceNetworkContext) {
 			// Wait until we hear from domainmgr before applying (dhcp enable/disable)
 			// and testing this new configuration.
 			return
-		case types.DPC_IPDNS_WAIT, types.DPC_INTF_WAIT:
+		case types.DPC_IPDNS_WAIT, types.DPC_INTF_WAIT, types.DPC_DOT1X_WAIT:
 			// Either addressChange or PendTimer will result in calling us again.
 			duration := time.Duration(ctx.DPCTestDuration) * time.Second
 			pending.PendTimer = time.NewTimer(duration)
@@ -477,7 +509,9 @@ func VerifyDevicePortConfig(ctx *DeviceNetworkContext) {
 	DoDNSUpdate(ctx)
 
 	// Did we get a new DPC at index zero?
-	if ctx.DevicePortConfigList.PortConfigList[0].IsDPCUntested() {
+	// Note that DPC_REMOTE_WAIT leaves the DPC we tested as untested.
+	if ctx.DevicePortConfigList.PortConfigList[0].IsDPCUntested() &&
+		(res != types.DPC_REMOTE_WAIT || ctx.NextDPCIndex != 0) {
 		log.Warn("VerifyDevicePortConfig DPC_SUCCESS: New DPC arrived " +
 			"or a old working DPC moved up to top of DPC list while network testing " +
 			"was in progress. Restarting DPC verification.")
@@ -959,13 +993,15 @@ func DoDNSUpdate(ctx *DeviceNetworkContext) {
 		ctx.DeviceNetworkStatus.Testing = false
 		log.Functionf("PublishDeviceNetworkStatus: %+v\n",
 			ctx.DeviceNetworkStatus)
+		ctx.DeviceNetworkStatus.CurrentIndex = ctx.DevicePortConfigList.CurrentIndex
 		ctx.PubDeviceNetworkStatus.Publish("global",
 			*ctx.DeviceNetworkStatus)
 	}
 	ctx.Changed = true
 }
 
-const destFilename = "/etc/resolv.conf"
+// Variable to allow the test harness to run without touching the host
+var destFilename = "/etc/resolv.conf"
 
 // Track changes in DNS servers.
 var lastServers []net.IP
@@ -82,6 +82,62 @@ func GetDhcpInfo(log *base.LogObject, us *types.NetworkPortStatus) {
 	us.Subnet = net.IPNet{IP: subnet, Mask: net.CIDRMask(masklen, 32)}
 }
 
+// GetDhcp6Info gets the IPv6 prefixes delegated to the port from dhcpcd.
+// Updates DelegatedPrefixes
+func GetDhcp6Info(log *base.LogObject, us *types.NetworkPortStatus) {
+
+	log.Functionf("GetDhcp6Info(%s)\n", us.IfName)
+	if us.Dhcp != types.DT_CLIENT {
+		return
+	}
+	if strings.HasPrefix(us.IfName, "wwan") {
+		return
+	}
+	// Fails if there is no DHCPv6 lease which is the common case
+	stdoutStderr, err := base.Exec(log, "dhcpcd", "-U", "-6", us.IfName).CombinedOutput()
+	if err != nil {
+		log.Functionf("dhcpcd -U -6 %s failed %s: %s",
+			us.IfName, string(stdoutStderr), err)
+		return
+	}
+	us.DelegatedPrefixes = parseDelegatedPrefixes(log, us.IfName,
+		string(stdoutStderr))
+}
+
+// parseDelegatedPrefixes looks for pairs of dhcp6_ia_pdN_prefixM and
+// dhcp6_ia_pdN_prefixM_length in the dhcpcd -U output
+func parseDelegatedPrefixes(log *base.LogObject, ifname string, output string) []net.IPNet {
+	var prefixes []net.IPNet
+	values := make(map[string]string)
+	var keys []string
+	for _, line := range strings.Split(output, "\n") {
+		items := strings.SplitN(line, "=", 2)
+		if len(items) != 2 || !strings.HasPrefix(items[0], "dhcp6_ia_pd") {
+			continue
+		}
+		values[items[0]] = trimQuotes(items[1])
+		if !strings.HasSuffix(items[0], "_length") &&
+			strings.Contains(items[0], "_prefix") &&
+			!strings.Contains(items[0], "time") {
+			keys = append(keys, items[0])
+		}
+	}
+	for _, key := range keys {
+		ip := net.ParseIP(values[key])
+		length, err := strconv.Atoi(values[key+"_length"])
+		if ip == nil || err != nil || length > 128 {
+			log.Errorf("GetDhcp6Info(%s) bad delegated prefix %s/%s",
+				ifname, values[key], values[key+"_length"])
+			continue
+		}
+		log.Functionf("GetDhcp6Info(%s) delegated prefix %s/%d",
+			ifname, ip, length)
+		prefixes = append(prefixes, net.IPNet{IP: ip,
+			Mask: net.CIDRMask(length, 128)})
+	}
+	return prefixes
+}
+
 // GetDNSInfo gets DNS info from /run files. Updates DomainName and DnsServers
 func GetDNSInfo(log *base.LogObject, us *types.NetworkPortStatus) {
 
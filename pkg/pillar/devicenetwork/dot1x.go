@@ -0,0 +1,322 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// 802.1X authentication on wired ports using wpa_supplicant with the
+// wired driver. There is one wpa_supplicant per port and we query its
+// state over the control interface using wpa_cli.
+
+package devicenetwork
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/eriknordmark/netlink"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/cipher"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	dot1xDir     = "/run/dot1x"
+	dot1xCtrlDir = dot1xDir + "/ctrl"
+)
+
+// Errors from starting wpa_supplicant; reported when the DPC is tested
+var dot1xStartErrors = make(map[string]error)
+
+// dot1xFiles are the files wpa_supplicant uses for a port
+type dot1xFiles struct {
+	config     string
+	pidFile    string
+	caCert     string
+	clientCert string
+	privateKey string
+}
+
+func getDot1xFiles(ifname string) dot1xFiles {
+	prefix := filepath.Join(dot1xDir, ifname)
+	return dot1xFiles{
+		config:     prefix + ".conf",
+		pidFile:    prefix + ".pid",
+		caCert:     prefix + "-ca.pem",
+		clientCert: prefix + "-cert.pem",
+		privateKey: prefix + "-key.pem",
+	}
+}
+
+// dot1xIfname returns the interface on which to run wpa_supplicant.
+// The 802.1X frames are not forwarded by a bridge hence we use kethN
+// when ethN is a bridge.
+func dot1xIfname(ifname string) string {
+	kernIfname := "k" + ifname
+	if _, err := netlink.LinkByName(kernIfname); err == nil {
+		return kernIfname
+	}
+	return ifname
+}
+
+// updateDot1x starts, restarts or stops wpa_supplicant for the ports
+// whose 802.1X configuration changed
+func updateDot1x(ctx *DeviceNetworkContext, newConfig, oldConfig types.DevicePortConfig) {
+
+	log := ctx.Log
+	for _, oldU := range oldConfig.Ports {
+		if !oldU.Dot1xCfg.Enable {
+			continue
+		}
+		newU := lookupOnIfname(newConfig, oldU.IfName)
+		if newU == nil || !reflect.DeepEqual(newU.Dot1xCfg, oldU.Dot1xCfg) {
+			dot1xStop(log, oldU.IfName)
+		}
+	}
+	for _, newU := range newConfig.Ports {
+		if !newU.Dot1xCfg.Enable {
+			continue
+		}
+		oldU := lookupOnIfname(oldConfig, newU.IfName)
+		if oldU != nil && reflect.DeepEqual(newU.Dot1xCfg, oldU.Dot1xCfg) {
+			continue
+		}
+		if err := dot1xStart(ctx, newU.IfName, newU.Dot1xCfg); err != nil {
+			log.Errorf("updateDot1x(%s): %s", newU.IfName, err)
+			dot1xStartErrors[newU.IfName] = err
+		} else {
+			delete(dot1xStartErrors, newU.IfName)
+		}
+	}
+}
+
+func dot1xStart(ctx *DeviceNetworkContext, ifname string,
+	dot1x types.Dot1xConfig) error {
+
+	log := ctx.Log
+	log.Noticef("dot1xStart(%s) method %s identity %s",
+		ifname, dot1x.EapMethod, dot1x.Identity)
+	// In case one is left from a previous run
+	dot1xStop(log, ifname)
+	if err := os.MkdirAll(dot1xDir, 0700); err != nil {
+		return err
+	}
+	decBlock, err := getDot1xCredential(ctx, ifname, dot1x)
+	if err != nil {
+		return err
+	}
+	files := getDot1xFiles(ifname)
+	config, err := dot1xSupplicantConfig(dot1x, decBlock, files)
+	if err != nil {
+		return err
+	}
+	if len(dot1x.CACertPEM) != 0 {
+		err = ioutil.WriteFile(files.caCert, dot1x.CACertPEM, 0600)
+		if err != nil {
+			return err
+		}
+	}
+	if dot1x.EapMethod == types.Dot1xEapMethodTLS {
+		err = ioutil.WriteFile(files.clientCert, dot1x.ClientCertPEM, 0600)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(files.privateKey,
+			[]byte(decBlock.Dot1xPrivateKey), 0600)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(files.config, []byte(config), 0600); err != nil {
+		return err
+	}
+	out, err := base.Exec(log, "wpa_supplicant", "-B", "-D", "wired",
+		"-i", dot1xIfname(ifname), "-c", files.config,
+		"-P", files.pidFile).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wpa_supplicant failed: %s: %s", err, out)
+	}
+	return nil
+}
+
+func dot1xStop(log *base.LogObject, ifname string) {
+	files := getDot1xFiles(ifname)
+	if _, err := os.Stat(files.pidFile); err == nil {
+		log.Noticef("dot1xStop(%s)", ifname)
+		out, err := base.Exec(log, "wpa_cli", "-p", dot1xCtrlDir,
+			"-i", dot1xIfname(ifname), "terminate").CombinedOutput()
+		if err != nil {
+			log.Errorf("dot1xStop(%s) terminate failed: %s: %s",
+				ifname, err, out)
+		}
+	}
+	for _, filename := range []string{files.config, files.pidFile,
+		files.caCert, files.clientCert, files.privateKey} {
+		os.Remove(filename)
+	}
+	delete(dot1xStartErrors, ifname)
+}
+
+// dot1xSupplicantConfig returns the wpa_supplicant configuration
+func dot1xSupplicantConfig(dot1x types.Dot1xConfig,
+	decBlock types.EncryptionBlock, files dot1xFiles) (string, error) {
+
+	quote := func(name, value string) (string, error) {
+		if strings.ContainsAny(value, "\"\n") {
+			return "", fmt.Errorf("invalid character in %s", name)
+		}
+		return fmt.Sprintf("\t%s=\"%s\"\n", name, value), nil
+	}
+	var lines []string
+	add := func(name, value string) error {
+		line, err := quote(name, value)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		return nil
+	}
+	if dot1x.Identity == "" {
+		return "", errors.New("no 802.1X identity")
+	}
+	if err := add("identity", dot1x.Identity); err != nil {
+		return "", err
+	}
+	switch dot1x.EapMethod {
+	case types.Dot1xEapMethodTLS:
+		if len(dot1x.ClientCertPEM) == 0 || decBlock.Dot1xPrivateKey == "" {
+			return "", errors.New("EAP-TLS needs a client certificate and private key")
+		}
+		lines = append(lines, "\teap=TLS\n")
+		if err := add("client_cert", files.clientCert); err != nil {
+			return "", err
+		}
+		if err := add("private_key", files.privateKey); err != nil {
+			return "", err
+		}
+	case types.Dot1xEapMethodPEAP:
+		if decBlock.Dot1xPassword == "" {
+			return "", errors.New("PEAP needs a password")
+		}
+		lines = append(lines, "\teap=PEAP\n")
+		if dot1x.AnonymousIdentity != "" {
+			err := add("anonymous_identity", dot1x.AnonymousIdentity)
+			if err != nil {
+				return "", err
+			}
+		}
+		if err := add("password", decBlock.Dot1xPassword); err != nil {
+			return "", err
+		}
+		lines = append(lines, "\tphase2=\"auth=MSCHAPV2\"\n")
+	default:
+		return "", fmt.Errorf("unsupported 802.1X EAP method %d",
+			dot1x.EapMethod)
+	}
+	if len(dot1x.CACertPEM) != 0 {
+		if err := add("ca_cert", files.caCert); err != nil {
+			return "", err
+		}
+	}
+	if dot1x.ServerDomainSuffix != "" {
+		err := add("domain_suffix_match", dot1x.ServerDomainSuffix)
+		if err != nil {
+			return "", err
+		}
+	}
+	config := "# Automatically generated\n" +
+		fmt.Sprintf("ctrl_interface=%s\n", dot1xCtrlDir) +
+		"ap_scan=0\n" +
+		"network={\n" +
+		"\tkey_mgmt=IEEE8021X\n" +
+		"\teapol_flags=0\n" +
+		strings.Join(lines, "") +
+		"}\n"
+	return config, nil
+}
+
+func getDot1xCredential(ctx *DeviceNetworkContext, ifname string,
+	dot1x types.Dot1xConfig) (types.EncryptionBlock, error) {
+
+	log := ctx.Log
+	if !dot1x.CipherBlockStatus.IsCipher {
+		cipher.RecordFailure(ctx.AgentName, types.NoData)
+		return types.EncryptionBlock{}, errors.New("no 802.1X credentials")
+	}
+	status, decBlock, err := cipher.GetCipherCredentials(&ctx.DecryptCipherContext,
+		"devicenetwork", dot1x.CipherBlockStatus)
+	if ctx.PubCipherBlockStatus != nil {
+		ctx.PubCipherBlockStatus.Publish(status.Key(), status)
+	}
+	if err != nil {
+		return decBlock, fmt.Errorf("802.1X credentials decryption failed: %v",
+			err)
+	}
+	log.Functionf("%s, 802.1X cipherblock decryption successful", ifname)
+	return decBlock, nil
+}
+
+// getDot1xStatus returns whether the port is authorized, and an error
+// if the authentication failed
+func getDot1xStatus(log *base.LogObject, ifname string) (bool, error) {
+	if err, ok := dot1xStartErrors[ifname]; ok {
+		return false, err
+	}
+	out, err := base.Exec(log, "wpa_cli", "-p", dot1xCtrlDir,
+		"-i", dot1xIfname(ifname), "status").CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("wpa_supplicant not running: %s", err)
+	}
+	return parseDot1xStatus(string(out))
+}
+
+// parseDot1xStatus parses the output of wpa_cli status
+func parseDot1xStatus(status string) (bool, error) {
+	var paeState, eapState, portStatus string
+	for _, line := range strings.Split(status, "\n") {
+		items := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(items) != 2 {
+			continue
+		}
+		switch items[0] {
+		case "Supplicant PAE state":
+			paeState = items[1]
+		case "EAP state":
+			eapState = items[1]
+		case "suppPortStatus":
+			portStatus = items[1]
+		}
+	}
+	if portStatus == "Authorized" {
+		return true, nil
+	}
+	if eapState == "FAILURE" || paeState == "HELD" {
+		return false, fmt.Errorf("802.1X authentication failed: PAE state %s, EAP state %s",
+			paeState, eapState)
+	}
+	return false, nil
+}
+
+// checkDot1xPorts returns the ports where we are waiting for 802.1X
+// authentication, and the authentication errors
+func checkDot1xPorts(log *base.LogObject, dpc types.DevicePortConfig) ([]string, map[string]error) {
+	var waiting []string
+	dot1xErrors := make(map[string]error)
+	for _, port := range dpc.Ports {
+		if !port.Dot1xCfg.Enable {
+			continue
+		}
+		authorized, err := getDot1xStatus(log, port.IfName)
+		if err != nil {
+			log.Warnf("checkDot1xPorts(%s): %s", port.IfName, err)
+			dot1xErrors[port.IfName] = err
+		} else if !authorized {
+			log.Functionf("checkDot1xPorts(%s): authenticating",
+				port.IfName)
+			waiting = append(waiting, port.IfName)
+		}
+	}
+	return waiting, dot1xErrors
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package devicenetwork
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+func TestParseDot1xStatus(t *testing.T) {
+	testMatrix := map[string]struct {
+		status     string
+		authorized bool
+		expectFail bool
+	}{
+		"Authorized": {
+			status: "Supplicant PAE state=AUTHENTICATED\n" +
+				"suppPortStatus=Authorized\nEAP state=SUCCESS\n" +
+				"selectedMethod=25 (EAP-PEAP)\n",
+			authorized: true,
+		},
+		"Authenticating": {
+			status: "Supplicant PAE state=AUTHENTICATING\n" +
+				"suppPortStatus=Unauthorized\nEAP state=METHOD\n",
+		},
+		"Connecting": {
+			status: "Supplicant PAE state=CONNECTING\n" +
+				"suppPortStatus=Unauthorized\nEAP state=IDLE\n",
+		},
+		"EAP failure": {
+			status: "Supplicant PAE state=AUTHENTICATING\n" +
+				"suppPortStatus=Unauthorized\nEAP state=FAILURE\n",
+			expectFail: true,
+		},
+		"Held": {
+			status: "Supplicant PAE state=HELD\n" +
+				"suppPortStatus=Unauthorized\nEAP state=IDLE\n",
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		authorized, err := parseDot1xStatus(test.status)
+		if authorized != test.authorized {
+			t.Errorf("%s: authorized %t, expected %t", testname,
+				authorized, test.authorized)
+		}
+		if (err != nil) != test.expectFail {
+			t.Errorf("%s: error %v, expected failure %t", testname,
+				err, test.expectFail)
+		}
+	}
+}
+
+func TestDot1xSupplicantConfig(t *testing.T) {
+	testMatrix := map[string]struct {
+		ifname     string // eth0 if not set
+		dot1x      types.Dot1xConfig
+		decBlock   types.EncryptionBlock
+		expectFail bool
+		expected   []string
+	}{
+		"PEAP": {
+			dot1x: types.Dot1xConfig{
+				Enable:             true,
+				EapMethod:          types.Dot1xEapMethodPEAP,
+				Identity:           "device1",
+				AnonymousIdentity:  "anonymous",
+				CACertPEM:          []byte("ca"),
+				ServerDomainSuffix: "radius.example.com",
+			},
+			decBlock: types.EncryptionBlock{Dot1xPassword: "secret"},
+			expected: []string{"key_mgmt=IEEE8021X", "eap=PEAP",
+				"identity=\"device1\"", "anonymous_identity=\"anonymous\"",
+				"password=\"secret\"", "phase2=\"auth=MSCHAPV2\"",
+				"ca_cert=\"/run/dot1x/eth0-ca.pem\"",
+				"domain_suffix_match=\"radius.example.com\""},
+		},
+		"TLS": {
+			dot1x: types.Dot1xConfig{
+				Enable:        true,
+				EapMethod:     types.Dot1xEapMethodTLS,
+				Identity:      "device1",
+				ClientCertPEM: []byte("cert"),
+			},
+			decBlock: types.EncryptionBlock{Dot1xPrivateKey: "key"},
+			expected: []string{"eap=TLS",
+				"client_cert=\"/run/dot1x/eth0-cert.pem\"",
+				"private_key=\"/run/dot1x/eth0-key.pem\""},
+		},
+		"TLS without key": {
+			dot1x: types.Dot1xConfig{
+				Enable:        true,
+				EapMethod:     types.Dot1xEapMethodTLS,
+				Identity:      "device1",
+				ClientCertPEM: []byte("cert"),
+			},
+			expectFail: true,
+		},
+		"PEAP without password": {
+			dot1x: types.Dot1xConfig{
+				Enable:    true,
+				EapMethod: types.Dot1xEapMethodPEAP,
+				Identity:  "device1",
+			},
+			expectFail: true,
+		},
+		"Quote in password": {
+			dot1x: types.Dot1xConfig{
+				Enable:    true,
+				EapMethod: types.Dot1xEapMethodPEAP,
+				Identity:  "device1",
+			},
+			decBlock:   types.EncryptionBlock{Dot1xPassword: "a\"b"},
+			expectFail: true,
+		},
+		"Quote in certificate path": {
+			ifname: "eth\"0",
+			dot1x: types.Dot1xConfig{
+				Enable:        true,
+				EapMethod:     types.Dot1xEapMethodTLS,
+				Identity:      "device1",
+				ClientCertPEM: []byte("cert"),
+			},
+			decBlock:   types.EncryptionBlock{Dot1xPrivateKey: "key"},
+			expectFail: true,
+		},
+		"Quote in CA certificate path": {
+			ifname: "eth\"0",
+			dot1x: types.Dot1xConfig{
+				Enable:    true,
+				EapMethod: types.Dot1xEapMethodPEAP,
+				Identity:  "device1",
+				CACertPEM: []byte("ca"),
+			},
+			decBlock:   types.EncryptionBlock{Dot1xPassword: "secret"},
+			expectFail: true,
+		},
+		"No method": {
+			dot1x: types.Dot1xConfig{
+				Enable:   true,
+				Identity: "device1",
+			},
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		ifname := test.ifname
+		if ifname == "" {
+			ifname = "eth0"
+		}
+		files := getDot1xFiles(ifname)
+		config, err := dot1xSupplicantConfig(test.dot1x, test.decBlock, files)
+		if test.expectFail {
+			if err == nil {
+				t.Errorf("%s: expected failure, got %s", testname, config)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected failure %s", testname, err)
+			continue
+		}
+		for _, line := range test.expected {
+			if !strings.Contains(config, "\t"+line+"\n") {
+				t.Errorf("%s: missing %s in %s", testname, line, config)
+			}
+		}
+	}
+}
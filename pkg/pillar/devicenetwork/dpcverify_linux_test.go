@@ -0,0 +1,133 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package devicenetwork
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerifyDPCWorking(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	h.checkCurrent(0, "zedagent")
+	h.checkPort("dut0", true)
+	if !h.ctx.CloudConnectivityWorks {
+		t.Errorf("CloudConnectivityWorks not set")
+	}
+}
+
+func TestVerifyDPCLinkFailure(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	h.setLink("dut0", false)
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	h.checkFailed(0, "dut0")
+	h.checkCurrent(1, "lastresort")
+	h.checkPort("dut1", true)
+}
+
+func TestVerifyDPCDHCPFailure(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	h.setDhcp("dut0", false)
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	h.checkFailed(0, "dut0")
+	h.checkCurrent(1, "lastresort")
+	h.checkPort("dut1", true)
+}
+
+func TestVerifyDPCDNSFailure(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	h.setDNS("dut0", false)
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	h.checkFailed(0, "dut0")
+	h.checkCurrent(1, "lastresort")
+	h.checkPort("dut1", true)
+}
+
+func TestVerifyDPCMissingPort(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0")
+	defer h.close()
+
+	h.setDPCList(h.zedagentDPC("dut9"), h.lastResortDPC("dut0"))
+	h.verify()
+	h.checkFailed(0, "dut9")
+	h.checkCurrent(1, "lastresort")
+	h.checkPort("dut0", true)
+}
+
+func TestVerifyDPCControllerFailure(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	// We reached the controller hence we stay with the DPC
+	h.setController(http.StatusServiceUnavailable)
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	dpcl := h.publishedDPCL()
+	if dpcl.CurrentIndex != 0 {
+		t.Errorf("CurrentIndex %d, expected 0", dpcl.CurrentIndex)
+	}
+	if !h.ctx.CloudConnectivityWorks {
+		t.Errorf("CloudConnectivityWorks not set")
+	}
+}
+
+func TestVerifyDPCFailover(t *testing.T) {
+	if !runInNetns(t) {
+		return
+	}
+	h := newTestHarness(t, "dut0", "dut1")
+	defer h.close()
+
+	h.setDPCList(h.zedagentDPC("dut0"), h.lastResortDPC("dut0", "dut1"))
+	h.verify()
+	h.checkCurrent(0, "zedagent")
+
+	t.Logf("Taking down link of dut0")
+	h.setLink("dut0", false)
+	h.verify()
+	h.checkFailed(0, "dut0")
+	h.checkCurrent(1, "lastresort")
+	h.checkPort("dut1", true)
+
+	t.Logf("Restoring link of dut0")
+	h.setLink("dut0", true)
+	// Make the failed DPC testable again without waiting for a minute
+	dpc := &h.ctx.DevicePortConfigList.PortConfigList[0]
+	dpc.LastFailed = dpc.LastFailed.Add(-2 * time.Minute)
+	h.verify()
+	h.checkCurrent(0, "zedagent")
+	h.checkPort("dut0", true)
+}
@@ -0,0 +1,524 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Test harness for DPC verification which runs the device side in a
+// throwaway network namespace. Each port is one end of a veth pair whose
+// other end is in a second namespace holding the controller stand-in.
+// dhcpcd is replaced by code assigning the lease directly, which
+// allows injecting DHCP, DNS and link failures per port.
+
+package devicenetwork
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/eriknordmark/netlink"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/pubsub"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/lf-edge/eve/pkg/pillar/zedcloud"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netns"
+)
+
+// Set in the environment of the re-executed test binary to the name
+// of the test to run
+const netnsTestEnv = "DEVICENETWORK_NETNS_TEST"
+
+// Address of the controller stand-in in the controller namespace
+const testControllerIP = "192.0.2.1"
+
+// Maximum number of times we fire the pending timer before giving up
+const maxVerifyIterations = 30
+
+// runInNetns returns true when called in the re-executed test binary.
+// Otherwise it runs the test in a new network namespace and returns false.
+// We need a separate process since the namespace of a locked thread does
+// not apply to the goroutines doing the network I/O.
+func runInNetns(t *testing.T) bool {
+	if os.Getenv(netnsTestEnv) == t.Name() {
+		return true
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("network namespace tests need root")
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), netnsTestEnv+"="+t.Name())
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNET}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Skipf("no network namespace support: %s", err)
+		}
+		t.Fatalf("%s in network namespace failed: %s\n%s",
+			t.Name(), err, out)
+	}
+	return false
+}
+
+// testPort is a port of the device with its fake DHCP server
+type testPort struct {
+	ifName   string
+	peerName string
+	subnet   int // Using 10.99.<subnet>.0/24
+	noDhcp   bool
+	noDNS    bool
+	hasLease bool
+}
+
+func (p *testPort) addr() *net.IPNet {
+	return &net.IPNet{IP: net.IPv4(10, 99, byte(p.subnet), 2),
+		Mask: net.CIDRMask(24, 32)}
+}
+
+func (p *testPort) gateway() net.IP {
+	return net.IPv4(10, 99, byte(p.subnet), 1)
+}
+
+type testHarness struct {
+	t          *testing.T
+	log        *base.LogObject
+	dir        string
+	ctlNs      netns.NsHandle
+	ctlHandle  *netlink.Handle
+	controller *httptest.Server
+	ctlURL     string
+	ctlStatus  int32 // HTTP status returned by the controller
+	ports      map[string]*testPort
+	ctx        *DeviceNetworkContext
+}
+
+// newTestHarness creates the controller namespace and a port for each
+// of the ifnames
+func newTestHarness(t *testing.T, ifNames ...string) *testHarness {
+	logger := logrus.StandardLogger()
+	log := base.NewSourceLogObject(logger, "test", 1234)
+	h := &testHarness{
+		t:         t,
+		log:       log,
+		ctlStatus: http.StatusOK,
+		ports:     make(map[string]*testPort),
+	}
+	dir, err := ioutil.TempDir("", "netns_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.dir = dir
+	destFilename = filepath.Join(dir, "resolv.conf")
+	resolveConfDirs = []string{dir}
+	updateDhcpClient = h.updateDhcpClient
+	verifyDeviceNetworkStatus = h.verifyDeviceNetworkStatus
+	updateDeviceNetworkGeo = func(*base.LogObject, time.Duration,
+		*types.DeviceNetworkStatus) bool {
+		return false
+	}
+
+	h.setupController()
+	for i, ifName := range ifNames {
+		h.addPort(ifName, i)
+	}
+
+	ps := pubsub.New(&pubsub.EmptyDriver{}, logger, log)
+	h.ctx = &DeviceNetworkContext{
+		AgentName:              "test",
+		DevicePortConfig:       &types.DevicePortConfig{},
+		DevicePortConfigList:   &types.DevicePortConfigList{},
+		AssignableAdapters:     &types.AssignableAdapters{},
+		DeviceNetworkStatus:    &types.DeviceNetworkStatus{},
+		NetworkTestTimer:       time.NewTimer(time.Hour),
+		NetworkTestBetterTimer: time.NewTimer(time.Hour),
+		NetworkTestInterval:    300,
+		TestSendTimeout:        2,
+		Log:                    log,
+	}
+	h.ctx.Pending.PendTimer = time.NewTimer(time.Hour)
+	h.ctx.PubDummyDevicePortConfig = h.newPublication(ps,
+		types.DevicePortConfig{})
+	h.ctx.PubDevicePortConfigList = h.newPublication(ps,
+		types.DevicePortConfigList{})
+	h.ctx.PubDeviceNetworkStatus = h.newPublication(ps,
+		types.DeviceNetworkStatus{})
+	return h
+}
+
+func (h *testHarness) newPublication(ps *pubsub.PubSub,
+	topicType interface{}) pubsub.Publication {
+	pub, err := ps.NewPublication(pubsub.PublicationOptions{
+		AgentName: "test",
+		TopicType: topicType,
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return pub
+}
+
+func (h *testHarness) close() {
+	h.controller.Close()
+	h.ctlHandle.Delete()
+	h.ctlNs.Close()
+	os.RemoveAll(h.dir)
+}
+
+// setupController creates the controller namespace with the HTTP
+// stand-in for the controller listening on testControllerIP
+func (h *testHarness) setupController() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	devNs, err := netns.Get()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer devNs.Close()
+	// Switches the thread to the new namespace
+	h.ctlNs, err = netns.New()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer netns.Set(devNs)
+
+	h.ctlHandle, err = netlink.NewHandleAt(h.ctlNs)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	lo, err := h.ctlHandle.LinkByName("lo")
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(testControllerIP),
+		Mask: net.CIDRMask(32, 32)}}
+	if err := h.ctlHandle.AddrAdd(lo, addr); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := h.ctlHandle.LinkSetUp(lo); err != nil {
+		h.t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", testControllerIP+":0")
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.controller = httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(int(atomic.LoadInt32(&h.ctlStatus)))
+		}))
+	h.controller.Listener = listener
+	h.controller.Start()
+	h.ctlURL = zedcloud.URLPathString(h.controller.URL, true, nilUUID, "ping")
+}
+
+// addPort creates a veth pair with ifName in the device namespace and
+// its peer in the controller namespace
+func (h *testHarness) addPort(ifName string, subnet int) {
+	p := &testPort{
+		ifName:   ifName,
+		peerName: fmt.Sprintf("ctl%d", subnet),
+		subnet:   subnet,
+	}
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: ifName},
+		PeerName:  p.peerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		h.t.Fatalf("LinkAdd %s: %s", ifName, err)
+	}
+	peer, err := netlink.LinkByName(p.peerName)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err := netlink.LinkSetNsFd(peer, int(h.ctlNs)); err != nil {
+		h.t.Fatal(err)
+	}
+	peer, err = h.ctlHandle.LinkByName(p.peerName)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: p.gateway(),
+		Mask: net.CIDRMask(24, 32)}}
+	if err := h.ctlHandle.AddrAdd(peer, addr); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := h.ctlHandle.LinkSetUp(peer); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := netlink.LinkSetUp(veth); err != nil {
+		h.t.Fatal(err)
+	}
+	h.ports[ifName] = p
+}
+
+// setLink injects a link failure by taking down the peer of the port,
+// which drops the carrier on the port
+func (h *testHarness) setLink(ifName string, up bool) {
+	p := h.ports[ifName]
+	peer, err := h.ctlHandle.LinkByName(p.peerName)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if up {
+		err = h.ctlHandle.LinkSetUp(peer)
+	} else {
+		err = h.ctlHandle.LinkSetDown(peer)
+	}
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if up {
+		// Setting the link down removed the connected route
+		addr := &netlink.Addr{IPNet: &net.IPNet{IP: p.gateway(),
+			Mask: net.CIDRMask(24, 32)}}
+		h.ctlHandle.AddrReplace(peer, addr)
+	}
+}
+
+// setDhcp injects a DHCP failure; the lease is lost and no new lease is
+// handed out until the failure is removed
+func (h *testHarness) setDhcp(ifName string, works bool) {
+	p := h.ports[ifName]
+	p.noDhcp = !works
+	if works {
+		h.lease(p)
+	} else {
+		h.release(p)
+	}
+}
+
+// setDNS injects a failure to get DNS servers from DHCP
+func (h *testHarness) setDNS(ifName string, works bool) {
+	p := h.ports[ifName]
+	p.noDNS = !works
+	if p.hasLease {
+		h.writeDNS(p)
+	}
+}
+
+// setController sets the HTTP status returned by the controller stand-in
+func (h *testHarness) setController(status int) {
+	atomic.StoreInt32(&h.ctlStatus, int32(status))
+}
+
+func (h *testHarness) lease(p *testPort) {
+	if p.noDhcp || p.hasLease {
+		return
+	}
+	link, err := netlink.LinkByName(p.ifName)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err := netlink.AddrAdd(link, &netlink.Addr{IPNet: p.addr()}); err != nil {
+		h.t.Fatalf("AddrAdd %s: %s", p.ifName, err)
+	}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Gw:        p.gateway(),
+		Priority:  p.subnet + 1,
+	}
+	if err := netlink.RouteAdd(route); err != nil {
+		h.t.Fatalf("RouteAdd %s: %s", p.ifName, err)
+	}
+	p.hasLease = true
+	h.writeDNS(p)
+}
+
+func (h *testHarness) release(p *testPort) {
+	if !p.hasLease {
+		return
+	}
+	link, err := netlink.LinkByName(p.ifName)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	// Also removes the default route
+	if err := netlink.AddrDel(link, &netlink.Addr{IPNet: p.addr()}); err != nil {
+		h.t.Fatalf("AddrDel %s: %s", p.ifName, err)
+	}
+	p.hasLease = false
+	h.writeDNS(p)
+}
+
+// writeDNS maintains the file where dhcpcd would save the DNS servers
+func (h *testHarness) writeDNS(p *testPort) {
+	filename := filepath.Join(h.dir, p.ifName+".dhcp")
+	if !p.hasLease || p.noDNS {
+		os.Remove(filename)
+		return
+	}
+	content := fmt.Sprintf("nameserver %s\n", p.gateway())
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// updateDhcpClient replaces UpdateDhcpClient
+func (h *testHarness) updateDhcpClient(log *base.LogObject,
+	newConfig, oldConfig types.DevicePortConfig) {
+
+	for _, port := range oldConfig.Ports {
+		newPort := newConfig.GetPortByIfName(port.IfName)
+		if newPort != nil && newPort.Dhcp == types.DT_CLIENT {
+			continue
+		}
+		if p, ok := h.ports[port.IfName]; ok {
+			h.release(p)
+		}
+	}
+	for _, port := range newConfig.Ports {
+		if port.Dhcp != types.DT_CLIENT {
+			continue
+		}
+		if p, ok := h.ports[port.IfName]; ok {
+			h.lease(p)
+		}
+	}
+}
+
+// verifyDeviceNetworkStatus replaces VerifyDeviceNetworkStatus by
+// testing the ports against the controller stand-in over plain HTTP
+func (h *testHarness) verifyDeviceNetworkStatus(log *base.LogObject,
+	status types.DeviceNetworkStatus, successCount uint, iteration int,
+	timeout uint32) (bool, types.IntfStatusMap, error) {
+
+	zedcloudCtx := zedcloud.NewContext(log, zedcloud.ContextOptions{
+		DevNetworkStatus: &status,
+		Timeout:          timeout,
+		AgentName:        "devicenetwork",
+	})
+	cloudReachable, rtf, intfStatusMap, err := zedcloud.VerifyAllIntf(
+		&zedcloudCtx, h.ctlURL, successCount, iteration)
+	if err != nil {
+		return rtf, intfStatusMap, err
+	}
+	if !cloudReachable {
+		return rtf, intfStatusMap, fmt.Errorf("Uplink test FAIL to URL: %s",
+			h.ctlURL)
+	}
+	return false, intfStatusMap, nil
+}
+
+// zedagentDPC returns a DPC from the controller with the ports as
+// management ports using DHCP
+func (h *testHarness) zedagentDPC(ifNames ...string) types.DevicePortConfig {
+	dpc := makeDevicePortConfig(h.ctx, ifNames, ifNames)
+	dpc.Key = "zedagent"
+	dpc.TimePriority = time.Now()
+	return dpc
+}
+
+func (h *testHarness) lastResortDPC(ifNames ...string) types.DevicePortConfig {
+	dpc := LastResortDevicePortConfig(h.ctx, ifNames)
+	dpc.Key = "lastresort"
+	return dpc
+}
+
+// setDPCList replaces the DPC list; the first entry has the highest priority
+func (h *testHarness) setDPCList(dpcs ...types.DevicePortConfig) {
+	h.ctx.DevicePortConfigList.PortConfigList = dpcs
+}
+
+// verify restarts verification of the DPC list and fires the pending
+// timer until the verification has completed
+func (h *testHarness) verify() {
+	RestartVerify(h.ctx, "test")
+	for i := 0; h.ctx.Pending.Inprogress; i++ {
+		if i >= maxVerifyIterations {
+			h.t.Fatalf("DPC verification did not complete: %+v",
+				h.ctx.Pending.PendDPC)
+		}
+		VerifyDevicePortConfig(h.ctx)
+	}
+}
+
+// publishedDPCL returns the published DevicePortConfigList
+func (h *testHarness) publishedDPCL() types.DevicePortConfigList {
+	item, err := h.ctx.PubDevicePortConfigList.Get("global")
+	if err != nil {
+		h.t.Fatalf("no DevicePortConfigList published: %s", err)
+	}
+	return item.(types.DevicePortConfigList)
+}
+
+// publishedDNS returns the published DeviceNetworkStatus
+func (h *testHarness) publishedDNS() types.DeviceNetworkStatus {
+	item, err := h.ctx.PubDeviceNetworkStatus.Get("global")
+	if err != nil {
+		h.t.Fatalf("no DeviceNetworkStatus published: %s", err)
+	}
+	return item.(types.DeviceNetworkStatus)
+}
+
+// checkCurrent checks that verification ended up using the DPC with
+// the key at the index, and that it is working
+func (h *testHarness) checkCurrent(index int, key string) {
+	dpcl := h.publishedDPCL()
+	if dpcl.CurrentIndex != index {
+		h.t.Fatalf("CurrentIndex %d, expected %d: %+v",
+			dpcl.CurrentIndex, index, dpcl)
+	}
+	dpc := dpcl.PortConfigList[index]
+	if dpc.Key != key {
+		h.t.Errorf("DPC at index %d has key %s, expected %s",
+			index, dpc.Key, key)
+	}
+	if !dpc.WasDPCWorking() {
+		h.t.Errorf("DPC at index %d is not working: %+v", index, dpc)
+	}
+	dns := h.publishedDNS()
+	if dns.Testing {
+		h.t.Errorf("DeviceNetworkStatus still testing")
+	}
+	if dns.CurrentIndex != index {
+		h.t.Errorf("DeviceNetworkStatus CurrentIndex %d, expected %d",
+			dns.CurrentIndex, index)
+	}
+	if len(dns.Ports) != len(dpc.Ports) {
+		h.t.Errorf("DeviceNetworkStatus has %d ports, expected %d",
+			len(dns.Ports), len(dpc.Ports))
+	}
+}
+
+// checkFailed checks that the DPC at the index failed with an error
+// for the port
+func (h *testHarness) checkFailed(index int, ifName string) {
+	dpc := h.publishedDPCL().PortConfigList[index]
+	if dpc.WasDPCWorking() || dpc.LastFailed.IsZero() {
+		h.t.Errorf("DPC at index %d did not fail: %+v", index, dpc)
+	}
+	if dpc.LastError == "" {
+		h.t.Errorf("DPC at index %d has no error", index)
+	}
+	port := dpc.GetPortByIfName(ifName)
+	if port == nil || !port.HasError() {
+		h.t.Errorf("port %s of DPC at index %d has no error: %+v",
+			ifName, index, port)
+	}
+}
+
+// checkPort checks the port in the published DeviceNetworkStatus
+func (h *testHarness) checkPort(ifName string, working bool) {
+	dns := h.publishedDNS()
+	port := dns.GetPortByIfName(ifName)
+	if port == nil {
+		h.t.Fatalf("port %s not in DeviceNetworkStatus: %+v", ifName, dns)
+	}
+	if port.HasError() == working {
+		h.t.Errorf("port %s error %q, expected working %t",
+			ifName, port.LastError, working)
+	}
+	if working && !port.HasIPAndDNS() {
+		h.t.Errorf("port %s has no IP and DNS: %+v", ifName, port)
+	}
+}
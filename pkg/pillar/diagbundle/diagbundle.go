@@ -0,0 +1,418 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Collect a diagnostic bundle: a gzipped tar with what is needed to look
+// into a problem on a device without access to it. The bundle has
+//   - the pubsub topics under /run and /persist/status, which include the
+//     DevicePortConfigList of nim
+//   - the logs of newlogd of the last day, newest first up to maxLogBytes
+//   - the reboot reason and stack, the logs in /persist/log and the debug
+//     output of the agents in /persist/agentdebug
+//   - the output of iptables-save, nft and ip
+//   - the hypervisor and the CPU and memory of the host
+// The secrets are redacted, and nothing is read from /config nor
+// /persist/certs which hold the keys of the device.
+
+package diagbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/agentlog"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/hypervisor"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	maxTopicSize   = 4 * 1024 * 1024  // larger files in the topics are skipped
+	maxFileSize    = 16 * 1024 * 1024 // larger log and debug files are truncated
+	maxLogBytes    = 64 * 1024 * 1024
+	logAge         = 24 * time.Hour
+	commandTimeout = 30 * time.Second
+)
+
+// topicDirs are walked for the JSON files of the pubsub topics. The
+// directories in skipDirs are not pubsub and can be large.
+var (
+	topicDirs = []string{"/run", types.PersistStatusDir}
+	skipDirs  = map[string]bool{
+		"/run/containerd":      true,
+		"/run/containerd-user": true,
+		"/run/runc":            true,
+		"/run/udev":            true,
+	}
+	// logDirs are the directories of the gzip files of newlogd
+	logDirs = []string{
+		types.NewlogKeepSentQueueDir,
+		types.NewlogDir + "/failedUpload",
+		types.NewlogUploadDevDir,
+		types.NewlogUploadAppDir,
+	}
+	fileDirs = []string{
+		types.PersistDir + "/log",
+		types.PersistDebugDir,
+	}
+	procFiles = []string{
+		"/proc/cmdline",
+		"/proc/cpuinfo",
+		"/proc/meminfo",
+		"/proc/mounts",
+	}
+	commands = [][]string{
+		{"iptables-save"},
+		{"ip6tables-save"},
+		{"nft", "list", "ruleset"},
+		{"ip", "addr"},
+		{"ip", "rule"},
+		{"ip", "route", "show", "table", "all"},
+		{"ip", "-6", "route", "show", "table", "all"},
+		{"uname", "-a"},
+		{"df", "-h"},
+	}
+)
+
+// bundle is the tar being written, with the errors met on the way
+type bundle struct {
+	log    *base.LogObject
+	tw     *tar.Writer
+	now    time.Time
+	errors []string
+}
+
+// CollectToFile writes the bundle to filename and returns its size. The
+// bundle is written to a temporary file first so a partial bundle is never
+// left as filename.
+func CollectToFile(log *base.LogObject, filename string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return 0, err
+	}
+	tmpfile := filename + ".tmp"
+	f, err := os.OpenFile(tmpfile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	err = Collect(log, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpfile)
+		return 0, err
+	}
+	info, err := os.Stat(tmpfile)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpfile, filename); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Collect writes the bundle as a gzipped tar to w. Only the failures to
+// write are returned; what can't be read is listed in errors.txt of the
+// bundle.
+func Collect(log *base.LogObject, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	b := &bundle{log: log, tw: tar.NewWriter(gw), now: time.Now()}
+	for _, step := range []func() error{
+		b.addReboot,
+		b.addHypervisor,
+		b.addCommands,
+		b.addProcFiles,
+		b.addTopics,
+		b.addFiles,
+		b.addLogs,
+	} {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	if err := b.addErrors(); err != nil {
+		return err
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// addError records what could not be collected
+func (b *bundle) addError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	b.log.Warnf("diagbundle: %s", msg)
+	b.errors = append(b.errors, msg)
+}
+
+// write adds a file to the tar
+func (b *bundle) write(name string, modTime time.Time, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := b.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := b.tw.Write(data)
+	return err
+}
+
+func (b *bundle) addErrors() error {
+	var buf bytes.Buffer
+	for _, msg := range b.errors {
+		fmt.Fprintln(&buf, msg)
+	}
+	return b.write("errors.txt", b.now, buf.Bytes())
+}
+
+// rebootInfo is what agentlog kept about the last reboot
+type rebootInfo struct {
+	RebootReason string
+	RebootTime   time.Time
+	RebootImage  string
+	BootReason   string
+	BootTime     time.Time
+	RebootStack  string
+}
+
+func (b *bundle) addReboot() error {
+	var info rebootInfo
+	info.RebootReason, info.RebootTime, info.RebootStack = agentlog.GetRebootReason(b.log)
+	var bootReason types.BootReason
+	bootReason, info.BootTime = agentlog.GetBootReason(b.log)
+	info.BootReason = bootReason.String()
+	info.RebootImage = agentlog.GetRebootImage(b.log)
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.write("reboot.json", b.now, redactJSON(data))
+}
+
+// hypervisorInfo tells which hypervisor runs the app instances and what
+// it leaves of the host
+type hypervisorInfo struct {
+	Available []string
+	Enabled   []string
+	Name      string
+	Host      types.HostMemory
+	Error     string `json:",omitempty"`
+}
+
+func (b *bundle) addHypervisor() error {
+	var info hypervisorInfo
+	info.Available, info.Enabled = hypervisor.GetAvailableHypervisors()
+	if len(info.Enabled) != 0 {
+		hyper, err := hypervisor.GetHypervisor(info.Enabled[0])
+		if err == nil {
+			info.Name = hyper.Name()
+			info.Host, err = hyper.GetHostCPUMem()
+		}
+		if err != nil {
+			info.Error = err.Error()
+		}
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.write("hypervisor.json", b.now, data)
+}
+
+func (b *bundle) addCommands() error {
+	for _, args := range commands {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		cancel()
+		if err != nil {
+			b.addError("%s: %v", strings.Join(args, " "), err)
+		}
+		name := "commands/" + strings.Join(args, "_") + ".txt"
+		if err := b.write(name, b.now, redactText(out)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundle) addProcFiles() error {
+	for _, filename := range procFiles {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			b.addError("%v", err)
+			continue
+		}
+		if err := b.write(strings.TrimPrefix(filename, "/"), b.now, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTopics adds the JSON files of the pubsub topics, redacted
+func (b *bundle) addTopics() error {
+	for _, dir := range topicDirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				b.addError("%v", err)
+				return nil
+			}
+			if info.IsDir() {
+				if skipDirs[path] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || filepath.Ext(path) != ".json" {
+				return nil
+			}
+			if info.Size() > maxTopicSize {
+				b.addError("%s: skipped %d bytes", path, info.Size())
+				return nil
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				b.addError("%v", err)
+				return nil
+			}
+			return b.write(strings.TrimPrefix(path, "/"), info.ModTime(), redactJSON(data))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFiles adds the files of fileDirs, redacted as text
+func (b *bundle) addFiles() error {
+	for _, dir := range fileDirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				b.addError("%v", err)
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			data, err := readFile(path, maxFileSize)
+			if err != nil {
+				b.addError("%v", err)
+				return nil
+			}
+			if info.Size() > maxFileSize {
+				b.addError("%s: truncated %d bytes to %d", path, info.Size(), maxFileSize)
+			}
+			return b.write(strings.TrimPrefix(path, "/"), info.ModTime(), redactText(data))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logFile is a gzip file of newlogd, or a file being collected
+type logFile struct {
+	path    string
+	modTime time.Time
+	gzip    bool
+}
+
+// addLogs adds the logs of the last logAge, newest first up to
+// maxLogBytes, decompressed and redacted
+func (b *bundle) addLogs() error {
+	var files []logFile
+	since := b.now.Add(-logAge)
+	addDir := func(dir string, gzip bool) {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				b.addError("%v", err)
+			}
+			return
+		}
+		for _, info := range infos {
+			if !info.Mode().IsRegular() || info.ModTime().Before(since) {
+				continue
+			}
+			if gzip != strings.HasSuffix(info.Name(), ".gz") {
+				continue
+			}
+			files = append(files, logFile{
+				path:    filepath.Join(dir, info.Name()),
+				modTime: info.ModTime(),
+				gzip:    gzip,
+			})
+		}
+	}
+	for _, dir := range logDirs {
+		addDir(dir, true)
+	}
+	addDir(types.NewlogCollectDir, false)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var total int64
+	for _, f := range files {
+		if total >= maxLogBytes {
+			b.addError("%s: skipped over %d bytes of logs", f.path, maxLogBytes)
+			continue
+		}
+		data, err := readLogFile(f, maxLogBytes-total)
+		if err != nil {
+			b.addError("%s: %v", f.path, err)
+			continue
+		}
+		total += int64(len(data))
+		name := strings.TrimSuffix(strings.TrimPrefix(f.path, "/"), ".gz")
+		if err := b.write(name, f.modTime, redactText(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readLogFile(f logFile, limit int64) ([]byte, error) {
+	if !f.gzip {
+		return readFile(f.path, limit)
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return ioutil.ReadAll(io.LimitReader(gr, limit))
+}
+
+// readFile reads up to limit bytes of the file
+func readFile(filename string, limit int64) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(io.LimitReader(f, limit))
+}
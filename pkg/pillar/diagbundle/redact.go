@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package diagbundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// redacted replaces the values of the secrets
+const redacted = "<redacted>"
+
+// secretWords are in the names of the fields and keys holding secrets
+const secretWords = `password|passwd|passphrase|apikey|api_key|secret|token|` +
+	`privatekey|private_key|sharedkey|credential|userdata|psk|cipherdata`
+
+var (
+	secretKeyRe = regexp.MustCompile(`(?i)(` + secretWords + `)`)
+	// The key=value, key: value and "key":"value" pairs of text, also
+	// when quoted in a JSON string as in the log entries
+	secretPairRe = regexp.MustCompile(`(?i)((?:` + secretWords +
+		`)[a-z0-9_]*(?:\\?")?\s*[:=]\s*)` +
+		`(?:"(?:\\.|[^"\\])*"|\\"(?:[^"\\]|\\[^"])*\\"|[^\s,;&"}\]]+)`)
+	privateKeyRe = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?` +
+		`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactText removes the private keys in PEM and the values of the pairs
+// whose key names a secret
+func redactText(data []byte) []byte {
+	data = privateKeyRe.ReplaceAll(data, []byte(redacted))
+	return secretPairRe.ReplaceAll(data, []byte("${1}"+redacted))
+}
+
+// redactJSON removes the values of the fields whose name has a secret in
+// the JSON document, and redacts the strings as text. Falls back to
+// redactText when data is not JSON.
+func redactJSON(data []byte) []byte {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return redactText(data)
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	// Keep the redacted placeholder readable
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(redactValue(doc)); err != nil {
+		return redactText(data)
+	}
+	return out.Bytes()
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secretKeyRe.MatchString(key) && isSecretValue(value) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+		return v
+	case string:
+		return string(redactText([]byte(v)))
+	default:
+		return v
+	}
+}
+
+// isSecretValue is false for the values which can't hold a secret e.g., a
+// flag telling whether a password is set
+func isSecretValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, bool, json.Number:
+		return false
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) != 0
+	case map[string]interface{}:
+		return len(v) != 0
+	default:
+		return true
+	}
+}
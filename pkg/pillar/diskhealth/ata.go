@@ -0,0 +1,186 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package diskhealth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"golang.org/x/sys/unix"
+)
+
+// From linux/hdreg.h and the ATA specification
+const (
+	hdioGetIdentity = 0x030d
+	hdioDriveCmd    = 0x031f
+
+	ataSmartCmd            = 0xb0
+	ataSmartReadValues     = 0xd0
+	ataSmartReadThresholds = 0xd1
+
+	ataSectorSize = 512
+	// The attribute tables hold 30 entries of 12 bytes after the revision
+	ataAttributeCount = 30
+	ataAttributeSize  = 12
+)
+
+// ataAttributeNames are the usual names of the attributes, as printed by
+// smartctl; the meaning of the others depends on the vendor
+var ataAttributeNames = map[uint8]string{
+	1:   "Raw_Read_Error_Rate",
+	2:   "Throughput_Performance",
+	3:   "Spin_Up_Time",
+	4:   "Start_Stop_Count",
+	5:   "Reallocated_Sector_Ct",
+	7:   "Seek_Error_Rate",
+	8:   "Seek_Time_Performance",
+	9:   "Power_On_Hours",
+	10:  "Spin_Retry_Count",
+	11:  "Calibration_Retry_Count",
+	12:  "Power_Cycle_Count",
+	177: "Wear_Leveling_Count",
+	183: "Runtime_Bad_Block",
+	184: "End-to-End_Error",
+	187: "Reported_Uncorrect",
+	188: "Command_Timeout",
+	190: "Airflow_Temperature_Cel",
+	191: "G-Sense_Error_Rate",
+	192: "Power-Off_Retract_Count",
+	193: "Load_Cycle_Count",
+	194: "Temperature_Celsius",
+	196: "Reallocated_Event_Count",
+	197: "Current_Pending_Sector",
+	198: "Offline_Uncorrectable",
+	199: "UDMA_CRC_Error_Count",
+	231: "SSD_Life_Left",
+	233: "Media_Wearout_Indicator",
+	241: "Total_LBAs_Written",
+	242: "Total_LBAs_Read",
+}
+
+// ataCounters are the attributes whose raw value counts errors, and whose
+// growth is tracked
+var ataCounters = []uint8{5, 187, 197, 198, 199}
+
+// readATA reads the identity and the SMART attributes of an ATA disk
+func readATA(disk string) (*types.DiskHealthStatus, error) {
+	f, err := os.OpenFile("/dev/"+disk, os.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	status := types.DiskHealthStatus{Protocol: protocolATA}
+	var identity [ataSectorSize]byte
+	if err := ioctl(f, hdioGetIdentity, unsafe.Pointer(&identity[0])); err == nil {
+		status.Serial = ataString(identity[20:40])
+		status.Model = ataString(identity[54:94])
+	}
+	values, err := ataSmartCommand(f, ataSmartReadValues)
+	if err != nil {
+		return nil, fmt.Errorf("SMART READ DATA: %v", err)
+	}
+	thresholds, err := ataSmartCommand(f, ataSmartReadThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("SMART READ THRESHOLDS: %v", err)
+	}
+	status.Attributes = parseATAAttributes(values, thresholds)
+	setATAFields(&status)
+	return &status, nil
+}
+
+// ataSmartCommand runs a SMART command which reads a sector through
+// HDIO_DRIVE_CMD, which libata translates into ATA PASS-THROUGH
+func ataSmartCommand(f *os.File, feature byte) ([]byte, error) {
+	var buf [4 + ataSectorSize]byte
+	buf[0] = ataSmartCmd
+	buf[1] = 1 // LBA low
+	buf[2] = feature
+	buf[3] = 1 // Sector count
+	if err := ioctl(f, hdioDriveCmd, unsafe.Pointer(&buf[0])); err != nil {
+		return nil, err
+	}
+	return buf[4:], nil
+}
+
+// parseATAAttributes parses the sectors of SMART READ DATA and SMART READ
+// THRESHOLDS
+func parseATAAttributes(values, thresholds []byte) []types.DiskSmartAttribute {
+	thresh := make(map[uint8]uint8)
+	for i := 0; i < ataAttributeCount; i++ {
+		entry := thresholds[2+i*ataAttributeSize:]
+		if entry[0] != 0 {
+			thresh[entry[0]] = entry[1]
+		}
+	}
+	var attributes []types.DiskSmartAttribute
+	for i := 0; i < ataAttributeCount; i++ {
+		entry := values[2+i*ataAttributeSize:]
+		id := entry[0]
+		if id == 0 {
+			continue
+		}
+		var raw [8]byte
+		copy(raw[:6], entry[5:11])
+		name, ok := ataAttributeNames[id]
+		if !ok {
+			name = "Unknown_Attribute"
+		}
+		attributes = append(attributes, types.DiskSmartAttribute{
+			ID:         id,
+			Name:       name,
+			Value:      entry[3],
+			Worst:      entry[4],
+			Threshold:  thresh[id],
+			Raw:        binary.LittleEndian.Uint64(raw[:]),
+			PreFailure: binary.LittleEndian.Uint16(entry[1:3])&1 != 0,
+		})
+	}
+	return attributes
+}
+
+// setATAFields sets the temperature, power on hours and counters from the
+// attributes
+func setATAFields(status *types.DiskHealthStatus) {
+	byID := make(map[uint8]types.DiskSmartAttribute)
+	for _, attr := range status.Attributes {
+		byID[attr.ID] = attr
+	}
+	if attr, ok := byID[194]; ok {
+		// The other bytes hold the min and max on some disks
+		status.Temperature = uint32(attr.Raw & 0xff)
+	}
+	if attr, ok := byID[9]; ok {
+		status.PowerOnHours = attr.Raw & 0xffffffff
+	}
+	for _, id := range ataCounters {
+		if attr, ok := byID[id]; ok {
+			status.Counters = append(status.Counters, types.DiskHealthCounter{
+				Name:  attr.Name,
+				Value: attr.Raw & 0xffffffff,
+			})
+		}
+	}
+}
+
+// ataString decodes the strings of IDENTIFY DEVICE, whose words hold the
+// characters swapped
+func ataString(b []byte) string {
+	s := make([]byte, len(b))
+	for i := 0; i+1 < len(b); i += 2 {
+		s[i], s[i+1] = b[i+1], b[i]
+	}
+	return strings.TrimSpace(string(s))
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
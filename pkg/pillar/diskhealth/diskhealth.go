@@ -0,0 +1,161 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Read the health of the disks: the SMART attributes and thresholds of the
+// ATA disks, and the SMART/health log page of the NVMe disks, using the
+// ioctls of the kernel since smartctl is not in pillar. The disks which
+// answer neither, such as USB sticks and virtio disks, are skipped.
+// A Tracker keeps hourly samples of the error and wear counters of each
+// disk across reboots to report their growth, and Evaluate derives the
+// good/warning/failing state from the attributes and that growth.
+
+package diskhealth
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	protocolATA  = "ATA"
+	protocolNVMe = "NVMe"
+)
+
+var (
+	// sysRoot and mountsFile are changed by the tests
+	sysRoot    = "/sys"
+	mountsFile = "/proc/self/mounts"
+)
+
+// Read returns the health of the disks, without the growth of the counters
+// nor the state which are set by Tracker.Update and Evaluate
+func Read(log *base.LogObject) []types.DiskHealthStatus {
+	persist := persistDisks(log)
+	var statuses []types.DiskHealthStatus
+	for _, disk := range listDisks(log) {
+		var status *types.DiskHealthStatus
+		var err error
+		switch {
+		case strings.HasPrefix(disk, "nvme"):
+			status, err = readNVMe(disk)
+		case strings.HasPrefix(disk, "sd"), strings.HasPrefix(disk, "hd"):
+			status, err = readATA(disk)
+		default:
+			continue
+		}
+		if err != nil {
+			// Not every disk supports SMART, or the ioctls
+			log.Functionf("diskhealth.Read: %s: %v", disk, err)
+			continue
+		}
+		status.DiskName = disk
+		if status.Model == "" {
+			status.Model = readString(filepath.Join(sysRoot, "block", disk, "device/model"))
+		}
+		if status.Serial == "" {
+			status.Serial = readString(filepath.Join(sysRoot, "block", disk, "device/serial"))
+		}
+		status.Persist = persist == nil || persist[disk]
+		status.LastUpdate = time.Now()
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+// listDisks returns the names of the block devices which have a device,
+// hence are not loop, ram, dm or md devices
+func listDisks(log *base.LogObject) []string {
+	entries, err := ioutil.ReadDir(filepath.Join(sysRoot, "block"))
+	if err != nil {
+		log.Errorf("listDisks: %v", err)
+		return nil
+	}
+	var disks []string
+	for _, entry := range entries {
+		if fileExists(filepath.Join(sysRoot, "block", entry.Name(), "device")) {
+			disks = append(disks, entry.Name())
+		}
+	}
+	sort.Strings(disks)
+	return disks
+}
+
+// persistDisks returns the disks under the filesystem mounted on /persist,
+// through its partition and the slaves of dm and md devices. It returns
+// nil when they can't be told, as for ZFS, in which case any disk is
+// assumed to hold /persist.
+func persistDisks(log *base.LogObject) map[string]bool {
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		log.Errorf("persistDisks: %v", err)
+		return nil
+	}
+	defer f.Close()
+	var source string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[1] == types.PersistDir {
+			source = fields[0]
+		}
+	}
+	if !strings.HasPrefix(source, "/dev/") {
+		return nil
+	}
+	// Resolve /dev/mapper and /dev/disk links to the kernel name
+	if resolved, err := filepath.EvalSymlinks(source); err == nil {
+		source = resolved
+	}
+	disks := make(map[string]bool)
+	addDisks(filepath.Base(source), disks, 0)
+	if len(disks) == 0 {
+		return nil
+	}
+	return disks
+}
+
+// addDisks adds the disks under the block device to disks
+func addDisks(name string, disks map[string]bool, depth int) {
+	if depth > 8 {
+		return
+	}
+	dir := filepath.Join(sysRoot, "class/block", name)
+	if fileExists(filepath.Join(dir, "partition")) {
+		// The parent of a partition is its disk
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			addDisks(filepath.Base(filepath.Dir(resolved)), disks, depth+1)
+		}
+		return
+	}
+	slaves, _ := ioutil.ReadDir(filepath.Join(dir, "slaves"))
+	if len(slaves) == 0 {
+		if fileExists(dir) {
+			disks[name] = true
+		}
+		return
+	}
+	for _, slave := range slaves {
+		addDisks(slave.Name(), disks, depth+1)
+	}
+}
+
+func readString(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
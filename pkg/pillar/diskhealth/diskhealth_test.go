@@ -0,0 +1,209 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package diskhealth
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+var log = base.NewSourceLogObject(logrus.StandardLogger(), "diskhealth", 0)
+
+func ataEntry(sector []byte, i int, id uint8, flags uint16, value, worst uint8, raw uint64) {
+	entry := sector[2+i*ataAttributeSize:]
+	entry[0] = id
+	binary.LittleEndian.PutUint16(entry[1:3], flags)
+	entry[3] = value
+	entry[4] = worst
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], raw)
+	copy(entry[5:11], b[:6])
+}
+
+func TestParseATAAttributes(t *testing.T) {
+	values := make([]byte, ataSectorSize)
+	thresholds := make([]byte, ataSectorSize)
+	ataEntry(values, 0, 5, 0x33, 5, 5, 1200)
+	ataEntry(values, 1, 9, 0x32, 90, 90, 0x123400004e20)
+	ataEntry(values, 2, 194, 0x22, 64, 40, 0x002d00120024)
+	ataEntry(values, 3, 197, 0x12, 100, 100, 8)
+	ataEntry(values, 4, 250, 0x12, 100, 100, 0)
+	thresholds[2], thresholds[3] = 5, 10
+	thresholds[2+ataAttributeSize], thresholds[3+ataAttributeSize] = 9, 0
+
+	status := types.DiskHealthStatus{Protocol: protocolATA,
+		Attributes: parseATAAttributes(values, thresholds)}
+	setATAFields(&status)
+	if len(status.Attributes) != 5 || status.Attributes[4].Name != "Unknown_Attribute" {
+		t.Fatalf("got attributes %+v", status.Attributes)
+	}
+	attr := status.Attributes[0]
+	if attr.Name != "Reallocated_Sector_Ct" || !attr.PreFailure ||
+		attr.Threshold != 10 || attr.Raw != 1200 {
+		t.Errorf("got attribute %+v", attr)
+	}
+	if status.Temperature != 36 || status.PowerOnHours != 20000 {
+		t.Errorf("got temperature %d power on hours %d",
+			status.Temperature, status.PowerOnHours)
+	}
+	expected := []types.DiskHealthCounter{
+		{Name: "Reallocated_Sector_Ct", Value: 1200},
+		{Name: "Current_Pending_Sector", Value: 8},
+	}
+	if !reflect.DeepEqual(status.Counters, expected) {
+		t.Errorf("got counters %+v", status.Counters)
+	}
+
+	Evaluate(&status)
+	if status.State != types.DiskHealthFailing || len(status.Reasons) != 2 ||
+		status.Reasons[0] != "attribute 5 Reallocated_Sector_Ct is 5, threshold 10" ||
+		status.Reasons[1] != "8 sectors pending reallocation" {
+		t.Errorf("got %s %q", status.State, status.Reasons)
+	}
+
+	if s := ataString([]byte("TS23Z4  ")); s != "ST324Z" {
+		t.Errorf("ataString got %q", s)
+	}
+}
+
+func TestParseNVMeHealth(t *testing.T) {
+	page := make([]byte, nvmeLogSize)
+	page[0] = nvmeWarningTemperature
+	binary.LittleEndian.PutUint16(page[1:3], 273+81)
+	page[3], page[4], page[5] = 100, 10, 93
+	binary.LittleEndian.PutUint64(page[128:], 9000)
+	binary.LittleEndian.PutUint64(page[160:], 2)
+	binary.LittleEndian.PutUint64(page[176:], 40)
+
+	status := types.DiskHealthStatus{Protocol: protocolNVMe}
+	parseNVMeHealth(page, &status)
+	if status.Temperature != 81 || status.PercentageUsed != 93 ||
+		status.PowerOnHours != 9000 || len(status.Counters) != 3 ||
+		status.Counters[0].Value != 2 || status.Counters[1].Value != 40 {
+		t.Fatalf("got %+v", status)
+	}
+	Evaluate(&status)
+	if status.State != types.DiskHealthWarning || len(status.Reasons) != 2 {
+		t.Errorf("got %s %q", status.State, status.Reasons)
+	}
+
+	status.CriticalWarning = nvmeWarningReadOnly
+	status.PercentageUsed = 10
+	Evaluate(&status)
+	if status.State != types.DiskHealthFailing ||
+		!reflect.DeepEqual(status.Reasons, []string{"media is read-only"}) {
+		t.Errorf("got %s %q", status.State, status.Reasons)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskhealth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tracker := NewTracker(log, dir)
+	start := time.Unix(1600000000, 0)
+	update := func(errors uint64, at time.Duration) types.DiskHealthStatus {
+		status := types.DiskHealthStatus{DiskName: "nvme0n1", Serial: "S4 EW",
+			Protocol: protocolNVMe,
+			Counters: []types.DiskHealthCounter{{Name: "media_errors", Value: errors}}}
+		tracker.Update(&status, start.Add(at))
+		Evaluate(&status)
+		return status
+	}
+
+	// A sample per hour over 10 days, with an error on the 3rd and 8th days
+	var status types.DiskHealthStatus
+	for hour := 0; hour < 10*24; hour++ {
+		errors := uint64(0)
+		if hour >= 2*24 {
+			errors++
+		}
+		if hour >= 7*24 {
+			errors++
+		}
+		status = update(errors, time.Duration(hour)*time.Hour)
+		if hour == 7*24 && (status.Counters[0].DeltaDay != 1 ||
+			status.Counters[0].DeltaWeek != 2 ||
+			status.State != types.DiskHealthWarning) {
+			t.Errorf("on the 8th day got %+v", status)
+		}
+	}
+	counter := status.Counters[0]
+	if counter.DeltaDay != 0 || counter.DeltaWeek != 1 ||
+		status.State != types.DiskHealthGood {
+		t.Errorf("on the 10th day got %+v", status)
+	}
+	// Not sampled within the hour
+	status = update(5, 10*24*time.Hour-time.Minute)
+	if status.Counters[0].DeltaDay != 3 {
+		t.Errorf("got %+v", status)
+	}
+	samples := tracker.load(filepath.Join(dir, "S4_EW.json"))
+	if len(samples) != 8*24 || samples[len(samples)-1].Values["media_errors"] != 2 {
+		t.Errorf("kept %d samples", len(samples))
+	}
+}
+
+func TestPersistDisks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskhealth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(root, mounts string) {
+		sysRoot, mountsFile = root, mounts
+	}(sysRoot, mountsFile)
+	sysRoot = dir
+	mountsFile = filepath.Join(dir, "mounts")
+
+	// sda9 is a partition of sda, and dm-0 is on nvme0n1p1 and sdb
+	for _, d := range []string{
+		"devices/sda/sda9", "devices/nvme0n1/nvme0n1p1", "devices/sdb",
+		"devices/dm-0/slaves", "class/block"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, target := range map[string]string{
+		"sda": "sda", "sda9": "sda/sda9", "sdb": "sdb",
+		"nvme0n1": "nvme0n1", "nvme0n1p1": "nvme0n1/nvme0n1p1", "dm-0": "dm-0"} {
+		if err := os.Symlink(filepath.Join(dir, "devices", target),
+			filepath.Join(dir, "class/block", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"devices/sda/sda9/partition",
+		"devices/nvme0n1/nvme0n1p1/partition",
+		"devices/dm-0/slaves/nvme0n1p1", "devices/dm-0/slaves/sdb"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for source, expected := range map[string]map[string]bool{
+		"/dev/sda9":  {"sda": true},
+		"/dev/dm-0":  {"nvme0n1": true, "sdb": true},
+		"persist":    nil,
+		"/dev/loop0": nil,
+	} {
+		mounts := source + " /persist ext4 rw 0 0\n"
+		if err := ioutil.WriteFile(mountsFile, []byte(mounts), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if disks := persistDisks(log); !reflect.DeepEqual(disks, expected) {
+			t.Errorf("%s: got %v, expected %v", source, disks, expected)
+		}
+	}
+}
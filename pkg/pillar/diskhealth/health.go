@@ -0,0 +1,211 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package diskhealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	fileutils "github.com/lf-edge/eve/pkg/pillar/utils/file"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+	// A sample is kept per hour for a bit more than a week
+	sampleInterval  = time.Hour
+	sampleRetention = week + day
+
+	// wornPercentage of the rated endurance of an NVMe disk is a warning
+	wornPercentage = 90
+)
+
+// sample is the values of the counters of a disk at a time
+type sample struct {
+	Time   time.Time
+	Values map[string]uint64
+}
+
+// Tracker keeps the samples of the counters of the disks in a directory,
+// one file per disk named after its serial number so that they follow the
+// disk if it is renamed
+type Tracker struct {
+	log *base.LogObject
+	dir string
+}
+
+// NewTracker returns a Tracker keeping its samples in dir
+func NewTracker(log *base.LogObject, dir string) *Tracker {
+	return &Tracker{log: log, dir: dir}
+}
+
+// Update sets the growth of the counters of the status over the last day
+// and week, and records a sample if the last one is older than an hour
+func (t *Tracker) Update(status *types.DiskHealthStatus, now time.Time) {
+	filename := t.filename(status)
+	samples := t.load(filename)
+	for i := range status.Counters {
+		counter := &status.Counters[i]
+		counter.DeltaDay = growth(samples, counter.Name, counter.Value, now.Add(-day))
+		counter.DeltaWeek = growth(samples, counter.Name, counter.Value, now.Add(-week))
+	}
+	if len(samples) != 0 && now.Sub(samples[len(samples)-1].Time) < sampleInterval {
+		return
+	}
+	current := sample{Time: now, Values: make(map[string]uint64)}
+	for _, counter := range status.Counters {
+		current.Values[counter.Name] = counter.Value
+	}
+	var kept []sample
+	for _, s := range samples {
+		if now.Sub(s.Time) < sampleRetention {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, current)
+	data, err := json.Marshal(kept)
+	if err != nil {
+		t.log.Errorf("Tracker.Update: %v", err)
+		return
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		t.log.Errorf("Tracker.Update: %v", err)
+		return
+	}
+	if err := fileutils.WriteRename(filename, data); err != nil {
+		t.log.Errorf("Tracker.Update: %v", err)
+	}
+}
+
+func (t *Tracker) filename(status *types.DiskHealthStatus) string {
+	name := status.Serial
+	if name == "" {
+		name = status.DiskName
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	return filepath.Join(t.dir, name+".json")
+}
+
+func (t *Tracker) load(filename string) []sample {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.log.Errorf("Tracker.load: %v", err)
+		}
+		return nil
+	}
+	var samples []sample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		t.log.Errorf("Tracker.load: %s: %v", filename, err)
+		return nil
+	}
+	return samples
+}
+
+// growth returns how much the counter grew since the last sample at or
+// before since, or the first sample if there is none that old
+func growth(samples []sample, name string, value uint64, since time.Time) uint64 {
+	var baseline uint64
+	found := false
+	for _, s := range samples {
+		v, ok := s.Values[name]
+		if !ok {
+			continue
+		}
+		if !found || !s.Time.After(since) {
+			baseline = v
+			found = true
+		}
+		if s.Time.After(since) {
+			break
+		}
+	}
+	if !found || value < baseline {
+		return 0
+	}
+	return value - baseline
+}
+
+// Evaluate sets the state of the disk and the reasons when it is not good.
+// The disk is failing when a pre-failure attribute reached its threshold,
+// or when the NVMe disk warns of its spare, reliability or read-only media,
+// or is past its rated endurance. It is in warning when an error counter
+// grew over the last day, sectors are pending reallocation, an attribute
+// reached its threshold in the past, or the NVMe disk is almost worn out or
+// too hot.
+func Evaluate(status *types.DiskHealthStatus) {
+	var failing, warning []string
+	for _, attr := range status.Attributes {
+		if attr.Threshold == 0 {
+			continue
+		}
+		if attr.PreFailure && attr.Value <= attr.Threshold {
+			failing = append(failing, fmt.Sprintf("attribute %d %s is %d, threshold %d",
+				attr.ID, attr.Name, attr.Value, attr.Threshold))
+		} else if attr.Worst <= attr.Threshold {
+			warning = append(warning, fmt.Sprintf("attribute %d %s was %d, threshold %d",
+				attr.ID, attr.Name, attr.Worst, attr.Threshold))
+		}
+	}
+	if status.Protocol == protocolNVMe {
+		warn := status.CriticalWarning
+		if warn&nvmeWarningSpare != 0 {
+			failing = append(failing, fmt.Sprintf("available spare %d%% below threshold %d%%",
+				status.AvailableSpare, status.SpareThreshold))
+		}
+		if warn&nvmeWarningReliability != 0 {
+			failing = append(failing, "reliability degraded by media errors")
+		}
+		if warn&nvmeWarningReadOnly != 0 {
+			failing = append(failing, "media is read-only")
+		}
+		if warn&nvmeWarningBackup != 0 {
+			failing = append(failing, "volatile memory backup failed")
+		}
+		if warn&nvmeWarningTemperature != 0 {
+			warning = append(warning, fmt.Sprintf("temperature %dC out of range",
+				status.Temperature))
+		}
+		if status.PercentageUsed >= 100 {
+			failing = append(failing, fmt.Sprintf("%d%% of the endurance used",
+				status.PercentageUsed))
+		} else if status.PercentageUsed >= wornPercentage {
+			warning = append(warning, fmt.Sprintf("%d%% of the endurance used",
+				status.PercentageUsed))
+		}
+	}
+	for _, counter := range status.Counters {
+		switch {
+		case counter.Name == "percentage_used":
+			// Handled above
+		case counter.Name == "Current_Pending_Sector" && counter.Value != 0:
+			warning = append(warning, fmt.Sprintf("%d sectors pending reallocation",
+				counter.Value))
+		case counter.DeltaDay != 0:
+			warning = append(warning, fmt.Sprintf("%s grew by %d in a day, to %d",
+				counter.Name, counter.DeltaDay, counter.Value))
+		}
+	}
+	switch {
+	case len(failing) != 0:
+		status.State = types.DiskHealthFailing
+	case len(warning) != 0:
+		status.State = types.DiskHealthWarning
+	default:
+		status.State = types.DiskHealthGood
+	}
+	status.Reasons = append(failing, warning...)
+}
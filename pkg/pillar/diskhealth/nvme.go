@@ -0,0 +1,109 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package diskhealth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// From linux/nvme_ioctl.h and the NVMe specification
+const (
+	// _IOWR('N', 0x41, struct nvme_admin_cmd)
+	nvmeIoctlAdminCmd = 0xc0484e41
+
+	nvmeAdminGetLogPage = 0x02
+	nvmeLogSmartHealth  = 0x02
+	nvmeNsidAll         = 0xffffffff
+	nvmeLogSize         = 512
+	nvmeTimeoutMs       = 10000
+)
+
+// Bits of the critical warning of the health log
+const (
+	nvmeWarningSpare       = 1 << 0
+	nvmeWarningTemperature = 1 << 1
+	nvmeWarningReliability = 1 << 2
+	nvmeWarningReadOnly    = 1 << 3
+	nvmeWarningBackup      = 1 << 4
+)
+
+// nvmePassthruCmd is struct nvme_passthru_cmd
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+var (
+	// nvmeLog receives the health log. The command passes its address as
+	// an integer, which is not updated if the stack is moved, hence it is
+	// not on the stack.
+	nvmeLog     [nvmeLogSize]byte
+	nvmeLogLock sync.Mutex
+)
+
+// readNVMe reads the SMART/health log of the controller of the namespace
+func readNVMe(disk string) (*types.DiskHealthStatus, error) {
+	f, err := os.Open("/dev/" + disk)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	nvmeLogLock.Lock()
+	defer nvmeLogLock.Unlock()
+	log := &nvmeLog
+	cmd := nvmePassthruCmd{
+		opcode:    nvmeAdminGetLogPage,
+		nsid:      nvmeNsidAll,
+		addr:      uint64(uintptr(unsafe.Pointer(&log[0]))),
+		dataLen:   nvmeLogSize,
+		cdw10:     (nvmeLogSize/4-1)<<16 | nvmeLogSmartHealth,
+		timeoutMs: nvmeTimeoutMs,
+	}
+	if err := ioctl(f, nvmeIoctlAdminCmd, unsafe.Pointer(&cmd)); err != nil {
+		return nil, fmt.Errorf("Get Log Page SMART/Health: %v", err)
+	}
+	status := types.DiskHealthStatus{Protocol: protocolNVMe}
+	parseNVMeHealth(log[:], &status)
+	return &status, nil
+}
+
+// parseNVMeHealth parses the SMART/health log page. The 128-bit counters
+// are read as 64-bit.
+func parseNVMeHealth(log []byte, status *types.DiskHealthStatus) {
+	status.CriticalWarning = log[0]
+	if kelvin := binary.LittleEndian.Uint16(log[1:3]); kelvin > 273 {
+		status.Temperature = uint32(kelvin) - 273
+	}
+	status.AvailableSpare = log[3]
+	status.SpareThreshold = log[4]
+	status.PercentageUsed = log[5]
+	status.PowerOnHours = binary.LittleEndian.Uint64(log[128:136])
+	status.Counters = []types.DiskHealthCounter{
+		{Name: "media_errors", Value: binary.LittleEndian.Uint64(log[160:168])},
+		{Name: "error_log_entries", Value: binary.LittleEndian.Uint64(log[176:184])},
+		{Name: "percentage_used", Value: uint64(status.PercentageUsed)},
+	}
+}
@@ -4,16 +4,21 @@
 package hypervisor
 
 import (
+	"context"
 	"fmt"
 	"github.com/lf-edge/eve/pkg/pillar/containerd"
 	"github.com/lf-edge/eve/pkg/pillar/types"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// When a pod starts its init containers have that long to complete, each
+const initContainerTimeout = 5 * time.Minute
+
 type ctrdContext struct {
 	domCounter int
 	PCI        map[string]bool
@@ -58,7 +63,7 @@ func (ctx ctrdContext) setupSpec(status *types.DomainStatus, config *types.Domai
 		return nil, err
 	}
 	spec.UpdateFromDomain(config)
-	spec.UpdateMounts(status.DiskStatusList)
+	spec.UpdateMounts(podVolumes(status.DiskStatusList))
 	spec.UpdateVifList(status.VifList)
 	spec.UpdateEnvVar(status.EnvVariables)
 
@@ -74,19 +79,101 @@ func (ctx ctrdContext) Setup(status types.DomainStatus, config types.DomainConfi
 	if err != nil {
 		return logError("setting up OCI spec for domain %s failed %v", status.DomainName, err)
 	}
-	spec.Get().Mounts = append(spec.Get().Mounts, specs.Mount{
-		Type:        "bind",
-		Source:      "/etc/resolv.conf",
-		Destination: "/etc/resolv.conf",
-		Options:     []string{"rbind", "ro"}})
+	spec.Get().Mounts = append(spec.Get().Mounts, resolvConfMount)
+	var initContainers, sidecars []string
+	for _, container := range config.Containers {
+		if container.Init {
+			initContainers = append(initContainers, container.Name)
+		} else {
+			sidecars = append(sidecars, container.Name)
+		}
+	}
+	spec.Get().Annotations[containerd.EVEOCIPodInitLabel] = strings.Join(initContainers, ",")
+	spec.Get().Annotations[containerd.EVEOCIPodSidecarsLabel] = strings.Join(sidecars, ",")
 
 	if err := spec.CreateContainer(true); err != nil {
 		return logError("Failed to create container for task %s from %v: %v", status.DomainName, config, err)
 	}
 
+	for _, container := range config.Containers {
+		if err := ctx.setupContainer(&status, &config, container); err != nil {
+			return logError("Failed to create container %s for task %s: %v",
+				container.Name, status.DomainName, err)
+		}
+	}
+
 	return nil
 }
 
+var resolvConfMount = specs.Mount{
+	Type:        "bind",
+	Source:      "/etc/resolv.conf",
+	Destination: "/etc/resolv.conf",
+	Options:     []string{"rbind", "ro"}}
+
+// podVolumes returns the volumes shared by the containers of a pod, i.e.,
+// without the images of the additional containers
+func podVolumes(disks []types.DiskStatus) []types.DiskStatus {
+	var volumes []types.DiskStatus
+	for _, disk := range disks {
+		if disk.Container == "" {
+			volumes = append(volumes, disk)
+		}
+	}
+	return volumes
+}
+
+// setupContainer creates an additional container of the pod. It gets the
+// volumes of the pod other than the root of the main container.
+func (ctx ctrdContext) setupContainer(status *types.DomainStatus, config *types.DomainConfig,
+	container types.ContainerConfig) error {
+
+	image := ""
+	for _, disk := range status.DiskStatusList {
+		if disk.Container == container.Name {
+			image = disk.FileLocation
+		}
+	}
+	if image == "" {
+		return fmt.Errorf("no volume with the image")
+	}
+	volumes := podVolumes(status.DiskStatusList)
+	if len(volumes) > 0 && volumes[0].FileLocation == status.OCIConfigDir {
+		volumes = volumes[1:]
+	}
+	spec, err := ctx.ctrdClient.NewOciSpec(types.PodContainerName(status.DomainName, container.Name))
+	if err != nil {
+		return err
+	}
+	if err := spec.UpdateFromVolume(image); err != nil {
+		return err
+	}
+	if err := spec.UpdateMounts(volumes); err != nil {
+		return err
+	}
+	spec.UpdateEnvVar(status.EnvVariables)
+	spec.UpdateFromContainer(config, container)
+	spec.Get().Mounts = append(spec.Get().Mounts, resolvConfMount)
+	return spec.CreateContainer(true)
+}
+
+// podContainers returns the additional containers of the pod, the ones
+// which are left from previous runs included
+func (ctx ctrdContext) podContainers(ctrdCtx context.Context, domainName string) []string {
+	ids, err := ctx.ctrdClient.CtrListContainerIds(ctrdCtx)
+	if err != nil {
+		logrus.Errorf("podContainers(%s) failed: %v", domainName, err)
+		return nil
+	}
+	var res []string
+	for _, id := range ids {
+		if pod, name := types.PodDomainName(id); pod == domainName && name != "" {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
 func (ctx ctrdContext) Create(domainName string, cfgFilename string, config *types.DomainConfig) (int, error) {
 	// if we are here we may need to get rid of the wedged, stale task just in case
 	// we are ignoring error here since it is cheaper to always call this as opposed
@@ -96,13 +183,70 @@ func (ctx ctrdContext) Create(domainName string, cfgFilename string, config *typ
 	defer done()
 	_ = ctx.ctrdClient.CtrStopContainer(ctrdCtx, domainName, true)
 
-	return ctx.ctrdClient.CtrCreateTask(ctrdCtx, domainName)
+	pid, err := ctx.ctrdClient.CtrCreateTask(ctrdCtx, domainName)
+	if err != nil {
+		return pid, err
+	}
+	// the additional containers of the pod join the network of the main task
+	for _, container := range config.Containers {
+		name := types.PodContainerName(domainName, container.Name)
+		_ = ctx.ctrdClient.CtrStopContainer(ctrdCtx, name, true)
+		if _, err := ctx.ctrdClient.CtrCreatePodTask(ctrdCtx, name, pid); err != nil {
+			return pid, fmt.Errorf("creating task for container %s failed: %v", container.Name, err)
+		}
+	}
+	return pid, nil
+}
+
+// podList splits the value of a pod label
+func podList(label string) []string {
+	if label == "" {
+		return nil
+	}
+	return strings.Split(label, ",")
+}
+
+// runInitContainer starts an init container of the pod and waits for it to complete
+func (ctx ctrdContext) runInitContainer(ctrdCtx context.Context, domainName string, container string) error {
+	name := types.PodContainerName(domainName, container)
+	if err := ctx.ctrdClient.CtrStartTask(ctrdCtx, name); err != nil {
+		return fmt.Errorf("starting init container %s failed: %v", container, err)
+	}
+	for start := time.Now(); time.Since(start) < initContainerTimeout; time.Sleep(time.Second) {
+		_, exit, status, err := ctx.ctrdClient.CtrContainerInfo(ctrdCtx, name)
+		if err != nil {
+			return fmt.Errorf("init container %s: %v", container, err)
+		}
+		if status != "stopped" {
+			continue
+		}
+		if exit != 0 {
+			return fmt.Errorf("init container %s exited with status %d", container, exit)
+		}
+		return nil
+	}
+	return fmt.Errorf("init container %s did not complete in %v", container, initContainerTimeout)
 }
 
 func (ctx ctrdContext) Start(domainName string, domainID int) error {
 	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
 	defer done()
-	err := ctx.ctrdClient.CtrStartTask(ctrdCtx, domainName)
+	annotations, err := ctx.ctrdClient.CtrGetAnnotations(ctrdCtx, domainName)
+	if err != nil {
+		return err
+	}
+	for _, container := range podList(annotations[containerd.EVEOCIPodInitLabel]) {
+		if err := ctx.runInitContainer(ctrdCtx, domainName, container); err != nil {
+			return err
+		}
+	}
+	for _, container := range podList(annotations[containerd.EVEOCIPodSidecarsLabel]) {
+		name := types.PodContainerName(domainName, container)
+		if err := ctx.ctrdClient.CtrStartTask(ctrdCtx, name); err != nil {
+			return fmt.Errorf("starting container %s failed: %v", container, err)
+		}
+	}
+	err = ctx.ctrdClient.CtrStartTask(ctrdCtx, domainName)
 	if err != nil {
 		return err
 	}
@@ -122,15 +266,33 @@ func (ctx ctrdContext) Start(domainName string, domainID int) error {
 func (ctx ctrdContext) Stop(domainName string, domainID int, force bool) error {
 	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
 	defer done()
+	// the tasks of the completed init containers are gone already
+	for _, name := range ctx.podContainers(ctrdCtx, domainName) {
+		_ = ctx.ctrdClient.CtrStopContainer(ctrdCtx, name, force)
+	}
 	return ctx.ctrdClient.CtrStopContainer(ctrdCtx, domainName, force)
 }
 
 func (ctx ctrdContext) Delete(domainName string, domainID int) error {
 	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
 	defer done()
+	for _, name := range ctx.podContainers(ctrdCtx, domainName) {
+		if err := ctx.ctrdClient.CtrDeleteContainer(ctrdCtx, name); err != nil {
+			logrus.Errorf("deleting container %s failed: %v", name, err)
+		}
+	}
 	return ctx.ctrdClient.CtrDeleteContainer(ctrdCtx, domainName)
 }
 
+// Exec runs a command in the container of the task
+func (ctx ctrdContext) Exec(domainName string, args []string, timeout time.Duration) (string, string, error) {
+	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
+	defer done()
+	ctrdCtx, cancel := context.WithTimeout(ctrdCtx, timeout)
+	defer cancel()
+	return ctx.ctrdClient.CtrExec(ctrdCtx, domainName, args)
+}
+
 func (ctx ctrdContext) Annotations(domainName string, domainID int) (map[string]string, error) {
 	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
 	defer done()
@@ -154,14 +316,7 @@ func (ctx ctrdContext) Info(domainName string, domainID int) (int, types.SwState
 			domainName, effectiveDomainID, domainID, status)
 	}
 
-	stateMap := map[string]types.SwState{
-		"created": types.INSTALLED,
-		"running": types.RUNNING,
-		"pausing": types.PAUSING,
-		"paused":  types.PAUSED,
-		"stopped": types.HALTED,
-	}
-	if effectiveDomainState, matched := stateMap[status]; !matched {
+	if effectiveDomainState, matched := ctrdStateMap[status]; !matched {
 		return effectiveDomainID, types.BROKEN, fmt.Errorf("task %s happens to be in an unexpected state %s",
 			domainName, status)
 	} else {
@@ -169,6 +324,32 @@ func (ctx ctrdContext) Info(domainName string, domainID int) (int, types.SwState
 	}
 }
 
+var ctrdStateMap = map[string]types.SwState{
+	"created": types.INSTALLED,
+	"running": types.RUNNING,
+	"pausing": types.PAUSING,
+	"paused":  types.PAUSED,
+	"stopped": types.HALTED,
+}
+
+// ContainerInfo returns the state and the exit code of an additional container of the pod
+func (ctx ctrdContext) ContainerInfo(domainName string, name string) (types.SwState, int, error) {
+	ctrdCtx, done := ctx.ctrdClient.CtrNewUserServicesCtx()
+	defer done()
+	_, exit, status, err := ctx.ctrdClient.CtrContainerInfo(ctrdCtx, types.PodContainerName(domainName, name))
+	if err != nil {
+		return types.UNKNOWN, 0, err
+	}
+	if status == "stopped" && exit != 0 {
+		return types.BROKEN, exit, nil
+	}
+	state, matched := ctrdStateMap[status]
+	if !matched {
+		return types.BROKEN, exit, fmt.Errorf("unexpected state %s", status)
+	}
+	return state, exit, nil
+}
+
 func (ctx ctrdContext) PCIReserve(long string) error {
 	if ctx.PCI[long] {
 		return fmt.Errorf("PCI %s is already reserved", long)
@@ -205,6 +386,10 @@ func (ctx ctrdContext) GetDomsCPUMem() (map[string]types.DomainMetric, error) {
 	}
 
 	for _, id := range ids {
+		// the additional containers of a pod are accounted in the cgroup of its main task
+		if _, container := types.PodDomainName(id); container != "" {
+			continue
+		}
 		var usedMem, availMem, totalMem uint32
 		var usedMemPerc float64
 		var cpuTotal uint64
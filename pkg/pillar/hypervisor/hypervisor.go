@@ -10,6 +10,7 @@ import (
 	"github.com/shirou/gopsutil/mem"
 	"github.com/sirupsen/logrus"
 	"os"
+	"time"
 )
 
 // Hypervisor provides methods for manipulating domains on the host
@@ -24,6 +25,46 @@ type Hypervisor interface {
 	GetDomsCPUMem() (map[string]types.DomainMetric, error)
 }
 
+// GuestAgent is implemented by the hypervisors which can talk to an
+// agent running inside the domain
+type GuestAgent interface {
+	GuestPing(domainName string, timeout time.Duration) error
+	// GuestInfo returns what the agent reports about the domain and
+	// its filesystems
+	GuestInfo(domainName string, timeout time.Duration) (types.GuestInfo, []types.GuestFilesystem, error)
+	// GuestShutdown asks the agent to power off the domain
+	GuestShutdown(domainName string, timeout time.Duration) error
+}
+
+// UsbHotplug is implemented by the hypervisors which can attach a host USB
+// device to a running domain. The id is used to detach it.
+type UsbHotplug interface {
+	UsbAttach(domainName string, id string, dev types.UsbDevice) error
+	UsbDetach(domainName string, id string) error
+}
+
+// Pod is implemented by the tasks which can run additional containers,
+// e.g., sidecars, with the main container of the domain
+type Pod interface {
+	// ContainerInfo returns the state and the exit code of a container
+	ContainerInfo(domainName string, name string) (types.SwState, int, error)
+}
+
+// VTpm is implemented by the hypervisors which can give a domain a
+// persistent virtual TPM. The state in stateDir is encrypted with key,
+// which must be VTpmKeySize bytes. VTpmStart is called before the domain
+// starts.
+type VTpm interface {
+	VTpmStart(domainName string, stateDir string, key []byte) error
+	VTpmStop(domainName string) error
+}
+
+// TaskExec is implemented by the tasks which can run a command inside
+// the domain; returns stdout and stderr
+type TaskExec interface {
+	Exec(domainName string, args []string, timeout time.Duration) (string, string, error)
+}
+
 type hypervisorDesc struct {
 	constructor func() Hypervisor
 	dom0handle  string
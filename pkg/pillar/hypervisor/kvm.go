@@ -9,6 +9,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/agentlog"
 	"github.com/lf-edge/eve/pkg/pillar/containerd"
 	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
 	"os"
@@ -32,7 +33,7 @@ const minUringKernelTag = uint64((5 << 16) | (4 << 8) | (72 << 0))
 // Thus the only PCI devices plugged directly into the root (pci.0) bus are:
 //    00:01.0 cirrus-vga
 //    00:02.0 pcie-root-port for QEMU XHCI Host Controller
-//    00:03.0 virtio-serial for hvc consoles and serial communications with the domain
+//    00:03.0 virtio-serial for hvc consoles, the QEMU guest agent and serial communications with the domain
 //    00:0x.0 pcie-root-port for block or network device #x (where x > 2)
 //    00:0y.0 virtio-9p-pci
 //
@@ -149,6 +150,34 @@ const qemuConfTemplate = `# This file is automatically generated by domainmgr
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "` + kvmStateDir + `{{.DisplayName}}/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
+{{if .VTpm}}
+[chardev "chartpm"]
+  backend = "socket"
+  path = "` + kvmStateDir + `{{.DisplayName}}/swtpm"
+
+[tpmdev "tpm0"]
+  type = "emulator"
+  chardev = "chartpm"
+
+[device]
+{{- if eq .Machine "virt" }}
+  driver = "tpm-tis-device"
+{{- else }}
+  driver = "tpm-crb"
+{{- end }}
+  tpmdev = "tpm0"
+{{end}}
 {{if .EnableVnc}}
 [vnc "default"]
   vnc = "0.0.0.0:{{if .VncDisplay}}{{.VncDisplay}}{{else}}0{{end}}"
@@ -331,6 +360,7 @@ const vfioDriverPath = "/sys/bus/pci/drivers/vfio-pci"
 //    pid - contains PID of the anchor process
 //    qmp - UNIX domain socket that allows us to talk to anchor process
 //   cons - symlink to /dev/pts/X that allows us to talk to the serial console of the domain
+//  swtpm - UNIX domain socket of the virtual TPM of the domain, if it has one
 // In addition to that, we also maintain DOMAIN_NAME -> PID mapping in kvmContext, so we don't
 // have to look things up in the filesystem all the time (this also allows us to filter domains
 // that may be created by others)
@@ -422,6 +452,21 @@ func (ctx kvmContext) Setup(status types.DomainStatus, config types.DomainConfig
 		return logError("failed to add kvm hypervisor loader to domain %s: %v", status.DomainName, err)
 	}
 
+	if config.Hugepages.Bytes() != 0 {
+		// qemu maps the guest memory from a hugetlbfs of its own,
+		// the pages come from the pool domainmgr reserved
+		memPath := kvmStateDir + domainName + "/hugepages"
+		if err := os.MkdirAll(memPath, 0700); err != nil {
+			return logError("failed to create %s: %v", memPath, err)
+		}
+		spec.Get().Mounts = append(spec.Get().Mounts, specs.Mount{
+			Type:        "hugetlbfs",
+			Source:      "hugetlbfs",
+			Destination: memPath,
+			Options:     []string{"pagesize=" + config.Hugepages.String()},
+		})
+		args = append(args, "-mem-path", memPath, "-mem-prealloc")
+	}
 	spec.AdjustMemLimit(config, qemuOverHead)
 	spec.Get().Process.Args = args
 	if err := spec.CreateContainer(true); err != nil {
@@ -438,6 +483,10 @@ func (ctx kvmContext) CreateDomConfig(domainName string, config types.DomainConf
 		types.DomainConfig
 	}{ctx.devicemodel, config}
 	tmplCtx.Memory = (config.Memory + 1023) / 1024
+	if config.Hugepages.Bytes() != 0 {
+		// qemu needs whole pages
+		tmplCtx.Memory = int(config.Hugepages.RoundUpMemory(config.Memory) >> 20)
+	}
 	tmplCtx.DisplayName = domainName
 
 	// render global device model settings
@@ -702,6 +751,9 @@ func (ctx kvmContext) Delete(domainName string, domainID int) (result error) {
 		return logError("failed to execute quit command %v", err)
 	}
 	// we may want to wait a little bit here and actually kill qemu process if it gets wedged
+	if err := ctx.VTpmStop(domainName); err != nil {
+		logrus.Warnf("failed to stop vTPM of domain %s: %v", domainName, err)
+	}
 	if err := os.RemoveAll(kvmStateDir + domainName); err != nil {
 		return logError("failed to clean up domain state directory %s (%v)", domainName, err)
 	}
@@ -821,6 +873,36 @@ func usbBusPort(USBAddr string) (string, string) {
 	return "", ""
 }
 
+// GuestPing checks that the QEMU guest agent in the domain responds
+func (ctx kvmContext) GuestPing(domainName string, timeout time.Duration) error {
+	return execGuestPing(getQgaSocket(domainName), timeout)
+}
+
+// GuestInfo returns what the QEMU guest agent reports about the domain
+func (ctx kvmContext) GuestInfo(domainName string, timeout time.Duration) (types.GuestInfo, []types.GuestFilesystem, error) {
+	return execGuestInfo(getQgaSocket(domainName), timeout)
+}
+
+// GuestShutdown asks the QEMU guest agent to power off the domain
+func (ctx kvmContext) GuestShutdown(domainName string, timeout time.Duration) error {
+	return execGuestShutdown(getQgaSocket(domainName), timeout)
+}
+
+// UsbAttach passes the host USB device through to the running domain
+func (ctx kvmContext) UsbAttach(domainName string, id string, dev types.UsbDevice) error {
+	_, hostport := usbBusPort(dev.PortPath)
+	if hostport == "" {
+		return fmt.Errorf("invalid USB port path %s", dev.PortPath)
+	}
+	return execUsbAttach(getQmpExecutorSocket(domainName), id, dev.Busnum,
+		hostport)
+}
+
+// UsbDetach removes a USB device added by UsbAttach
+func (ctx kvmContext) UsbDetach(domainName string, id string) error {
+	return execDeviceDel(getQmpExecutorSocket(domainName), id)
+}
+
 func getQmpExecutorSocket(domainName string) string {
 	return kvmStateDir + domainName + "/qmp"
 }
@@ -189,6 +189,17 @@ func TestCreateDomConfigOnlyCom1(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -451,6 +462,17 @@ func TestCreateDomConfigOnlyCom1(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -689,6 +711,17 @@ func TestCreateDomConfigOnlyCom1(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -1025,6 +1058,17 @@ func TestCreateDomConfig(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -1294,6 +1338,17 @@ func TestCreateDomConfig(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -1568,6 +1623,17 @@ func TestCreateDomConfig(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -1814,6 +1880,17 @@ func TestCreateDomConfig(t *testing.T) {
   chardev = "charserial0"
   name = "org.lfedge.eve.console.0"
 
+[chardev "charqga"]
+  backend = "socket"
+  path = "/run/hypervisor/kvm/test/qga"
+  server = "on"
+  wait = "off"
+
+[device]
+  driver = "virtserialport"
+  chardev = "charqga"
+  name = "org.qemu.guest_agent.0"
+
 
 #[device "video0"]
 #  driver = "qxl-vga"
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package hypervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// this file implements a subset of the QEMU guest agent protocol
+//     https://qemu.readthedocs.io/en/latest/interop/qemu-ga-ref.html
+// spoken over the org.qemu.guest_agent.0 virtio-serial port
+
+type qgaResponse struct {
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+}
+
+func getQgaSocket(domainName string) string {
+	return kvmStateDir + domainName + "/qga"
+}
+
+// qgaConnect connects to the guest agent. The channel has no framing or
+// greeting and it can hold a stale response from an earlier command which
+// timed out, hence we first synchronize with guest-sync and a random id.
+func qgaConnect(socket string, timeout time.Duration) (net.Conn, *json.Decoder, error) {
+	conn, err := net.DialTimeout("unix", socket, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	id := rand.Int31()
+	sync := fmt.Sprintf(`{"execute": "guest-sync", "arguments": {"id": %d}}`, id)
+	if _, err := conn.Write([]byte(sync + "\n")); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	dec := json.NewDecoder(conn)
+	for {
+		var resp qgaResponse
+		if err := dec.Decode(&resp); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("guest-sync failed: %v", err)
+		}
+		var gotID int32
+		if json.Unmarshal(resp.Return, &gotID) == nil && gotID == id {
+			return conn, dec, nil
+		}
+	}
+}
+
+// execQgaCmd runs one guest agent command and returns the content of its
+// return value
+func execQgaCmd(socket string, cmd string, timeout time.Duration) (json.RawMessage, error) {
+	conn, dec, err := qgaConnect(socket, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(cmd + "\n")); err != nil {
+		return nil, err
+	}
+	var resp qgaResponse
+	if err := dec.Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s: %s", resp.Error.Class, resp.Error.Desc)
+	}
+	return resp.Return, nil
+}
+
+func execGuestPing(socket string, timeout time.Duration) error {
+	_, err := execQgaCmd(socket, `{"execute": "guest-ping"}`, timeout)
+	return err
+}
+
+// execGuestShutdown asks the guest to power off. The agent does not respond
+// on success, hence only an error response within the timeout is a failure.
+func execGuestShutdown(socket string, timeout time.Duration) error {
+	conn, dec, err := qgaConnect(socket, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	cmd := `{"execute": "guest-shutdown", "arguments": {"mode": "powerdown"}}`
+	if _, err := conn.Write([]byte(cmd + "\n")); err != nil {
+		return err
+	}
+	var resp qgaResponse
+	if err := dec.Decode(&resp); err != nil {
+		return nil
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %s", resp.Error.Class, resp.Error.Desc)
+	}
+	return nil
+}
+
+type qgaInterface struct {
+	Name            string `json:"name"`
+	HardwareAddress string `json:"hardware-address"`
+	IPAddresses     []struct {
+		Type    string `json:"ip-address-type"`
+		Address string `json:"ip-address"`
+	} `json:"ip-addresses"`
+}
+
+type qgaFilesystem struct {
+	Mountpoint string `json:"mountpoint"`
+	Type       string `json:"type"`
+	UsedBytes  uint64 `json:"used-bytes"`
+	TotalBytes uint64 `json:"total-bytes"`
+}
+
+type qgaOSInfo struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	KernelRelease string `json:"kernel-release"`
+}
+
+type qgaHostName struct {
+	HostName string `json:"host-name"`
+}
+
+// parseGuestInterfaces returns the interfaces with global IP addresses
+func parseGuestInterfaces(raw json.RawMessage) ([]types.GuestInterface, error) {
+	var ifs []qgaInterface
+	if err := json.Unmarshal(raw, &ifs); err != nil {
+		return nil, err
+	}
+	var result []types.GuestInterface
+	for _, i := range ifs {
+		gi := types.GuestInterface{
+			Name:    i.Name,
+			MacAddr: i.HardwareAddress,
+		}
+		for _, addr := range i.IPAddresses {
+			ip := net.ParseIP(addr.Address)
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			gi.IPAddrs = append(gi.IPAddrs, ip.String())
+		}
+		if len(gi.IPAddrs) != 0 {
+			result = append(result, gi)
+		}
+	}
+	return result, nil
+}
+
+// parseGuestFilesystems returns the filesystems with a size; the guest
+// agent reports a mount once per disk, and pseudo filesystems without one
+func parseGuestFilesystems(raw json.RawMessage) ([]types.GuestFilesystem, error) {
+	var fss []qgaFilesystem
+	if err := json.Unmarshal(raw, &fss); err != nil {
+		return nil, err
+	}
+	var result []types.GuestFilesystem
+	seen := make(map[string]bool)
+	for _, fs := range fss {
+		if fs.TotalBytes == 0 || seen[fs.Mountpoint] {
+			continue
+		}
+		seen[fs.Mountpoint] = true
+		result = append(result, types.GuestFilesystem{
+			Mountpoint: fs.Mountpoint,
+			Type:       fs.Type,
+			TotalBytes: fs.TotalBytes,
+			UsedBytes:  fs.UsedBytes,
+		})
+	}
+	return result, nil
+}
+
+// execGuestInfo collects what the guest agent reports about the domain.
+// Only guest-network-get-interfaces is required; the other commands are
+// missing in older agents or disabled in some distributions.
+func execGuestInfo(socket string, timeout time.Duration) (types.GuestInfo, []types.GuestFilesystem, error) {
+	var info types.GuestInfo
+	raw, err := execQgaCmd(socket, `{"execute": "guest-network-get-interfaces"}`, timeout)
+	if err != nil {
+		return info, nil, err
+	}
+	if info.Interfaces, err = parseGuestInterfaces(raw); err != nil {
+		return info, nil, err
+	}
+	if raw, err := execQgaCmd(socket, `{"execute": "guest-get-osinfo"}`, timeout); err == nil {
+		var osInfo qgaOSInfo
+		if json.Unmarshal(raw, &osInfo) == nil {
+			info.OSName = osInfo.Name
+			info.OSVersion = osInfo.Version
+			info.KernelRelease = osInfo.KernelRelease
+		}
+	}
+	if raw, err := execQgaCmd(socket, `{"execute": "guest-get-host-name"}`, timeout); err == nil {
+		var hostName qgaHostName
+		if json.Unmarshal(raw, &hostName) == nil {
+			info.Hostname = hostName.HostName
+		}
+	}
+	var fss []types.GuestFilesystem
+	if raw, err := execQgaCmd(socket, `{"execute": "guest-get-fsinfo"}`, timeout); err == nil {
+		fss, _ = parseGuestFilesystems(raw)
+	}
+	return info, fss, nil
+}
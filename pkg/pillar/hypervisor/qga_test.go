@@ -0,0 +1,162 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package hypervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeGuestAgent answers the commands on the socket like qemu-ga. The
+// stale response is sent first to check that we skip it.
+func fakeGuestAgent(t *testing.T, l net.Listener, stale string, pingErr bool) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if stale != "" {
+		conn.Write([]byte(stale + "\n"))
+	}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var cmd struct {
+			Execute   string `json:"execute"`
+			Arguments struct {
+				ID int32 `json:"id"`
+			} `json:"arguments"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &cmd); err != nil {
+			t.Errorf("bad command %s: %v", scanner.Text(), err)
+			return
+		}
+		switch {
+		case cmd.Execute == "guest-sync":
+			fmt.Fprintf(conn, "{\"return\": %d}\n", cmd.Arguments.ID)
+		case cmd.Execute == "guest-ping" && pingErr:
+			conn.Write([]byte(`{"error": {"class": "GenericError", "desc": "broken"}}` + "\n"))
+		case cmd.Execute == "guest-ping":
+			conn.Write([]byte(`{"return": {}}` + "\n"))
+		case cmd.Execute == "guest-shutdown":
+			// No response; the agent goes away with the guest
+			return
+		}
+	}
+}
+
+func TestGuestPing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testMatrix := map[string]struct {
+		stale      string
+		pingErr    bool
+		noAgent    bool
+		expectFail bool
+	}{
+		"Ping": {},
+		"Stale response": {
+			stale: `{"return": 12345}`,
+		},
+		"Error": {
+			pingErr:    true,
+			expectFail: true,
+		},
+		"No socket": {
+			noAgent:    true,
+			expectFail: true,
+		},
+	}
+	for testname, test := range testMatrix {
+		t.Logf("Running test case %s", testname)
+		socket := filepath.Join(dir, "qga")
+		var l net.Listener
+		if !test.noAgent {
+			l, err = net.Listen("unix", socket)
+			if err != nil {
+				t.Fatal(err)
+			}
+			go fakeGuestAgent(t, l, test.stale, test.pingErr)
+		}
+		err := execGuestPing(socket, 5*time.Second)
+		if (err != nil) != test.expectFail {
+			t.Errorf("%s: error %v, expected failure %t", testname,
+				err, test.expectFail)
+		}
+		if l != nil {
+			l.Close()
+			os.Remove(socket)
+		}
+	}
+}
+
+func TestGuestShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "qga")
+	if err := execGuestShutdown(socket, time.Second); err == nil {
+		t.Errorf("shutdown without an agent succeeded")
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go fakeGuestAgent(t, l, "", false)
+	if err := execGuestShutdown(socket, 5*time.Second); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+}
+
+func TestParseGuestInfo(t *testing.T) {
+	interfaces := `[
+		{"name": "lo", "hardware-address": "00:00:00:00:00:00",
+		 "ip-addresses": [{"ip-address-type": "ipv4", "ip-address": "127.0.0.1", "prefix": 8}]},
+		{"name": "eth0", "hardware-address": "02:16:3e:11:22:33",
+		 "ip-addresses": [
+			{"ip-address-type": "ipv4", "ip-address": "10.1.0.2", "prefix": 24},
+			{"ip-address-type": "ipv6", "ip-address": "fe80::16:3eff:fe11:2233", "prefix": 64},
+			{"ip-address-type": "ipv6", "ip-address": "2001:db8::2", "prefix": 64}]},
+		{"name": "eth1", "hardware-address": "02:16:3e:11:22:34"}]`
+	ifs, err := parseGuestInterfaces(json.RawMessage(interfaces))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifs) != 1 || ifs[0].Name != "eth0" ||
+		ifs[0].MacAddr != "02:16:3e:11:22:33" || len(ifs[0].IPAddrs) != 2 ||
+		ifs[0].IPAddrs[0] != "10.1.0.2" || ifs[0].IPAddrs[1] != "2001:db8::2" {
+		t.Errorf("interfaces %+v", ifs)
+	}
+
+	filesystems := `[
+		{"name": "sda1", "mountpoint": "/", "type": "ext4",
+		 "used-bytes": 1000, "total-bytes": 4000, "disk": []},
+		{"name": "sda1", "mountpoint": "/", "type": "ext4",
+		 "used-bytes": 1000, "total-bytes": 4000, "disk": []},
+		{"name": "sysfs", "mountpoint": "/sys", "type": "sysfs", "disk": []},
+		{"name": "sdb1", "mountpoint": "/data", "type": "xfs",
+		 "used-bytes": 10, "total-bytes": 20, "disk": []}]`
+	fss, err := parseGuestFilesystems(json.RawMessage(filesystems))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fss) != 2 || fss[0].Mountpoint != "/" || fss[0].UsedBytes != 1000 ||
+		fss[0].TotalBytes != 4000 || fss[1].Mountpoint != "/data" ||
+		fss[1].Type != "xfs" {
+		t.Errorf("filesystems %+v", fss)
+	}
+}
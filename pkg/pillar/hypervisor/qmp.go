@@ -54,6 +54,21 @@ func execVNCPassword(socket string, password string) error {
 	return err
 }
 
+// execUsbAttach adds a usb-host device for the bus and port path, which
+// also follows the device if it is unplugged and plugged in again
+func execUsbAttach(socket, id string, hostbus uint32, hostport string) error {
+	deviceAdd := fmt.Sprintf(`{ "execute": "device_add", "arguments": { "driver": "usb-host", "bus": "usb.0", "id": "%s", "hostbus": %d, "hostport": "%s" } }`,
+		id, hostbus, hostport)
+	_, err := execRawCmd(socket, deviceAdd)
+	return err
+}
+
+func execDeviceDel(socket, id string) error {
+	deviceDel := fmt.Sprintf(`{ "execute": "device_del", "arguments": { "id": "%s" } }`, id)
+	_, err := execRawCmd(socket, deviceDel)
+	return err
+}
+
 func getQemuStatus(socket string) (string, error) {
 	if raw, err := execRawCmd(socket, `{ "execute": "query-status" }`); err == nil {
 		var result struct {
@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Virtual TPM of a KVM domain. An swtpm process emulates the TPM of every
+// domain which has one; qemu talks to it through the control socket in the
+// state directory of the domain and the process terminates when qemu
+// closes the socket. The persistent state is encrypted with a key which
+// the caller passes to swtpm through a pipe, so it never touches the disk
+// in the clear.
+
+package hypervisor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const swtpmExec = "/usr/bin/swtpm"
+
+// VTpmKeySize is the size of the key encrypting the state of a vTPM
+const VTpmKeySize = 32
+
+func getSwtpmCtrlSocket(domainName string) string {
+	return kvmStateDir + domainName + "/swtpm"
+}
+
+func getSwtpmPidFile(domainName string) string {
+	return kvmStateDir + domainName + "/swtpm.pid"
+}
+
+// VTpmStart starts the vTPM of the domain with its state in stateDir
+func (ctx kvmContext) VTpmStart(domainName string, stateDir string, key []byte) error {
+	if len(key) != VTpmKeySize {
+		return logError("VTpmStart(%s): wrong key size %d", domainName, len(key))
+	}
+	// an swtpm left behind, e.g., by a domain which failed to start
+	// holds the lock of the state directory
+	if err := ctx.VTpmStop(domainName); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(kvmStateDir+domainName, 0777); err != nil {
+		return logError("VTpmStart(%s): %v", domainName, err)
+	}
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return logError("VTpmStart(%s): %v", domainName, err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return logError("VTpmStart(%s): %v", domainName, err)
+	}
+	defer r.Close()
+	_, err = w.Write(key)
+	w.Close()
+	if err != nil {
+		return logError("VTpmStart(%s): writing key: %v", domainName, err)
+	}
+	ctrlSocket := getSwtpmCtrlSocket(domainName)
+	cmd := exec.Command(swtpmExec, "socket", "--tpm2",
+		"--tpmstate", "dir="+stateDir+",mode=0600",
+		"--ctrl", "type=unixio,path="+ctrlSocket+",terminate",
+		// the pipe is the first of ExtraFiles
+		"--key", "fd=3,format=binary,mode=aes-256-cbc",
+		"--pid", "file="+getSwtpmPidFile(domainName),
+		"--log", "file="+filepath.Join(stateDir, "swtpm.log"),
+		"--daemon")
+	cmd.ExtraFiles = []*os.File{r}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return logError("VTpmStart(%s): %s failed: %v: %s",
+			domainName, swtpmExec, err, out)
+	}
+	return waitForSwtpm(domainName)
+}
+
+// VTpmStop stops the vTPM of the domain if it is running
+func (ctx kvmContext) VTpmStop(domainName string) error {
+	pidFile := getSwtpmPidFile(domainName)
+	b, err := ioutil.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return logError("VTpmStop(%s): %v", domainName, err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return logError("VTpmStop(%s): bad pid in %s: %v", domainName, pidFile, err)
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+		return logError("VTpmStop(%s): kill %d: %v", domainName, pid, err)
+	}
+	os.Remove(pidFile)
+	os.Remove(getSwtpmCtrlSocket(domainName))
+	logrus.Infof("VTpmStop(%s): stopped swtpm %d", domainName, pid)
+	return nil
+}
+
+func waitForSwtpm(domainName string) error {
+	maxDelay := time.Second * 10
+	delay := 100 * time.Millisecond
+	var waited time.Duration
+	ctrlSocket := getSwtpmCtrlSocket(domainName)
+	for {
+		if _, err := os.Stat(ctrlSocket); err == nil {
+			logrus.Infof("waitForSwtpm for %s, found socket", domainName)
+			return nil
+		}
+		if waited > maxDelay {
+			return fmt.Errorf("swtpm socket %s not found", ctrlSocket)
+		}
+		time.Sleep(delay)
+		waited += delay
+		delay = 2 * delay
+	}
+}
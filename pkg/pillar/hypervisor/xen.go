@@ -84,6 +84,12 @@ func (ctx xenContext) Setup(status types.DomainStatus, config types.DomainConfig
 	if err = spec.AddLoader("/containers/services/xen-tools"); err != nil {
 		return logError("failed to add xen hypervisor loader to domain %s: %v", status.DomainName, err)
 	}
+	// xl pins the vCPUs using the cpus in the domain config. The loader
+	// runs in dom0 whose CPUs are not the physical ones.
+	if linux := spec.Get().Linux; linux != nil && linux.Resources != nil &&
+		linux.Resources.CPU != nil {
+		linux.Resources.CPU.Cpus = ""
+	}
 
 	// finally we can start it up
 	spec.Get().Process.Args = []string{"/etc/xen/scripts/xen-start", status.DomainName, file.Name()}
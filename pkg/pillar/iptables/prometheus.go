@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Lets in the connections to the local Prometheus endpoint of zedagent
+
+package iptables
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+)
+
+// PrometheusAccess is who can connect to the Prometheus endpoint
+type PrometheusAccess struct {
+	Port   uint32       // Zero if the endpoint is disabled
+	Ifname string       // Only through this port if set
+	Allow  []*net.IPNet // Only from these prefixes if set
+}
+
+type prometheusRule struct {
+	ipv6 bool
+	args []string
+}
+
+// rules returns the rules in the order they are to be in the chains
+func (access PrometheusAccess) rules() []prometheusRule {
+	if access.Port == 0 {
+		return nil
+	}
+	match := []string{"-p", "tcp", "--dport", strconv.Itoa(int(access.Port))}
+	if access.Ifname != "" {
+		match = append(match, "-i", access.Ifname)
+	}
+	var rules []prometheusRule
+	for _, ipv6 := range []bool{false, true} {
+		rules = append(rules, prometheusRule{ipv6: ipv6,
+			args: append([]string{"-t", "mangle", "PREROUTING"}, append(match,
+				"-j", "CONNMARK", "--set-mark", ControlProtocolMarkingIDMap["in_prometheus"])...)})
+	}
+	if access.Ifname == "" && len(access.Allow) == 0 {
+		return rules
+	}
+	for _, prefix := range access.Allow {
+		rules = append(rules, prometheusRule{ipv6: prefix.IP.To4() == nil,
+			args: append([]string{"-t", "filter", "INPUT"}, append(match,
+				"-s", prefix.String(), "-j", "ACCEPT")...)})
+	}
+	if len(access.Allow) == 0 {
+		for _, ipv6 := range []bool{false, true} {
+			rules = append(rules, prometheusRule{ipv6: ipv6,
+				args: append([]string{"-t", "filter", "INPUT"}, append(match,
+					"-j", "ACCEPT")...)})
+		}
+	}
+	// Everything else, e.g., through the other ports, is rejected
+	for _, ipv6 := range []bool{false, true} {
+		rules = append(rules, prometheusRule{ipv6: ipv6,
+			args: []string{"-t", "filter", "INPUT", "-p", "tcp",
+				"--dport", strconv.Itoa(int(access.Port)),
+				"-j", "REJECT", "--reject-with", "tcp-reset"}})
+	}
+	return rules
+}
+
+// apply inserts or deletes the rule
+func (rule prometheusRule) apply(log *base.LogObject, op string) {
+	// args are the table, the chain and the rule
+	args := []string{rule.args[0], rule.args[1], op, rule.args[2]}
+	if op == "-I" {
+		args = append(args, "1")
+	}
+	args = append(args, rule.args[3:]...)
+	if rule.ipv6 {
+		Ip6tableCmd(log, args...)
+	} else {
+		IptableCmd(log, args...)
+	}
+}
+
+// UpdatePrometheusAccess replaces the rules for the old access with the
+// ones for the new access
+func UpdatePrometheusAccess(log *base.LogObject, old, new PrometheusAccess) {
+
+	log.Functionf("UpdatePrometheusAccess(%+v, %+v)", old, new)
+
+	for _, rule := range old.rules() {
+		rule.apply(log, "-D")
+	}
+	// Each rule is inserted first hence in the reverse order
+	rules := new.rules()
+	for i := len(rules) - 1; i >= 0; i-- {
+		rules[i].apply(log, "-I")
+	}
+}
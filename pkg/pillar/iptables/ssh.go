@@ -34,6 +34,8 @@ var ControlProtocolMarkingIDMap = map[string]string{
 	"in_vpn_control": "8",
 	// ICMP and ICMPv6
 	"in_icmp": "9",
+	// INPUT flows for the Prometheus endpoint
+	"in_prometheus": "10",
 }
 
 func UpdateSshAccess(log *base.LogObject, enable bool, first bool) {
@@ -0,0 +1,310 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Read the hardware sensors of the device: the temperatures, fans,
+// voltages, currents and power of the Linux hwmon chips, the temperatures
+// of the thermal zones, and when asked the analog sensors of the SDR of the
+// BMC through the local IPMI interface with ipmitool. The thermal zones
+// which are also hwmon chips, such as acpitz, are read once from hwmon.
+
+package sensors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const ipmiTimeout = 20 * time.Second
+
+var (
+	// sysRoot is changed by the tests
+	sysRoot = "/sys"
+	// ipmiDevices are where the ipmi_devintf driver puts the interface
+	ipmiDevices = []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"}
+)
+
+// hwmonKind is a kind of hwmon attribute
+type hwmonKind struct {
+	prefix string
+	typ    types.SensorType
+	scale  float64 // of the sysfs values to the unit of typ
+}
+
+var hwmonKinds = []hwmonKind{
+	{"temp", types.SensorTypeTemperature, 1000},   // millidegree
+	{"fan", types.SensorTypeFan, 1},               // RPM
+	{"in", types.SensorTypeVoltage, 1000},         // millivolt
+	{"curr", types.SensorTypeCurrent, 1000},       // milliampere
+	{"power", types.SensorTypePower, 1000 * 1000}, // microwatt
+}
+
+// Read returns the sensors of hwmon and the thermal zones, and of IPMI
+// if ipmi is set and the device has an IPMI interface, sorted by source
+// and name
+func Read(log *base.LogObject, ipmi bool) []types.SensorMetric {
+	metrics, chips := readHwmon(log)
+	metrics = append(metrics, readThermal(log, chips)...)
+	if ipmi {
+		if dev := ipmiDevice(); dev != "" {
+			ipmiMetrics, err := readIPMI()
+			if err != nil {
+				log.Warnf("sensors: ipmi %s: %v", dev, err)
+			}
+			metrics = append(metrics, ipmiMetrics...)
+		}
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Source != metrics[j].Source {
+			return metrics[i].Source < metrics[j].Source
+		}
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+// readHwmon reads the attributes of the chips in /sys/class/hwmon, and
+// returns the names of the chips
+func readHwmon(log *base.LogObject) ([]types.SensorMetric, map[string]bool) {
+	var metrics []types.SensorMetric
+	chips := make(map[string]bool)
+	classDir := filepath.Join(sysRoot, "class/hwmon")
+	infos, err := ioutil.ReadDir(classDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("sensors: %v", err)
+		}
+		return nil, chips
+	}
+	for _, info := range infos {
+		dir := filepath.Join(classDir, info.Name())
+		name := readString(filepath.Join(dir, "name"))
+		if name == "" {
+			// The attributes of older drivers are under device
+			dir = filepath.Join(dir, "device")
+			name = readString(filepath.Join(dir, "name"))
+		}
+		if name == "" {
+			continue
+		}
+		chips[name] = true
+		chip := name + "." + strings.TrimPrefix(info.Name(), "hwmon")
+		metrics = append(metrics, readHwmonChip(dir, chip)...)
+	}
+	return metrics, chips
+}
+
+func readHwmonChip(dir string, chip string) []types.SensorMetric {
+	var metrics []types.SensorMetric
+	inputs, _ := filepath.Glob(filepath.Join(dir, "*_input"))
+	averages, _ := filepath.Glob(filepath.Join(dir, "power*_average"))
+	for _, path := range append(inputs, averages...) {
+		file := filepath.Base(path)
+		sensor := file[:strings.LastIndex(file, "_")]
+		if strings.HasSuffix(file, "_average") && fileExists(filepath.Join(dir, sensor+"_input")) {
+			continue
+		}
+		kind, ok := hwmonKindOf(sensor)
+		if !ok {
+			continue
+		}
+		raw, err := readFloat(path)
+		if err != nil {
+			// e.g., a sensor which is not connected
+			continue
+		}
+		attr := func(suffix string) float64 {
+			v, _ := readFloat(filepath.Join(dir, sensor+"_"+suffix))
+			return v / kind.scale
+		}
+		label := readString(filepath.Join(dir, sensor+"_label"))
+		if label == "" {
+			label = sensor
+		}
+		metric := types.SensorMetric{
+			Name:     chip + "/" + label,
+			Source:   "hwmon",
+			Type:     kind.typ,
+			Value:    raw / kind.scale,
+			Min:      attr("min"),
+			Max:      attr("max"),
+			Critical: attr("crit"),
+		}
+		if kind.typ == types.SensorTypePower && metric.Max == 0 {
+			metric.Max = attr("cap")
+		}
+		for _, alarm := range []string{"alarm", "crit_alarm", "fault"} {
+			if v, err := readFloat(filepath.Join(dir, sensor+"_"+alarm)); err == nil && v != 0 {
+				metric.Alarm = true
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// hwmonKindOf returns the kind of a sensor such as temp1 or in0
+func hwmonKindOf(sensor string) (hwmonKind, bool) {
+	for _, kind := range hwmonKinds {
+		index := strings.TrimPrefix(sensor, kind.prefix)
+		if index == sensor {
+			continue
+		}
+		if _, err := strconv.Atoi(index); err == nil {
+			return kind, true
+		}
+	}
+	return hwmonKind{}, false
+}
+
+// readThermal reads the thermal zones which are not hwmon chips
+func readThermal(log *base.LogObject, chips map[string]bool) []types.SensorMetric {
+	var metrics []types.SensorMetric
+	classDir := filepath.Join(sysRoot, "class/thermal")
+	zones, _ := filepath.Glob(filepath.Join(classDir, "thermal_zone*"))
+	for _, dir := range zones {
+		zoneType := readString(filepath.Join(dir, "type"))
+		if chips[zoneType] {
+			continue
+		}
+		temp, err := readFloat(filepath.Join(dir, "temp"))
+		if err != nil {
+			continue
+		}
+		metric := types.SensorMetric{
+			Name:   filepath.Base(dir) + "/" + zoneType,
+			Source: "thermal",
+			Type:   types.SensorTypeTemperature,
+			Value:  temp / 1000,
+		}
+		trips, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+		for _, trip := range trips {
+			tripTemp, err := readFloat(strings.TrimSuffix(trip, "_type") + "_temp")
+			if err != nil || tripTemp <= 0 {
+				continue
+			}
+			tripTemp /= 1000
+			switch readString(trip) {
+			case "critical":
+				metric.Critical = tripTemp
+			case "hot":
+				metric.Max = tripTemp
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+func ipmiDevice() string {
+	for _, dev := range ipmiDevices {
+		if fileExists(dev) {
+			return dev
+		}
+	}
+	return ""
+}
+
+// readIPMI reads the full sensor records of the SDR
+func readIPMI() ([]types.SensorMetric, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ipmiTimeout)
+	defer cancel()
+	// ipmitool finds the interface of the first BMC
+	cmd := exec.CommandContext(ctx, "ipmitool", "-c", "sdr", "list", "full")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ipmitool: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseIPMI(bytes.NewReader(out))
+}
+
+// ipmiUnits are the units of ipmitool for the types
+var ipmiUnits = map[string]types.SensorType{
+	"degrees C": types.SensorTypeTemperature,
+	"RPM":       types.SensorTypeFan,
+	"Volts":     types.SensorTypeVoltage,
+	"Amps":      types.SensorTypeCurrent,
+	"Watts":     types.SensorTypePower,
+}
+
+// parseIPMI parses the lines of ipmitool -c sdr list full, which are
+// name,value,unit,status such as "CPU1 Temp,45,degrees C,ok". The sensors
+// without reading and with other units are skipped. The status is ok, or
+// the threshold crossed: nc, cr or nr, with the lower or upper ones in some
+// versions; crossing a critical or non recoverable threshold is an alarm.
+func parseIPMI(r io.Reader) ([]types.SensorMetric, error) {
+	var metrics []types.SensorMetric
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return metrics, err
+		}
+		if len(record) < 4 {
+			continue
+		}
+		name := strings.TrimSpace(record[0])
+		typ, ok := ipmiUnits[strings.TrimSpace(record[2])]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			continue
+		}
+		status := strings.TrimSpace(record[3])
+		if status == "ns" {
+			continue
+		}
+		alarm := strings.HasSuffix(status, "cr") || strings.HasSuffix(status, "nr")
+		metrics = append(metrics, types.SensorMetric{
+			Name:   name,
+			Source: "ipmi",
+			Type:   typ,
+			Value:  value,
+			Alarm:  alarm,
+		})
+	}
+	return metrics, nil
+}
+
+func readString(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readFloat(filename string) (float64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sensors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	log := base.NewSourceLogObject(logrus.StandardLogger(), "sensors", 0)
+	dir, err := ioutil.TempDir("", "sensors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"class/hwmon/hwmon0/name":                       "acpitz",
+		"class/hwmon/hwmon0/temp1_input":                "27800",
+		"class/hwmon/hwmon0/temp1_crit":                 "105000",
+		"class/hwmon/hwmon1/name":                       "coretemp",
+		"class/hwmon/hwmon1/temp2_input":                "98000",
+		"class/hwmon/hwmon1/temp2_label":                "Core 0",
+		"class/hwmon/hwmon1/temp2_max":                  "84000",
+		"class/hwmon/hwmon1/temp2_crit":                 "100000",
+		"class/hwmon/hwmon1/temp2_crit_alarm":           "1",
+		"class/hwmon/hwmon2/device/name":                "nct6775",
+		"class/hwmon/hwmon2/device/fan1_input":          "0",
+		"class/hwmon/hwmon2/device/fan1_min":            "300",
+		"class/hwmon/hwmon2/device/fan1_alarm":          "1",
+		"class/hwmon/hwmon2/device/in0_input":           "1104",
+		"class/hwmon/hwmon2/device/power1_average":      "12500000",
+		"class/thermal/thermal_zone0/type":              "acpitz",
+		"class/thermal/thermal_zone0/temp":              "27800",
+		"class/thermal/thermal_zone1/type":              "soc_dts0",
+		"class/thermal/thermal_zone1/temp":              "45000",
+		"class/thermal/thermal_zone1/trip_point_0_type": "critical",
+		"class/thermal/thermal_zone1/trip_point_0_temp": "90000",
+		"class/thermal/thermal_zone1/trip_point_1_type": "hot",
+		"class/thermal/thermal_zone1/trip_point_1_temp": "85000",
+	})
+	sysRoot = dir
+
+	metrics := Read(log, false)
+	expected := []types.SensorMetric{
+		{Name: "acpitz.0/temp1", Source: "hwmon", Type: types.SensorTypeTemperature,
+			Value: 27.8, Critical: 105},
+		{Name: "coretemp.1/Core 0", Source: "hwmon", Type: types.SensorTypeTemperature,
+			Value: 98, Max: 84, Critical: 100, Alarm: true},
+		{Name: "nct6775.2/fan1", Source: "hwmon", Type: types.SensorTypeFan,
+			Min: 300, Alarm: true},
+		{Name: "nct6775.2/in0", Source: "hwmon", Type: types.SensorTypeVoltage,
+			Value: 1.104},
+		{Name: "nct6775.2/power1", Source: "hwmon", Type: types.SensorTypePower,
+			Value: 12.5},
+		{Name: "thermal_zone1/soc_dts0", Source: "thermal", Type: types.SensorTypeTemperature,
+			Value: 45, Max: 85, Critical: 90},
+	}
+	if len(metrics) != len(expected) {
+		t.Fatalf("got %d sensors, expected %d: %+v", len(metrics), len(expected), metrics)
+	}
+	for i := range expected {
+		if metrics[i] != expected[i] {
+			t.Errorf("got %+v, expected %+v", metrics[i], expected[i])
+		}
+	}
+	if !metrics[1].OverCritical() || metrics[0].OverCritical() {
+		t.Errorf("wrong OverCritical")
+	}
+}
+
+func TestParseIPMI(t *testing.T) {
+	out := `Inlet Temp,23,degrees C,ok
+CPU1 Temp,91,degrees C,cr
+FAN1,4200,RPM,ok
+FAN2,0,RPM,lcr
+12V,12.06,Volts,ok
+PS1 Input Power,180,Watts,ok
+PS2 Input Power,no reading,Watts,ns
+PS1 Status,0x01,discrete,ok
+`
+	metrics, err := parseIPMI(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []types.SensorMetric{
+		{Name: "Inlet Temp", Source: "ipmi", Type: types.SensorTypeTemperature, Value: 23},
+		{Name: "CPU1 Temp", Source: "ipmi", Type: types.SensorTypeTemperature, Value: 91, Alarm: true},
+		{Name: "FAN1", Source: "ipmi", Type: types.SensorTypeFan, Value: 4200},
+		{Name: "FAN2", Source: "ipmi", Type: types.SensorTypeFan, Alarm: true},
+		{Name: "12V", Source: "ipmi", Type: types.SensorTypeVoltage, Value: 12.06},
+		{Name: "PS1 Input Power", Source: "ipmi", Type: types.SensorTypePower, Value: 180},
+	}
+	if len(metrics) != len(expected) {
+		t.Fatalf("got %d sensors, expected %d: %+v", len(metrics), len(expected), metrics)
+	}
+	for i := range expected {
+		if metrics[i] != expected[i] {
+			t.Errorf("got %+v, expected %+v", metrics[i], expected[i])
+		}
+	}
+	if key := expected[0].Key(); key != "ipmi-Inlet_Temp" {
+		t.Errorf("got key %s", key)
+	}
+}
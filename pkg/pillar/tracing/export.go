@@ -0,0 +1,257 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Export of the ended spans of all the agents of the process. The spans
+// are batched every exportInterval, or sooner when exportBatch are queued,
+// into an OTLP ExportTraceServiceRequest in JSON which is appended as a
+// line to spansFilename if types.TraceFile is set, and posted to the
+// OTLP/HTTP collector in types.TraceURL if any. The spans are dropped
+// when neither is set.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+const (
+	spansFilename  = types.PersistDir + "/traces/spans.json"
+	maxFileSize    = 10 * 1024 * 1024 // rotated to spansFilename.1 beyond
+	exportInterval = 5 * time.Second
+	exportBatch    = 100
+	maxQueued      = 2000 // spans dropped beyond while the export is behind
+	postTimeout    = 10 * time.Second
+)
+
+type exporter struct {
+	lock     sync.Mutex
+	log      *base.LogObject
+	filename string // empty unless types.TraceFile
+	url      string
+	queued   []*Span
+	dropped  int
+	running  bool
+	kick     chan struct{}
+}
+
+var exp = exporter{kick: make(chan struct{}, 1)}
+
+// UpdateConfig picks where the spans are exported from the global config
+func (t *Tracer) UpdateConfig(gcp *types.ConfigItemValueMap) {
+	exp.lock.Lock()
+	defer exp.lock.Unlock()
+	exp.log = t.log
+	exp.filename = ""
+	if gcp.GlobalValueBool(types.TraceFile) {
+		exp.filename = spansFilename
+	}
+	exp.url = gcp.GlobalValueString(types.TraceURL)
+}
+
+func (e *exporter) add(span *Span) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.filename == "" && e.url == "" {
+		return
+	}
+	if len(e.queued) >= maxQueued {
+		e.dropped++
+		return
+	}
+	e.queued = append(e.queued, span)
+	if !e.running {
+		e.running = true
+		go e.run()
+	}
+	if len(e.queued) >= exportBatch {
+		select {
+		case e.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) run() {
+	ticker := time.NewTicker(exportInterval)
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.kick:
+		}
+		e.flush()
+	}
+}
+
+func (e *exporter) flush() {
+	e.lock.Lock()
+	spans := e.queued
+	dropped := e.dropped
+	e.queued = nil
+	e.dropped = 0
+	log, filename, url := e.log, e.filename, e.url
+	e.lock.Unlock()
+
+	if dropped != 0 {
+		log.Warnf("tracing: dropped %d spans", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+	data, err := json.Marshal(newExportRequest(spans))
+	if err != nil {
+		log.Errorf("tracing: %v", err)
+		return
+	}
+	if filename != "" {
+		if err := appendSpansFile(filename, data); err != nil {
+			log.Errorf("tracing: %v", err)
+		}
+	}
+	if url != "" {
+		if err := postSpans(url, data); err != nil {
+			log.Warnf("tracing: dropped %d spans: %v", len(spans), err)
+		}
+	}
+}
+
+func appendSpansFile(filename string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(filename); err == nil && info.Size() >= maxFileSize {
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func postSpans(url string, data []byte) error {
+	client := http.Client{Timeout: postTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of opentelemetry/proto/collector/trace/v1
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            spanStatus `json:"status"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type spanStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+// newExportRequest puts the spans of each agent in a resource with the
+// agent as service.name
+func newExportRequest(spans []*Span) exportRequest {
+	var req exportRequest
+	index := make(map[string]int)
+	for _, span := range spans {
+		i, ok := index[span.service]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[span.service] = i
+			req.ResourceSpans = append(req.ResourceSpans, resourceSpans{
+				Resource: resource{Attributes: []keyValue{
+					{Key: "service.namespace", Value: anyValue{StringValue: "eve"}},
+					{Key: "service.name", Value: anyValue{StringValue: span.service}},
+				}},
+				ScopeSpans: []scopeSpans{{Scope: scope{Name: "eve/pillar"}}},
+			})
+		}
+		ss := &req.ResourceSpans[i].ScopeSpans[0]
+		ss.Spans = append(ss.Spans, span.otlp())
+	}
+	return req
+}
+
+func (span *Span) otlp() otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.trace.TraceID,
+		SpanID:            span.spanID,
+		ParentSpanID:      span.parentSpanID,
+		Name:              span.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+	}
+	for i := 0; i+1 < len(span.attrs); i += 2 {
+		s.Attributes = append(s.Attributes, keyValue{
+			Key:   span.attrs[i],
+			Value: anyValue{StringValue: span.attrs[i+1]},
+		})
+	}
+	if span.errStr != "" {
+		s.Status = spanStatus{Code: statusCodeError, Message: span.errStr}
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Spans of the operations which go through several agents, such as the
+// deployment of an app instance. The operation gets a types.TraceContext
+// which the agents pass along in their Config and Status objects. The
+// agent which owns the operation starts and ends its root span, with the
+// SpanID of the trace, and the others a child span for each stage they
+// run e.g., download or verify.
+// Usage:
+//  tracer := tracing.NewTracer(log, agentName)
+//  tracer.UpdateConfig(gcp) // in the global config handler
+//  span := tracer.Start(config.Trace, "verify", "sha256", config.ImageSha256)
+//  ...
+//  span.End(status.Error)
+// The ended spans are exported as OTLP JSON, see export.go.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+)
+
+// Span is a timed stage of a trace
+type Span struct {
+	service      string
+	name         string
+	trace        types.TraceContext
+	spanID       string
+	parentSpanID string
+	start        time.Time
+	end          time.Time
+	attrs        []string // key and value pairs
+	errStr       string
+}
+
+// Tracer starts the spans of an agent
+type Tracer struct {
+	log     *base.LogObject
+	service string
+	lock    sync.Mutex
+	roots   map[string]*Span
+}
+
+// NewTracer returns the tracer of the agent
+func NewTracer(log *base.LogObject, agentName string) *Tracer {
+	return &Tracer{
+		log:     log,
+		service: agentName,
+		roots:   make(map[string]*Span),
+	}
+}
+
+func newSpanID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Start starts a span of the trace under its root span. The attributes
+// are key and value pairs. Returns nil, which can be ended, if the trace
+// is not set.
+func (t *Tracer) Start(trace types.TraceContext, name string, attrs ...string) *Span {
+	if !trace.IsValid() {
+		return nil
+	}
+	return &Span{
+		service:      t.service,
+		name:         name,
+		trace:        trace,
+		spanID:       newSpanID(),
+		parentSpanID: trace.SpanID,
+		start:        time.Now(),
+		attrs:        attrs,
+	}
+}
+
+// StartRoot starts the root span of the trace, kept under the key until
+// EndRoot. A root span of another trace started for the key is ended as
+// superseded.
+func (t *Tracer) StartRoot(trace types.TraceContext, name string, key string,
+	attrs ...string) {
+
+	if !trace.IsValid() {
+		return
+	}
+	t.lock.Lock()
+	old := t.roots[key]
+	if old != nil && old.trace == trace {
+		t.lock.Unlock()
+		return
+	}
+	t.roots[key] = &Span{
+		service: t.service,
+		name:    name,
+		trace:   trace,
+		spanID:  trace.SpanID,
+		start:   time.Now(),
+		attrs:   attrs,
+	}
+	t.lock.Unlock()
+	if old != nil {
+		old.End("superseded by trace " + trace.TraceID)
+	}
+	t.log.Functionf("StartRoot %s %s trace %s", name, key, trace.TraceID)
+}
+
+// EndRoot ends the root span of the key if any; a non-empty errStr marks
+// it failed
+func (t *Tracer) EndRoot(key string, errStr string) {
+	t.lock.Lock()
+	span := t.roots[key]
+	delete(t.roots, key)
+	t.lock.Unlock()
+	if span == nil {
+		return
+	}
+	span.End(errStr)
+	t.log.Functionf("EndRoot %s %s trace %s after %v error %s",
+		span.name, key, span.trace.TraceID, span.end.Sub(span.start), errStr)
+}
+
+// End ends the span and queues it for export; a non-empty errStr marks it
+// failed
+func (span *Span) End(errStr string) {
+	if span == nil || !span.end.IsZero() {
+		return
+	}
+	span.end = time.Now()
+	span.errStr = errStr
+	exp.add(span)
+}
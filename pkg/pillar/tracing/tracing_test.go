@@ -0,0 +1,87 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	"github.com/lf-edge/eve/pkg/pillar/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExport(t *testing.T) {
+	log := base.NewSourceLogObject(logrus.StandardLogger(), "tracing", 0)
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "spans.json")
+	exp.filename = filename
+	exp.log = log
+
+	trace := types.NewTraceContext()
+	zedmanager := NewTracer(log, "zedmanager")
+	downloader := NewTracer(log, "downloader")
+	zedmanager.StartRoot(trace, "deploy", "app1", "app", "app1")
+	span := downloader.Start(trace, "download", "sha256", "abc")
+	span.End("")
+	span.End("ended twice")
+	downloader.Start(types.TraceContext{}, "download").End("")
+	span = downloader.Start(trace, "download", "sha256", "def")
+	span.End("no route to host")
+	zedmanager.EndRoot("app1", "")
+	zedmanager.EndRoot("app1", "ended twice")
+	exp.flush()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var lines int
+	var req exportRequest
+	for scanner.Scan() {
+		lines++
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lines != 1 {
+		t.Fatalf("got %d lines, expected 1", lines)
+	}
+	if len(req.ResourceSpans) != 2 {
+		t.Fatalf("got %d resources, expected 2", len(req.ResourceSpans))
+	}
+	downloads := req.ResourceSpans[0]
+	if downloads.Resource.Attributes[1].Value.StringValue != "downloader" {
+		t.Errorf("got resource %v", downloads.Resource)
+	}
+	spans := downloads.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d download spans, expected 2", len(spans))
+	}
+	if spans[0].TraceID != trace.TraceID || spans[0].ParentSpanID != trace.SpanID ||
+		spans[0].SpanID == trace.SpanID || spans[0].Status.Code != 0 ||
+		spans[0].Attributes[0].Value.StringValue != "abc" {
+		t.Errorf("got span %+v", spans[0])
+	}
+	if spans[1].Status.Code != statusCodeError ||
+		spans[1].Status.Message != "no route to host" {
+		t.Errorf("got span status %+v", spans[1].Status)
+	}
+	root := req.ResourceSpans[1].ScopeSpans[0].Spans
+	if len(root) != 1 || root[0].SpanID != trace.SpanID ||
+		root[0].ParentSpanID != "" || root[0].Name != "deploy" ||
+		root[0].Status.Code != 0 {
+		t.Errorf("got root spans %+v", root)
+	}
+}
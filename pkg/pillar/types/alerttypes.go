@@ -0,0 +1,189 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+)
+
+// AlertSeverity of an alert rule
+type AlertSeverity uint8
+
+// The severities match the AlertSeverity of the API
+const (
+	AlertSeverityUnspecified AlertSeverity = iota
+	AlertSeverityWarning
+	AlertSeverityCritical
+)
+
+func (severity AlertSeverity) String() string {
+	switch severity {
+	case AlertSeverityWarning:
+		return "warning"
+	case AlertSeverityCritical:
+		return "critical"
+	default:
+		return "unspecified"
+	}
+}
+
+// MarshalText makes the severity a string in JSON
+func (severity AlertSeverity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// UnmarshalText parses the severity strings of MarshalText
+func (severity *AlertSeverity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "warning":
+		*severity = AlertSeverityWarning
+	case "critical":
+		*severity = AlertSeverityCritical
+	default:
+		return fmt.Errorf("unknown alert severity %s", text)
+	}
+	return nil
+}
+
+// AlertCondition is what an alert rule checks
+type AlertCondition string
+
+const (
+	// AlertConditionDiskUsage - percent used of a filesystem over the threshold
+	AlertConditionDiskUsage AlertCondition = "disk_usage"
+	// AlertConditionMemoryUsage - percent of the device memory used over the threshold
+	AlertConditionMemoryUsage AlertCondition = "memory_usage"
+	// AlertConditionSmartPreFailure - a SMART pre-failure attribute at or below its
+	// threshold, or failed
+	AlertConditionSmartPreFailure AlertCondition = "smart_prefailure"
+	// AlertConditionAppNotRunning - an activated app instance not running
+	AlertConditionAppNotRunning AlertCondition = "app_not_running"
+	// AlertConditionPortDown - a management port without link or usable address
+	AlertConditionPortDown AlertCondition = "port_down"
+	// AlertConditionCertExpiry - days until the device certificate expires under
+	// the threshold
+	AlertConditionCertExpiry AlertCondition = "cert_expiry"
+	// AlertConditionTemperature - a temperature sensor over the threshold in
+	// degrees Celsius
+	AlertConditionTemperature AlertCondition = "temperature"
+	// AlertConditionSensorCritical - a hardware sensor past its critical limit,
+	// or flagged by the hardware
+	AlertConditionSensorCritical AlertCondition = "sensor_critical"
+	// AlertConditionDiskFailing - the health of a disk is failing
+	AlertConditionDiskFailing AlertCondition = "disk_failing"
+)
+
+// AlertRule is a condition checked on the device, either built from the
+// alert.* global config items or read from AlertRulesFilename
+type AlertRule struct {
+	Name      string         `json:"name"`
+	Condition AlertCondition `json:"condition"`
+	Threshold float64        `json:"threshold"`
+	// Seconds the condition has to hold before the alert fires
+	For      uint32        `json:"for"`
+	Severity AlertSeverity `json:"severity"`
+}
+
+// Validate checks the rule can be evaluated
+func (rule AlertRule) Validate() error {
+	if rule.Name == "" {
+		return fmt.Errorf("alert rule without name")
+	}
+	switch rule.Condition {
+	case AlertConditionDiskUsage, AlertConditionMemoryUsage:
+		if rule.Threshold <= 0 || rule.Threshold > 100 {
+			return fmt.Errorf("alert rule %s: threshold %v not a percentage",
+				rule.Name, rule.Threshold)
+		}
+	case AlertConditionCertExpiry:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("alert rule %s: threshold %v not a number of days",
+				rule.Name, rule.Threshold)
+		}
+	case AlertConditionTemperature:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("alert rule %s: threshold %v not a temperature",
+				rule.Name, rule.Threshold)
+		}
+	case AlertConditionSmartPreFailure, AlertConditionAppNotRunning, AlertConditionPortDown,
+		AlertConditionSensorCritical, AlertConditionDiskFailing:
+	default:
+		return fmt.Errorf("alert rule %s: unknown condition %s",
+			rule.Name, rule.Condition)
+	}
+	if rule.Severity == AlertSeverityUnspecified {
+		return fmt.Errorf("alert rule %s: no severity", rule.Name)
+	}
+	return nil
+}
+
+// AlertStatus is published by zedagent for each firing alert, that is each
+// rule and subject for which the condition has held for the time of the
+// rule. It is unpublished when the condition no longer holds.
+type AlertStatus struct {
+	Rule      string
+	Condition AlertCondition
+	// Subject is what the alert is about e.g., a mount path, a SMART
+	// attribute, an app instance UUID, a port or a sensor. Empty for the
+	// device.
+	Subject   string
+	Severity  AlertSeverity
+	Message   string
+	Value     float64
+	Threshold float64
+	Since     time.Time // when the condition started to hold
+}
+
+// Key is the rule and the subject
+func (status AlertStatus) Key() string {
+	if status.Subject == "" {
+		return status.Rule
+	}
+	return status.Rule + "-" + strings.ReplaceAll(status.Subject, "/", "_")
+}
+
+// LogCreate :
+func (status AlertStatus) LogCreate(logBase *base.LogObject) {
+	logObject := base.NewLogObject(logBase, base.AlertStatusLogType, "",
+		nilUUID, status.LogKey())
+	if logObject == nil {
+		return
+	}
+	logObject.CloneAndAddField("severity", status.Severity.String()).
+		AddField("message", status.Message).
+		Noticef("Alert status create")
+}
+
+// LogModify :
+func (status AlertStatus) LogModify(logBase *base.LogObject, old interface{}) {
+	logObject := base.EnsureLogObject(logBase, base.AlertStatusLogType, "",
+		nilUUID, status.LogKey())
+
+	oldStatus, ok := old.(AlertStatus)
+	if !ok {
+		logObject.Clone().Fatalf("LogModify: Old object interface passed is not of AlertStatus type")
+	}
+	logObject.CloneAndAddField("diff", cmp.Diff(oldStatus, status)).
+		Functionf("Alert status modify")
+}
+
+// LogDelete :
+func (status AlertStatus) LogDelete(logBase *base.LogObject) {
+	logObject := base.EnsureLogObject(logBase, base.AlertStatusLogType, "",
+		nilUUID, status.LogKey())
+	logObject.CloneAndAddField("severity", status.Severity.String()).
+		Noticef("Alert status delete")
+
+	base.DeleteLogObject(logBase, status.LogKey())
+}
+
+// LogKey :
+func (status AlertStatus) LogKey() string {
+	return string(base.AlertStatusLogType) + "-" + status.Key()
+}
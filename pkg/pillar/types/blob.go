@@ -38,6 +38,8 @@ type BlobStatus struct {
 	RefCount    uint
 	TotalSize   int64 // expected size as reported by the downloader, if any
 	CurrentSize int64 // current total downloaded size as reported by the downloader
+	// Trace of the content tree the blob is downloaded and verified for
+	Trace TraceContext
 	// Progress percentage downloaded 0-100, defined by CurrentSize/TotalSize
 	Progress uint
 	// ErrorAndTimeWithSource provide common error handling capabilities
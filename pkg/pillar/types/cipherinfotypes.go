@@ -143,4 +143,6 @@ type EncryptionBlock struct {
 	WifiUserName      string // If the authentication type is EAP
 	WifiPassword      string
 	ProtectedUserData string
+	Dot1xPassword     string // 802.1X PEAP password
+	Dot1xPrivateKey   string // 802.1X EAP-TLS PEM private key
 }
@@ -23,6 +23,7 @@ type ContentTreeConfig struct {
 	MaxDownloadSize   uint64
 	GenerationCounter int64
 	DisplayName       string
+	Trace             TraceContext
 }
 
 // Key is content info UUID which will be unique
@@ -117,6 +118,7 @@ type ContentTreeStatus struct {
 	NameIsURL         bool
 	// Blobs the sha256 hashes of the blobs that are in this tree, the first of which always is the root
 	Blobs []string
+	Trace TraceContext
 
 	ErrorAndTimeWithSource
 }
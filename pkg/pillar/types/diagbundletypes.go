@@ -0,0 +1,161 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lf-edge/eve/pkg/pillar/base"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DiagBundleState of a diagnostic bundle
+type DiagBundleState uint8
+
+// The states match the DiagBundleState of the API
+const (
+	DiagBundleStateUnspecified DiagBundleState = iota
+	DiagBundleStateCollecting
+	DiagBundleStateUploading
+	DiagBundleStateUploaded
+	DiagBundleStateFailed
+)
+
+func (state DiagBundleState) String() string {
+	switch state {
+	case DiagBundleStateCollecting:
+		return "collecting"
+	case DiagBundleStateUploading:
+		return "uploading"
+	case DiagBundleStateUploaded:
+		return "uploaded"
+	case DiagBundleStateFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unspecified (%d)", state)
+	}
+}
+
+// DiagBundleConfig is published by zedagent once the diagnostic bundle
+// requested with the counter has been collected into Filename, for
+// downloader to upload it as Name under the path of the datastore
+type DiagBundleConfig struct {
+	Counter     uint32
+	DatastoreID uuid.UUID
+	Filename    string
+	Name        string
+}
+
+// Key is the counter
+func (config DiagBundleConfig) Key() string {
+	return strconv.FormatUint(uint64(config.Counter), 10)
+}
+
+// LogCreate :
+func (config DiagBundleConfig) LogCreate(logBase *base.LogObject) {
+	logObject := base.NewLogObject(logBase, base.DiagBundleConfigLogType, "",
+		nilUUID, config.LogKey())
+	if logObject == nil {
+		return
+	}
+	logObject.CloneAndAddField("datastore-id", config.DatastoreID.String()).
+		AddField("name", config.Name).
+		Noticef("Diag bundle config create")
+}
+
+// LogModify :
+func (config DiagBundleConfig) LogModify(logBase *base.LogObject, old interface{}) {
+	logObject := base.EnsureLogObject(logBase, base.DiagBundleConfigLogType, "",
+		nilUUID, config.LogKey())
+
+	oldConfig, ok := old.(DiagBundleConfig)
+	if !ok {
+		logObject.Clone().Fatalf("LogModify: Old object interface passed is not of DiagBundleConfig type")
+	}
+	logObject.CloneAndAddField("diff", cmp.Diff(oldConfig, config)).
+		Noticef("Diag bundle config modify")
+}
+
+// LogDelete :
+func (config DiagBundleConfig) LogDelete(logBase *base.LogObject) {
+	logObject := base.EnsureLogObject(logBase, base.DiagBundleConfigLogType, "",
+		nilUUID, config.LogKey())
+	logObject.CloneAndAddField("name", config.Name).
+		Noticef("Diag bundle config delete")
+
+	base.DeleteLogObject(logBase, config.LogKey())
+}
+
+// LogKey :
+func (config DiagBundleConfig) LogKey() string {
+	return string(base.DiagBundleConfigLogType) + "-" + config.Key()
+}
+
+// DiagBundleStatus is published by downloader for the upload of each
+// DiagBundleConfig
+type DiagBundleStatus struct {
+	Counter uint32
+	Name    string
+	State   DiagBundleState
+	Size    int64
+	// Time is when State last changed
+	Time time.Time
+	ErrorAndTime
+}
+
+// Key is the counter
+func (status DiagBundleStatus) Key() string {
+	return strconv.FormatUint(uint64(status.Counter), 10)
+}
+
+// LogCreate :
+func (status DiagBundleStatus) LogCreate(logBase *base.LogObject) {
+	logObject := base.NewLogObject(logBase, base.DiagBundleStatusLogType, "",
+		nilUUID, status.LogKey())
+	if logObject == nil {
+		return
+	}
+	logObject.CloneAndAddField("state", status.State.String()).
+		AddField("name", status.Name).
+		Noticef("Diag bundle status create")
+}
+
+// LogModify :
+func (status DiagBundleStatus) LogModify(logBase *base.LogObject, old interface{}) {
+	logObject := base.EnsureLogObject(logBase, base.DiagBundleStatusLogType, "",
+		nilUUID, status.LogKey())
+
+	oldStatus, ok := old.(DiagBundleStatus)
+	if !ok {
+		logObject.Clone().Fatalf("LogModify: Old object interface passed is not of DiagBundleStatus type")
+	}
+	if oldStatus.State != status.State || oldStatus.Error != status.Error {
+		logObject.CloneAndAddField("state", status.State.String()).
+			AddField("old-state", oldStatus.State.String()).
+			AddField("size-int64", status.Size).
+			AddField("error", status.Error).
+			Noticef("Diag bundle status modify")
+	} else {
+		logObject.CloneAndAddField("diff", cmp.Diff(oldStatus, status)).
+			Functionf("Diag bundle status modify other change")
+	}
+}
+
+// LogDelete :
+func (status DiagBundleStatus) LogDelete(logBase *base.LogObject) {
+	logObject := base.EnsureLogObject(logBase, base.DiagBundleStatusLogType, "",
+		nilUUID, status.LogKey())
+	logObject.CloneAndAddField("state", status.State.String()).
+		Noticef("Diag bundle status delete")
+
+	base.DeleteLogObject(logBase, status.LogKey())
+}
+
+// LogKey :
+func (status DiagBundleStatus) LogKey() string {
+	return string(base.DiagBundleStatusLogType) + "-" + status.Key()
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+)
+
+// DiskHealthState is the health of a disk
+type DiskHealthState uint8
+
+// The states match the DiskHealthState of the API
+const (
+	DiskHealthUnknown DiskHealthState = iota
+	DiskHealthGood
+	DiskHealthWarning // Errors are growing, or the disk is worn
+	DiskHealthFailing // The disk reports that it is failing
+)
+
+func (state DiskHealthState) String() string {
+	switch state {
+	case DiskHealthGood:
+		return "good"
+	case DiskHealthWarning:
+		return "warning"
+	case DiskHealthFailing:
+		return "failing"
+	default:
+		return fmt.Sprintf("unknown (%d)", state)
+	}
+}
+
+// DiskHealthCounter is an error or wear counter of a disk, with its growth
+// over the samples kept in DiskHealthDir
+type DiskHealthCounter struct {
+	Name      string // ATA attribute name or NVMe health log field
+	Value     uint64
+	DeltaDay  uint64
+	DeltaWeek uint64
+}
+
+// DiskSmartAttribute is an ATA SMART attribute with its threshold
+type DiskSmartAttribute struct {
+	ID         uint8
+	Name       string
+	Value      uint8 // Normalized value
+	Worst      uint8
+	Threshold  uint8
+	Raw        uint64
+	PreFailure bool
+}
+
+// DiskHealthStatus is published by volumemgr for each disk which reports
+// its ATA SMART attributes or its NVMe SMART/health log
+type DiskHealthStatus struct {
+	DiskName string // E.g., "sda" or "nvme0n1"
+	Model    string
+	Serial   string
+	Protocol string // "ATA" or "NVMe"
+	// Persist is set when the disk holds /persist, hence the volumes
+	Persist bool
+	State   DiskHealthState
+	Reasons []string // Why the state is not good
+
+	Temperature  uint32 // Degrees Celsius; zero if unknown
+	PowerOnHours uint64
+	Attributes   []DiskSmartAttribute // ATA only
+	// From the NVMe health log
+	PercentageUsed  uint8
+	AvailableSpare  uint8
+	SpareThreshold  uint8
+	CriticalWarning uint8
+
+	Counters   []DiskHealthCounter
+	LastUpdate time.Time
+}
+
+// Key is the name of the disk
+func (status DiskHealthStatus) Key() string {
+	return status.DiskName
+}
+
+// LogCreate :
+func (status DiskHealthStatus) LogCreate(logBase *base.LogObject) {
+	logObject := base.NewLogObject(logBase, base.DiskHealthStatusLogType, status.DiskName,
+		nilUUID, status.LogKey())
+	if logObject == nil {
+		return
+	}
+	logObject.CloneAndAddField("model", status.Model).
+		AddField("serial", status.Serial).
+		AddField("state", status.State.String()).
+		AddField("reasons", status.Reasons).
+		Noticef("Disk health status create")
+}
+
+// LogModify :
+func (status DiskHealthStatus) LogModify(logBase *base.LogObject, old interface{}) {
+	logObject := base.EnsureLogObject(logBase, base.DiskHealthStatusLogType, status.DiskName,
+		nilUUID, status.LogKey())
+
+	oldStatus, ok := old.(DiskHealthStatus)
+	if !ok {
+		logObject.Clone().Fatalf("LogModify: Old object interface passed is not of DiskHealthStatus type")
+	}
+	if oldStatus.State != status.State {
+		logObject.CloneAndAddField("state", status.State.String()).
+			AddField("old-state", oldStatus.State.String()).
+			AddField("reasons", status.Reasons).
+			Noticef("Disk health status state change")
+	} else {
+		logObject.CloneAndAddField("state", status.State.String()).
+			Metricf("Disk health status modify")
+	}
+}
+
+// LogDelete :
+func (status DiskHealthStatus) LogDelete(logBase *base.LogObject) {
+	logObject := base.EnsureLogObject(logBase, base.DiskHealthStatusLogType, status.DiskName,
+		nilUUID, status.LogKey())
+	logObject.CloneAndAddField("state", status.State.String()).
+		Noticef("Disk health status delete")
+
+	base.DeleteLogObject(logBase, status.LogKey())
+}
+
+// LogKey :
+func (status DiskHealthStatus) LogKey() string {
+	return string(base.DiskHealthStatusLogType) + "-" + status.Key()
+}
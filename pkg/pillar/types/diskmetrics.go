@@ -39,6 +39,7 @@ var AppPersistPaths = []string{
 	VolumeClearDirName,
 	SealedDirName + "/downloader",
 	SealedDirName + "/verifier",
+	VTpmDirName,
 }
 
 //DiskMetric holds metrics data per disk
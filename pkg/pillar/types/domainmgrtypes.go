@@ -39,6 +39,17 @@ type DomainConfig struct {
 
 	// CipherBlockStatus, for encrypted cloud-init data
 	CipherBlockStatus
+
+	CloudInitVendorData string         // base64-encoded
+	CloudInitNoCloud    bool           // Hostname and network-config
+	GuestNetworks       []GuestNetwork // For the cloud-init network-config
+
+	Probes        []AppProbe
+	RestartPolicy AppRestartPolicy
+	DependsOn     []uuid.UUID // So nodeagent can wait in order
+	UsbDevices    []UsbDeviceRule
+	Containers    []ContainerConfig // In addition to the main container
+	Trace         TraceContext      // Of the deployment of the app instance
 }
 
 // GetOCIConfigDir returns a location for OCI Config
@@ -63,6 +74,25 @@ func (config DomainConfig) GetTaskName() string {
 		strconv.Itoa(config.AppNum)
 }
 
+// podSeparator is not used in the task names of the domains
+const podSeparator = "_"
+
+// PodContainerName returns the task name of an additional container of
+// the domain
+func PodContainerName(domainName string, container string) string {
+	return domainName + podSeparator + container
+}
+
+// PodDomainName does the reverse of PodContainerName. Returns the task
+// name and an empty container name for the main task of a domain.
+func PodDomainName(taskName string) (string, string) {
+	res := strings.SplitN(taskName, podSeparator, 2)
+	if len(res) == 2 {
+		return res[0], res[1]
+	}
+	return taskName, ""
+}
+
 // DomainnameToUUID does the reverse of GetTaskName
 func DomainnameToUUID(name string) (uuid.UUID, error) {
 	// FIXME: we can likely drop this altogether
@@ -159,6 +189,8 @@ type VmConfig struct {
 	BootLoader string // default ""
 	// For CPU pinning
 	CPUs string // default "", list of "1,2"
+	// Dedicated physical cores allocated by domainmgr; overrides CPUs
+	ExclusiveCPUs bool
 	// Needed for device passthru
 	DeviceTree string // default ""; sets device_tree
 	// Example: device_tree="guest-gpio.dtb"
@@ -172,6 +204,163 @@ type VmConfig struct {
 	EnableVnc          bool
 	VncDisplay         uint32
 	VncPasswd          string
+	Hugepages          HugepageSize // Page size backing the guest memory
+	VTpm               VTpmMode     // Virtual TPM of the VM
+}
+
+// VTpmMode selects whether a VM has a virtual TPM and how its state is
+// protected
+type VTpmMode uint8
+
+// The values match the VtpmMode enum in the API
+const (
+	VTpmNone   VTpmMode = iota // No virtual TPM
+	VTpmVault                  // State encrypted with a key in the vault
+	VTpmSealed                 // Key additionally sealed by the device TPM
+)
+
+// String returns a human readable name of the mode
+func (mode VTpmMode) String() string {
+	switch mode {
+	case VTpmNone:
+		return "none"
+	case VTpmVault:
+		return "vault"
+	case VTpmSealed:
+		return "sealed"
+	default:
+		return fmt.Sprintf("Unknown VTpmMode %d", mode)
+	}
+}
+
+// HugepageSize is the page size used to back the memory of a VM
+type HugepageSize uint8
+
+// The values match the HugepageSize enum in the API
+const (
+	HugepageNone HugepageSize = iota // Regular pages
+	Hugepage2M
+	Hugepage1G
+)
+
+// Bytes returns the page size, or zero for HugepageNone
+func (size HugepageSize) Bytes() uint64 {
+	switch size {
+	case Hugepage2M:
+		return 2 << 20
+	case Hugepage1G:
+		return 1 << 30
+	default:
+		return 0
+	}
+}
+
+// String returns the name used for the size in the kernel command line
+func (size HugepageSize) String() string {
+	switch size {
+	case HugepageNone:
+		return "none"
+	case Hugepage2M:
+		return "2M"
+	case Hugepage1G:
+		return "1G"
+	default:
+		return fmt.Sprintf("Unknown HugepageSize %d", size)
+	}
+}
+
+// RoundUpMemory returns the memory in bytes needed for memoryKB when backed
+// by pages of this size
+func (size HugepageSize) RoundUpMemory(memoryKB int) uint64 {
+	memory := uint64(memoryKB) << 10
+	pageSize := size.Bytes()
+	if pageSize == 0 {
+		return memory
+	}
+	return (memory + pageSize - 1) / pageSize * pageSize
+}
+
+// UsbDeviceRule selects USB devices to pass through to an app instance.
+// The empty fields match any device.
+type UsbDeviceRule struct {
+	VendorID  string // Hexadecimal, e.g., "0781"
+	ProductID string
+	Serial    string
+	PortPath  string // Same format as IoBundle.UsbAddr, e.g., "1:2.3"
+}
+
+// UsbDevice is a USB device plugged into the device
+type UsbDevice struct {
+	PortPath  string
+	Busnum    uint32
+	Devnum    uint32
+	VendorID  string
+	ProductID string
+	Serial    string
+	Product   string
+}
+
+// Match returns true if the device matches the rule. A rule without any
+// field matches nothing.
+func (rule UsbDeviceRule) Match(dev UsbDevice) bool {
+	if rule == (UsbDeviceRule{}) {
+		return false
+	}
+	return (rule.VendorID == "" || strings.EqualFold(rule.VendorID, dev.VendorID)) &&
+		(rule.ProductID == "" || strings.EqualFold(rule.ProductID, dev.ProductID)) &&
+		(rule.Serial == "" || rule.Serial == dev.Serial) &&
+		(rule.PortPath == "" || rule.PortPath == dev.PortPath)
+}
+
+// GuestInfo is reported by the QEMU guest agent running in the domain
+type GuestInfo struct {
+	OSName        string
+	OSVersion     string
+	KernelRelease string
+	Hostname      string
+	Interfaces    []GuestInterface
+}
+
+// GuestInterface is a network interface in the domain
+type GuestInterface struct {
+	Name    string
+	MacAddr string
+	IPAddrs []string
+}
+
+// GuestFilesystem is a mounted filesystem in the domain
+type GuestFilesystem struct {
+	Mountpoint string
+	Type       string
+	TotalBytes uint64
+	UsedBytes  uint64
+}
+
+// ContainerConfig is an additional container of a domain which runs
+// containers, e.g., a sidecar. It shares the network and the volumes of
+// the main container.
+type ContainerConfig struct {
+	Name    string
+	Image   uuid.UUID // The CONTAINER volume with the image
+	Init    bool      // Runs to completion before the others start
+	Command []string  // Overrides the entrypoint and cmd of the image
+	Env     []string  // "NAME=value"
+}
+
+// ContainerStatus is the state of an additional container of a domain
+type ContainerStatus struct {
+	Name     string
+	Init     bool
+	State    SwState // RUNNING, HALTED after a zero exit status, or BROKEN
+	ExitCode int
+	Error    string
+}
+
+// UsbDeviceStatus is a plugged in USB device matching a rule of the domain
+type UsbDeviceStatus struct {
+	UsbDevice
+	Attached bool
+	Error    string // Why it is not attached
 }
 
 type VmMode uint8
@@ -221,6 +410,13 @@ type DomainStatus struct {
 	AdaptersFailed bool
 	OCIConfigDir   string            // folder holding an OCI Image config for this domain (empty string means no config)
 	EnvVariables   map[string]string // List of environment variables to be set in container
+	Health         AppHealthStatus
+	DependsOn      []uuid.UUID
+	CPUs           string // CPUs the domain is pinned to, e.g. "2,3"
+	ExclusiveCPUs  bool   // CPUs are not used by anything else
+	UsbDevices     []UsbDeviceStatus
+	GuestInfo      GuestInfo // Empty without the guest agent
+	Containers     []ContainerStatus
 }
 
 func (status DomainStatus) Key() string {
@@ -310,6 +506,14 @@ type VifInfo struct {
 	Mac     string
 }
 
+// GuestNetwork is a network adapter of the domain as described to the
+// guest in the cloud-init network-config
+type GuestNetwork struct {
+	Name string // Of the network adapter in the app instance config
+	Mac  string
+	GuestIPConfig
+}
+
 // DomainManager will pass these to the xen xl config file
 // The vdev is automatically assigned as xvd[x], where X is a, b, c etc,
 // based on the order in the DiskList
@@ -321,6 +525,7 @@ type DiskConfig struct {
 	Format       zconfig.Format
 	MountDir     string
 	DisplayName  string
+	Container    string // The additional container with this image if any
 }
 
 type DiskStatus struct {
@@ -329,6 +534,7 @@ type DiskStatus struct {
 	Format       zconfig.Format
 	MountDir     string
 	DisplayName  string
+	Container    string // From DiskConfig
 	Devtype      string // XXX used internally by hypervisor; deprecate?
 	Vdev         string // Allocated
 }
@@ -340,6 +546,7 @@ type DomainMetric struct {
 	UsedMemory        uint32
 	AvailableMemory   uint32
 	UsedMemoryPercent float64
+	GuestFilesystems  []GuestFilesystem // From the guest agent
 }
 
 // Key returns the key for pubsub
@@ -392,6 +599,9 @@ type HostMemory struct {
 	TotalMemoryMB uint64
 	FreeMemoryMB  uint64
 	Ncpus         uint32
+	// Memory reserved for hugepages, which is part of TotalMemoryMB
+	// but only usable by app instances backed by hugepages
+	HugepagesMB uint64
 }
 
 // Key returns the key for pubsub
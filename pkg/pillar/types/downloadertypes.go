@@ -22,6 +22,7 @@ type DownloaderConfig struct {
 	Size             uint64 // In bytes
 	FinalObjDir      string // final Object Store
 	RefCount         uint
+	Trace            TraceContext
 }
 
 func (config DownloaderConfig) Key() string {
@@ -6,10 +6,13 @@ package types
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 
+	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus" // OK for logrus.Fatal
 )
 
@@ -173,6 +176,33 @@ const (
 	VaultReadyCutOffTime GlobalSettingKey = "timer.vault.ready.cutoff"
 	// LogRemainToSendMBytes Max gzip log files remain on device to be sent in Mbytes
 	LogRemainToSendMBytes GlobalSettingKey = "newlog.gzipfiles.ondisk.maxmegabytes"
+	// LogSourceRateLimit global setting key; log entries per second of
+	// each device source, zero for no limit
+	LogSourceRateLimit GlobalSettingKey = "newlog.ratelimit.source"
+	// LogAppRateLimit global setting key; log entries per second of each
+	// app, zero for no limit
+	LogAppRateLimit GlobalSettingKey = "newlog.ratelimit.app"
+	// PrometheusPort global setting key; zero disables the endpoint
+	PrometheusPort GlobalSettingKey = "metrics.prometheus.port"
+	// MetricsHistoryResolution global setting key; seconds between the
+	// metrics kept on the device while the controller is not reachable,
+	// zero disables the history
+	MetricsHistoryResolution GlobalSettingKey = "metrics.history.resolution"
+	// MetricsHistoryRetention global setting key; seconds of metrics kept
+	MetricsHistoryRetention GlobalSettingKey = "metrics.history.retention"
+	// AlertDiskUsagePercent global setting key; zero disables the alert
+	AlertDiskUsagePercent GlobalSettingKey = "alert.disk.usage.percent"
+	// AlertMemoryUsagePercent global setting key; zero disables the alert
+	AlertMemoryUsagePercent GlobalSettingKey = "alert.memory.usage.percent"
+	// AlertCertExpiryDays global setting key; zero disables the alert
+	AlertCertExpiryDays GlobalSettingKey = "alert.cert.expiry.days"
+	// AlertHoldTime global setting key; seconds a condition has to hold
+	// before the alert fires
+	AlertHoldTime GlobalSettingKey = "alert.hold.time"
+	// AlertTemperatureCelsius global setting key; zero disables the alert
+	AlertTemperatureCelsius GlobalSettingKey = "alert.temperature.celsius"
+	// DiskHealthInterval global setting key
+	DiskHealthInterval GlobalSettingKey = "timer.metric.diskhealth.interval"
 
 	// ForceFallbackCounter global setting key
 	ForceFallbackCounter = "force.fallback.counter"
@@ -190,6 +220,26 @@ const (
 	IgnoreDiskCheckForApps GlobalSettingKey = "storage.apps.ignore.disk.check"
 	// AllowLogFastupload global setting key
 	AllowLogFastupload GlobalSettingKey = "newlog.allow.fastupload"
+	// LogDedup global setting key
+	LogDedup GlobalSettingKey = "newlog.dedup.enable"
+	// AlertSmartPreFailure global setting key
+	AlertSmartPreFailure GlobalSettingKey = "alert.smart.prefailure"
+	// AlertAppNotRunning global setting key
+	AlertAppNotRunning GlobalSettingKey = "alert.app.notrunning"
+	// AlertPortDown global setting key
+	AlertPortDown GlobalSettingKey = "alert.port.down"
+	// PrometheusTLS global setting key
+	PrometheusTLS GlobalSettingKey = "metrics.prometheus.tls"
+	// TraceFile global setting key
+	TraceFile GlobalSettingKey = "debug.trace.file"
+	// AlertSensorCritical global setting key
+	AlertSensorCritical GlobalSettingKey = "alert.sensor.critical"
+	// SensorsIPMI global setting key
+	SensorsIPMI GlobalSettingKey = "metrics.sensors.ipmi"
+	// AlertDiskFailing global setting key
+	AlertDiskFailing GlobalSettingKey = "alert.disk.failing"
+	// BlockFailingDiskForApps global setting key
+	BlockFailingDiskForApps GlobalSettingKey = "storage.apps.block.failing.disk"
 
 	// TriState Items
 	// NetworkFallbackAnyEth global setting key
@@ -206,6 +256,29 @@ const (
 	DefaultLogLevel GlobalSettingKey = "debug.default.loglevel"
 	// DefaultRemoteLogLevel global setting key
 	DefaultRemoteLogLevel GlobalSettingKey = "debug.default.remote.loglevel"
+	// PrometheusInterface global setting key
+	PrometheusInterface GlobalSettingKey = "metrics.prometheus.interface"
+	// PrometheusAllow global setting key
+	PrometheusAllow GlobalSettingKey = "metrics.prometheus.allow"
+	// AlertWebhookURL global setting key
+	AlertWebhookURL GlobalSettingKey = "alert.webhook.url"
+	// TraceURL global setting key
+	TraceURL GlobalSettingKey = "debug.trace.url"
+	// SyslogURL global setting key; tcp://, tls:// or udp://host:port
+	SyslogURL GlobalSettingKey = "newlog.syslog.url"
+	// SyslogLogLevel global setting key
+	SyslogLogLevel GlobalSettingKey = "newlog.syslog.loglevel"
+	// SyslogSources global setting key
+	SyslogSources GlobalSettingKey = "newlog.syslog.sources"
+	// LokiURL global setting key; http:// or https:// push URL
+	LokiURL GlobalSettingKey = "newlog.loki.url"
+	// LokiLogLevel global setting key
+	LokiLogLevel GlobalSettingKey = "newlog.loki.loglevel"
+	// LogAppRateLimitOverrides global setting key; comma separated
+	// <app UUID>:<entries per second> overriding LogAppRateLimit
+	LogAppRateLimitOverrides GlobalSettingKey = "newlog.ratelimit.app.overrides"
+	// LokiSources global setting key
+	LokiSources GlobalSettingKey = "newlog.loki.sources"
 )
 
 // AgentSettingKey - keys for per-agent settings
@@ -753,6 +826,18 @@ func NewConfigItemSpecMap() ConfigItemSpecMap {
 		eveMemoryLimitInBytes, 0xFFFFFFFF)
 	// LogRemainToSendMBytes - Default is 2 Gbytes, minimum is 10 Mbytes
 	configItemSpecMap.AddIntItem(LogRemainToSendMBytes, 2048, 10, 0xFFFFFFFF)
+	configItemSpecMap.AddIntItem(LogSourceRateLimit, 200, 0, 1000000)
+	configItemSpecMap.AddIntItem(LogAppRateLimit, 200, 0, 1000000)
+	configItemSpecMap.AddIntItem(PrometheusPort, 0, 0, 0xFFFF)
+	configItemSpecMap.AddIntItem(MetricsHistoryResolution, 60, 0, HourInSec)
+	configItemSpecMap.AddIntItem(MetricsHistoryRetention, 24*HourInSec,
+		HourInSec, 7*24*HourInSec)
+	configItemSpecMap.AddIntItem(AlertDiskUsagePercent, 90, 0, 100)
+	configItemSpecMap.AddIntItem(AlertMemoryUsagePercent, 95, 0, 100)
+	configItemSpecMap.AddIntItem(AlertCertExpiryDays, 30, 0, 3650)
+	configItemSpecMap.AddIntItem(AlertHoldTime, 300, 0, 24*HourInSec)
+	configItemSpecMap.AddIntItem(AlertTemperatureCelsius, 0, 0, 200)
+	configItemSpecMap.AddIntItem(DiskHealthInterval, HourInSec, 60, 24*HourInSec)
 
 	// Add Bool Items
 	configItemSpecMap.AddBoolItem(UsbAccess, true) // Controller likely default to false
@@ -760,6 +845,16 @@ func NewConfigItemSpecMap() ConfigItemSpecMap {
 	configItemSpecMap.AddBoolItem(IgnoreMemoryCheckForApps, false)
 	configItemSpecMap.AddBoolItem(IgnoreDiskCheckForApps, false)
 	configItemSpecMap.AddBoolItem(AllowLogFastupload, false)
+	configItemSpecMap.AddBoolItem(LogDedup, true)
+	configItemSpecMap.AddBoolItem(PrometheusTLS, true)
+	configItemSpecMap.AddBoolItem(AlertSmartPreFailure, true)
+	configItemSpecMap.AddBoolItem(AlertAppNotRunning, true)
+	configItemSpecMap.AddBoolItem(AlertPortDown, true)
+	configItemSpecMap.AddBoolItem(TraceFile, false)
+	configItemSpecMap.AddBoolItem(AlertSensorCritical, true)
+	configItemSpecMap.AddBoolItem(SensorsIPMI, false)
+	configItemSpecMap.AddBoolItem(AlertDiskFailing, true)
+	configItemSpecMap.AddBoolItem(BlockFailingDiskForApps, false)
 
 	// Add TriState Items
 	configItemSpecMap.AddTriStateItem(NetworkFallbackAnyEth, TS_ENABLED)
@@ -770,6 +865,17 @@ func NewConfigItemSpecMap() ConfigItemSpecMap {
 	configItemSpecMap.AddStringItem(SSHAuthorizedKeys, "", blankValidator)
 	configItemSpecMap.AddStringItem(DefaultLogLevel, "info", parseLevel)
 	configItemSpecMap.AddStringItem(DefaultRemoteLogLevel, "info", parseLevel)
+	configItemSpecMap.AddStringItem(PrometheusInterface, "", blankValidator)
+	configItemSpecMap.AddStringItem(PrometheusAllow, "", prefixListValidator)
+	configItemSpecMap.AddStringItem(AlertWebhookURL, "", webhookURLValidator)
+	configItemSpecMap.AddStringItem(TraceURL, "", traceURLValidator)
+	configItemSpecMap.AddStringItem(SyslogURL, "", syslogURLValidator)
+	configItemSpecMap.AddStringItem(SyslogLogLevel, "info", parseLevel)
+	configItemSpecMap.AddStringItem(SyslogSources, "", blankValidator)
+	configItemSpecMap.AddStringItem(LokiURL, "", lokiURLValidator)
+	configItemSpecMap.AddStringItem(LokiLogLevel, "info", parseLevel)
+	configItemSpecMap.AddStringItem(LokiSources, "", blankValidator)
+	configItemSpecMap.AddStringItem(LogAppRateLimitOverrides, "", rateLimitOverridesValidator)
 
 	// Add Agent Settings
 	configItemSpecMap.AddAgentSettingStringItem(LogLevel, "info", parseLevel)
@@ -789,6 +895,118 @@ func blankValidator(s string) error {
 	return nil
 }
 
+// urlValidator - A validator for an empty string or an URL with a host
+// and one of the schemes
+func urlValidator(s string, schemes ...string) error {
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("no host in %s", s)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("scheme %s not one of %v", u.Scheme, schemes)
+}
+
+// syslogURLValidator - A validator for the syslog server URL
+func syslogURLValidator(s string) error {
+	return urlValidator(s, "tcp", "tls", "udp")
+}
+
+// lokiURLValidator - A validator for the Loki push URL
+func lokiURLValidator(s string) error {
+	return urlValidator(s, "http", "https")
+}
+
+// webhookURLValidator - A validator for the alert webhook URL
+func webhookURLValidator(s string) error {
+	return urlValidator(s, "http", "https")
+}
+
+// traceURLValidator - A validator for the URL of the OTLP/HTTP traces collector
+func traceURLValidator(s string) error {
+	return urlValidator(s, "http", "https")
+}
+
+// prefixListValidator - A validator for a list of IP prefixes
+func prefixListValidator(s string) error {
+	_, err := ParsePrefixList(s)
+	return err
+}
+
+// ParsePrefixList parses a comma separated list of IP prefixes, e.g.,
+// "10.0.0.0/8, 2001:db8::/32". An address without a length is a host.
+func ParsePrefixList(s string) ([]*net.IPNet, error) {
+	var prefixes []*net.IPNet
+	for _, str := range strings.Split(s, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		if !strings.Contains(str, "/") {
+			ip := net.ParseIP(str)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %s", str)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			prefixes = append(prefixes,
+				&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, prefix, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// rateLimitOverridesValidator - A validator for LogAppRateLimitOverrides
+func rateLimitOverridesValidator(s string) error {
+	_, err := ParseRateLimitOverrides(s)
+	return err
+}
+
+// ParseRateLimitOverrides parses a comma separated list of app UUIDs with
+// their rate limit, e.g., "6ba7b810-9dad-11d1-80b4-00c04fd430c8:1000".
+// The map is keyed by the app UUID in its canonical form.
+func ParseRateLimitOverrides(s string) (map[string]uint32, error) {
+	overrides := make(map[string]uint32)
+	for _, str := range strings.Split(s, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		fields := strings.Split(str, ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rate limit override %s", str)
+		}
+		appUUID, err := uuid.FromString(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid app UUID in %s: %v", str, err)
+		}
+		rate, err := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit in %s: %v", str, err)
+		}
+		overrides[appUUID.String()] = uint32(rate)
+	}
+	return overrides, nil
+}
+
 // NewConfigItemValueMap - Create new instance of ConfigItemValueMap
 func NewConfigItemValueMap() *ConfigItemValueMap {
 	var valueMap ConfigItemValueMap
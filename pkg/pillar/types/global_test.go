@@ -171,6 +171,17 @@ func TestNewConfigItemSpecMap(t *testing.T) {
 		Dom0DiskUsageMaxBytes,
 		ForceFallbackCounter,
 		LogRemainToSendMBytes,
+		LogSourceRateLimit,
+		LogAppRateLimit,
+		PrometheusPort,
+		MetricsHistoryResolution,
+		MetricsHistoryRetention,
+		AlertDiskUsagePercent,
+		AlertMemoryUsagePercent,
+		AlertCertExpiryDays,
+		AlertHoldTime,
+		AlertTemperatureCelsius,
+		DiskHealthInterval,
 		// Bool Items
 		UsbAccess,
 		AllowAppVnc,
@@ -178,6 +189,16 @@ func TestNewConfigItemSpecMap(t *testing.T) {
 		IgnoreMemoryCheckForApps,
 		IgnoreDiskCheckForApps,
 		AllowLogFastupload,
+		LogDedup,
+		PrometheusTLS,
+		AlertSmartPreFailure,
+		AlertAppNotRunning,
+		AlertPortDown,
+		TraceFile,
+		AlertSensorCritical,
+		SensorsIPMI,
+		AlertDiskFailing,
+		BlockFailingDiskForApps,
 		// TriState Items
 		NetworkFallbackAnyEth,
 		AllowNonFreeImages,
@@ -186,6 +207,17 @@ func TestNewConfigItemSpecMap(t *testing.T) {
 		SSHAuthorizedKeys,
 		DefaultLogLevel,
 		DefaultRemoteLogLevel,
+		PrometheusInterface,
+		PrometheusAllow,
+		AlertWebhookURL,
+		TraceURL,
+		SyslogURL,
+		SyslogLogLevel,
+		SyslogSources,
+		LokiURL,
+		LokiLogLevel,
+		LokiSources,
+		LogAppRateLimitOverrides,
 	}
 	if len(specMap.GlobalSettings) != len(gsKeys) {
 		t.Errorf("GlobalSettings has more (%d) than expected keys (%d)",
@@ -544,3 +576,21 @@ func TestSetGlobalValue(t *testing.T) {
 	assert.Equal(t, TS_DISABLED, valueMap.GlobalValueTriState(FallbackIfCloudGoneTime))
 	assert.Equal(t, "hola amigo", valueMap.GlobalValueString(SSHAuthorizedKeys))
 }
+
+func TestParseRateLimitOverrides(t *testing.T) {
+	overrides, err := ParseRateLimitOverrides(
+		"6BA7B810-9DAD-11D1-80B4-00C04FD430C8:1000, 6ba7b811-9dad-11d1-80b4-00c04fd430c8:0,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint32{
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8": 1000,
+		"6ba7b811-9dad-11d1-80b4-00c04fd430c8": 0,
+	}, overrides)
+	overrides, err = ParseRateLimitOverrides("")
+	assert.NoError(t, err)
+	assert.Empty(t, overrides)
+	for _, s := range []string{"app1:100", "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8:-1"} {
+		_, err := ParseRateLimitOverrides(s)
+		assert.Error(t, err, s)
+	}
+}
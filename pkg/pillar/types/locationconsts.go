@@ -19,12 +19,18 @@ const (
 	SealedDirName = PersistDir + "/vault"
 	// VolumeEncryptedDirName - sealed directory used to store volumes
 	VolumeEncryptedDirName = SealedDirName + "/volumes"
+	// VTpmDirName - sealed directory used to store the state of the vTPMs
+	VTpmDirName = SealedDirName + "/vtpm"
 	// ClearDirName - directory which is not encrypted
 	ClearDirName = PersistDir + "/clear"
 	// VolumeClearDirName - Not encrypted directory used to store volumes
 	VolumeClearDirName = ClearDirName + "/volumes"
 	// PersistDebugDir - Location for service specific debug/traces
 	PersistDebugDir = PersistDir + "/agentdebug"
+	// DiagBundleDir - Location of the collected diagnostic bundles
+	DiagBundleDir = PersistDir + "/diag"
+	// DiskHealthDir - Location of the samples of the disk health counters
+	DiskHealthDir = PersistStatusDir + "/diskhealth"
 
 	// IdentityDirname - Config dir
 	IdentityDirname = "/config"
@@ -40,6 +46,8 @@ const (
 	OnboardCertName = IdentityDirname + "/onboard.cert.pem"
 	// OnboardKeyName - onboard key
 	OnboardKeyName = IdentityDirname + "/onboard.key.pem"
+	// AlertRulesFilename - local alert rules signed by the controller
+	AlertRulesFilename = IdentityDirname + "/alert-rules.auth"
 	// RootCertFileName - what we trust for signatures and object encryption
 	RootCertFileName = IdentityDirname + "/root-certificate.pem"
 	// V2TLSCertShaFilename - find TLS root cert for API V2 based on this sha
@@ -44,6 +44,18 @@ type cloudDelay struct {
 	CurrUploadMsec uint32 // current upload to cloud delay in msec
 }
 
+// LogSinkMetrics - Metrics of forwarding the logs to a syslog server or Loki
+type LogSinkMetrics struct {
+	Enabled       bool      // sink is configured
+	NumSent       uint64    // total log entries sent
+	NumDropped    uint64    // total log entries dropped with the queue full
+	NumFailed     uint64    // total log entries failed to send
+	QueueLen      uint32    // current number of log entries queued
+	MaxQueueLen   uint32    // largest number of log entries queued
+	LastError     string    // last send error
+	LastErrorTime time.Time // time of the last send error
+}
+
 // NewlogMetrics - Metrics from newlogd and loguploader
 type NewlogMetrics struct {
 	// logupload signal to newlogd
@@ -62,6 +74,9 @@ type NewlogMetrics struct {
 	NumKmessages          uint64            // total input kmessages
 	NumSyslogMessages     uint64            // total input syslog message
 	DevTop10InputBytesPCT map[string]uint32 // top 10 sources device log input in percentage
+	NumDeduplicated       uint64            // total repeated log entries collapsed
+	NumRateLimited        uint64            // total log entries dropped by the rate limits
+	Top10RateLimited      map[string]uint64 // top 10 sources, app UUIDs for apps, of the dropped log entries
 
 	// upload latency
 	Latency cloudDelay
@@ -71,4 +86,8 @@ type NewlogMetrics struct {
 	// Dev and App file metrics
 	DevMetrics logfileMetrics // Device metrics
 	AppMetrics logfileMetrics // App metrics
+
+	// Log forwarding from newlogd
+	SyslogMetrics LogSinkMetrics
+	LokiMetrics   LogSinkMetrics
 }
@@ -20,6 +20,7 @@ type ResolveConfig struct {
 	Name             string
 	AllowNonFreePort bool
 	Counter          uint32
+	Trace            TraceContext
 }
 
 // Key : DatastoreID, name and sequence counter are used
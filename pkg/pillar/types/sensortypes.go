@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lf-edge/eve/pkg/pillar/base"
+)
+
+// SensorType is what a sensor measures
+type SensorType uint8
+
+// The types match the SensorType of the API
+const (
+	SensorTypeUnspecified SensorType = iota
+	SensorTypeTemperature            // degrees Celsius
+	SensorTypeFan                    // RPM
+	SensorTypeVoltage                // Volts
+	SensorTypeCurrent                // Amperes
+	SensorTypePower                  // Watts
+)
+
+func (typ SensorType) String() string {
+	switch typ {
+	case SensorTypeTemperature:
+		return "temperature"
+	case SensorTypeFan:
+		return "fan"
+	case SensorTypeVoltage:
+		return "voltage"
+	case SensorTypeCurrent:
+		return "current"
+	case SensorTypePower:
+		return "power"
+	default:
+		return fmt.Sprintf("unspecified (%d)", typ)
+	}
+}
+
+// Unit of the values of the type
+func (typ SensorType) Unit() string {
+	switch typ {
+	case SensorTypeTemperature:
+		return "C"
+	case SensorTypeFan:
+		return "RPM"
+	case SensorTypeVoltage:
+		return "V"
+	case SensorTypeCurrent:
+		return "A"
+	case SensorTypePower:
+		return "W"
+	default:
+		return ""
+	}
+}
+
+// SensorMetric is published by domainmgr for each hardware sensor read
+// from Linux hwmon, the thermal zones, or the SDR of the BMC through IPMI
+type SensorMetric struct {
+	// Name is the chip or zone and the sensor e.g., "coretemp.0/Core 0"
+	Name   string
+	Source string // "hwmon", "thermal" or "ipmi"
+	Type   SensorType
+	Value  float64
+	// The limits set in the hardware; zero if unknown
+	Min      float64
+	Max      float64
+	Critical float64
+	// Alarm is set when the hardware flags the value out of its limits
+	Alarm bool
+}
+
+// Key is the source and the name, without the characters which can't be
+// in a file name
+func (metric SensorMetric) Key() string {
+	key := metric.Source + "-" + metric.Name
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', ' ', ':':
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// OverCritical is true when the hardware flags the value, or it is over
+// its critical limit
+func (metric SensorMetric) OverCritical() bool {
+	if metric.Alarm {
+		return true
+	}
+	return metric.Critical != 0 && metric.Value >= metric.Critical
+}
+
+// LogCreate :
+func (metric SensorMetric) LogCreate(logBase *base.LogObject) {
+	logObject := base.NewLogObject(logBase, base.SensorMetricLogType, metric.Name,
+		nilUUID, metric.LogKey())
+	if logObject == nil {
+		return
+	}
+	logObject.CloneAndAddField("type", metric.Type.String()).
+		AddField("value", metric.Value).
+		AddField("critical", metric.Critical).
+		Metricf("Sensor metric create")
+}
+
+// LogModify :
+func (metric SensorMetric) LogModify(logBase *base.LogObject, old interface{}) {
+	logObject := base.EnsureLogObject(logBase, base.SensorMetricLogType, metric.Name,
+		nilUUID, metric.LogKey())
+
+	oldMetric, ok := old.(SensorMetric)
+	if !ok {
+		logObject.Clone().Fatalf("LogModify: Old object interface passed is not of SensorMetric type")
+	}
+	if oldMetric.Alarm != metric.Alarm {
+		logObject.CloneAndAddField("value", metric.Value).
+			AddField("old-value", oldMetric.Value).
+			AddField("alarm", metric.Alarm).
+			Noticef("Sensor metric alarm change")
+	} else {
+		logObject.CloneAndAddField("value", metric.Value).
+			AddField("old-value", oldMetric.Value).
+			Metricf("Sensor metric modify")
+	}
+}
+
+// LogDelete :
+func (metric SensorMetric) LogDelete(logBase *base.LogObject) {
+	logObject := base.EnsureLogObject(logBase, base.SensorMetricLogType, metric.Name,
+		nilUUID, metric.LogKey())
+	logObject.CloneAndAddField("value", metric.Value).
+		Metricf("Sensor metric delete")
+
+	base.DeleteLogObject(logBase, metric.LogKey())
+}
+
+// LogKey :
+func (metric SensorMetric) LogKey() string {
+	return string(base.SensorMetricLogType) + "-" + metric.Key()
+}
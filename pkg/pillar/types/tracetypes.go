@@ -0,0 +1,44 @@
+// Copyright (c) 2021 Zededa, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// TraceContext identifies an operation which spans several agents, such
+// as the deployment of an app instance. It is set by zedagent and copied
+// from the Config to the Status objects and into the Config objects
+// derived from them, so each agent can report the spans of its stage.
+// TraceID is 16 bytes and SpanID, the span of the whole operation, is 8
+// bytes, both in hex as in W3C trace context and OTLP.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// NewTraceContext returns a new random trace
+func NewTraceContext() TraceContext {
+	traceID := make([]byte, 16)
+	spanID := make([]byte, 8)
+	rand.Read(traceID)
+	rand.Read(spanID)
+	return TraceContext{
+		TraceID: hex.EncodeToString(traceID),
+		SpanID:  hex.EncodeToString(spanID),
+	}
+}
+
+// IsValid returns true if the trace was set
+func (trace TraceContext) IsValid() bool {
+	return trace.TraceID != "" && trace.SpanID != ""
+}
+
+func (trace TraceContext) String() string {
+	if !trace.IsValid() {
+		return ""
+	}
+	return trace.TraceID + "-" + trace.SpanID
+}
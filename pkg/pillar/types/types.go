@@ -36,6 +36,8 @@ const (
 	CREATED_VOLUME  // Volume create done or failed
 	INSTALLED       // Available to be activated
 	AWAITNETWORKINSTANCE
+	AWAITDEPENDENCY // Waiting for the app instances it depends on
+	AWAITRESOURCES  // Waiting for memory, CPUs, disk space or adapters
 	BOOTING
 	RUNNING
 	PAUSING
@@ -78,6 +80,10 @@ func (state SwState) String() string {
 		return "INSTALLED"
 	case AWAITNETWORKINSTANCE:
 		return "AWAITNETWORKINSTANCE"
+	case AWAITDEPENDENCY:
+		return "AWAITDEPENDENCY"
+	case AWAITRESOURCES:
+		return "AWAITRESOURCES"
 	case BOOTING:
 		return "BOOTING"
 	case RUNNING:
@@ -135,6 +141,10 @@ func (state SwState) ZSwState() info.ZSwState {
 		return info.ZSwState_INSTALLED
 	case AWAITNETWORKINSTANCE:
 		return info.ZSwState_AWAITNETWORKINSTANCE
+	case AWAITDEPENDENCY:
+		return info.ZSwState_AWAITDEPENDENCY
+	case AWAITRESOURCES:
+		return info.ZSwState_AWAITRESOURCES
 	case BOOTING:
 		return info.ZSwState_BOOTING
 	case RUNNING:
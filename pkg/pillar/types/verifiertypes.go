@@ -25,6 +25,7 @@ type VerifyImageConfig struct {
 	Size         int64  //FileLocation size
 	RefCount     uint
 	Expired      bool // Used in delete handshake
+	Trace        TraceContext
 }
 
 // Key returns the pubsub Key
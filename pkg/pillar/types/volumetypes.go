@@ -25,6 +25,7 @@ type VolumeConfig struct {
 	GenerationCounter       int64
 	VolumeDir               string
 	DisplayName             string
+	Trace                   TraceContext
 }
 
 // Key is volume UUID which will be unique
@@ -115,6 +116,9 @@ type VolumeStatus struct {
 	LastUse                 time.Time
 	PreReboot               bool // Was volume last use prior to device reboot?
 	ReferenceName           string
+	AwaitingDiskSpace       bool // Not created yet due to lack of disk space
+	AwaitingHealthyDisk     bool // Not created yet since a /persist disk is failing
+	Trace                   TraceContext
 
 	ErrorAndTimeWithSource
 }
@@ -293,6 +297,7 @@ type VolumeRefStatus struct {
 	MaxVolSize         uint64
 	MountDir           string
 	PendingAdd         bool // Flag to identify whether volume ref config published or not
+	AwaitingDiskSpace  bool // Copied from VolumeStatus
 
 	ErrorAndTimeWithSource
 }
@@ -62,12 +62,34 @@ type AppInstanceConfig struct {
 	PurgeCmd            AppInstanceOpsCmd
 	// XXX: to be deprecated, use CipherBlockStatus instead
 	CloudInitUserData *string // base64-encoded
-	RemoteConsole     bool
+	// Vendor-data for cloud-init, base64-encoded
+	CloudInitVendorData string
+	// Hostname from DisplayName and network-config for cloud-init
+	CloudInitNoCloud bool
+	RemoteConsole    bool
 	// Collect Stats IP Address, assume port is the default docker API for http: 2375
 	CollectStatsIPAddr net.IP
 
 	// CipherBlockStatus, for encrypted cloud-init data
 	CipherBlockStatus
+
+	Probes        []AppProbe
+	RestartPolicy AppRestartPolicy
+
+	// The app instances which need to run before this one is booted.
+	// They are halted after this one.
+	DependsOn []uuid.UUID
+
+	Priority AppPriority
+
+	// USB devices passed through when they are plugged in
+	UsbDevices []UsbDeviceRule
+
+	// Sidecars and init containers run with the main container
+	Containers []ContainerConfig
+
+	// Trace of the deployment, new for each new version, restart or purge
+	Trace TraceContext
 }
 
 type AppInstanceOpsCmd struct {
@@ -75,6 +97,173 @@ type AppInstanceOpsCmd struct {
 	ApplyTime string // XXX not currently used
 }
 
+// AppProbeKind : what a failing probe means for the app instance
+type AppProbeKind uint8
+
+const (
+	// AppProbeKindNone : the probe is not used
+	AppProbeKindNone AppProbeKind = iota
+	// AppProbeLiveness : the app instance is restarted when the probe
+	// fails, depending on the restart policy
+	AppProbeLiveness
+	// AppProbeReadiness : the app instance is reported as not ready when
+	// the probe fails, which holds back the app instances depending on it
+	AppProbeReadiness
+)
+
+// AppProbeType : how the app instance is probed
+type AppProbeType uint8
+
+const (
+	// AppProbeNone : no probe
+	AppProbeNone AppProbeType = iota
+	// AppProbeHTTP : HTTP GET of HTTPPath which must return a 2xx or 3xx
+	AppProbeHTTP
+	// AppProbeTCP : TCP connect to Port
+	AppProbeTCP
+	// AppProbeExec : run Command in the container, which must exit zero
+	AppProbeExec
+	// AppProbeGuestAgent : ping the QEMU guest agent of the VM
+	AppProbeGuestAgent
+)
+
+// String returns the probe type in lower case
+func (t AppProbeType) String() string {
+	switch t {
+	case AppProbeHTTP:
+		return "http"
+	case AppProbeTCP:
+		return "tcp"
+	case AppProbeExec:
+		return "exec"
+	case AppProbeGuestAgent:
+		return "guest-agent"
+	default:
+		return fmt.Sprintf("unknown %d", t)
+	}
+}
+
+// AppProbe : a health probe of an app instance.
+// The zero values are replaced by defaults in zedagent.
+type AppProbe struct {
+	Kind             AppProbeKind
+	Type             AppProbeType
+	NetworkAdapter   string // Name from UnderlayNetworkConfig
+	Port             uint16
+	HTTPPath         string
+	Command          []string
+	InitialDelay     uint32 // Seconds
+	Period           uint32 // Seconds
+	Timeout          uint32 // Seconds
+	FailureThreshold uint32
+	SuccessThreshold uint32
+	// IPAddr of NetworkAdapter for HTTP and TCP probes, filled in by
+	// zedmanager in the DomainConfig once zedrouter knows it
+	IPAddr net.IP
+}
+
+// AppRestartPolicy : what domainmgr does when an app instance fails a
+// liveness probe or its domain stops
+type AppRestartPolicy uint8
+
+const (
+	// RestartPolicyNever : the app instance is never restarted; the default
+	RestartPolicyNever AppRestartPolicy = iota
+	// RestartPolicyAlways : restarted when its domain stops or fails, or a
+	// liveness probe fails
+	RestartPolicyAlways
+	// RestartPolicyOnFailure : restarted when its domain fails or a
+	// liveness probe fails, with an exponential backoff
+	RestartPolicyOnFailure
+)
+
+// String returns the restart policy in lower case
+func (p AppRestartPolicy) String() string {
+	switch p {
+	case RestartPolicyNever:
+		return "never"
+	case RestartPolicyAlways:
+		return "always"
+	case RestartPolicyOnFailure:
+		return "on-failure"
+	default:
+		return fmt.Sprintf("unknown %d", p)
+	}
+}
+
+// AppPriority : what zedmanager does when there are not enough resources
+// to boot an app instance. Same values as config.AppPriorityClass
+type AppPriority uint8
+
+const (
+	// AppPriorityNormal app instances wait for resources
+	AppPriorityNormal AppPriority = iota
+	// AppPriorityBestEffort app instances can be preempted
+	AppPriorityBestEffort
+	// AppPriorityCritical app instances preempt best-effort ones
+	AppPriorityCritical
+)
+
+// String returns the priority in lower case
+func (p AppPriority) String() string {
+	switch p {
+	case AppPriorityNormal:
+		return "normal"
+	case AppPriorityBestEffort:
+		return "best-effort"
+	case AppPriorityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("unknown %d", p)
+	}
+}
+
+// AppHealthState : health of an app instance based on its probes.
+// Same values as info.ZAppHealthState
+type AppHealthState uint8
+
+const (
+	// AppHealthUnknown : the app instance has no probes
+	AppHealthUnknown AppHealthState = iota
+	// AppHealthStarting : the probes did not pass yet since boot
+	AppHealthStarting
+	// AppHealthHealthy : all probes pass
+	AppHealthHealthy
+	// AppHealthNotReady : a readiness probe fails
+	AppHealthNotReady
+	// AppHealthUnhealthy : a liveness probe fails
+	AppHealthUnhealthy
+)
+
+// String returns the health state in lower case
+func (state AppHealthState) String() string {
+	switch state {
+	case AppHealthUnknown:
+		return "unknown"
+	case AppHealthStarting:
+		return "starting"
+	case AppHealthHealthy:
+		return "healthy"
+	case AppHealthNotReady:
+		return "not-ready"
+	case AppHealthUnhealthy:
+		return "unhealthy"
+	default:
+		return fmt.Sprintf("unknown %d", state)
+	}
+}
+
+// AppHealthStatus : reported by domainmgr in DomainStatus and copied to
+// AppInstanceStatus by zedmanager
+type AppHealthStatus struct {
+	State        AppHealthState
+	LastChange   time.Time // When State last changed
+	LastError    string    // From the last failed probe
+	RestartCount uint32    // Restarts done by the restart policy
+	LastRestart  time.Time
+	NextRestart  time.Time // Set while waiting for the backoff
+}
+
 // IoAdapter specifies that a group of ports should be assigned
 type IoAdapter struct {
 	Type IoType
@@ -156,6 +345,15 @@ type AppInstanceStatus struct {
 	// Error* set implies error.
 	State          SwState
 	MissingNetwork bool // If some Network UUID not found
+	Health         AppHealthStatus
+	// What the app instance waits for, e.g. in AWAITRESOURCES
+	PendingReason string
+	// Set while halted to free resources for a critical app instance
+	PreemptedBy string
+	UsbDevices  []UsbDeviceStatus // Copied from DomainStatus
+	GuestInfo   GuestInfo         // Copied from DomainStatus
+	Containers  []ContainerStatus // Copied from DomainStatus
+	Trace       TraceContext      // Copied from AppInstanceConfig
 	// All error strings across all steps and all StorageStatus
 	// ErrorAndTimeWithSource provides SetError, SetErrrorWithSource, etc
 	ErrorAndTimeWithSource
@@ -404,6 +404,7 @@ const (
 	DPC_PCI_WAIT    // DPC_PCI_WAIT means some interface still in pci back
 	DPC_INTF_WAIT   // DPC_INTF_WAIT means some interface missing from kernel
 	DPC_REMOTE_WAIT // DPC_REMOTE_WAIT means controller is down or has old certificate
+	DPC_DOT1X_WAIT  // DPC_DOT1X_WAIT means 802.1X authentication in progress
 )
 
 // String returns the string name
@@ -425,6 +426,8 @@ func (status PendDPCStatus) String() string {
 		return "DPC_INTF_WAIT"
 	case DPC_REMOTE_WAIT:
 		return "DPC_REMOTE_WAIT"
+	case DPC_DOT1X_WAIT:
+		return "DPC_DOT1X_WAIT"
 	default:
 		return fmt.Sprintf("Unknown status %d", status)
 	}
@@ -731,7 +734,8 @@ func (config *DevicePortConfig) MostlyEqual(config2 *DevicePortConfig) bool {
 		}
 		if !reflect.DeepEqual(p1.DhcpConfig, p2.DhcpConfig) ||
 			!reflect.DeepEqual(p1.ProxyConfig, p2.ProxyConfig) ||
-			!reflect.DeepEqual(p1.WirelessCfg, p2.WirelessCfg) {
+			!reflect.DeepEqual(p1.WirelessCfg, p2.WirelessCfg) ||
+			!reflect.DeepEqual(p1.Dot1xCfg, p2.Dot1xCfg) {
 			return false
 		}
 	}
@@ -859,6 +863,8 @@ type DhcpConfig struct {
 	DomainName string
 	NtpServer  net.IP
 	DnsServers []net.IP // If not set we use Gateway as DNS server
+	// PrefixDelegation requests an IPv6 address and prefix over DHCPv6
+	PrefixDelegation bool
 }
 
 // WifiConfig - Wifi structure
@@ -889,6 +895,43 @@ type WirelessConfig struct {
 	Wifi     []WifiConfig // Wifi Config params
 }
 
+// Dot1xEapMethod - EAP method used for 802.1X
+type Dot1xEapMethod uint8
+
+// Dot1xEapMethodNone and friends; matches zconfig.Dot1XEapMethod
+const (
+	Dot1xEapMethodNone Dot1xEapMethod = iota
+	Dot1xEapMethodTLS
+	Dot1xEapMethodPEAP
+)
+
+// String returns the name used in the wpa_supplicant configuration
+func (method Dot1xEapMethod) String() string {
+	switch method {
+	case Dot1xEapMethodTLS:
+		return "TLS"
+	case Dot1xEapMethodPEAP:
+		return "PEAP"
+	default:
+		return fmt.Sprintf("Unknown EAP method %d", method)
+	}
+}
+
+// Dot1xConfig - 802.1X port authentication for wired ports
+// The password or private key are in the CipherBlockStatus
+type Dot1xConfig struct {
+	Enable             bool
+	EapMethod          Dot1xEapMethod
+	Identity           string
+	AnonymousIdentity  string // Outer identity for PEAP
+	CACertPEM          []byte // To verify the authentication server
+	ServerDomainSuffix string
+	ClientCertPEM      []byte // For EAP-TLS
+
+	// CipherBlockStatus, for encrypted credentials
+	CipherBlockStatus
+}
+
 // NetworkPortConfig has the configuration and some status like TestResults
 // for one IfName.
 // XXX odd to have ParseErrors and/or TestResults here but we don't have
@@ -906,6 +949,7 @@ type NetworkPortConfig struct {
 	DhcpConfig
 	ProxyConfig
 	WirelessCfg WirelessConfig
+	Dot1xCfg    Dot1xConfig
 	// TestResults - Errors from parsing plus success/failure from testing
 	TestResults
 }
@@ -927,6 +971,8 @@ type NetworkPortStatus struct {
 	Up             bool
 	MacAddr        string
 	DefaultRouters []net.IP
+	// DelegatedPrefixes are the IPv6 prefixes delegated to us over DHCPv6
+	DelegatedPrefixes []net.IPNet
 	ProxyConfig
 	// TestResults provides recording of failure and success
 	TestResults
@@ -1120,6 +1166,14 @@ func (status DeviceNetworkStatus) MostlyEqual(status2 DeviceNetworkStatus) bool
 				return false
 			}
 		}
+		if len(p1.DelegatedPrefixes) != len(p2.DelegatedPrefixes) {
+			return false
+		}
+		for i := range p1.DelegatedPrefixes {
+			if !EqualSubnet(p1.DelegatedPrefixes[i], p2.DelegatedPrefixes[i]) {
+				return false
+			}
+		}
 
 		if !reflect.DeepEqual(p1.ProxyConfig, p2.ProxyConfig) {
 			return false
@@ -1303,6 +1357,22 @@ func GetDNSServers(globalStatus DeviceNetworkStatus, ifname string) []net.IP {
 	return servers
 }
 
+// GetDelegatedPrefix returns the first IPv6 prefix delegated to ifname,
+// or nil if there is none
+func GetDelegatedPrefix(globalStatus DeviceNetworkStatus, ifname string) *net.IPNet {
+
+	for _, us := range globalStatus.Ports {
+		if ifname != us.IfName {
+			continue
+		}
+		for _, prefix := range us.DelegatedPrefixes {
+			p := prefix
+			return &p
+		}
+	}
+	return nil
+}
+
 // GetNTPServers returns all, or the ones on one interface if ifname is set
 func GetNTPServers(globalStatus DeviceNetworkStatus, ifname string) []net.IP {
 
@@ -1781,6 +1851,18 @@ type UnderlayNetworkStatus struct {
 	IPAddrMisMatch  bool
 	HostName        string
 	ACLDependList   []ACLDepend
+	// What DHCP hands out to the app; only set on local network instances
+	GuestIP GuestIPConfig
+}
+
+// GuestIPConfig is the IPv4 configuration of a network adapter of an app,
+// which can be given to the guest statically instead of using DHCP
+type GuestIPConfig struct {
+	IPAddr     net.IP // nil if the guest has to use DHCP
+	Subnet     net.IPNet
+	Gateway    net.IP // nil if the network instance does not route
+	DNSServers []net.IP
+	DomainName string
 }
 
 // ACLDepend is used to track an external interface/port and optional IP addresses
@@ -1822,6 +1904,9 @@ type NetworkXObjectConfig struct {
 	DnsNameToIPList []DnsNameToIP // Used for DNS and ACL ipset
 	Proxy           *ProxyConfig
 	WirelessCfg     WirelessConfig
+	Dot1xCfg        Dot1xConfig
+	// PrefixDelegation requests an IPv6 prefix over DHCPv6 on the ports
+	PrefixDelegation bool
 	// Any errrors from the parser
 	// ErrorAndTime provides SetErrorNow() and ClearError()
 	ErrorAndTime
@@ -1893,6 +1978,9 @@ type NetworkInstanceInfo struct {
 	// Set of vifs on this bridge
 	Vifs []VifNameMac
 
+	// Not created until DHCPv6 delegates the prefix of its subnet
+	AwaitingDelegatedPrefix bool
+
 	// Any errrors from provisioning the network
 	// ErrorAndTime provides SetErrorNow() and ClearError()
 	ErrorAndTime
@@ -2165,6 +2253,144 @@ type NetworkInstanceConfig struct {
 
 	// For other network services - Proxy / StrongSwan etc..
 	OpaqueConfig string
+
+	// Export of the flow records over IPFIX
+	IPFIXExporter IPFIXExporterConfig
+
+	// IPv6 addressing and uplink mode for local network instances
+	IPv6 IPv6Config
+
+	// Load balancers referenced by port map ACEs of the app instances
+	LoadBalancers []LoadBalancerConfig
+}
+
+// IPv6AddressingMode : how apps on an IPv6 local network instance get
+// their addresses
+type IPv6AddressingMode uint8
+
+const (
+	// IPv6AddressingDHCPv6 : stateful DHCPv6 from the DhcpRange
+	IPv6AddressingDHCPv6 IPv6AddressingMode = iota
+	// IPv6AddressingSLAAC : SLAAC with EUI-64 interface identifiers
+	IPv6AddressingSLAAC
+)
+
+// IPv6UplinkMode : how an IPv6 local network instance reaches its port
+type IPv6UplinkMode uint8
+
+const (
+	// IPv6UplinkNAT66 : masquerade behind the address of the port
+	IPv6UplinkNAT66 IPv6UplinkMode = iota
+	// IPv6UplinkPrefixDelegation : route a /64 from the prefix delegated
+	// to the port
+	IPv6UplinkPrefixDelegation
+)
+
+// IPv6Config : IPv6 specific configuration of a local network instance
+type IPv6Config struct {
+	Addressing IPv6AddressingMode
+	Uplink     IPv6UplinkMode
+}
+
+// IPFIXExporterConfig : IPFIX export of the flow records of a network
+// instance to a collector on a local network
+type IPFIXExporterConfig struct {
+	Collector           net.IP // Not set if IPFIX export is disabled
+	Port                uint16
+	ObservationDomainID uint32
+	// EnterpriseNumber for the EVE specific information elements;
+	// if zero only the IANA defined elements are exported
+	EnterpriseNumber uint32
+}
+
+// IsEnabled : returns true if a collector is configured
+func (config IPFIXExporterConfig) IsEnabled() bool {
+	return config.Collector != nil
+}
+
+// Equal : compares two IPFIX exporter configurations
+func (config IPFIXExporterConfig) Equal(config2 IPFIXExporterConfig) bool {
+	return config.Collector.Equal(config2.Collector) &&
+		config.Port == config2.Port &&
+		config.ObservationDomainID == config2.ObservationDomainID &&
+		config.EnterpriseNumber == config2.EnterpriseNumber
+}
+
+// LoadBalancerAlgorithm : how a load balancer picks the backend
+type LoadBalancerAlgorithm uint8
+
+const (
+	// LBAlgorithmRoundRobin : new connections go to the backends in turn
+	LBAlgorithmRoundRobin LoadBalancerAlgorithm = iota
+	// LBAlgorithmSourceHash : new connections go to a backend based on
+	// the source address
+	LBAlgorithmSourceHash
+)
+
+// LBHealthCheckType : how the backends of a load balancer are checked
+type LBHealthCheckType uint8
+
+const (
+	// LBHealthCheckNone : backends are always healthy
+	LBHealthCheckNone LBHealthCheckType = iota
+	// LBHealthCheckTCP : TCP connect to the backend
+	LBHealthCheckTCP
+	// LBHealthCheckHTTP : HTTP GET from the backend
+	LBHealthCheckHTTP
+)
+
+// LBHealthCheck : health checking of the backends of a load balancer.
+// The zero values are replaced by defaults in zedagent.
+type LBHealthCheck struct {
+	Type               LBHealthCheckType
+	Interval           uint32 // Seconds
+	Timeout            uint32 // Seconds
+	HealthyThreshold   uint32
+	UnhealthyThreshold uint32
+	HTTPPath           string
+}
+
+// LoadBalancerConfig : a load balancer on a local network instance.
+// The backends are the app instances with port map ACEs referring to
+// the load balancer by name.
+type LoadBalancerConfig struct {
+	Name        string
+	Algorithm   LoadBalancerAlgorithm
+	HealthCheck LBHealthCheck
+}
+
+// LoadBalancerStatus : the backends of a load balancer and their health
+type LoadBalancerStatus struct {
+	Name     string
+	Protocol string // From the port map ACEs
+	Port     string // The lport of the port map ACEs
+	Backends []LBBackendStatus
+	// Error if the port map ACEs do not agree on protocol and lport
+	Error string
+}
+
+// LBBackendStatus : one app instance behind a load balancer
+type LBBackendStatus struct {
+	AppUUID    uuid.UUID
+	VifName    string
+	IPAddr     net.IP
+	Port       int // Target port in the app instance
+	Healthy    bool
+	LastChange time.Time // When Healthy last changed
+	LastError  string
+}
+
+// LoadBalancerEqual : compares two lists of load balancer configurations
+func LoadBalancerEqual(lbs1, lbs2 []LoadBalancerConfig) bool {
+	if len(lbs1) != len(lbs2) {
+		return false
+	}
+	for i := range lbs1 {
+		if lbs1[i] != lbs2[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func (config *NetworkInstanceConfig) Key() string {
@@ -2219,6 +2445,13 @@ func (config *NetworkInstanceConfig) IsIPv6() bool {
 	return false
 }
 
+// IsLocalIPv6 : returns true for a local network instance with IPv6
+// addressing, which gets DHCPv6/SLAAC and router advertisements from dnsmasq
+func (config *NetworkInstanceConfig) IsLocalIPv6() bool {
+	return config.Type == NetworkInstanceTypeLocal &&
+		config.IpType == AddressTypeIPV6
+}
+
 type ChangeInProgressType int32
 
 const (
@@ -2245,6 +2478,9 @@ type NetworkInstanceStatus struct {
 	OpaqueStatus string
 	VpnStatus    *VpnStatus
 
+	// Health of the backends of the load balancers
+	LoadBalancerStatus []LoadBalancerStatus
+
 	NetworkInstanceProbeStatus
 }
 
@@ -2457,6 +2693,9 @@ type ACEAction struct {
 
 	PortMap    bool // Is port mapping part of action?
 	TargetPort int  // Internal port
+
+	// LoadBalancer in the network instance the port map is part of
+	LoadBalancer string
 }
 
 // Retrieved from geolocation service for device underlay connectivity
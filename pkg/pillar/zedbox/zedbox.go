@@ -21,6 +21,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/cmd/command"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/conntrack"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/diag"
+	"github.com/lf-edge/eve/pkg/pillar/cmd/diagbundle"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/domainmgr"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/downloader"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/executor"
@@ -28,6 +29,7 @@ import (
 	"github.com/lf-edge/eve/pkg/pillar/cmd/hardwaremodel"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/ipcmonitor"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/ledmanager"
+	"github.com/lf-edge/eve/pkg/pillar/cmd/logquery"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/loguploader"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/nim"
 	"github.com/lf-edge/eve/pkg/pillar/cmd/nodeagent"
@@ -96,6 +98,8 @@ var (
 		"tpmmgr":           {f: tpmmgr.Run, inline: inlineUnlessService},
 		"vaultmgr":         {f: vaultmgr.Run, inline: inlineUnlessService},
 		"upgradeconverter": {f: upgradeconverter.Run, inline: inlineAlways},
+		"logquery":         {f: logquery.Run, inline: inlineAlways},
+		"diagbundle":       {f: diagbundle.Run, inline: inlineAlways},
 	}
 	logger *logrus.Logger
 	log    *base.LogObject
@@ -99,6 +99,14 @@ func verifyAuthentication(ctx *ZedCloudContext, c []byte, skipVerify bool) ([]by
 	return data, senderSt, nil
 }
 
+// VerifyAuthContainer verifies an AuthContainer signed by the controller
+// which did not come in a response e.g., from a local file, and returns
+// its payload
+func VerifyAuthContainer(ctx *ZedCloudContext, c []byte) ([]byte, error) {
+	data, _, err := verifyAuthentication(ctx, c, false)
+	return data, err
+}
+
 func getServerSigingCert(ctx *ZedCloudContext) error {
 	certBytes, err := ioutil.ReadFile(types.ServerSigningCertFileName)
 	if err != nil {